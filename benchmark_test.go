@@ -46,7 +46,7 @@ func (s *benchServer) Watch(in *pb.WatchRequest, stream pb.KvStore_WatchServer)
 	defer s.store.Unwatch(w)
 
 	for event := range w.Events {
-		if err := stream.Send(&pb.WatchResponse{Message: event}); err != nil {
+		if err := stream.Send(&pb.WatchResponse{Message: event.Message}); err != nil {
 			return err
 		}
 	}
@@ -317,6 +317,7 @@ func BenchmarkManySmallValues(b *testing.B) {
 
 	client := createBenchmarkClient(b, addr)
 
+	b.ReportAllocs()
 	b.ResetTimer()
 
 	for i := 0; i < b.N; i++ {
@@ -331,6 +332,29 @@ func BenchmarkManySmallValues(b *testing.B) {
 	}
 }
 
+// BenchmarkManySmallValues_Bytes é o BenchmarkManySmallValues com o valor em
+// value_bytes, que não passa pela validação de UTF-8 do proto
+func BenchmarkManySmallValues_Bytes(b *testing.B) {
+	srv, addr := setupBenchmarkServer(b)
+	defer cleanupBenchmarkServer(b, srv)
+
+	client := createBenchmarkClient(b, addr)
+	value := []byte("v")
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		key := fmt.Sprintf("small_key_%d", i)
+
+		req := &pb.PutRequest{Key: key, ValueBytes: value}
+		_, err := client.Put(context.Background(), req)
+		if err != nil {
+			b.Fatalf("Put() failed: %v", err)
+		}
+	}
+}
+
 func BenchmarkMixedOperations(b *testing.B) {
 	srv, addr := setupBenchmarkServer(b)
 	defer cleanupBenchmarkServer(b, srv)
@@ -385,6 +409,28 @@ func BenchmarkStorePut(b *testing.B) {
 	}
 }
 
+// BenchmarkStorePut_Batched é o BenchmarkStorePut com as escritas agrupadas:
+// um commit (e um fsync) por lote em vez de um por Put
+func BenchmarkStorePut_Batched(b *testing.B) {
+	db := setupTestDB(b)
+	defer cleanupTestDB(b, db)
+
+	kv := store.NewKVStore(store.WithBackend(store.NewBatchBackend(store.NewBoltBackend(db), 5*time.Millisecond, store.DefaultBatchSize)))
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		key := fmt.Sprintf("store_key_%d", i)
+		value := fmt.Sprintf("store_value_%d", i)
+		kv.Put(key, value)
+	}
+
+	// O último lote faz parte do custo
+	if err := kv.Flush(); err != nil {
+		b.Fatalf("Flush() failed: %v", err)
+	}
+}
+
 func BenchmarkStoreGet(b *testing.B) {
 	db := setupTestDB(b)
 	defer cleanupTestDB(b, db)
@@ -1,118 +1,1404 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
-	"log"
+	"math"
+	"math/rand/v2"
+	"os"
+	"os/signal"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
 	"time"
 
+	"github.com/carvalhodanielg/kvstore/internal/version"
 	pb "github.com/carvalhodanielg/kvstore/pb/proto"
+	"github.com/carvalhodanielg/kvstore/store"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
 )
 
 const (
 	defaultKey  = "pedra"
 	defaultFlag = "get"
+	// backoff entre as tentativas de reabrir um watch que caiu
+	watchRetryMin = 100 * time.Millisecond
+	watchRetryMax = 5 * time.Second
+	// retryMaxBackoff limita a espera entre as tentativas de uma chamada
+	retryMaxBackoff = 2 * time.Second
+	// transferTimeout limita export e import, que percorrem a store inteira
+	transferTimeout = 30 * time.Minute
+
+	formatHuman = "human"
+	formatJSON  = "json"
+
+	// limites padrão do gRPC para o tamanho das mensagens
+	defaultMaxRecvMsgSize = 4 << 20
+	defaultMaxSendMsgSize = math.MaxInt32
+)
+
+// Códigos de saída do cliente, um por tipo de falha
+const (
+	exitOK          = 0
+	exitFailure     = 1 // o servidor respondeu com erro
+	exitUsage       = 2 // flags ou ação inválidas
+	exitUnavailable = 3 // servidor inacessível ou timeout
+	exitNotFound    = 4 // a chave não existe (servidor em modo strict)
 )
 
 var (
-	addr         = flag.String("addr", "localhost:50051", "the address to connect to")
-	key          = flag.String("key", defaultKey, "Key recibida")
-	value        = flag.String("value", "dV", "valor recebido")
-	typeOfAction = flag.String("flag", defaultFlag, "Tipo de ação desejada pelo cliente")
+	errUnknownAction = errors.New("unknown action")
+	errMissingKey    = errors.New("missing key")
+	errInvalidFormat = errors.New("invalid format")
+	errMissingFile   = errors.New("missing file")
+	errUnknownOp     = errors.New("unknown WAL operation")
 )
 
+// rpcError descreve qual operação falhou sem repetir o prefixo "rpc error: code = ..."
+type rpcError struct {
+	op  string
+	err error
+}
+
+func (e *rpcError) Error() string {
+	st := status.Convert(e.err)
+	return fmt.Sprintf("%s: %s (%s)", e.op, st.Message(), st.Code())
+}
+
+func (e *rpcError) Unwrap() error {
+	return e.err
+}
+
+// exitCode traduz o erro de uma ação no código de saída do processo
+func exitCode(err error) int {
+	if errors.Is(err, errUnknownAction) || errors.Is(err, errMissingKey) || errors.Is(err, errInvalidFormat) ||
+		errors.Is(err, errMissingFile) || errors.Is(err, errUnknownOp) {
+		return exitUsage
+	}
+
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded:
+		return exitUnavailable
+	case codes.NotFound:
+		return exitNotFound
+	default:
+		return exitFailure
+	}
+}
+
+type options struct {
+	action       string
+	key          string
+	value        string
+	keySet       bool
+	initial      bool
+	timeout      time.Duration
+	format       string
+	linearizable bool
+	replica      bool
+	maxStaleness time.Duration
+	watchFor     time.Duration
+	file         string
+	dryRun       bool
+	// op filtra o walcat por operação (write, delete, clear...)
+	op string
+}
+
+// prefix usa a key como prefixo apenas se ela foi passada explicitamente
+func (o options) prefix() string {
+	if o.keySet {
+		return o.key
+	}
+	return ""
+}
+
+// emit escreve o resultado de uma ação: human no formato legível ou v como
+// uma linha de JSON quando --format=json.
+func (o options) emit(out io.Writer, human string, v any) error {
+	if o.format == formatJSON {
+		return json.NewEncoder(out).Encode(v)
+	}
+
+	_, err := io.WriteString(out, human)
+	return err
+}
+
+type getResult struct {
+	Key      string `json:"key"`
+	Value    string `json:"value"`
+	Revision uint64 `json:"revision,omitempty"`
+	// StalenessMs só aparece com --replica
+	StalenessMs int64 `json:"staleness_ms,omitempty"`
+	// CreatedAt e UpdatedAt em RFC 3339; vazios para chaves sem metadados
+	CreatedAt string `json:"created_at,omitempty"`
+	UpdatedAt string `json:"updated_at,omitempty"`
+}
+
+// formatNanos formata um timestamp em unix nanos do servidor, "" para 0
+func formatNanos(ns int64) string {
+	if ns == 0 {
+		return ""
+	}
+	return time.Unix(0, ns).Format(time.RFC3339Nano)
+}
+
+type putResult struct {
+	Key     string `json:"key"`
+	Success bool   `json:"success"`
+}
+
+type deleteResult struct {
+	Key string `json:"key"`
+}
+
+type countResult struct {
+	Count int64 `json:"count"`
+}
+
+type pingResult struct {
+	Nonce string  `json:"nonce"`
+	RTTMs float64 `json:"rtt_ms"`
+}
+
+// versionResult é o build do servidor; ClientVersion é o deste cliente
+type versionResult struct {
+	Version       string `json:"version"`
+	Commit        string `json:"commit"`
+	GoVersion     string `json:"go_version"`
+	ClientVersion string `json:"client_version"`
+}
+
+type watchEvent struct {
+	// Time é quando o evento chegou; só o watch-prefix preenche
+	Time     string `json:"time,omitempty"`
+	Message  string `json:"message"`
+	Type     string `json:"type"`
+	Key      string `json:"key"`
+	Value    string `json:"value,omitempty"`
+	Revision uint64 `json:"revision,omitempty"`
+}
+
+// newWatchEvent converte o WatchResponse para a saída --format=json, com o
+// tipo como "put", "delete", "clear" ou "drop"
+func newWatchEvent(w *pb.WatchResponse) watchEvent {
+	return watchEvent{
+		Message:  w.GetMessage(),
+		Type:     strings.ToLower(strings.TrimPrefix(w.GetType().String(), "WATCH_EVENT_")),
+		Key:      w.GetKey(),
+		Value:    w.GetValue(),
+		Revision: w.GetRevision(),
+	}
+}
+
+// record é uma linha do arquivo ndjson usado pelo export e pelo import
+type record struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+type transferResult struct {
+	File  string `json:"file"`
+	Count int64  `json:"count"`
+}
+
+// validateResult é o resumo de um import com --dry-run
+type validateResult struct {
+	File       string   `json:"file"`
+	Count      int64    `json:"count"`
+	Bytes      int64    `json:"bytes"`
+	Duplicates int64    `json:"duplicates"`
+	Invalid    int64    `json:"invalid"`
+	Errors     []string `json:"errors,omitempty"`
+}
+
+// failover distribui as chamadas entre os nós do cluster. Uma chamada que
+// falha com Unavailable (nó fora do ar ou que não é o líder) é repetida no
+// próximo endereço; o nó que aceitou a última escrita é tentado primeiro.
+type failover struct {
+	conns []*grpc.ClientConn
+
+	mu     sync.Mutex
+	leader int
+}
+
+// writeMethods são as chamadas que só o líder aceita
+var writeMethods = map[string]bool{
+	pb.KvStore_Put_FullMethodName:          true,
+	pb.KvStore_Delete_FullMethodName:       true,
+	pb.KvStore_Clear_FullMethodName:        true,
+	pb.KvStore_PutIfAbsent_FullMethodName:  true,
+	pb.KvStore_PutIfVersion_FullMethodName: true,
+	pb.KvStore_Txn_FullMethodName:          true,
+	pb.KvStore_DeleteMany_FullMethodName:   true,
+	pb.KvStore_BulkPut_FullMethodName:      true,
+	pb.KvStore_Restore_FullMethodName:      true,
+}
+
+// conditionalMethods são as escritas que dependem do estado atual da chave.
+// Repetir uma que o servidor já aplicou daria um falso conflito (ou aplicaria
+// o Txn duas vezes), então elas só são repetidas com erros de notSent.
+var conditionalMethods = map[string]bool{
+	pb.KvStore_PutIfAbsent_FullMethodName:  true,
+	pb.KvStore_PutIfVersion_FullMethodName: true,
+	pb.KvStore_Txn_FullMethodName:          true,
+}
+
+// refusedErrors são os erros com que um nó recusa a escrita antes de tocar
+// em qualquer coisa
+var refusedErrors = []error{store.ErrNotLeader, store.ErrClosed}
+
+// notSent indica se err garante que a escrita não foi aplicada: a conexão
+// nem chegou a ser aberta ou o nó a recusou por não ser o líder. Um timeout
+// ou uma conexão que caiu no meio da chamada não garantem nada.
+func notSent(err error) bool {
+	if status.Code(err) != codes.Unavailable {
+		return false
+	}
+	msg := status.Convert(err).Message()
+	if strings.Contains(msg, "Error while dialing") {
+		return true
+	}
+	for _, refused := range refusedErrors {
+		if strings.Contains(msg, refused.Error()) {
+			return true
+		}
+	}
+	return false
+}
+
+// dialAll conecta em cada endereço. recv e send limitam o tamanho das
+// mensagens de cada chamada, como o --max-recv-msg-size do servidor.
+func dialAll(addrs []string, recv, send int) (*failover, error) {
+	f := &failover{}
+	for _, addr := range addrs {
+		conn, err := grpc.NewClient(addr,
+			grpc.WithTransportCredentials(insecure.NewCredentials()),
+			grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(recv), grpc.MaxCallSendMsgSize(send)),
+		)
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("invalid address %q: %w", addr, err)
+		}
+		f.conns = append(f.conns, conn)
+	}
+	return f, nil
+}
+
+func (f *failover) Close() {
+	for _, conn := range f.conns {
+		conn.Close()
+	}
+}
+
+func (f *failover) start() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.leader
+}
+
+func (f *failover) setLeader(i int) {
+	f.mu.Lock()
+	f.leader = i
+	f.mu.Unlock()
+}
+
+// retryable indica se vale tentar outro nó depois de err em method
+func retryable(ctx context.Context, method string, err error) bool {
+	if conditionalMethods[method] && !notSent(err) {
+		return false
+	}
+	return status.Code(err) == codes.Unavailable && ctx.Err() == nil
+}
+
+// retrier repete as chamadas unárias que falham com um erro transitório,
+// esperando entre as tentativas um backoff exponencial com jitter, para
+// vários clientes não voltarem juntos. Fica por fora do failover, então cada
+// tentativa já percorre todos os nós. Erros como InvalidArgument e NotFound
+// voltam na hora, e os streams passam direto.
+type retrier struct {
+	grpc.ClientConnInterface
+	attempts int
+	backoff  time.Duration
+}
+
+// transient indica se err em method pode passar sozinho. Um DeadlineExceeded
+// do próprio ctx (o --timeout) não é repetido, e uma escrita condicional só é
+// repetida se notSent garantir que ela não foi aplicada.
+func transient(ctx context.Context, method string, err error) bool {
+	if conditionalMethods[method] && !notSent(err) {
+		return false
+	}
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded:
+		return ctx.Err() == nil
+	}
+	return false
+}
+
+func (r *retrier) Invoke(ctx context.Context, method string, args, reply any, opts ...grpc.CallOption) error {
+	var err error
+	for attempt := range r.attempts {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return err
+			case <-time.After(r.delay(attempt)):
+			}
+		}
+
+		err = r.ClientConnInterface.Invoke(ctx, method, args, reply, opts...)
+		if err == nil || !transient(ctx, method, err) {
+			return err
+		}
+	}
+	return err
+}
+
+// delay sorteia a espera antes da tentativa n (a partir de 1) entre zero e
+// backoff*2^(n-1), limitado a retryMaxBackoff
+func (r *retrier) delay(n int) time.Duration {
+	d := r.backoff
+	for i := 1; i < n && d < retryMaxBackoff; i++ {
+		d *= 2
+	}
+	return rand.N(min(d, retryMaxBackoff) + 1)
+}
+
+func (f *failover) Invoke(ctx context.Context, method string, args, reply any, opts ...grpc.CallOption) error {
+	start := f.start()
+
+	var err error
+	for n := range f.conns {
+		i := (start + n) % len(f.conns)
+
+		err = f.conns[i].Invoke(ctx, method, args, reply, opts...)
+		if err == nil {
+			if writeMethods[method] {
+				f.setLeader(i)
+			}
+			return nil
+		}
+		if !retryable(ctx, method, err) {
+			return err
+		}
+	}
+	return err
+}
+
+func (f *failover) NewStream(ctx context.Context, desc *grpc.StreamDesc, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	start := f.start()
+
+	var err error
+	for n := range f.conns {
+		i := (start + n) % len(f.conns)
+
+		var stream grpc.ClientStream
+		stream, err = f.conns[i].NewStream(ctx, desc, method, opts...)
+		if err == nil {
+			if writeMethods[method] {
+				return &leaderStream{ClientStream: stream, f: f, i: i}, nil
+			}
+			return stream, nil
+		}
+		if !retryable(ctx, method, err) {
+			return nil, err
+		}
+	}
+	return nil, err
+}
+
+// leaderStream guarda o nó como líder quando a resposta de um stream de
+// escrita (BulkPut, Restore) chega sem erro, como o Invoke faz
+type leaderStream struct {
+	grpc.ClientStream
+	f *failover
+	i int
+}
+
+func (s *leaderStream) RecvMsg(m any) error {
+	err := s.ClientStream.RecvMsg(m)
+	if err == nil {
+		s.f.setLeader(s.i)
+	}
+	return err
+}
+
 func main() {
-	flag.Parse()
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
 
-	conn, err := grpc.NewClient(*addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+// run executa o cliente e devolve o código de saída. Resultados vão para
+// stdout e erros para stderr; stdin só é lido no modo interativo.
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("kvstore-client", flag.ContinueOnError)
+	fs.SetOutput(stderr)
 
-	if err != nil {
-		log.Fatalf("did not connect: %v", err)
+	var o options
+	addr := fs.String("addr", "localhost:50051", "Endereço do servidor; vários separados por vírgula fazem failover entre os nós")
+	fs.StringVar(&o.key, "key", defaultKey, "Key recebida")
+	fs.StringVar(&o.value, "value", "dV", "valor recebido")
+	fs.StringVar(&o.action, "flag", defaultFlag, "Tipo de ação desejada pelo cliente")
+	fs.BoolVar(&o.initial, "initial", false, "No watch, recebe o valor atual da key como primeiro evento")
+	fs.DurationVar(&o.watchFor, "watch-duration", 0, "Encerra o watch depois desse tempo (0 observa até Ctrl+C)")
+	fs.DurationVar(&o.timeout, "timeout", time.Second, "Tempo máximo de cada requisição (exceto watch, export e import)")
+	fs.BoolVar(&o.linearizable, "linearizable", false, "No get, lê do líder confirmando via raft em vez da memória local")
+	fs.BoolVar(&o.replica, "replica", false, "No get, aceita ler de um follower (o servidor precisa de --replica-read)")
+	fs.DurationVar(&o.maxStaleness, "max-staleness", 0, "Com --replica, recusa followers mais atrasados que isso (0 aceita qualquer atraso)")
+	fs.StringVar(&o.format, "format", formatHuman, "Formato da saída: human ou json")
+	fs.StringVar(&o.file, "file", "", "Arquivo ndjson escrito pelo export e lido pelo import")
+	fs.BoolVar(&o.dryRun, "dry-run", false, "No import, só valida o arquivo e mostra o resumo, sem gravar nada")
+	fs.StringVar(&o.op, "op", "", "No walcat, mostra só as entradas dessa operação (write, delete, clear, dropnamespace, checkpoint)")
+	interactive := fs.Bool("interactive", false, "Lê comandos de stdin até EOF usando uma única conexão")
+	shard := fs.Bool("shard", false, "Com vários endereços, divide as chaves entre os nós por hashing consistente em vez de fazer failover")
+	maxRecv := fs.Int("max-recv-msg-size", defaultMaxRecvMsgSize, "Maior resposta aceita do servidor, em bytes; aumente para valores ou GetAll grandes")
+	maxSend := fs.Int("max-send-msg-size", defaultMaxSendMsgSize, "Maior requisição enviada ao servidor, em bytes")
+	retries := fs.Int("retries", 3, "Tentativas de cada chamada que falha com Unavailable ou DeadlineExceeded, dentro do --timeout (1 não repete); escritas condicionais só são repetidas quando com certeza não foram aplicadas")
+	retryBackoff := fs.Duration("retry-backoff", 100*time.Millisecond, "Espera antes da segunda tentativa; dobra a cada nova tentativa, com jitter")
+
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+
+	if o.format != formatHuman && o.format != formatJSON {
+		fmt.Fprintf(stderr, "kvstore-client: %v %q, expected %s or %s\n", errInvalidFormat, o.format, formatHuman, formatJSON)
+		return exitUsage
+	}
+
+	if o.linearizable && o.replica {
+		fmt.Fprintln(stderr, "kvstore-client: --linearizable and --replica cannot be used together")
+		return exitUsage
+	}
+
+	if *maxRecv <= 0 || *maxSend <= 0 {
+		fmt.Fprintln(stderr, "kvstore-client: --max-recv-msg-size and --max-send-msg-size must be positive")
+		return exitUsage
+	}
+
+	if *retries < 1 || *retryBackoff <= 0 {
+		fmt.Fprintln(stderr, "kvstore-client: --retries and --retry-backoff must be positive")
+		return exitUsage
 	}
 
-	defer conn.Close()
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == "key" {
+			o.keySet = true
+		}
+	})
 
+	var addrs []string
+	for _, a := range strings.Split(*addr, ",") {
+		if a = strings.TrimSpace(a); a != "" {
+			addrs = append(addrs, a)
+		}
+	}
+	if len(addrs) == 0 {
+		fmt.Fprintln(stderr, "kvstore-client: no server address given")
+		return exitUsage
+	}
+
+	cluster, err := dialAll(addrs, *maxRecv, *maxSend)
+	if err != nil {
+		fmt.Fprintf(stderr, "kvstore-client: %v\n", err)
+		return exitUsage
+	}
+	defer cluster.Close()
+
+	var conn grpc.ClientConnInterface = cluster
+	if *shard {
+		conn = newSharded(addrs, cluster.conns)
+	}
+	conn = &retrier{ClientConnInterface: conn, attempts: *retries, backoff: *retryBackoff}
 	c := pb.NewKvStoreClient(conn)
 
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	if *interactive {
+		return repl(c, o, stdin, stdout, stderr)
+	}
+
+	if err := execute(c, o, stdout); err != nil {
+		fmt.Fprintf(stderr, "kvstore-client: %v\n", err)
+		return exitCode(err)
+	}
+
+	return exitOK
+}
+
+// repl executa um comando por linha (put/get/del/all/count/keys/watch/export/import) reaproveitando
+// a mesma conexão. Um comando com erro não interrompe os seguintes; o código de
+// saída é o da última falha.
+func repl(c pb.KvStoreClient, base options, in io.Reader, out, errOut io.Writer) int {
+	code := exitOK
+
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		o, err := parseCommand(line, base)
+		if err == nil {
+			err = execute(c, o, out)
+		}
+		if err != nil {
+			fmt.Fprintf(errOut, "kvstore-client: %v\n", err)
+			code = exitCode(err)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		fmt.Fprintf(errOut, "kvstore-client: reading commands: %v\n", err)
+		return exitFailure
+	}
+
+	return code
+}
 
+// parseCommand lê uma linha no formato "<ação> [key] [value]". O value é o
+// resto da linha, então pode conter espaços.
+func parseCommand(line string, base options) (options, error) {
+	action, rest, _ := strings.Cut(line, " ")
+	key, value, _ := strings.Cut(strings.TrimSpace(rest), " ")
+
+	o := base
+	o.action = action
+	o.key = key
+	o.value = strings.TrimSpace(value)
+	o.keySet = key != ""
+
+	if o.action == "del" {
+		o.action = "delete"
+	}
+
+	switch o.action {
+	case "get", "put", "delete", "watch":
+		if key == "" {
+			return o, fmt.Errorf("%w for %s", errMissingKey, o.action)
+		}
+	case "export", "import", "walcat":
+		// "export <arquivo>": o arquivo vem no lugar da key
+		if key != "" {
+			o.file = key
+			o.keySet = false
+		}
+	}
+
+	return o, nil
+}
+
+// execute roda uma única ação contra o servidor
+func execute(c pb.KvStoreClient, o options, out io.Writer) error {
+	switch o.action {
+	case "watch":
+		return watch(c, o, out)
+	case "watch-prefix":
+		return watchPrefix(c, o, out)
+	case "export":
+		return export(c, o, out)
+	case "import":
+		return importFile(c, o, out)
+	case "walcat":
+		return walcat(o, out)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), o.timeout)
 	defer cancel()
 
-	switch *typeOfAction {
-	case "put":
-		r, err := c.Put(ctx, &pb.PutRequest{Key: *key, Value: *value})
+	switch o.action {
+	case "get":
+		req := &pb.GetRequest{Key: o.key}
+		if o.linearizable {
+			req.Consistency = pb.Consistency_CONSISTENCY_LINEARIZABLE
+		}
+		if o.replica {
+			req.Consistency = pb.Consistency_CONSISTENCY_REPLICA
+			req.MaxStalenessMs = o.maxStaleness.Milliseconds()
+		}
 
+		r, err := c.Get(ctx, req)
 		if err != nil {
-			log.Fatalf("could not greet: %v", err)
+			return &rpcError{fmt.Sprintf("could not get %q", o.key), err}
 		}
 
-		log.Printf("Sucess %v, ", r.GetSuccess())
+		return o.emit(out, fmt.Sprintf("GET-> %s::%s\n", r.GetKey(), r.GetValue()),
+			getResult{
+				Key:         r.GetKey(),
+				Value:       r.GetValue(),
+				Revision:    r.GetRevision(),
+				StalenessMs: r.GetStalenessMs(),
+				CreatedAt:   formatNanos(r.GetCreatedAt()),
+				UpdatedAt:   formatNanos(r.GetUpdatedAt()),
+			})
+	case "put":
+		r, err := c.Put(ctx, &pb.PutRequest{Key: o.key, Value: o.value})
+		if err != nil {
+			return &rpcError{fmt.Sprintf("could not put %q", o.key), err}
+		}
 
+		return o.emit(out, fmt.Sprintf("PUT-> success %v\n", r.GetSuccess()),
+			putResult{Key: o.key, Success: r.GetSuccess()})
 	case "delete":
-		r, err := c.Delete(ctx, &pb.DeleteRequest{Key: *key})
+		r, err := c.Delete(ctx, &pb.DeleteRequest{Key: o.key})
 		if err != nil {
-			log.Fatalf("could not delete: %v", err)
+			return &rpcError{fmt.Sprintf("could not delete %q", o.key), err}
 		}
 
-		log.Printf("DELETE-> key: %s", r.GetKey())
+		return o.emit(out, fmt.Sprintf("DELETE-> key: %s\n", r.GetKey()), deleteResult{Key: r.GetKey()})
 	case "all":
 		r, err := c.GetAll(ctx, &pb.GetAllRequest{})
 		if err != nil {
-			log.Fatalf("could not get all: %v", err)
+			return &rpcError{"could not get all", err}
 		}
 
-		log.Printf("All values-> %v", r.GetValues())
+		values := r.GetValues()
+		if values == nil {
+			values = map[string]string{}
+		}
+
+		return o.emit(out, fmt.Sprintf("All values-> %v\n", values), values)
+	case "count":
+		r, err := c.Count(ctx, &pb.CountRequest{Prefix: o.prefix()})
+		if err != nil {
+			return &rpcError{"could not count", err}
+		}
+
+		return o.emit(out, fmt.Sprintf("COUNT-> %d\n", r.GetCount()), countResult{Count: r.GetCount()})
+	case "keys":
+		r, err := c.Keys(ctx, &pb.KeysRequest{Prefix: o.prefix()})
+		if err != nil {
+			return &rpcError{"could not list keys", err}
+		}
+
+		keys := r.GetKeys()
+		if keys == nil {
+			keys = []string{}
+		}
+
+		var human strings.Builder
+		for _, k := range keys {
+			human.WriteString(k + "\n")
+		}
+
+		return o.emit(out, human.String(), keys)
+	case "ping":
+		nonce := strconv.FormatInt(time.Now().UnixNano(), 36)
+
+		start := time.Now()
+		r, err := c.Ping(ctx, &pb.PingRequest{Nonce: nonce})
+		if err != nil {
+			return &rpcError{"could not ping", err}
+		}
+		rtt := time.Since(start)
+
+		if r.GetNonce() != nonce {
+			return fmt.Errorf("ping: server echoed nonce %q, expected %q", r.GetNonce(), nonce)
+		}
+
+		return o.emit(out, fmt.Sprintf("PONG-> rtt %v\n", rtt),
+			pingResult{Nonce: nonce, RTTMs: float64(rtt.Microseconds()) / 1000})
+	case "version":
+		r, err := c.Version(ctx, &pb.VersionRequest{})
+		if err != nil {
+			return &rpcError{"could not get version", err}
+		}
+
+		return o.emit(out, fmt.Sprintf("VERSION-> server %s (commit %s, %s), client %s\n", r.GetVersion(), r.GetCommit(), r.GetGoVersion(), version.Version),
+			versionResult{Version: r.GetVersion(), Commit: r.GetCommit(), GoVersion: r.GetGoVersion(), ClientVersion: version.Version})
 	case "populate":
+		stream, err := c.BulkPut(ctx)
+		if err != nil {
+			return &rpcError{"could not populate", err}
+		}
+
+		var pairs []*pb.PutRequest
 		for i := range 15 {
-			_, err := c.Put(ctx, &pb.PutRequest{Key: fmt.Sprintf("key-%v", i), Value: fmt.Sprintf("value-%v", i)})
-			if err != nil {
-				log.Fatalf("could not delete: %v", err)
+			letter := string(rune('A' + i - 1))
+			pairs = append(pairs,
+				&pb.PutRequest{Key: fmt.Sprintf("key-%v", i), Value: fmt.Sprintf("value-%v", i)},
+				&pb.PutRequest{Key: fmt.Sprintf("key-%v", letter), Value: fmt.Sprintf("value-%v", letter)})
+		}
+
+		for _, req := range pairs {
+			//um erro no Send aparece com o status real no CloseAndRecv
+			if err := stream.Send(req); err != nil {
+				break
 			}
+		}
+
+		r, err := stream.CloseAndRecv()
+		if err != nil {
+			return &rpcError{"could not populate", err}
+		}
 
-			_, err = c.Put(ctx, &pb.PutRequest{Key: fmt.Sprintf("key-%v", string(rune('A'+i-1))), Value: fmt.Sprintf("value-%v", string(rune('A'+i-1)))})
+		return o.emit(out, fmt.Sprintf("POPULATED-> %d pairs\n", r.GetCount()), countResult{Count: r.GetCount()})
+	default:
+		return fmt.Errorf("%w %q", errUnknownAction, o.action)
+	}
+}
+
+// watch observa a key até Ctrl+C ou até --watch-duration. Se o stream cair
+// (servidor reiniciado, rede instável) ele é reaberto com backoff; ao retomar
+// pede o valor atual e só o mostra se mudou enquanto o stream estava fora.
+func watch(c pb.KvStoreClient, o options, out io.Writer) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if o.watchFor > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, o.watchFor)
+		defer cancel()
+	}
+
+	var (
+		last    string // último valor mostrado, para descartar o snapshot repetido
+		seen    bool
+		resumed bool
+		backoff = watchRetryMin
+	)
+
+	for {
+		err := func() error {
+			stream, err := c.Watch(ctx, &pb.WatchRequest{Key: o.key, SendInitialValue: o.initial || seen})
 			if err != nil {
-				log.Fatalf("could not delete: %v", err)
+				return err
+			}
+
+			first := true
+			for {
+				w, err := stream.Recv()
+				if err != nil {
+					return err
+				}
+				backoff = watchRetryMin
+
+				value, snapshot := watchValue(o.key, w.GetMessage())
+				skip := first && resumed && snapshot && seen && value == last
+				first = false
+				if skip {
+					continue
+				}
+				last, seen = value, true
+
+				if err := o.emit(out, fmt.Sprintf("Result is %v\n", w.GetMessage()), newWatchEvent(w)); err != nil {
+					return err
+				}
 			}
+		}()
 
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != io.EOF && status.Code(err) != codes.Unavailable {
+			if _, ok := status.FromError(err); !ok {
+				return err
+			}
+			return &rpcError{fmt.Sprintf("watch on %q failed", o.key), err}
 		}
-		log.Printf("POPULATED")
-	case "watch":
 
-		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, watchRetryMax)
+		resumed = true
+	}
+}
+
+// watchPrefix mostra, com a hora de chegada, os eventos das chaves que começam
+// com --key (sem --key, de todas) até Ctrl+C ou --watch-duration
+func watchPrefix(c pb.KvStoreClient, o options, out io.Writer) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if o.watchFor > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, o.watchFor)
 		defer cancel()
-		client := pb.NewKvStoreClient(conn)
-		stream, err := client.Watch(ctx, &pb.WatchRequest{Key: *key})
+	}
+
+	var prefix string
+	if o.keySet {
+		prefix = o.key
+	}
+
+	stream, err := c.WatchAll(ctx, &pb.WatchAllRequest{Prefix: prefix})
+	if err != nil {
+		return &rpcError{fmt.Sprintf("watch on prefix %q failed", prefix), err}
+	}
+
+	for {
+		w, err := stream.Recv()
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return &rpcError{fmt.Sprintf("watch on prefix %q failed", prefix), err}
+		}
+
+		now := time.Now().Format(time.RFC3339Nano)
+		event := newWatchEvent(w)
+		event.Time = now
+		if err := o.emit(out, fmt.Sprintf("%s %s\n", now, w.GetMessage()), event); err != nil {
+			return err
+		}
+	}
+}
+
+// watchValue extrai o valor de um evento do watch e informa se ele é o
+// snapshot enviado ao abrir o stream (WithInitialValue) em vez de uma escrita
+func watchValue(key, message string) (value string, snapshot bool) {
+	if v, ok := strings.CutPrefix(message, "Key "+key+" current value "); ok {
+		return v, true
+	}
+	if v, ok := strings.CutPrefix(message, "Key "+key+" updated to "); ok {
+		return v, false
+	}
+	return message, false
+}
+
+// export grava todos os pares em o.file, um objeto JSON por linha, conforme o
+// stream do Backup chega. Se falhar no meio, o arquivo parcial é removido.
+func export(c pb.KvStoreClient, o options, out io.Writer) (err error) {
+	if o.file == "" {
+		return fmt.Errorf("%w for export", errMissingFile)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), transferTimeout)
+	defer cancel()
+
+	stream, err := c.Backup(ctx, &pb.BackupRequest{})
+	if err != nil {
+		return &rpcError{"could not export", err}
+	}
+
+	f, err := os.Create(o.file)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			os.Remove(o.file)
+		}
+	}()
+
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+
+	var count int64
+	for {
+		r, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
 		if err != nil {
-			log.Fatalf("client.watch failed w/nil: %v", err)
+			return &rpcError{"export failed", err}
+		}
+
+		if err := enc.Encode(record{Key: r.GetKey(), Value: r.GetValue()}); err != nil {
+			return fmt.Errorf("writing %s: %w", o.file, err)
 		}
+		count++
+	}
+
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("writing %s: %w", o.file, err)
+	}
+
+	return o.emit(out, fmt.Sprintf("EXPORTED-> %d pairs to %s\n", count, o.file),
+		transferResult{File: o.file, Count: count})
+}
+
+// importFile lê o.file linha a linha e envia cada par pelo stream do Restore,
+// sem carregar o arquivo em memória. As chaves existentes são mantidas. Como o
+// restore não é atômico, um erro no meio deixa os pares anteriores gravados.
+// Com --dry-run o servidor só valida os pares e nada é gravado; o resumo
+// inclui as linhas malformadas e a saída é de erro se houver algum inválido.
+func importFile(c pb.KvStoreClient, o options, out io.Writer) error {
+	if o.file == "" {
+		return fmt.Errorf("%w for import", errMissingFile)
+	}
+
+	f, err := os.Open(o.file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
 
-		for {
-			w, err := stream.Recv()
+	ctx, cancel := context.WithTimeout(context.Background(), transferTimeout)
+	defer cancel()
 
-			if err == io.EOF {
+	stream, err := c.Restore(ctx)
+	if err != nil {
+		return &rpcError{"could not import", err}
+	}
+
+	//no dry-run uma linha malformada é registrada e a leitura continua
+	var malformed []string
+	reader := bufio.NewReader(f)
+	for line := 1; ; line++ {
+		raw, err := reader.ReadBytes('\n')
+		if len(bytes.TrimSpace(raw)) > 0 {
+			var rec record
+			if jerr := json.Unmarshal(raw, &rec); jerr != nil {
+				if !o.dryRun {
+					return fmt.Errorf("reading %s: line %d: %w", o.file, line, jerr)
+				}
+				malformed = append(malformed, fmt.Sprintf("line %d: %v", line, jerr))
+			} else if serr := stream.Send(&pb.RestoreRequest{Key: rec.Key, Value: rec.Value, Mode: pb.RestoreMode_RESTORE_MERGE, DryRun: o.dryRun}); serr != nil {
+				// o motivo real vem do CloseAndRecv
 				break
 			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("reading %s: %w", o.file, err)
+		}
+	}
 
-			if err != nil {
-				log.Fatalf("client.watch failed: %v", err)
+	r, err := stream.CloseAndRecv()
+	if err != nil {
+		return &rpcError{"import failed", err}
+	}
 
-			}
+	if !o.dryRun {
+		return o.emit(out, fmt.Sprintf("IMPORTED-> %d pairs from %s\n", r.GetRestored(), o.file),
+			transferResult{File: o.file, Count: r.GetRestored()})
+	}
+
+	res := validateResult{
+		File:       o.file,
+		Count:      r.GetChecked() + int64(len(malformed)),
+		Bytes:      r.GetBytes(),
+		Duplicates: r.GetDuplicates(),
+		Invalid:    r.GetInvalid() + int64(len(malformed)),
+		Errors:     append(malformed, r.GetErrors()...),
+	}
+
+	human := fmt.Sprintf("VALIDATED-> %d pairs from %s: %d bytes, %d duplicates, %d invalid\n",
+		res.Count, res.File, res.Bytes, res.Duplicates, res.Invalid)
+	for _, e := range res.Errors {
+		human += "  " + e + "\n"
+	}
+	if err := o.emit(out, human, res); err != nil {
+		return err
+	}
+
+	if res.Invalid > 0 {
+		return fmt.Errorf("%s has %d invalid records", o.file, res.Invalid)
+	}
+	return nil
+}
+
+// walcat mostra as entradas do WAL em o.file, em ordem de sequência, sem
+// falar com o servidor. Com --key só as da chave e com --op só as da operação.
+func walcat(o options, out io.Writer) error {
+	if o.file == "" {
+		return fmt.Errorf("%w for walcat", errMissingFile)
+	}
+
+	var op store.Operation
+	if o.op != "" {
+		var ok bool
+		if op, ok = parseOperation(o.op); !ok {
+			return fmt.Errorf("%w %q", errUnknownOp, o.op)
+		}
+	}
 
-			log.Printf("Result is %v", w.GetMessage())
+	entries, err := store.ReadWAL(o.file)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range entries {
+		if o.keySet && e.Key != o.key {
+			continue
+		}
+		if o.op != "" && e.Operation != op {
+			continue
+		}
+		if err := o.emit(out, formatWALEntry(e), e); err != nil {
+			return err
 		}
+	}
+	return nil
+}
 
-	default:
-		r, err := c.Get(ctx, &pb.GetRequest{Key: *key})
+// parseOperation aceita o nome da operação sem diferenciar maiúsculas
+func parseOperation(name string) (store.Operation, bool) {
+	for _, op := range []store.Operation{store.Write, store.Delete, store.DropNamespace, store.Clear, store.Checkpoint} {
+		if strings.EqualFold(op.String(), name) {
+			return op, true
+		}
+	}
+	return 0, false
+}
 
+// formatWALEntry escreve uma entrada por linha: sequência, horário, operação,
+// namespace/chave e, nas escritas, o valor e a revisão
+func formatWALEntry(e store.WalLog) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "#%d", e.SequenceNumber)
+	if ts := formatNanos(e.Timestamp); ts != "" {
+		fmt.Fprintf(&b, " %s", ts)
+	}
+	fmt.Fprintf(&b, " %s", e.Operation)
+
+	key := e.Key
+	if e.Namespace != "" {
+		key = e.Namespace + "/" + key
+	}
+	if key != "" {
+		fmt.Fprintf(&b, " %s", key)
+	}
+	if e.Operation == store.Write {
+		fmt.Fprintf(&b, " = %q", e.Value)
+	}
+	if e.Revision != 0 {
+		fmt.Fprintf(&b, " (rev %d)", e.Revision)
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+// shardReplicas é quantos pontos cada nó tem no anel; mais pontos espalham
+// melhor as chaves entre poucos nós
+const shardReplicas = 128
+
+// sharded divide as chaves entre nós independentes, sem raft, com hashing
+// consistente: cada nó ocupa shardReplicas pontos de um anel e a chave vai
+// para o primeiro ponto a partir do hash dela. Acrescentar ou tirar um nó só
+// move as chaves dos pontos dele.
+//
+// As chamadas com chave (get, put, del...) vão para o nó da chave. GetAll,
+// Count, Keys, Clear e MultiScan são feitos em todos os nós, e MultiGet e
+// DeleteMany em cada nó só com as chaves dele, juntando as respostas. Uma Txn
+// vai para o nó das chaves dela, se forem todas do mesmo nó. As demais chamadas
+// sem chave (status, version...) vão para o primeiro nó. Streams (watch,
+// export, import) não são suportados.
+type sharded struct {
+	conns []*grpc.ClientConn
+	ring  []shardPoint
+}
+
+type shardPoint struct {
+	hash uint64
+	node int
+}
+
+// keyedRequest é implementado pelas mensagens que têm uma chave
+type keyedRequest interface {
+	GetKey() string
+}
+
+// newSharded monta o anel a partir dos endereços, na mesma ordem das
+// conexões. Os pontos dependem só do endereço, então clientes com a mesma
+// lista de nós escolhem sempre o mesmo nó para cada chave.
+func newSharded(addrs []string, conns []*grpc.ClientConn) *sharded {
+	s := &sharded{conns: conns}
+	for i, addr := range addrs {
+		for r := range shardReplicas {
+			s.ring = append(s.ring, shardPoint{hash: shardHash(fmt.Sprintf("%s#%d", addr, r)), node: i})
+		}
+	}
+	slices.SortFunc(s.ring, func(a, b shardPoint) int {
+		if a.hash < b.hash {
+			return -1
+		}
+		if a.hash > b.hash {
+			return 1
+		}
+		return a.node - b.node
+	})
+	return s
+}
+
+// shardHash usa os primeiros 8 bytes do SHA-256: o FNV sozinho espalha mal
+// entradas parecidas como "addr#1" e "addr#2", e o anel fica desbalanceado
+func shardHash(s string) uint64 {
+	sum := sha256.Sum256([]byte(s))
+	return binary.BigEndian.Uint64(sum[:8])
+}
+
+// node retorna o índice do nó responsável pela chave
+func (s *sharded) node(key string) int {
+	h := shardHash(key)
+	i, _ := slices.BinarySearchFunc(s.ring, h, func(p shardPoint, h uint64) int {
+		if p.hash < h {
+			return -1
+		}
+		if p.hash > h {
+			return 1
+		}
+		return 0
+	})
+	if i == len(s.ring) {
+		i = 0
+	}
+	return s.ring[i].node
+}
+
+func (s *sharded) Invoke(ctx context.Context, method string, args, reply any, opts ...grpc.CallOption) error {
+	switch method {
+	case pb.KvStore_GetAll_FullMethodName:
+		return s.getAll(ctx, args, reply.(*pb.GetAllResponse), opts...)
+	case pb.KvStore_Count_FullMethodName:
+		return s.count(ctx, args, reply.(*pb.CountResponse), opts...)
+	case pb.KvStore_Keys_FullMethodName:
+		return s.keys(ctx, args, reply.(*pb.KeysResponse), opts...)
+	case pb.KvStore_Clear_FullMethodName:
+		return s.clear(ctx, args, reply.(*pb.ClearResponse), opts...)
+	case pb.KvStore_MultiScan_FullMethodName:
+		return s.multiScan(ctx, args, reply.(*pb.MultiScanResponse), opts...)
+	case pb.KvStore_MultiGet_FullMethodName:
+		return s.multiGet(ctx, args.(*pb.MultiGetRequest), reply.(*pb.MultiGetResponse), opts...)
+	case pb.KvStore_DeleteMany_FullMethodName:
+		return s.deleteMany(ctx, args.(*pb.DeleteManyRequest), reply.(*pb.DeleteManyResponse), opts...)
+	case pb.KvStore_Txn_FullMethodName:
+		node, err := s.txnNode(args.(*pb.TxnRequest))
+		if err != nil {
+			return err
+		}
+		return s.conns[node].Invoke(ctx, method, args, reply, opts...)
+	}
+
+	node := 0
+	if r, ok := args.(keyedRequest); ok {
+		node = s.node(r.GetKey())
+	}
+	return s.conns[node].Invoke(ctx, method, args, reply, opts...)
+}
+
+// fanOut chama method em todos os nós ao mesmo tempo, com args[i] no nó i; um
+// nó com args[i] nil fica de fora e a resposta dele é nil. Qualquer nó com erro
+// faz a chamada inteira falhar, já que o resultado ficaria incompleto.
+func fanOut[R any](ctx context.Context, s *sharded, method string, args []any, opts ...grpc.CallOption) ([]*R, error) {
+	resps := make([]*R, len(s.conns))
+	errs := make([]error, len(s.conns))
+
+	var wg sync.WaitGroup
+	for i, conn := range s.conns {
+		if args[i] == nil {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resps[i] = new(R)
+			errs[i] = conn.Invoke(ctx, method, args[i], resps[i], opts...)
+		}()
+	}
+	wg.Wait()
+
+	for _, err := range errs {
 		if err != nil {
-			log.Fatalf("could not get: %v", err)
+			return nil, err
 		}
+	}
+	return resps, nil
+}
 
-		log.Printf("GET-> %s::%s", r.GetKey(), r.GetValue())
+// everyNode repete o mesmo request para todos os nós do fanOut
+func (s *sharded) everyNode(args any) []any {
+	all := make([]any, len(s.conns))
+	for i := range all {
+		all[i] = args
 	}
+	return all
+}
+
+// byNode separa as chaves pelo nó de cada uma, mantendo a ordem do pedido
+func (s *sharded) byNode(keys []string) [][]string {
+	split := make([][]string, len(s.conns))
+	for _, key := range keys {
+		node := s.node(key)
+		split[node] = append(split[node], key)
+	}
+	return split
+}
+
+// getAll chama o GetAll em todos os nós e junta os valores
+func (s *sharded) getAll(ctx context.Context, args any, reply *pb.GetAllResponse, opts ...grpc.CallOption) error {
+	resps, err := fanOut[pb.GetAllResponse](ctx, s, pb.KvStore_GetAll_FullMethodName, s.everyNode(args), opts...)
+	if err != nil {
+		return err
+	}
+
+	values := make(map[string]string)
+	var sorted []*pb.KeyValue
+	for _, resp := range resps {
+		for k, v := range resp.GetValues() {
+			values[k] = v
+		}
+		sorted = append(sorted, resp.GetSortedValues()...)
+	}
+
+	//cada nó devolve a sua parte ordenada; juntas, precisam ser ordenadas de novo
+	if req, ok := args.(*pb.GetAllRequest); ok && req.GetSorted() {
+		slices.SortFunc(sorted, func(a, b *pb.KeyValue) int {
+			return strings.Compare(a.GetKey(), b.GetKey())
+		})
+		reply.SortedValues = sorted
+		return nil
+	}
+	reply.Values = values
+	return nil
+}
+
+// count soma as contagens de todos os nós
+func (s *sharded) count(ctx context.Context, args any, reply *pb.CountResponse, opts ...grpc.CallOption) error {
+	resps, err := fanOut[pb.CountResponse](ctx, s, pb.KvStore_Count_FullMethodName, s.everyNode(args), opts...)
+	if err != nil {
+		return err
+	}
+	for _, resp := range resps {
+		reply.Count += resp.GetCount()
+	}
+	return nil
+}
+
+// keys junta as chaves de todos os nós, ordenadas como as de um nó só
+func (s *sharded) keys(ctx context.Context, args any, reply *pb.KeysResponse, opts ...grpc.CallOption) error {
+	resps, err := fanOut[pb.KeysResponse](ctx, s, pb.KvStore_Keys_FullMethodName, s.everyNode(args), opts...)
+	if err != nil {
+		return err
+	}
+	for _, resp := range resps {
+		reply.Keys = append(reply.Keys, resp.GetKeys()...)
+	}
+	slices.Sort(reply.Keys)
+	return nil
+}
+
+// clear limpa todos os nós; success só se todos limparam
+func (s *sharded) clear(ctx context.Context, args any, reply *pb.ClearResponse, opts ...grpc.CallOption) error {
+	resps, err := fanOut[pb.ClearResponse](ctx, s, pb.KvStore_Clear_FullMethodName, s.everyNode(args), opts...)
+	if err != nil {
+		return err
+	}
+	reply.Success = true
+	for _, resp := range resps {
+		reply.Success = reply.Success && resp.GetSuccess()
+	}
+	return nil
+}
+
+// multiScan faz o mesmo pedido em todos os nós: cada um devolve um resultado
+// por prefixo, na mesma ordem, e os valores de cada prefixo são juntados e
+// ordenados de novo
+func (s *sharded) multiScan(ctx context.Context, args any, reply *pb.MultiScanResponse, opts ...grpc.CallOption) error {
+	resps, err := fanOut[pb.MultiScanResponse](ctx, s, pb.KvStore_MultiScan_FullMethodName, s.everyNode(args), opts...)
+	if err != nil {
+		return err
+	}
+	for _, resp := range resps {
+		for i, r := range resp.GetResults() {
+			if i == len(reply.Results) {
+				reply.Results = append(reply.Results, &pb.PrefixScan{Prefix: r.GetPrefix()})
+			}
+			reply.Results[i].Values = append(reply.Results[i].Values, r.GetValues()...)
+		}
+	}
+	for _, r := range reply.Results {
+		slices.SortFunc(r.Values, func(a, b *pb.KeyValue) int {
+			return strings.Compare(a.GetKey(), b.GetKey())
+		})
+	}
+	return nil
+}
+
+// multiGet pede a cada nó só as chaves dele e devolve um item por chave
+// distinta, na ordem do pedido, como um nó só faria
+func (s *sharded) multiGet(ctx context.Context, req *pb.MultiGetRequest, reply *pb.MultiGetResponse, opts ...grpc.CallOption) error {
+	args := make([]any, len(s.conns))
+	for i, keys := range s.byNode(req.GetKeys()) {
+		if len(keys) > 0 {
+			args[i] = &pb.MultiGetRequest{Keys: keys}
+		}
+	}
+	resps, err := fanOut[pb.MultiGetResponse](ctx, s, pb.KvStore_MultiGet_FullMethodName, args, opts...)
+	if err != nil {
+		return err
+	}
+
+	found := make(map[string]*pb.KeyValue)
+	for _, resp := range resps {
+		for _, kv := range resp.GetValues() {
+			found[kv.GetKey()] = kv
+		}
+	}
+	for _, key := range req.GetKeys() {
+		if kv, ok := found[key]; ok {
+			reply.Values = append(reply.Values, kv)
+			delete(found, key)
+		}
+	}
+	return nil
+}
+
+// deleteMany apaga em cada nó só as chaves dele e soma as apagadas
+func (s *sharded) deleteMany(ctx context.Context, req *pb.DeleteManyRequest, reply *pb.DeleteManyResponse, opts ...grpc.CallOption) error {
+	args := make([]any, len(s.conns))
+	for i, keys := range s.byNode(req.GetKeys()) {
+		if len(keys) > 0 {
+			args[i] = &pb.DeleteManyRequest{Keys: keys}
+		}
+	}
+	resps, err := fanOut[pb.DeleteManyResponse](ctx, s, pb.KvStore_DeleteMany_FullMethodName, args, opts...)
+	if err != nil {
+		return err
+	}
+	for _, resp := range resps {
+		reply.Deleted += resp.GetDeleted()
+	}
+	return nil
+}
+
+// txnNode retorna o nó de uma Txn cujas chaves estão todas no mesmo nó. Uma
+// Txn com chaves em nós diferentes não teria como ser atômica e é recusada
+// com Unimplemented.
+func (s *sharded) txnNode(req *pb.TxnRequest) (int, error) {
+	var keys []string
+	for _, c := range req.GetCompares() {
+		keys = append(keys, c.GetKey())
+	}
+	for _, op := range slices.Concat(req.GetThenOps(), req.GetElseOps()) {
+		keys = append(keys, op.GetKey())
+	}
+	if len(keys) == 0 {
+		return 0, nil
+	}
+
+	node := s.node(keys[0])
+	for _, key := range keys[1:] {
+		if s.node(key) != node {
+			return 0, status.Errorf(codes.Unimplemented, "txn with keys on different nodes is not supported with --shard")
+		}
+	}
+	return node, nil
+}
 
+func (s *sharded) NewStream(_ context.Context, _ *grpc.StreamDesc, method string, _ ...grpc.CallOption) (grpc.ClientStream, error) {
+	return nil, status.Errorf(codes.Unimplemented, "%s is not supported with --shard", method)
 }
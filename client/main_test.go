@@ -0,0 +1,1067 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/carvalhodanielg/kvstore/internal/version"
+	pb "github.com/carvalhodanielg/kvstore/pb/proto"
+	"github.com/carvalhodanielg/kvstore/store"
+	"github.com/carvalhodanielg/kvstore/testutils"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+// stoppedAddr devolve um endereço onde nenhum servidor está escutando
+func stoppedAddr(t *testing.T) string {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	addr := lis.Addr().String()
+	lis.Close()
+	return addr
+}
+
+// followerServer recusa escritas como um nó que não é o líder
+type followerServer struct {
+	pb.UnimplementedKvStoreServer
+	puts atomic.Int32
+}
+
+func (f *followerServer) Put(_ context.Context, _ *pb.PutRequest) (*pb.PutResponse, error) {
+	f.puts.Add(1)
+	return nil, status.Error(codes.Unavailable, store.ErrNotLeader.Error())
+}
+
+func startFollower(t *testing.T) (*followerServer, string) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+
+	f := &followerServer{}
+	srv := grpc.NewServer()
+	pb.RegisterKvStoreServer(srv, f)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	return f, lis.Addr().String()
+}
+
+func TestRun_ConnectionFailure(t *testing.T) {
+	addr := stoppedAddr(t)
+
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"--addr", addr, "--flag", "get", "--key", "k", "--timeout", "500ms"}, nil, &stdout, &stderr)
+
+	if code != exitUnavailable {
+		t.Errorf("Expected exit code %d, got %d (stderr: %s)", exitUnavailable, code, stderr.String())
+	}
+	if stdout.Len() != 0 {
+		t.Errorf("Expected nothing on stdout, got %q", stdout.String())
+	}
+	if !strings.HasPrefix(stderr.String(), `kvstore-client: could not get "k": `) {
+		t.Errorf("Unexpected error message: %q", stderr.String())
+	}
+}
+
+func TestRun_Usage(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+
+	if code := run([]string{"--flag", "bogus"}, nil, &stdout, &stderr); code != exitUsage {
+		t.Errorf("unknown action: expected exit code %d, got %d", exitUsage, code)
+	}
+	if !strings.Contains(stderr.String(), `unknown action "bogus"`) {
+		t.Errorf("Unexpected error message: %q", stderr.String())
+	}
+
+	if code := run([]string{"--timeout", "soon"}, nil, &stdout, &stderr); code != exitUsage {
+		t.Errorf("invalid flag: expected exit code %d, got %d", exitUsage, code)
+	}
+}
+
+func TestRun_Success(t *testing.T) {
+	ts := testutils.SetupTestServer(t)
+	defer testutils.CleanupTestServer(t, ts)
+
+	var stdout, stderr bytes.Buffer
+
+	if code := run([]string{"--addr", ts.Addr, "--flag", "put", "--key", "nome", "--value", "Daniel"}, nil, &stdout, &stderr); code != exitOK {
+		t.Fatalf("put: expected exit code %d, got %d (stderr: %s)", exitOK, code, stderr.String())
+	}
+
+	stdout.Reset()
+	if code := run([]string{"--addr", ts.Addr, "--flag", "get", "--key", "nome"}, nil, &stdout, &stderr); code != exitOK {
+		t.Fatalf("get: expected exit code %d, got %d (stderr: %s)", exitOK, code, stderr.String())
+	}
+
+	if got := stdout.String(); got != "GET-> nome::Daniel\n" {
+		t.Errorf("Unexpected output: %q", got)
+	}
+	if stderr.Len() != 0 {
+		t.Errorf("Expected nothing on stderr, got %q", stderr.String())
+	}
+}
+
+func TestRun_Ping(t *testing.T) {
+	ts := testutils.SetupTestServer(t)
+	defer testutils.CleanupTestServer(t, ts)
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--addr", ts.Addr, "--flag", "ping", "--format", "json"}, nil, &stdout, &stderr); code != exitOK {
+		t.Fatalf("Expected exit code %d, got %d (stderr: %s)", exitOK, code, stderr.String())
+	}
+
+	var got pingResult
+	if err := json.Unmarshal(stdout.Bytes(), &got); err != nil {
+		t.Fatalf("Output is not a ping result: %v (%q)", err, stdout.String())
+	}
+	if got.Nonce == "" || got.RTTMs <= 0 {
+		t.Errorf("Expected a nonce and a positive RTT, got %+v", got)
+	}
+}
+
+func TestRun_Version(t *testing.T) {
+	ts := testutils.SetupTestServer(t)
+	defer testutils.CleanupTestServer(t, ts)
+
+	oldVersion := version.Version
+	version.Version = "v1.2.3-test"
+	defer func() { version.Version = oldVersion }()
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--addr", ts.Addr, "--flag", "version", "--format", "json"}, nil, &stdout, &stderr); code != exitOK {
+		t.Fatalf("Expected exit code %d, got %d (stderr: %s)", exitOK, code, stderr.String())
+	}
+
+	var got versionResult
+	if err := json.Unmarshal(stdout.Bytes(), &got); err != nil {
+		t.Fatalf("Output is not a version result: %v (%q)", err, stdout.String())
+	}
+	if got.Version != "v1.2.3-test" || got.ClientVersion != "v1.2.3-test" || got.GoVersion == "" || got.Commit == "" {
+		t.Errorf("Unexpected version result %+v", got)
+	}
+}
+
+func TestRun_Interactive(t *testing.T) {
+	ts := testutils.SetupTestServer(t)
+	defer testutils.CleanupTestServer(t, ts)
+
+	script := `put a 1
+put b hello world
+
+# comentários e linhas vazias são ignorados
+bogus
+del a
+put c 3
+get b
+`
+
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"--addr", ts.Addr, "--interactive"}, strings.NewReader(script), &stdout, &stderr)
+
+	// O comando inválido não interrompe o script, mas define o código de saída
+	if code != exitUsage {
+		t.Errorf("Expected exit code %d, got %d", exitUsage, code)
+	}
+	if !strings.Contains(stderr.String(), `unknown action "bogus"`) {
+		t.Errorf("Expected error for bogus command, got %q", stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "GET-> b::hello world\n") {
+		t.Errorf("Expected get output, got %q", stdout.String())
+	}
+
+	expected := map[string]string{"b": "hello world", "c": "3"}
+	testutils.AssertDataEqual(t, expected, ts.Store.GetAll())
+}
+
+func TestParseCommand(t *testing.T) {
+	tests := []struct {
+		line    string
+		action  string
+		key     string
+		value   string
+		wantErr bool
+	}{
+		{"get user:1", "get", "user:1", "", false},
+		{"put user:1  João da Silva ", "put", "user:1", "João da Silva", false},
+		{"del user:1", "delete", "user:1", "", false},
+		{"keys user:", "keys", "user:", "", false},
+		{"all", "all", "", "", false},
+		{"get", "get", "", "", true},
+		{"put", "put", "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			o, err := parseCommand(tt.line, options{})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseCommand(%q) error = %v, wantErr %v", tt.line, err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if o.action != tt.action || o.key != tt.key || o.value != tt.value {
+				t.Errorf("parseCommand(%q) = %q %q %q, expected %q %q %q", tt.line, o.action, o.key, o.value, tt.action, tt.key, tt.value)
+			}
+		})
+	}
+}
+
+func TestRun_JSONFormat(t *testing.T) {
+	ts := testutils.SetupTestServer(t)
+	defer testutils.CleanupTestServer(t, ts)
+
+	// Cada passo roda em ordem contra o mesmo servidor
+	tests := []struct {
+		name     string
+		args     []string
+		expected any
+	}{
+		{"put", []string{"--flag", "put", "--key", "nome", "--value", "Daniel"}, map[string]any{"key": "nome", "success": true}},
+		{"get", []string{"--flag", "get", "--key", "nome"}, map[string]any{"key": "nome", "value": "Daniel"}},
+		{"all", []string{"--flag", "all"}, map[string]any{"nome": "Daniel"}},
+		{"delete", []string{"--flag", "delete", "--key", "nome"}, map[string]any{"key": "nome"}},
+		{"all empty", []string{"--flag", "all"}, map[string]any{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			args := append([]string{"--addr", ts.Addr, "--format", "json"}, tt.args...)
+
+			if code := run(args, nil, &stdout, &stderr); code != exitOK {
+				t.Fatalf("Expected exit code %d, got %d (stderr: %s)", exitOK, code, stderr.String())
+			}
+
+			var got map[string]any
+			if err := json.Unmarshal(stdout.Bytes(), &got); err != nil {
+				t.Fatalf("Output is not a JSON object: %v (%q)", err, stdout.String())
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--addr", ts.Addr, "--format", "xml"}, nil, &stdout, &stderr); code != exitUsage {
+		t.Errorf("invalid format: expected exit code %d, got %d", exitUsage, code)
+	}
+}
+
+func TestRun_Failover(t *testing.T) {
+	ts := testutils.SetupTestServer(t)
+	defer testutils.CleanupTestServer(t, ts)
+
+	t.Run("stopped node", func(t *testing.T) {
+		addrs := stoppedAddr(t) + "," + ts.Addr
+
+		var stdout, stderr bytes.Buffer
+		if code := run([]string{"--addr", addrs, "--flag", "put", "--key", "a", "--value", "1"}, nil, &stdout, &stderr); code != exitOK {
+			t.Fatalf("Expected exit code %d, got %d (stderr: %s)", exitOK, code, stderr.String())
+		}
+
+		if got := ts.Store.Get("a"); got != "1" {
+			t.Errorf("Expected the second node to store the key, got %q", got)
+		}
+	})
+
+	t.Run("not leader", func(t *testing.T) {
+		follower, followerAddr := startFollower(t)
+
+		// Depois da primeira escrita o líder fica em cache e o follower não é mais tentado
+		script := "put b 2\nput c 3\nget c\n"
+
+		var stdout, stderr bytes.Buffer
+		code := run([]string{"--addr", followerAddr + "," + ts.Addr, "--interactive"}, strings.NewReader(script), &stdout, &stderr)
+		if code != exitOK {
+			t.Fatalf("Expected exit code %d, got %d (stderr: %s)", exitOK, code, stderr.String())
+		}
+
+		if got := follower.puts.Load(); got != 1 {
+			t.Errorf("Expected the follower to see 1 put, got %d", got)
+		}
+		if got := ts.Store.Get("c"); got != "3" {
+			t.Errorf("Expected the leader to store the key, got %q", got)
+		}
+		if !strings.Contains(stdout.String(), "GET-> c::3\n") {
+			t.Errorf("Expected get output, got %q", stdout.String())
+		}
+	})
+}
+
+// flakyServer falha as primeiras chamadas de Get e PutIfAbsent com code e
+// message
+type flakyServer struct {
+	pb.UnimplementedKvStoreServer
+	failures int32
+	code     codes.Code
+	message  string
+	calls    atomic.Int32
+}
+
+func (f *flakyServer) Get(_ context.Context, r *pb.GetRequest) (*pb.GetResponse, error) {
+	if f.calls.Add(1) <= f.failures {
+		return nil, status.Error(f.code, f.message)
+	}
+	return &pb.GetResponse{Key: r.GetKey(), Value: "v"}, nil
+}
+
+func (f *flakyServer) PutIfAbsent(_ context.Context, _ *pb.PutRequest) (*pb.PutIfAbsentResponse, error) {
+	if f.calls.Add(1) <= f.failures {
+		return nil, status.Error(f.code, f.message)
+	}
+	return &pb.PutIfAbsentResponse{Stored: true}, nil
+}
+
+func startFlaky(t *testing.T, failures int32, code codes.Code, message string) (*flakyServer, string) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+
+	f := &flakyServer{failures: failures, code: code, message: message}
+	srv := grpc.NewServer()
+	pb.RegisterKvStoreServer(srv, f)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	return f, lis.Addr().String()
+}
+
+func TestRun_Retry(t *testing.T) {
+	tests := []struct {
+		name     string
+		failures int32
+		code     codes.Code
+		retries  string
+		exit     int
+		calls    int32
+	}{
+		{"recovers within budget", 2, codes.Unavailable, "3", exitOK, 3},
+		{"deadline exceeded is retried", 1, codes.DeadlineExceeded, "3", exitOK, 2},
+		{"budget exhausted", 5, codes.Unavailable, "3", exitUnavailable, 3},
+		{"invalid argument fails fast", 5, codes.InvalidArgument, "3", exitFailure, 1},
+		{"not found fails fast", 5, codes.NotFound, "3", exitNotFound, 1},
+		{"retries disabled", 1, codes.Unavailable, "1", exitUnavailable, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, addr := startFlaky(t, tt.failures, tt.code, "try again")
+
+			var stdout, stderr bytes.Buffer
+			code := run([]string{"--addr", addr, "--flag", "get", "--key", "k", "--retries", tt.retries, "--retry-backoff", "5ms"}, nil, &stdout, &stderr)
+			if code != tt.exit {
+				t.Errorf("Expected exit code %d, got %d (stderr: %s)", tt.exit, code, stderr.String())
+			}
+			if got := f.calls.Load(); got != tt.calls {
+				t.Errorf("Expected %d calls, got %d", tt.calls, got)
+			}
+			if tt.exit == exitOK && stdout.String() != "GET-> k::v\n" {
+				t.Errorf("Unexpected output %q", stdout.String())
+			}
+		})
+	}
+}
+
+func TestRetrier_ConditionalWrites(t *testing.T) {
+	tests := []struct {
+		name    string
+		code    codes.Code
+		message string
+		calls   int32
+		ok      bool
+	}{
+		{"not leader is retried", codes.Unavailable, store.ErrNotLeader.Error(), 2, true},
+		{"unknown outcome is not retried", codes.Unavailable, store.ErrReplicationFailed.Error(), 1, false},
+		{"deadline exceeded is not retried", codes.DeadlineExceeded, "try again", 1, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, addr := startFlaky(t, 1, tt.code, tt.message)
+			conn, err := dialAll([]string{addr}, defaultMaxRecvMsgSize, defaultMaxSendMsgSize)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer conn.Close()
+
+			c := pb.NewKvStoreClient(&retrier{ClientConnInterface: conn, attempts: 3, backoff: time.Millisecond})
+			_, err = c.PutIfAbsent(context.Background(), &pb.PutRequest{Key: "k", Value: "v"})
+			if (err == nil) != tt.ok {
+				t.Errorf("PutIfAbsent() returned %v, expected success %v", err, tt.ok)
+			}
+			if got := f.calls.Load(); got != tt.calls {
+				t.Errorf("Expected %d calls, got %d", tt.calls, got)
+			}
+		})
+	}
+}
+
+func TestNotSent(t *testing.T) {
+	conn, err := dialAll([]string{stoppedAddr(t)}, defaultMaxRecvMsgSize, defaultMaxSendMsgSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	_, err = pb.NewKvStoreClient(conn).Txn(context.Background(), &pb.TxnRequest{})
+	if !notSent(err) {
+		t.Errorf("notSent(%v) = false for a node that is down", err)
+	}
+	if notSent(status.Error(codes.Unavailable, "error reading from server: EOF")) {
+		t.Error("notSent() = true for a connection lost during the call")
+	}
+}
+
+func TestRetrier_Delay(t *testing.T) {
+	r := &retrier{backoff: 100 * time.Millisecond}
+	for n, limit := range map[int]time.Duration{1: 100 * time.Millisecond, 3: 400 * time.Millisecond, 20: retryMaxBackoff} {
+		for range 50 {
+			if d := r.delay(n); d < 0 || d > limit {
+				t.Fatalf("delay(%d) = %v, expected between 0 and %v", n, d, limit)
+			}
+		}
+	}
+}
+
+func TestRun_ExportImport(t *testing.T) {
+	ts := testutils.SetupTestServer(t)
+	defer testutils.CleanupTestServer(t, ts)
+
+	data := testutils.GenerateTestData("user", 50)
+	data["com espaço"] = "linha 1\nlinha 2"
+	data["vazio"] = ""
+	for k, v := range data {
+		ts.Store.Put(k, v)
+	}
+
+	file := filepath.Join(t.TempDir(), "dump.ndjson")
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--addr", ts.Addr, "--flag", "export", "--file", file}, nil, &stdout, &stderr); code != exitOK {
+		t.Fatalf("export: expected exit code %d, got %d (stderr: %s)", exitOK, code, stderr.String())
+	}
+	if want := fmt.Sprintf("EXPORTED-> %d pairs to %s\n", len(data), file); stdout.String() != want {
+		t.Errorf("Unexpected export output: %q", stdout.String())
+	}
+
+	// Importa em uma store vazia
+	if err := ts.Store.Clear(); err != nil {
+		t.Fatalf("Clear() failed: %v", err)
+	}
+
+	stdout.Reset()
+	if code := run([]string{"--addr", ts.Addr, "--interactive"}, strings.NewReader("import "+file+"\n"), &stdout, &stderr); code != exitOK {
+		t.Fatalf("import: expected exit code %d, got %d (stderr: %s)", exitOK, code, stderr.String())
+	}
+	if want := fmt.Sprintf("IMPORTED-> %d pairs from %s\n", len(data), file); stdout.String() != want {
+		t.Errorf("Unexpected import output: %q", stdout.String())
+	}
+
+	testutils.AssertDataEqual(t, data, ts.Store.GetAll())
+
+	if code := run([]string{"--addr", ts.Addr, "--flag", "import"}, nil, &stdout, &stderr); code != exitUsage {
+		t.Errorf("import without file: expected exit code %d, got %d", exitUsage, code)
+	}
+}
+
+func TestRun_ImportDryRun(t *testing.T) {
+	ts := testutils.SetupTestServer(t)
+	defer testutils.CleanupTestServer(t, ts)
+
+	ts.Store.Put("existing", "kept")
+
+	file := filepath.Join(t.TempDir(), "dump.ndjson")
+	backup := `{"key":"a","value":"1"}
+{"key":"b","value":"22"}
+not json
+{"key":"a","value":"333"}
+
+{"key":"c","value":
+`
+	if err := os.WriteFile(file, []byte(backup), 0o644); err != nil {
+		t.Fatalf("failed to write backup: %v", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"--addr", ts.Addr, "--flag", "import", "--file", file, "--dry-run", "--format", "json"}, nil, &stdout, &stderr)
+	if code != exitFailure {
+		t.Errorf("Expected exit code %d for a backup with errors, got %d", exitFailure, code)
+	}
+
+	var got validateResult
+	if err := json.Unmarshal(stdout.Bytes(), &got); err != nil {
+		t.Fatalf("Output is not a validate result: %v (%q)", err, stdout.String())
+	}
+	want := validateResult{File: file, Count: 5, Bytes: 9, Duplicates: 1, Invalid: 2}
+	if got.Count != want.Count || got.Bytes != want.Bytes || got.Duplicates != want.Duplicates || got.Invalid != want.Invalid {
+		t.Errorf("Unexpected summary %+v, expected %+v", got, want)
+	}
+	if len(got.Errors) != 2 || !strings.HasPrefix(got.Errors[0], "line 3:") || !strings.HasPrefix(got.Errors[1], "line 6:") {
+		t.Errorf("Unexpected errors %q", got.Errors)
+	}
+
+	// Nada foi gravado
+	testutils.AssertDataEqual(t, map[string]string{"existing": "kept"}, ts.Store.GetAll())
+}
+
+func TestRun_Populate(t *testing.T) {
+	ts := testutils.SetupTestServer(t)
+	defer testutils.CleanupTestServer(t, ts)
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--addr", ts.Addr, "--flag", "populate"}, nil, &stdout, &stderr); code != exitOK {
+		t.Fatalf("Expected exit code %d, got %d (stderr: %s)", exitOK, code, stderr.String())
+	}
+	if stdout.String() != "POPULATED-> 30 pairs\n" {
+		t.Errorf("Unexpected populate output: %q", stdout.String())
+	}
+	if got := ts.Store.Get("key-14"); got != "value-14" {
+		t.Errorf("Get(key-14) = %q, expected value-14", got)
+	}
+	if n := ts.Store.Count(); n != 30 {
+		t.Errorf("Count() = %d, expected 30", n)
+	}
+}
+
+// lockedBuffer é um bytes.Buffer seguro para o watch escrever enquanto o teste lê
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestRun_WatchReconnect(t *testing.T) {
+	ts := testutils.SetupTestServer(t)
+	defer testutils.CleanupTestServer(t, ts)
+
+	waitFor := func(what string, cond func() bool) {
+		t.Helper()
+		deadline := time.Now().Add(3 * time.Second)
+		for !cond() {
+			if time.Now().After(deadline) {
+				t.Fatalf("timeout waiting for %s", what)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	var stdout, stderr lockedBuffer
+	done := make(chan int, 1)
+	go func() {
+		done <- run([]string{"--addr", ts.Addr, "--flag", "watch", "--key", "k", "--watch-duration", "5s"}, nil, &stdout, &stderr)
+	}()
+
+	waitFor("the watcher", func() bool { return ts.Store.WatcherCount() == 1 })
+	ts.Store.Put("k", "v1")
+	waitFor("the first event", func() bool { return strings.Contains(stdout.String(), "updated to v1") })
+
+	// O stream cai com o servidor; o cliente reabre o watch no servidor novo.
+	// O Restart só retorna depois do watcher antigo sair, então v2 é gravado
+	// depois do cliente se registrar de novo e o snapshot ainda é v1.
+	ts.Restart(t)
+	waitFor("the watcher to come back", func() bool { return ts.Store.WatcherCount() == 1 })
+	ts.Store.Put("k", "v2")
+	waitFor("an event after the restart", func() bool { return strings.Contains(stdout.String(), "updated to v2") })
+
+	// O snapshot pedido ao retomar repete v1 e não deve aparecer
+	if strings.Contains(stdout.String(), "current value") {
+		t.Errorf("Unchanged snapshot was not deduplicated: %q", stdout.String())
+	}
+
+	select {
+	case code := <-done:
+		if code != exitOK {
+			t.Errorf("Expected exit code %d, got %d (stderr: %s)", exitOK, code, stderr.String())
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("watch did not stop after --watch-duration")
+	}
+}
+
+func TestRun_WatchPrefix(t *testing.T) {
+	ts := testutils.SetupTestServer(t)
+	defer testutils.CleanupTestServer(t, ts)
+
+	var stdout, stderr lockedBuffer
+	done := make(chan int, 1)
+	go func() {
+		done <- run([]string{"--addr", ts.Addr, "--flag", "watch-prefix", "--key", "user:", "--watch-duration", "3s", "--format", "json"}, nil, &stdout, &stderr)
+	}()
+
+	deadline := time.Now().Add(3 * time.Second)
+	for ts.Store.WatcherCount() != 1 {
+		if time.Now().After(deadline) {
+			t.Fatal("timeout waiting for the watcher")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	ts.Store.Put("user:1", "ana")
+	ts.Store.Put("order:1", "x")
+	ts.Store.Delete("user:1")
+
+	select {
+	case code := <-done:
+		if code != exitOK {
+			t.Fatalf("Expected exit code %d, got %d (stderr: %s)", exitOK, code, stderr.String())
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("watch-prefix did not stop after --watch-duration")
+	}
+
+	var events []watchEvent
+	dec := json.NewDecoder(strings.NewReader(stdout.String()))
+	for dec.More() {
+		var e watchEvent
+		if err := dec.Decode(&e); err != nil {
+			t.Fatalf("Invalid JSON output %q: %v", stdout.String(), err)
+		}
+		events = append(events, e)
+	}
+
+	// só as chaves com o prefixo, cada uma com a hora de chegada
+	if len(events) != 2 || events[0].Key != "user:1" || events[0].Value != "ana" || events[1].Type != "delete" {
+		t.Fatalf("Unexpected events: %+v", events)
+	}
+	for _, e := range events {
+		if _, err := time.Parse(time.RFC3339Nano, e.Time); err != nil {
+			t.Errorf("Event %+v has an invalid time: %v", e, err)
+		}
+	}
+}
+
+// shardServer guarda as chaves em um mapa, como um nó sem raft
+type shardServer struct {
+	pb.UnimplementedKvStoreServer
+
+	mu     sync.Mutex
+	values map[string]string
+}
+
+func (s *shardServer) Put(_ context.Context, r *pb.PutRequest) (*pb.PutResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.values[r.GetKey()] = r.GetValue()
+	return &pb.PutResponse{Success: true}, nil
+}
+
+func (s *shardServer) Get(_ context.Context, r *pb.GetRequest) (*pb.GetResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return &pb.GetResponse{Key: r.GetKey(), Value: s.values[r.GetKey()]}, nil
+}
+
+func (s *shardServer) GetAll(_ context.Context, r *pb.GetAllRequest) (*pb.GetAllResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if r.GetSorted() {
+		var sorted []*pb.KeyValue
+		for _, k := range slices.Sorted(maps.Keys(s.values)) {
+			sorted = append(sorted, &pb.KeyValue{Key: k, Value: s.values[k], Found: true})
+		}
+		return &pb.GetAllResponse{SortedValues: sorted}, nil
+	}
+	values := make(map[string]string, len(s.values))
+	for k, v := range s.values {
+		values[k] = v
+	}
+	return &pb.GetAllResponse{Values: values}, nil
+}
+
+func (s *shardServer) Count(_ context.Context, r *pb.CountRequest) (*pb.CountResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var n int64
+	for k := range s.values {
+		if strings.HasPrefix(k, r.GetPrefix()) {
+			n++
+		}
+	}
+	return &pb.CountResponse{Count: n}, nil
+}
+
+func (s *shardServer) Keys(_ context.Context, r *pb.KeysRequest) (*pb.KeysResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var keys []string
+	for _, k := range slices.Sorted(maps.Keys(s.values)) {
+		if strings.HasPrefix(k, r.GetPrefix()) {
+			keys = append(keys, k)
+		}
+	}
+	return &pb.KeysResponse{Keys: keys}, nil
+}
+
+func (s *shardServer) Clear(context.Context, *pb.ClearRequest) (*pb.ClearResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	clear(s.values)
+	return &pb.ClearResponse{Success: true}, nil
+}
+
+func (s *shardServer) MultiGet(_ context.Context, r *pb.MultiGetRequest) (*pb.MultiGetResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var values []*pb.KeyValue
+	for _, k := range r.GetKeys() {
+		v, ok := s.values[k]
+		values = append(values, &pb.KeyValue{Key: k, Value: v, Found: ok})
+	}
+	return &pb.MultiGetResponse{Values: values}, nil
+}
+
+func (s *shardServer) DeleteMany(_ context.Context, r *pb.DeleteManyRequest) (*pb.DeleteManyResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var n int64
+	for _, k := range r.GetKeys() {
+		if _, ok := s.values[k]; ok {
+			delete(s.values, k)
+			n++
+		}
+	}
+	return &pb.DeleteManyResponse{Deleted: n}, nil
+}
+
+// Txn sem compares: aplica os puts do then
+func (s *shardServer) Txn(_ context.Context, r *pb.TxnRequest) (*pb.TxnResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, op := range r.GetThenOps() {
+		s.values[op.GetKey()] = op.GetValue()
+	}
+	return &pb.TxnResponse{Succeeded: true}, nil
+}
+
+func (s *shardServer) len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.values)
+}
+
+func (s *shardServer) has(key string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.values[key]
+	return ok
+}
+
+func startShards(t *testing.T, n int) ([]*shardServer, []string) {
+	var servers []*shardServer
+	var addrs []string
+	for range n {
+		lis, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("failed to listen: %v", err)
+		}
+
+		s := &shardServer{values: make(map[string]string)}
+		srv := grpc.NewServer()
+		pb.RegisterKvStoreServer(srv, s)
+		go srv.Serve(lis)
+		t.Cleanup(srv.Stop)
+
+		servers = append(servers, s)
+		addrs = append(addrs, lis.Addr().String())
+	}
+	return servers, addrs
+}
+
+func dialShards(t *testing.T, addrs []string) *sharded {
+	var conns []*grpc.ClientConn
+	for _, addr := range addrs {
+		conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { conn.Close() })
+		conns = append(conns, conn)
+	}
+	return newSharded(addrs, conns)
+}
+
+func TestSharded_RoutesAndMerges(t *testing.T) {
+	servers, addrs := startShards(t, 3)
+	s := dialShards(t, addrs)
+	c := pb.NewKvStoreClient(s)
+	ctx := context.Background()
+
+	const n = 60
+	for i := range n {
+		key := fmt.Sprintf("key%d", i)
+		if _, err := c.Put(ctx, &pb.PutRequest{Key: key, Value: fmt.Sprint(i)}); err != nil {
+			t.Fatalf("Put(%s) failed: %v", key, err)
+		}
+	}
+
+	// cada chave está só no nó escolhido pelo anel, e um segundo cliente com
+	// os mesmos endereços escolhe o mesmo nó
+	other := dialShards(t, addrs)
+	for i := range n {
+		key := fmt.Sprintf("key%d", i)
+		node := s.node(key)
+		if got := other.node(key); got != node {
+			t.Errorf("%s routed to node %d and %d by two clients", key, node, got)
+		}
+		for j, srv := range servers {
+			if ok := srv.has(key); ok != (j == node) {
+				t.Errorf("%s on node %d: %v, expected only on node %d", key, j, ok, node)
+			}
+		}
+
+		r, err := c.Get(ctx, &pb.GetRequest{Key: key})
+		if err != nil || r.GetValue() != fmt.Sprint(i) {
+			t.Errorf("Get(%s) = %q, %v", key, r.GetValue(), err)
+		}
+	}
+	for j, srv := range servers {
+		if srv.len() == 0 {
+			t.Errorf("node %d got no keys", j)
+		}
+	}
+
+	r, err := c.GetAll(ctx, &pb.GetAllRequest{})
+	if err != nil {
+		t.Fatalf("GetAll failed: %v", err)
+	}
+	if len(r.GetValues()) != n {
+		t.Errorf("GetAll returned %d keys, expected %d merged from all nodes", len(r.GetValues()), n)
+	}
+	for i := range n {
+		if v := r.GetValues()[fmt.Sprintf("key%d", i)]; v != fmt.Sprint(i) {
+			t.Errorf("GetAll key%d = %q", i, v)
+		}
+	}
+
+	// com sorted, as partes de cada nó voltam ordenadas juntas
+	r, err = c.GetAll(ctx, &pb.GetAllRequest{Sorted: true})
+	if err != nil {
+		t.Fatalf("GetAll(sorted) failed: %v", err)
+	}
+	var keys []string
+	for _, kv := range r.GetSortedValues() {
+		keys = append(keys, kv.GetKey())
+	}
+	if len(keys) != n || !slices.IsSorted(keys) {
+		t.Errorf("GetAll(sorted) returned keys %v, expected %d sorted keys", keys, n)
+	}
+}
+
+func TestSharded_KeylessCalls(t *testing.T) {
+	servers, addrs := startShards(t, 3)
+	s := dialShards(t, addrs)
+	c := pb.NewKvStoreClient(s)
+	ctx := context.Background()
+
+	const n = 30
+	for i := range n {
+		key := fmt.Sprintf("key%02d", i)
+		if _, err := c.Put(ctx, &pb.PutRequest{Key: key, Value: fmt.Sprint(i)}); err != nil {
+			t.Fatalf("Put(%s) failed: %v", key, err)
+		}
+	}
+
+	// Count e Keys somam todos os nós, não só o primeiro
+	if r, err := c.Count(ctx, &pb.CountRequest{}); err != nil || r.GetCount() != n {
+		t.Errorf("Count() = %d, %v, expected %d", r.GetCount(), err, n)
+	}
+	if r, err := c.Count(ctx, &pb.CountRequest{Prefix: "key1"}); err != nil || r.GetCount() != 10 {
+		t.Errorf("Count(key1) = %d, %v, expected 10", r.GetCount(), err)
+	}
+	r, err := c.Keys(ctx, &pb.KeysRequest{})
+	if err != nil {
+		t.Fatalf("Keys() failed: %v", err)
+	}
+	if len(r.GetKeys()) != n || !slices.IsSorted(r.GetKeys()) {
+		t.Errorf("Keys() = %v, expected %d sorted keys", r.GetKeys(), n)
+	}
+
+	// MultiGet e DeleteMany levam a cada nó só as chaves dele
+	mg, err := c.MultiGet(ctx, &pb.MultiGetRequest{Keys: []string{"key05", "missing", "key00", "key05", "key29"}})
+	if err != nil {
+		t.Fatalf("MultiGet() failed: %v", err)
+	}
+	var got []string
+	for _, kv := range mg.GetValues() {
+		got = append(got, fmt.Sprintf("%s=%s/%v", kv.GetKey(), kv.GetValue(), kv.GetFound()))
+	}
+	if want := []string{"key05=5/true", "missing=/false", "key00=0/true", "key29=29/true"}; !slices.Equal(got, want) {
+		t.Errorf("MultiGet() = %v, expected %v", got, want)
+	}
+	if r, err := c.DeleteMany(ctx, &pb.DeleteManyRequest{Keys: []string{"key00", "key01", "key02", "missing"}}); err != nil || r.GetDeleted() != 3 {
+		t.Errorf("DeleteMany() = %d, %v, expected 3", r.GetDeleted(), err)
+	}
+
+	// uma Txn vai para o nó das chaves dela; com chaves em nós diferentes não
+	// teria como ser atômica
+	same, other := "", ""
+	for i := range 100 {
+		key := fmt.Sprintf("txn%d", i)
+		switch {
+		case s.node(key) == s.node("txn") && same == "":
+			same = key
+		case s.node(key) != s.node("txn") && other == "":
+			other = key
+		}
+	}
+	if _, err := c.Txn(ctx, &pb.TxnRequest{ThenOps: []*pb.TxnOp{{Key: "txn", Value: "1"}, {Key: same, Value: "2"}}}); err != nil {
+		t.Errorf("Txn() on one node failed: %v", err)
+	}
+	if !servers[s.node("txn")].has("txn") || !servers[s.node("txn")].has(same) {
+		t.Errorf("Txn() did not reach node %d", s.node("txn"))
+	}
+	_, err = c.Txn(ctx, &pb.TxnRequest{ThenOps: []*pb.TxnOp{{Key: "txn", Value: "3"}, {Key: other, Value: "4"}}})
+	if status.Code(err) != codes.Unimplemented {
+		t.Errorf("Txn() across nodes returned %v, expected Unimplemented", err)
+	}
+	if servers[s.node(other)].has(other) {
+		t.Errorf("Txn() across nodes wrote %s", other)
+	}
+
+	// Clear limpa todos os nós
+	if r, err := c.Clear(ctx, &pb.ClearRequest{}); err != nil || !r.GetSuccess() {
+		t.Fatalf("Clear() = %v, %v", r, err)
+	}
+	for j, srv := range servers {
+		if srv.len() != 0 {
+			t.Errorf("node %d still has %d keys after Clear()", j, srv.len())
+		}
+	}
+	if r, err := c.Count(ctx, &pb.CountRequest{}); err != nil || r.GetCount() != 0 {
+		t.Errorf("Count() after Clear() = %d, %v, expected 0", r.GetCount(), err)
+	}
+}
+
+func TestRun_Shard(t *testing.T) {
+	servers, addrs := startShards(t, 3)
+	addr := addrs[0] + "," + addrs[1] + "," + addrs[2]
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--addr", addr, "--shard", "--flag", "put", "--key", "k", "--value", "v"}, nil, &stdout, &stderr); code != exitOK {
+		t.Fatalf("put: expected exit code %d, got %d (stderr: %s)", exitOK, code, stderr.String())
+	}
+
+	stored := 0
+	for _, srv := range servers {
+		stored += srv.len()
+	}
+	if stored != 1 {
+		t.Errorf("expected the key on exactly one node, found on %d", stored)
+	}
+}
+
+func TestRun_MaxRecvMsgSize(t *testing.T) {
+	servers, addrs := startShards(t, 1)
+	value := strings.Repeat("v", defaultMaxRecvMsgSize+1024)
+	servers[0].values["big"] = value
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--addr", addrs[0], "--flag", "get", "--key", "big"}, nil, &stdout, &stderr); code != exitFailure {
+		t.Fatalf("get with the default limit: expected exit code %d, got %d", exitFailure, code)
+	}
+	if !strings.Contains(stderr.String(), "ResourceExhausted") {
+		t.Errorf("expected a message size error, got %q", stderr.String())
+	}
+
+	stdout.Reset()
+	stderr.Reset()
+	if code := run([]string{"--addr", addrs[0], "--flag", "get", "--key", "big", "--max-recv-msg-size", "8388608"}, nil, &stdout, &stderr); code != exitOK {
+		t.Fatalf("get with a raised limit: expected exit code %d, got %d (stderr: %s)", exitOK, code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), value) {
+		t.Errorf("expected the whole value in the output, got %d bytes", stdout.Len())
+	}
+}
+
+func TestRun_Walcat(t *testing.T) {
+	walFile := filepath.Join(t.TempDir(), store.WALFileName)
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, e := range []store.WalLog{
+		{SequenceNumber: 1, Operation: store.Write, Key: "a", Value: "1", Revision: 1},
+		{SequenceNumber: 2, Operation: store.Write, Key: "b", Value: "2", Revision: 2},
+		{SequenceNumber: 3, Operation: store.Delete, Key: "a", Revision: 3},
+		{SequenceNumber: 4, Operation: store.Write, Namespace: "users", Key: "c", Value: "3"},
+	} {
+		enc.Encode(e)
+	}
+	if err := os.WriteFile(walFile, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	walcat := func(args ...string) ([]store.WalLog, int, string) {
+		var stdout, stderr bytes.Buffer
+		code := run(append([]string{"--flag", "walcat", "--file", walFile, "--format", "json"}, args...), nil, &stdout, &stderr)
+
+		var entries []store.WalLog
+		dec := json.NewDecoder(&stdout)
+		for dec.More() {
+			var e store.WalLog
+			if err := dec.Decode(&e); err != nil {
+				t.Fatalf("invalid walcat output: %v", err)
+			}
+			entries = append(entries, e)
+		}
+		return entries, code, stderr.String()
+	}
+
+	if entries, code, stderr := walcat(); code != exitOK || len(entries) != 4 {
+		t.Fatalf("walcat returned %d entries with code %d (stderr: %s)", len(entries), code, stderr)
+	}
+
+	entries, code, stderr := walcat("--op", "delete")
+	if code != exitOK {
+		t.Fatalf("walcat --op delete exited with %d (stderr: %s)", code, stderr)
+	}
+	if len(entries) != 1 || entries[0].Operation != store.Delete || entries[0].Key != "a" || entries[0].SequenceNumber != 3 {
+		t.Errorf("walcat --op delete = %+v, expected only the delete of a", entries)
+	}
+
+	entries, _, _ = walcat("--op", "Write", "--key", "a")
+	if len(entries) != 1 || entries[0].Value != "1" {
+		t.Errorf("walcat --op Write --key a = %+v, expected the write of a", entries)
+	}
+
+	if _, code, _ := walcat("--op", "bogus"); code != exitUsage {
+		t.Errorf("unknown operation: expected exit code %d, got %d", exitUsage, code)
+	}
+
+	var stdout, errOut bytes.Buffer
+	if code := run([]string{"--flag", "walcat", "--file", walFile, "--op", "write", "--key", "c"}, nil, &stdout, &errOut); code != exitOK {
+		t.Fatalf("walcat exited with %d (stderr: %s)", code, errOut.String())
+	}
+	if got := stdout.String(); got != "#4 Write users/c = \"3\"\n" {
+		t.Errorf("unexpected human output %q", got)
+	}
+}
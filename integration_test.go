@@ -46,12 +46,19 @@ func (s *server) Watch(in *pb.WatchRequest, stream pb.KvStore_WatchServer) error
 	w := s.store.Watch(in.Key)
 	defer s.store.Unwatch(w)
 
-	for event := range w.Events {
-		if err := stream.Send(&pb.WatchResponse{Message: event}); err != nil {
-			return err
+	for {
+		select {
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		case event, ok := <-w.Events:
+			if !ok {
+				return nil
+			}
+			if err := stream.Send(&pb.WatchResponse{Message: event.Message}); err != nil {
+				return err
+			}
 		}
 	}
-	return nil
 }
 
 // IntegrationTestServer representa um servidor completo para testes de integração
@@ -66,8 +73,15 @@ type IntegrationTestServer struct {
 // setupIntegrationTestServer cria um servidor completo para testes de integração
 func setupIntegrationTestServer(t *testing.T) *IntegrationTestServer {
 	// Cria um banco de dados temporário
+	os.Remove("integration_test.db") // Remove se existir
+
+	return startIntegrationTestServer(t)
+}
+
+// startIntegrationTestServer sobe o servidor sobre o banco que já existir,
+// como num restart
+func startIntegrationTestServer(t *testing.T) *IntegrationTestServer {
 	dbPath := "integration_test.db"
-	os.Remove(dbPath) // Remove se existir
 
 	db, err := bolt.Open(dbPath, constants.DBFilePermission, nil)
 	if err != nil {
@@ -89,6 +103,10 @@ func setupIntegrationTestServer(t *testing.T) *IntegrationTestServer {
 	// Cria o servidor
 	srv := grpc.NewServer()
 	kvStore := store.NewKVStore()
+	// Como no servidor, a memória é restaurada a partir do banco
+	if err := kvStore.LoadKeys(); err != nil {
+		t.Fatalf("failed to load keys: %v", err)
+	}
 	s := &server{
 		store: kvStore,
 	}
@@ -122,6 +140,14 @@ func setupIntegrationTestServer(t *testing.T) *IntegrationTestServer {
 
 // cleanupIntegrationTestServer limpa o servidor de integração
 func cleanupIntegrationTestServer(t *testing.T, its *IntegrationTestServer) {
+	stopIntegrationTestServer(its)
+	os.Remove("integration_test.db")
+	os.Remove("walog.ndjson")
+}
+
+// stopIntegrationTestServer para o servidor sem apagar o banco e o WAL, para
+// a próxima sessão recuperar os dados
+func stopIntegrationTestServer(its *IntegrationTestServer) {
 	if its.server != nil {
 		its.server.Stop()
 	}
@@ -131,8 +157,6 @@ func cleanupIntegrationTestServer(t *testing.T, its *IntegrationTestServer) {
 	if its.listener != nil {
 		its.listener.Close()
 	}
-	os.Remove("integration_test.db")
-	os.Remove("walog.ndjson")
 }
 
 // createIntegrationTestClient cria um cliente gRPC para testes de integração
@@ -253,11 +277,11 @@ func TestIntegration_Persistence(t *testing.T) {
 		}
 	}
 
-	// Fecha primeira sessão
-	cleanupIntegrationTestServer(t, its1)
+	// Fecha primeira sessão mantendo o banco
+	stopIntegrationTestServer(its1)
 
 	// Segunda sessão: verifica se dados persistem
-	its2 := setupIntegrationTestServer(t)
+	its2 := startIntegrationTestServer(t)
 	defer cleanupIntegrationTestServer(t, its2)
 
 	client2 := createIntegrationTestClient(t, its2.addr)
@@ -284,14 +308,17 @@ func TestIntegration_WatchMultipleClients(t *testing.T) {
 	client2 := createIntegrationTestClient(t, its.addr)
 
 	// Cria streams de watch para ambos os clientes
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
 	watchReq1 := &pb.WatchRequest{Key: "shared_key"}
-	stream1, err := client1.Watch(context.Background(), watchReq1)
+	stream1, err := client1.Watch(ctx, watchReq1)
 	if err != nil {
 		t.Fatalf("Watch() failed for client1: %v", err)
 	}
 
 	watchReq2 := &pb.WatchRequest{Key: "shared_key"}
-	stream2, err := client2.Watch(context.Background(), watchReq2)
+	stream2, err := client2.Watch(ctx, watchReq2)
 	if err != nil {
 		t.Fatalf("Watch() failed for client2: %v", err)
 	}
@@ -344,9 +371,9 @@ func TestIntegration_WatchMultipleClients(t *testing.T) {
 	// Aguarda notificações
 	time.Sleep(300 * time.Millisecond)
 
-	// Fecha streams
-	stream1.CloseSend()
-	stream2.CloseSend()
+	// Fecha streams: o CloseSend não encerra um stream do servidor, só o
+	// cancelamento do contexto faz o Watch retornar
+	cancel()
 
 	// Aguarda goroutines terminarem
 	<-done1
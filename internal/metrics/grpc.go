@@ -0,0 +1,81 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"path"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+)
+
+// ServerMetrics agrupa as métricas das RPCs do servidor
+type ServerMetrics struct {
+	Registry *prometheus.Registry
+
+	Requests *prometheus.CounterVec
+	Errors   *prometheus.CounterVec
+	Latency  *prometheus.HistogramVec
+}
+
+// NewServerMetrics cria e registra as métricas de RPC em um registry novo, e
+// não no global do client_golang, então cada servidor (e cada teste) registra
+// suas métricas uma única vez
+func NewServerMetrics() *ServerMetrics {
+	m := &ServerMetrics{
+		Registry: prometheus.NewRegistry(),
+		Requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "kvstore_requests_total",
+			Help: "Total number of RPCs received.",
+		}, []string{"method"}),
+		Errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "kvstore_request_errors_total",
+			Help: "Total number of RPCs that returned an error.",
+		}, []string{"method"}),
+		Latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "kvstore_request_duration_seconds",
+			Help:    "RPC latency in seconds.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"method"}),
+	}
+
+	m.Registry.MustRegister(m.Requests, m.Errors, m.Latency)
+
+	return m
+}
+
+// Handler serve as métricas registradas no /metrics
+func (m *ServerMetrics) Handler() http.Handler {
+	return Handler(m.Registry)
+}
+
+func (m *ServerMetrics) observe(fullMethod string, start time.Time, err error) {
+	method := path.Base(fullMethod)
+
+	m.Requests.WithLabelValues(method).Inc()
+	if err != nil {
+		m.Errors.WithLabelValues(method).Inc()
+	}
+	m.Latency.WithLabelValues(method).Observe(time.Since(start).Seconds())
+}
+
+// UnaryServerInterceptor conta e mede a latência das RPCs unárias
+func (m *ServerMetrics) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		m.observe(info.FullMethod, start, err)
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor conta e mede a duração das RPCs de streaming
+func (m *ServerMetrics) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, ss)
+		m.observe(info.FullMethod, start, err)
+		return err
+	}
+}
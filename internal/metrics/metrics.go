@@ -0,0 +1,15 @@
+// Package metrics expõe as métricas do servidor no formato do Prometheus,
+// usando o client_golang.
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Handler serve as métricas do registry no formato texto do Prometheus
+func Handler(r *prometheus.Registry) http.Handler {
+	return promhttp.HandlerFor(r, promhttp.HandlerOpts{Registry: r})
+}
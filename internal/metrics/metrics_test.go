@@ -0,0 +1,58 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+)
+
+func TestServerMetrics_Handler(t *testing.T) {
+	m := NewServerMetrics()
+	m.Registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{Name: "test_gauge", Help: "Test gauge."}, func() float64 { return 42 }))
+
+	interceptor := m.UnaryServerInterceptor()
+	ok := func(ctx context.Context, req any) (any, error) { return nil, nil }
+	fail := func(ctx context.Context, req any) (any, error) { return nil, errors.New("boom") }
+
+	interceptor(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/kvstore.KvStore/Put"}, ok)
+	interceptor(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/kvstore.KvStore/Put"}, fail)
+
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body, _ := io.ReadAll(rec.Body)
+
+	expected := []string{
+		"# TYPE kvstore_requests_total counter",
+		`kvstore_requests_total{method="Put"} 2`,
+		`kvstore_request_errors_total{method="Put"} 1`,
+		"# TYPE kvstore_request_duration_seconds histogram",
+		`kvstore_request_duration_seconds_bucket{method="Put",le="+Inf"} 2`,
+		`kvstore_request_duration_seconds_count{method="Put"} 2`,
+		"# TYPE test_gauge gauge",
+		"test_gauge 42",
+	}
+
+	for _, line := range expected {
+		if !strings.Contains(string(body), line) {
+			t.Errorf("/metrics missing %q, got:\n%s", line, body)
+		}
+	}
+}
+
+func TestServerMetrics_MustRegisterDuplicate(t *testing.T) {
+	m := NewServerMetrics()
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("MustRegister() should panic on duplicate metric name")
+		}
+	}()
+
+	m.Registry.MustRegister(prometheus.NewCounterVec(prometheus.CounterOpts{Name: "kvstore_requests_total", Help: "Duplicated counter."}, []string{"method"}))
+}
@@ -0,0 +1,44 @@
+// Package version guarda a identificação do build, injetada com -ldflags:
+//
+//	go build -ldflags "-X github.com/carvalhodanielg/kvstore/internal/version.Version=v1.2.0 \
+//	  -X github.com/carvalhodanielg/kvstore/internal/version.Commit=$(git rev-parse --short HEAD)" ./server
+package version
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+)
+
+var (
+	// Version é a versão do build; "dev" quando não foi injetada
+	Version = "dev"
+	// Commit é o commit do git do build. Sem -ldflags vem das informações de
+	// VCS que o go build grava no binário, quando existirem.
+	Commit = ""
+)
+
+// GetCommit retorna o Commit, ou "unknown" se ele não foi injetado nem gravado pelo go build
+func GetCommit() string {
+	if Commit != "" {
+		return Commit
+	}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range info.Settings {
+			if s.Key == "vcs.revision" {
+				return s.Value
+			}
+		}
+	}
+	return "unknown"
+}
+
+// GoVersion é a versão do Go usada no build
+func GoVersion() string {
+	return runtime.Version()
+}
+
+// String é a linha impressa pelo --version
+func String() string {
+	return fmt.Sprintf("kvstore %s (commit %s, %s)", Version, GetCommit(), GoVersion())
+}
@@ -21,6 +21,394 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+// o que o servidor faz quando o buffer do watcher enche
+type WatchPolicy int32
+
+const (
+	WatchPolicy_WATCH_POLICY_DROP_NEWEST WatchPolicy = 0
+	WatchPolicy_WATCH_POLICY_DROP_OLDEST WatchPolicy = 1
+	WatchPolicy_WATCH_POLICY_BLOCK       WatchPolicy = 2
+)
+
+// Enum value maps for WatchPolicy.
+var (
+	WatchPolicy_name = map[int32]string{
+		0: "WATCH_POLICY_DROP_NEWEST",
+		1: "WATCH_POLICY_DROP_OLDEST",
+		2: "WATCH_POLICY_BLOCK",
+	}
+	WatchPolicy_value = map[string]int32{
+		"WATCH_POLICY_DROP_NEWEST": 0,
+		"WATCH_POLICY_DROP_OLDEST": 1,
+		"WATCH_POLICY_BLOCK":       2,
+	}
+)
+
+func (x WatchPolicy) Enum() *WatchPolicy {
+	p := new(WatchPolicy)
+	*p = x
+	return p
+}
+
+func (x WatchPolicy) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (WatchPolicy) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_kvstore_proto_enumTypes[0].Descriptor()
+}
+
+func (WatchPolicy) Type() protoreflect.EnumType {
+	return &file_proto_kvstore_proto_enumTypes[0]
+}
+
+func (x WatchPolicy) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use WatchPolicy.Descriptor instead.
+func (WatchPolicy) EnumDescriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{0}
+}
+
+// clear e drop só chegam ao WatchAll, um por Clear ou DropNamespace
+type WatchEventType int32
+
+const (
+	WatchEventType_WATCH_EVENT_PUT    WatchEventType = 0
+	WatchEventType_WATCH_EVENT_DELETE WatchEventType = 1
+	WatchEventType_WATCH_EVENT_CLEAR  WatchEventType = 2
+	WatchEventType_WATCH_EVENT_DROP   WatchEventType = 3
+)
+
+// Enum value maps for WatchEventType.
+var (
+	WatchEventType_name = map[int32]string{
+		0: "WATCH_EVENT_PUT",
+		1: "WATCH_EVENT_DELETE",
+		2: "WATCH_EVENT_CLEAR",
+		3: "WATCH_EVENT_DROP",
+	}
+	WatchEventType_value = map[string]int32{
+		"WATCH_EVENT_PUT":    0,
+		"WATCH_EVENT_DELETE": 1,
+		"WATCH_EVENT_CLEAR":  2,
+		"WATCH_EVENT_DROP":   3,
+	}
+)
+
+func (x WatchEventType) Enum() *WatchEventType {
+	p := new(WatchEventType)
+	*p = x
+	return p
+}
+
+func (x WatchEventType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (WatchEventType) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_kvstore_proto_enumTypes[1].Descriptor()
+}
+
+func (WatchEventType) Type() protoreflect.EnumType {
+	return &file_proto_kvstore_proto_enumTypes[1]
+}
+
+func (x WatchEventType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use WatchEventType.Descriptor instead.
+func (WatchEventType) EnumDescriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{1}
+}
+
+type RepairSource int32
+
+const (
+	RepairSource_REPAIR_SOURCE_DB     RepairSource = 0
+	RepairSource_REPAIR_SOURCE_MEMORY RepairSource = 1
+)
+
+// Enum value maps for RepairSource.
+var (
+	RepairSource_name = map[int32]string{
+		0: "REPAIR_SOURCE_DB",
+		1: "REPAIR_SOURCE_MEMORY",
+	}
+	RepairSource_value = map[string]int32{
+		"REPAIR_SOURCE_DB":     0,
+		"REPAIR_SOURCE_MEMORY": 1,
+	}
+)
+
+func (x RepairSource) Enum() *RepairSource {
+	p := new(RepairSource)
+	*p = x
+	return p
+}
+
+func (x RepairSource) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (RepairSource) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_kvstore_proto_enumTypes[2].Descriptor()
+}
+
+func (RepairSource) Type() protoreflect.EnumType {
+	return &file_proto_kvstore_proto_enumTypes[2]
+}
+
+func (x RepairSource) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use RepairSource.Descriptor instead.
+func (RepairSource) EnumDescriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{2}
+}
+
+type DiscrepancyKind int32
+
+const (
+	DiscrepancyKind_DISCREPANCY_VALUE_MISMATCH    DiscrepancyKind = 0
+	DiscrepancyKind_DISCREPANCY_MISSING_IN_DB     DiscrepancyKind = 1
+	DiscrepancyKind_DISCREPANCY_MISSING_IN_MEMORY DiscrepancyKind = 2
+)
+
+// Enum value maps for DiscrepancyKind.
+var (
+	DiscrepancyKind_name = map[int32]string{
+		0: "DISCREPANCY_VALUE_MISMATCH",
+		1: "DISCREPANCY_MISSING_IN_DB",
+		2: "DISCREPANCY_MISSING_IN_MEMORY",
+	}
+	DiscrepancyKind_value = map[string]int32{
+		"DISCREPANCY_VALUE_MISMATCH":    0,
+		"DISCREPANCY_MISSING_IN_DB":     1,
+		"DISCREPANCY_MISSING_IN_MEMORY": 2,
+	}
+)
+
+func (x DiscrepancyKind) Enum() *DiscrepancyKind {
+	p := new(DiscrepancyKind)
+	*p = x
+	return p
+}
+
+func (x DiscrepancyKind) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (DiscrepancyKind) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_kvstore_proto_enumTypes[3].Descriptor()
+}
+
+func (DiscrepancyKind) Type() protoreflect.EnumType {
+	return &file_proto_kvstore_proto_enumTypes[3]
+}
+
+func (x DiscrepancyKind) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use DiscrepancyKind.Descriptor instead.
+func (DiscrepancyKind) EnumDescriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{3}
+}
+
+// EVENTUAL lê a memória local (rápido, pode estar atrasado em um follower);
+// LINEARIZABLE confirma a liderança via raft antes de ler;
+// REPLICA aceita ler de um follower com atraso limitado (exige --replica-read no servidor)
+type Consistency int32
+
+const (
+	Consistency_CONSISTENCY_EVENTUAL     Consistency = 0
+	Consistency_CONSISTENCY_LINEARIZABLE Consistency = 1
+	Consistency_CONSISTENCY_REPLICA      Consistency = 2
+)
+
+// Enum value maps for Consistency.
+var (
+	Consistency_name = map[int32]string{
+		0: "CONSISTENCY_EVENTUAL",
+		1: "CONSISTENCY_LINEARIZABLE",
+		2: "CONSISTENCY_REPLICA",
+	}
+	Consistency_value = map[string]int32{
+		"CONSISTENCY_EVENTUAL":     0,
+		"CONSISTENCY_LINEARIZABLE": 1,
+		"CONSISTENCY_REPLICA":      2,
+	}
+)
+
+func (x Consistency) Enum() *Consistency {
+	p := new(Consistency)
+	*p = x
+	return p
+}
+
+func (x Consistency) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Consistency) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_kvstore_proto_enumTypes[4].Descriptor()
+}
+
+func (Consistency) Type() protoreflect.EnumType {
+	return &file_proto_kvstore_proto_enumTypes[4]
+}
+
+func (x Consistency) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Consistency.Descriptor instead.
+func (Consistency) EnumDescriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{4}
+}
+
+type RestoreMode int32
+
+const (
+	RestoreMode_RESTORE_MERGE   RestoreMode = 0
+	RestoreMode_RESTORE_REPLACE RestoreMode = 1
+)
+
+// Enum value maps for RestoreMode.
+var (
+	RestoreMode_name = map[int32]string{
+		0: "RESTORE_MERGE",
+		1: "RESTORE_REPLACE",
+	}
+	RestoreMode_value = map[string]int32{
+		"RESTORE_MERGE":   0,
+		"RESTORE_REPLACE": 1,
+	}
+)
+
+func (x RestoreMode) Enum() *RestoreMode {
+	p := new(RestoreMode)
+	*p = x
+	return p
+}
+
+func (x RestoreMode) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (RestoreMode) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_kvstore_proto_enumTypes[5].Descriptor()
+}
+
+func (RestoreMode) Type() protoreflect.EnumType {
+	return &file_proto_kvstore_proto_enumTypes[5]
+}
+
+func (x RestoreMode) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use RestoreMode.Descriptor instead.
+func (RestoreMode) EnumDescriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{5}
+}
+
+type CompareTarget int32
+
+const (
+	CompareTarget_COMPARE_VALUE    CompareTarget = 0
+	CompareTarget_COMPARE_REVISION CompareTarget = 1
+)
+
+// Enum value maps for CompareTarget.
+var (
+	CompareTarget_name = map[int32]string{
+		0: "COMPARE_VALUE",
+		1: "COMPARE_REVISION",
+	}
+	CompareTarget_value = map[string]int32{
+		"COMPARE_VALUE":    0,
+		"COMPARE_REVISION": 1,
+	}
+)
+
+func (x CompareTarget) Enum() *CompareTarget {
+	p := new(CompareTarget)
+	*p = x
+	return p
+}
+
+func (x CompareTarget) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (CompareTarget) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_kvstore_proto_enumTypes[6].Descriptor()
+}
+
+func (CompareTarget) Type() protoreflect.EnumType {
+	return &file_proto_kvstore_proto_enumTypes[6]
+}
+
+func (x CompareTarget) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use CompareTarget.Descriptor instead.
+func (CompareTarget) EnumDescriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{6}
+}
+
+type TxnOpType int32
+
+const (
+	TxnOpType_TXN_PUT    TxnOpType = 0
+	TxnOpType_TXN_DELETE TxnOpType = 1
+)
+
+// Enum value maps for TxnOpType.
+var (
+	TxnOpType_name = map[int32]string{
+		0: "TXN_PUT",
+		1: "TXN_DELETE",
+	}
+	TxnOpType_value = map[string]int32{
+		"TXN_PUT":    0,
+		"TXN_DELETE": 1,
+	}
+)
+
+func (x TxnOpType) Enum() *TxnOpType {
+	p := new(TxnOpType)
+	*p = x
+	return p
+}
+
+func (x TxnOpType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (TxnOpType) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_kvstore_proto_enumTypes[7].Descriptor()
+}
+
+func (TxnOpType) Type() protoreflect.EnumType {
+	return &file_proto_kvstore_proto_enumTypes[7]
+}
+
+func (x TxnOpType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use TxnOpType.Descriptor instead.
+func (TxnOpType) EnumDescriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{7}
+}
+
 type HeartbeatRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	NodeId        string                 `protobuf:"bytes,1,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
@@ -59,43 +447,2963 @@ func (*HeartbeatRequest) Descriptor() ([]byte, []int) {
 	return file_proto_kvstore_proto_rawDescGZIP(), []int{0}
 }
 
-func (x *HeartbeatRequest) GetNodeId() string {
+func (x *HeartbeatRequest) GetNodeId() string {
+	if x != nil {
+		return x.NodeId
+	}
+	return ""
+}
+
+func (x *HeartbeatRequest) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+type HeartbeatResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Alive         bool                   `protobuf:"varint,1,opt,name=alive,proto3" json:"alive,omitempty"`
+	Timestamp     int64                  `protobuf:"varint,2,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HeartbeatResponse) Reset() {
+	*x = HeartbeatResponse{}
+	mi := &file_proto_kvstore_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HeartbeatResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HeartbeatResponse) ProtoMessage() {}
+
+func (x *HeartbeatResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_kvstore_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HeartbeatResponse.ProtoReflect.Descriptor instead.
+func (*HeartbeatResponse) Descriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *HeartbeatResponse) GetAlive() bool {
+	if x != nil {
+		return x.Alive
+	}
+	return false
+}
+
+func (x *HeartbeatResponse) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+type WatchRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Key   string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	//envia o valor atual como primeiro evento
+	SendInitialValue bool `protobuf:"varint,2,opt,name=send_initial_value,json=sendInitialValue,proto3" json:"send_initial_value,omitempty"`
+	//block não perde eventos, mas segura as escritas enquanto o cliente não lê
+	Policy        WatchPolicy `protobuf:"varint,3,opt,name=policy,proto3,enum=kvstore.WatchPolicy" json:"policy,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchRequest) Reset() {
+	*x = WatchRequest{}
+	mi := &file_proto_kvstore_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchRequest) ProtoMessage() {}
+
+func (x *WatchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_kvstore_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchRequest.ProtoReflect.Descriptor instead.
+func (*WatchRequest) Descriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *WatchRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *WatchRequest) GetSendInitialValue() bool {
+	if x != nil {
+		return x.SendInitialValue
+	}
+	return false
+}
+
+func (x *WatchRequest) GetPolicy() WatchPolicy {
+	if x != nil {
+		return x.Policy
+	}
+	return WatchPolicy_WATCH_POLICY_DROP_NEWEST
+}
+
+type WatchResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	//texto legível do evento, mantido enquanto os clientes migram para os campos abaixo
+	Message string         `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	Type    WatchEventType `protobuf:"varint,2,opt,name=type,proto3,enum=kvstore.WatchEventType" json:"type,omitempty"`
+	Key     string         `protobuf:"bytes,3,opt,name=key,proto3" json:"key,omitempty"`
+	//vazio em deletes
+	Value string `protobuf:"bytes,4,opt,name=value,proto3" json:"value,omitempty"`
+	//revisão gerada pela mudança; 0 fora do namespace padrão e no drop
+	Revision      uint64 `protobuf:"varint,5,opt,name=revision,proto3" json:"revision,omitempty"`
+	Namespace     string `protobuf:"bytes,6,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchResponse) Reset() {
+	*x = WatchResponse{}
+	mi := &file_proto_kvstore_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchResponse) ProtoMessage() {}
+
+func (x *WatchResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_kvstore_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchResponse.ProtoReflect.Descriptor instead.
+func (*WatchResponse) Descriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *WatchResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *WatchResponse) GetType() WatchEventType {
+	if x != nil {
+		return x.Type
+	}
+	return WatchEventType_WATCH_EVENT_PUT
+}
+
+func (x *WatchResponse) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *WatchResponse) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+func (x *WatchResponse) GetRevision() uint64 {
+	if x != nil {
+		return x.Revision
+	}
+	return 0
+}
+
+func (x *WatchResponse) GetNamespace() string {
+	if x != nil {
+		return x.Namespace
+	}
+	return ""
+}
+
+// recebe as escritas, deletes e clears de todas as chaves; com prefix só
+// chegam as escritas e deletes das chaves que começam com ele, além dos clears
+// e drops, que podem apagá-las
+type WatchAllRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Policy        WatchPolicy            `protobuf:"varint,1,opt,name=policy,proto3,enum=kvstore.WatchPolicy" json:"policy,omitempty"`
+	Prefix        string                 `protobuf:"bytes,2,opt,name=prefix,proto3" json:"prefix,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchAllRequest) Reset() {
+	*x = WatchAllRequest{}
+	mi := &file_proto_kvstore_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchAllRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchAllRequest) ProtoMessage() {}
+
+func (x *WatchAllRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_kvstore_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchAllRequest.ProtoReflect.Descriptor instead.
+func (*WatchAllRequest) Descriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *WatchAllRequest) GetPolicy() WatchPolicy {
+	if x != nil {
+		return x.Policy
+	}
+	return WatchPolicy_WATCH_POLICY_DROP_NEWEST
+}
+
+func (x *WatchAllRequest) GetPrefix() string {
+	if x != nil {
+		return x.Prefix
+	}
+	return ""
+}
+
+// trunca o WAL, tira um snapshot do raft e copia o bbolt para um arquivo
+// novo; com skip_db o banco não é copiado, só tem o tamanho reportado
+type CompactRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SkipDb        bool                   `protobuf:"varint,1,opt,name=skip_db,json=skipDb,proto3" json:"skip_db,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CompactRequest) Reset() {
+	*x = CompactRequest{}
+	mi := &file_proto_kvstore_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CompactRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CompactRequest) ProtoMessage() {}
+
+func (x *CompactRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_kvstore_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CompactRequest.ProtoReflect.Descriptor instead.
+func (*CompactRequest) Descriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *CompactRequest) GetSkipDb() bool {
+	if x != nil {
+		return x.SkipDb
+	}
+	return false
+}
+
+// em modo somente leitura as escritas voltam FailedPrecondition
+type SetReadOnlyRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ReadOnly      bool                   `protobuf:"varint,1,opt,name=read_only,json=readOnly,proto3" json:"read_only,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetReadOnlyRequest) Reset() {
+	*x = SetReadOnlyRequest{}
+	mi := &file_proto_kvstore_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetReadOnlyRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetReadOnlyRequest) ProtoMessage() {}
+
+func (x *SetReadOnlyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_kvstore_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetReadOnlyRequest.ProtoReflect.Descriptor instead.
+func (*SetReadOnlyRequest) Descriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *SetReadOnlyRequest) GetReadOnly() bool {
+	if x != nil {
+		return x.ReadOnly
+	}
+	return false
+}
+
+type SetReadOnlyResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ReadOnly      bool                   `protobuf:"varint,1,opt,name=read_only,json=readOnly,proto3" json:"read_only,omitempty"`
+	Previous      bool                   `protobuf:"varint,2,opt,name=previous,proto3" json:"previous,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetReadOnlyResponse) Reset() {
+	*x = SetReadOnlyResponse{}
+	mi := &file_proto_kvstore_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetReadOnlyResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetReadOnlyResponse) ProtoMessage() {}
+
+func (x *SetReadOnlyResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_kvstore_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetReadOnlyResponse.ProtoReflect.Descriptor instead.
+func (*SetReadOnlyResponse) Descriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *SetReadOnlyResponse) GetReadOnly() bool {
+	if x != nil {
+		return x.ReadOnly
+	}
+	return false
+}
+
+func (x *SetReadOnlyResponse) GetPrevious() bool {
+	if x != nil {
+		return x.Previous
+	}
+	return false
+}
+
+type ClusterInfoRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ClusterInfoRequest) Reset() {
+	*x = ClusterInfoRequest{}
+	mi := &file_proto_kvstore_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ClusterInfoRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ClusterInfoRequest) ProtoMessage() {}
+
+func (x *ClusterInfoRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_kvstore_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ClusterInfoRequest.ProtoReflect.Descriptor instead.
+func (*ClusterInfoRequest) Descriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{8}
+}
+
+// suffrage segue o raft: Voter, Nonvoter ou Staging
+type ClusterServer struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Address       string                 `protobuf:"bytes,2,opt,name=address,proto3" json:"address,omitempty"`
+	Suffrage      string                 `protobuf:"bytes,3,opt,name=suffrage,proto3" json:"suffrage,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ClusterServer) Reset() {
+	*x = ClusterServer{}
+	mi := &file_proto_kvstore_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ClusterServer) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ClusterServer) ProtoMessage() {}
+
+func (x *ClusterServer) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_kvstore_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ClusterServer.ProtoReflect.Descriptor instead.
+func (*ClusterServer) Descriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *ClusterServer) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *ClusterServer) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+func (x *ClusterServer) GetSuffrage() string {
+	if x != nil {
+		return x.Suffrage
+	}
+	return ""
+}
+
+type ClusterInfoResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Servers       []*ClusterServer       `protobuf:"bytes,1,rep,name=servers,proto3" json:"servers,omitempty"`
+	Term          uint64                 `protobuf:"varint,2,opt,name=term,proto3" json:"term,omitempty"`
+	LeaderId      string                 `protobuf:"bytes,3,opt,name=leader_id,json=leaderId,proto3" json:"leader_id,omitempty"`
+	LeaderAddress string                 `protobuf:"bytes,4,opt,name=leader_address,json=leaderAddress,proto3" json:"leader_address,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ClusterInfoResponse) Reset() {
+	*x = ClusterInfoResponse{}
+	mi := &file_proto_kvstore_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ClusterInfoResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ClusterInfoResponse) ProtoMessage() {}
+
+func (x *ClusterInfoResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_kvstore_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ClusterInfoResponse.ProtoReflect.Descriptor instead.
+func (*ClusterInfoResponse) Descriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *ClusterInfoResponse) GetServers() []*ClusterServer {
+	if x != nil {
+		return x.Servers
+	}
+	return nil
+}
+
+func (x *ClusterInfoResponse) GetTerm() uint64 {
+	if x != nil {
+		return x.Term
+	}
+	return 0
+}
+
+func (x *ClusterInfoResponse) GetLeaderId() string {
+	if x != nil {
+		return x.LeaderId
+	}
+	return ""
+}
+
+func (x *ClusterInfoResponse) GetLeaderAddress() string {
+	if x != nil {
+		return x.LeaderAddress
+	}
+	return ""
+}
+
+// target_id vazio deixa o raft escolher o follower mais atualizado
+type StepDownRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TargetId      string                 `protobuf:"bytes,1,opt,name=target_id,json=targetId,proto3" json:"target_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StepDownRequest) Reset() {
+	*x = StepDownRequest{}
+	mi := &file_proto_kvstore_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StepDownRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StepDownRequest) ProtoMessage() {}
+
+func (x *StepDownRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_kvstore_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StepDownRequest.ProtoReflect.Descriptor instead.
+func (*StepDownRequest) Descriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *StepDownRequest) GetTargetId() string {
+	if x != nil {
+		return x.TargetId
+	}
+	return ""
+}
+
+type StepDownResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StepDownResponse) Reset() {
+	*x = StepDownResponse{}
+	mi := &file_proto_kvstore_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StepDownResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StepDownResponse) ProtoMessage() {}
+
+func (x *StepDownResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_kvstore_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StepDownResponse.ProtoReflect.Descriptor instead.
+func (*StepDownResponse) Descriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{12}
+}
+
+// com repair as divergências são corrigidas copiando o lado source para o outro
+type VerifyRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Repair        bool                   `protobuf:"varint,1,opt,name=repair,proto3" json:"repair,omitempty"`
+	Source        RepairSource           `protobuf:"varint,2,opt,name=source,proto3,enum=kvstore.RepairSource" json:"source,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *VerifyRequest) Reset() {
+	*x = VerifyRequest{}
+	mi := &file_proto_kvstore_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *VerifyRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VerifyRequest) ProtoMessage() {}
+
+func (x *VerifyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_kvstore_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VerifyRequest.ProtoReflect.Descriptor instead.
+func (*VerifyRequest) Descriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *VerifyRequest) GetRepair() bool {
+	if x != nil {
+		return x.Repair
+	}
+	return false
+}
+
+func (x *VerifyRequest) GetSource() RepairSource {
+	if x != nil {
+		return x.Source
+	}
+	return RepairSource_REPAIR_SOURCE_DB
+}
+
+// o valor do lado onde a chave falta fica vazio
+type Discrepancy struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Kind          DiscrepancyKind        `protobuf:"varint,1,opt,name=kind,proto3,enum=kvstore.DiscrepancyKind" json:"kind,omitempty"`
+	Key           string                 `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
+	MemoryValue   string                 `protobuf:"bytes,3,opt,name=memory_value,json=memoryValue,proto3" json:"memory_value,omitempty"`
+	DbValue       string                 `protobuf:"bytes,4,opt,name=db_value,json=dbValue,proto3" json:"db_value,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Discrepancy) Reset() {
+	*x = Discrepancy{}
+	mi := &file_proto_kvstore_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Discrepancy) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Discrepancy) ProtoMessage() {}
+
+func (x *Discrepancy) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_kvstore_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Discrepancy.ProtoReflect.Descriptor instead.
+func (*Discrepancy) Descriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *Discrepancy) GetKind() DiscrepancyKind {
+	if x != nil {
+		return x.Kind
+	}
+	return DiscrepancyKind_DISCREPANCY_VALUE_MISMATCH
+}
+
+func (x *Discrepancy) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *Discrepancy) GetMemoryValue() string {
+	if x != nil {
+		return x.MemoryValue
+	}
+	return ""
+}
+
+func (x *Discrepancy) GetDbValue() string {
+	if x != nil {
+		return x.DbValue
+	}
+	return ""
+}
+
+// divergências entre a memória e o bbolt, ordenadas por chave; vazio se estiverem iguais.
+// Com repair são as que foram corrigidas
+type VerifyResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Discrepancies []*Discrepancy         `protobuf:"bytes,1,rep,name=discrepancies,proto3" json:"discrepancies,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *VerifyResponse) Reset() {
+	*x = VerifyResponse{}
+	mi := &file_proto_kvstore_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *VerifyResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VerifyResponse) ProtoMessage() {}
+
+func (x *VerifyResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_kvstore_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VerifyResponse.ProtoReflect.Descriptor instead.
+func (*VerifyResponse) Descriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *VerifyResponse) GetDiscrepancies() []*Discrepancy {
+	if x != nil {
+		return x.Discrepancies
+	}
+	return nil
+}
+
+type DBStatsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DBStatsRequest) Reset() {
+	*x = DBStatsRequest{}
+	mi := &file_proto_kvstore_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DBStatsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DBStatsRequest) ProtoMessage() {}
+
+func (x *DBStatsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_kvstore_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DBStatsRequest.ProtoReflect.Descriptor instead.
+func (*DBStatsRequest) Descriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{16}
+}
+
+// métricas do bbolt; os campos de páginas ficam zerados em outros backends
+type DBStatsResponse struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	FileSize int64                  `protobuf:"varint,1,opt,name=file_size,json=fileSize,proto3" json:"file_size,omitempty"`
+	PageSize int64                  `protobuf:"varint,2,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	//free_pages podem ser reusadas; pending_pages ainda são vistas por leituras abertas
+	FreePages    int64 `protobuf:"varint,3,opt,name=free_pages,json=freePages,proto3" json:"free_pages,omitempty"`
+	PendingPages int64 `protobuf:"varint,4,opt,name=pending_pages,json=pendingPages,proto3" json:"pending_pages,omitempty"`
+	FreeBytes    int64 `protobuf:"varint,5,opt,name=free_bytes,json=freeBytes,proto3" json:"free_bytes,omitempty"`
+	OpenReadTxs  int64 `protobuf:"varint,6,opt,name=open_read_txs,json=openReadTxs,proto3" json:"open_read_txs,omitempty"`
+	//páginas do bucket das chaves do namespace padrão
+	LeafPages   int64 `protobuf:"varint,7,opt,name=leaf_pages,json=leafPages,proto3" json:"leaf_pages,omitempty"`
+	BranchPages int64 `protobuf:"varint,8,opt,name=branch_pages,json=branchPages,proto3" json:"branch_pages,omitempty"`
+	LeafInuse   int64 `protobuf:"varint,9,opt,name=leaf_inuse,json=leafInuse,proto3" json:"leaf_inuse,omitempty"`
+	BranchInuse int64 `protobuf:"varint,10,opt,name=branch_inuse,json=branchInuse,proto3" json:"branch_inuse,omitempty"`
+	//bytes em uso sobre os alocados nessas páginas, entre 0 e 1
+	Utilization float64 `protobuf:"fixed64,11,opt,name=utilization,proto3" json:"utilization,omitempty"`
+	//chaves do namespace padrão no banco e em memória, para detectar divergência
+	DbKeys        int64 `protobuf:"varint,12,opt,name=db_keys,json=dbKeys,proto3" json:"db_keys,omitempty"`
+	MemoryKeys    int64 `protobuf:"varint,13,opt,name=memory_keys,json=memoryKeys,proto3" json:"memory_keys,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DBStatsResponse) Reset() {
+	*x = DBStatsResponse{}
+	mi := &file_proto_kvstore_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DBStatsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DBStatsResponse) ProtoMessage() {}
+
+func (x *DBStatsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_kvstore_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DBStatsResponse.ProtoReflect.Descriptor instead.
+func (*DBStatsResponse) Descriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *DBStatsResponse) GetFileSize() int64 {
+	if x != nil {
+		return x.FileSize
+	}
+	return 0
+}
+
+func (x *DBStatsResponse) GetPageSize() int64 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *DBStatsResponse) GetFreePages() int64 {
+	if x != nil {
+		return x.FreePages
+	}
+	return 0
+}
+
+func (x *DBStatsResponse) GetPendingPages() int64 {
+	if x != nil {
+		return x.PendingPages
+	}
+	return 0
+}
+
+func (x *DBStatsResponse) GetFreeBytes() int64 {
+	if x != nil {
+		return x.FreeBytes
+	}
+	return 0
+}
+
+func (x *DBStatsResponse) GetOpenReadTxs() int64 {
+	if x != nil {
+		return x.OpenReadTxs
+	}
+	return 0
+}
+
+func (x *DBStatsResponse) GetLeafPages() int64 {
+	if x != nil {
+		return x.LeafPages
+	}
+	return 0
+}
+
+func (x *DBStatsResponse) GetBranchPages() int64 {
+	if x != nil {
+		return x.BranchPages
+	}
+	return 0
+}
+
+func (x *DBStatsResponse) GetLeafInuse() int64 {
+	if x != nil {
+		return x.LeafInuse
+	}
+	return 0
+}
+
+func (x *DBStatsResponse) GetBranchInuse() int64 {
+	if x != nil {
+		return x.BranchInuse
+	}
+	return 0
+}
+
+func (x *DBStatsResponse) GetUtilization() float64 {
+	if x != nil {
+		return x.Utilization
+	}
+	return 0
+}
+
+func (x *DBStatsResponse) GetDbKeys() int64 {
+	if x != nil {
+		return x.DbKeys
+	}
+	return 0
+}
+
+func (x *DBStatsResponse) GetMemoryKeys() int64 {
+	if x != nil {
+		return x.MemoryKeys
+	}
+	return 0
+}
+
+// tamanhos em bytes
+type CompactResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	DbSizeBefore  int64                  `protobuf:"varint,1,opt,name=db_size_before,json=dbSizeBefore,proto3" json:"db_size_before,omitempty"`
+	DbSizeAfter   int64                  `protobuf:"varint,2,opt,name=db_size_after,json=dbSizeAfter,proto3" json:"db_size_after,omitempty"`
+	WalSizeBefore int64                  `protobuf:"varint,3,opt,name=wal_size_before,json=walSizeBefore,proto3" json:"wal_size_before,omitempty"`
+	WalSizeAfter  int64                  `protobuf:"varint,4,opt,name=wal_size_after,json=walSizeAfter,proto3" json:"wal_size_after,omitempty"`
+	SnapshotTaken bool                   `protobuf:"varint,5,opt,name=snapshot_taken,json=snapshotTaken,proto3" json:"snapshot_taken,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CompactResponse) Reset() {
+	*x = CompactResponse{}
+	mi := &file_proto_kvstore_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CompactResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CompactResponse) ProtoMessage() {}
+
+func (x *CompactResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_kvstore_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CompactResponse.ProtoReflect.Descriptor instead.
+func (*CompactResponse) Descriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *CompactResponse) GetDbSizeBefore() int64 {
+	if x != nil {
+		return x.DbSizeBefore
+	}
+	return 0
+}
+
+func (x *CompactResponse) GetDbSizeAfter() int64 {
+	if x != nil {
+		return x.DbSizeAfter
+	}
+	return 0
+}
+
+func (x *CompactResponse) GetWalSizeBefore() int64 {
+	if x != nil {
+		return x.WalSizeBefore
+	}
+	return 0
+}
+
+func (x *CompactResponse) GetWalSizeAfter() int64 {
+	if x != nil {
+		return x.WalSizeAfter
+	}
+	return 0
+}
+
+func (x *CompactResponse) GetSnapshotTaken() bool {
+	if x != nil {
+		return x.SnapshotTaken
+	}
+	return false
+}
+
+// response é vazia
+// com sorted a resposta vem em sorted_values, ordenada por chave, e values fica vazio
+type GetAllRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Sorted        bool                   `protobuf:"varint,1,opt,name=sorted,proto3" json:"sorted,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetAllRequest) Reset() {
+	*x = GetAllRequest{}
+	mi := &file_proto_kvstore_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetAllRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetAllRequest) ProtoMessage() {}
+
+func (x *GetAllRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_kvstore_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetAllRequest.ProtoReflect.Descriptor instead.
+func (*GetAllRequest) Descriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *GetAllRequest) GetSorted() bool {
+	if x != nil {
+		return x.Sorted
+	}
+	return false
+}
+
+type GetAllResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Values        map[string]string      `protobuf:"bytes,1,rep,name=values,proto3" json:"values,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	SortedValues  []*KeyValue            `protobuf:"bytes,2,rep,name=sorted_values,json=sortedValues,proto3" json:"sorted_values,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetAllResponse) Reset() {
+	*x = GetAllResponse{}
+	mi := &file_proto_kvstore_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetAllResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetAllResponse) ProtoMessage() {}
+
+func (x *GetAllResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_kvstore_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetAllResponse.ProtoReflect.Descriptor instead.
+func (*GetAllResponse) Descriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *GetAllResponse) GetValues() map[string]string {
+	if x != nil {
+		return x.Values
+	}
+	return nil
+}
+
+func (x *GetAllResponse) GetSortedValues() []*KeyValue {
+	if x != nil {
+		return x.SortedValues
+	}
+	return nil
+}
+
+type DeleteRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteRequest) Reset() {
+	*x = DeleteRequest{}
+	mi := &file_proto_kvstore_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteRequest) ProtoMessage() {}
+
+func (x *DeleteRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_kvstore_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteRequest.ProtoReflect.Descriptor instead.
+func (*DeleteRequest) Descriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *DeleteRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+type DeleteResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteResponse) Reset() {
+	*x = DeleteResponse{}
+	mi := &file_proto_kvstore_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteResponse) ProtoMessage() {}
+
+func (x *DeleteResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_kvstore_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteResponse.ProtoReflect.Descriptor instead.
+func (*DeleteResponse) Descriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *DeleteResponse) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+// apaga as chaves numa única transação; as inexistentes são ignoradas
+type DeleteManyRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Keys          []string               `protobuf:"bytes,1,rep,name=keys,proto3" json:"keys,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteManyRequest) Reset() {
+	*x = DeleteManyRequest{}
+	mi := &file_proto_kvstore_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteManyRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteManyRequest) ProtoMessage() {}
+
+func (x *DeleteManyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_kvstore_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteManyRequest.ProtoReflect.Descriptor instead.
+func (*DeleteManyRequest) Descriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *DeleteManyRequest) GetKeys() []string {
+	if x != nil {
+		return x.Keys
+	}
+	return nil
+}
+
+// deleted é quantas das chaves existiam
+type DeleteManyResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Deleted       int64                  `protobuf:"varint,1,opt,name=deleted,proto3" json:"deleted,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteManyResponse) Reset() {
+	*x = DeleteManyResponse{}
+	mi := &file_proto_kvstore_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteManyResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteManyResponse) ProtoMessage() {}
+
+func (x *DeleteManyResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_kvstore_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteManyResponse.ProtoReflect.Descriptor instead.
+func (*DeleteManyResponse) Descriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *DeleteManyResponse) GetDeleted() int64 {
+	if x != nil {
+		return x.Deleted
+	}
+	return 0
+}
+
+type PutRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Key   string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Value string                 `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	//valor binário, que não precisa ser UTF-8 válido; quando preenchido substitui value
+	ValueBytes []byte `protobuf:"bytes,3,opt,name=value_bytes,json=valueBytes,proto3" json:"value_bytes,omitempty"`
+	//um retry com a mesma chave devolve o resultado da primeira requisição sem
+	//aplicá-la de novo (Put e PutIfAbsent, enquanto o servidor lembrar da chave)
+	IdempotencyKey string `protobuf:"bytes,4,opt,name=idempotency_key,json=idempotencyKey,proto3" json:"idempotency_key,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *PutRequest) Reset() {
+	*x = PutRequest{}
+	mi := &file_proto_kvstore_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PutRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PutRequest) ProtoMessage() {}
+
+func (x *PutRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_kvstore_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PutRequest.ProtoReflect.Descriptor instead.
+func (*PutRequest) Descriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *PutRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *PutRequest) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+func (x *PutRequest) GetValueBytes() []byte {
+	if x != nil {
+		return x.ValueBytes
+	}
+	return nil
+}
+
+func (x *PutRequest) GetIdempotencyKey() string {
+	if x != nil {
+		return x.IdempotencyKey
+	}
+	return ""
+}
+
+type PutResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PutResponse) Reset() {
+	*x = PutResponse{}
+	mi := &file_proto_kvstore_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PutResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PutResponse) ProtoMessage() {}
+
+func (x *PutResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_kvstore_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PutResponse.ProtoReflect.Descriptor instead.
+func (*PutResponse) Descriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *PutResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+// stored é false quando a chave já existia e nada foi gravado
+type PutIfAbsentResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Stored        bool                   `protobuf:"varint,1,opt,name=stored,proto3" json:"stored,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PutIfAbsentResponse) Reset() {
+	*x = PutIfAbsentResponse{}
+	mi := &file_proto_kvstore_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PutIfAbsentResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PutIfAbsentResponse) ProtoMessage() {}
+
+func (x *PutIfAbsentResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_kvstore_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PutIfAbsentResponse.ProtoReflect.Descriptor instead.
+func (*PutIfAbsentResponse) Descriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *PutIfAbsentResponse) GetStored() bool {
+	if x != nil {
+		return x.Stored
+	}
+	return false
+}
+
+type GetRequest struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Key         string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Consistency Consistency            `protobuf:"varint,2,opt,name=consistency,proto3,enum=kvstore.Consistency" json:"consistency,omitempty"`
+	//com REPLICA, recusa a leitura se o follower estiver mais atrasado que isso (0 aceita qualquer atraso)
+	MaxStalenessMs int64 `protobuf:"varint,3,opt,name=max_staleness_ms,json=maxStalenessMs,proto3" json:"max_staleness_ms,omitempty"`
+	//devolve o valor em value_bytes em vez de value
+	Binary        bool `protobuf:"varint,4,opt,name=binary,proto3" json:"binary,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetRequest) Reset() {
+	*x = GetRequest{}
+	mi := &file_proto_kvstore_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetRequest) ProtoMessage() {}
+
+func (x *GetRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_kvstore_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetRequest.ProtoReflect.Descriptor instead.
+func (*GetRequest) Descriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *GetRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *GetRequest) GetConsistency() Consistency {
+	if x != nil {
+		return x.Consistency
+	}
+	return Consistency_CONSISTENCY_EVENTUAL
+}
+
+func (x *GetRequest) GetMaxStalenessMs() int64 {
+	if x != nil {
+		return x.MaxStalenessMs
+	}
+	return 0
+}
+
+func (x *GetRequest) GetBinary() bool {
+	if x != nil {
+		return x.Binary
+	}
+	return false
+}
+
+type GetResponse struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Key      string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Value    string                 `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	Revision uint64                 `protobuf:"varint,3,opt,name=revision,proto3" json:"revision,omitempty"`
+	//com REPLICA, o atraso estimado do nó que respondeu (0 no líder)
+	StalenessMs int64 `protobuf:"varint,4,opt,name=staleness_ms,json=stalenessMs,proto3" json:"staleness_ms,omitempty"`
+	//criação e última escrita da chave em unix nanos; 0 se a chave não existe
+	//ou foi gravada antes desses metadados
+	CreatedAt int64 `protobuf:"varint,5,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt int64 `protobuf:"varint,6,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	//o valor vai aqui, com value vazio, quando o GetRequest pede binary ou
+	//quando ele não é UTF-8 válido (um campo string não pode levá-lo)
+	ValueBytes    []byte `protobuf:"bytes,7,opt,name=value_bytes,json=valueBytes,proto3" json:"value_bytes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetResponse) Reset() {
+	*x = GetResponse{}
+	mi := &file_proto_kvstore_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetResponse) ProtoMessage() {}
+
+func (x *GetResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_kvstore_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetResponse.ProtoReflect.Descriptor instead.
+func (*GetResponse) Descriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *GetResponse) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *GetResponse) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+func (x *GetResponse) GetRevision() uint64 {
+	if x != nil {
+		return x.Revision
+	}
+	return 0
+}
+
+func (x *GetResponse) GetStalenessMs() int64 {
+	if x != nil {
+		return x.StalenessMs
+	}
+	return 0
+}
+
+func (x *GetResponse) GetCreatedAt() int64 {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return 0
+}
+
+func (x *GetResponse) GetUpdatedAt() int64 {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return 0
+}
+
+func (x *GetResponse) GetValueBytes() []byte {
+	if x != nil {
+		return x.ValueBytes
+	}
+	return nil
+}
+
+// expected_revision 0 exige que a chave não exista
+type PutIfVersionRequest struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Key              string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Value            string                 `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	ExpectedRevision uint64                 `protobuf:"varint,3,opt,name=expected_revision,json=expectedRevision,proto3" json:"expected_revision,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *PutIfVersionRequest) Reset() {
+	*x = PutIfVersionRequest{}
+	mi := &file_proto_kvstore_proto_msgTypes[30]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PutIfVersionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PutIfVersionRequest) ProtoMessage() {}
+
+func (x *PutIfVersionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_kvstore_proto_msgTypes[30]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PutIfVersionRequest.ProtoReflect.Descriptor instead.
+func (*PutIfVersionRequest) Descriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *PutIfVersionRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *PutIfVersionRequest) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+func (x *PutIfVersionRequest) GetExpectedRevision() uint64 {
+	if x != nil {
+		return x.ExpectedRevision
+	}
+	return 0
+}
+
+type PutIfVersionResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Revision      uint64                 `protobuf:"varint,1,opt,name=revision,proto3" json:"revision,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PutIfVersionResponse) Reset() {
+	*x = PutIfVersionResponse{}
+	mi := &file_proto_kvstore_proto_msgTypes[31]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PutIfVersionResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PutIfVersionResponse) ProtoMessage() {}
+
+func (x *PutIfVersionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_kvstore_proto_msgTypes[31]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PutIfVersionResponse.ProtoReflect.Descriptor instead.
+func (*PutIfVersionResponse) Descriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *PutIfVersionResponse) GetRevision() uint64 {
+	if x != nil {
+		return x.Revision
+	}
+	return 0
+}
+
+type MultiGetRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Keys          []string               `protobuf:"bytes,1,rep,name=keys,proto3" json:"keys,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MultiGetRequest) Reset() {
+	*x = MultiGetRequest{}
+	mi := &file_proto_kvstore_proto_msgTypes[32]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MultiGetRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MultiGetRequest) ProtoMessage() {}
+
+func (x *MultiGetRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_kvstore_proto_msgTypes[32]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MultiGetRequest.ProtoReflect.Descriptor instead.
+func (*MultiGetRequest) Descriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{32}
+}
+
+func (x *MultiGetRequest) GetKeys() []string {
+	if x != nil {
+		return x.Keys
+	}
+	return nil
+}
+
+// found é false quando a chave não existe, diferente de uma chave com valor vazio.
+// No GetAllStream as chaves chegam em ordem e found é sempre true.
+type KeyValue struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Value         string                 `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	Found         bool                   `protobuf:"varint,3,opt,name=found,proto3" json:"found,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KeyValue) Reset() {
+	*x = KeyValue{}
+	mi := &file_proto_kvstore_proto_msgTypes[33]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KeyValue) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KeyValue) ProtoMessage() {}
+
+func (x *KeyValue) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_kvstore_proto_msgTypes[33]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KeyValue.ProtoReflect.Descriptor instead.
+func (*KeyValue) Descriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{33}
+}
+
+func (x *KeyValue) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *KeyValue) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+func (x *KeyValue) GetFound() bool {
+	if x != nil {
+		return x.Found
+	}
+	return false
+}
+
+// um item por chave distinta, na ordem em que apareceram no request
+type MultiGetResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Values        []*KeyValue            `protobuf:"bytes,1,rep,name=values,proto3" json:"values,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MultiGetResponse) Reset() {
+	*x = MultiGetResponse{}
+	mi := &file_proto_kvstore_proto_msgTypes[34]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MultiGetResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MultiGetResponse) ProtoMessage() {}
+
+func (x *MultiGetResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_kvstore_proto_msgTypes[34]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MultiGetResponse.ProtoReflect.Descriptor instead.
+func (*MultiGetResponse) Descriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{34}
+}
+
+func (x *MultiGetResponse) GetValues() []*KeyValue {
+	if x != nil {
+		return x.Values
+	}
+	return nil
+}
+
+type BackupRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BackupRequest) Reset() {
+	*x = BackupRequest{}
+	mi := &file_proto_kvstore_proto_msgTypes[35]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BackupRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BackupRequest) ProtoMessage() {}
+
+func (x *BackupRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_kvstore_proto_msgTypes[35]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BackupRequest.ProtoReflect.Descriptor instead.
+func (*BackupRequest) Descriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{35}
+}
+
+type BackupResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Value         string                 `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BackupResponse) Reset() {
+	*x = BackupResponse{}
+	mi := &file_proto_kvstore_proto_msgTypes[36]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BackupResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BackupResponse) ProtoMessage() {}
+
+func (x *BackupResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_kvstore_proto_msgTypes[36]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BackupResponse.ProtoReflect.Descriptor instead.
+func (*BackupResponse) Descriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{36}
+}
+
+func (x *BackupResponse) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *BackupResponse) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+// o mode e o dry_run são lidos apenas da primeira mensagem do stream
+type RestoreRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Key   string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Value string                 `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	Mode  RestoreMode            `protobuf:"varint,3,opt,name=mode,proto3,enum=kvstore.RestoreMode" json:"mode,omitempty"`
+	//dry_run só valida os pares e devolve o resumo, sem gravar nada
+	DryRun        bool `protobuf:"varint,4,opt,name=dry_run,json=dryRun,proto3" json:"dry_run,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RestoreRequest) Reset() {
+	*x = RestoreRequest{}
+	mi := &file_proto_kvstore_proto_msgTypes[37]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RestoreRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RestoreRequest) ProtoMessage() {}
+
+func (x *RestoreRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_kvstore_proto_msgTypes[37]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RestoreRequest.ProtoReflect.Descriptor instead.
+func (*RestoreRequest) Descriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{37}
+}
+
+func (x *RestoreRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *RestoreRequest) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+func (x *RestoreRequest) GetMode() RestoreMode {
+	if x != nil {
+		return x.Mode
+	}
+	return RestoreMode_RESTORE_MERGE
+}
+
+func (x *RestoreRequest) GetDryRun() bool {
+	if x != nil {
+		return x.DryRun
+	}
+	return false
+}
+
+// checked, bytes, duplicates, invalid e errors só são preenchidos no dry_run
+type RestoreResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Restored      int64                  `protobuf:"varint,1,opt,name=restored,proto3" json:"restored,omitempty"`
+	Checked       int64                  `protobuf:"varint,2,opt,name=checked,proto3" json:"checked,omitempty"`
+	Bytes         int64                  `protobuf:"varint,3,opt,name=bytes,proto3" json:"bytes,omitempty"`
+	Duplicates    int64                  `protobuf:"varint,4,opt,name=duplicates,proto3" json:"duplicates,omitempty"`
+	Invalid       int64                  `protobuf:"varint,5,opt,name=invalid,proto3" json:"invalid,omitempty"`
+	Errors        []string               `protobuf:"bytes,6,rep,name=errors,proto3" json:"errors,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RestoreResponse) Reset() {
+	*x = RestoreResponse{}
+	mi := &file_proto_kvstore_proto_msgTypes[38]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RestoreResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RestoreResponse) ProtoMessage() {}
+
+func (x *RestoreResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_kvstore_proto_msgTypes[38]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RestoreResponse.ProtoReflect.Descriptor instead.
+func (*RestoreResponse) Descriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{38}
+}
+
+func (x *RestoreResponse) GetRestored() int64 {
+	if x != nil {
+		return x.Restored
+	}
+	return 0
+}
+
+func (x *RestoreResponse) GetChecked() int64 {
+	if x != nil {
+		return x.Checked
+	}
+	return 0
+}
+
+func (x *RestoreResponse) GetBytes() int64 {
+	if x != nil {
+		return x.Bytes
+	}
+	return 0
+}
+
+func (x *RestoreResponse) GetDuplicates() int64 {
+	if x != nil {
+		return x.Duplicates
+	}
+	return 0
+}
+
+func (x *RestoreResponse) GetInvalid() int64 {
+	if x != nil {
+		return x.Invalid
+	}
+	return 0
+}
+
+func (x *RestoreResponse) GetErrors() []string {
+	if x != nil {
+		return x.Errors
+	}
+	return nil
+}
+
+// count é quantos pares foram gravados
+type BulkPutResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Count         int64                  `protobuf:"varint,1,opt,name=count,proto3" json:"count,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BulkPutResponse) Reset() {
+	*x = BulkPutResponse{}
+	mi := &file_proto_kvstore_proto_msgTypes[39]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BulkPutResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BulkPutResponse) ProtoMessage() {}
+
+func (x *BulkPutResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_kvstore_proto_msgTypes[39]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BulkPutResponse.ProtoReflect.Descriptor instead.
+func (*BulkPutResponse) Descriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{39}
+}
+
+func (x *BulkPutResponse) GetCount() int64 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+type StatusRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StatusRequest) Reset() {
+	*x = StatusRequest{}
+	mi := &file_proto_kvstore_proto_msgTypes[40]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StatusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatusRequest) ProtoMessage() {}
+
+func (x *StatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_kvstore_proto_msgTypes[40]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatusRequest.ProtoReflect.Descriptor instead.
+func (*StatusRequest) Descriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{40}
+}
+
+type StatusResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	NodeId        string                 `protobuf:"bytes,1,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
+	State         string                 `protobuf:"bytes,2,opt,name=state,proto3" json:"state,omitempty"`
+	LeaderAddress string                 `protobuf:"bytes,3,opt,name=leader_address,json=leaderAddress,proto3" json:"leader_address,omitempty"`
+	LeaderId      string                 `protobuf:"bytes,4,opt,name=leader_id,json=leaderId,proto3" json:"leader_id,omitempty"`
+	Keys          int64                  `protobuf:"varint,5,opt,name=keys,proto3" json:"keys,omitempty"`
+	WalSize       int64                  `protobuf:"varint,6,opt,name=wal_size,json=walSize,proto3" json:"wal_size,omitempty"`
+	Peers         []*PeerStatus          `protobuf:"bytes,7,rep,name=peers,proto3" json:"peers,omitempty"`
+	ReadOnly      bool                   `protobuf:"varint,8,opt,name=read_only,json=readOnly,proto3" json:"read_only,omitempty"`
+	//eventos descartados por buffers de watcher cheios desde o start, e os
+	//que estão nos buffers esperando ser lidos
+	WatchEventsDropped uint64 `protobuf:"varint,9,opt,name=watch_events_dropped,json=watchEventsDropped,proto3" json:"watch_events_dropped,omitempty"`
+	WatchQueueDepth    int64  `protobuf:"varint,10,opt,name=watch_queue_depth,json=watchQueueDepth,proto3" json:"watch_queue_depth,omitempty"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *StatusResponse) Reset() {
+	*x = StatusResponse{}
+	mi := &file_proto_kvstore_proto_msgTypes[41]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StatusResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatusResponse) ProtoMessage() {}
+
+func (x *StatusResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_kvstore_proto_msgTypes[41]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatusResponse.ProtoReflect.Descriptor instead.
+func (*StatusResponse) Descriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{41}
+}
+
+func (x *StatusResponse) GetNodeId() string {
+	if x != nil {
+		return x.NodeId
+	}
+	return ""
+}
+
+func (x *StatusResponse) GetState() string {
+	if x != nil {
+		return x.State
+	}
+	return ""
+}
+
+func (x *StatusResponse) GetLeaderAddress() string {
+	if x != nil {
+		return x.LeaderAddress
+	}
+	return ""
+}
+
+func (x *StatusResponse) GetLeaderId() string {
+	if x != nil {
+		return x.LeaderId
+	}
+	return ""
+}
+
+func (x *StatusResponse) GetKeys() int64 {
+	if x != nil {
+		return x.Keys
+	}
+	return 0
+}
+
+func (x *StatusResponse) GetWalSize() int64 {
+	if x != nil {
+		return x.WalSize
+	}
+	return 0
+}
+
+func (x *StatusResponse) GetPeers() []*PeerStatus {
+	if x != nil {
+		return x.Peers
+	}
+	return nil
+}
+
+func (x *StatusResponse) GetReadOnly() bool {
+	if x != nil {
+		return x.ReadOnly
+	}
+	return false
+}
+
+func (x *StatusResponse) GetWatchEventsDropped() uint64 {
+	if x != nil {
+		return x.WatchEventsDropped
+	}
+	return 0
+}
+
+func (x *StatusResponse) GetWatchQueueDepth() int64 {
+	if x != nil {
+		return x.WatchQueueDepth
+	}
+	return 0
+}
+
+// last_seen é unix em segundos, 0 se o peer nunca respondeu um heartbeat
+type PeerStatus struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Address       string                 `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+	Alive         bool                   `protobuf:"varint,2,opt,name=alive,proto3" json:"alive,omitempty"`
+	LastSeen      int64                  `protobuf:"varint,3,opt,name=last_seen,json=lastSeen,proto3" json:"last_seen,omitempty"`
+	LastError     string                 `protobuf:"bytes,4,opt,name=last_error,json=lastError,proto3" json:"last_error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PeerStatus) Reset() {
+	*x = PeerStatus{}
+	mi := &file_proto_kvstore_proto_msgTypes[42]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PeerStatus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PeerStatus) ProtoMessage() {}
+
+func (x *PeerStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_kvstore_proto_msgTypes[42]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PeerStatus.ProtoReflect.Descriptor instead.
+func (*PeerStatus) Descriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{42}
+}
+
+func (x *PeerStatus) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+func (x *PeerStatus) GetAlive() bool {
+	if x != nil {
+		return x.Alive
+	}
+	return false
+}
+
+func (x *PeerStatus) GetLastSeen() int64 {
+	if x != nil {
+		return x.LastSeen
+	}
+	return 0
+}
+
+func (x *PeerStatus) GetLastError() string {
+	if x != nil {
+		return x.LastError
+	}
+	return ""
+}
+
+// prefix vazio conta todas as chaves
+type CountRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Prefix        string                 `protobuf:"bytes,1,opt,name=prefix,proto3" json:"prefix,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CountRequest) Reset() {
+	*x = CountRequest{}
+	mi := &file_proto_kvstore_proto_msgTypes[43]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CountRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CountRequest) ProtoMessage() {}
+
+func (x *CountRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_kvstore_proto_msgTypes[43]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CountRequest.ProtoReflect.Descriptor instead.
+func (*CountRequest) Descriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{43}
+}
+
+func (x *CountRequest) GetPrefix() string {
+	if x != nil {
+		return x.Prefix
+	}
+	return ""
+}
+
+type CountResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Count         int64                  `protobuf:"varint,1,opt,name=count,proto3" json:"count,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CountResponse) Reset() {
+	*x = CountResponse{}
+	mi := &file_proto_kvstore_proto_msgTypes[44]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CountResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CountResponse) ProtoMessage() {}
+
+func (x *CountResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_kvstore_proto_msgTypes[44]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CountResponse.ProtoReflect.Descriptor instead.
+func (*CountResponse) Descriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{44}
+}
+
+func (x *CountResponse) GetCount() int64 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+// lista só os nomes das chaves, ordenados; prefix vazio lista todas
+type KeysRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Prefix        string                 `protobuf:"bytes,1,opt,name=prefix,proto3" json:"prefix,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KeysRequest) Reset() {
+	*x = KeysRequest{}
+	mi := &file_proto_kvstore_proto_msgTypes[45]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KeysRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KeysRequest) ProtoMessage() {}
+
+func (x *KeysRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_kvstore_proto_msgTypes[45]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KeysRequest.ProtoReflect.Descriptor instead.
+func (*KeysRequest) Descriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{45}
+}
+
+func (x *KeysRequest) GetPrefix() string {
+	if x != nil {
+		return x.Prefix
+	}
+	return ""
+}
+
+type KeysResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Keys          []string               `protobuf:"bytes,1,rep,name=keys,proto3" json:"keys,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KeysResponse) Reset() {
+	*x = KeysResponse{}
+	mi := &file_proto_kvstore_proto_msgTypes[46]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KeysResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KeysResponse) ProtoMessage() {}
+
+func (x *KeysResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_kvstore_proto_msgTypes[46]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KeysResponse.ProtoReflect.Descriptor instead.
+func (*KeysResponse) Descriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{46}
+}
+
+func (x *KeysResponse) GetKeys() []string {
+	if x != nil {
+		return x.Keys
+	}
+	return nil
+}
+
+type MultiScanRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Prefixes      []string               `protobuf:"bytes,1,rep,name=prefixes,proto3" json:"prefixes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MultiScanRequest) Reset() {
+	*x = MultiScanRequest{}
+	mi := &file_proto_kvstore_proto_msgTypes[47]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MultiScanRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MultiScanRequest) ProtoMessage() {}
+
+func (x *MultiScanRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_kvstore_proto_msgTypes[47]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MultiScanRequest.ProtoReflect.Descriptor instead.
+func (*MultiScanRequest) Descriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{47}
+}
+
+func (x *MultiScanRequest) GetPrefixes() []string {
+	if x != nil {
+		return x.Prefixes
+	}
+	return nil
+}
+
+// values ordenados por chave; uma chave aparece só no prefixo mais longo que combina
+type PrefixScan struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Prefix        string                 `protobuf:"bytes,1,opt,name=prefix,proto3" json:"prefix,omitempty"`
+	Values        []*KeyValue            `protobuf:"bytes,2,rep,name=values,proto3" json:"values,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PrefixScan) Reset() {
+	*x = PrefixScan{}
+	mi := &file_proto_kvstore_proto_msgTypes[48]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PrefixScan) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PrefixScan) ProtoMessage() {}
+
+func (x *PrefixScan) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_kvstore_proto_msgTypes[48]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PrefixScan.ProtoReflect.Descriptor instead.
+func (*PrefixScan) Descriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{48}
+}
+
+func (x *PrefixScan) GetPrefix() string {
+	if x != nil {
+		return x.Prefix
+	}
+	return ""
+}
+
+func (x *PrefixScan) GetValues() []*KeyValue {
+	if x != nil {
+		return x.Values
+	}
+	return nil
+}
+
+// um resultado por prefixo distinto, na ordem do pedido
+type MultiScanResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Results       []*PrefixScan          `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MultiScanResponse) Reset() {
+	*x = MultiScanResponse{}
+	mi := &file_proto_kvstore_proto_msgTypes[49]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MultiScanResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MultiScanResponse) ProtoMessage() {}
+
+func (x *MultiScanResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_kvstore_proto_msgTypes[49]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MultiScanResponse.ProtoReflect.Descriptor instead.
+func (*MultiScanResponse) Descriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{49}
+}
+
+func (x *MultiScanResponse) GetResults() []*PrefixScan {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+type WatchLeaderRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchLeaderRequest) Reset() {
+	*x = WatchLeaderRequest{}
+	mi := &file_proto_kvstore_proto_msgTypes[50]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchLeaderRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchLeaderRequest) ProtoMessage() {}
+
+func (x *WatchLeaderRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_kvstore_proto_msgTypes[50]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchLeaderRequest.ProtoReflect.Descriptor instead.
+func (*WatchLeaderRequest) Descriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{50}
+}
+
+// leader vazio significa que o cluster está sem líder no momento
+type WatchLeaderResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	LeaderAddress string                 `protobuf:"bytes,1,opt,name=leader_address,json=leaderAddress,proto3" json:"leader_address,omitempty"`
+	LeaderId      string                 `protobuf:"bytes,2,opt,name=leader_id,json=leaderId,proto3" json:"leader_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchLeaderResponse) Reset() {
+	*x = WatchLeaderResponse{}
+	mi := &file_proto_kvstore_proto_msgTypes[51]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchLeaderResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchLeaderResponse) ProtoMessage() {}
+
+func (x *WatchLeaderResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_kvstore_proto_msgTypes[51]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchLeaderResponse.ProtoReflect.Descriptor instead.
+func (*WatchLeaderResponse) Descriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{51}
+}
+
+func (x *WatchLeaderResponse) GetLeaderAddress() string {
+	if x != nil {
+		return x.LeaderAddress
+	}
+	return ""
+}
+
+func (x *WatchLeaderResponse) GetLeaderId() string {
+	if x != nil {
+		return x.LeaderId
+	}
+	return ""
+}
+
+// condição de igualdade: value exige a chave com esse valor, revision 0 exige a chave ausente
+type Compare struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Target        CompareTarget          `protobuf:"varint,2,opt,name=target,proto3,enum=kvstore.CompareTarget" json:"target,omitempty"`
+	Value         string                 `protobuf:"bytes,3,opt,name=value,proto3" json:"value,omitempty"`
+	Revision      uint64                 `protobuf:"varint,4,opt,name=revision,proto3" json:"revision,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Compare) Reset() {
+	*x = Compare{}
+	mi := &file_proto_kvstore_proto_msgTypes[52]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Compare) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Compare) ProtoMessage() {}
+
+func (x *Compare) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_kvstore_proto_msgTypes[52]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Compare.ProtoReflect.Descriptor instead.
+func (*Compare) Descriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{52}
+}
+
+func (x *Compare) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *Compare) GetTarget() CompareTarget {
+	if x != nil {
+		return x.Target
+	}
+	return CompareTarget_COMPARE_VALUE
+}
+
+func (x *Compare) GetValue() string {
 	if x != nil {
-		return x.NodeId
+		return x.Value
 	}
 	return ""
 }
 
-func (x *HeartbeatRequest) GetTimestamp() int64 {
+func (x *Compare) GetRevision() uint64 {
 	if x != nil {
-		return x.Timestamp
+		return x.Revision
 	}
 	return 0
 }
 
-type HeartbeatResponse struct {
+type TxnOp struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Alive         bool                   `protobuf:"varint,1,opt,name=alive,proto3" json:"alive,omitempty"`
-	Timestamp     int64                  `protobuf:"varint,2,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Type          TxnOpType              `protobuf:"varint,1,opt,name=type,proto3,enum=kvstore.TxnOpType" json:"type,omitempty"`
+	Key           string                 `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
+	Value         string                 `protobuf:"bytes,3,opt,name=value,proto3" json:"value,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *HeartbeatResponse) Reset() {
-	*x = HeartbeatResponse{}
-	mi := &file_proto_kvstore_proto_msgTypes[1]
+func (x *TxnOp) Reset() {
+	*x = TxnOp{}
+	mi := &file_proto_kvstore_proto_msgTypes[53]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *HeartbeatResponse) String() string {
+func (x *TxnOp) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*HeartbeatResponse) ProtoMessage() {}
+func (*TxnOp) ProtoMessage() {}
 
-func (x *HeartbeatResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_kvstore_proto_msgTypes[1]
+func (x *TxnOp) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_kvstore_proto_msgTypes[53]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -106,47 +3414,57 @@ func (x *HeartbeatResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use HeartbeatResponse.ProtoReflect.Descriptor instead.
-func (*HeartbeatResponse) Descriptor() ([]byte, []int) {
-	return file_proto_kvstore_proto_rawDescGZIP(), []int{1}
+// Deprecated: Use TxnOp.ProtoReflect.Descriptor instead.
+func (*TxnOp) Descriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{53}
 }
 
-func (x *HeartbeatResponse) GetAlive() bool {
+func (x *TxnOp) GetType() TxnOpType {
 	if x != nil {
-		return x.Alive
+		return x.Type
 	}
-	return false
+	return TxnOpType_TXN_PUT
 }
 
-func (x *HeartbeatResponse) GetTimestamp() int64 {
+func (x *TxnOp) GetKey() string {
 	if x != nil {
-		return x.Timestamp
+		return x.Key
 	}
-	return 0
+	return ""
 }
 
-type WatchRequest struct {
+func (x *TxnOp) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+// se todas as compares baterem aplica then_ops, senão else_ops, tudo atomicamente
+type TxnRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Compares      []*Compare             `protobuf:"bytes,1,rep,name=compares,proto3" json:"compares,omitempty"`
+	ThenOps       []*TxnOp               `protobuf:"bytes,2,rep,name=then_ops,json=thenOps,proto3" json:"then_ops,omitempty"`
+	ElseOps       []*TxnOp               `protobuf:"bytes,3,rep,name=else_ops,json=elseOps,proto3" json:"else_ops,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *WatchRequest) Reset() {
-	*x = WatchRequest{}
-	mi := &file_proto_kvstore_proto_msgTypes[2]
+func (x *TxnRequest) Reset() {
+	*x = TxnRequest{}
+	mi := &file_proto_kvstore_proto_msgTypes[54]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *WatchRequest) String() string {
+func (x *TxnRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*WatchRequest) ProtoMessage() {}
+func (*TxnRequest) ProtoMessage() {}
 
-func (x *WatchRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_kvstore_proto_msgTypes[2]
+func (x *TxnRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_kvstore_proto_msgTypes[54]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -157,40 +3475,55 @@ func (x *WatchRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use WatchRequest.ProtoReflect.Descriptor instead.
-func (*WatchRequest) Descriptor() ([]byte, []int) {
-	return file_proto_kvstore_proto_rawDescGZIP(), []int{2}
+// Deprecated: Use TxnRequest.ProtoReflect.Descriptor instead.
+func (*TxnRequest) Descriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{54}
 }
 
-func (x *WatchRequest) GetKey() string {
+func (x *TxnRequest) GetCompares() []*Compare {
 	if x != nil {
-		return x.Key
+		return x.Compares
 	}
-	return ""
+	return nil
 }
 
-type WatchResponse struct {
+func (x *TxnRequest) GetThenOps() []*TxnOp {
+	if x != nil {
+		return x.ThenOps
+	}
+	return nil
+}
+
+func (x *TxnRequest) GetElseOps() []*TxnOp {
+	if x != nil {
+		return x.ElseOps
+	}
+	return nil
+}
+
+type TxnResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Message       string                 `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	Succeeded     bool                   `protobuf:"varint,1,opt,name=succeeded,proto3" json:"succeeded,omitempty"`
+	Revision      uint64                 `protobuf:"varint,2,opt,name=revision,proto3" json:"revision,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *WatchResponse) Reset() {
-	*x = WatchResponse{}
-	mi := &file_proto_kvstore_proto_msgTypes[3]
+func (x *TxnResponse) Reset() {
+	*x = TxnResponse{}
+	mi := &file_proto_kvstore_proto_msgTypes[55]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *WatchResponse) String() string {
+func (x *TxnResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*WatchResponse) ProtoMessage() {}
+func (*TxnResponse) ProtoMessage() {}
 
-func (x *WatchResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_kvstore_proto_msgTypes[3]
+func (x *TxnResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_kvstore_proto_msgTypes[55]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -201,40 +3534,47 @@ func (x *WatchResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use WatchResponse.ProtoReflect.Descriptor instead.
-func (*WatchResponse) Descriptor() ([]byte, []int) {
-	return file_proto_kvstore_proto_rawDescGZIP(), []int{3}
+// Deprecated: Use TxnResponse.ProtoReflect.Descriptor instead.
+func (*TxnResponse) Descriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{55}
 }
 
-func (x *WatchResponse) GetMessage() string {
+func (x *TxnResponse) GetSucceeded() bool {
 	if x != nil {
-		return x.Message
+		return x.Succeeded
 	}
-	return ""
+	return false
 }
 
-// response é vazia
-type GetAllRequest struct {
+func (x *TxnResponse) GetRevision() uint64 {
+	if x != nil {
+		return x.Revision
+	}
+	return 0
+}
+
+// nonce é devolvido sem alteração, para o cliente casar a resposta com o request
+type VersionRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *GetAllRequest) Reset() {
-	*x = GetAllRequest{}
-	mi := &file_proto_kvstore_proto_msgTypes[4]
+func (x *VersionRequest) Reset() {
+	*x = VersionRequest{}
+	mi := &file_proto_kvstore_proto_msgTypes[56]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *GetAllRequest) String() string {
+func (x *VersionRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GetAllRequest) ProtoMessage() {}
+func (*VersionRequest) ProtoMessage() {}
 
-func (x *GetAllRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_kvstore_proto_msgTypes[4]
+func (x *VersionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_kvstore_proto_msgTypes[56]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -245,33 +3585,36 @@ func (x *GetAllRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GetAllRequest.ProtoReflect.Descriptor instead.
-func (*GetAllRequest) Descriptor() ([]byte, []int) {
-	return file_proto_kvstore_proto_rawDescGZIP(), []int{4}
+// Deprecated: Use VersionRequest.ProtoReflect.Descriptor instead.
+func (*VersionRequest) Descriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{56}
 }
 
-type GetAllResponse struct {
+// o build do nó, para conferir as versões de um cluster durante um rolling upgrade
+type VersionResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Values        map[string]string      `protobuf:"bytes,1,rep,name=values,proto3" json:"values,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Version       string                 `protobuf:"bytes,1,opt,name=version,proto3" json:"version,omitempty"`
+	Commit        string                 `protobuf:"bytes,2,opt,name=commit,proto3" json:"commit,omitempty"`
+	GoVersion     string                 `protobuf:"bytes,3,opt,name=go_version,json=goVersion,proto3" json:"go_version,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *GetAllResponse) Reset() {
-	*x = GetAllResponse{}
-	mi := &file_proto_kvstore_proto_msgTypes[5]
+func (x *VersionResponse) Reset() {
+	*x = VersionResponse{}
+	mi := &file_proto_kvstore_proto_msgTypes[57]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *GetAllResponse) String() string {
+func (x *VersionResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GetAllResponse) ProtoMessage() {}
+func (*VersionResponse) ProtoMessage() {}
 
-func (x *GetAllResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_kvstore_proto_msgTypes[5]
+func (x *VersionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_kvstore_proto_msgTypes[57]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -282,40 +3625,54 @@ func (x *GetAllResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GetAllResponse.ProtoReflect.Descriptor instead.
-func (*GetAllResponse) Descriptor() ([]byte, []int) {
-	return file_proto_kvstore_proto_rawDescGZIP(), []int{5}
+// Deprecated: Use VersionResponse.ProtoReflect.Descriptor instead.
+func (*VersionResponse) Descriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{57}
 }
 
-func (x *GetAllResponse) GetValues() map[string]string {
+func (x *VersionResponse) GetVersion() string {
 	if x != nil {
-		return x.Values
+		return x.Version
 	}
-	return nil
+	return ""
 }
 
-type DeleteRequest struct {
+func (x *VersionResponse) GetCommit() string {
+	if x != nil {
+		return x.Commit
+	}
+	return ""
+}
+
+func (x *VersionResponse) GetGoVersion() string {
+	if x != nil {
+		return x.GoVersion
+	}
+	return ""
+}
+
+type PingRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Nonce         string                 `protobuf:"bytes,1,opt,name=nonce,proto3" json:"nonce,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *DeleteRequest) Reset() {
-	*x = DeleteRequest{}
-	mi := &file_proto_kvstore_proto_msgTypes[6]
+func (x *PingRequest) Reset() {
+	*x = PingRequest{}
+	mi := &file_proto_kvstore_proto_msgTypes[58]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *DeleteRequest) String() string {
+func (x *PingRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*DeleteRequest) ProtoMessage() {}
+func (*PingRequest) ProtoMessage() {}
 
-func (x *DeleteRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_kvstore_proto_msgTypes[6]
+func (x *PingRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_kvstore_proto_msgTypes[58]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -326,40 +3683,42 @@ func (x *DeleteRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use DeleteRequest.ProtoReflect.Descriptor instead.
-func (*DeleteRequest) Descriptor() ([]byte, []int) {
-	return file_proto_kvstore_proto_rawDescGZIP(), []int{6}
+// Deprecated: Use PingRequest.ProtoReflect.Descriptor instead.
+func (*PingRequest) Descriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{58}
 }
 
-func (x *DeleteRequest) GetKey() string {
+func (x *PingRequest) GetNonce() string {
 	if x != nil {
-		return x.Key
+		return x.Nonce
 	}
 	return ""
 }
 
-type DeleteResponse struct {
+// timestamp é o relógio do servidor em nanossegundos unix
+type PingResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Nonce         string                 `protobuf:"bytes,1,opt,name=nonce,proto3" json:"nonce,omitempty"`
+	Timestamp     int64                  `protobuf:"varint,2,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *DeleteResponse) Reset() {
-	*x = DeleteResponse{}
-	mi := &file_proto_kvstore_proto_msgTypes[7]
+func (x *PingResponse) Reset() {
+	*x = PingResponse{}
+	mi := &file_proto_kvstore_proto_msgTypes[59]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *DeleteResponse) String() string {
+func (x *PingResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*DeleteResponse) ProtoMessage() {}
+func (*PingResponse) ProtoMessage() {}
 
-func (x *DeleteResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_kvstore_proto_msgTypes[7]
+func (x *PingResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_kvstore_proto_msgTypes[59]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -370,41 +3729,46 @@ func (x *DeleteResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use DeleteResponse.ProtoReflect.Descriptor instead.
-func (*DeleteResponse) Descriptor() ([]byte, []int) {
-	return file_proto_kvstore_proto_rawDescGZIP(), []int{7}
+// Deprecated: Use PingResponse.ProtoReflect.Descriptor instead.
+func (*PingResponse) Descriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{59}
 }
 
-func (x *DeleteResponse) GetKey() string {
+func (x *PingResponse) GetNonce() string {
 	if x != nil {
-		return x.Key
+		return x.Nonce
 	}
 	return ""
 }
 
-type PutRequest struct {
+func (x *PingResponse) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+type ClearRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
-	Value         string                 `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *PutRequest) Reset() {
-	*x = PutRequest{}
-	mi := &file_proto_kvstore_proto_msgTypes[8]
+func (x *ClearRequest) Reset() {
+	*x = ClearRequest{}
+	mi := &file_proto_kvstore_proto_msgTypes[60]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *PutRequest) String() string {
+func (x *ClearRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*PutRequest) ProtoMessage() {}
+func (*ClearRequest) ProtoMessage() {}
 
-func (x *PutRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_kvstore_proto_msgTypes[8]
+func (x *ClearRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_kvstore_proto_msgTypes[60]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -415,47 +3779,33 @@ func (x *PutRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use PutRequest.ProtoReflect.Descriptor instead.
-func (*PutRequest) Descriptor() ([]byte, []int) {
-	return file_proto_kvstore_proto_rawDescGZIP(), []int{8}
-}
-
-func (x *PutRequest) GetKey() string {
-	if x != nil {
-		return x.Key
-	}
-	return ""
-}
-
-func (x *PutRequest) GetValue() string {
-	if x != nil {
-		return x.Value
-	}
-	return ""
+// Deprecated: Use ClearRequest.ProtoReflect.Descriptor instead.
+func (*ClearRequest) Descriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{60}
 }
 
-type PutResponse struct {
+type ClearResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *PutResponse) Reset() {
-	*x = PutResponse{}
-	mi := &file_proto_kvstore_proto_msgTypes[9]
+func (x *ClearResponse) Reset() {
+	*x = ClearResponse{}
+	mi := &file_proto_kvstore_proto_msgTypes[61]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *PutResponse) String() string {
+func (x *ClearResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*PutResponse) ProtoMessage() {}
+func (*ClearResponse) ProtoMessage() {}
 
-func (x *PutResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_kvstore_proto_msgTypes[9]
+func (x *ClearResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_kvstore_proto_msgTypes[61]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -466,40 +3816,42 @@ func (x *PutResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use PutResponse.ProtoReflect.Descriptor instead.
-func (*PutResponse) Descriptor() ([]byte, []int) {
-	return file_proto_kvstore_proto_rawDescGZIP(), []int{9}
+// Deprecated: Use ClearResponse.ProtoReflect.Descriptor instead.
+func (*ClearResponse) Descriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{61}
 }
 
-func (x *PutResponse) GetSuccess() bool {
+func (x *ClearResponse) GetSuccess() bool {
 	if x != nil {
 		return x.Success
 	}
 	return false
 }
 
-type GetRequest struct {
+// pede ao líder que adicione o nó como voter; só o líder aceita
+type JoinRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	NodeId        string                 `protobuf:"bytes,1,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
+	RaftAddress   string                 `protobuf:"bytes,2,opt,name=raft_address,json=raftAddress,proto3" json:"raft_address,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *GetRequest) Reset() {
-	*x = GetRequest{}
-	mi := &file_proto_kvstore_proto_msgTypes[10]
+func (x *JoinRequest) Reset() {
+	*x = JoinRequest{}
+	mi := &file_proto_kvstore_proto_msgTypes[62]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *GetRequest) String() string {
+func (x *JoinRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GetRequest) ProtoMessage() {}
+func (*JoinRequest) ProtoMessage() {}
 
-func (x *GetRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_kvstore_proto_msgTypes[10]
+func (x *JoinRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_kvstore_proto_msgTypes[62]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -510,41 +3862,46 @@ func (x *GetRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GetRequest.ProtoReflect.Descriptor instead.
-func (*GetRequest) Descriptor() ([]byte, []int) {
-	return file_proto_kvstore_proto_rawDescGZIP(), []int{10}
+// Deprecated: Use JoinRequest.ProtoReflect.Descriptor instead.
+func (*JoinRequest) Descriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{62}
 }
 
-func (x *GetRequest) GetKey() string {
+func (x *JoinRequest) GetNodeId() string {
 	if x != nil {
-		return x.Key
+		return x.NodeId
 	}
 	return ""
 }
 
-type GetResponse struct {
+func (x *JoinRequest) GetRaftAddress() string {
+	if x != nil {
+		return x.RaftAddress
+	}
+	return ""
+}
+
+type JoinResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
-	Value         string                 `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *GetResponse) Reset() {
-	*x = GetResponse{}
-	mi := &file_proto_kvstore_proto_msgTypes[11]
+func (x *JoinResponse) Reset() {
+	*x = JoinResponse{}
+	mi := &file_proto_kvstore_proto_msgTypes[63]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *GetResponse) String() string {
+func (x *JoinResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GetResponse) ProtoMessage() {}
+func (*JoinResponse) ProtoMessage() {}
 
-func (x *GetResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_kvstore_proto_msgTypes[11]
+func (x *JoinResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_kvstore_proto_msgTypes[63]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -555,23 +3912,9 @@ func (x *GetResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GetResponse.ProtoReflect.Descriptor instead.
-func (*GetResponse) Descriptor() ([]byte, []int) {
-	return file_proto_kvstore_proto_rawDescGZIP(), []int{11}
-}
-
-func (x *GetResponse) GetKey() string {
-	if x != nil {
-		return x.Key
-	}
-	return ""
-}
-
-func (x *GetResponse) GetValue() string {
-	if x != nil {
-		return x.Value
-	}
-	return ""
+// Deprecated: Use JoinResponse.ProtoReflect.Descriptor instead.
+func (*JoinResponse) Descriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{63}
 }
 
 var File_proto_kvstore_proto protoreflect.FileDescriptor
@@ -584,41 +3927,295 @@ const file_proto_kvstore_proto_rawDesc = "" +
 	"\ttimestamp\x18\x02 \x01(\x03R\ttimestamp\"G\n" +
 	"\x11HeartbeatResponse\x12\x14\n" +
 	"\x05alive\x18\x01 \x01(\bR\x05alive\x12\x1c\n" +
-	"\ttimestamp\x18\x02 \x01(\x03R\ttimestamp\" \n" +
+	"\ttimestamp\x18\x02 \x01(\x03R\ttimestamp\"|\n" +
 	"\fWatchRequest\x12\x10\n" +
-	"\x03key\x18\x01 \x01(\tR\x03key\")\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12,\n" +
+	"\x12send_initial_value\x18\x02 \x01(\bR\x10sendInitialValue\x12,\n" +
+	"\x06policy\x18\x03 \x01(\x0e2\x14.kvstore.WatchPolicyR\x06policy\"\xb8\x01\n" +
 	"\rWatchResponse\x12\x18\n" +
-	"\amessage\x18\x01 \x01(\tR\amessage\"\x0f\n" +
-	"\rGetAllRequest\"\x88\x01\n" +
+	"\amessage\x18\x01 \x01(\tR\amessage\x12+\n" +
+	"\x04type\x18\x02 \x01(\x0e2\x17.kvstore.WatchEventTypeR\x04type\x12\x10\n" +
+	"\x03key\x18\x03 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x04 \x01(\tR\x05value\x12\x1a\n" +
+	"\brevision\x18\x05 \x01(\x04R\brevision\x12\x1c\n" +
+	"\tnamespace\x18\x06 \x01(\tR\tnamespace\"W\n" +
+	"\x0fWatchAllRequest\x12,\n" +
+	"\x06policy\x18\x01 \x01(\x0e2\x14.kvstore.WatchPolicyR\x06policy\x12\x16\n" +
+	"\x06prefix\x18\x02 \x01(\tR\x06prefix\")\n" +
+	"\x0eCompactRequest\x12\x17\n" +
+	"\askip_db\x18\x01 \x01(\bR\x06skipDb\"1\n" +
+	"\x12SetReadOnlyRequest\x12\x1b\n" +
+	"\tread_only\x18\x01 \x01(\bR\breadOnly\"N\n" +
+	"\x13SetReadOnlyResponse\x12\x1b\n" +
+	"\tread_only\x18\x01 \x01(\bR\breadOnly\x12\x1a\n" +
+	"\bprevious\x18\x02 \x01(\bR\bprevious\"\x14\n" +
+	"\x12ClusterInfoRequest\"U\n" +
+	"\rClusterServer\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x18\n" +
+	"\aaddress\x18\x02 \x01(\tR\aaddress\x12\x1a\n" +
+	"\bsuffrage\x18\x03 \x01(\tR\bsuffrage\"\x9f\x01\n" +
+	"\x13ClusterInfoResponse\x120\n" +
+	"\aservers\x18\x01 \x03(\v2\x16.kvstore.ClusterServerR\aservers\x12\x12\n" +
+	"\x04term\x18\x02 \x01(\x04R\x04term\x12\x1b\n" +
+	"\tleader_id\x18\x03 \x01(\tR\bleaderId\x12%\n" +
+	"\x0eleader_address\x18\x04 \x01(\tR\rleaderAddress\".\n" +
+	"\x0fStepDownRequest\x12\x1b\n" +
+	"\ttarget_id\x18\x01 \x01(\tR\btargetId\"\x12\n" +
+	"\x10StepDownResponse\"V\n" +
+	"\rVerifyRequest\x12\x16\n" +
+	"\x06repair\x18\x01 \x01(\bR\x06repair\x12-\n" +
+	"\x06source\x18\x02 \x01(\x0e2\x15.kvstore.RepairSourceR\x06source\"\x8b\x01\n" +
+	"\vDiscrepancy\x12,\n" +
+	"\x04kind\x18\x01 \x01(\x0e2\x18.kvstore.DiscrepancyKindR\x04kind\x12\x10\n" +
+	"\x03key\x18\x02 \x01(\tR\x03key\x12!\n" +
+	"\fmemory_value\x18\x03 \x01(\tR\vmemoryValue\x12\x19\n" +
+	"\bdb_value\x18\x04 \x01(\tR\adbValue\"L\n" +
+	"\x0eVerifyResponse\x12:\n" +
+	"\rdiscrepancies\x18\x01 \x03(\v2\x14.kvstore.DiscrepancyR\rdiscrepancies\"\x10\n" +
+	"\x0eDBStatsRequest\"\xb2\x03\n" +
+	"\x0fDBStatsResponse\x12\x1b\n" +
+	"\tfile_size\x18\x01 \x01(\x03R\bfileSize\x12\x1b\n" +
+	"\tpage_size\x18\x02 \x01(\x03R\bpageSize\x12\x1d\n" +
+	"\n" +
+	"free_pages\x18\x03 \x01(\x03R\tfreePages\x12#\n" +
+	"\rpending_pages\x18\x04 \x01(\x03R\fpendingPages\x12\x1d\n" +
+	"\n" +
+	"free_bytes\x18\x05 \x01(\x03R\tfreeBytes\x12\"\n" +
+	"\ropen_read_txs\x18\x06 \x01(\x03R\vopenReadTxs\x12\x1d\n" +
+	"\n" +
+	"leaf_pages\x18\a \x01(\x03R\tleafPages\x12!\n" +
+	"\fbranch_pages\x18\b \x01(\x03R\vbranchPages\x12\x1d\n" +
+	"\n" +
+	"leaf_inuse\x18\t \x01(\x03R\tleafInuse\x12!\n" +
+	"\fbranch_inuse\x18\n" +
+	" \x01(\x03R\vbranchInuse\x12 \n" +
+	"\vutilization\x18\v \x01(\x01R\vutilization\x12\x17\n" +
+	"\adb_keys\x18\f \x01(\x03R\x06dbKeys\x12\x1f\n" +
+	"\vmemory_keys\x18\r \x01(\x03R\n" +
+	"memoryKeys\"\xd0\x01\n" +
+	"\x0fCompactResponse\x12$\n" +
+	"\x0edb_size_before\x18\x01 \x01(\x03R\fdbSizeBefore\x12\"\n" +
+	"\rdb_size_after\x18\x02 \x01(\x03R\vdbSizeAfter\x12&\n" +
+	"\x0fwal_size_before\x18\x03 \x01(\x03R\rwalSizeBefore\x12$\n" +
+	"\x0ewal_size_after\x18\x04 \x01(\x03R\fwalSizeAfter\x12%\n" +
+	"\x0esnapshot_taken\x18\x05 \x01(\bR\rsnapshotTaken\"'\n" +
+	"\rGetAllRequest\x12\x16\n" +
+	"\x06sorted\x18\x01 \x01(\bR\x06sorted\"\xc0\x01\n" +
 	"\x0eGetAllResponse\x12;\n" +
-	"\x06values\x18\x01 \x03(\v2#.kvstore.GetAllResponse.ValuesEntryR\x06values\x1a9\n" +
+	"\x06values\x18\x01 \x03(\v2#.kvstore.GetAllResponse.ValuesEntryR\x06values\x126\n" +
+	"\rsorted_values\x18\x02 \x03(\v2\x11.kvstore.KeyValueR\fsortedValues\x1a9\n" +
 	"\vValuesEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
 	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"!\n" +
 	"\rDeleteRequest\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\"\"\n" +
 	"\x0eDeleteResponse\x12\x10\n" +
-	"\x03key\x18\x01 \x01(\tR\x03key\"4\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\"'\n" +
+	"\x11DeleteManyRequest\x12\x12\n" +
+	"\x04keys\x18\x01 \x03(\tR\x04keys\".\n" +
+	"\x12DeleteManyResponse\x12\x18\n" +
+	"\adeleted\x18\x01 \x01(\x03R\adeleted\"~\n" +
 	"\n" +
 	"PutRequest\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
-	"\x05value\x18\x02 \x01(\tR\x05value\"'\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value\x12\x1f\n" +
+	"\vvalue_bytes\x18\x03 \x01(\fR\n" +
+	"valueBytes\x12'\n" +
+	"\x0fidempotency_key\x18\x04 \x01(\tR\x0eidempotencyKey\"'\n" +
 	"\vPutResponse\x12\x18\n" +
-	"\asuccess\x18\x01 \x01(\bR\asuccess\"\x1e\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\"-\n" +
+	"\x13PutIfAbsentResponse\x12\x16\n" +
+	"\x06stored\x18\x01 \x01(\bR\x06stored\"\x98\x01\n" +
 	"\n" +
 	"GetRequest\x12\x10\n" +
-	"\x03key\x18\x01 \x01(\tR\x03key\"5\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x126\n" +
+	"\vconsistency\x18\x02 \x01(\x0e2\x14.kvstore.ConsistencyR\vconsistency\x12(\n" +
+	"\x10max_staleness_ms\x18\x03 \x01(\x03R\x0emaxStalenessMs\x12\x16\n" +
+	"\x06binary\x18\x04 \x01(\bR\x06binary\"\xd3\x01\n" +
 	"\vGetResponse\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
-	"\x05value\x18\x02 \x01(\tR\x05value2\x9d\x02\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value\x12\x1a\n" +
+	"\brevision\x18\x03 \x01(\x04R\brevision\x12!\n" +
+	"\fstaleness_ms\x18\x04 \x01(\x03R\vstalenessMs\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\x05 \x01(\x03R\tcreatedAt\x12\x1d\n" +
+	"\n" +
+	"updated_at\x18\x06 \x01(\x03R\tupdatedAt\x12\x1f\n" +
+	"\vvalue_bytes\x18\a \x01(\fR\n" +
+	"valueBytes\"j\n" +
+	"\x13PutIfVersionRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value\x12+\n" +
+	"\x11expected_revision\x18\x03 \x01(\x04R\x10expectedRevision\"2\n" +
+	"\x14PutIfVersionResponse\x12\x1a\n" +
+	"\brevision\x18\x01 \x01(\x04R\brevision\"%\n" +
+	"\x0fMultiGetRequest\x12\x12\n" +
+	"\x04keys\x18\x01 \x03(\tR\x04keys\"H\n" +
+	"\bKeyValue\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value\x12\x14\n" +
+	"\x05found\x18\x03 \x01(\bR\x05found\"=\n" +
+	"\x10MultiGetResponse\x12)\n" +
+	"\x06values\x18\x01 \x03(\v2\x11.kvstore.KeyValueR\x06values\"\x0f\n" +
+	"\rBackupRequest\"8\n" +
+	"\x0eBackupResponse\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value\"{\n" +
+	"\x0eRestoreRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value\x12(\n" +
+	"\x04mode\x18\x03 \x01(\x0e2\x14.kvstore.RestoreModeR\x04mode\x12\x17\n" +
+	"\adry_run\x18\x04 \x01(\bR\x06dryRun\"\xaf\x01\n" +
+	"\x0fRestoreResponse\x12\x1a\n" +
+	"\brestored\x18\x01 \x01(\x03R\brestored\x12\x18\n" +
+	"\achecked\x18\x02 \x01(\x03R\achecked\x12\x14\n" +
+	"\x05bytes\x18\x03 \x01(\x03R\x05bytes\x12\x1e\n" +
+	"\n" +
+	"duplicates\x18\x04 \x01(\x03R\n" +
+	"duplicates\x12\x18\n" +
+	"\ainvalid\x18\x05 \x01(\x03R\ainvalid\x12\x16\n" +
+	"\x06errors\x18\x06 \x03(\tR\x06errors\"'\n" +
+	"\x0fBulkPutResponse\x12\x14\n" +
+	"\x05count\x18\x01 \x01(\x03R\x05count\"\x0f\n" +
+	"\rStatusRequest\"\xd8\x02\n" +
+	"\x0eStatusResponse\x12\x17\n" +
+	"\anode_id\x18\x01 \x01(\tR\x06nodeId\x12\x14\n" +
+	"\x05state\x18\x02 \x01(\tR\x05state\x12%\n" +
+	"\x0eleader_address\x18\x03 \x01(\tR\rleaderAddress\x12\x1b\n" +
+	"\tleader_id\x18\x04 \x01(\tR\bleaderId\x12\x12\n" +
+	"\x04keys\x18\x05 \x01(\x03R\x04keys\x12\x19\n" +
+	"\bwal_size\x18\x06 \x01(\x03R\awalSize\x12)\n" +
+	"\x05peers\x18\a \x03(\v2\x13.kvstore.PeerStatusR\x05peers\x12\x1b\n" +
+	"\tread_only\x18\b \x01(\bR\breadOnly\x120\n" +
+	"\x14watch_events_dropped\x18\t \x01(\x04R\x12watchEventsDropped\x12*\n" +
+	"\x11watch_queue_depth\x18\n" +
+	" \x01(\x03R\x0fwatchQueueDepth\"x\n" +
+	"\n" +
+	"PeerStatus\x12\x18\n" +
+	"\aaddress\x18\x01 \x01(\tR\aaddress\x12\x14\n" +
+	"\x05alive\x18\x02 \x01(\bR\x05alive\x12\x1b\n" +
+	"\tlast_seen\x18\x03 \x01(\x03R\blastSeen\x12\x1d\n" +
+	"\n" +
+	"last_error\x18\x04 \x01(\tR\tlastError\"&\n" +
+	"\fCountRequest\x12\x16\n" +
+	"\x06prefix\x18\x01 \x01(\tR\x06prefix\"%\n" +
+	"\rCountResponse\x12\x14\n" +
+	"\x05count\x18\x01 \x01(\x03R\x05count\"%\n" +
+	"\vKeysRequest\x12\x16\n" +
+	"\x06prefix\x18\x01 \x01(\tR\x06prefix\"\"\n" +
+	"\fKeysResponse\x12\x12\n" +
+	"\x04keys\x18\x01 \x03(\tR\x04keys\".\n" +
+	"\x10MultiScanRequest\x12\x1a\n" +
+	"\bprefixes\x18\x01 \x03(\tR\bprefixes\"O\n" +
+	"\n" +
+	"PrefixScan\x12\x16\n" +
+	"\x06prefix\x18\x01 \x01(\tR\x06prefix\x12)\n" +
+	"\x06values\x18\x02 \x03(\v2\x11.kvstore.KeyValueR\x06values\"B\n" +
+	"\x11MultiScanResponse\x12-\n" +
+	"\aresults\x18\x01 \x03(\v2\x13.kvstore.PrefixScanR\aresults\"\x14\n" +
+	"\x12WatchLeaderRequest\"Y\n" +
+	"\x13WatchLeaderResponse\x12%\n" +
+	"\x0eleader_address\x18\x01 \x01(\tR\rleaderAddress\x12\x1b\n" +
+	"\tleader_id\x18\x02 \x01(\tR\bleaderId\"}\n" +
+	"\aCompare\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12.\n" +
+	"\x06target\x18\x02 \x01(\x0e2\x16.kvstore.CompareTargetR\x06target\x12\x14\n" +
+	"\x05value\x18\x03 \x01(\tR\x05value\x12\x1a\n" +
+	"\brevision\x18\x04 \x01(\x04R\brevision\"W\n" +
+	"\x05TxnOp\x12&\n" +
+	"\x04type\x18\x01 \x01(\x0e2\x12.kvstore.TxnOpTypeR\x04type\x12\x10\n" +
+	"\x03key\x18\x02 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x03 \x01(\tR\x05value\"\x90\x01\n" +
+	"\n" +
+	"TxnRequest\x12,\n" +
+	"\bcompares\x18\x01 \x03(\v2\x10.kvstore.CompareR\bcompares\x12)\n" +
+	"\bthen_ops\x18\x02 \x03(\v2\x0e.kvstore.TxnOpR\athenOps\x12)\n" +
+	"\belse_ops\x18\x03 \x03(\v2\x0e.kvstore.TxnOpR\aelseOps\"G\n" +
+	"\vTxnResponse\x12\x1c\n" +
+	"\tsucceeded\x18\x01 \x01(\bR\tsucceeded\x12\x1a\n" +
+	"\brevision\x18\x02 \x01(\x04R\brevision\"\x10\n" +
+	"\x0eVersionRequest\"b\n" +
+	"\x0fVersionResponse\x12\x18\n" +
+	"\aversion\x18\x01 \x01(\tR\aversion\x12\x16\n" +
+	"\x06commit\x18\x02 \x01(\tR\x06commit\x12\x1d\n" +
+	"\n" +
+	"go_version\x18\x03 \x01(\tR\tgoVersion\"#\n" +
+	"\vPingRequest\x12\x14\n" +
+	"\x05nonce\x18\x01 \x01(\tR\x05nonce\"B\n" +
+	"\fPingResponse\x12\x14\n" +
+	"\x05nonce\x18\x01 \x01(\tR\x05nonce\x12\x1c\n" +
+	"\ttimestamp\x18\x02 \x01(\x03R\ttimestamp\"\x0e\n" +
+	"\fClearRequest\")\n" +
+	"\rClearResponse\x12\x18\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\"I\n" +
+	"\vJoinRequest\x12\x17\n" +
+	"\anode_id\x18\x01 \x01(\tR\x06nodeId\x12!\n" +
+	"\fraft_address\x18\x02 \x01(\tR\vraftAddress\"\x0e\n" +
+	"\fJoinResponse*a\n" +
+	"\vWatchPolicy\x12\x1c\n" +
+	"\x18WATCH_POLICY_DROP_NEWEST\x10\x00\x12\x1c\n" +
+	"\x18WATCH_POLICY_DROP_OLDEST\x10\x01\x12\x16\n" +
+	"\x12WATCH_POLICY_BLOCK\x10\x02*j\n" +
+	"\x0eWatchEventType\x12\x13\n" +
+	"\x0fWATCH_EVENT_PUT\x10\x00\x12\x16\n" +
+	"\x12WATCH_EVENT_DELETE\x10\x01\x12\x15\n" +
+	"\x11WATCH_EVENT_CLEAR\x10\x02\x12\x14\n" +
+	"\x10WATCH_EVENT_DROP\x10\x03*>\n" +
+	"\fRepairSource\x12\x14\n" +
+	"\x10REPAIR_SOURCE_DB\x10\x00\x12\x18\n" +
+	"\x14REPAIR_SOURCE_MEMORY\x10\x01*s\n" +
+	"\x0fDiscrepancyKind\x12\x1e\n" +
+	"\x1aDISCREPANCY_VALUE_MISMATCH\x10\x00\x12\x1d\n" +
+	"\x19DISCREPANCY_MISSING_IN_DB\x10\x01\x12!\n" +
+	"\x1dDISCREPANCY_MISSING_IN_MEMORY\x10\x02*^\n" +
+	"\vConsistency\x12\x18\n" +
+	"\x14CONSISTENCY_EVENTUAL\x10\x00\x12\x1c\n" +
+	"\x18CONSISTENCY_LINEARIZABLE\x10\x01\x12\x17\n" +
+	"\x13CONSISTENCY_REPLICA\x10\x02*5\n" +
+	"\vRestoreMode\x12\x11\n" +
+	"\rRESTORE_MERGE\x10\x00\x12\x13\n" +
+	"\x0fRESTORE_REPLACE\x10\x01*8\n" +
+	"\rCompareTarget\x12\x11\n" +
+	"\rCOMPARE_VALUE\x10\x00\x12\x14\n" +
+	"\x10COMPARE_REVISION\x10\x01*(\n" +
+	"\tTxnOpType\x12\v\n" +
+	"\aTXN_PUT\x10\x00\x12\x0e\n" +
+	"\n" +
+	"TXN_DELETE\x10\x012\x8b\x0e\n" +
 	"\aKvStore\x120\n" +
 	"\x03Put\x12\x13.kvstore.PutRequest\x1a\x14.kvstore.PutResponse\x120\n" +
 	"\x03Get\x12\x13.kvstore.GetRequest\x1a\x14.kvstore.GetResponse\x129\n" +
 	"\x06Delete\x12\x16.kvstore.DeleteRequest\x1a\x17.kvstore.DeleteResponse\x129\n" +
 	"\x06GetAll\x12\x16.kvstore.GetAllRequest\x1a\x17.kvstore.GetAllResponse\x128\n" +
-	"\x05Watch\x12\x15.kvstore.WatchRequest\x1a\x16.kvstore.WatchResponse0\x012W\n" +
+	"\x05Watch\x12\x15.kvstore.WatchRequest\x1a\x16.kvstore.WatchResponse0\x01\x12;\n" +
+	"\x06Backup\x12\x16.kvstore.BackupRequest\x1a\x17.kvstore.BackupResponse0\x01\x12>\n" +
+	"\aRestore\x12\x17.kvstore.RestoreRequest\x1a\x18.kvstore.RestoreResponse(\x01\x129\n" +
+	"\x06Status\x12\x16.kvstore.StatusRequest\x1a\x17.kvstore.StatusResponse\x126\n" +
+	"\x05Count\x12\x15.kvstore.CountRequest\x1a\x16.kvstore.CountResponse\x126\n" +
+	"\x05Clear\x12\x15.kvstore.ClearRequest\x1a\x16.kvstore.ClearResponse\x12@\n" +
+	"\vPutIfAbsent\x12\x13.kvstore.PutRequest\x1a\x1c.kvstore.PutIfAbsentResponse\x12?\n" +
+	"\bMultiGet\x12\x18.kvstore.MultiGetRequest\x1a\x19.kvstore.MultiGetResponse\x12K\n" +
+	"\fPutIfVersion\x12\x1c.kvstore.PutIfVersionRequest\x1a\x1d.kvstore.PutIfVersionResponse\x123\n" +
+	"\x04Keys\x12\x14.kvstore.KeysRequest\x1a\x15.kvstore.KeysResponse\x12J\n" +
+	"\vWatchLeader\x12\x1b.kvstore.WatchLeaderRequest\x1a\x1c.kvstore.WatchLeaderResponse0\x01\x123\n" +
+	"\x04Ping\x12\x14.kvstore.PingRequest\x1a\x15.kvstore.PingResponse\x120\n" +
+	"\x03Txn\x12\x13.kvstore.TxnRequest\x1a\x14.kvstore.TxnResponse\x12:\n" +
+	"\aBulkPut\x12\x13.kvstore.PutRequest\x1a\x18.kvstore.BulkPutResponse(\x01\x12;\n" +
+	"\fGetAllStream\x12\x16.kvstore.GetAllRequest\x1a\x11.kvstore.KeyValue0\x01\x12>\n" +
+	"\bWatchAll\x12\x18.kvstore.WatchAllRequest\x1a\x16.kvstore.WatchResponse0\x01\x12<\n" +
+	"\aCompact\x12\x17.kvstore.CompactRequest\x1a\x18.kvstore.CompactResponse\x12H\n" +
+	"\vSetReadOnly\x12\x1b.kvstore.SetReadOnlyRequest\x1a\x1c.kvstore.SetReadOnlyResponse\x12H\n" +
+	"\vClusterInfo\x12\x1b.kvstore.ClusterInfoRequest\x1a\x1c.kvstore.ClusterInfoResponse\x12<\n" +
+	"\aDBStats\x12\x17.kvstore.DBStatsRequest\x1a\x18.kvstore.DBStatsResponse\x12?\n" +
+	"\bStepDown\x12\x18.kvstore.StepDownRequest\x1a\x19.kvstore.StepDownResponse\x12B\n" +
+	"\tMultiScan\x12\x19.kvstore.MultiScanRequest\x1a\x1a.kvstore.MultiScanResponse\x12<\n" +
+	"\aVersion\x12\x17.kvstore.VersionRequest\x1a\x18.kvstore.VersionResponse\x129\n" +
+	"\x06Verify\x12\x16.kvstore.VerifyRequest\x1a\x17.kvstore.VerifyResponse\x12E\n" +
+	"\n" +
+	"DeleteMany\x12\x1a.kvstore.DeleteManyRequest\x1a\x1b.kvstore.DeleteManyResponse2\x8c\x01\n" +
 	"\x11NodeCommunication\x12B\n" +
-	"\tHeartbeat\x12\x19.kvstore.HeartbeatRequest\x1a\x1a.kvstore.HeartbeatResponseB*Z(github.com/carvalhodanielg/kvstore/pb;pbb\x06proto3"
+	"\tHeartbeat\x12\x19.kvstore.HeartbeatRequest\x1a\x1a.kvstore.HeartbeatResponse\x123\n" +
+	"\x04Join\x12\x14.kvstore.JoinRequest\x1a\x15.kvstore.JoinResponseB*Z(github.com/carvalhodanielg/kvstore/pb;pbb\x06proto3"
 
 var (
 	file_proto_kvstore_proto_rawDescOnce sync.Once
@@ -632,41 +4229,171 @@ func file_proto_kvstore_proto_rawDescGZIP() []byte {
 	return file_proto_kvstore_proto_rawDescData
 }
 
-var file_proto_kvstore_proto_msgTypes = make([]protoimpl.MessageInfo, 13)
+var file_proto_kvstore_proto_enumTypes = make([]protoimpl.EnumInfo, 8)
+var file_proto_kvstore_proto_msgTypes = make([]protoimpl.MessageInfo, 65)
 var file_proto_kvstore_proto_goTypes = []any{
-	(*HeartbeatRequest)(nil),  // 0: kvstore.HeartbeatRequest
-	(*HeartbeatResponse)(nil), // 1: kvstore.HeartbeatResponse
-	(*WatchRequest)(nil),      // 2: kvstore.WatchRequest
-	(*WatchResponse)(nil),     // 3: kvstore.WatchResponse
-	(*GetAllRequest)(nil),     // 4: kvstore.GetAllRequest
-	(*GetAllResponse)(nil),    // 5: kvstore.GetAllResponse
-	(*DeleteRequest)(nil),     // 6: kvstore.DeleteRequest
-	(*DeleteResponse)(nil),    // 7: kvstore.DeleteResponse
-	(*PutRequest)(nil),        // 8: kvstore.PutRequest
-	(*PutResponse)(nil),       // 9: kvstore.PutResponse
-	(*GetRequest)(nil),        // 10: kvstore.GetRequest
-	(*GetResponse)(nil),       // 11: kvstore.GetResponse
-	nil,                       // 12: kvstore.GetAllResponse.ValuesEntry
+	(WatchPolicy)(0),             // 0: kvstore.WatchPolicy
+	(WatchEventType)(0),          // 1: kvstore.WatchEventType
+	(RepairSource)(0),            // 2: kvstore.RepairSource
+	(DiscrepancyKind)(0),         // 3: kvstore.DiscrepancyKind
+	(Consistency)(0),             // 4: kvstore.Consistency
+	(RestoreMode)(0),             // 5: kvstore.RestoreMode
+	(CompareTarget)(0),           // 6: kvstore.CompareTarget
+	(TxnOpType)(0),               // 7: kvstore.TxnOpType
+	(*HeartbeatRequest)(nil),     // 8: kvstore.HeartbeatRequest
+	(*HeartbeatResponse)(nil),    // 9: kvstore.HeartbeatResponse
+	(*WatchRequest)(nil),         // 10: kvstore.WatchRequest
+	(*WatchResponse)(nil),        // 11: kvstore.WatchResponse
+	(*WatchAllRequest)(nil),      // 12: kvstore.WatchAllRequest
+	(*CompactRequest)(nil),       // 13: kvstore.CompactRequest
+	(*SetReadOnlyRequest)(nil),   // 14: kvstore.SetReadOnlyRequest
+	(*SetReadOnlyResponse)(nil),  // 15: kvstore.SetReadOnlyResponse
+	(*ClusterInfoRequest)(nil),   // 16: kvstore.ClusterInfoRequest
+	(*ClusterServer)(nil),        // 17: kvstore.ClusterServer
+	(*ClusterInfoResponse)(nil),  // 18: kvstore.ClusterInfoResponse
+	(*StepDownRequest)(nil),      // 19: kvstore.StepDownRequest
+	(*StepDownResponse)(nil),     // 20: kvstore.StepDownResponse
+	(*VerifyRequest)(nil),        // 21: kvstore.VerifyRequest
+	(*Discrepancy)(nil),          // 22: kvstore.Discrepancy
+	(*VerifyResponse)(nil),       // 23: kvstore.VerifyResponse
+	(*DBStatsRequest)(nil),       // 24: kvstore.DBStatsRequest
+	(*DBStatsResponse)(nil),      // 25: kvstore.DBStatsResponse
+	(*CompactResponse)(nil),      // 26: kvstore.CompactResponse
+	(*GetAllRequest)(nil),        // 27: kvstore.GetAllRequest
+	(*GetAllResponse)(nil),       // 28: kvstore.GetAllResponse
+	(*DeleteRequest)(nil),        // 29: kvstore.DeleteRequest
+	(*DeleteResponse)(nil),       // 30: kvstore.DeleteResponse
+	(*DeleteManyRequest)(nil),    // 31: kvstore.DeleteManyRequest
+	(*DeleteManyResponse)(nil),   // 32: kvstore.DeleteManyResponse
+	(*PutRequest)(nil),           // 33: kvstore.PutRequest
+	(*PutResponse)(nil),          // 34: kvstore.PutResponse
+	(*PutIfAbsentResponse)(nil),  // 35: kvstore.PutIfAbsentResponse
+	(*GetRequest)(nil),           // 36: kvstore.GetRequest
+	(*GetResponse)(nil),          // 37: kvstore.GetResponse
+	(*PutIfVersionRequest)(nil),  // 38: kvstore.PutIfVersionRequest
+	(*PutIfVersionResponse)(nil), // 39: kvstore.PutIfVersionResponse
+	(*MultiGetRequest)(nil),      // 40: kvstore.MultiGetRequest
+	(*KeyValue)(nil),             // 41: kvstore.KeyValue
+	(*MultiGetResponse)(nil),     // 42: kvstore.MultiGetResponse
+	(*BackupRequest)(nil),        // 43: kvstore.BackupRequest
+	(*BackupResponse)(nil),       // 44: kvstore.BackupResponse
+	(*RestoreRequest)(nil),       // 45: kvstore.RestoreRequest
+	(*RestoreResponse)(nil),      // 46: kvstore.RestoreResponse
+	(*BulkPutResponse)(nil),      // 47: kvstore.BulkPutResponse
+	(*StatusRequest)(nil),        // 48: kvstore.StatusRequest
+	(*StatusResponse)(nil),       // 49: kvstore.StatusResponse
+	(*PeerStatus)(nil),           // 50: kvstore.PeerStatus
+	(*CountRequest)(nil),         // 51: kvstore.CountRequest
+	(*CountResponse)(nil),        // 52: kvstore.CountResponse
+	(*KeysRequest)(nil),          // 53: kvstore.KeysRequest
+	(*KeysResponse)(nil),         // 54: kvstore.KeysResponse
+	(*MultiScanRequest)(nil),     // 55: kvstore.MultiScanRequest
+	(*PrefixScan)(nil),           // 56: kvstore.PrefixScan
+	(*MultiScanResponse)(nil),    // 57: kvstore.MultiScanResponse
+	(*WatchLeaderRequest)(nil),   // 58: kvstore.WatchLeaderRequest
+	(*WatchLeaderResponse)(nil),  // 59: kvstore.WatchLeaderResponse
+	(*Compare)(nil),              // 60: kvstore.Compare
+	(*TxnOp)(nil),                // 61: kvstore.TxnOp
+	(*TxnRequest)(nil),           // 62: kvstore.TxnRequest
+	(*TxnResponse)(nil),          // 63: kvstore.TxnResponse
+	(*VersionRequest)(nil),       // 64: kvstore.VersionRequest
+	(*VersionResponse)(nil),      // 65: kvstore.VersionResponse
+	(*PingRequest)(nil),          // 66: kvstore.PingRequest
+	(*PingResponse)(nil),         // 67: kvstore.PingResponse
+	(*ClearRequest)(nil),         // 68: kvstore.ClearRequest
+	(*ClearResponse)(nil),        // 69: kvstore.ClearResponse
+	(*JoinRequest)(nil),          // 70: kvstore.JoinRequest
+	(*JoinResponse)(nil),         // 71: kvstore.JoinResponse
+	nil,                          // 72: kvstore.GetAllResponse.ValuesEntry
 }
 var file_proto_kvstore_proto_depIdxs = []int32{
-	12, // 0: kvstore.GetAllResponse.values:type_name -> kvstore.GetAllResponse.ValuesEntry
-	8,  // 1: kvstore.KvStore.Put:input_type -> kvstore.PutRequest
-	10, // 2: kvstore.KvStore.Get:input_type -> kvstore.GetRequest
-	6,  // 3: kvstore.KvStore.Delete:input_type -> kvstore.DeleteRequest
-	4,  // 4: kvstore.KvStore.GetAll:input_type -> kvstore.GetAllRequest
-	2,  // 5: kvstore.KvStore.Watch:input_type -> kvstore.WatchRequest
-	0,  // 6: kvstore.NodeCommunication.Heartbeat:input_type -> kvstore.HeartbeatRequest
-	9,  // 7: kvstore.KvStore.Put:output_type -> kvstore.PutResponse
-	11, // 8: kvstore.KvStore.Get:output_type -> kvstore.GetResponse
-	7,  // 9: kvstore.KvStore.Delete:output_type -> kvstore.DeleteResponse
-	5,  // 10: kvstore.KvStore.GetAll:output_type -> kvstore.GetAllResponse
-	3,  // 11: kvstore.KvStore.Watch:output_type -> kvstore.WatchResponse
-	1,  // 12: kvstore.NodeCommunication.Heartbeat:output_type -> kvstore.HeartbeatResponse
-	7,  // [7:13] is the sub-list for method output_type
-	1,  // [1:7] is the sub-list for method input_type
-	1,  // [1:1] is the sub-list for extension type_name
-	1,  // [1:1] is the sub-list for extension extendee
-	0,  // [0:1] is the sub-list for field type_name
+	0,  // 0: kvstore.WatchRequest.policy:type_name -> kvstore.WatchPolicy
+	1,  // 1: kvstore.WatchResponse.type:type_name -> kvstore.WatchEventType
+	0,  // 2: kvstore.WatchAllRequest.policy:type_name -> kvstore.WatchPolicy
+	17, // 3: kvstore.ClusterInfoResponse.servers:type_name -> kvstore.ClusterServer
+	2,  // 4: kvstore.VerifyRequest.source:type_name -> kvstore.RepairSource
+	3,  // 5: kvstore.Discrepancy.kind:type_name -> kvstore.DiscrepancyKind
+	22, // 6: kvstore.VerifyResponse.discrepancies:type_name -> kvstore.Discrepancy
+	72, // 7: kvstore.GetAllResponse.values:type_name -> kvstore.GetAllResponse.ValuesEntry
+	41, // 8: kvstore.GetAllResponse.sorted_values:type_name -> kvstore.KeyValue
+	4,  // 9: kvstore.GetRequest.consistency:type_name -> kvstore.Consistency
+	41, // 10: kvstore.MultiGetResponse.values:type_name -> kvstore.KeyValue
+	5,  // 11: kvstore.RestoreRequest.mode:type_name -> kvstore.RestoreMode
+	50, // 12: kvstore.StatusResponse.peers:type_name -> kvstore.PeerStatus
+	41, // 13: kvstore.PrefixScan.values:type_name -> kvstore.KeyValue
+	56, // 14: kvstore.MultiScanResponse.results:type_name -> kvstore.PrefixScan
+	6,  // 15: kvstore.Compare.target:type_name -> kvstore.CompareTarget
+	7,  // 16: kvstore.TxnOp.type:type_name -> kvstore.TxnOpType
+	60, // 17: kvstore.TxnRequest.compares:type_name -> kvstore.Compare
+	61, // 18: kvstore.TxnRequest.then_ops:type_name -> kvstore.TxnOp
+	61, // 19: kvstore.TxnRequest.else_ops:type_name -> kvstore.TxnOp
+	33, // 20: kvstore.KvStore.Put:input_type -> kvstore.PutRequest
+	36, // 21: kvstore.KvStore.Get:input_type -> kvstore.GetRequest
+	29, // 22: kvstore.KvStore.Delete:input_type -> kvstore.DeleteRequest
+	27, // 23: kvstore.KvStore.GetAll:input_type -> kvstore.GetAllRequest
+	10, // 24: kvstore.KvStore.Watch:input_type -> kvstore.WatchRequest
+	43, // 25: kvstore.KvStore.Backup:input_type -> kvstore.BackupRequest
+	45, // 26: kvstore.KvStore.Restore:input_type -> kvstore.RestoreRequest
+	48, // 27: kvstore.KvStore.Status:input_type -> kvstore.StatusRequest
+	51, // 28: kvstore.KvStore.Count:input_type -> kvstore.CountRequest
+	68, // 29: kvstore.KvStore.Clear:input_type -> kvstore.ClearRequest
+	33, // 30: kvstore.KvStore.PutIfAbsent:input_type -> kvstore.PutRequest
+	40, // 31: kvstore.KvStore.MultiGet:input_type -> kvstore.MultiGetRequest
+	38, // 32: kvstore.KvStore.PutIfVersion:input_type -> kvstore.PutIfVersionRequest
+	53, // 33: kvstore.KvStore.Keys:input_type -> kvstore.KeysRequest
+	58, // 34: kvstore.KvStore.WatchLeader:input_type -> kvstore.WatchLeaderRequest
+	66, // 35: kvstore.KvStore.Ping:input_type -> kvstore.PingRequest
+	62, // 36: kvstore.KvStore.Txn:input_type -> kvstore.TxnRequest
+	33, // 37: kvstore.KvStore.BulkPut:input_type -> kvstore.PutRequest
+	27, // 38: kvstore.KvStore.GetAllStream:input_type -> kvstore.GetAllRequest
+	12, // 39: kvstore.KvStore.WatchAll:input_type -> kvstore.WatchAllRequest
+	13, // 40: kvstore.KvStore.Compact:input_type -> kvstore.CompactRequest
+	14, // 41: kvstore.KvStore.SetReadOnly:input_type -> kvstore.SetReadOnlyRequest
+	16, // 42: kvstore.KvStore.ClusterInfo:input_type -> kvstore.ClusterInfoRequest
+	24, // 43: kvstore.KvStore.DBStats:input_type -> kvstore.DBStatsRequest
+	19, // 44: kvstore.KvStore.StepDown:input_type -> kvstore.StepDownRequest
+	55, // 45: kvstore.KvStore.MultiScan:input_type -> kvstore.MultiScanRequest
+	64, // 46: kvstore.KvStore.Version:input_type -> kvstore.VersionRequest
+	21, // 47: kvstore.KvStore.Verify:input_type -> kvstore.VerifyRequest
+	31, // 48: kvstore.KvStore.DeleteMany:input_type -> kvstore.DeleteManyRequest
+	8,  // 49: kvstore.NodeCommunication.Heartbeat:input_type -> kvstore.HeartbeatRequest
+	70, // 50: kvstore.NodeCommunication.Join:input_type -> kvstore.JoinRequest
+	34, // 51: kvstore.KvStore.Put:output_type -> kvstore.PutResponse
+	37, // 52: kvstore.KvStore.Get:output_type -> kvstore.GetResponse
+	30, // 53: kvstore.KvStore.Delete:output_type -> kvstore.DeleteResponse
+	28, // 54: kvstore.KvStore.GetAll:output_type -> kvstore.GetAllResponse
+	11, // 55: kvstore.KvStore.Watch:output_type -> kvstore.WatchResponse
+	44, // 56: kvstore.KvStore.Backup:output_type -> kvstore.BackupResponse
+	46, // 57: kvstore.KvStore.Restore:output_type -> kvstore.RestoreResponse
+	49, // 58: kvstore.KvStore.Status:output_type -> kvstore.StatusResponse
+	52, // 59: kvstore.KvStore.Count:output_type -> kvstore.CountResponse
+	69, // 60: kvstore.KvStore.Clear:output_type -> kvstore.ClearResponse
+	35, // 61: kvstore.KvStore.PutIfAbsent:output_type -> kvstore.PutIfAbsentResponse
+	42, // 62: kvstore.KvStore.MultiGet:output_type -> kvstore.MultiGetResponse
+	39, // 63: kvstore.KvStore.PutIfVersion:output_type -> kvstore.PutIfVersionResponse
+	54, // 64: kvstore.KvStore.Keys:output_type -> kvstore.KeysResponse
+	59, // 65: kvstore.KvStore.WatchLeader:output_type -> kvstore.WatchLeaderResponse
+	67, // 66: kvstore.KvStore.Ping:output_type -> kvstore.PingResponse
+	63, // 67: kvstore.KvStore.Txn:output_type -> kvstore.TxnResponse
+	47, // 68: kvstore.KvStore.BulkPut:output_type -> kvstore.BulkPutResponse
+	41, // 69: kvstore.KvStore.GetAllStream:output_type -> kvstore.KeyValue
+	11, // 70: kvstore.KvStore.WatchAll:output_type -> kvstore.WatchResponse
+	26, // 71: kvstore.KvStore.Compact:output_type -> kvstore.CompactResponse
+	15, // 72: kvstore.KvStore.SetReadOnly:output_type -> kvstore.SetReadOnlyResponse
+	18, // 73: kvstore.KvStore.ClusterInfo:output_type -> kvstore.ClusterInfoResponse
+	25, // 74: kvstore.KvStore.DBStats:output_type -> kvstore.DBStatsResponse
+	20, // 75: kvstore.KvStore.StepDown:output_type -> kvstore.StepDownResponse
+	57, // 76: kvstore.KvStore.MultiScan:output_type -> kvstore.MultiScanResponse
+	65, // 77: kvstore.KvStore.Version:output_type -> kvstore.VersionResponse
+	23, // 78: kvstore.KvStore.Verify:output_type -> kvstore.VerifyResponse
+	32, // 79: kvstore.KvStore.DeleteMany:output_type -> kvstore.DeleteManyResponse
+	9,  // 80: kvstore.NodeCommunication.Heartbeat:output_type -> kvstore.HeartbeatResponse
+	71, // 81: kvstore.NodeCommunication.Join:output_type -> kvstore.JoinResponse
+	51, // [51:82] is the sub-list for method output_type
+	20, // [20:51] is the sub-list for method input_type
+	20, // [20:20] is the sub-list for extension type_name
+	20, // [20:20] is the sub-list for extension extendee
+	0,  // [0:20] is the sub-list for field type_name
 }
 
 func init() { file_proto_kvstore_proto_init() }
@@ -679,13 +4406,14 @@ func file_proto_kvstore_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_kvstore_proto_rawDesc), len(file_proto_kvstore_proto_rawDesc)),
-			NumEnums:      0,
-			NumMessages:   13,
+			NumEnums:      8,
+			NumMessages:   65,
 			NumExtensions: 0,
 			NumServices:   2,
 		},
 		GoTypes:           file_proto_kvstore_proto_goTypes,
 		DependencyIndexes: file_proto_kvstore_proto_depIdxs,
+		EnumInfos:         file_proto_kvstore_proto_enumTypes,
 		MessageInfos:      file_proto_kvstore_proto_msgTypes,
 	}.Build()
 	File_proto_kvstore_proto = out.File
@@ -19,11 +19,35 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	KvStore_Put_FullMethodName    = "/kvstore.KvStore/Put"
-	KvStore_Get_FullMethodName    = "/kvstore.KvStore/Get"
-	KvStore_Delete_FullMethodName = "/kvstore.KvStore/Delete"
-	KvStore_GetAll_FullMethodName = "/kvstore.KvStore/GetAll"
-	KvStore_Watch_FullMethodName  = "/kvstore.KvStore/Watch"
+	KvStore_Put_FullMethodName          = "/kvstore.KvStore/Put"
+	KvStore_Get_FullMethodName          = "/kvstore.KvStore/Get"
+	KvStore_Delete_FullMethodName       = "/kvstore.KvStore/Delete"
+	KvStore_GetAll_FullMethodName       = "/kvstore.KvStore/GetAll"
+	KvStore_Watch_FullMethodName        = "/kvstore.KvStore/Watch"
+	KvStore_Backup_FullMethodName       = "/kvstore.KvStore/Backup"
+	KvStore_Restore_FullMethodName      = "/kvstore.KvStore/Restore"
+	KvStore_Status_FullMethodName       = "/kvstore.KvStore/Status"
+	KvStore_Count_FullMethodName        = "/kvstore.KvStore/Count"
+	KvStore_Clear_FullMethodName        = "/kvstore.KvStore/Clear"
+	KvStore_PutIfAbsent_FullMethodName  = "/kvstore.KvStore/PutIfAbsent"
+	KvStore_MultiGet_FullMethodName     = "/kvstore.KvStore/MultiGet"
+	KvStore_PutIfVersion_FullMethodName = "/kvstore.KvStore/PutIfVersion"
+	KvStore_Keys_FullMethodName         = "/kvstore.KvStore/Keys"
+	KvStore_WatchLeader_FullMethodName  = "/kvstore.KvStore/WatchLeader"
+	KvStore_Ping_FullMethodName         = "/kvstore.KvStore/Ping"
+	KvStore_Txn_FullMethodName          = "/kvstore.KvStore/Txn"
+	KvStore_BulkPut_FullMethodName      = "/kvstore.KvStore/BulkPut"
+	KvStore_GetAllStream_FullMethodName = "/kvstore.KvStore/GetAllStream"
+	KvStore_WatchAll_FullMethodName     = "/kvstore.KvStore/WatchAll"
+	KvStore_Compact_FullMethodName      = "/kvstore.KvStore/Compact"
+	KvStore_SetReadOnly_FullMethodName  = "/kvstore.KvStore/SetReadOnly"
+	KvStore_ClusterInfo_FullMethodName  = "/kvstore.KvStore/ClusterInfo"
+	KvStore_DBStats_FullMethodName      = "/kvstore.KvStore/DBStats"
+	KvStore_StepDown_FullMethodName     = "/kvstore.KvStore/StepDown"
+	KvStore_MultiScan_FullMethodName    = "/kvstore.KvStore/MultiScan"
+	KvStore_Version_FullMethodName      = "/kvstore.KvStore/Version"
+	KvStore_Verify_FullMethodName       = "/kvstore.KvStore/Verify"
+	KvStore_DeleteMany_FullMethodName   = "/kvstore.KvStore/DeleteMany"
 )
 
 // KvStoreClient is the client API for KvStore service.
@@ -35,6 +59,30 @@ type KvStoreClient interface {
 	Delete(ctx context.Context, in *DeleteRequest, opts ...grpc.CallOption) (*DeleteResponse, error)
 	GetAll(ctx context.Context, in *GetAllRequest, opts ...grpc.CallOption) (*GetAllResponse, error)
 	Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[WatchResponse], error)
+	Backup(ctx context.Context, in *BackupRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[BackupResponse], error)
+	Restore(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[RestoreRequest, RestoreResponse], error)
+	Status(ctx context.Context, in *StatusRequest, opts ...grpc.CallOption) (*StatusResponse, error)
+	Count(ctx context.Context, in *CountRequest, opts ...grpc.CallOption) (*CountResponse, error)
+	Clear(ctx context.Context, in *ClearRequest, opts ...grpc.CallOption) (*ClearResponse, error)
+	PutIfAbsent(ctx context.Context, in *PutRequest, opts ...grpc.CallOption) (*PutIfAbsentResponse, error)
+	MultiGet(ctx context.Context, in *MultiGetRequest, opts ...grpc.CallOption) (*MultiGetResponse, error)
+	PutIfVersion(ctx context.Context, in *PutIfVersionRequest, opts ...grpc.CallOption) (*PutIfVersionResponse, error)
+	Keys(ctx context.Context, in *KeysRequest, opts ...grpc.CallOption) (*KeysResponse, error)
+	WatchLeader(ctx context.Context, in *WatchLeaderRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[WatchLeaderResponse], error)
+	Ping(ctx context.Context, in *PingRequest, opts ...grpc.CallOption) (*PingResponse, error)
+	Txn(ctx context.Context, in *TxnRequest, opts ...grpc.CallOption) (*TxnResponse, error)
+	BulkPut(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[PutRequest, BulkPutResponse], error)
+	GetAllStream(ctx context.Context, in *GetAllRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[KeyValue], error)
+	WatchAll(ctx context.Context, in *WatchAllRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[WatchResponse], error)
+	Compact(ctx context.Context, in *CompactRequest, opts ...grpc.CallOption) (*CompactResponse, error)
+	SetReadOnly(ctx context.Context, in *SetReadOnlyRequest, opts ...grpc.CallOption) (*SetReadOnlyResponse, error)
+	ClusterInfo(ctx context.Context, in *ClusterInfoRequest, opts ...grpc.CallOption) (*ClusterInfoResponse, error)
+	DBStats(ctx context.Context, in *DBStatsRequest, opts ...grpc.CallOption) (*DBStatsResponse, error)
+	StepDown(ctx context.Context, in *StepDownRequest, opts ...grpc.CallOption) (*StepDownResponse, error)
+	MultiScan(ctx context.Context, in *MultiScanRequest, opts ...grpc.CallOption) (*MultiScanResponse, error)
+	Version(ctx context.Context, in *VersionRequest, opts ...grpc.CallOption) (*VersionResponse, error)
+	Verify(ctx context.Context, in *VerifyRequest, opts ...grpc.CallOption) (*VerifyResponse, error)
+	DeleteMany(ctx context.Context, in *DeleteManyRequest, opts ...grpc.CallOption) (*DeleteManyResponse, error)
 }
 
 type kvStoreClient struct {
@@ -104,6 +152,288 @@ func (c *kvStoreClient) Watch(ctx context.Context, in *WatchRequest, opts ...grp
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
 type KvStore_WatchClient = grpc.ServerStreamingClient[WatchResponse]
 
+func (c *kvStoreClient) Backup(ctx context.Context, in *BackupRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[BackupResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &KvStore_ServiceDesc.Streams[1], KvStore_Backup_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[BackupRequest, BackupResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type KvStore_BackupClient = grpc.ServerStreamingClient[BackupResponse]
+
+func (c *kvStoreClient) Restore(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[RestoreRequest, RestoreResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &KvStore_ServiceDesc.Streams[2], KvStore_Restore_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[RestoreRequest, RestoreResponse]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type KvStore_RestoreClient = grpc.ClientStreamingClient[RestoreRequest, RestoreResponse]
+
+func (c *kvStoreClient) Status(ctx context.Context, in *StatusRequest, opts ...grpc.CallOption) (*StatusResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(StatusResponse)
+	err := c.cc.Invoke(ctx, KvStore_Status_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kvStoreClient) Count(ctx context.Context, in *CountRequest, opts ...grpc.CallOption) (*CountResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CountResponse)
+	err := c.cc.Invoke(ctx, KvStore_Count_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kvStoreClient) Clear(ctx context.Context, in *ClearRequest, opts ...grpc.CallOption) (*ClearResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ClearResponse)
+	err := c.cc.Invoke(ctx, KvStore_Clear_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kvStoreClient) PutIfAbsent(ctx context.Context, in *PutRequest, opts ...grpc.CallOption) (*PutIfAbsentResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PutIfAbsentResponse)
+	err := c.cc.Invoke(ctx, KvStore_PutIfAbsent_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kvStoreClient) MultiGet(ctx context.Context, in *MultiGetRequest, opts ...grpc.CallOption) (*MultiGetResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(MultiGetResponse)
+	err := c.cc.Invoke(ctx, KvStore_MultiGet_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kvStoreClient) PutIfVersion(ctx context.Context, in *PutIfVersionRequest, opts ...grpc.CallOption) (*PutIfVersionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PutIfVersionResponse)
+	err := c.cc.Invoke(ctx, KvStore_PutIfVersion_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kvStoreClient) Keys(ctx context.Context, in *KeysRequest, opts ...grpc.CallOption) (*KeysResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(KeysResponse)
+	err := c.cc.Invoke(ctx, KvStore_Keys_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kvStoreClient) WatchLeader(ctx context.Context, in *WatchLeaderRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[WatchLeaderResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &KvStore_ServiceDesc.Streams[3], KvStore_WatchLeader_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchLeaderRequest, WatchLeaderResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type KvStore_WatchLeaderClient = grpc.ServerStreamingClient[WatchLeaderResponse]
+
+func (c *kvStoreClient) Ping(ctx context.Context, in *PingRequest, opts ...grpc.CallOption) (*PingResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PingResponse)
+	err := c.cc.Invoke(ctx, KvStore_Ping_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kvStoreClient) Txn(ctx context.Context, in *TxnRequest, opts ...grpc.CallOption) (*TxnResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TxnResponse)
+	err := c.cc.Invoke(ctx, KvStore_Txn_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kvStoreClient) BulkPut(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[PutRequest, BulkPutResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &KvStore_ServiceDesc.Streams[4], KvStore_BulkPut_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[PutRequest, BulkPutResponse]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type KvStore_BulkPutClient = grpc.ClientStreamingClient[PutRequest, BulkPutResponse]
+
+func (c *kvStoreClient) GetAllStream(ctx context.Context, in *GetAllRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[KeyValue], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &KvStore_ServiceDesc.Streams[5], KvStore_GetAllStream_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[GetAllRequest, KeyValue]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type KvStore_GetAllStreamClient = grpc.ServerStreamingClient[KeyValue]
+
+func (c *kvStoreClient) WatchAll(ctx context.Context, in *WatchAllRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[WatchResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &KvStore_ServiceDesc.Streams[6], KvStore_WatchAll_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchAllRequest, WatchResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type KvStore_WatchAllClient = grpc.ServerStreamingClient[WatchResponse]
+
+func (c *kvStoreClient) Compact(ctx context.Context, in *CompactRequest, opts ...grpc.CallOption) (*CompactResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CompactResponse)
+	err := c.cc.Invoke(ctx, KvStore_Compact_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kvStoreClient) SetReadOnly(ctx context.Context, in *SetReadOnlyRequest, opts ...grpc.CallOption) (*SetReadOnlyResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SetReadOnlyResponse)
+	err := c.cc.Invoke(ctx, KvStore_SetReadOnly_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kvStoreClient) ClusterInfo(ctx context.Context, in *ClusterInfoRequest, opts ...grpc.CallOption) (*ClusterInfoResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ClusterInfoResponse)
+	err := c.cc.Invoke(ctx, KvStore_ClusterInfo_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kvStoreClient) DBStats(ctx context.Context, in *DBStatsRequest, opts ...grpc.CallOption) (*DBStatsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DBStatsResponse)
+	err := c.cc.Invoke(ctx, KvStore_DBStats_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kvStoreClient) StepDown(ctx context.Context, in *StepDownRequest, opts ...grpc.CallOption) (*StepDownResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(StepDownResponse)
+	err := c.cc.Invoke(ctx, KvStore_StepDown_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kvStoreClient) MultiScan(ctx context.Context, in *MultiScanRequest, opts ...grpc.CallOption) (*MultiScanResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(MultiScanResponse)
+	err := c.cc.Invoke(ctx, KvStore_MultiScan_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kvStoreClient) Version(ctx context.Context, in *VersionRequest, opts ...grpc.CallOption) (*VersionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(VersionResponse)
+	err := c.cc.Invoke(ctx, KvStore_Version_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kvStoreClient) Verify(ctx context.Context, in *VerifyRequest, opts ...grpc.CallOption) (*VerifyResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(VerifyResponse)
+	err := c.cc.Invoke(ctx, KvStore_Verify_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kvStoreClient) DeleteMany(ctx context.Context, in *DeleteManyRequest, opts ...grpc.CallOption) (*DeleteManyResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteManyResponse)
+	err := c.cc.Invoke(ctx, KvStore_DeleteMany_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // KvStoreServer is the server API for KvStore service.
 // All implementations must embed UnimplementedKvStoreServer
 // for forward compatibility.
@@ -113,134 +443,612 @@ type KvStoreServer interface {
 	Delete(context.Context, *DeleteRequest) (*DeleteResponse, error)
 	GetAll(context.Context, *GetAllRequest) (*GetAllResponse, error)
 	Watch(*WatchRequest, grpc.ServerStreamingServer[WatchResponse]) error
+	Backup(*BackupRequest, grpc.ServerStreamingServer[BackupResponse]) error
+	Restore(grpc.ClientStreamingServer[RestoreRequest, RestoreResponse]) error
+	Status(context.Context, *StatusRequest) (*StatusResponse, error)
+	Count(context.Context, *CountRequest) (*CountResponse, error)
+	Clear(context.Context, *ClearRequest) (*ClearResponse, error)
+	PutIfAbsent(context.Context, *PutRequest) (*PutIfAbsentResponse, error)
+	MultiGet(context.Context, *MultiGetRequest) (*MultiGetResponse, error)
+	PutIfVersion(context.Context, *PutIfVersionRequest) (*PutIfVersionResponse, error)
+	Keys(context.Context, *KeysRequest) (*KeysResponse, error)
+	WatchLeader(*WatchLeaderRequest, grpc.ServerStreamingServer[WatchLeaderResponse]) error
+	Ping(context.Context, *PingRequest) (*PingResponse, error)
+	Txn(context.Context, *TxnRequest) (*TxnResponse, error)
+	BulkPut(grpc.ClientStreamingServer[PutRequest, BulkPutResponse]) error
+	GetAllStream(*GetAllRequest, grpc.ServerStreamingServer[KeyValue]) error
+	WatchAll(*WatchAllRequest, grpc.ServerStreamingServer[WatchResponse]) error
+	Compact(context.Context, *CompactRequest) (*CompactResponse, error)
+	SetReadOnly(context.Context, *SetReadOnlyRequest) (*SetReadOnlyResponse, error)
+	ClusterInfo(context.Context, *ClusterInfoRequest) (*ClusterInfoResponse, error)
+	DBStats(context.Context, *DBStatsRequest) (*DBStatsResponse, error)
+	StepDown(context.Context, *StepDownRequest) (*StepDownResponse, error)
+	MultiScan(context.Context, *MultiScanRequest) (*MultiScanResponse, error)
+	Version(context.Context, *VersionRequest) (*VersionResponse, error)
+	Verify(context.Context, *VerifyRequest) (*VerifyResponse, error)
+	DeleteMany(context.Context, *DeleteManyRequest) (*DeleteManyResponse, error)
+	mustEmbedUnimplementedKvStoreServer()
+}
+
+// UnimplementedKvStoreServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedKvStoreServer struct{}
+
+func (UnimplementedKvStoreServer) Put(context.Context, *PutRequest) (*PutResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Put not implemented")
+}
+func (UnimplementedKvStoreServer) Get(context.Context, *GetRequest) (*GetResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Get not implemented")
+}
+func (UnimplementedKvStoreServer) Delete(context.Context, *DeleteRequest) (*DeleteResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Delete not implemented")
+}
+func (UnimplementedKvStoreServer) GetAll(context.Context, *GetAllRequest) (*GetAllResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetAll not implemented")
+}
+func (UnimplementedKvStoreServer) Watch(*WatchRequest, grpc.ServerStreamingServer[WatchResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method Watch not implemented")
+}
+func (UnimplementedKvStoreServer) Backup(*BackupRequest, grpc.ServerStreamingServer[BackupResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method Backup not implemented")
+}
+func (UnimplementedKvStoreServer) Restore(grpc.ClientStreamingServer[RestoreRequest, RestoreResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method Restore not implemented")
+}
+func (UnimplementedKvStoreServer) Status(context.Context, *StatusRequest) (*StatusResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Status not implemented")
+}
+func (UnimplementedKvStoreServer) Count(context.Context, *CountRequest) (*CountResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Count not implemented")
+}
+func (UnimplementedKvStoreServer) Clear(context.Context, *ClearRequest) (*ClearResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Clear not implemented")
+}
+func (UnimplementedKvStoreServer) PutIfAbsent(context.Context, *PutRequest) (*PutIfAbsentResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PutIfAbsent not implemented")
+}
+func (UnimplementedKvStoreServer) MultiGet(context.Context, *MultiGetRequest) (*MultiGetResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method MultiGet not implemented")
+}
+func (UnimplementedKvStoreServer) PutIfVersion(context.Context, *PutIfVersionRequest) (*PutIfVersionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PutIfVersion not implemented")
+}
+func (UnimplementedKvStoreServer) Keys(context.Context, *KeysRequest) (*KeysResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Keys not implemented")
+}
+func (UnimplementedKvStoreServer) WatchLeader(*WatchLeaderRequest, grpc.ServerStreamingServer[WatchLeaderResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method WatchLeader not implemented")
+}
+func (UnimplementedKvStoreServer) Ping(context.Context, *PingRequest) (*PingResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Ping not implemented")
+}
+func (UnimplementedKvStoreServer) Txn(context.Context, *TxnRequest) (*TxnResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Txn not implemented")
+}
+func (UnimplementedKvStoreServer) BulkPut(grpc.ClientStreamingServer[PutRequest, BulkPutResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method BulkPut not implemented")
+}
+func (UnimplementedKvStoreServer) GetAllStream(*GetAllRequest, grpc.ServerStreamingServer[KeyValue]) error {
+	return status.Errorf(codes.Unimplemented, "method GetAllStream not implemented")
+}
+func (UnimplementedKvStoreServer) WatchAll(*WatchAllRequest, grpc.ServerStreamingServer[WatchResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method WatchAll not implemented")
+}
+func (UnimplementedKvStoreServer) Compact(context.Context, *CompactRequest) (*CompactResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Compact not implemented")
+}
+func (UnimplementedKvStoreServer) SetReadOnly(context.Context, *SetReadOnlyRequest) (*SetReadOnlyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetReadOnly not implemented")
+}
+func (UnimplementedKvStoreServer) ClusterInfo(context.Context, *ClusterInfoRequest) (*ClusterInfoResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ClusterInfo not implemented")
+}
+func (UnimplementedKvStoreServer) DBStats(context.Context, *DBStatsRequest) (*DBStatsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DBStats not implemented")
+}
+func (UnimplementedKvStoreServer) StepDown(context.Context, *StepDownRequest) (*StepDownResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method StepDown not implemented")
+}
+func (UnimplementedKvStoreServer) MultiScan(context.Context, *MultiScanRequest) (*MultiScanResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method MultiScan not implemented")
+}
+func (UnimplementedKvStoreServer) Version(context.Context, *VersionRequest) (*VersionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Version not implemented")
+}
+func (UnimplementedKvStoreServer) Verify(context.Context, *VerifyRequest) (*VerifyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Verify not implemented")
+}
+func (UnimplementedKvStoreServer) DeleteMany(context.Context, *DeleteManyRequest) (*DeleteManyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteMany not implemented")
+}
+func (UnimplementedKvStoreServer) mustEmbedUnimplementedKvStoreServer() {}
+func (UnimplementedKvStoreServer) testEmbeddedByValue()                 {}
+
+// UnsafeKvStoreServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to KvStoreServer will
+// result in compilation errors.
+type UnsafeKvStoreServer interface {
 	mustEmbedUnimplementedKvStoreServer()
 }
 
-// UnimplementedKvStoreServer must be embedded to have
-// forward compatible implementations.
-//
-// NOTE: this should be embedded by value instead of pointer to avoid a nil
-// pointer dereference when methods are called.
-type UnimplementedKvStoreServer struct{}
+func RegisterKvStoreServer(s grpc.ServiceRegistrar, srv KvStoreServer) {
+	// If the following call pancis, it indicates UnimplementedKvStoreServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&KvStore_ServiceDesc, srv)
+}
+
+func _KvStore_Put_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PutRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KvStoreServer).Put(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KvStore_Put_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KvStoreServer).Put(ctx, req.(*PutRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KvStore_Get_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KvStoreServer).Get(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KvStore_Get_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KvStoreServer).Get(ctx, req.(*GetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KvStore_Delete_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KvStoreServer).Delete(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KvStore_Delete_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KvStoreServer).Delete(ctx, req.(*DeleteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KvStore_GetAll_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetAllRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KvStoreServer).GetAll(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KvStore_GetAll_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KvStoreServer).GetAll(ctx, req.(*GetAllRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KvStore_Watch_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(KvStoreServer).Watch(m, &grpc.GenericServerStream[WatchRequest, WatchResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type KvStore_WatchServer = grpc.ServerStreamingServer[WatchResponse]
+
+func _KvStore_Backup_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(BackupRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(KvStoreServer).Backup(m, &grpc.GenericServerStream[BackupRequest, BackupResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type KvStore_BackupServer = grpc.ServerStreamingServer[BackupResponse]
+
+func _KvStore_Restore_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(KvStoreServer).Restore(&grpc.GenericServerStream[RestoreRequest, RestoreResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type KvStore_RestoreServer = grpc.ClientStreamingServer[RestoreRequest, RestoreResponse]
+
+func _KvStore_Status_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KvStoreServer).Status(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KvStore_Status_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KvStoreServer).Status(ctx, req.(*StatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KvStore_Count_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CountRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KvStoreServer).Count(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KvStore_Count_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KvStoreServer).Count(ctx, req.(*CountRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KvStore_Clear_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ClearRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KvStoreServer).Clear(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KvStore_Clear_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KvStoreServer).Clear(ctx, req.(*ClearRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KvStore_PutIfAbsent_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PutRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KvStoreServer).PutIfAbsent(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KvStore_PutIfAbsent_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KvStoreServer).PutIfAbsent(ctx, req.(*PutRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KvStore_MultiGet_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MultiGetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KvStoreServer).MultiGet(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KvStore_MultiGet_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KvStoreServer).MultiGet(ctx, req.(*MultiGetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KvStore_PutIfVersion_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PutIfVersionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KvStoreServer).PutIfVersion(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KvStore_PutIfVersion_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KvStoreServer).PutIfVersion(ctx, req.(*PutIfVersionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KvStore_Keys_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(KeysRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KvStoreServer).Keys(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KvStore_Keys_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KvStoreServer).Keys(ctx, req.(*KeysRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
 
-func (UnimplementedKvStoreServer) Put(context.Context, *PutRequest) (*PutResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method Put not implemented")
+func _KvStore_WatchLeader_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchLeaderRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(KvStoreServer).WatchLeader(m, &grpc.GenericServerStream[WatchLeaderRequest, WatchLeaderResponse]{ServerStream: stream})
 }
-func (UnimplementedKvStoreServer) Get(context.Context, *GetRequest) (*GetResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method Get not implemented")
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type KvStore_WatchLeaderServer = grpc.ServerStreamingServer[WatchLeaderResponse]
+
+func _KvStore_Ping_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PingRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KvStoreServer).Ping(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KvStore_Ping_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KvStoreServer).Ping(ctx, req.(*PingRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
-func (UnimplementedKvStoreServer) Delete(context.Context, *DeleteRequest) (*DeleteResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method Delete not implemented")
+
+func _KvStore_Txn_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TxnRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KvStoreServer).Txn(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KvStore_Txn_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KvStoreServer).Txn(ctx, req.(*TxnRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
-func (UnimplementedKvStoreServer) GetAll(context.Context, *GetAllRequest) (*GetAllResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method GetAll not implemented")
+
+func _KvStore_BulkPut_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(KvStoreServer).BulkPut(&grpc.GenericServerStream[PutRequest, BulkPutResponse]{ServerStream: stream})
 }
-func (UnimplementedKvStoreServer) Watch(*WatchRequest, grpc.ServerStreamingServer[WatchResponse]) error {
-	return status.Errorf(codes.Unimplemented, "method Watch not implemented")
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type KvStore_BulkPutServer = grpc.ClientStreamingServer[PutRequest, BulkPutResponse]
+
+func _KvStore_GetAllStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(GetAllRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(KvStoreServer).GetAllStream(m, &grpc.GenericServerStream[GetAllRequest, KeyValue]{ServerStream: stream})
 }
-func (UnimplementedKvStoreServer) mustEmbedUnimplementedKvStoreServer() {}
-func (UnimplementedKvStoreServer) testEmbeddedByValue()                 {}
 
-// UnsafeKvStoreServer may be embedded to opt out of forward compatibility for this service.
-// Use of this interface is not recommended, as added methods to KvStoreServer will
-// result in compilation errors.
-type UnsafeKvStoreServer interface {
-	mustEmbedUnimplementedKvStoreServer()
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type KvStore_GetAllStreamServer = grpc.ServerStreamingServer[KeyValue]
+
+func _KvStore_WatchAll_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchAllRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(KvStoreServer).WatchAll(m, &grpc.GenericServerStream[WatchAllRequest, WatchResponse]{ServerStream: stream})
 }
 
-func RegisterKvStoreServer(s grpc.ServiceRegistrar, srv KvStoreServer) {
-	// If the following call pancis, it indicates UnimplementedKvStoreServer was
-	// embedded by pointer and is nil.  This will cause panics if an
-	// unimplemented method is ever invoked, so we test this at initialization
-	// time to prevent it from happening at runtime later due to I/O.
-	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
-		t.testEmbeddedByValue()
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type KvStore_WatchAllServer = grpc.ServerStreamingServer[WatchResponse]
+
+func _KvStore_Compact_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CompactRequest)
+	if err := dec(in); err != nil {
+		return nil, err
 	}
-	s.RegisterService(&KvStore_ServiceDesc, srv)
+	if interceptor == nil {
+		return srv.(KvStoreServer).Compact(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KvStore_Compact_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KvStoreServer).Compact(ctx, req.(*CompactRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-func _KvStore_Put_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(PutRequest)
+func _KvStore_SetReadOnly_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetReadOnlyRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(KvStoreServer).Put(ctx, in)
+		return srv.(KvStoreServer).SetReadOnly(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: KvStore_Put_FullMethodName,
+		FullMethod: KvStore_SetReadOnly_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(KvStoreServer).Put(ctx, req.(*PutRequest))
+		return srv.(KvStoreServer).SetReadOnly(ctx, req.(*SetReadOnlyRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _KvStore_Get_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(GetRequest)
+func _KvStore_ClusterInfo_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ClusterInfoRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(KvStoreServer).Get(ctx, in)
+		return srv.(KvStoreServer).ClusterInfo(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: KvStore_Get_FullMethodName,
+		FullMethod: KvStore_ClusterInfo_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(KvStoreServer).Get(ctx, req.(*GetRequest))
+		return srv.(KvStoreServer).ClusterInfo(ctx, req.(*ClusterInfoRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _KvStore_Delete_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(DeleteRequest)
+func _KvStore_DBStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DBStatsRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(KvStoreServer).Delete(ctx, in)
+		return srv.(KvStoreServer).DBStats(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: KvStore_Delete_FullMethodName,
+		FullMethod: KvStore_DBStats_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(KvStoreServer).Delete(ctx, req.(*DeleteRequest))
+		return srv.(KvStoreServer).DBStats(ctx, req.(*DBStatsRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _KvStore_GetAll_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(GetAllRequest)
+func _KvStore_StepDown_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StepDownRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(KvStoreServer).GetAll(ctx, in)
+		return srv.(KvStoreServer).StepDown(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: KvStore_GetAll_FullMethodName,
+		FullMethod: KvStore_StepDown_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(KvStoreServer).GetAll(ctx, req.(*GetAllRequest))
+		return srv.(KvStoreServer).StepDown(ctx, req.(*StepDownRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _KvStore_Watch_Handler(srv interface{}, stream grpc.ServerStream) error {
-	m := new(WatchRequest)
-	if err := stream.RecvMsg(m); err != nil {
-		return err
+func _KvStore_MultiScan_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MultiScanRequest)
+	if err := dec(in); err != nil {
+		return nil, err
 	}
-	return srv.(KvStoreServer).Watch(m, &grpc.GenericServerStream[WatchRequest, WatchResponse]{ServerStream: stream})
+	if interceptor == nil {
+		return srv.(KvStoreServer).MultiScan(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KvStore_MultiScan_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KvStoreServer).MultiScan(ctx, req.(*MultiScanRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
-type KvStore_WatchServer = grpc.ServerStreamingServer[WatchResponse]
+func _KvStore_Version_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(VersionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KvStoreServer).Version(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KvStore_Version_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KvStoreServer).Version(ctx, req.(*VersionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KvStore_Verify_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(VerifyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KvStoreServer).Verify(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KvStore_Verify_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KvStoreServer).Verify(ctx, req.(*VerifyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KvStore_DeleteMany_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteManyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KvStoreServer).DeleteMany(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KvStore_DeleteMany_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KvStoreServer).DeleteMany(ctx, req.(*DeleteManyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
 
 // KvStore_ServiceDesc is the grpc.ServiceDesc for KvStore service.
 // It's only intended for direct use with grpc.RegisterService,
@@ -265,6 +1073,78 @@ var KvStore_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetAll",
 			Handler:    _KvStore_GetAll_Handler,
 		},
+		{
+			MethodName: "Status",
+			Handler:    _KvStore_Status_Handler,
+		},
+		{
+			MethodName: "Count",
+			Handler:    _KvStore_Count_Handler,
+		},
+		{
+			MethodName: "Clear",
+			Handler:    _KvStore_Clear_Handler,
+		},
+		{
+			MethodName: "PutIfAbsent",
+			Handler:    _KvStore_PutIfAbsent_Handler,
+		},
+		{
+			MethodName: "MultiGet",
+			Handler:    _KvStore_MultiGet_Handler,
+		},
+		{
+			MethodName: "PutIfVersion",
+			Handler:    _KvStore_PutIfVersion_Handler,
+		},
+		{
+			MethodName: "Keys",
+			Handler:    _KvStore_Keys_Handler,
+		},
+		{
+			MethodName: "Ping",
+			Handler:    _KvStore_Ping_Handler,
+		},
+		{
+			MethodName: "Txn",
+			Handler:    _KvStore_Txn_Handler,
+		},
+		{
+			MethodName: "Compact",
+			Handler:    _KvStore_Compact_Handler,
+		},
+		{
+			MethodName: "SetReadOnly",
+			Handler:    _KvStore_SetReadOnly_Handler,
+		},
+		{
+			MethodName: "ClusterInfo",
+			Handler:    _KvStore_ClusterInfo_Handler,
+		},
+		{
+			MethodName: "DBStats",
+			Handler:    _KvStore_DBStats_Handler,
+		},
+		{
+			MethodName: "StepDown",
+			Handler:    _KvStore_StepDown_Handler,
+		},
+		{
+			MethodName: "MultiScan",
+			Handler:    _KvStore_MultiScan_Handler,
+		},
+		{
+			MethodName: "Version",
+			Handler:    _KvStore_Version_Handler,
+		},
+		{
+			MethodName: "Verify",
+			Handler:    _KvStore_Verify_Handler,
+		},
+		{
+			MethodName: "DeleteMany",
+			Handler:    _KvStore_DeleteMany_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -272,12 +1152,43 @@ var KvStore_ServiceDesc = grpc.ServiceDesc{
 			Handler:       _KvStore_Watch_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "Backup",
+			Handler:       _KvStore_Backup_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Restore",
+			Handler:       _KvStore_Restore_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "WatchLeader",
+			Handler:       _KvStore_WatchLeader_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "BulkPut",
+			Handler:       _KvStore_BulkPut_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "GetAllStream",
+			Handler:       _KvStore_GetAllStream_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "WatchAll",
+			Handler:       _KvStore_WatchAll_Handler,
+			ServerStreams: true,
+		},
 	},
 	Metadata: "proto/kvstore.proto",
 }
 
 const (
 	NodeCommunication_Heartbeat_FullMethodName = "/kvstore.NodeCommunication/Heartbeat"
+	NodeCommunication_Join_FullMethodName      = "/kvstore.NodeCommunication/Join"
 )
 
 // NodeCommunicationClient is the client API for NodeCommunication service.
@@ -285,6 +1196,7 @@ const (
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type NodeCommunicationClient interface {
 	Heartbeat(ctx context.Context, in *HeartbeatRequest, opts ...grpc.CallOption) (*HeartbeatResponse, error)
+	Join(ctx context.Context, in *JoinRequest, opts ...grpc.CallOption) (*JoinResponse, error)
 }
 
 type nodeCommunicationClient struct {
@@ -305,11 +1217,22 @@ func (c *nodeCommunicationClient) Heartbeat(ctx context.Context, in *HeartbeatRe
 	return out, nil
 }
 
+func (c *nodeCommunicationClient) Join(ctx context.Context, in *JoinRequest, opts ...grpc.CallOption) (*JoinResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(JoinResponse)
+	err := c.cc.Invoke(ctx, NodeCommunication_Join_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // NodeCommunicationServer is the server API for NodeCommunication service.
 // All implementations must embed UnimplementedNodeCommunicationServer
 // for forward compatibility.
 type NodeCommunicationServer interface {
 	Heartbeat(context.Context, *HeartbeatRequest) (*HeartbeatResponse, error)
+	Join(context.Context, *JoinRequest) (*JoinResponse, error)
 	mustEmbedUnimplementedNodeCommunicationServer()
 }
 
@@ -323,6 +1246,9 @@ type UnimplementedNodeCommunicationServer struct{}
 func (UnimplementedNodeCommunicationServer) Heartbeat(context.Context, *HeartbeatRequest) (*HeartbeatResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Heartbeat not implemented")
 }
+func (UnimplementedNodeCommunicationServer) Join(context.Context, *JoinRequest) (*JoinResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Join not implemented")
+}
 func (UnimplementedNodeCommunicationServer) mustEmbedUnimplementedNodeCommunicationServer() {}
 func (UnimplementedNodeCommunicationServer) testEmbeddedByValue()                           {}
 
@@ -362,6 +1288,24 @@ func _NodeCommunication_Heartbeat_Handler(srv interface{}, ctx context.Context,
 	return interceptor(ctx, in, info, handler)
 }
 
+func _NodeCommunication_Join_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(JoinRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NodeCommunicationServer).Join(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NodeCommunication_Join_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NodeCommunicationServer).Join(ctx, req.(*JoinRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // NodeCommunication_ServiceDesc is the grpc.ServiceDesc for NodeCommunication service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -373,6 +1317,10 @@ var NodeCommunication_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Heartbeat",
 			Handler:    _NodeCommunication_Heartbeat_Handler,
 		},
+		{
+			MethodName: "Join",
+			Handler:    _NodeCommunication_Join_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "proto/kvstore.proto",
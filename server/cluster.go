@@ -0,0 +1,122 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// NodeConfig descreve um nó do cluster. O endereço do raft e o endereço
+// usado pelos clientes (gRPC do KvStore) podem ser diferentes.
+type NodeConfig struct {
+	ID            string `json:"id"`
+	RaftAddress   string `json:"raft_address"`
+	ClientAddress string `json:"client_address"`
+}
+
+// ClusterConfig é a topologia do cluster, lida de um arquivo JSON:
+//
+//	{
+//	  "bootstrap": "1",
+//	  "nodes": [
+//	    {"id": "1", "raft_address": "node1:7000", "client_address": "node1:50051"},
+//	    {"id": "2", "raft_address": "node2:7000", "client_address": "node2:50051"}
+//	  ]
+//	}
+//
+// bootstrap é o id do nó que inicia o cluster; vazio usa o primeiro da lista.
+type ClusterConfig struct {
+	Bootstrap string       `json:"bootstrap"`
+	Nodes     []NodeConfig `json:"nodes"`
+}
+
+// LoadClusterConfig lê e valida o arquivo de topologia
+func LoadClusterConfig(path string) (*ClusterConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read cluster config: %w", err)
+	}
+
+	var c ClusterConfig
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("parse cluster config %s: %w", path, err)
+	}
+	if err := c.validate(); err != nil {
+		return nil, fmt.Errorf("invalid cluster config %s: %w", path, err)
+	}
+
+	return &c, nil
+}
+
+func (c *ClusterConfig) validate() error {
+	if len(c.Nodes) == 0 {
+		return errors.New("no nodes defined")
+	}
+
+	seen := make(map[string]bool, len(c.Nodes))
+	for i, n := range c.Nodes {
+		if n.ID == "" {
+			return fmt.Errorf("node %d has no id", i)
+		}
+		if seen[n.ID] {
+			return fmt.Errorf("duplicate node id %q", n.ID)
+		}
+		seen[n.ID] = true
+
+		if n.RaftAddress == "" || n.ClientAddress == "" {
+			return fmt.Errorf("node %q needs both raft_address and client_address", n.ID)
+		}
+	}
+
+	if c.Bootstrap == "" {
+		c.Bootstrap = c.Nodes[0].ID
+	}
+	if !seen[c.Bootstrap] {
+		return fmt.Errorf("bootstrap node %q is not in the node list", c.Bootstrap)
+	}
+
+	return nil
+}
+
+// Node busca um nó pelo id
+func (c *ClusterConfig) Node(id string) (NodeConfig, bool) {
+	for _, n := range c.Nodes {
+		if n.ID == id {
+			return n, true
+		}
+	}
+	return NodeConfig{}, false
+}
+
+// Peers devolve todos os nós exceto o de id informado
+func (c *ClusterConfig) Peers(id string) []NodeConfig {
+	var peers []NodeConfig
+	for _, n := range c.Nodes {
+		if n.ID != id {
+			peers = append(peers, n)
+		}
+	}
+	return peers
+}
+
+// clusterFromEnv monta a topologia a partir das variáveis antigas (PORT e
+// PEERS) para quem ainda não usa o arquivo. PEERS só tem endereços, então
+// eles servem também de id, e o nó "1" continua sendo o bootstrap.
+func clusterFromEnv(id string) *ClusterConfig {
+	self := NodeConfig{
+		ID:            id,
+		RaftAddress:   "localhost:" + os.Getenv("PORT"),
+		ClientAddress: "localhost:" + os.Getenv("PORT"),
+	}
+
+	c := &ClusterConfig{Bootstrap: "1", Nodes: []NodeConfig{self}}
+	if peers := os.Getenv("PEERS"); peers != "" {
+		for _, addr := range strings.Split(peers, ",") {
+			c.Nodes = append(c.Nodes, NodeConfig{ID: addr, RaftAddress: addr, ClientAddress: addr})
+		}
+	}
+
+	return c
+}
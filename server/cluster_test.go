@@ -0,0 +1,112 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "cluster.json")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	return path
+}
+
+func TestLoadClusterConfig(t *testing.T) {
+	c, err := LoadClusterConfig("../cluster.example.json")
+	if err != nil {
+		t.Fatalf("LoadClusterConfig() failed: %v", err)
+	}
+
+	if c.Bootstrap != "1" {
+		t.Errorf("Bootstrap = %q, expected 1", c.Bootstrap)
+	}
+	if len(c.Nodes) != 3 {
+		t.Fatalf("Expected 3 nodes, got %d", len(c.Nodes))
+	}
+
+	node, ok := c.Node("2")
+	expected := NodeConfig{ID: "2", RaftAddress: "kvstore-server-02:7000", ClientAddress: "kvstore-server-02:50051"}
+	if !ok || node != expected {
+		t.Errorf("Node(2) = %+v, %v; expected %+v", node, ok, expected)
+	}
+	if _, ok := c.Node("4"); ok {
+		t.Error("Node(4) should not exist")
+	}
+
+	var peers []string
+	for _, p := range c.Peers("2") {
+		peers = append(peers, p.ID)
+	}
+	if !reflect.DeepEqual(peers, []string{"1", "3"}) {
+		t.Errorf("Peers(2) = %v, expected [1 3]", peers)
+	}
+}
+
+func TestLoadClusterConfig_DefaultBootstrap(t *testing.T) {
+	path := writeConfig(t, `{"nodes": [
+		{"id": "a", "raft_address": "a:7000", "client_address": "a:50051"},
+		{"id": "b", "raft_address": "b:7000", "client_address": "b:50051"}
+	]}`)
+
+	c, err := LoadClusterConfig(path)
+	if err != nil {
+		t.Fatalf("LoadClusterConfig() failed: %v", err)
+	}
+	if c.Bootstrap != "a" {
+		t.Errorf("Bootstrap = %q, expected the first node", c.Bootstrap)
+	}
+}
+
+func TestLoadClusterConfig_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{"malformed", `{"nodes": [`, "parse cluster config"},
+		{"empty", `{"nodes": []}`, "no nodes defined"},
+		{"missing id", `{"nodes": [{"raft_address": "a:7000", "client_address": "a:50051"}]}`, "has no id"},
+		{"duplicate id", `{"nodes": [
+			{"id": "a", "raft_address": "a:7000", "client_address": "a:50051"},
+			{"id": "a", "raft_address": "b:7000", "client_address": "b:50051"}
+		]}`, `duplicate node id "a"`},
+		{"missing address", `{"nodes": [{"id": "a", "raft_address": "a:7000"}]}`, "needs both"},
+		{"unknown bootstrap", `{"bootstrap": "z", "nodes": [{"id": "a", "raft_address": "a:7000", "client_address": "a:50051"}]}`, `bootstrap node "z"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadClusterConfig(writeConfig(t, tt.content))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("LoadClusterConfig() error = %v, expected it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	if _, err := LoadClusterConfig(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("Expected an error for a missing file")
+	}
+}
+
+func TestClusterFromEnv(t *testing.T) {
+	t.Setenv("PORT", "50052")
+	t.Setenv("PEERS", "node1:50051,node3:50051")
+
+	c := clusterFromEnv("2")
+
+	self, ok := c.Node("2")
+	if !ok || self.RaftAddress != "localhost:50052" || self.ClientAddress != "localhost:50052" {
+		t.Errorf("Node(2) = %+v, %v", self, ok)
+	}
+	if c.Bootstrap != "1" {
+		t.Errorf("Bootstrap = %q, expected 1", c.Bootstrap)
+	}
+	if peers := c.Peers("2"); len(peers) != 2 || peers[0].ClientAddress != "node1:50051" {
+		t.Errorf("Unexpected peers: %+v", peers)
+	}
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/carvalhodanielg/kvstore/internal/constants"
+	"github.com/carvalhodanielg/kvstore/store"
+)
+
+// nodePaths são os arquivos que um nó grava em disco
+type nodePaths struct {
+	DB      string
+	WAL     string
+	RaftDir string
+}
+
+// dataDirPaths coloca o banco, o WAL e o raft do nó em <dataDir>/<nodeID>,
+// criando o diretório. O raft já usa um subdiretório com o id dentro do
+// RaftDir, então RaftDir é o próprio dataDir e tudo do nó fica junto.
+func dataDirPaths(dataDir, nodeID string) (nodePaths, error) {
+	nodeDir := filepath.Join(dataDir, nodeID)
+	if err := os.MkdirAll(nodeDir, 0755); err != nil {
+		return nodePaths{}, fmt.Errorf("create data dir %s: %w", nodeDir, err)
+	}
+
+	return nodePaths{
+		DB:      filepath.Join(nodeDir, constants.DBFileName),
+		WAL:     filepath.Join(nodeDir, store.WALFileName),
+		RaftDir: dataDir,
+	}, nil
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/carvalhodanielg/kvstore/internal/constants"
+	"github.com/carvalhodanielg/kvstore/store"
+)
+
+func TestDataDirPaths(t *testing.T) {
+	dataDir := filepath.Join(t.TempDir(), "data")
+
+	paths, err := dataDirPaths(dataDir, "node1")
+	if err != nil {
+		t.Fatalf("dataDirPaths() failed: %v", err)
+	}
+
+	store.SetWALPath(paths.WAL)
+	defer store.SetWALPath(store.WALFileName)
+
+	db := InitDb(paths.DB, constants.BucketStore, store.DefaultDBConfig())
+	defer db.Close()
+
+	kv := store.NewKVStore(store.WithDB(db), store.WithRaftDir(paths.RaftDir))
+	kv.Put("key", "value")
+
+	// Banco e WAL ficam em <data-dir>/<node-id>
+	nodeDir := filepath.Join(dataDir, "node1")
+	for _, name := range []string{constants.DBFileName, store.WALFileName} {
+		if _, err := os.Stat(filepath.Join(nodeDir, name)); err != nil {
+			t.Errorf("Expected %s in %s: %v", name, nodeDir, err)
+		}
+	}
+
+	// O Open do raft grava em <RaftDir>/<node-id>, o mesmo diretório
+	if got := filepath.Join(paths.RaftDir, "node1"); got != nodeDir {
+		t.Errorf("Raft files would go to %s, expected %s", got, nodeDir)
+	}
+}
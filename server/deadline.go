@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// deadlineInterceptor limita cada RPC unária ao menor entre o deadline do
+// cliente e max (0 desliga o limite do servidor). O handler roda em outra
+// goroutine: se o prazo acabar antes, a RPC responde DeadlineExceeded na hora
+// e o worker do gRPC fica livre, mesmo com o handler preso no bbolt ou no
+// raft. O resultado atrasado do handler é descartado.
+//
+// Streams (Watch, Backup, Restore) não passam por aqui, já que são longos
+// por natureza e respeitam o contexto do próprio stream.
+func deadlineInterceptor(max time.Duration) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if max > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, max)
+			defer cancel()
+		}
+
+		type result struct {
+			resp any
+			err  error
+		}
+
+		//buffer de 1 para o handler atrasado não ficar bloqueado para sempre
+		done := make(chan result, 1)
+		go func() {
+			resp, err := handler(ctx, req)
+			done <- result{resp, err}
+		}()
+
+		select {
+		case r := <-done:
+			return r.resp, r.err
+		case <-ctx.Done():
+			return nil, status.FromContextError(ctx.Err()).Err()
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	pb "github.com/carvalhodanielg/kvstore/pb/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// blockingHandler simula um handler preso (ex.: transação do bbolt travada)
+func blockingHandler(release <-chan struct{}) grpc.UnaryHandler {
+	return func(ctx context.Context, req any) (any, error) {
+		<-release
+		return &pb.PutResponse{Success: true}, nil
+	}
+}
+
+func TestDeadlineInterceptor(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+
+	tests := []struct {
+		name     string
+		max      time.Duration
+		deadline time.Duration
+	}{
+		{"client deadline", 0, 20 * time.Millisecond},
+		{"server max", 20 * time.Millisecond, 0},
+		{"client shorter than server", time.Hour, 20 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.deadline > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.deadline)
+				defer cancel()
+			}
+
+			start := time.Now()
+			_, err := deadlineInterceptor(tt.max)(ctx, &pb.PutRequest{}, &grpc.UnaryServerInfo{}, blockingHandler(release))
+
+			if status.Code(err) != codes.DeadlineExceeded {
+				t.Errorf("Expected DeadlineExceeded, got %v", err)
+			}
+			if elapsed := time.Since(start); elapsed > time.Second {
+				t.Errorf("Interceptor blocked for %v waiting on the handler", elapsed)
+			}
+		})
+	}
+}
+
+func TestDeadlineInterceptor_PassesResult(t *testing.T) {
+	errBoom := status.Error(codes.Internal, "boom")
+
+	handler := func(ctx context.Context, req any) (any, error) {
+		if _, ok := ctx.Deadline(); !ok {
+			t.Error("Expected the handler context to carry the server deadline")
+		}
+		return "ok", errBoom
+	}
+
+	resp, err := deadlineInterceptor(time.Minute)(context.Background(), nil, &grpc.UnaryServerInfo{}, handler)
+	if resp != "ok" || !errors.Is(err, errBoom) {
+		t.Errorf("Expected handler result to pass through, got %v, %v", resp, err)
+	}
+}
+
+// stuckServer trava o Put até release ser fechado
+type stuckServer struct {
+	pb.UnimplementedKvStoreServer
+	release chan struct{}
+}
+
+func (s *stuckServer) Put(ctx context.Context, _ *pb.PutRequest) (*pb.PutResponse, error) {
+	<-s.release
+	return &pb.PutResponse{Success: true}, nil
+}
+
+func TestServer_MaxRequestDuration(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+
+	stuck := &stuckServer{release: make(chan struct{})}
+	defer close(stuck.release)
+
+	srv := grpc.NewServer(grpc.ChainUnaryInterceptor(deadlineInterceptor(50 * time.Millisecond)))
+	pb.RegisterKvStoreServer(srv, stuck)
+	go srv.Serve(lis)
+	defer srv.Stop()
+
+	client := createTestClient(t, lis.Addr().String())
+
+	// Sem deadline do cliente, quem encerra a RPC é o limite do servidor
+	start := time.Now()
+	_, err = client.Put(context.Background(), &pb.PutRequest{Key: "k", Value: "v"})
+	if status.Code(err) != codes.DeadlineExceeded {
+		t.Errorf("Expected DeadlineExceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Put took %v, expected the server to give up after 50ms", elapsed)
+	}
+}
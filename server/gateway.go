@@ -0,0 +1,171 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// gatewayMaxBody limita o corpo de um PUT no gateway HTTP. Valores acima dos
+// limites da store já são recusados pelo Put; isso só evita ler um corpo
+// gigante inteiro para a memória antes.
+const gatewayMaxBody = 8 << 20
+
+// gatewayValue é o corpo JSON aceito pelo PUT /kv/{key}
+type gatewayValue struct {
+	Value string `json:"value"`
+}
+
+// gatewayEntry é a resposta do GET /kv/{key}; os tempos são Unix em nanossegundos
+type gatewayEntry struct {
+	Key       string `json:"key"`
+	Value     string `json:"value"`
+	Revision  uint64 `json:"revision"`
+	CreatedAt int64  `json:"created_at,omitempty"`
+	UpdatedAt int64  `json:"updated_at,omitempty"`
+}
+
+// gatewayHandler expõe a store em HTTP para quem não fala gRPC:
+//
+//	GET    /kv         todas as chaves, como {"values": {...}}
+//	GET    /kv/{key}   a chave com a revisão e os tempos, 404 se não existir
+//	PUT    /kv/{key}   grava o corpo como valor; com Content-Type
+//	                   application/json o corpo é {"value": "..."}
+//	DELETE /kv/{key}
+//
+// As chaves podem ter "/". Erros voltam como {"error": "..."} com o status
+// HTTP equivalente ao código gRPC.
+func (s *server) gatewayHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /kv", s.gatewayGetAll)
+	mux.HandleFunc("GET /kv/{key...}", s.gatewayGet)
+	mux.HandleFunc("PUT /kv/{key...}", s.gatewayPut)
+	mux.HandleFunc("DELETE /kv/{key...}", s.gatewayDelete)
+	return mux
+}
+
+func (s *server) gatewayGetAll(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]map[string]string{"values": s.store.GetAll()})
+}
+
+func (s *server) gatewayGet(w http.ResponseWriter, r *http.Request) {
+	key := r.PathValue("key")
+
+	e, err := s.store.GetEntry(r.Context(), key)
+	if err != nil {
+		writeGatewayError(w, storeError(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, gatewayEntry{
+		Key:       key,
+		Value:     e.Value,
+		Revision:  e.Revision,
+		CreatedAt: unixNanos(e.CreatedAt),
+		UpdatedAt: unixNanos(e.UpdatedAt),
+	})
+}
+
+func (s *server) gatewayPut(w http.ResponseWriter, r *http.Request) {
+	if err := s.checkWritable(); err != nil {
+		writeGatewayError(w, err)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, gatewayMaxBody))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("body larger than %d bytes", tooLarge.Limit))
+			return
+		}
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	value := string(body)
+	if mt, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mt == "application/json" {
+		var v gatewayValue
+		if err := json.Unmarshal(body, &v); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid JSON body: %v", err))
+			return
+		}
+		value = v.Value
+	}
+
+	if err := s.store.PutContext(r.Context(), r.PathValue("key"), value); err != nil {
+		writeGatewayError(w, storeError(err))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *server) gatewayDelete(w http.ResponseWriter, r *http.Request) {
+	if err := s.checkWritable(); err != nil {
+		writeGatewayError(w, err)
+		return
+	}
+
+	if err := s.store.DeleteContext(r.Context(), r.PathValue("key")); err != nil {
+		writeGatewayError(w, storeError(err))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// httpStatus traduz o código gRPC de err para o status HTTP equivalente
+func httpStatus(err error) int {
+	switch status.Code(err) {
+	case codes.InvalidArgument:
+		return http.StatusBadRequest
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.FailedPrecondition:
+		return http.StatusPreconditionFailed
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func writeGatewayError(w http.ResponseWriter, err error) {
+	writeError(w, httpStatus(err), status.Convert(err).Message())
+}
+
+func writeError(w http.ResponseWriter, code int, msg string) {
+	writeJSON(w, code, map[string]string{"error": msg})
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.Debug("failed to write gateway response", "error", err)
+	}
+}
+
+// serveGateway sobe o gateway HTTP na porta informada, como o serveMetrics
+func serveGateway(port int, s *server) *http.Server {
+	srv := &http.Server{Addr: fmt.Sprintf(":%d", port), Handler: s.gatewayHandler()}
+
+	go func() {
+		slog.Info("http gateway listening", "address", srv.Addr)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("http gateway failed", "error", err)
+		}
+	}()
+
+	return srv
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/carvalhodanielg/kvstore/store"
+)
+
+// setupGateway sobe o gateway HTTP de um servidor com store em memória
+func setupGateway(t *testing.T, opts ...store.Option) (*httptest.Server, *server) {
+	store.SetWALPath(filepath.Join(t.TempDir(), store.WALFileName))
+	t.Cleanup(func() { store.SetWALPath(store.WALFileName) })
+
+	s := &server{store: store.NewKVStore(append([]store.Option{store.WithBackend(store.NewMemoryBackend())}, opts...)...)}
+	ts := httptest.NewServer(s.gatewayHandler())
+	t.Cleanup(ts.Close)
+
+	return ts, s
+}
+
+// doRequest faz a requisição e devolve o status e o corpo
+func doRequest(t *testing.T, method, url, contentType, body string) (int, string) {
+	t.Helper()
+
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s failed: %v", method, url, err)
+	}
+	defer resp.Body.Close()
+
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode, string(b)
+}
+
+func TestGateway_Routes(t *testing.T) {
+	ts, s := setupGateway(t)
+
+	if code, body := doRequest(t, http.MethodPut, ts.URL+"/kv/user:1", "text/plain", "Daniel"); code != http.StatusNoContent {
+		t.Fatalf("PUT returned %d: %s", code, body)
+	}
+	if got := s.store.Get("user:1"); got != "Daniel" {
+		t.Errorf("expected user:1=Daniel in the store, got %q", got)
+	}
+
+	// corpo JSON e chave com barra
+	if code, body := doRequest(t, http.MethodPut, ts.URL+"/kv/a/b", "application/json; charset=utf-8", `{"value":"{\"x\":1}"}`); code != http.StatusNoContent {
+		t.Fatalf("PUT with JSON returned %d: %s", code, body)
+	}
+
+	code, body := doRequest(t, http.MethodGet, ts.URL+"/kv/a/b", "", "")
+	if code != http.StatusOK {
+		t.Fatalf("GET returned %d: %s", code, body)
+	}
+	var entry gatewayEntry
+	if err := json.Unmarshal([]byte(body), &entry); err != nil {
+		t.Fatalf("invalid GET body %q: %v", body, err)
+	}
+	if entry.Key != "a/b" || entry.Value != `{"x":1}` || entry.Revision == 0 || entry.UpdatedAt == 0 {
+		t.Errorf("unexpected entry %+v", entry)
+	}
+
+	code, body = doRequest(t, http.MethodGet, ts.URL+"/kv", "", "")
+	if code != http.StatusOK {
+		t.Fatalf("GET /kv returned %d: %s", code, body)
+	}
+	var all struct {
+		Values map[string]string `json:"values"`
+	}
+	if err := json.Unmarshal([]byte(body), &all); err != nil {
+		t.Fatalf("invalid GET /kv body %q: %v", body, err)
+	}
+	if len(all.Values) != 2 || all.Values["user:1"] != "Daniel" {
+		t.Errorf("unexpected values %v", all.Values)
+	}
+
+	if code, body := doRequest(t, http.MethodDelete, ts.URL+"/kv/user:1", "", ""); code != http.StatusNoContent {
+		t.Fatalf("DELETE returned %d: %s", code, body)
+	}
+
+	code, body = doRequest(t, http.MethodGet, ts.URL+"/kv/user:1", "", "")
+	if code != http.StatusNotFound || !strings.Contains(body, `"error"`) {
+		t.Errorf("expected 404 with an error body after DELETE, got %d: %s", code, body)
+	}
+}
+
+func TestGateway_Errors(t *testing.T) {
+	ts, _ := setupGateway(t, store.WithLimits(store.Limits{MaxKeySize: 4, MaxValueSize: 4}))
+
+	tests := []struct {
+		name        string
+		method      string
+		path        string
+		contentType string
+		body        string
+		want        int
+	}{
+		{"value too large", http.MethodPut, "/kv/k", "", "too large", http.StatusBadRequest},
+		{"key too large", http.MethodPut, "/kv/long-key", "", "v", http.StatusBadRequest},
+		{"invalid JSON", http.MethodPut, "/kv/k", "application/json", "{", http.StatusBadRequest},
+		{"wrong method", http.MethodPost, "/kv/k", "", "v", http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code, body := doRequest(t, tt.method, ts.URL+tt.path, tt.contentType, tt.body); code != tt.want {
+				t.Errorf("expected %d, got %d: %s", tt.want, code, body)
+			}
+		})
+	}
+}
+
+func TestGateway_ReadOnly(t *testing.T) {
+	ts, s := setupGateway(t)
+	s.store.Put("k", "v")
+	s.readOnly.Store(true)
+
+	if code, body := doRequest(t, http.MethodPut, ts.URL+"/kv/k", "", "new"); code != http.StatusPreconditionFailed {
+		t.Errorf("PUT in read-only mode returned %d: %s", code, body)
+	}
+	if code, body := doRequest(t, http.MethodDelete, ts.URL+"/kv/k", "", ""); code != http.StatusPreconditionFailed {
+		t.Errorf("DELETE in read-only mode returned %d: %s", code, body)
+	}
+	if code, body := doRequest(t, http.MethodGet, ts.URL+"/kv/k", "", ""); code != http.StatusOK {
+		t.Errorf("GET in read-only mode returned %d: %s", code, body)
+	}
+}
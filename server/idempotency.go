@@ -0,0 +1,136 @@
+package main
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	defaultIdempotencyTTL     = 10 * time.Minute
+	defaultIdempotencyMaxKeys = 100000
+)
+
+// idempotentCall é uma requisição com idempotency key, em andamento enquanto
+// done não fecha
+type idempotentCall struct {
+	fingerprint [sha256.Size]byte
+	done        chan struct{}
+	resp        any
+	err         error
+
+	expires time.Time
+}
+
+// IdempotencyCache guarda por um tempo limitado o resultado das escritas
+// feitas com idempotency key, para que o retry de uma requisição que já foi
+// aplicada devolva o primeiro resultado em vez de aplicá-la de novo. Só as
+// chamadas bem-sucedidas ficam guardadas: depois de um erro o retry executa
+// normalmente. O cache é local ao nó.
+type IdempotencyCache struct {
+	mu    sync.Mutex
+	ttl   time.Duration
+	max   int
+	calls map[string]*idempotentCall
+	// order são as chamadas concluídas, da mais antiga para a mais nova; como
+	// o ttl é o mesmo para todas, também é a ordem de expiração
+	order *list.List
+
+	// now permite controlar o relógio nos testes
+	now func() time.Time
+}
+
+func NewIdempotencyCache(ttl time.Duration, maxKeys int) *IdempotencyCache {
+	return &IdempotencyCache{
+		ttl:   ttl,
+		max:   max(maxKeys, 1),
+		calls: make(map[string]*idempotentCall),
+		order: list.New(),
+		now:   time.Now,
+	}
+}
+
+// fingerprint identifica o conteúdo da requisição, para recusar uma
+// idempotency key reaproveitada em outra requisição
+func fingerprint(parts ...string) [sha256.Size]byte {
+	h := sha256.New()
+	for _, p := range parts {
+		h.Write([]byte(p))
+		h.Write([]byte{0})
+	}
+	var sum [sha256.Size]byte
+	h.Sum(sum[:0])
+	return sum
+}
+
+// Do executa fn uma vez por key enquanto o resultado estiver guardado.
+// Chamadas repetidas com a mesma key esperam a primeira terminar e devolvem o
+// resultado dela; com um fingerprint diferente retornam InvalidArgument.
+func (c *IdempotencyCache) Do(key string, fp [sha256.Size]byte, fn func() (any, error)) (any, error) {
+	for {
+		c.mu.Lock()
+		c.prune(c.now())
+
+		call, ok := c.calls[key]
+		if !ok {
+			call = &idempotentCall{fingerprint: fp, done: make(chan struct{})}
+			c.calls[key] = call
+			c.mu.Unlock()
+
+			call.resp, call.err = fn()
+			c.finish(key, call)
+			return call.resp, call.err
+		}
+		c.mu.Unlock()
+
+		if call.fingerprint != fp {
+			return nil, status.Error(codes.InvalidArgument, "idempotency key was already used for a different request")
+		}
+		<-call.done
+		if call.err == nil {
+			return call.resp, nil
+		}
+		//a primeira tentativa falhou e saiu do cache: esta executa de novo
+	}
+}
+
+// finish guarda o resultado de uma chamada bem-sucedida e descarta as mais
+// antigas acima do limite; uma chamada com erro sai do cache
+func (c *IdempotencyCache) finish(key string, call *idempotentCall) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if call.err != nil {
+		delete(c.calls, key)
+	} else {
+		call.expires = c.now().Add(c.ttl)
+		c.order.PushBack(key)
+		for c.order.Len() > c.max {
+			delete(c.calls, c.order.Remove(c.order.Front()).(string))
+		}
+	}
+	close(call.done)
+}
+
+// prune descarta as chamadas expiradas. Deve ser chamado com c.mu travado.
+func (c *IdempotencyCache) prune(now time.Time) {
+	for e := c.order.Front(); e != nil; e = c.order.Front() {
+		key := e.Value.(string)
+		if now.Before(c.calls[key].expires) {
+			return
+		}
+		c.order.Remove(e)
+		delete(c.calls, key)
+	}
+}
+
+// Len retorna quantas chamadas estão guardadas ou em andamento
+func (c *IdempotencyCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.calls)
+}
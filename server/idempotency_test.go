@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	pb "github.com/carvalhodanielg/kvstore/pb/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestIdempotencyCache_Do(t *testing.T) {
+	now := time.Unix(1000, 0)
+	c := NewIdempotencyCache(time.Minute, 2)
+	c.now = func() time.Time { return now }
+
+	calls := 0
+	apply := func() (any, error) {
+		calls++
+		return calls, nil
+	}
+	fp := fingerprint("key", "value")
+
+	// A repetição devolve o primeiro resultado sem executar de novo
+	for range 2 {
+		if got, err := c.Do("a", fp, apply); got != 1 || err != nil {
+			t.Errorf("Do(a) = %v, %v, expected 1, nil", got, err)
+		}
+	}
+
+	// A mesma key com outro conteúdo é recusada
+	if _, err := c.Do("a", fingerprint("key", "other"), apply); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Do(a) with a different request returned %v, expected InvalidArgument", err)
+	}
+
+	// Um erro não fica guardado, então o retry executa
+	fail := errors.New("transient")
+	if _, err := c.Do("b", fp, func() (any, error) { return nil, fail }); err != fail {
+		t.Errorf("Do(b) returned %v, expected the error from fn", err)
+	}
+	if got, _ := c.Do("b", fp, apply); got != 2 {
+		t.Errorf("Do(b) after an error = %v, expected a new execution", got)
+	}
+
+	// Acima do limite a key mais antiga é esquecida
+	c.Do("c", fp, apply)
+	if got, _ := c.Do("a", fp, apply); got != 4 {
+		t.Errorf("Do(a) after eviction = %v, expected a new execution", got)
+	}
+
+	// Depois do ttl todas expiram
+	now = now.Add(time.Minute)
+	if got, _ := c.Do("c", fp, apply); got != 5 {
+		t.Errorf("Do(c) after the ttl = %v, expected a new execution", got)
+	}
+	if n := c.Len(); n != 1 {
+		t.Errorf("Len() = %d after expiry, expected 1", n)
+	}
+}
+
+func TestServer_Put_IdempotencyKey(t *testing.T) {
+	srv, s, addr := setupTestServer(t, func(s *server) {
+		s.idempotency = NewIdempotencyCache(time.Minute, 100)
+	})
+	defer cleanupTestServer(t, srv, addr)
+
+	client := createTestClient(t, addr)
+	ctx := context.Background()
+
+	// O retry com a mesma key não aplica a escrita de novo: a revisão avança uma vez
+	req := &pb.PutRequest{Key: "counter", Value: "1", IdempotencyKey: "req-1"}
+	for i := range 2 {
+		if _, err := client.Put(ctx, req); err != nil {
+			t.Fatalf("Put() attempt %d failed: %v", i, err)
+		}
+		if rev := s.store.Revision("counter"); rev != 1 {
+			t.Errorf("Revision() after attempt %d = %d, expected 1", i, rev)
+		}
+	}
+
+	// Sem key, ou com outra key, cada requisição é aplicada
+	client.Put(ctx, &pb.PutRequest{Key: "counter", Value: "1"})
+	client.Put(ctx, &pb.PutRequest{Key: "counter", Value: "1", IdempotencyKey: "req-2"})
+	if rev := s.store.Revision("counter"); rev != 3 {
+		t.Errorf("Revision() = %d, expected 3", rev)
+	}
+
+	// O PutIfAbsent repetido devolve o stored da primeira chamada
+	absent := &pb.PutRequest{Key: "lock", Value: "owner", IdempotencyKey: "req-3"}
+	for i := range 2 {
+		resp, err := client.PutIfAbsent(ctx, absent)
+		if err != nil || !resp.GetStored() {
+			t.Errorf("PutIfAbsent() attempt %d = %v, %v, expected stored", i, resp, err)
+		}
+	}
+
+	_, err := client.Put(ctx, &pb.PutRequest{Key: "counter", Value: "2", IdempotencyKey: "req-1"})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Put() reusing a key for another value returned %v, expected InvalidArgument", err)
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	pb "github.com/carvalhodanielg/kvstore/pb/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// joinTimeout é quanto tempo um nó novo insiste em entrar no cluster
+	joinTimeout = 2 * time.Minute
+	// joinRetryMin e joinRetryMax limitam a espera entre as rodadas do joinCluster
+	joinRetryMin = 500 * time.Millisecond
+	joinRetryMax = 10 * time.Second
+	// joinRPCTimeout limita cada chamada de Join a um peer
+	joinRPCTimeout = 5 * time.Second
+)
+
+// joinCluster pede ao líder, pela RPC Join, que adicione self ao cluster. Cada
+// rodada tenta o nó de bootstrap primeiro e depois os outros peers, já que o
+// líder pode ter mudado desde o bootstrap; entre as rodadas espera um backoff
+// que começa em retry e dobra até joinRetryMax. Desiste quando o ctx acaba,
+// com a última recusa de um peer, ou quando um peer recusa o pedido como
+// inválido.
+func joinCluster(ctx context.Context, self NodeConfig, peers []NodeConfig, bootstrap string, retry time.Duration) error {
+	if len(peers) == 0 {
+		return fmt.Errorf("join cluster: no peers to ask")
+	}
+	peers = slices.Clone(peers)
+	slices.SortStableFunc(peers, func(a, b NodeConfig) int {
+		switch {
+		case a.ID == bootstrap && b.ID != bootstrap:
+			return -1
+		case b.ID == bootstrap && a.ID != bootstrap:
+			return 1
+		}
+		return 0
+	})
+
+	req := &pb.JoinRequest{NodeId: self.ID, RaftAddress: self.RaftAddress}
+	backoff := retry
+	//lastErr é a última recusa de um peer; uma chamada interrompida pelo fim do
+	//ctx não conta, senão o erro devolvido seria só o DeadlineExceeded
+	var lastErr error
+	for {
+		for _, p := range peers {
+			err := joinVia(ctx, p.ClientAddress, req)
+			if err == nil {
+				slog.Info("joined cluster", "node_id", self.ID, "via", p.ID)
+				return nil
+			}
+			if ctx.Err() != nil {
+				break
+			}
+			if status.Code(err) == codes.InvalidArgument {
+				return fmt.Errorf("join cluster via %s: %w", p.ID, err)
+			}
+			lastErr = err
+			slog.Debug("join attempt failed", "node_id", self.ID, "peer", p.ID, "address", p.ClientAddress, "error", err)
+		}
+
+		if ctx.Err() == nil {
+			slog.Warn("could not join cluster, retrying", "node_id", self.ID, "backoff", backoff, "error", lastErr)
+		}
+		select {
+		case <-ctx.Done():
+			if lastErr == nil {
+				lastErr = ctx.Err()
+			}
+			return fmt.Errorf("join cluster: %w", lastErr)
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, joinRetryMax)
+	}
+}
+
+// joinVia faz uma chamada de Join ao nó em addr
+func joinVia(ctx context.Context, addr string, req *pb.JoinRequest) error {
+	conn, err := dialPeer(addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, joinRPCTimeout)
+	defer cancel()
+
+	_, err = pb.NewNodeCommunicationClient(conn).Join(ctx, req)
+	return err
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	pb "github.com/carvalhodanielg/kvstore/pb/proto"
+	"github.com/carvalhodanielg/kvstore/store"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// joinPeer recusa os primeiros failures pedidos de Join como um follower e
+// guarda o último pedido aceito
+type joinPeer struct {
+	pb.UnimplementedNodeCommunicationServer
+	failures int32
+	calls    atomic.Int32
+	joined   atomic.Pointer[pb.JoinRequest]
+}
+
+func (p *joinPeer) Join(_ context.Context, in *pb.JoinRequest) (*pb.JoinResponse, error) {
+	if p.calls.Add(1) <= p.failures {
+		return nil, status.Error(codes.Unavailable, store.ErrNotLeader.Error())
+	}
+	p.joined.Store(in)
+	return &pb.JoinResponse{}, nil
+}
+
+// closedAddr devolve um endereço sem ninguém escutando
+func closedAddr(t *testing.T) string {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	addr := lis.Addr().String()
+	lis.Close()
+	return addr
+}
+
+func TestJoinCluster(t *testing.T) {
+	self := NodeConfig{ID: "3", RaftAddress: "node3:7000", ClientAddress: "node3:50051"}
+
+	t.Run("retries until the leader accepts", func(t *testing.T) {
+		leader := &joinPeer{failures: 2}
+		_, addr := startPeerWith(t, leader)
+
+		// O bootstrap caiu; o líder agora é o nó 2
+		peers := []NodeConfig{
+			{ID: "2", ClientAddress: addr},
+			{ID: "1", ClientAddress: closedAddr(t)},
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := joinCluster(ctx, self, peers, "1", time.Millisecond); err != nil {
+			t.Fatalf("joinCluster() failed: %v", err)
+		}
+
+		got := leader.joined.Load()
+		if got == nil || got.GetNodeId() != "3" || got.GetRaftAddress() != "node3:7000" {
+			t.Errorf("Leader got join request %v, expected node 3 at node3:7000", got)
+		}
+		if n := leader.calls.Load(); n != 3 {
+			t.Errorf("Expected 3 join calls, got %d", n)
+		}
+	})
+
+	t.Run("gives up with the last error", func(t *testing.T) {
+		follower := &joinPeer{failures: 1 << 30}
+		_, addr := startPeerWith(t, follower)
+
+		// O ctx só acaba depois de duas recusas, por mais lento que seja o RPC;
+		// cancelado no meio de uma chamada, o erro continua sendo a recusa
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		done := make(chan error, 1)
+		go func() {
+			done <- joinCluster(ctx, self, []NodeConfig{{ID: "1", ClientAddress: addr}}, "1", time.Millisecond)
+		}()
+
+		deadline := time.Now().Add(10 * time.Second)
+		for follower.calls.Load() < 2 && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+		}
+		if follower.calls.Load() < 2 {
+			t.Fatalf("Expected joinCluster() to retry, got %d calls", follower.calls.Load())
+		}
+		cancel()
+
+		if err := <-done; status.Code(err) != codes.Unavailable {
+			t.Errorf("joinCluster() returned %v, expected the follower's Unavailable", err)
+		}
+	})
+
+	t.Run("no peers", func(t *testing.T) {
+		if err := joinCluster(context.Background(), self, nil, "1", time.Millisecond); err == nil {
+			t.Error("joinCluster() without peers returned nil")
+		}
+	})
+}
+
+func TestServer_Join(t *testing.T) {
+	_, addr := startPeerWith(t, &server{store: store.NewKVStore(store.WithBackend(store.NewMemoryBackend()))})
+	conn, err := dialPeer(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	client := pb.NewNodeCommunicationClient(conn)
+
+	if _, err := client.Join(context.Background(), &pb.JoinRequest{NodeId: "2"}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Join() without raft_address expected InvalidArgument, got %v", err)
+	}
+	// sem raft aberto não há configuração para mudar
+	if _, err := client.Join(context.Background(), &pb.JoinRequest{NodeId: "2", RaftAddress: "node2:7000"}); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("Join() without raft expected FailedPrecondition, got %v", err)
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/keepalive"
+)
+
+const (
+	// defaultKeepaliveTime é o intervalo dos pings do servidor em conexões
+	// paradas; bem abaixo do idle timeout comum de load balancers e NATs,
+	// para um Watch sem eventos não ser derrubado no meio do caminho
+	defaultKeepaliveTime = time.Minute
+	// defaultKeepaliveTimeout é quanto o servidor espera a resposta do ping
+	// antes de fechar a conexão
+	defaultKeepaliveTimeout = 20 * time.Second
+	// defaultKeepaliveMinTime é o menor intervalo aceito entre pings dos
+	// clientes; abaixo disso o servidor fecha a conexão
+	defaultKeepaliveMinTime = 10 * time.Second
+)
+
+// keepaliveConfig junta os parâmetros de keepalive do servidor gRPC. Zero em
+// MaxConnectionIdle não fecha conexões paradas.
+type keepaliveConfig struct {
+	// MaxConnectionIdle fecha (com GOAWAY) as conexões sem nenhuma RPC em
+	// andamento há esse tempo. Um Watch aberto conta como RPC em andamento.
+	MaxConnectionIdle time.Duration
+	Time              time.Duration
+	Timeout           time.Duration
+	// MinTime e PermitWithoutStream formam a política aplicada aos pings dos
+	// clientes
+	MinTime             time.Duration
+	PermitWithoutStream bool
+}
+
+func (c keepaliveConfig) serverOptions() []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.KeepaliveParams(keepalive.ServerParameters{
+			MaxConnectionIdle: c.MaxConnectionIdle,
+			Time:              c.Time,
+			Timeout:           c.Timeout,
+		}),
+		grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+			MinTime:             c.MinTime,
+			PermitWithoutStream: c.PermitWithoutStream,
+		}),
+	}
+}
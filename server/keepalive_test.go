@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	pb "github.com/carvalhodanielg/kvstore/pb/proto"
+	"github.com/carvalhodanielg/kvstore/store"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+func TestServer_MaxConnectionIdle(t *testing.T) {
+	store.SetWALPath(filepath.Join(t.TempDir(), store.WALFileName))
+	defer store.SetWALPath(store.WALFileName)
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+
+	ka := keepaliveConfig{
+		MaxConnectionIdle: 200 * time.Millisecond,
+		Time:              defaultKeepaliveTime,
+		Timeout:           defaultKeepaliveTimeout,
+		MinTime:           defaultKeepaliveMinTime,
+	}
+	s := &server{store: store.NewKVStore(store.WithBackend(store.NewMemoryBackend()))}
+	srv := grpc.NewServer(ka.serverOptions()...)
+	pb.RegisterKvStoreServer(srv, s)
+	go srv.Serve(lis)
+	defer srv.Stop()
+
+	dial := func() *grpc.ClientConn {
+		conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			t.Fatalf("failed to connect: %v", err)
+		}
+		t.Cleanup(func() { conn.Close() })
+		return conn
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	idle := dial()
+	if _, err := pb.NewKvStoreClient(idle).Ping(ctx, &pb.PingRequest{}); err != nil {
+		t.Fatalf("Ping() failed: %v", err)
+	}
+
+	watching := dial()
+	stream, err := pb.NewKvStoreClient(watching).Watch(ctx, &pb.WatchRequest{Key: "k"})
+	if err != nil {
+		t.Fatalf("Watch() failed: %v", err)
+	}
+	waitForWatchers(t, s.store, 1)
+
+	// sem nenhuma RPC em andamento, o servidor manda GOAWAY e a conexão sai de Ready
+	if idle.GetState() == connectivity.Ready && !idle.WaitForStateChange(ctx, connectivity.Ready) {
+		t.Fatal("idle connection was not closed")
+	}
+
+	// o Watch aberto mantém a outra conexão viva bem depois do idle timeout
+	time.Sleep(4 * ka.MaxConnectionIdle)
+	if state := watching.GetState(); state != connectivity.Ready {
+		t.Fatalf("watching connection is %v, expected Ready", state)
+	}
+
+	s.store.Put("k", "v")
+	resp, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("Recv() failed after the idle timeout: %v", err)
+	}
+	if resp.Message != "Key k updated to v" {
+		t.Errorf("unexpected event %q", resp.Message)
+	}
+}
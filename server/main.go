@@ -1,113 +1,981 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"log"
+	"log/slog"
+	"maps"
 	"net"
+	"net/http"
 	"os"
+	"os/signal"
+	"slices"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
 	"time"
+	"unicode/utf8"
 
 	"github.com/carvalhodanielg/kvstore/internal/constants"
+	"github.com/carvalhodanielg/kvstore/internal/version"
 	pb "github.com/carvalhodanielg/kvstore/pb/proto"
 	"github.com/carvalhodanielg/kvstore/store"
+	"github.com/hashicorp/raft"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
 
 	bolt "go.etcd.io/bbolt"
 )
 
+const (
+	// defaultHeartbeatInterval é o intervalo entre heartbeats enviados pelo líder
+	defaultHeartbeatInterval = 10 * time.Second
+	// defaultHeartbeatTimeout é quanto cada peer tem para responder um heartbeat
+	defaultHeartbeatTimeout = 5 * time.Second
+	// peerDownAfter é quantos intervalos sem heartbeat respondido marcam um peer como down
+	peerDownAfter = 3
+	// bulkPutChunk é quantos pares do BulkPut vão em cada transação
+	bulkPutChunk = 500
+)
+
 var (
-	port = flag.Int("port", 50051, "The server port")
+	port            = flag.Int("port", 50051, "The server port")
+	strict          = flag.Bool("strict", false, "Return NotFound for missing keys and reject empty keys")
+	maxKeySize      = flag.Int("max-key-size", store.DefaultMaxKeySize, "Maximum key size in bytes")
+	maxValueSize    = flag.Int("max-value-size", store.DefaultMaxValueSize, "Maximum value size in bytes")
+	_               = flag.Bool("reject-empty-keys", false, "Deprecated: empty keys are always rejected")
+	strictKeys      = flag.Bool("strict-keys", false, "Reject keys containing newlines, tabs or other control characters")
+	shutdownTimeout = flag.Duration("shutdown-timeout", 10*time.Second, "Time to wait for in-flight RPCs before forcing shutdown")
+	metricsPort     = flag.Int("metrics-port", 0, "HTTP port to serve Prometheus metrics on /metrics (0 disables)")
+	httpPort        = flag.Int("http-port", 0, "HTTP port for the REST gateway on /kv (0 disables)")
+	dbPath          = flag.String("db-path", envOr("DB_PATH", constants.DBFileName), "Path of the bbolt database file (env DB_PATH)")
+	dbBucket        = flag.String("db-bucket", envOr("DB_BUCKET", constants.BucketStore), "Name of the bbolt bucket holding the keys (env DB_BUCKET)")
+	dbTimeout       = flag.Duration("db-timeout", store.DefaultDBTimeout, "Time to wait for the bbolt file lock held by another process (0 waits forever)")
+	dbNoSync        = flag.Bool("db-no-sync", false, "Skip fsync on bbolt commits: faster writes, but a machine crash may lose or corrupt recent data")
+	batchWindow     = flag.Duration("batch-window", 0, "Group bbolt writes made within this window into one transaction (0 writes each one immediately)")
+	batchSize       = flag.Int("batch-size", store.DefaultBatchSize, "Pending writes that flush a batch before --batch-window ends")
+	enableClear     = flag.Bool("enable-clear", false, "Allow the Clear RPC to wipe the store")
+	enableCompact   = flag.Bool("enable-compact", false, "Allow the Compact RPC, which blocks writes while it rewrites the bbolt file")
+	enableCluster   = flag.Bool("enable-cluster-info", false, "Allow the ClusterInfo RPC, which lists the raft servers with their ids and addresses")
+	enableStepDown  = flag.Bool("enable-step-down", false, "Allow the StepDown RPC, which transfers raft leadership away from this node")
+	enableVerify    = flag.Bool("enable-verify", false, "Allow the Verify RPC, which blocks writes while it compares memory with bbolt")
+	enableReadOnly  = flag.Bool("enable-set-read-only", false, "Allow the SetReadOnly RPC, which turns read-only mode on and off at runtime")
+	logLevel        = flag.String("log-level", "info", "Minimum log level: debug, info, warn or error")
+	printVersion    = flag.Bool("version", false, "Print the build version, git commit and Go version, then exit")
+	compressAbove   = flag.Int("compression-threshold", store.DefaultCompressionThreshold, "Compress values larger than this many bytes before writing them to disk")
+	noCompression   = flag.Bool("disable-compression", false, "Store values uncompressed regardless of size")
+	valueEnvelope   = flag.Bool("value-envelope", false, "Store each value in bbolt inside a JSON envelope with its revision and timestamps instead of separate buckets")
+	maxEntries      = flag.Int("max-entries", 0, "Keep at most this many keys in memory, evicting the least recently used (0 disables)")
+	evictionMode    = flag.String("eviction-mode", store.EvictMemory.String(), "What happens to keys evicted by --max-entries: memory keeps them in bbolt for read-through, delete removes them")
+	watchBuffer     = flag.Int("watch-buffer", store.DefaultWatchBufferSize, "Events buffered per watcher before new events are dropped")
+	watchBlockTime  = flag.Duration("watch-block-timeout", store.DefaultWatchBlockTimeout, "How long a WATCH_POLICY_BLOCK watcher may go without reading before it is disconnected")
+	clusterConfig   = flag.String("cluster-config", envOr("CLUSTER_CONFIG", ""), "JSON file describing the cluster nodes (env CLUSTER_CONFIG); without it NODE_ID, PORT and PEERS are used")
+	nodeID          = flag.String("node-id", envOr("NODE_ID", ""), "ID of this node in the cluster config (env NODE_ID)")
+	dataDir         = flag.String("data-dir", envOr("DATA_DIR", ""), "Base directory for the db, WAL and raft files, kept in <data-dir>/<node-id> (env DATA_DIR); overrides --db-path and --raft-dir")
+	raftDir         = flag.String("raft-dir", envOr("RAFT_DIR", store.DefaultRaftDir), "Directory for raft logs and snapshots (env RAFT_DIR)")
+	maxRequestTime  = flag.Duration("max-request-duration", 30*time.Second, "Maximum time a unary RPC may run before returning DeadlineExceeded (0 disables; client deadlines always apply)")
+	rateLimit       = flag.Float64("rate-limit", 0, "Unary requests per second allowed for each client host (0 disables)")
+	rateBurst       = flag.Int("rate-burst", 20, "Requests a client may send at once before --rate-limit applies")
+	replicaRead     = flag.Bool("replica-read", false, "Let followers serve Gets with REPLICA consistency from their local state")
+	bootstrap       = flag.Bool("bootstrap", false, "Bootstrap a new raft cluster with this node if it has no raft state; the cluster config's bootstrap node always does")
+	walCheckpoint   = flag.Duration("wal-checkpoint-interval", 0, "Fsync bbolt and truncate the WAL up to the durable entries at this interval (0 disables)")
+	walFormat       = flag.String("wal-format", store.WALNDJSON.String(), "Encoding of a new WAL file: ndjson is human-readable, binary is length-prefixed and faster; an existing WAL keeps its format")
+	snapshotEvery   = flag.Uint64("snapshot-threshold", store.DefaultSnapshotThreshold, "Take a raft snapshot after this many applied log entries (0 disables)")
+	snapshotPeriod  = flag.Duration("snapshot-interval", 0, "Take a raft snapshot this often if there were writes since the last one, instead of the snapshot-threshold trigger (0 disables)")
+	snapshotRetain  = flag.Int("snapshot-retain", store.DefaultRetainSnapshotCount, "Number of raft snapshots kept on disk (at least 1)")
+	hbInterval      = flag.Duration("heartbeat-interval", defaultHeartbeatInterval, "Interval between heartbeats sent by the leader to its peers")
+	hbTimeout       = flag.Duration("heartbeat-timeout", defaultHeartbeatTimeout, "Time each peer has to answer a heartbeat; a round slower than --heartbeat-interval delays the next one instead of overlapping")
+	maxConnIdle     = flag.Duration("max-connection-idle", 0, "Close client connections with no active RPC for this long; open Watch streams keep a connection active (0 never closes)")
+	keepaliveTime   = flag.Duration("keepalive-time", defaultKeepaliveTime, "Ping clients after this long without activity to keep connections through proxies and detect dead peers")
+	keepaliveWait   = flag.Duration("keepalive-timeout", defaultKeepaliveTimeout, "Close the connection if a keepalive ping is not answered within this time")
+	keepaliveMin    = flag.Duration("keepalive-min-time", defaultKeepaliveMinTime, "Minimum interval between client keepalive pings; clients pinging more often are disconnected")
+	readOnly        = flag.Bool("read-only", false, "Reject client writes with FailedPrecondition while still serving reads; toggle at runtime with the SetReadOnly RPC when --enable-set-read-only is set")
+	maxWatches      = flag.Int("max-watches", 0, "Maximum Watch and WatchAll streams open at once across all clients (0 disables)")
+	maxClientWatch  = flag.Int("max-watches-per-client", 0, "Maximum Watch and WatchAll streams open at once per client host (0 disables)")
+	maxRecvMsgSize  = flag.Int("max-recv-msg-size", defaultMaxRecvMsgSize, "Largest gRPC message the server accepts, in bytes; raise it together with --max-value-size for big values")
+	maxSendMsgSize  = flag.Int("max-send-msg-size", defaultMaxSendMsgSize, "Largest gRPC message the server sends, in bytes; clients also limit what they receive (4MB by default)")
+	idempotencyTTL  = flag.Duration("idempotency-ttl", defaultIdempotencyTTL, "How long the result of a Put with an idempotency key is remembered for retries (0 disables)")
+	idempotencyKeys = flag.Int("idempotency-max-keys", defaultIdempotencyMaxKeys, "Maximum idempotency keys remembered at once; the oldest are forgotten first")
+	encryptionKey   = flag.String("encryption-key-file", envOr("ENCRYPTION_KEY_FILE", ""), "File with the secret used to encrypt values in bbolt and the WAL with AES-GCM (env ENCRYPTION_KEY_FILE); without it values are stored in plaintext")
 )
 
+// envOr lê a variável de ambiente ou devolve o valor padrão
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
 type server struct {
 	pb.UnimplementedKvStoreServer
 	pb.UnimplementedNodeCommunicationServer
 	store *store.KVStore
+
+	// strict faz o Get devolver NotFound para chaves inexistentes.
+	// Desligado, mantém o comportamento antigo de devolver valor vazio.
+	strict bool
+
+	// allowClear libera a RPC Clear. Enquanto não existe autenticação,
+	// apagar a store inteira precisa ser habilitado explicitamente.
+	allowClear bool
+	// allowCompact libera a RPC Compact, pelo mesmo motivo: ela segura as
+	// escritas enquanto copia o banco
+	allowCompact bool
+	// allowClusterInfo libera a RPC ClusterInfo, que expõe os ids e endereços
+	// de todos os nós do cluster
+	allowClusterInfo bool
+	// allowStepDown libera a RPC StepDown, que tira a liderança deste nó
+	allowStepDown bool
+	// allowVerify libera a RPC Verify, que segura as escritas enquanto
+	// percorre o banco inteiro
+	allowVerify bool
+	// allowSetReadOnly libera a RPC SetReadOnly: sem ela qualquer cliente
+	// poderia desligar o --read-only
+	allowSetReadOnly bool
+
+	// peers acompanha os heartbeats enviados; nil quando PEERS não foi definido
+	peers *PeerTracker
+	// heartbeatTimeout limita a chamada a cada peer; zero usa defaultHeartbeatTimeout
+	heartbeatTimeout time.Duration
+
+	// replicaRead deixa os followers responderem Gets REPLICA com a memória
+	// local. Desligado, só o líder (ou um nó sem raft) atende essas leituras.
+	replicaRead bool
+
+	// watches limita os streams de Watch e WatchAll abertos; nil não limita
+	watches *WatchLimiter
+
+	// idempotency guarda os resultados das escritas com idempotency key; nil
+	// ignora as chaves
+	idempotency *IdempotencyCache
+
+	// readOnly recusa as escritas dos clientes (ver writeMethods); começa com
+	// --read-only e pode ser trocado pela RPC SetReadOnly
+	readOnly atomic.Bool
 }
 
 func (s *server) GetAll(_ context.Context, in *pb.GetAllRequest) (*pb.GetAllResponse, error) {
+	if in.GetSorted() {
+		pairs := s.store.GetAllSorted()
+		values := make([]*pb.KeyValue, 0, len(pairs))
+		for _, p := range pairs {
+			values = append(values, &pb.KeyValue{Key: p.Key, Value: p.Value, Found: true})
+		}
+		return &pb.GetAllResponse{SortedValues: values}, nil
+	}
 
-	//Isso aqui pode ser problemático pq quem recebe os dados pode alterar a store
-	//pra evitar isso precisar fazer e retornar uma cópia.
-	//pra isso, devemos fazer um for aqui pra copiar tudo, ou criar um snapshop atualizado a cada update
-	//e retornar ele aqui
+	//o GetAll devolve um snapshot imutável, então a serialização acontece
+	//sem segurar o lock da store
 	res := s.store.GetAll()
 
 	return &pb.GetAllResponse{Values: res}, nil
 }
 
-func (s *server) Delete(_ context.Context, in *pb.DeleteRequest) (*pb.DeleteResponse, error) {
-	log.Printf("Received key: %v", in.GetKey())
+// GetAllStream envia as chaves uma a uma, em ordem, lendo o banco com um
+// cursor. Diferente do GetAll, a resposta não precisa caber em memória nem
+// numa mensagem, e inclui as chaves despejadas por --max-entries.
+func (s *server) GetAllStream(_ *pb.GetAllRequest, stream pb.KvStore_GetAllStreamServer) error {
+	return s.store.Scan(func(key, value string) error {
+		return stream.Send(&pb.KeyValue{Key: key, Value: value, Found: true})
+	})
+}
+
+func (s *server) Delete(ctx context.Context, in *pb.DeleteRequest) (*pb.DeleteResponse, error) {
+	slog.Debug("delete", "key", in.GetKey())
 
-	s.store.Delete(in.GetKey())
+	if err := s.store.DeleteContext(ctx, in.GetKey()); err != nil {
+		return nil, storeError(err)
+	}
 
 	return &pb.DeleteResponse{Key: in.GetKey()}, nil
 }
 
-func (s *server) Get(_ context.Context, in *pb.GetRequest) (*pb.GetResponse, error) {
+// DeleteMany apaga uma lista de chaves de uma vez, com um lock e uma
+// transação no banco em vez de uma RPC por chave
+func (s *server) DeleteMany(ctx context.Context, in *pb.DeleteManyRequest) (*pb.DeleteManyResponse, error) {
+	slog.Debug("delete many", "keys", len(in.GetKeys()))
+
+	n, err := s.store.DeleteManyContext(ctx, in.GetKeys())
+	if err != nil {
+		return nil, storeError(err)
+	}
+
+	return &pb.DeleteManyResponse{Deleted: int64(n)}, nil
+}
+
+func (s *server) Get(ctx context.Context, in *pb.GetRequest) (*pb.GetResponse, error) {
+
+	slog.Debug("get", "key", in.GetKey())
+
+	if err := ctx.Err(); err != nil {
+		return nil, storeError(err)
+	}
+
+	var staleness time.Duration
+	switch in.GetConsistency() {
+	case pb.Consistency_CONSISTENCY_LINEARIZABLE:
+		if err := s.store.ReadIndex(ctx); err != nil {
+			return nil, storeError(err)
+		}
+	case pb.Consistency_CONSISTENCY_REPLICA:
+		var err error
+		if staleness, err = s.replicaStaleness(in.GetMaxStalenessMs()); err != nil {
+			return nil, err
+		}
+	}
+
+	e, err := s.store.GetEntry(ctx, in.GetKey())
+	if err != nil && (s.strict || !errors.Is(err, store.ErrNotFound)) {
+		return nil, storeError(err)
+	}
+
+	resp := &pb.GetResponse{
+		Key:         in.GetKey(),
+		Value:       e.Value,
+		Revision:    e.Revision,
+		StalenessMs: staleness.Milliseconds(),
+		CreatedAt:   unixNanos(e.CreatedAt),
+		UpdatedAt:   unixNanos(e.UpdatedAt),
+	}
+	if in.GetBinary() || !utf8.ValidString(e.Value) {
+		resp.Value, resp.ValueBytes = "", []byte(e.Value)
+	}
+	return resp, nil
+}
+
+// putValue é o valor de um PutRequest: value_bytes quando preenchido, senão value
+func putValue(in *pb.PutRequest) string {
+	if b := in.GetValueBytes(); len(b) > 0 {
+		return string(b)
+	}
+	return in.GetValue()
+}
+
+// unixNanos converte t para o formato dos timestamps do proto, com 0 para o zero value
+func unixNanos(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano()
+}
+
+// replicaStaleness decide se este nó pode atender uma leitura REPLICA e
+// devolve o atraso estimado. Unavailable faz o cliente tentar outro nó.
+func (s *server) replicaStaleness(maxMs int64) (time.Duration, error) {
+	if !s.replicaRead && s.store.IsFollower() {
+		return 0, status.Error(codes.Unavailable, "replica reads are disabled on this node")
+	}
+
+	staleness, err := s.store.Staleness()
+	if err != nil {
+		return 0, status.Error(codes.Unavailable, err.Error())
+	}
+
+	if limit := time.Duration(maxMs) * time.Millisecond; limit > 0 && staleness > limit {
+		return 0, status.Errorf(codes.Unavailable, "replica is %s behind the leader, above the %s limit", staleness.Round(time.Millisecond), limit)
+	}
+	return staleness, nil
+}
+
+func (s *server) MultiGet(_ context.Context, in *pb.MultiGetRequest) (*pb.MultiGetResponse, error) {
+	found := s.store.MultiGet(in.GetKeys())
+
+	values := make([]*pb.KeyValue, 0, len(in.GetKeys()))
+	seen := make(map[string]bool, len(in.GetKeys()))
+	for _, key := range in.GetKeys() {
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		value, ok := found[key]
+		values = append(values, &pb.KeyValue{Key: key, Value: value, Found: ok})
+	}
+
+	return &pb.MultiGetResponse{Values: values}, nil
+}
+
+func (s *server) Put(ctx context.Context, in *pb.PutRequest) (*pb.PutResponse, error) {
+
+	slog.Debug("put", "key", in.GetKey(), "value", in.GetValue())
+
+	resp, err := s.idempotent("Put", in, func() (any, error) {
+		if err := s.store.PutContext(ctx, in.GetKey(), putValue(in)); err != nil {
+			return &pb.PutResponse{Success: false}, storeError(err)
+		}
+		return &pb.PutResponse{Success: true}, nil
+	})
+	r, _ := resp.(*pb.PutResponse)
+	return r, err
+}
+
+func (s *server) PutIfAbsent(_ context.Context, in *pb.PutRequest) (*pb.PutIfAbsentResponse, error) {
+	resp, err := s.idempotent("PutIfAbsent", in, func() (any, error) {
+		stored, err := s.store.PutIfAbsent(in.GetKey(), putValue(in))
+		if err != nil {
+			return nil, storeError(err)
+		}
+		return &pb.PutIfAbsentResponse{Stored: stored}, nil
+	})
+	r, _ := resp.(*pb.PutIfAbsentResponse)
+	return r, err
+}
+
+// idempotent executa fn pelo cache de idempotência quando a requisição traz
+// uma idempotency key. A chave vale por método, e a mesma chave com outro
+// par chave/valor é recusada.
+func (s *server) idempotent(method string, in *pb.PutRequest, fn func() (any, error)) (any, error) {
+	key := in.GetIdempotencyKey()
+	if key == "" || s.idempotency == nil {
+		return fn()
+	}
+	return s.idempotency.Do(method+"\x00"+key, fingerprint(in.GetKey(), putValue(in)), fn)
+}
 
-	log.Printf("Received %v", in.GetKey())
+func (s *server) PutIfVersion(_ context.Context, in *pb.PutIfVersionRequest) (*pb.PutIfVersionResponse, error) {
+	rev, err := s.store.PutIfVersion(in.GetKey(), in.GetValue(), in.GetExpectedRevision())
+	if errors.Is(err, store.ErrRevisionMismatch) {
+		return nil, status.Errorf(codes.FailedPrecondition, "key %q is at revision %d, expected %d", in.GetKey(), rev, in.GetExpectedRevision())
+	}
+	if err != nil {
+		return nil, storeError(err)
+	}
 
-	return &pb.GetResponse{Key: in.GetKey(), Value: s.store.Get(in.GetKey())}, nil
+	return &pb.PutIfVersionResponse{Revision: rev}, nil
 }
 
-func (s *server) Put(_ context.Context, in *pb.PutRequest) (*pb.PutResponse, error) {
+func (s *server) Txn(ctx context.Context, in *pb.TxnRequest) (*pb.TxnResponse, error) {
+	compares := make([]store.Compare, 0, len(in.GetCompares()))
+	for _, c := range in.GetCompares() {
+		target := store.CompareValue
+		if c.GetTarget() == pb.CompareTarget_COMPARE_REVISION {
+			target = store.CompareRevision
+		}
+		compares = append(compares, store.Compare{Key: c.GetKey(), Target: target, Value: c.GetValue(), Revision: c.GetRevision()})
+	}
 
-	log.Printf("Received key - %v and value - %v in PUT,", in.GetKey(), in.GetValue())
+	res, err := s.store.Txn(ctx, compares, txnOps(in.GetThenOps()), txnOps(in.GetElseOps()))
+	if err != nil {
+		return nil, storeError(err)
+	}
 
-	s.store.Put(in.GetKey(), in.GetValue())
+	return &pb.TxnResponse{Succeeded: res.Succeeded, Revision: res.Revision}, nil
+}
 
-	return &pb.PutResponse{Success: true}, nil
+func txnOps(ops []*pb.TxnOp) []store.TxnOp {
+	out := make([]store.TxnOp, 0, len(ops))
+	for _, op := range ops {
+		t := store.TxnPut
+		if op.GetType() == pb.TxnOpType_TXN_DELETE {
+			t = store.TxnDelete
+		}
+		out = append(out, store.TxnOp{Type: t, Key: op.GetKey(), Value: op.GetValue()})
+	}
+	return out
+}
+
+// storeError traduz os erros da store para status gRPC
+func storeError(err error) error {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return status.FromContextError(err).Err()
+	}
+	switch {
+	case store.IsValidationError(err), errors.Is(err, store.ErrDefaultNamespace), errors.Is(err, store.ErrUnknownServer):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, store.ErrNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, store.ErrVersionMismatch), errors.Is(err, store.ErrReadOnly), errors.Is(err, store.ErrRaftNotOpen):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	//Unavailable faz o cliente tentar o próximo nó do cluster
+	case errors.Is(err, store.ErrNotLeader), errors.Is(err, store.ErrNoLeaderContact), errors.Is(err, store.ErrReplicationFailed),
+		errors.Is(err, store.ErrClosed):
+		return status.Error(codes.Unavailable, err.Error())
+	}
+	return status.Error(codes.Internal, err.Error())
+}
+
+// WatchLeader envia o líder atual e depois cada troca de líder até o cliente desconectar
+func (s *server) WatchLeader(_ *pb.WatchLeaderRequest, stream pb.KvStore_WatchLeaderServer) error {
+	changes := s.store.LeaderChanges()
+	defer s.store.StopLeaderChanges(changes)
+
+	current := s.store.Status()
+	if err := stream.Send(&pb.WatchLeaderResponse{LeaderAddress: current.LeaderAddress, LeaderId: current.LeaderID}); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		case o := <-changes:
+			leader := o.Data.(raft.LeaderObservation)
+			resp := &pb.WatchLeaderResponse{LeaderAddress: string(leader.LeaderAddr), LeaderId: string(leader.LeaderID)}
+			if err := stream.Send(resp); err != nil {
+				return err
+			}
+		}
+	}
 }
 
 func (s *server) Watch(in *pb.WatchRequest, stream pb.KvStore_WatchServer) error {
-	w := s.store.Watch(in.Key)
+	opts := watchPolicyOptions(in.GetPolicy())
+	if in.GetSendInitialValue() {
+		opts = append(opts, store.WithInitialValue())
+	}
+
+	release, err := s.watches.Acquire(clientKey(stream.Context()))
+	if err != nil {
+		return err
+	}
+	defer release()
 
+	w := s.store.Watch(in.Key, opts...)
 	defer s.store.Unwatch(w)
 
-	for event := range w.Events {
-		if err := stream.Send(&pb.WatchResponse{Message: event}); err != nil {
+	return streamEvents(w, stream, "")
+}
+
+// WatchAll envia as mudanças de todas as chaves até o cliente desconectar
+func (s *server) WatchAll(in *pb.WatchAllRequest, stream pb.KvStore_WatchAllServer) error {
+	release, err := s.watches.Acquire(clientKey(stream.Context()))
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	w := s.store.WatchAll(watchPolicyOptions(in.GetPolicy())...)
+	defer s.store.Unwatch(w)
+
+	return streamEvents(w, stream, in.GetPrefix())
+}
+
+// matchesPrefix diz se o evento interessa a um WatchAll com prefix. Clear e
+// drop não têm chave e passam sempre.
+func matchesPrefix(e store.WatchEvent, prefix string) bool {
+	if e.Type != store.EventPut && e.Type != store.EventDelete {
+		return true
+	}
+	return strings.HasPrefix(e.Key, prefix)
+}
+
+func watchPolicyOptions(p pb.WatchPolicy) []store.WatchOption {
+	switch p {
+	case pb.WatchPolicy_WATCH_POLICY_DROP_OLDEST:
+		return []store.WatchOption{store.WithOverflowPolicy(store.OverflowDropOldest)}
+	case pb.WatchPolicy_WATCH_POLICY_BLOCK:
+		return []store.WatchOption{store.WithOverflowPolicy(store.OverflowBlock)}
+	}
+	return nil
+}
+
+// streamEvents repassa os eventos do watcher para o stream até o canal fechar
+// ou o cliente desconectar. Com prefix, as escritas e deletes de outras chaves
+// são descartados.
+func streamEvents(w *store.KVWatcher, stream grpc.ServerStreamingServer[pb.WatchResponse], prefix string) error {
+	//o contexto do stream é cancelado quando o cliente desconecta, liberando
+	//o watcher mesmo sem nenhum evento novo
+	for {
+		select {
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		case event, ok := <-w.Events:
+			if !ok {
+				return nil
+			}
+			if !matchesPrefix(event, prefix) {
+				continue
+			}
+			if err := stream.Send(watchResponse(event)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// watchResponse converte o evento do watcher para o proto
+func watchResponse(e store.WatchEvent) *pb.WatchResponse {
+	var t pb.WatchEventType
+	switch e.Type {
+	case store.EventDelete:
+		t = pb.WatchEventType_WATCH_EVENT_DELETE
+	case store.EventClear:
+		t = pb.WatchEventType_WATCH_EVENT_CLEAR
+	case store.EventDrop:
+		t = pb.WatchEventType_WATCH_EVENT_DROP
+	}
+	return &pb.WatchResponse{
+		Message:   e.Message,
+		Type:      t,
+		Key:       e.Key,
+		Value:     e.Value,
+		Revision:  e.Revision,
+		Namespace: e.Namespace,
+	}
+}
+
+func (s *server) Backup(_ *pb.BackupRequest, stream pb.KvStore_BackupServer) error {
+	return s.store.Backup(func(key, value string) error {
+		return stream.Send(&pb.BackupResponse{Key: key, Value: value})
+	})
+}
+
+func (s *server) Restore(stream pb.KvStore_RestoreServer) error {
+	first, err := stream.Recv()
+	if err == io.EOF {
+		return stream.SendAndClose(&pb.RestoreResponse{})
+	}
+	if err != nil {
+		return err
+	}
+
+	mode := store.RestoreMerge
+	if first.GetMode() == pb.RestoreMode_RESTORE_REPLACE {
+		mode = store.RestoreReplace
+	}
+
+	//o iterador consome o stream conforme a store vai gravando, sem carregar tudo em memória
+	var recvErr error
+	pairs := func(yield func(string, string) bool) {
+		req := first
+		for {
+			if !yield(req.GetKey(), req.GetValue()) {
+				return
+			}
+			req, recvErr = stream.Recv()
+			if recvErr != nil {
+				return
+			}
+		}
+	}
+
+	if first.GetDryRun() {
+		report := s.store.CheckRestore(pairs)
+		if recvErr != io.EOF {
+			return recvErr
+		}
+
+		slog.Info("restore dry run finished", "checked", report.Pairs, "invalid", report.Invalid)
+
+		return stream.SendAndClose(&pb.RestoreResponse{
+			Checked:    int64(report.Pairs),
+			Bytes:      report.Bytes,
+			Duplicates: int64(report.Duplicates),
+			Invalid:    int64(report.Invalid),
+			Errors:     report.Errors,
+		})
+	}
+
+	restored, err := s.store.RestoreFrom(pairs, mode)
+	if err != nil {
+		return storeError(err)
+	}
+	if recvErr != io.EOF {
+		return recvErr
+	}
+
+	slog.Info("restore finished", "restored", restored, "mode", first.GetMode())
+
+	return stream.SendAndClose(&pb.RestoreResponse{Restored: int64(restored)})
+}
+
+// BulkPut grava os pares do stream em lotes de bulkPutChunk, cada lote numa
+// única transação do bbolt e num único comando do raft (via Txn). Se um lote
+// falhar, os anteriores continuam gravados.
+func (s *server) BulkPut(stream pb.KvStore_BulkPutServer) error {
+	ctx := stream.Context()
+
+	var count int64
+	ops := make([]store.TxnOp, 0, bulkPutChunk)
+	flush := func() error {
+		if len(ops) == 0 {
+			return nil
+		}
+		if _, err := s.store.Txn(ctx, nil, ops, nil); err != nil {
+			return storeError(err)
+		}
+		count += int64(len(ops))
+		ops = ops[:0]
+		return nil
+	}
+
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
 			return err
 		}
+
+		ops = append(ops, store.TxnOp{Type: store.TxnPut, Key: req.GetKey(), Value: putValue(req)})
+		if len(ops) == bulkPutChunk {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
 	}
-	return nil
+	if err := flush(); err != nil {
+		return err
+	}
+
+	slog.Debug("bulk put", "count", count)
+
+	return stream.SendAndClose(&pb.BulkPutResponse{Count: count})
+}
+
+func (s *server) Status(_ context.Context, _ *pb.StatusRequest) (*pb.StatusResponse, error) {
+	st := s.store.Status()
+
+	return &pb.StatusResponse{
+		NodeId:        st.NodeID,
+		State:         st.State,
+		LeaderAddress: st.LeaderAddress,
+		LeaderId:      st.LeaderID,
+		Keys:          int64(st.Keys),
+		WalSize:       st.WALSize,
+		Peers:         s.peerStatuses(),
+		ReadOnly:      s.readOnly.Load(),
+
+		WatchEventsDropped: st.DroppedEvents,
+		WatchQueueDepth:    int64(st.WatchQueueDepth),
+	}, nil
+}
+
+// ClusterInfo devolve a configuração do raft vista por este nó: servidores,
+// termo atual e líder. Serve para depurar a composição do cluster sem ler os
+// logs do Open.
+func (s *server) ClusterInfo(_ context.Context, _ *pb.ClusterInfoRequest) (*pb.ClusterInfoResponse, error) {
+	if !s.allowClusterInfo {
+		return nil, status.Error(codes.PermissionDenied, "ClusterInfo is disabled, start the server with --enable-cluster-info")
+	}
+
+	info, err := s.store.ClusterInfo()
+	if err != nil {
+		return nil, storeError(err)
+	}
+
+	res := &pb.ClusterInfoResponse{Term: info.Term, LeaderId: info.LeaderID, LeaderAddress: info.LeaderAddress}
+	for _, srv := range info.Servers {
+		res.Servers = append(res.Servers, &pb.ClusterServer{Id: srv.ID, Address: srv.Address, Suffrage: srv.Suffrage})
+	}
+	return res, nil
+}
+
+// StepDown passa a liderança do raft para outro nó antes de um restart
+// planejado. Em um nó que não é o líder retorna Unavailable, como as escritas.
+func (s *server) StepDown(ctx context.Context, in *pb.StepDownRequest) (*pb.StepDownResponse, error) {
+	if !s.allowStepDown {
+		return nil, status.Error(codes.PermissionDenied, "StepDown is disabled, start the server with --enable-step-down")
+	}
+
+	if err := s.store.StepDown(ctx, in.GetTargetId()); err != nil {
+		return nil, storeError(err)
+	}
+	return &pb.StepDownResponse{}, nil
+}
+
+// Verify compara a memória com o bbolt e devolve as chaves que divergem. Com
+// repair, corrige as divergências a partir do lado escolhido em source.
+func (s *server) Verify(_ context.Context, in *pb.VerifyRequest) (*pb.VerifyResponse, error) {
+	if !s.allowVerify {
+		return nil, status.Error(codes.PermissionDenied, "Verify is disabled, start the server with --enable-verify")
+	}
+
+	var (
+		found []store.Discrepancy
+		err   error
+	)
+	if in.GetRepair() {
+		//o repair escreve na memória ou no banco, então conta como escrita
+		if err := s.checkWritable(); err != nil {
+			return nil, err
+		}
+		found, err = s.store.Repair(store.Source(in.GetSource()))
+	} else {
+		found, err = s.store.Verify()
+	}
+	if err != nil {
+		return nil, storeError(err)
+	}
+
+	discrepancies := make([]*pb.Discrepancy, 0, len(found))
+	for _, d := range found {
+		discrepancies = append(discrepancies, &pb.Discrepancy{
+			Kind:        pb.DiscrepancyKind(d.Kind),
+			Key:         d.Key,
+			MemoryValue: d.MemoryValue,
+			DbValue:     d.DBValue,
+		})
+	}
+	if len(found) > 0 && !in.GetRepair() {
+		slog.Warn("memory and db diverge", "discrepancies", len(found))
+	}
+	return &pb.VerifyResponse{Discrepancies: discrepancies}, nil
+}
+
+// DBStats reporta o tamanho e a ocupação das páginas do bbolt, junto com as
+// chaves no banco e em memória
+func (s *server) DBStats(_ context.Context, _ *pb.DBStatsRequest) (*pb.DBStatsResponse, error) {
+	st, err := s.store.DBStats()
+	if err != nil {
+		return nil, storeError(err)
+	}
+
+	return &pb.DBStatsResponse{
+		FileSize:     st.FileSize,
+		PageSize:     int64(st.PageSize),
+		FreePages:    int64(st.FreePages),
+		PendingPages: int64(st.PendingPages),
+		FreeBytes:    int64(st.FreeBytes),
+		OpenReadTxs:  int64(st.OpenReadTxs),
+		LeafPages:    int64(st.LeafPages),
+		BranchPages:  int64(st.BranchPages),
+		LeafInuse:    int64(st.LeafInuse),
+		BranchInuse:  int64(st.BranchInuse),
+		Utilization:  st.Utilization,
+		DbKeys:       int64(st.DBKeys),
+		MemoryKeys:   int64(st.MemoryKeys),
+	}, nil
+}
+
+// peerStatuses converte a visão do PeerTracker para a resposta do Status
+func (s *server) peerStatuses() []*pb.PeerStatus {
+	if s.peers == nil {
+		return nil
+	}
+
+	var peers []*pb.PeerStatus
+	for _, p := range s.peers.Peers() {
+		var lastSeen int64
+		if !p.LastSeen.IsZero() {
+			lastSeen = p.LastSeen.Unix()
+		}
+		peers = append(peers, &pb.PeerStatus{Address: p.Address, Alive: p.Alive, LastSeen: lastSeen, LastError: p.LastError})
+	}
+	return peers
+}
+
+func (s *server) Count(_ context.Context, in *pb.CountRequest) (*pb.CountResponse, error) {
+	return &pb.CountResponse{Count: int64(s.store.CountPrefix(in.GetPrefix()))}, nil
+}
+
+func (s *server) Keys(_ context.Context, in *pb.KeysRequest) (*pb.KeysResponse, error) {
+	return &pb.KeysResponse{Keys: s.store.Keys(in.GetPrefix())}, nil
+}
+
+func (s *server) MultiScan(_ context.Context, in *pb.MultiScanRequest) (*pb.MultiScanResponse, error) {
+	found := s.store.MultiScan(in.GetPrefixes())
+
+	results := make([]*pb.PrefixScan, 0, len(found))
+	seen := make(map[string]bool, len(found))
+	for _, prefix := range in.GetPrefixes() {
+		if seen[prefix] {
+			continue
+		}
+		seen[prefix] = true
+
+		values := make([]*pb.KeyValue, 0, len(found[prefix]))
+		for _, key := range slices.Sorted(maps.Keys(found[prefix])) {
+			values = append(values, &pb.KeyValue{Key: key, Value: found[prefix][key], Found: true})
+		}
+		results = append(results, &pb.PrefixScan{Prefix: prefix, Values: values})
+	}
+
+	return &pb.MultiScanResponse{Results: results}, nil
+}
+
+func (s *server) Clear(_ context.Context, _ *pb.ClearRequest) (*pb.ClearResponse, error) {
+	if !s.allowClear {
+		return nil, status.Error(codes.PermissionDenied, "Clear is disabled, start the server with --enable-clear")
+	}
+
+	if err := s.store.Clear(); err != nil {
+		return nil, storeError(err)
+	}
+
+	slog.Info("store cleared")
+
+	return &pb.ClearResponse{Success: true}, nil
+}
+
+// Compact roda a manutenção do disco deste nó (WAL, snapshot do raft e
+// cópia do bbolt) e devolve os tamanhos antes e depois
+func (s *server) Compact(_ context.Context, in *pb.CompactRequest) (*pb.CompactResponse, error) {
+	if !s.allowCompact {
+		return nil, status.Error(codes.PermissionDenied, "Compact is disabled, start the server with --enable-compact")
+	}
+
+	res, err := s.store.Compact(!in.GetSkipDb())
+	if err != nil {
+		return nil, storeError(err)
+	}
+
+	return &pb.CompactResponse{
+		DbSizeBefore:  res.DBSizeBefore,
+		DbSizeAfter:   res.DBSizeAfter,
+		WalSizeBefore: res.WALSizeBefore,
+		WalSizeAfter:  res.WALSizeAfter,
+		SnapshotTaken: res.Snapshot,
+	}, nil
+}
+
+// Ping responde na hora, sem tocar na store: serve para medir a latência e
+// como probe de load balancer
+func (s *server) Ping(_ context.Context, in *pb.PingRequest) (*pb.PingResponse, error) {
+	return &pb.PingResponse{Nonce: in.GetNonce(), Timestamp: time.Now().UnixNano()}, nil
+}
+
+func (s *server) Version(_ context.Context, _ *pb.VersionRequest) (*pb.VersionResponse, error) {
+	return &pb.VersionResponse{Version: version.Version, Commit: version.GetCommit(), GoVersion: version.GoVersion()}, nil
 }
 
 func (s *server) Heartbeat(_ context.Context, in *pb.HeartbeatRequest) (*pb.HeartbeatResponse, error) {
-	log.Printf("Received Heartbeat from %v at %v", in.NodeId, in.Timestamp)
+	slog.Debug("heartbeat received", "node_id", in.NodeId, "timestamp", in.Timestamp)
 
 	return &pb.HeartbeatResponse{Alive: true, Timestamp: time.Now().Unix()}, nil
 }
 
-func (s *server) sendHeartbeatToPeers() {
-	peers := os.Getenv("PEERS")
+// Join adiciona outro nó ao cluster como voter. Só o líder aceita: num
+// follower volta Unavailable e o nó que está entrando tenta o próximo peer.
+func (s *server) Join(_ context.Context, in *pb.JoinRequest) (*pb.JoinResponse, error) {
+	if in.GetNodeId() == "" || in.GetRaftAddress() == "" {
+		return nil, status.Error(codes.InvalidArgument, "join needs node_id and raft_address")
+	}
+	if err := s.store.Join(in.GetRaftAddress(), in.GetNodeId()); err != nil {
+		return nil, storeError(err)
+	}
+	return &pb.JoinResponse{}, nil
+}
+
+// runHeartbeats envia heartbeats a cada interval enquanto este nó for o líder,
+// ligando e desligando o heartbeatLoop conforme a liderança muda.
+func (s *server) runHeartbeats(ctx context.Context, interval time.Duration) {
+	changes := s.store.LeaderChanges()
+	defer s.store.StopLeaderChanges(changes)
+
+	var stop context.CancelFunc
+	var done chan struct{}
+
+	//espera o loop anterior terminar, então duas rodadas nunca se sobrepõem
+	//mesmo se a liderança oscilar
+	stopLoop := func() {
+		stop()
+		<-done
+		stop, done = nil, nil
+	}
+
+	update := func() {
+		switch leader := s.store.IsLeader(); {
+		case leader && stop == nil:
+			slog.Info("became leader, starting heartbeats")
+			var loopCtx context.Context
+			loopCtx, stop = context.WithCancel(ctx)
+			done = make(chan struct{})
+			go func(done chan struct{}) {
+				defer close(done)
+				s.heartbeatLoop(loopCtx, interval)
+			}(done)
+		case !leader && stop != nil:
+			slog.Info("lost leadership, stopping heartbeats")
+			stopLoop()
+		}
+	}
+
+	update()
+	for {
+		select {
+		case <-ctx.Done():
+			if stop != nil {
+				stopLoop()
+			}
+			return
+		case <-changes:
+			update()
+		}
+	}
+}
+
+// heartbeatLoop envia uma rodada de heartbeats a cada interval até o ctx ser
+// cancelado. As rodadas são sequenciais: se uma demorar mais que o interval,
+// o ticker descarta os ticks perdidos e a próxima sai logo em seguida, sem
+// acumular envios.
+func (s *server) heartbeatLoop(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
 
-	if peers == "" {
-		fmt.Printf("Sem pares definidos")
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			//com uma rodada lenta o tick e o ctx ficam prontos juntos e o select
+			//escolhe ao acaso, então confere o ctx antes de começar outra
+			if ctx.Err() != nil {
+				return
+			}
+			s.sendHeartbeatToPeers()
+		}
+	}
+}
+
+// runCheckpoints faz um checkpoint do WAL a cada interval até o ctx ser cancelado
+func runCheckpoints(ctx context.Context, kv *store.KVStore, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := kv.Checkpoint(); err != nil {
+				slog.Error("wal checkpoint failed", "error", err)
+			}
+		}
+	}
+}
+
+// sendHeartbeatToPeers envia um heartbeat para cada peer em paralelo e espera
+// todos responderem (ou o timeout), registrando o resultado no PeerTracker.
+// As conexões ficam abertas no PeerTracker entre as rodadas.
+func (s *server) sendHeartbeatToPeers() {
+	if s.peers == nil {
+		slog.Warn("no peers defined, set PEERS to send heartbeats")
 		return
 	}
 
-	peersList := strings.Split(peers, ",")
+	nodeID := s.store.Status().NodeID
 
-	nodeID := os.Getenv("NODE_ID")
+	timeout := s.heartbeatTimeout
+	if timeout <= 0 {
+		timeout = defaultHeartbeatTimeout
+	}
 
-	for _, peer := range peersList {
+	var wg sync.WaitGroup
+	for _, peer := range s.peers.Addresses() {
+		wg.Add(1)
 		go func(peerAddr string) {
-			conn, err := grpc.NewClient(peerAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
-			if err != nil {
-				log.Printf("Failed to connect to %s: %v", peerAddr, err)
+			defer wg.Done()
 
+			conn, err := s.peers.Conn(peerAddr)
+			if err != nil {
+				slog.Error("failed to connect to peer", "peer", peerAddr, "error", err)
+				s.peers.RecordFailure(peerAddr, err)
 				return
 			}
 
-			defer conn.Close()
-
 			client := pb.NewNodeCommunicationClient(conn)
-			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
 			defer cancel()
 
 			req := &pb.HeartbeatRequest{
@@ -117,95 +985,316 @@ func (s *server) sendHeartbeatToPeers() {
 
 			resp, err := client.Heartbeat(ctx, req)
 			if err != nil {
-				log.Printf("Heartbeat failed to %s: %v", peerAddr, err)
+				slog.Warn("heartbeat failed", "peer", peerAddr, "error", err)
+				s.peers.RecordFailure(peerAddr, err)
+				//a próxima rodada reconecta
+				s.peers.DropConn(peerAddr, conn)
 				return
 			}
 
-			log.Printf("Heartbeat to %s: alive=%v, timestamp=%d", peerAddr, resp.Alive, resp.Timestamp)
+			s.peers.RecordSuccess(peerAddr)
+			slog.Debug("heartbeat sent", "peer", peerAddr, "alive", resp.Alive, "timestamp", resp.Timestamp)
 		}(peer)
 	}
-
+	wg.Wait()
 }
 
-func InitDb(path string) *bolt.DB {
-	db, err := bolt.Open(path, constants.DBFilePermission, nil)
-
+func InitDb(path, bucket string, cfg store.DBConfig) *bolt.DB {
+	db, err := store.OpenDB(path, bucket, cfg)
 	if err != nil {
 		log.Fatalf("failed to open db: %v", err)
 	}
+	return db
+}
 
-	err = db.Update(func(tx *bolt.Tx) error {
-		_, err := tx.CreateBucketIfNotExists([]byte(constants.BucketStore))
-		return err
-	})
+// Shutdown para o servidor esperando as RPCs em andamento terminarem: fecha os
+// watchers logo de início, o que encerra os streams de Watch, e só fecha a
+// store (gravando as escritas ainda agrupadas) depois da última RPC. Por fim
+// desliga o raft e fecha o WAL e o banco. Streams longos que não terminarem
+// dentro do timeout são cancelados com srv.Stop().
+func Shutdown(srv *grpc.Server, kv *store.KVStore, db *bolt.DB, timeout time.Duration) error {
+	stopped := make(chan struct{})
+	go func() {
+		srv.GracefulStop()
+		close(stopped)
+	}()
 
-	if err != nil {
-		log.Fatalf("failed to create bucket db: %v", err)
+	//fechar os watchers deixa os Watch terminarem em vez de esperar o timeout;
+	//a store continua aberta para as requisições em andamento
+	kv.CloseWatchers()
+
+	select {
+	case <-stopped:
+	case <-time.After(timeout):
+		slog.Warn("graceful stop timed out, forcing stop", "timeout", timeout)
+		srv.Stop()
+		<-stopped
 	}
-	return db
+
+	//nenhuma requisição está mais rodando: o Flush do Close pega todas as escritas
+	closeErr := kv.Close()
+	raftErr := kv.ShutdownRaft()
+	store.CloseWAL()
+
+	return errors.Join(closeErr, raftErr, db.Close())
+}
+
+// newLogger cria o logger do servidor filtrando pelo nível informado
+func newLogger(w io.Writer, level string) (*slog.Logger, error) {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("invalid log level %q: %w", level, err)
+	}
+
+	return slog.New(slog.NewTextHandler(w, &slog.HandlerOptions{Level: l})), nil
 }
 
 func main() {
 	flag.Parse()
 
+	if *printVersion {
+		fmt.Println(version.String())
+		return
+	}
+
+	logger, err := newLogger(os.Stderr, *logLevel)
+	if err != nil {
+		log.Fatal(err)
+	}
+	slog.SetDefault(logger)
+
+	if *hbInterval <= 0 || *hbTimeout <= 0 {
+		log.Fatal("--heartbeat-interval and --heartbeat-timeout must be positive")
+	}
+	if *maxRecvMsgSize <= 0 || *maxSendMsgSize <= 0 {
+		log.Fatal("--max-recv-msg-size and --max-send-msg-size must be positive")
+	}
+	if *maxValueSize > *maxRecvMsgSize {
+		slog.Warn("--max-value-size is larger than --max-recv-msg-size, bigger values are rejected by gRPC", "max_value_size", *maxValueSize, "max_recv_msg_size", *maxRecvMsgSize)
+	}
+
 	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", *port))
 
 	if err != nil {
 		log.Fatalf("SOME'IN aint righ: %v", err)
 	}
 
-	srv := grpc.NewServer()
+	limits := store.Limits{
+		MaxKeySize:         *maxKeySize,
+		MaxValueSize:       *maxValueSize,
+		RejectControlChars: *strictKeys,
+	}
+
+	threshold := *compressAbove
+	if *noCompression {
+		threshold = 0
+	}
+
+	cluster := clusterFromEnv(*nodeID)
+	if *clusterConfig != "" {
+		if cluster, err = LoadClusterConfig(*clusterConfig); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	self, ok := cluster.Node(*nodeID)
+	if !ok {
+		log.Fatalf("node %q is not in the cluster config", *nodeID)
+	}
+
+	paths := nodePaths{DB: *dbPath, WAL: store.WALFileName, RaftDir: *raftDir}
+	if *dataDir != "" {
+		if paths, err = dataDirPaths(*dataDir, self.ID); err != nil {
+			log.Fatal(err)
+		}
+	}
+	format, err := store.ParseWALFormat(*walFormat)
+	if err != nil {
+		log.Fatal(err)
+	}
+	store.SetWALFormat(format)
+	store.SetWALPath(paths.WAL)
+
+	db := InitDb(paths.DB, *dbBucket, store.DBConfig{Timeout: *dbTimeout, NoSync: *dbNoSync})
+	store.Init(db)
+
+	eviction, err := store.ParseEvictionMode(*evictionMode)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var secret []byte
+	if *encryptionKey != "" {
+		b, err := os.ReadFile(*encryptionKey)
+		if err != nil {
+			log.Fatalf("read encryption key: %v", err)
+		}
+		if secret = bytes.TrimSpace(b); len(secret) == 0 {
+			log.Fatalf("encryption key file %s is empty", *encryptionKey)
+		}
+	}
+
+	//o Compact troca o banco do bolt backend; o Shutdown fecha o atual
+	boltBackend := store.NewBoltBackend(db)
+	var backend store.Backend = boltBackend
+	if *batchWindow > 0 {
+		backend = store.NewBatchBackend(backend, *batchWindow, *batchSize)
+	}
+
+	kv := store.NewKVStore(
+		store.WithLimits(limits),
+		store.WithBackend(backend),
+		store.WithBucket(*dbBucket),
+		store.WithCompressionThreshold(threshold),
+		store.WithValueEnvelope(*valueEnvelope),
+		store.WithLogger(logger),
+		store.WithWatchBufferSize(*watchBuffer),
+		store.WithWatchBlockTimeout(*watchBlockTime),
+		store.WithSnapshotThreshold(*snapshotEvery),
+		store.WithSnapshotInterval(*snapshotPeriod),
+		store.WithSnapshotRetention(*snapshotRetain),
+		store.WithEncryptionKey(secret),
+		store.WithRaftDir(paths.RaftDir),
+		store.WithMaxEntries(*maxEntries, eviction),
+		store.WithBootstrap(*bootstrap || self.ID == cluster.Bootstrap),
+	)
 
 	s := &server{
-		store: store.NewKVStore(),
+		store:        kv,
+		strict:       *strict,
+		allowClear:   *enableClear,
+		allowCompact: *enableCompact,
+		replicaRead:  *replicaRead,
+
+		allowClusterInfo: *enableCluster,
+		allowStepDown:    *enableStepDown,
+		allowVerify:      *enableVerify,
+		allowSetReadOnly: *enableReadOnly,
+		heartbeatTimeout: *hbTimeout,
+	}
+	s.readOnly.Store(*readOnly)
+	if *maxWatches > 0 || *maxClientWatch > 0 {
+		s.watches = NewWatchLimiter(*maxWatches, *maxClientWatch)
+	}
+	if *idempotencyTTL > 0 {
+		s.idempotency = NewIdempotencyCache(*idempotencyTTL, *idempotencyKeys)
+	}
+
+	m := newMetrics(s.store)
+
+	unary := []grpc.UnaryServerInterceptor{m.UnaryServerInterceptor()}
+	if *rateLimit > 0 {
+		unary = append(unary, NewRateLimiter(*rateLimit, *rateBurst).UnaryServerInterceptor())
 	}
+	unary = append(unary, s.readOnlyUnaryInterceptor(), deadlineInterceptor(*maxRequestTime))
+
+	ka := keepaliveConfig{
+		MaxConnectionIdle:   *maxConnIdle,
+		Time:                *keepaliveTime,
+		Timeout:             *keepaliveWait,
+		MinTime:             *keepaliveMin,
+		PermitWithoutStream: true,
+	}
+
+	serverOpts := append(ka.serverOptions(), messageSizeOptions(*maxRecvMsgSize, *maxSendMsgSize)...)
+	srv := grpc.NewServer(append(serverOpts,
+		grpc.ChainUnaryInterceptor(unary...),
+		grpc.ChainStreamInterceptor(m.StreamServerInterceptor(), s.readOnlyStreamInterceptor()),
+	)...)
 
 	pb.RegisterKvStoreServer(srv, s)
 	pb.RegisterNodeCommunicationServer(srv, s)
+	reflection.Register(srv)
 
-	// if os.Getenv("NODE_ID") == os.Getenv("LEADER") {
-	// 	go func() {
-	// 		ticker := time.NewTicker(10 * time.Second) //10 segundos
-	// 		defer ticker.Stop()
+	//o estado local vem antes do raft: o FSM aplica as entradas e os snapshots
+	//sobre a memória já carregada, e o Join só começa com ela pronta.
+	//reaplica no banco o que ficou só no WAL (ex.: lote do --batch-window perdido num crash)
+	if _, err := s.store.ReplayWAL(); err != nil {
+		slog.Error("failed to replay wal", "error", err)
+	}
 
-	// 		for range ticker.C {
-	// 			s.sendHeartbeatToPeers()
-	// 		}
-	// 	}()
-	// }
+	//restore memomy based on dbData
+	if err := s.store.LoadKeys(); err != nil {
+		slog.Error("failed to load keys", "error", err)
+	}
+	if err := s.store.LoadNamespaces(); err != nil {
+		slog.Error("failed to load namespaces", "error", err)
+	}
+	if err := s.store.LoadRevisions(); err != nil {
+		slog.Error("failed to load revisions", "error", err)
+	}
 
-	db := InitDb(constants.DBFileName)
-	defer db.Close()
-	store.Init(db)
+	if err := s.store.Open(self.RaftAddress, self.ID); err != nil {
+		log.Fatalf("failed to open raft: %v", err)
+	}
 
-	s.store.Open("localhost:"+os.Getenv("PORT"), os.Getenv("NODE_ID"))
+	//heartbeats só saem do líder atual, não de um NODE_ID fixo
+	if peers := cluster.Peers(self.ID); len(peers) > 0 {
+		addrs := make([]string, 0, len(peers))
+		for _, p := range peers {
+			addrs = append(addrs, p.ClientAddress)
+		}
 
-	// if os.Getenv("NODE_ID") == "1" {
-	// 	log.Printf("node 1 %v", os.Getenv("NODE_ID"))
-	// 	s.store.Open("localhost:"+os.Getenv("PORT"), os.Getenv("NODE_ID"))
-	// } else {
-	if os.Getenv("NODE_ID") != "1" {
-		time.Sleep(2 * time.Second)
-		log.Printf("node other nodes %v", os.Getenv("NODE_ID"))
-		s.store.Join("localhost:50051", os.Getenv("NODE_ID"))
+		s.peers = NewPeerTracker(addrs, *hbInterval*peerDownAfter)
+		go s.runHeartbeats(context.Background(), *hbInterval)
 	}
-	// }
 
-	// s.store.Join("localhost:50002", "NODE_03")
-	//restore memomy based on dbData
-	db.View(func(tx *bolt.Tx) error {
-		b := tx.Bucket([]byte(constants.BucketStore))
+	//o Join é feito no líder, pela RPC, enquanto este nó já atende
+	if self.ID != cluster.Bootstrap {
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), joinTimeout)
+			defer cancel()
 
-		b.ForEach(func(k, v []byte) error {
-			s.store.PutFromDb(string(k), string(v))
-			return nil
-		})
-		return nil
-	})
+			slog.Info("joining cluster", "node_id", self.ID, "bootstrap", cluster.Bootstrap)
+			if err := joinCluster(ctx, self, cluster.Peers(self.ID), cluster.Bootstrap, joinRetryMin); err != nil {
+				slog.Error("failed to join cluster", "node_id", self.ID, "error", err)
+			}
+		}()
+	}
+
+	if *walCheckpoint > 0 {
+		go runCheckpoints(context.Background(), s.store, *walCheckpoint)
+	}
+
+	var metricsSrv *http.Server
+	if *metricsPort != 0 {
+		metricsSrv = serveMetrics(*metricsPort, m)
+	}
+
+	var gatewaySrv *http.Server
+	if *httpPort != 0 {
+		gatewaySrv = serveGateway(*httpPort, s)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		sigCh := make(chan os.Signal, 1)
+		signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+
+		sig := <-sigCh
+		slog.Info("shutting down", "signal", sig)
+
+		if metricsSrv != nil {
+			metricsSrv.Close()
+		}
+		if gatewaySrv != nil {
+			gatewaySrv.Close()
+		}
+
+		if err := Shutdown(srv, s.store, boltBackend.DB(), *shutdownTimeout); err != nil {
+			slog.Error("error during shutdown", "error", err)
+		}
+		if s.peers != nil {
+			s.peers.Close()
+		}
+		close(done)
+	}()
 
-	log.Printf("server listening at %v", lis.Addr())
+	slog.Info("server listening", "address", lis.Addr())
 	if err := srv.Serve(lis); err != nil {
 		log.Fatalf("failed to serve: %v", err)
 	}
 
+	<-done
+	slog.Info("server stopped")
 }
@@ -1,24 +1,35 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"net"
 	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/carvalhodanielg/kvstore/internal/constants"
+	"github.com/carvalhodanielg/kvstore/internal/version"
 	pb "github.com/carvalhodanielg/kvstore/pb/proto"
 	"github.com/carvalhodanielg/kvstore/store"
 	bolt "go.etcd.io/bbolt"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
 )
 
-// setupTestServer cria um servidor de teste
-func setupTestServer(t *testing.T) (*grpc.Server, *server, string) {
+// setupTestServer cria um servidor de teste. As opções são aplicadas ao
+// server antes de começar a servir.
+func setupTestServer(t *testing.T, opts ...func(*server)) (*grpc.Server, *server, string) {
 	// Cria um banco de dados temporário
 	dbPath := "test_server.db"
 	os.Remove(dbPath) // Remove se existir
@@ -46,6 +57,10 @@ func setupTestServer(t *testing.T) (*grpc.Server, *server, string) {
 		store: store.NewKVStore(),
 	}
 
+	for _, opt := range opts {
+		opt(s)
+	}
+
 	pb.RegisterKvStoreServer(srv, s)
 
 	// Escolhe uma porta disponível
@@ -91,15 +106,17 @@ func TestServer_Put(t *testing.T) {
 	client := createTestClient(t, addr)
 
 	tests := []struct {
-		name  string
-		key   string
-		value string
+		name    string
+		key     string
+		value   string
+		wantErr bool
 	}{
-		{"normal_put", "key1", "value1"},
-		{"empty_key", "", "value"},
-		{"empty_value", "key", ""},
-		{"special_chars", "key!@#$%", "value!@#$%"},
-		{"unicode", "key_中文", "value_中文"},
+		{"normal_put", "key1", "value1", false},
+		// A chave vazia é recusada antes de chegar ao bbolt, que não a grava
+		{"empty_key", "", "value", true},
+		{"empty_value", "key", "", false},
+		{"special_chars", "key!@#$%", "value!@#$%", false},
+		{"unicode", "key_中文", "value_中文", false},
 	}
 
 	for _, tt := range tests {
@@ -110,6 +127,19 @@ func TestServer_Put(t *testing.T) {
 			}
 
 			resp, err := client.Put(context.Background(), req)
+			if tt.wantErr {
+				if status.Code(err) != codes.InvalidArgument {
+					t.Fatalf("Put() error = %v, want InvalidArgument", err)
+				}
+				getResp, err := client.Get(context.Background(), &pb.GetRequest{Key: tt.key})
+				if err != nil {
+					t.Fatalf("Get() failed: %v", err)
+				}
+				if getResp.Value != "" {
+					t.Errorf("Get() after failed Put() = %q, want empty", getResp.Value)
+				}
+				return
+			}
 			if err != nil {
 				t.Fatalf("Put() failed: %v", err)
 			}
@@ -157,7 +187,11 @@ func TestServer_Get(t *testing.T) {
 	testData := map[string]string{
 		"key1": "value1",
 		"key2": "value2",
-		"":     "empty_key",
+	}
+
+	// A chave vazia não é gravada
+	if _, err := client.Put(context.Background(), &pb.PutRequest{Key: "", Value: "empty_key"}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Put() with an empty key returned %v, expected InvalidArgument", err)
 	}
 
 	for key, value := range testData {
@@ -186,6 +220,110 @@ func TestServer_Get(t *testing.T) {
 	}
 }
 
+func TestServer_Get_Replica(t *testing.T) {
+	srv, _, addr := setupTestServer(t)
+	defer cleanupTestServer(t, srv, addr)
+
+	client := createTestClient(t, addr)
+
+	if _, err := client.Put(context.Background(), &pb.PutRequest{Key: "key", Value: "value"}); err != nil {
+		t.Fatalf("Put() failed: %v", err)
+	}
+
+	// Sem raft o nó é a fonte da verdade: atende REPLICA mesmo sem --replica-read
+	resp, err := client.Get(context.Background(), &pb.GetRequest{
+		Key:            "key",
+		Consistency:    pb.Consistency_CONSISTENCY_REPLICA,
+		MaxStalenessMs: 1,
+	})
+	if err != nil {
+		t.Fatalf("Get() with REPLICA failed: %v", err)
+	}
+	if resp.GetValue() != "value" || resp.GetStalenessMs() != 0 {
+		t.Errorf("Get() with REPLICA = %q (staleness %dms), expected value with no staleness", resp.GetValue(), resp.GetStalenessMs())
+	}
+}
+
+func TestServer_Get_Timestamps(t *testing.T) {
+	srv, _, addr := setupTestServer(t)
+	defer cleanupTestServer(t, srv, addr)
+
+	client := createTestClient(t, addr)
+
+	put := func(value string) *pb.GetResponse {
+		t.Helper()
+		if _, err := client.Put(context.Background(), &pb.PutRequest{Key: "key", Value: value}); err != nil {
+			t.Fatalf("Put() failed: %v", err)
+		}
+		resp, err := client.Get(context.Background(), &pb.GetRequest{Key: "key"})
+		if err != nil {
+			t.Fatalf("Get() failed: %v", err)
+		}
+		return resp
+	}
+
+	first := put("v1")
+	if first.GetCreatedAt() == 0 || first.GetUpdatedAt() != first.GetCreatedAt() {
+		t.Errorf("First write: created_at %d, updated_at %d", first.GetCreatedAt(), first.GetUpdatedAt())
+	}
+
+	time.Sleep(2 * time.Millisecond)
+	second := put("v2")
+	if second.GetCreatedAt() != first.GetCreatedAt() || second.GetUpdatedAt() <= first.GetUpdatedAt() {
+		t.Errorf("Overwrite: created_at %d -> %d, updated_at %d -> %d",
+			first.GetCreatedAt(), second.GetCreatedAt(), first.GetUpdatedAt(), second.GetUpdatedAt())
+	}
+
+	missing, err := client.Get(context.Background(), &pb.GetRequest{Key: "missing"})
+	if err != nil {
+		t.Fatalf("Get() failed: %v", err)
+	}
+	if missing.GetCreatedAt() != 0 || missing.GetUpdatedAt() != 0 {
+		t.Errorf("Missing key has timestamps %d / %d, expected 0", missing.GetCreatedAt(), missing.GetUpdatedAt())
+	}
+}
+
+func TestServer_BinaryValues(t *testing.T) {
+	srv, _, addr := setupTestServer(t)
+	defer cleanupTestServer(t, srv, addr)
+
+	client := createTestClient(t, addr)
+	ctx := context.Background()
+	binary := []byte{0xff, 0x00, 0xfe, 0x80}
+
+	if _, err := client.Put(ctx, &pb.PutRequest{Key: "bin", ValueBytes: binary}); err != nil {
+		t.Fatalf("Put() with value_bytes failed: %v", err)
+	}
+	if _, err := client.Put(ctx, &pb.PutRequest{Key: "text", Value: "ignored", ValueBytes: []byte("bytes")}); err != nil {
+		t.Fatalf("Put() failed: %v", err)
+	}
+
+	//um valor que não é UTF-8 sempre volta em value_bytes
+	resp, err := client.Get(ctx, &pb.GetRequest{Key: "bin"})
+	if err != nil {
+		t.Fatalf("Get() failed: %v", err)
+	}
+	if !bytes.Equal(resp.GetValueBytes(), binary) || resp.GetValue() != "" {
+		t.Errorf("Get(bin) = %q / %x, expected value_bytes %x", resp.GetValue(), resp.GetValueBytes(), binary)
+	}
+
+	resp, err = client.Get(ctx, &pb.GetRequest{Key: "text"})
+	if err != nil {
+		t.Fatalf("Get() failed: %v", err)
+	}
+	if resp.GetValue() != "bytes" || resp.GetValueBytes() != nil {
+		t.Errorf("Get(text) = %q / %x, expected value %q", resp.GetValue(), resp.GetValueBytes(), "bytes")
+	}
+
+	resp, err = client.Get(ctx, &pb.GetRequest{Key: "text", Binary: true})
+	if err != nil {
+		t.Fatalf("Get() failed: %v", err)
+	}
+	if string(resp.GetValueBytes()) != "bytes" || resp.GetValue() != "" {
+		t.Errorf("Get(text, binary) = %q / %x, expected value_bytes %q", resp.GetValue(), resp.GetValueBytes(), "bytes")
+	}
+}
+
 func TestServer_Delete(t *testing.T) {
 	srv, _, addr := setupTestServer(t)
 	defer cleanupTestServer(t, srv, addr)
@@ -252,6 +390,30 @@ func TestServer_Delete(t *testing.T) {
 	}
 }
 
+func TestServer_DeleteMany(t *testing.T) {
+	srv, s, addr := setupTestServer(t)
+	defer cleanupTestServer(t, srv, addr)
+
+	client := createTestClient(t, addr)
+
+	for _, key := range []string{"key1", "key2", "key3"} {
+		if _, err := client.Put(context.Background(), &pb.PutRequest{Key: key, Value: "v"}); err != nil {
+			t.Fatalf("Put() failed: %v", err)
+		}
+	}
+
+	resp, err := client.DeleteMany(context.Background(), &pb.DeleteManyRequest{Keys: []string{"key1", "nope", "key3"}})
+	if err != nil {
+		t.Fatalf("DeleteMany() failed: %v", err)
+	}
+	if resp.GetDeleted() != 2 {
+		t.Errorf("DeleteMany() deleted %d keys, expected 2", resp.GetDeleted())
+	}
+	if got := s.store.GetAll(); len(got) != 1 || got["key2"] != "v" {
+		t.Errorf("GetAll() after DeleteMany() = %v", got)
+	}
+}
+
 func TestServer_GetAll(t *testing.T) {
 	srv, _, addr := setupTestServer(t)
 	defer cleanupTestServer(t, srv, addr)
@@ -309,7 +471,9 @@ func TestServer_Watch(t *testing.T) {
 
 	// Cria um stream de watch
 	req := &pb.WatchRequest{Key: "test_key"}
-	stream, err := client.Watch(context.Background(), req)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream, err := client.Watch(ctx, req)
 	if err != nil {
 		t.Fatalf("Watch() failed: %v", err)
 	}
@@ -356,8 +520,9 @@ func TestServer_Watch(t *testing.T) {
 	// Aguarda um pouco para as notificações chegarem
 	time.Sleep(200 * time.Millisecond)
 
-	// Fecha o stream
-	stream.CloseSend()
+	// Fecha o stream: o CloseSend não encerra um stream do servidor, só o
+	// cancelamento do contexto faz o Watch retornar
+	cancel()
 
 	// Aguarda o canal ser fechado
 	<-done
@@ -438,45 +603,1567 @@ func TestServer_Concurrency(t *testing.T) {
 	}
 }
 
-func TestInitDb(t *testing.T) {
-	dbPath := "test_init.db"
-	os.Remove(dbPath) // Remove se existir
+// waitForWatchers espera o número de watchers da store chegar em expected
+func waitForWatchers(t *testing.T, kv *store.KVStore, expected int) {
+	deadline := time.Now().Add(2 * time.Second)
+	for kv.WatcherCount() != expected {
+		if time.Now().After(deadline) {
+			t.Fatalf("WatcherCount() = %d, expected %d", kv.WatcherCount(), expected)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
 
-	// Testa criação do banco
-	db := InitDb(dbPath)
-	if db == nil {
-		t.Fatal("InitDb() returned nil")
+func TestServer_Watch_ClientDisconnect(t *testing.T) {
+	srv, s, addr := setupTestServer(t)
+	defer cleanupTestServer(t, srv, addr)
+
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
 	}
 
-	// Verifica se o bucket foi criado
-	err := db.View(func(tx *bolt.Tx) error {
-		b := tx.Bucket([]byte(constants.BucketStore))
-		if b == nil {
-			return fmt.Errorf("bucket not found")
+	client := pb.NewKvStoreClient(conn)
+	if _, err := client.Watch(context.Background(), &pb.WatchRequest{Key: "test_key"}); err != nil {
+		t.Fatalf("Watch() failed: %v", err)
+	}
+
+	waitForWatchers(t, s.store, 1)
+
+	// Derruba a conexão sem nenhum evento pendente: o handler só percebe
+	// pelo contexto do stream
+	conn.Close()
+
+	waitForWatchers(t, s.store, 0)
+}
+
+func TestServer_WatchLeader(t *testing.T) {
+	srv, _, addr := setupTestServer(t)
+	defer cleanupTestServer(t, srv, addr)
+
+	client := createTestClient(t, addr)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stream, err := client.WatchLeader(ctx, &pb.WatchLeaderRequest{})
+	if err != nil {
+		t.Fatalf("WatchLeader() failed: %v", err)
+	}
+
+	// Sem raft o primeiro evento informa que não há líder
+	resp, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("Recv() failed: %v", err)
+	}
+	if resp.LeaderAddress != "" || resp.LeaderId != "" {
+		t.Errorf("Expected no leader on a standalone node, got %q/%q", resp.LeaderAddress, resp.LeaderId)
+	}
+}
+
+func TestServer_Watch_InitialValue(t *testing.T) {
+	srv, _, addr := setupTestServer(t)
+	defer cleanupTestServer(t, srv, addr)
+
+	client := createTestClient(t, addr)
+
+	if _, err := client.Put(context.Background(), &pb.PutRequest{Key: "test_key", Value: "before"}); err != nil {
+		t.Fatalf("Put() failed: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	stream, err := client.Watch(ctx, &pb.WatchRequest{Key: "test_key", SendInitialValue: true})
+	if err != nil {
+		t.Fatalf("Watch() failed: %v", err)
+	}
+
+	// O primeiro evento chega sem nenhuma escrita nova
+	resp, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("Recv() failed: %v", err)
+	}
+	if resp.Message != "Key test_key current value before" {
+		t.Errorf("Wrong initial event. Expected current value before, got %s", resp.Message)
+	}
+
+	if _, err := client.Put(context.Background(), &pb.PutRequest{Key: "test_key", Value: "after"}); err != nil {
+		t.Fatalf("Put() failed: %v", err)
+	}
+
+	resp, err = stream.Recv()
+	if err != nil {
+		t.Fatalf("Recv() failed: %v", err)
+	}
+	if resp.Message != "Key test_key updated to after" {
+		t.Errorf("Wrong update event. Expected updated to after, got %s", resp.Message)
+	}
+}
+
+func TestServer_Watch_StructuredEvents(t *testing.T) {
+	srv, s, addr := setupTestServer(t)
+	defer cleanupTestServer(t, srv, addr)
+
+	client := createTestClient(t, addr)
+	kv := s.store
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	stream, err := client.Watch(ctx, &pb.WatchRequest{Key: "test_key"})
+	if err != nil {
+		t.Fatalf("Watch() failed: %v", err)
+	}
+	waitForWatchers(t, kv, 1)
+
+	if _, err := client.Put(ctx, &pb.PutRequest{Key: "test_key", Value: "v1"}); err != nil {
+		t.Fatalf("Put() failed: %v", err)
+	}
+	putRev := kv.Revision("test_key")
+	if _, err := client.Delete(ctx, &pb.DeleteRequest{Key: "test_key"}); err != nil {
+		t.Fatalf("Delete() failed: %v", err)
+	}
+
+	want := []*pb.WatchResponse{
+		{Message: "Key test_key updated to v1", Type: pb.WatchEventType_WATCH_EVENT_PUT, Key: "test_key", Value: "v1", Revision: putRev},
+		{Message: "Key test_key deleted", Type: pb.WatchEventType_WATCH_EVENT_DELETE, Key: "test_key", Revision: putRev + 1},
+	}
+	for _, w := range want {
+		resp, err := stream.Recv()
+		if err != nil {
+			t.Fatalf("Recv() failed: %v", err)
 		}
-		return nil
-	})
+		if resp.GetMessage() != w.GetMessage() || resp.GetType() != w.GetType() || resp.GetKey() != w.GetKey() ||
+			resp.GetValue() != w.GetValue() || resp.GetRevision() != w.GetRevision() || resp.GetNamespace() != "" {
+			t.Errorf("Watch event = %v, expected %v", resp, w)
+		}
+	}
+}
+
+func TestServer_Watch_BlockPolicy(t *testing.T) {
+	srv, s, addr := setupTestServer(t)
+	defer cleanupTestServer(t, srv, addr)
+
+	client := createTestClient(t, addr)
 
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	stream, err := client.Watch(ctx, &pb.WatchRequest{Key: "burst", Policy: pb.WatchPolicy_WATCH_POLICY_BLOCK})
 	if err != nil {
-		t.Fatalf("Bucket not created: %v", err)
+		t.Fatalf("Watch() failed: %v", err)
 	}
+	waitForWatchers(t, s.store, 1)
 
-	// Limpa
-	db.Close()
-	os.Remove(dbPath)
+	// Muito mais escritas que o buffer do watcher, sem o cliente ler
+	const updates = 200
+	go func() {
+		for i := range updates {
+			s.store.Put("burst", fmt.Sprintf("v%d", i))
+		}
+	}()
+
+	for i := range updates {
+		resp, err := stream.Recv()
+		if err != nil {
+			t.Fatalf("Recv() failed after %d events: %v", i, err)
+		}
+		if want := fmt.Sprintf("Key burst updated to v%d", i); resp.Message != want {
+			t.Fatalf("Expected %q, got %q", want, resp.Message)
+		}
+	}
 }
 
-func TestMain(m *testing.M) {
-	// Configura flags para testes
-	flag.Set("port", "0") // Usa porta aleatória
+func TestServer_WatchAll(t *testing.T) {
+	srv, s, addr := setupTestServer(t)
+	defer cleanupTestServer(t, srv, addr)
 
-	// Executa os testes
-	code := m.Run()
+	client := createTestClient(t, addr)
 
-	// Limpa arquivos de teste que possam ter sido criados
-	os.Remove("test_server.db")
-	os.Remove("test_init.db")
-	os.Remove("walog.ndjson")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
 
-	os.Exit(code)
+	stream, err := client.WatchAll(ctx, &pb.WatchAllRequest{})
+	if err != nil {
+		t.Fatalf("WatchAll() failed: %v", err)
+	}
+	waitForWatchers(t, s.store, 1)
+
+	if _, err := client.Put(ctx, &pb.PutRequest{Key: "k1", Value: "v1"}); err != nil {
+		t.Fatalf("Put() failed: %v", err)
+	}
+	if _, err := client.Put(ctx, &pb.PutRequest{Key: "k2", Value: "v2"}); err != nil {
+		t.Fatalf("Put() failed: %v", err)
+	}
+	if _, err := client.Delete(ctx, &pb.DeleteRequest{Key: "k1"}); err != nil {
+		t.Fatalf("Delete() failed: %v", err)
+	}
+
+	for _, want := range []string{"Key k1 updated to v1", "Key k2 updated to v2", "Key k1 deleted"} {
+		resp, err := stream.Recv()
+		if err != nil {
+			t.Fatalf("Recv() failed: %v", err)
+		}
+		if resp.Message != want {
+			t.Errorf("Expected %q, got %q", want, resp.Message)
+		}
+	}
+
+	// cancelar o stream libera o watcher global
+	cancel()
+	waitForWatchers(t, s.store, 0)
+}
+
+func TestServer_WatchAll_Prefix(t *testing.T) {
+	srv, s, addr := setupTestServer(t)
+	defer cleanupTestServer(t, srv, addr)
+
+	client := createTestClient(t, addr)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	stream, err := client.WatchAll(ctx, &pb.WatchAllRequest{Prefix: "user:"})
+	if err != nil {
+		t.Fatalf("WatchAll() failed: %v", err)
+	}
+	waitForWatchers(t, s.store, 1)
+
+	s.store.Put("order:1", "x")
+	s.store.Put("user:1", "ana")
+	s.store.Clear()
+
+	for _, want := range []pb.WatchEventType{pb.WatchEventType_WATCH_EVENT_PUT, pb.WatchEventType_WATCH_EVENT_CLEAR} {
+		resp, err := stream.Recv()
+		if err != nil {
+			t.Fatalf("Recv() failed: %v", err)
+		}
+		if resp.GetType() != want || (want == pb.WatchEventType_WATCH_EVENT_PUT && resp.GetKey() != "user:1") {
+			t.Errorf("Expected a %v event, got %+v", want, resp)
+		}
+	}
+}
+
+func TestServer_BackupRestore(t *testing.T) {
+	// Primeiro servidor: popula e faz o backup
+	srv, _, addr := setupTestServer(t)
+	client := createTestClient(t, addr)
+
+	testData := map[string]string{
+		"user:1":    "John Doe",
+		"user:2":    "Jane Smith",
+		"config:db": "postgresql://localhost:5432/mydb",
+	}
+
+	for key, value := range testData {
+		_, err := client.Put(context.Background(), &pb.PutRequest{Key: key, Value: value})
+		if err != nil {
+			t.Fatalf("Put() failed: %v", err)
+		}
+	}
+
+	backupStream, err := client.Backup(context.Background(), &pb.BackupRequest{})
+	if err != nil {
+		t.Fatalf("Backup() failed: %v", err)
+	}
+
+	var backup []*pb.BackupResponse
+	for {
+		resp, err := backupStream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Backup() stream failed: %v", err)
+		}
+		backup = append(backup, resp)
+	}
+
+	if len(backup) != len(testData) {
+		t.Fatalf("Backup() returned wrong number of items. Expected %d, got %d", len(testData), len(backup))
+	}
+
+	cleanupTestServer(t, srv, addr)
+
+	// Segundo servidor: começa vazio e recebe o restore
+	srv, _, addr = setupTestServer(t)
+	defer cleanupTestServer(t, srv, addr)
+
+	client = createTestClient(t, addr)
+
+	restoreStream, err := client.Restore(context.Background())
+	if err != nil {
+		t.Fatalf("Restore() failed: %v", err)
+	}
+
+	for _, entry := range backup {
+		req := &pb.RestoreRequest{Key: entry.Key, Value: entry.Value, Mode: pb.RestoreMode_RESTORE_REPLACE}
+		if err := restoreStream.Send(req); err != nil {
+			t.Fatalf("Restore() send failed: %v", err)
+		}
+	}
+
+	restoreResp, err := restoreStream.CloseAndRecv()
+	if err != nil {
+		t.Fatalf("Restore() failed: %v", err)
+	}
+
+	if restoreResp.Restored != int64(len(testData)) {
+		t.Errorf("Restore() returned wrong count. Expected %d, got %d", len(testData), restoreResp.Restored)
+	}
+
+	getAllResp, err := client.GetAll(context.Background(), &pb.GetAllRequest{})
+	if err != nil {
+		t.Fatalf("GetAll() failed: %v", err)
+	}
+
+	if len(getAllResp.Values) != len(testData) {
+		t.Errorf("GetAll() after restore returned wrong number of items. Expected %d, got %d", len(testData), len(getAllResp.Values))
+	}
+
+	for key, expectedValue := range testData {
+		if getAllResp.Values[key] != expectedValue {
+			t.Errorf("GetAll() after restore returned wrong value for key %s. Expected %s, got %s", key, expectedValue, getAllResp.Values[key])
+		}
+	}
+}
+
+func TestServer_RestoreDryRun(t *testing.T) {
+	srv, s, addr := setupTestServer(t, func(s *server) {
+		s.store = store.NewKVStore(store.WithLimits(store.Limits{MaxKeySize: 8, MaxValueSize: 16}))
+	})
+	defer cleanupTestServer(t, srv, addr)
+
+	s.store.Put("existing", "kept")
+
+	client := createTestClient(t, addr)
+
+	stream, err := client.Restore(context.Background())
+	if err != nil {
+		t.Fatalf("Restore() failed: %v", err)
+	}
+
+	// O modo REPLACE também não apaga nada no dry run
+	pairs := []*pb.RestoreRequest{
+		{Key: "a", Value: "1", Mode: pb.RestoreMode_RESTORE_REPLACE, DryRun: true},
+		{Key: "key_too_long", Value: "v"},
+		{Key: "b", Value: strings.Repeat("v", 17)},
+		{Key: "a", Value: "22"},
+	}
+	for _, p := range pairs {
+		if err := stream.Send(p); err != nil {
+			t.Fatalf("Send() failed: %v", err)
+		}
+	}
+
+	resp, err := stream.CloseAndRecv()
+	if err != nil {
+		t.Fatalf("CloseAndRecv() failed: %v", err)
+	}
+	if resp.GetRestored() != 0 || resp.GetChecked() != 4 || resp.GetInvalid() != 2 || resp.GetDuplicates() != 1 || resp.GetBytes() != 5 {
+		t.Errorf("Unexpected dry run summary: %v", resp)
+	}
+	if len(resp.GetErrors()) != 2 || !strings.Contains(resp.GetErrors()[0], "key_too_long") {
+		t.Errorf("Unexpected dry run errors: %q", resp.GetErrors())
+	}
+
+	if all := s.store.GetAll(); len(all) != 1 || all["existing"] != "kept" {
+		t.Errorf("Dry run changed the store: %v", all)
+	}
+}
+
+func TestServer_StrictStatusCodes(t *testing.T) {
+	srv, _, addr := setupTestServer(t, func(s *server) {
+		s.strict = true
+	})
+	defer cleanupTestServer(t, srv, addr)
+
+	client := createTestClient(t, addr)
+
+	tests := []struct {
+		name string
+		call func() error
+		code codes.Code
+	}{
+		{"missing_key", func() error {
+			_, err := client.Get(context.Background(), &pb.GetRequest{Key: "nonexistent"})
+			return err
+		}, codes.NotFound},
+		{"empty_key", func() error {
+			_, err := client.Put(context.Background(), &pb.PutRequest{Key: "", Value: "value"})
+			return err
+		}, codes.InvalidArgument},
+		{"oversized_key", func() error {
+			_, err := client.Put(context.Background(), &pb.PutRequest{Key: strings.Repeat("k", bolt.MaxKeySize+1), Value: "value"})
+			return err
+		}, codes.InvalidArgument},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.call()
+			if status.Code(err) != tt.code {
+				t.Errorf("expected status %v, got %v (err=%v)", tt.code, status.Code(err), err)
+			}
+		})
+	}
+
+	// Chave existente com valor vazio não é NotFound
+	if _, err := client.Put(context.Background(), &pb.PutRequest{Key: "empty_value", Value: ""}); err != nil {
+		t.Fatalf("Put() failed: %v", err)
+	}
+
+	resp, err := client.Get(context.Background(), &pb.GetRequest{Key: "empty_value"})
+	if err != nil {
+		t.Fatalf("Get() for key with empty value failed: %v", err)
+	}
+	if resp.Value != "" {
+		t.Errorf("Get() returned wrong value. Expected empty, got %s", resp.Value)
+	}
+}
+
+func TestServer_PermissiveGet(t *testing.T) {
+	srv, _, addr := setupTestServer(t)
+	defer cleanupTestServer(t, srv, addr)
+
+	client := createTestClient(t, addr)
+
+	// Sem o modo strict o comportamento antigo é mantido
+	resp, err := client.Get(context.Background(), &pb.GetRequest{Key: "nonexistent"})
+	if err != nil {
+		t.Fatalf("Get() in permissive mode should not fail: %v", err)
+	}
+	if resp.Value != "" {
+		t.Errorf("Get() for nonexistent key should return empty value, got %s", resp.Value)
+	}
+}
+
+func TestServer_SizeLimits(t *testing.T) {
+	srv, _, addr := setupTestServer(t, func(s *server) {
+		s.store = store.NewKVStore(store.WithLimits(store.Limits{MaxKeySize: 8, MaxValueSize: 16}))
+	})
+	defer cleanupTestServer(t, srv, addr)
+
+	client := createTestClient(t, addr)
+
+	tests := []struct {
+		name  string
+		key   string
+		value string
+		code  codes.Code
+	}{
+		{"key_at_limit", strings.Repeat("k", 8), "value", codes.OK},
+		{"key_over_limit", strings.Repeat("k", 9), "value", codes.InvalidArgument},
+		{"value_at_limit", "key", strings.Repeat("v", 16), codes.OK},
+		{"value_over_limit", "key", strings.Repeat("v", 17), codes.InvalidArgument},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := client.Put(context.Background(), &pb.PutRequest{Key: tt.key, Value: tt.value})
+			if status.Code(err) != tt.code {
+				t.Errorf("Put() expected status %v, got %v (err=%v)", tt.code, status.Code(err), err)
+			}
+		})
+	}
+}
+
+func TestServer_StrictKeys(t *testing.T) {
+	for _, strictKeys := range []bool{false, true} {
+		t.Run(fmt.Sprintf("strict_keys=%v", strictKeys), func(t *testing.T) {
+			srv, _, addr := setupTestServer(t, func(s *server) {
+				s.store = store.NewKVStore(store.WithLimits(store.Limits{RejectControlChars: strictKeys}))
+			})
+			defer cleanupTestServer(t, srv, addr)
+
+			client := createTestClient(t, addr)
+
+			_, err := client.Put(context.Background(), &pb.PutRequest{Key: "line\nbreak", Value: "value"})
+			want := codes.OK
+			if strictKeys {
+				want = codes.InvalidArgument
+			}
+			if status.Code(err) != want {
+				t.Errorf("Put() with newline key: expected status %v, got %v (err=%v)", want, status.Code(err), err)
+			}
+		})
+	}
+}
+
+func TestServer_Status(t *testing.T) {
+	srv, _, addr := setupTestServer(t)
+	defer cleanupTestServer(t, srv, addr)
+
+	client := createTestClient(t, addr)
+
+	for _, key := range []string{"key1", "key2"} {
+		if _, err := client.Put(context.Background(), &pb.PutRequest{Key: key, Value: "value"}); err != nil {
+			t.Fatalf("Put() failed: %v", err)
+		}
+	}
+
+	resp, err := client.Status(context.Background(), &pb.StatusRequest{})
+	if err != nil {
+		t.Fatalf("Status() failed: %v", err)
+	}
+
+	// O servidor de teste não abre o raft
+	if resp.State != "Standalone" {
+		t.Errorf("Status() returned wrong state. Expected Standalone, got %s", resp.State)
+	}
+	if resp.Keys != 2 {
+		t.Errorf("Status() returned wrong key count. Expected 2, got %d", resp.Keys)
+	}
+	if resp.WalSize == 0 {
+		t.Error("Status() returned empty WAL size after writes")
+	}
+}
+
+func TestServer_Ping(t *testing.T) {
+	srv, _, addr := setupTestServer(t)
+	defer cleanupTestServer(t, srv, addr)
+
+	client := createTestClient(t, addr)
+
+	before := time.Now()
+	resp, err := client.Ping(context.Background(), &pb.PingRequest{Nonce: "abc-123"})
+	if err != nil {
+		t.Fatalf("Ping() failed: %v", err)
+	}
+
+	if resp.Nonce != "abc-123" {
+		t.Errorf("Ping() returned nonce %q, expected abc-123", resp.Nonce)
+	}
+
+	serverTime := time.Unix(0, resp.Timestamp)
+	if serverTime.Before(before.Add(-time.Second)) || serverTime.After(time.Now().Add(time.Second)) {
+		t.Errorf("Ping() returned timestamp %v, expected close to %v", serverTime, before)
+	}
+}
+
+func TestServer_Count(t *testing.T) {
+	srv, _, addr := setupTestServer(t)
+	defer cleanupTestServer(t, srv, addr)
+
+	client := createTestClient(t, addr)
+	ctx := context.Background()
+
+	for _, key := range []string{"user:1", "user:2", "order:1"} {
+		if _, err := client.Put(ctx, &pb.PutRequest{Key: key, Value: "value"}); err != nil {
+			t.Fatalf("Put() failed: %v", err)
+		}
+	}
+	if _, err := client.Delete(ctx, &pb.DeleteRequest{Key: "user:2"}); err != nil {
+		t.Fatalf("Delete() failed: %v", err)
+	}
+
+	tests := []struct {
+		prefix   string
+		expected int64
+	}{
+		{"", 2},
+		{"user:", 1},
+		{"order:", 1},
+		{"missing:", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.prefix, func(t *testing.T) {
+			resp, err := client.Count(ctx, &pb.CountRequest{Prefix: tt.prefix})
+			if err != nil {
+				t.Fatalf("Count() failed: %v", err)
+			}
+			if resp.Count != tt.expected {
+				t.Errorf("Count(%q) returned %d, expected %d", tt.prefix, resp.Count, tt.expected)
+			}
+		})
+	}
+}
+
+func TestServer_Keys(t *testing.T) {
+	srv, _, addr := setupTestServer(t)
+	defer cleanupTestServer(t, srv, addr)
+
+	client := createTestClient(t, addr)
+	ctx := context.Background()
+
+	for _, key := range []string{"user:2", "order:1", "user:1"} {
+		if _, err := client.Put(ctx, &pb.PutRequest{Key: key, Value: "value"}); err != nil {
+			t.Fatalf("Put() failed: %v", err)
+		}
+	}
+
+	tests := []struct {
+		prefix   string
+		expected []string
+	}{
+		{"", []string{"order:1", "user:1", "user:2"}},
+		{"user:", []string{"user:1", "user:2"}},
+		{"missing:", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.prefix, func(t *testing.T) {
+			resp, err := client.Keys(ctx, &pb.KeysRequest{Prefix: tt.prefix})
+			if err != nil {
+				t.Fatalf("Keys() failed: %v", err)
+			}
+			if !slices.Equal(resp.Keys, tt.expected) {
+				t.Errorf("Keys(%q) returned %v, expected %v", tt.prefix, resp.Keys, tt.expected)
+			}
+		})
+	}
+}
+
+func TestServer_MultiScan(t *testing.T) {
+	srv, _, addr := setupTestServer(t)
+	defer cleanupTestServer(t, srv, addr)
+
+	client := createTestClient(t, addr)
+	ctx := context.Background()
+
+	for _, key := range []string{"user:2", "user:admin:1", "order:1", "user:1"} {
+		if _, err := client.Put(ctx, &pb.PutRequest{Key: key, Value: "v-" + key}); err != nil {
+			t.Fatalf("Put() failed: %v", err)
+		}
+	}
+
+	resp, err := client.MultiScan(ctx, &pb.MultiScanRequest{})
+	if err != nil {
+		t.Fatalf("MultiScan() failed: %v", err)
+	}
+	if len(resp.GetResults()) != 0 {
+		t.Errorf("MultiScan() without prefixes returned %v, expected none", resp.GetResults())
+	}
+
+	resp, err = client.MultiScan(ctx, &pb.MultiScanRequest{Prefixes: []string{"user:", "missing:", "user:admin:", "user:"}})
+	if err != nil {
+		t.Fatalf("MultiScan() failed: %v", err)
+	}
+
+	// Um resultado por prefixo distinto, na ordem do pedido, com as chaves ordenadas
+	expected := map[string][]string{
+		"user:":       {"user:1", "user:2"},
+		"missing:":    nil,
+		"user:admin:": {"user:admin:1"},
+	}
+	var prefixes []string
+	for _, r := range resp.GetResults() {
+		prefixes = append(prefixes, r.GetPrefix())
+
+		var keys []string
+		for _, kv := range r.GetValues() {
+			keys = append(keys, kv.GetKey())
+			if kv.GetValue() != "v-"+kv.GetKey() || !kv.GetFound() {
+				t.Errorf("MultiScan() returned %v for %s", kv, kv.GetKey())
+			}
+		}
+		if !slices.Equal(keys, expected[r.GetPrefix()]) {
+			t.Errorf("MultiScan() prefix %q returned %v, expected %v", r.GetPrefix(), keys, expected[r.GetPrefix()])
+		}
+	}
+	if want := []string{"user:", "missing:", "user:admin:"}; !slices.Equal(prefixes, want) {
+		t.Errorf("MultiScan() returned prefixes %v, expected %v", prefixes, want)
+	}
+}
+
+func TestServer_Clear(t *testing.T) {
+	t.Run("disabled", func(t *testing.T) {
+		srv, _, addr := setupTestServer(t)
+		defer cleanupTestServer(t, srv, addr)
+
+		client := createTestClient(t, addr)
+
+		_, err := client.Clear(context.Background(), &pb.ClearRequest{})
+		if status.Code(err) != codes.PermissionDenied {
+			t.Errorf("Clear() expected PermissionDenied, got %v", err)
+		}
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		srv, _, addr := setupTestServer(t, func(s *server) { s.allowClear = true })
+		defer cleanupTestServer(t, srv, addr)
+
+		client := createTestClient(t, addr)
+		ctx := context.Background()
+
+		for i := range 5 {
+			if _, err := client.Put(ctx, &pb.PutRequest{Key: fmt.Sprintf("key%d", i), Value: "value"}); err != nil {
+				t.Fatalf("Put() failed: %v", err)
+			}
+		}
+
+		resp, err := client.Clear(ctx, &pb.ClearRequest{})
+		if err != nil {
+			t.Fatalf("Clear() failed: %v", err)
+		}
+		if !resp.Success {
+			t.Error("Clear() returned success=false")
+		}
+
+		all, err := client.GetAll(ctx, &pb.GetAllRequest{})
+		if err != nil {
+			t.Fatalf("GetAll() failed: %v", err)
+		}
+		if len(all.Values) != 0 {
+			t.Errorf("GetAll() after Clear() returned %d keys, expected 0", len(all.Values))
+		}
+	})
+}
+
+// failingBackend recusa qualquer escrita
+type failingBackend struct {
+	store.Backend
+}
+
+func (failingBackend) Update(func(tx store.Backend) error) error {
+	return errors.New("disk full")
+}
+
+func TestServer_Put_WriteFailure(t *testing.T) {
+	srv, _, addr := setupTestServer(t, func(s *server) {
+		s.store = store.NewKVStore(store.WithBackend(failingBackend{store.NewMemoryBackend()}))
+	})
+	defer cleanupTestServer(t, srv, addr)
+
+	client := createTestClient(t, addr)
+
+	resp, err := client.Put(context.Background(), &pb.PutRequest{Key: "k", Value: "v"})
+	if status.Code(err) != codes.Internal {
+		t.Errorf("Put() expected Internal when the backend fails, got %v", err)
+	}
+	if resp.GetSuccess() {
+		t.Error("Put() reported Success for a failed write")
+	}
+
+	if _, err := client.Delete(context.Background(), &pb.DeleteRequest{Key: "k"}); status.Code(err) != codes.Internal {
+		t.Errorf("Delete() expected Internal when the backend fails, got %v", err)
+	}
+}
+
+func TestStoreError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want codes.Code
+	}{
+		{fmt.Errorf("%w: 9 bytes (max 8)", store.ErrValueTooLarge), codes.InvalidArgument},
+		{store.ErrDefaultNamespace, codes.InvalidArgument},
+		{fmt.Errorf("%w: %q", store.ErrNotFound, "k"), codes.NotFound},
+		{store.ErrRevisionMismatch, codes.FailedPrecondition},
+		{fmt.Errorf("put: %w", store.ErrReadOnly), codes.FailedPrecondition},
+		{store.ErrNotLeader, codes.Unavailable},
+		{fmt.Errorf("%w: %w", store.ErrReplicationFailed, errors.New("timed out enqueuing operation")), codes.Unavailable},
+		{fmt.Errorf("%w: put %q: %w", store.ErrWriteFailed, "k", errors.New("disk full")), codes.Internal},
+		{store.ErrNoLeaderContact, codes.Unavailable},
+		{store.ErrClosed, codes.Unavailable},
+		{store.ErrRaftNotOpen, codes.FailedPrecondition},
+		{fmt.Errorf("%w: %q", store.ErrUnknownServer, "node9"), codes.InvalidArgument},
+		{context.DeadlineExceeded, codes.DeadlineExceeded},
+		{errors.New("disk full"), codes.Internal},
+	}
+
+	for _, tt := range tests {
+		if got := status.Code(storeError(tt.err)); got != tt.want {
+			t.Errorf("storeError(%v) = %v, expected %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestServer_ClusterInfo(t *testing.T) {
+	t.Run("disabled", func(t *testing.T) {
+		srv, _, addr := setupTestServer(t)
+		defer cleanupTestServer(t, srv, addr)
+
+		client := createTestClient(t, addr)
+		if _, err := client.ClusterInfo(context.Background(), &pb.ClusterInfoRequest{}); status.Code(err) != codes.PermissionDenied {
+			t.Errorf("ClusterInfo() expected PermissionDenied, got %v", err)
+		}
+	})
+
+	// habilitada, mas sem raft aberto não há configuração para mostrar
+	t.Run("without raft", func(t *testing.T) {
+		srv, _, addr := setupTestServer(t, func(s *server) { s.allowClusterInfo = true })
+		defer cleanupTestServer(t, srv, addr)
+
+		if _, err := createTestClient(t, addr).ClusterInfo(context.Background(), &pb.ClusterInfoRequest{}); status.Code(err) != codes.FailedPrecondition {
+			t.Errorf("ClusterInfo() without raft expected FailedPrecondition, got %v", err)
+		}
+	})
+}
+
+func TestServer_StepDown(t *testing.T) {
+	t.Run("disabled", func(t *testing.T) {
+		srv, _, addr := setupTestServer(t)
+		defer cleanupTestServer(t, srv, addr)
+
+		if _, err := createTestClient(t, addr).StepDown(context.Background(), &pb.StepDownRequest{}); status.Code(err) != codes.PermissionDenied {
+			t.Errorf("StepDown() expected PermissionDenied, got %v", err)
+		}
+	})
+
+	// habilitada, mas sem raft não há liderança para passar
+	t.Run("without raft", func(t *testing.T) {
+		srv, _, addr := setupTestServer(t, func(s *server) { s.allowStepDown = true })
+		defer cleanupTestServer(t, srv, addr)
+
+		if _, err := createTestClient(t, addr).StepDown(context.Background(), &pb.StepDownRequest{}); status.Code(err) != codes.FailedPrecondition {
+			t.Errorf("StepDown() without raft expected FailedPrecondition, got %v", err)
+		}
+	})
+}
+
+func TestServer_Verify(t *testing.T) {
+	t.Run("disabled", func(t *testing.T) {
+		srv, _, addr := setupTestServer(t)
+		defer cleanupTestServer(t, srv, addr)
+
+		if _, err := createTestClient(t, addr).Verify(context.Background(), &pb.VerifyRequest{}); status.Code(err) != codes.PermissionDenied {
+			t.Errorf("Verify() expected PermissionDenied, got %v", err)
+		}
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		srv, s, addr := setupTestServer(t, func(s *server) { s.allowVerify = true })
+		defer cleanupTestServer(t, srv, addr)
+
+		client := createTestClient(t, addr)
+		ctx := context.Background()
+		if _, err := client.Put(ctx, &pb.PutRequest{Key: "a", Value: "1"}); err != nil {
+			t.Fatalf("Put() failed: %v", err)
+		}
+
+		resp, err := client.Verify(ctx, &pb.VerifyRequest{})
+		if err != nil || len(resp.GetDiscrepancies()) != 0 {
+			t.Fatalf("Verify() = %v, %v, expected no discrepancies", resp, err)
+		}
+
+		// o PutFromDb escreve só em memória
+		s.store.PutFromDb("a", "2")
+		s.store.PutFromDb("b", "3")
+
+		resp, err = client.Verify(ctx, &pb.VerifyRequest{})
+		if err != nil {
+			t.Fatalf("Verify() failed: %v", err)
+		}
+		got := resp.GetDiscrepancies()
+		if len(got) != 2 ||
+			got[0].GetKind() != pb.DiscrepancyKind_DISCREPANCY_VALUE_MISMATCH || got[0].GetKey() != "a" || got[0].GetMemoryValue() != "2" || got[0].GetDbValue() != "1" ||
+			got[1].GetKind() != pb.DiscrepancyKind_DISCREPANCY_MISSING_IN_DB || got[1].GetKey() != "b" || got[1].GetMemoryValue() != "3" {
+			t.Errorf("Verify() returned %v", got)
+		}
+
+		// com repair a partir do bbolt a memória volta a ser igual ao banco
+		resp, err = client.Verify(ctx, &pb.VerifyRequest{Repair: true, Source: pb.RepairSource_REPAIR_SOURCE_DB})
+		if err != nil || len(resp.GetDiscrepancies()) != 2 {
+			t.Fatalf("Verify(repair) = %v, %v, expected the 2 discrepancies", resp, err)
+		}
+		if got := s.store.GetAll(); len(got) != 1 || got["a"] != "1" {
+			t.Errorf("GetAll() after the repair = %v, expected only a=1", got)
+		}
+		if resp, err := client.Verify(ctx, &pb.VerifyRequest{}); err != nil || len(resp.GetDiscrepancies()) != 0 {
+			t.Errorf("Verify() after the repair = %v, %v, expected no discrepancies", resp, err)
+		}
+	})
+}
+
+func TestServer_DBStats(t *testing.T) {
+	srv, _, addr := setupTestServer(t)
+	defer cleanupTestServer(t, srv, addr)
+
+	client := createTestClient(t, addr)
+	// valores pequenos deixam o bucket inline na página do pai, sem leaf pages
+	// próprias; com 100 bytes cada as 25 chaves passam de um quarto de página
+	value := strings.Repeat("v", 100)
+	for i := range 25 {
+		if _, err := client.Put(context.Background(), &pb.PutRequest{Key: fmt.Sprintf("key%d", i), Value: value}); err != nil {
+			t.Fatalf("Put() failed: %v", err)
+		}
+	}
+
+	resp, err := client.DBStats(context.Background(), &pb.DBStatsRequest{})
+	if err != nil {
+		t.Fatalf("DBStats() failed: %v", err)
+	}
+	if resp.GetDbKeys() != 25 || resp.GetMemoryKeys() != 25 {
+		t.Errorf("DBStats() keys = %d in the db, %d in memory, expected 25", resp.GetDbKeys(), resp.GetMemoryKeys())
+	}
+	if resp.GetFileSize() <= 0 || resp.GetPageSize() <= 0 || resp.GetLeafPages() <= 0 {
+		t.Errorf("DBStats() missing bbolt metrics: %v", resp)
+	}
+	if u := resp.GetUtilization(); u <= 0 || u > 1 {
+		t.Errorf("DBStats() utilization = %f, expected (0, 1]", u)
+	}
+}
+
+func TestServer_Compact(t *testing.T) {
+	t.Run("disabled", func(t *testing.T) {
+		srv, _, addr := setupTestServer(t)
+		defer cleanupTestServer(t, srv, addr)
+
+		client := createTestClient(t, addr)
+
+		_, err := client.Compact(context.Background(), &pb.CompactRequest{})
+		if status.Code(err) != codes.PermissionDenied {
+			t.Errorf("Compact() expected PermissionDenied, got %v", err)
+		}
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		dir := t.TempDir()
+		d, err := store.OpenDB(filepath.Join(dir, constants.DBFileName), constants.BucketStore, store.DefaultDBConfig())
+		if err != nil {
+			t.Fatal(err)
+		}
+		backend := store.NewBoltBackend(d)
+		defer func() { backend.DB().Close() }()
+
+		srv, _, addr := setupTestServer(t, func(s *server) {
+			s.store = store.NewKVStore(store.WithBackend(backend))
+			s.allowCompact = true
+		})
+		defer cleanupTestServer(t, srv, addr)
+
+		client := createTestClient(t, addr)
+		ctx := context.Background()
+
+		for i := range 50 {
+			if _, err := client.Put(ctx, &pb.PutRequest{Key: fmt.Sprintf("key%d", i), Value: strings.Repeat("v", 1024)}); err != nil {
+				t.Fatalf("Put() failed: %v", err)
+			}
+		}
+		for i := 1; i < 50; i++ {
+			if _, err := client.Delete(ctx, &pb.DeleteRequest{Key: fmt.Sprintf("key%d", i)}); err != nil {
+				t.Fatalf("Delete() failed: %v", err)
+			}
+		}
+
+		resp, err := client.Compact(ctx, &pb.CompactRequest{})
+		if err != nil {
+			t.Fatalf("Compact() failed: %v", err)
+		}
+		if resp.DbSizeBefore == 0 || resp.DbSizeAfter == 0 || resp.DbSizeAfter > resp.DbSizeBefore {
+			t.Errorf("unexpected db sizes %d -> %d", resp.DbSizeBefore, resp.DbSizeAfter)
+		}
+		if resp.WalSizeAfter >= resp.WalSizeBefore {
+			t.Errorf("expected the WAL to shrink, got %d -> %d", resp.WalSizeBefore, resp.WalSizeAfter)
+		}
+
+		// a chave que sobrou continua lá e a store aceita escritas no banco novo
+		got, err := client.Get(ctx, &pb.GetRequest{Key: "key0"})
+		if err != nil || got.Value != strings.Repeat("v", 1024) {
+			t.Errorf("key0 lost after Compact(): %v", err)
+		}
+		if _, err := client.Put(ctx, &pb.PutRequest{Key: "after", Value: "compact"}); err != nil {
+			t.Fatalf("Put() after Compact() failed: %v", err)
+		}
+		if v, _ := backend.Get([]byte(constants.BucketStore), []byte("after")); string(v) != "compact" {
+			t.Errorf("write after Compact() not persisted, got %q", v)
+		}
+	})
+}
+func TestServer_PutIfAbsent(t *testing.T) {
+	srv, _, addr := setupTestServer(t)
+	defer cleanupTestServer(t, srv, addr)
+
+	client := createTestClient(t, addr)
+	ctx := context.Background()
+
+	resp, err := client.PutIfAbsent(ctx, &pb.PutRequest{Key: "lock", Value: "node1"})
+	if err != nil {
+		t.Fatalf("PutIfAbsent() failed: %v", err)
+	}
+	if !resp.Stored {
+		t.Error("PutIfAbsent() on absent key returned stored=false")
+	}
+
+	resp, err = client.PutIfAbsent(ctx, &pb.PutRequest{Key: "lock", Value: "node2"})
+	if err != nil {
+		t.Fatalf("PutIfAbsent() failed: %v", err)
+	}
+	if resp.Stored {
+		t.Error("PutIfAbsent() on present key returned stored=true")
+	}
+
+	get, err := client.Get(ctx, &pb.GetRequest{Key: "lock"})
+	if err != nil {
+		t.Fatalf("Get() failed: %v", err)
+	}
+	if get.Value != "node1" {
+		t.Errorf("PutIfAbsent() overwrote value. Expected node1, got %s", get.Value)
+	}
+}
+
+func TestServer_MultiGet(t *testing.T) {
+	srv, _, addr := setupTestServer(t)
+	defer cleanupTestServer(t, srv, addr)
+
+	client := createTestClient(t, addr)
+	ctx := context.Background()
+
+	for key, value := range map[string]string{"key1": "value1", "key2": "value2", "empty_value": ""} {
+		if _, err := client.Put(ctx, &pb.PutRequest{Key: key, Value: value}); err != nil {
+			t.Fatalf("Put() failed: %v", err)
+		}
+	}
+
+	resp, err := client.MultiGet(ctx, &pb.MultiGetRequest{
+		Keys: []string{"key2", "missing", "empty_value", "key2", "key1"},
+	})
+	if err != nil {
+		t.Fatalf("MultiGet() failed: %v", err)
+	}
+
+	// Chaves duplicadas aparecem uma única vez, na ordem do request
+	expected := []struct {
+		key   string
+		value string
+		found bool
+	}{
+		{"key2", "value2", true},
+		{"missing", "", false},
+		{"empty_value", "", true},
+		{"key1", "value1", true},
+	}
+
+	if len(resp.Values) != len(expected) {
+		t.Fatalf("MultiGet() returned %d values, expected %d", len(resp.Values), len(expected))
+	}
+
+	for i, want := range expected {
+		got := resp.Values[i]
+		if got.Key != want.key || got.Value != want.value || got.Found != want.found {
+			t.Errorf("MultiGet() value %d = (%s, %q, %v), expected (%s, %q, %v)",
+				i, got.Key, got.Value, got.Found, want.key, want.value, want.found)
+		}
+	}
+}
+
+func TestServer_CanceledContext(t *testing.T) {
+	srv, s, addr := setupTestServer(t)
+	defer cleanupTestServer(t, srv, addr)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// Chama os handlers direto: pela rede o próprio gRPC barraria a chamada
+	if _, err := s.Put(ctx, &pb.PutRequest{Key: "key1", Value: "value1"}); status.Code(err) != codes.Canceled {
+		t.Errorf("Put() expected Canceled, got %v", err)
+	}
+	if _, err := s.Delete(ctx, &pb.DeleteRequest{Key: "key1"}); status.Code(err) != codes.Canceled {
+		t.Errorf("Delete() expected Canceled, got %v", err)
+	}
+	if _, err := s.Get(ctx, &pb.GetRequest{Key: "key1"}); status.Code(err) != codes.Canceled {
+		t.Errorf("Get() expected Canceled, got %v", err)
+	}
+
+	if _, ok := s.store.Lookup("key1"); ok {
+		t.Errorf("Put() with canceled context stored the key")
+	}
+
+	deadline, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+
+	if _, err := s.Put(deadline, &pb.PutRequest{Key: "key1", Value: "value1"}); status.Code(err) != codes.DeadlineExceeded {
+		t.Errorf("Put() expected DeadlineExceeded, got %v", err)
+	}
+}
+
+func TestNewLogger(t *testing.T) {
+	tests := []struct {
+		level     string
+		wantDebug bool
+		wantInfo  bool
+		wantError bool
+	}{
+		{"debug", true, true, true},
+		{"info", false, true, true},
+		{"warn", false, false, true},
+		{"ERROR", false, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.level, func(t *testing.T) {
+			var buf strings.Builder
+			logger, err := newLogger(&buf, tt.level)
+			if err != nil {
+				t.Fatalf("newLogger() failed: %v", err)
+			}
+
+			logger.Debug("debug message")
+			logger.Info("info message")
+			logger.Error("error message")
+
+			out := buf.String()
+			if got := strings.Contains(out, "debug message"); got != tt.wantDebug {
+				t.Errorf("debug logged = %v, expected %v", got, tt.wantDebug)
+			}
+			if got := strings.Contains(out, "info message"); got != tt.wantInfo {
+				t.Errorf("info logged = %v, expected %v", got, tt.wantInfo)
+			}
+			if got := strings.Contains(out, "error message"); got != tt.wantError {
+				t.Errorf("error logged = %v, expected %v", got, tt.wantError)
+			}
+		})
+	}
+
+	if _, err := newLogger(io.Discard, "verbose"); err == nil {
+		t.Error("newLogger() should reject unknown level")
+	}
+}
+
+func TestServer_PutIfVersion(t *testing.T) {
+	srv, _, addr := setupTestServer(t)
+	defer cleanupTestServer(t, srv, addr)
+
+	client := createTestClient(t, addr)
+	ctx := context.Background()
+
+	if _, err := client.Put(ctx, &pb.PutRequest{Key: "key1", Value: "value1"}); err != nil {
+		t.Fatalf("Put() failed: %v", err)
+	}
+
+	get, err := client.Get(ctx, &pb.GetRequest{Key: "key1"})
+	if err != nil {
+		t.Fatalf("Get() failed: %v", err)
+	}
+	if get.Revision == 0 {
+		t.Fatal("Get() returned revision 0 for existing key")
+	}
+
+	resp, err := client.PutIfVersion(ctx, &pb.PutIfVersionRequest{Key: "key1", Value: "value2", ExpectedRevision: get.Revision})
+	if err != nil {
+		t.Fatalf("PutIfVersion() failed: %v", err)
+	}
+	if resp.Revision <= get.Revision {
+		t.Errorf("PutIfVersion() returned revision %d, expected > %d", resp.Revision, get.Revision)
+	}
+
+	// A revisão lida antes do update agora está desatualizada
+	_, err = client.PutIfVersion(ctx, &pb.PutIfVersionRequest{Key: "key1", Value: "value3", ExpectedRevision: get.Revision})
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("PutIfVersion() with stale revision expected FailedPrecondition, got %v", err)
+	}
+
+	get, err = client.Get(ctx, &pb.GetRequest{Key: "key1"})
+	if err != nil {
+		t.Fatalf("Get() failed: %v", err)
+	}
+	if get.Value != "value2" || get.Revision != resp.Revision {
+		t.Errorf("Get() = (%s, %d), expected (value2, %d)", get.Value, get.Revision, resp.Revision)
+	}
+}
+
+func TestServer_Txn(t *testing.T) {
+	srv, _, addr := setupTestServer(t)
+	defer cleanupTestServer(t, srv, addr)
+
+	client := createTestClient(t, addr)
+	ctx := context.Background()
+
+	if _, err := client.Put(ctx, &pb.PutRequest{Key: "stock", Value: "1"}); err != nil {
+		t.Fatalf("Put() failed: %v", err)
+	}
+
+	req := &pb.TxnRequest{
+		Compares: []*pb.Compare{{Key: "stock", Target: pb.CompareTarget_COMPARE_VALUE, Value: "1"}},
+		ThenOps: []*pb.TxnOp{
+			{Type: pb.TxnOpType_TXN_PUT, Key: "stock", Value: "0"},
+			{Type: pb.TxnOpType_TXN_PUT, Key: "order:1", Value: "reserved"},
+		},
+		ElseOps: []*pb.TxnOp{{Type: pb.TxnOpType_TXN_PUT, Key: "order:1", Value: "rejected"}},
+	}
+
+	// A primeira reserva passa, a segunda cai no else
+	for _, expected := range []struct {
+		succeeded bool
+		order     string
+	}{{true, "reserved"}, {false, "rejected"}} {
+		resp, err := client.Txn(ctx, req)
+		if err != nil {
+			t.Fatalf("Txn() failed: %v", err)
+		}
+		if resp.Succeeded != expected.succeeded {
+			t.Errorf("Txn() succeeded = %v, expected %v", resp.Succeeded, expected.succeeded)
+		}
+
+		get, err := client.Get(ctx, &pb.GetRequest{Key: "order:1"})
+		if err != nil {
+			t.Fatalf("Get() failed: %v", err)
+		}
+		if get.Value != expected.order {
+			t.Errorf("order:1 = %q, expected %q", get.Value, expected.order)
+		}
+	}
+
+	// Operação inválida vira InvalidArgument
+	_, err := client.Txn(ctx, &pb.TxnRequest{ThenOps: []*pb.TxnOp{{Type: pb.TxnOpType_TXN_PUT, Key: strings.Repeat("k", store.DefaultMaxKeySize+1)}}})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Txn() with an invalid key returned %v, expected InvalidArgument", err)
+	}
+}
+
+func TestShutdown(t *testing.T) {
+	goroutinesBefore := runtime.NumGoroutine()
+
+	db := InitDb("test_shutdown.db", constants.BucketStore, store.DefaultDBConfig())
+	defer os.Remove("test_shutdown.db")
+	defer store.OpenWAL()
+
+	store.Init(db)
+
+	// A janela longa deixa a escrita pendente até o Shutdown
+	kv := store.NewKVStore(store.WithBackend(store.NewBatchBackend(store.NewBoltBackend(db), time.Hour, 100)))
+
+	srv := grpc.NewServer()
+	pb.RegisterKvStoreServer(srv, &server{store: kv})
+
+	listener, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+
+	served := make(chan error, 1)
+	go func() {
+		served <- srv.Serve(listener)
+	}()
+
+	conn, err := grpc.NewClient(listener.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+
+	client := pb.NewKvStoreClient(conn)
+	if _, err := client.Put(context.Background(), &pb.PutRequest{Key: "key1", Value: "value1"}); err != nil {
+		t.Fatalf("Put() failed: %v", err)
+	}
+	conn.Close()
+
+	if err := Shutdown(srv, kv, db, time.Second); err != nil {
+		t.Fatalf("Shutdown() failed: %v", err)
+	}
+
+	if err := <-served; err != nil {
+		t.Errorf("Serve() returned error after shutdown: %v", err)
+	}
+
+	// O banco deve estar fechado
+	if err := db.View(func(tx *bolt.Tx) error { return nil }); !errors.Is(err, bolt.ErrDatabaseNotOpen) {
+		t.Errorf("expected db to be closed, got %v", err)
+	}
+
+	// A escrita agrupada chegou ao disco antes do banco fechar
+	reopened, err := bolt.Open("test_shutdown.db", constants.DBFilePermission, nil)
+	if err != nil {
+		t.Fatalf("failed to reopen db: %v", err)
+	}
+	reopened.View(func(tx *bolt.Tx) error {
+		if v := tx.Bucket([]byte(constants.BucketStore)).Get([]byte("key1")); string(v) != "value1" {
+			t.Errorf("Expected key1 to be flushed on shutdown, got %q", v)
+		}
+		return nil
+	})
+	reopened.Close()
+
+	// O WAL não deve receber novas escritas depois do shutdown
+	walBefore, _ := os.ReadFile("walog.ndjson")
+	store.LogWrite("after_shutdown", "value")
+	walAfter, _ := os.ReadFile("walog.ndjson")
+	if len(walAfter) != len(walBefore) {
+		t.Error("WAL accepted a write after shutdown")
+	}
+
+	// Aguarda as goroutines do gRPC terminarem
+	deadline := time.Now().Add(2 * time.Second)
+	for runtime.NumGoroutine() > goroutinesBefore && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if n := runtime.NumGoroutine(); n > goroutinesBefore {
+		t.Errorf("goroutines leaked after shutdown: before=%d, after=%d", goroutinesBefore, n)
+	}
+}
+
+func TestShutdown_InFlightRequests(t *testing.T) {
+	db := InitDb("test_shutdown_inflight.db", constants.BucketStore, store.DefaultDBConfig())
+	defer os.Remove("test_shutdown_inflight.db")
+	defer store.OpenWAL()
+
+	kv := store.NewKVStore(store.WithBackend(store.NewBoltBackend(db)))
+
+	// O interceptor segura o Put até o teste liberar
+	entered, release := make(chan struct{}), make(chan struct{})
+	hold := func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if info.FullMethod == pb.KvStore_Put_FullMethodName {
+			close(entered)
+			<-release
+		}
+		return handler(ctx, req)
+	}
+
+	srv := grpc.NewServer(grpc.UnaryInterceptor(hold))
+	pb.RegisterKvStoreServer(srv, &server{store: kv})
+
+	listener, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	go srv.Serve(listener)
+
+	conn, err := grpc.NewClient(listener.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer conn.Close()
+	client := pb.NewKvStoreClient(conn)
+
+	watch, err := client.Watch(context.Background(), &pb.WatchRequest{Key: "other"})
+	if err != nil {
+		t.Fatalf("Watch() failed: %v", err)
+	}
+	for kv.WatcherCount() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	put := make(chan error, 1)
+	go func() {
+		_, err := client.Put(context.Background(), &pb.PutRequest{Key: "key1", Value: "value1"})
+		put <- err
+	}()
+	<-entered
+
+	shutdown := make(chan error, 1)
+	go func() { shutdown <- Shutdown(srv, kv, db, 5*time.Second) }()
+
+	// O Watch termina logo, sem esperar o Put
+	if _, err := watch.Recv(); err != io.EOF {
+		t.Fatalf("Recv() after Shutdown = %v, expected io.EOF", err)
+	}
+
+	// e o Put em andamento ainda encontra a store aberta
+	close(release)
+	if err := <-put; err != nil {
+		t.Errorf("in-flight Put() during Shutdown failed: %v", err)
+	}
+	if err := <-shutdown; err != nil {
+		t.Fatalf("Shutdown() failed: %v", err)
+	}
+
+	reopened, err := bolt.Open("test_shutdown_inflight.db", constants.DBFilePermission, nil)
+	if err != nil {
+		t.Fatalf("failed to reopen db: %v", err)
+	}
+	defer reopened.Close()
+	reopened.View(func(tx *bolt.Tx) error {
+		if v := tx.Bucket([]byte(constants.BucketStore)).Get([]byte("key1")); string(v) != "value1" {
+			t.Errorf("in-flight write = %q, expected value1 on disk", v)
+		}
+		return nil
+	})
+}
+
+func TestInitDb(t *testing.T) {
+	dbPath := "test_init.db"
+	os.Remove(dbPath) // Remove se existir
+
+	// Testa criação do banco
+	db := InitDb(dbPath, constants.BucketStore, store.DefaultDBConfig())
+	if db == nil {
+		t.Fatal("InitDb() returned nil")
+	}
+
+	// Verifica se o bucket foi criado
+	err := db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(constants.BucketStore))
+		if b == nil {
+			return fmt.Errorf("bucket not found")
+		}
+		return nil
+	})
+
+	if err != nil {
+		t.Fatalf("Bucket not created: %v", err)
+	}
+
+	// Limpa
+	db.Close()
+	os.Remove(dbPath)
+}
+
+func TestMain(m *testing.M) {
+	// Configura flags para testes
+	flag.Set("port", "0") // Usa porta aleatória
+
+	// Executa os testes
+	code := m.Run()
+
+	// Limpa arquivos de teste que possam ter sido criados
+	os.Remove("test_server.db")
+	os.Remove("test_init.db")
+	os.Remove("walog.ndjson")
+
+	os.Exit(code)
+}
+
+func TestServer_BulkPut(t *testing.T) {
+	srv, s, addr := setupTestServer(t)
+	defer cleanupTestServer(t, srv, addr)
+
+	client := createTestClient(t, addr)
+
+	stream, err := client.BulkPut(context.Background())
+	if err != nil {
+		t.Fatalf("BulkPut() failed: %v", err)
+	}
+
+	// 1000 pares passam por dois lotes cheios e um parcial
+	const n = 1000
+	for i := range n {
+		if err := stream.Send(&pb.PutRequest{Key: fmt.Sprintf("key%04d", i), Value: fmt.Sprintf("value%d", i)}); err != nil {
+			t.Fatalf("Send() failed: %v", err)
+		}
+	}
+
+	resp, err := stream.CloseAndRecv()
+	if err != nil {
+		t.Fatalf("CloseAndRecv() failed: %v", err)
+	}
+	if resp.GetCount() != n {
+		t.Errorf("BulkPut() count = %d, expected %d", resp.GetCount(), n)
+	}
+	if got := s.store.Count(); got != n {
+		t.Errorf("Store has %d keys, expected %d", got, n)
+	}
+	for _, i := range []int{0, 499, 500, 999} {
+		key := fmt.Sprintf("key%04d", i)
+		if got, want := s.store.Get(key), fmt.Sprintf("value%d", i); got != want {
+			t.Errorf("Get(%s) = %q, expected %q", key, got, want)
+		}
+	}
+}
+
+func TestServer_BulkPut_InvalidKey(t *testing.T) {
+	srv, s, addr := setupTestServer(t, func(s *server) {
+		s.store = store.NewKVStore(store.WithLimits(store.Limits{MaxKeySize: 4}))
+	})
+	defer cleanupTestServer(t, srv, addr)
+
+	client := createTestClient(t, addr)
+
+	// Um par inválido faz o lote inteiro falhar com InvalidArgument
+	stream, err := client.BulkPut(context.Background())
+	if err != nil {
+		t.Fatalf("BulkPut() failed: %v", err)
+	}
+	stream.Send(&pb.PutRequest{Key: "ok", Value: "v"})
+	stream.Send(&pb.PutRequest{Key: "too_long", Value: "v"})
+	if _, err := stream.CloseAndRecv(); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("BulkPut() with invalid key returned %v, expected InvalidArgument", err)
+	}
+	if _, ok := s.store.Lookup("ok"); ok {
+		t.Error("BulkPut() stored part of a failed batch")
+	}
+}
+
+func TestServer_Version(t *testing.T) {
+	// simula o -ldflags do build
+	oldVersion, oldCommit := version.Version, version.Commit
+	version.Version, version.Commit = "v1.2.3-test", "abc1234"
+	defer func() { version.Version, version.Commit = oldVersion, oldCommit }()
+
+	srv, _, addr := setupTestServer(t)
+	defer cleanupTestServer(t, srv, addr)
+
+	client := createTestClient(t, addr)
+	resp, err := client.Version(context.Background(), &pb.VersionRequest{})
+	if err != nil {
+		t.Fatalf("Version() failed: %v", err)
+	}
+	if resp.GetVersion() != "v1.2.3-test" || resp.GetCommit() != "abc1234" {
+		t.Errorf("Version() = %v, expected the injected version and commit", resp)
+	}
+	if resp.GetGoVersion() != runtime.Version() {
+		t.Errorf("Version() go_version = %q, expected %q", resp.GetGoVersion(), runtime.Version())
+	}
+}
+
+func TestServer_GetAll_Sorted(t *testing.T) {
+	srv, _, addr := setupTestServer(t)
+	defer cleanupTestServer(t, srv, addr)
+
+	client := createTestClient(t, addr)
+	ctx := context.Background()
+
+	for _, key := range []string{"c", "a", "b:2", "b:1"} {
+		if _, err := client.Put(ctx, &pb.PutRequest{Key: key, Value: "v-" + key}); err != nil {
+			t.Fatalf("Put() failed: %v", err)
+		}
+	}
+
+	expected := []string{"a", "b:1", "b:2", "c"}
+	for range 5 {
+		resp, err := client.GetAll(ctx, &pb.GetAllRequest{Sorted: true})
+		if err != nil {
+			t.Fatalf("GetAll() failed: %v", err)
+		}
+		if len(resp.GetValues()) != 0 {
+			t.Errorf("GetAll(sorted) also filled values: %v", resp.GetValues())
+		}
+
+		var keys []string
+		for _, kv := range resp.GetSortedValues() {
+			keys = append(keys, kv.GetKey())
+			if kv.GetValue() != "v-"+kv.GetKey() {
+				t.Errorf("GetAll(sorted) %s = %q", kv.GetKey(), kv.GetValue())
+			}
+		}
+		if !slices.Equal(keys, expected) {
+			t.Fatalf("GetAll(sorted) returned keys %v, expected %v", keys, expected)
+		}
+	}
+}
+
+func TestServer_GetAllStream(t *testing.T) {
+	srv, s, addr := setupTestServer(t, func(s *server) {
+		s.store = store.NewKVStore(store.WithMaxEntries(5, store.EvictMemory))
+	})
+	defer cleanupTestServer(t, srv, addr)
+
+	const n = 50
+	for i := range n {
+		s.store.Put(fmt.Sprintf("key%02d", i), fmt.Sprintf("value%d", i))
+	}
+
+	client := createTestClient(t, addr)
+
+	all, err := client.GetAll(context.Background(), &pb.GetAllRequest{})
+	if err != nil {
+		t.Fatalf("GetAll() failed: %v", err)
+	}
+	if len(all.Values) != 5 {
+		t.Fatalf("expected GetAll() to return only the 5 keys in memory, got %d", len(all.Values))
+	}
+
+	stream, err := client.GetAllStream(context.Background(), &pb.GetAllRequest{})
+	if err != nil {
+		t.Fatalf("GetAllStream() failed: %v", err)
+	}
+
+	i := 0
+	for {
+		kv, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("GetAllStream() stream failed: %v", err)
+		}
+		if kv.Key != fmt.Sprintf("key%02d", i) || kv.Value != fmt.Sprintf("value%d", i) || !kv.Found {
+			t.Errorf("item %d: unexpected %v", i, kv)
+		}
+		i++
+	}
+	if i != n {
+		t.Errorf("GetAllStream() returned %d keys, expected %d", i, n)
+	}
 }
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/carvalhodanielg/kvstore/internal/metrics"
+	"github.com/carvalhodanielg/kvstore/store"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// newMetrics cria as métricas de RPC e registra os gauges lidos da store
+func newMetrics(kv *store.KVStore) *metrics.ServerMetrics {
+	m := metrics.NewServerMetrics()
+
+	m.Registry.MustRegister(
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{Name: "kvstore_keys", Help: "Number of keys in the store."}, func() float64 {
+			return float64(kv.Count())
+		}),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{Name: "kvstore_wal_size_bytes", Help: "Size of the write-ahead log in bytes."}, func() float64 {
+			return float64(store.WALSize())
+		}),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{Name: "kvstore_watchers", Help: "Number of active watchers."}, func() float64 {
+			return float64(kv.WatcherCount())
+		}),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{Name: "kvstore_watch_queue_depth", Help: "Events buffered in watcher channels waiting to be read."}, func() float64 {
+			return float64(kv.WatchQueueDepth())
+		}),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{Name: "kvstore_watch_events_dropped_total", Help: "Watch events dropped because a watcher buffer was full."}, func() float64 {
+			return float64(kv.DroppedEvents())
+		}),
+	)
+
+	return m
+}
+
+// serveMetrics expõe /metrics em uma porta HTTP separada da porta gRPC
+func serveMetrics(port int, m *metrics.ServerMetrics) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", m.Handler())
+
+	srv := &http.Server{Addr: fmt.Sprintf(":%d", port), Handler: mux}
+
+	go func() {
+		slog.Info("metrics listening", "address", srv.Addr)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("metrics server failed", "error", err)
+		}
+	}()
+
+	return srv
+}
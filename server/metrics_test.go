@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/carvalhodanielg/kvstore/internal/constants"
+	pb "github.com/carvalhodanielg/kvstore/pb/proto"
+	"github.com/carvalhodanielg/kvstore/store"
+	"google.golang.org/grpc"
+)
+
+func TestServer_Metrics(t *testing.T) {
+	db := InitDb("test_metrics.db", constants.BucketStore, store.DefaultDBConfig())
+	defer os.Remove("test_metrics.db")
+	defer db.Close()
+
+	store.Init(db)
+
+	s := &server{store: store.NewKVStore()}
+	m := newMetrics(s.store)
+
+	srv := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(m.UnaryServerInterceptor()),
+		grpc.ChainStreamInterceptor(m.StreamServerInterceptor()),
+	)
+	pb.RegisterKvStoreServer(srv, s)
+
+	listener, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	go srv.Serve(listener)
+	defer cleanupTestServer(t, srv, listener.Addr().String())
+
+	client := createTestClient(t, listener.Addr().String())
+	ctx := context.Background()
+
+	for _, key := range []string{"key1", "key2"} {
+		if _, err := client.Put(ctx, &pb.PutRequest{Key: key, Value: "value"}); err != nil {
+			t.Fatalf("Put() failed: %v", err)
+		}
+	}
+	if _, err := client.Get(ctx, &pb.GetRequest{Key: "key1"}); err != nil {
+		t.Fatalf("Get() failed: %v", err)
+	}
+	if _, err := client.Delete(ctx, &pb.DeleteRequest{Key: "key2"}); err != nil {
+		t.Fatalf("Delete() failed: %v", err)
+	}
+
+	metricsSrv := httptest.NewServer(m.Handler())
+	defer metricsSrv.Close()
+
+	resp, err := http.Get(metricsSrv.URL)
+	if err != nil {
+		t.Fatalf("failed to scrape metrics: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("failed to read metrics: %v", err)
+	}
+
+	expected := []string{
+		`kvstore_requests_total{method="Put"} 2`,
+		`kvstore_requests_total{method="Get"} 1`,
+		`kvstore_requests_total{method="Delete"} 1`,
+		`kvstore_request_duration_seconds_count{method="Put"} 2`,
+		`kvstore_keys 1`,
+		`kvstore_watchers 0`,
+	}
+
+	for _, line := range expected {
+		if !strings.Contains(string(body), line) {
+			t.Errorf("metrics output missing %q", line)
+		}
+	}
+
+	// Nenhuma RPC falhou
+	if strings.Contains(string(body), "kvstore_request_errors_total{") {
+		t.Errorf("expected no errors, got:\n%s", body)
+	}
+}
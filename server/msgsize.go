@@ -0,0 +1,26 @@
+package main
+
+import (
+	"math"
+
+	"google.golang.org/grpc"
+)
+
+const (
+	// defaultMaxRecvMsgSize é o limite padrão do gRPC para mensagens recebidas.
+	// Um Put maior que isso falha com ResourceExhausted antes de chegar à store.
+	defaultMaxRecvMsgSize = 4 << 20
+	// defaultMaxSendMsgSize é o limite padrão do gRPC para mensagens enviadas
+	defaultMaxSendMsgSize = math.MaxInt32
+)
+
+// messageSizeOptions limita o tamanho das mensagens recebidas e enviadas pelo
+// servidor. Aumentar os limites deixa passar valores e GetAll maiores, mas
+// cada mensagem fica inteira em memória dos dois lados; para stores grandes
+// prefira o GetAllStream e o Backup, que mandam uma chave por mensagem.
+func messageSizeOptions(recv, send int) []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.MaxRecvMsgSize(recv),
+		grpc.MaxSendMsgSize(send),
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	pb "github.com/carvalhodanielg/kvstore/pb/proto"
+	"github.com/carvalhodanielg/kvstore/store"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+func TestServer_MaxMessageSize(t *testing.T) {
+	store.SetWALPath(filepath.Join(t.TempDir(), store.WALFileName))
+	defer store.SetWALPath(store.WALFileName)
+
+	const limit = 8 << 20
+	value := strings.Repeat("v", defaultMaxRecvMsgSize+1024)
+
+	start := func(recv int) string {
+		lis, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("failed to listen: %v", err)
+		}
+		kv := store.NewKVStore(store.WithBackend(store.NewMemoryBackend()), store.WithLimits(store.Limits{MaxValueSize: limit}))
+		srv := grpc.NewServer(messageSizeOptions(recv, defaultMaxSendMsgSize)...)
+		pb.RegisterKvStoreServer(srv, &server{store: kv})
+		go srv.Serve(lis)
+		t.Cleanup(srv.Stop)
+		return lis.Addr().String()
+	}
+	dial := func(addr string, opts ...grpc.CallOption) pb.KvStoreClient {
+		conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()), grpc.WithDefaultCallOptions(opts...))
+		if err != nil {
+			t.Fatalf("failed to connect: %v", err)
+		}
+		t.Cleanup(func() { conn.Close() })
+		return pb.NewKvStoreClient(conn)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// com o limite padrão o valor nem chega à store
+	client := dial(start(defaultMaxRecvMsgSize))
+	if _, err := client.Put(ctx, &pb.PutRequest{Key: "big", Value: value}); status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("Put() with the default limit returned %v, expected ResourceExhausted", err)
+	}
+
+	// aumentando o do servidor e o do cliente o valor vai e volta inteiro
+	addr := start(limit)
+	client = dial(addr, grpc.MaxCallRecvMsgSize(limit))
+	if _, err := client.Put(ctx, &pb.PutRequest{Key: "big", Value: value}); err != nil {
+		t.Fatalf("Put() with a raised limit failed: %v", err)
+	}
+	res, err := client.Get(ctx, &pb.GetRequest{Key: "big"})
+	if err != nil {
+		t.Fatalf("Get() with a raised limit failed: %v", err)
+	}
+	if res.GetValue() != value {
+		t.Errorf("Get() returned %d bytes, expected %d", len(res.GetValue()), len(value))
+	}
+
+	// um cliente com o limite padrão não recebe a resposta
+	if _, err := dial(addr).Get(ctx, &pb.GetRequest{Key: "big"}); status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("Get() with the default client limit returned %v, expected ResourceExhausted", err)
+	}
+}
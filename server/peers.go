@@ -0,0 +1,156 @@
+package main
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// PeerStatus é a visão de um peer a partir dos heartbeats enviados a ele
+type PeerStatus struct {
+	Address   string
+	Alive     bool
+	LastSeen  time.Time // zero se o peer nunca respondeu
+	LastError string
+}
+
+// PeerTracker guarda quando cada peer respondeu um heartbeat pela última vez.
+// Um peer está vivo se o último heartbeat deu certo e foi há no máximo timeout;
+// um heartbeat com erro ou a falta de resposta dentro do timeout o marca como down.
+//
+// Ele também mantém uma conexão gRPC por peer, aberta no primeiro uso e
+// reaproveitada entre as rodadas de heartbeat em vez de uma conexão nova a
+// cada tick.
+type PeerTracker struct {
+	mu      sync.Mutex
+	timeout time.Duration
+	peers   map[string]*PeerStatus
+	conns   map[string]*grpc.ClientConn
+
+	// now permite controlar o relógio nos testes
+	now func() time.Time
+	// dial abre a conexão com um peer; os testes trocam para contar as conexões
+	dial func(addr string) (*grpc.ClientConn, error)
+}
+
+// NewPeerTracker começa com todos os peers down até o primeiro heartbeat
+func NewPeerTracker(addrs []string, timeout time.Duration) *PeerTracker {
+	t := &PeerTracker{
+		timeout: timeout,
+		peers:   make(map[string]*PeerStatus, len(addrs)),
+		conns:   make(map[string]*grpc.ClientConn, len(addrs)),
+		now:     time.Now,
+		dial:    dialPeer,
+	}
+	for _, addr := range addrs {
+		t.peers[addr] = &PeerStatus{Address: addr}
+	}
+	return t
+}
+
+// Addresses devolve os endereços acompanhados, em ordem
+func (t *PeerTracker) Addresses() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	addrs := make([]string, 0, len(t.peers))
+	for addr := range t.peers {
+		addrs = append(addrs, addr)
+	}
+	sort.Strings(addrs)
+	return addrs
+}
+
+// RecordSuccess registra que o peer respondeu o heartbeat agora
+func (t *PeerTracker) RecordSuccess(addr string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if p, ok := t.peers[addr]; ok {
+		p.Alive = true
+		p.LastSeen = t.now()
+		p.LastError = ""
+	}
+}
+
+// RecordFailure marca o peer como down com o erro do heartbeat
+func (t *PeerTracker) RecordFailure(addr string, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if p, ok := t.peers[addr]; ok {
+		p.Alive = false
+		p.LastError = err.Error()
+	}
+}
+
+// Peers devolve uma cópia do estado de cada peer, em ordem de endereço.
+// Peers sem resposta há mais de timeout aparecem como down.
+func (t *PeerTracker) Peers() []PeerStatus {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	peers := make([]PeerStatus, 0, len(t.peers))
+	for _, p := range t.peers {
+		status := *p
+		if status.Alive && now.Sub(status.LastSeen) > t.timeout {
+			status.Alive = false
+		}
+		peers = append(peers, status)
+	}
+
+	sort.Slice(peers, func(i, j int) bool {
+		return peers[i].Address < peers[j].Address
+	})
+	return peers
+}
+
+func dialPeer(addr string) (*grpc.ClientConn, error) {
+	return grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+}
+
+// Conn devolve a conexão com o peer, abrindo uma se ainda não houver. O
+// grpc.NewClient não conecta na hora, então abrir com o lock é barato.
+func (t *PeerTracker) Conn(addr string) (*grpc.ClientConn, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if conn, ok := t.conns[addr]; ok {
+		return conn, nil
+	}
+	conn, err := t.dial(addr)
+	if err != nil {
+		return nil, err
+	}
+	t.conns[addr] = conn
+	return conn, nil
+}
+
+// DropConn fecha conn depois de uma falha, para a próxima chamada ao peer
+// abrir uma conexão nova. Se outra goroutine já trocou a conexão do peer, a
+// nova é mantida.
+func (t *PeerTracker) DropConn(addr string, conn *grpc.ClientConn) {
+	t.mu.Lock()
+	if t.conns[addr] == conn {
+		delete(t.conns, addr)
+	}
+	t.mu.Unlock()
+
+	conn.Close()
+}
+
+// Close fecha as conexões com todos os peers
+func (t *PeerTracker) Close() {
+	t.mu.Lock()
+	conns := t.conns
+	t.conns = make(map[string]*grpc.ClientConn)
+	t.mu.Unlock()
+
+	for _, conn := range conns {
+		conn.Close()
+	}
+}
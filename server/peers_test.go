@@ -0,0 +1,275 @@
+package main
+
+import (
+	"context"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	pb "github.com/carvalhodanielg/kvstore/pb/proto"
+	"github.com/carvalhodanielg/kvstore/store"
+	"google.golang.org/grpc"
+)
+
+// startPeer sobe um nó que só responde heartbeats
+func startPeer(t *testing.T) (*grpc.Server, string) {
+	return startPeerWith(t, &server{})
+}
+
+func startPeerWith(t *testing.T, impl pb.NodeCommunicationServer) (*grpc.Server, string) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+
+	srv := grpc.NewServer()
+	pb.RegisterNodeCommunicationServer(srv, impl)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	return srv, lis.Addr().String()
+}
+
+// heartbeatServer é um servidor sem gRPC que só envia heartbeats para addrs
+func heartbeatServer(addrs ...string) *server {
+	return &server{
+		store: store.NewKVStore(store.WithBackend(store.NewMemoryBackend())),
+		peers: NewPeerTracker(addrs, time.Minute),
+	}
+}
+
+// countingPeer registra os heartbeats recebidos, demorando delay para
+// responder cada um, e quantos ficaram em andamento ao mesmo tempo
+type countingPeer struct {
+	pb.UnimplementedNodeCommunicationServer
+	delay time.Duration
+
+	mu          sync.Mutex
+	received    []time.Time
+	inFlight    int
+	maxInFlight int
+}
+
+func (p *countingPeer) Heartbeat(ctx context.Context, _ *pb.HeartbeatRequest) (*pb.HeartbeatResponse, error) {
+	p.mu.Lock()
+	p.received = append(p.received, time.Now())
+	p.inFlight++
+	p.maxInFlight = max(p.maxInFlight, p.inFlight)
+	p.mu.Unlock()
+
+	defer func() {
+		p.mu.Lock()
+		p.inFlight--
+		p.mu.Unlock()
+	}()
+
+	select {
+	case <-time.After(p.delay):
+		return &pb.HeartbeatResponse{Alive: true}, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (p *countingPeer) stats() ([]time.Time, int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]time.Time(nil), p.received...), p.maxInFlight
+}
+
+// runLoop roda o heartbeatLoop por d e espera ele terminar
+func runLoop(s *server, interval, d time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), d)
+	defer cancel()
+	s.heartbeatLoop(ctx, interval)
+}
+
+func TestPeerTracker_Timeout(t *testing.T) {
+	now := time.Unix(1000, 0)
+	tracker := NewPeerTracker([]string{"b:1", "a:1"}, 30*time.Second)
+	tracker.now = func() time.Time { return now }
+
+	// Antes do primeiro heartbeat todos estão down
+	for _, p := range tracker.Peers() {
+		if p.Alive || !p.LastSeen.IsZero() {
+			t.Errorf("peer %s should start down and never seen, got %+v", p.Address, p)
+		}
+	}
+
+	tracker.RecordSuccess("a:1")
+	tracker.RecordSuccess("b:1")
+
+	now = now.Add(20 * time.Second)
+	tracker.RecordSuccess("b:1")
+
+	// a:1 para de responder: passa o timeout sem novo heartbeat
+	now = now.Add(15 * time.Second)
+
+	peers := tracker.Peers()
+	if len(peers) != 2 || peers[0].Address != "a:1" || peers[1].Address != "b:1" {
+		t.Fatalf("Expected peers sorted by address, got %+v", peers)
+	}
+	if peers[0].Alive {
+		t.Errorf("a:1 should be down after the timeout, got %+v", peers[0])
+	}
+	if !peers[1].Alive {
+		t.Errorf("b:1 should still be alive, got %+v", peers[1])
+	}
+	if !peers[0].LastSeen.Equal(time.Unix(1000, 0)) {
+		t.Errorf("a:1 LastSeen = %v, expected the last successful heartbeat", peers[0].LastSeen)
+	}
+}
+
+func TestServer_Heartbeat_PeerLiveness(t *testing.T) {
+	peer, addr := startPeer(t)
+
+	s := heartbeatServer(addr)
+
+	s.sendHeartbeatToPeers()
+	if p := s.peers.Peers()[0]; !p.Alive || p.LastError != "" {
+		t.Fatalf("Expected peer alive after a successful heartbeat, got %+v", p)
+	}
+
+	// O peer cai: o próximo heartbeat falha e o marca como down na hora
+	peer.Stop()
+	s.sendHeartbeatToPeers()
+
+	p := s.peers.Peers()[0]
+	if p.Alive {
+		t.Errorf("Expected peer down after a failed heartbeat, got %+v", p)
+	}
+	if p.LastError == "" {
+		t.Error("Expected the heartbeat error to be recorded")
+	}
+	if p.LastSeen.IsZero() {
+		t.Error("LastSeen should keep the last successful heartbeat")
+	}
+}
+
+func TestServer_Heartbeat_ReusesConn(t *testing.T) {
+	_, addr := startPeer(t)
+	s := heartbeatServer(addr)
+	defer s.peers.Close()
+
+	var dials atomic.Int32
+	s.peers.dial = func(addr string) (*grpc.ClientConn, error) {
+		dials.Add(1)
+		return dialPeer(addr)
+	}
+
+	// Várias rodadas usam a mesma conexão
+	var first *grpc.ClientConn
+	for i := range 3 {
+		s.sendHeartbeatToPeers()
+		if p := s.peers.Peers()[0]; !p.Alive {
+			t.Fatalf("heartbeat %d failed: %+v", i, p)
+		}
+		conn, _ := s.peers.Conn(addr)
+		if first == nil {
+			first = conn
+		} else if conn != first {
+			t.Errorf("heartbeat %d used a new connection", i)
+		}
+	}
+	if n := dials.Load(); n != 1 {
+		t.Errorf("dialed %d times in 3 heartbeats, expected 1", n)
+	}
+
+	// Uma falha descarta a conexão e a rodada seguinte abre outra
+	first.Close()
+	s.sendHeartbeatToPeers()
+	if p := s.peers.Peers()[0]; p.Alive {
+		t.Errorf("expected the heartbeat over a closed connection to fail, got %+v", p)
+	}
+
+	s.sendHeartbeatToPeers()
+	if p := s.peers.Peers()[0]; !p.Alive {
+		t.Errorf("expected the heartbeat to succeed after reconnecting, got %+v", p)
+	}
+	if conn, _ := s.peers.Conn(addr); conn == first {
+		t.Error("the failed connection was not replaced")
+	}
+	if n := dials.Load(); n != 2 {
+		t.Errorf("dialed %d times, expected 2 after the failure", n)
+	}
+}
+
+func TestServer_Status_Peers(t *testing.T) {
+	_, addr := startPeer(t)
+	srv, s, serverAddr := setupTestServer(t, func(s *server) {
+		s.peers = NewPeerTracker([]string{addr, "127.0.0.1:1"}, time.Minute)
+	})
+	defer cleanupTestServer(t, srv, serverAddr)
+
+	s.sendHeartbeatToPeers()
+
+	client := createTestClient(t, serverAddr)
+	resp, err := client.Status(context.Background(), &pb.StatusRequest{})
+	if err != nil {
+		t.Fatalf("Status() failed: %v", err)
+	}
+
+	alive := map[string]bool{}
+	for _, p := range resp.Peers {
+		alive[p.Address] = p.Alive
+	}
+
+	if len(resp.Peers) != 2 || !alive[addr] || alive["127.0.0.1:1"] {
+		t.Errorf("Unexpected peers in Status: %v", resp.Peers)
+	}
+}
+
+func TestServer_HeartbeatLoop_Interval(t *testing.T) {
+	peer := &countingPeer{}
+	_, addr := startPeerWith(t, peer)
+	s := heartbeatServer(addr)
+
+	interval := 50 * time.Millisecond
+	runLoop(s, interval, 10*interval+interval/2)
+
+	received, _ := peer.stats()
+	if len(received) < 8 || len(received) > 10 {
+		t.Fatalf("expected about 10 heartbeats, got %d", len(received))
+	}
+	for i := 1; i < len(received); i++ {
+		if gap := received[i].Sub(received[i-1]); gap < interval/2 {
+			t.Errorf("heartbeats %d and %d only %v apart, expected about %v", i-1, i, gap, interval)
+		}
+	}
+}
+
+func TestServer_HeartbeatLoop_SlowPeerDoesNotOverlap(t *testing.T) {
+	// cada rodada demora bem mais que o intervalo
+	peer := &countingPeer{delay: 100 * time.Millisecond}
+	_, addr := startPeerWith(t, peer)
+	s := heartbeatServer(addr)
+
+	runLoop(s, 10*time.Millisecond, 450*time.Millisecond)
+
+	received, maxInFlight := peer.stats()
+	if maxInFlight != 1 {
+		t.Errorf("expected one heartbeat in flight at a time, got %d", maxInFlight)
+	}
+	if len(received) > 5 {
+		t.Errorf("expected at most 5 rounds of 100ms in 450ms, got %d", len(received))
+	}
+}
+
+func TestServer_Heartbeat_Timeout(t *testing.T) {
+	peer := &countingPeer{delay: time.Second}
+	_, addr := startPeerWith(t, peer)
+	s := heartbeatServer(addr)
+	s.heartbeatTimeout = 50 * time.Millisecond
+
+	start := time.Now()
+	s.sendHeartbeatToPeers()
+
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("heartbeat took %v, expected the 50ms timeout to apply", elapsed)
+	}
+	if p := s.peers.Peers()[0]; p.Alive || p.LastError == "" {
+		t.Errorf("expected peer down after a timed out heartbeat, got %+v", p)
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// idleBucketTTL é de quanto em quanto tempo os buckets de clientes parados são descartados
+const idleBucketTTL = time.Minute
+
+// tokenBucket guarda os tokens de um cliente na última vez em que foi usado
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// RateLimiter aplica um token bucket por cliente: cada requisição gasta um
+// token e os tokens voltam a rate por segundo, acumulando até burst.
+type RateLimiter struct {
+	mu      sync.Mutex
+	rate    float64
+	burst   float64
+	buckets map[string]*tokenBucket
+	pruned  time.Time
+
+	// now permite controlar o relógio nos testes
+	now func() time.Time
+}
+
+func NewRateLimiter(rate float64, burst int) *RateLimiter {
+	return &RateLimiter{
+		rate:    rate,
+		burst:   float64(max(burst, 1)),
+		buckets: make(map[string]*tokenBucket),
+		now:     time.Now,
+	}
+}
+
+// Allow consome um token do cliente key e informa se havia token disponível
+func (l *RateLimiter) Allow(key string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.prune(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: l.burst, last: now}
+		l.buckets[key] = b
+	}
+
+	b.tokens = min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// prune remove os buckets que já teriam voltado a ficar cheios: para esses
+// clientes, recomeçar com um bucket novo dá no mesmo.
+func (l *RateLimiter) prune(now time.Time) {
+	if now.Sub(l.pruned) < idleBucketTTL {
+		return
+	}
+	l.pruned = now
+
+	for key, b := range l.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*l.rate >= l.burst {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// UnaryServerInterceptor rejeita com ResourceExhausted as RPCs unárias de um
+// cliente que passou do limite. O cliente é identificado pelo host remoto da
+// conexão; streams não são limitados.
+func (l *RateLimiter) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if !l.Allow(clientKey(ctx)) {
+			return nil, status.Errorf(codes.ResourceExhausted, "rate limit exceeded for %s, try again later", info.FullMethod)
+		}
+		return handler(ctx, req)
+	}
+}
+
+// clientKey identifica o cliente pelo host remoto da conexão, sem a porta:
+// abrir outra conexão não dá um limite novo. Endereços sem porta (ex.: unix
+// socket) são usados como estão.
+func clientKey(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	addr := p.Addr.String()
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	pb "github.com/carvalhodanielg/kvstore/pb/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+func TestRateLimiter_Allow(t *testing.T) {
+	now := time.Unix(1000, 0)
+	l := NewRateLimiter(2, 3)
+	l.now = func() time.Time { return now }
+
+	// O burst é consumido de uma vez e depois as requisições são recusadas
+	for i := range 3 {
+		if !l.Allow("a") {
+			t.Fatalf("request %d within burst was rejected", i)
+		}
+	}
+	if l.Allow("a") {
+		t.Error("Expected request over burst to be rejected")
+	}
+
+	// Outro cliente tem o próprio bucket
+	if !l.Allow("b") {
+		t.Error("Expected a different client to be allowed")
+	}
+
+	// Com rate 2/s, meio segundo devolve um token
+	now = now.Add(500 * time.Millisecond)
+	if !l.Allow("a") {
+		t.Error("Expected a refilled token to be allowed")
+	}
+	if l.Allow("a") {
+		t.Error("Expected only one token to be refilled")
+	}
+
+	// Os tokens não passam do burst mesmo depois de muito tempo parado
+	now = now.Add(time.Hour)
+	for i := range 3 {
+		if !l.Allow("a") {
+			t.Fatalf("request %d after idle period was rejected", i)
+		}
+	}
+	if l.Allow("a") {
+		t.Error("Expected the bucket to be capped at burst")
+	}
+}
+
+func TestRateLimiter_PrunesIdleClients(t *testing.T) {
+	now := time.Unix(1000, 0)
+	l := NewRateLimiter(1, 1)
+	l.now = func() time.Time { return now }
+
+	for _, key := range []string{"a", "b", "c"} {
+		l.Allow(key)
+	}
+
+	now = now.Add(idleBucketTTL)
+	l.Allow("d")
+
+	if len(l.buckets) != 1 {
+		t.Errorf("Expected only the active client to be tracked, got %d buckets", len(l.buckets))
+	}
+}
+
+// okServer aceita qualquer Put
+type okServer struct {
+	pb.UnimplementedKvStoreServer
+}
+
+func (okServer) Put(context.Context, *pb.PutRequest) (*pb.PutResponse, error) {
+	return &pb.PutResponse{Success: true}, nil
+}
+
+func TestServer_RateLimit(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+
+	srv := grpc.NewServer(grpc.ChainUnaryInterceptor(NewRateLimiter(1, 5).UnaryServerInterceptor()))
+	pb.RegisterKvStoreServer(srv, okServer{})
+	go srv.Serve(lis)
+	defer srv.Stop()
+
+	// As duas conexões saem do mesmo host e dividem o limite
+	flooder := createTestClient(t, lis.Addr().String())
+	other := createTestClient(t, lis.Addr().String())
+
+	ctx := context.Background()
+
+	rejected := 0
+	for range 20 {
+		_, err := flooder.Put(ctx, &pb.PutRequest{Key: "k", Value: "v"})
+		switch status.Code(err) {
+		case codes.OK:
+		case codes.ResourceExhausted:
+			rejected++
+		default:
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	if rejected == 0 {
+		t.Error("Expected some requests over the limit to be rejected")
+	}
+	if rejected == 20 {
+		t.Error("Expected the burst to be allowed")
+	}
+
+	// reconectar não dá um limite novo
+	if _, err := other.Put(ctx, &pb.PutRequest{Key: "k", Value: "v"}); status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("Second connection from the same host returned %v, expected ResourceExhausted", err)
+	}
+}
+
+func TestClientKey(t *testing.T) {
+	tests := []struct {
+		name string
+		addr net.Addr
+		want string
+	}{
+		{"ipv4", &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 1234}, "10.0.0.1"},
+		{"ipv4 other port", &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 5678}, "10.0.0.1"},
+		{"ipv6", &net.TCPAddr{IP: net.ParseIP("::1"), Port: 1234}, "::1"},
+		{"unix socket", &net.UnixAddr{Name: "/tmp/kv.sock", Net: "unix"}, "/tmp/kv.sock"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: tt.addr})
+			if got := clientKey(ctx); got != tt.want {
+				t.Errorf("clientKey(%s) = %q, expected %q", tt.addr, got, tt.want)
+			}
+		})
+	}
+
+	if got := clientKey(context.Background()); got != "" {
+		t.Errorf("clientKey() without a peer = %q, expected empty", got)
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+
+	pb "github.com/carvalhodanielg/kvstore/pb/proto"
+	"github.com/carvalhodanielg/kvstore/store"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// writeMethods são as RPCs recusadas em modo somente leitura. Leituras,
+// Watch, Backup, Status e as RPCs de administração continuam funcionando; o
+// Verify com repair, que escreve, é recusado pelo próprio handler.
+var writeMethods = map[string]bool{
+	pb.KvStore_Put_FullMethodName:          true,
+	pb.KvStore_Delete_FullMethodName:       true,
+	pb.KvStore_DeleteMany_FullMethodName:   true,
+	pb.KvStore_Clear_FullMethodName:        true,
+	pb.KvStore_PutIfAbsent_FullMethodName:  true,
+	pb.KvStore_PutIfVersion_FullMethodName: true,
+	pb.KvStore_Txn_FullMethodName:          true,
+	pb.KvStore_BulkPut_FullMethodName:      true,
+	pb.KvStore_Restore_FullMethodName:      true,
+}
+
+// checkWritable devolve FailedPrecondition se o servidor está em modo somente
+// leitura. As escritas que chegam pelo raft não passam por aqui, então um nó
+// somente leitura continua acompanhando o cluster.
+func (s *server) checkWritable() error {
+	if s.readOnly.Load() {
+		return storeError(store.ErrReadOnly)
+	}
+	return nil
+}
+
+// readOnlyUnaryInterceptor recusa as escritas unárias enquanto o servidor
+// estiver em modo somente leitura
+func (s *server) readOnlyUnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if writeMethods[info.FullMethod] {
+			if err := s.checkWritable(); err != nil {
+				return nil, err
+			}
+		}
+		return handler(ctx, req)
+	}
+}
+
+// readOnlyStreamInterceptor faz o mesmo para os streams de escrita (BulkPut e Restore)
+func (s *server) readOnlyStreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if writeMethods[info.FullMethod] {
+			if err := s.checkWritable(); err != nil {
+				return err
+			}
+		}
+		return handler(srv, ss)
+	}
+}
+
+// SetReadOnly liga ou desliga o modo somente leitura em tempo de execução e
+// devolve o modo anterior. Só responde com --enable-set-read-only.
+func (s *server) SetReadOnly(_ context.Context, in *pb.SetReadOnlyRequest) (*pb.SetReadOnlyResponse, error) {
+	if !s.allowSetReadOnly {
+		return nil, status.Error(codes.PermissionDenied, "SetReadOnly is disabled, start the server with --enable-set-read-only")
+	}
+
+	previous := s.readOnly.Swap(in.GetReadOnly())
+	if previous != in.GetReadOnly() {
+		slog.Info("read-only mode changed", "read_only", in.GetReadOnly())
+	}
+	return &pb.SetReadOnlyResponse{ReadOnly: in.GetReadOnly(), Previous: previous}, nil
+}
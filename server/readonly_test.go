@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"net"
+	"path/filepath"
+	"testing"
+
+	pb "github.com/carvalhodanielg/kvstore/pb/proto"
+	"github.com/carvalhodanielg/kvstore/store"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestServer_ReadOnly(t *testing.T) {
+	store.SetWALPath(filepath.Join(t.TempDir(), store.WALFileName))
+	defer store.SetWALPath(store.WALFileName)
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+
+	s := &server{store: store.NewKVStore(store.WithBackend(store.NewMemoryBackend())), allowVerify: true, allowSetReadOnly: true}
+	s.readOnly.Store(true)
+
+	srv := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(s.readOnlyUnaryInterceptor()),
+		grpc.ChainStreamInterceptor(s.readOnlyStreamInterceptor()),
+	)
+	pb.RegisterKvStoreServer(srv, s)
+	go srv.Serve(lis)
+	defer srv.Stop()
+
+	client := createTestClient(t, lis.Addr().String())
+	ctx := context.Background()
+
+	s.store.Put("existing", "value")
+
+	// escritas recusadas
+	if _, err := client.Put(ctx, &pb.PutRequest{Key: "k", Value: "v"}); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("Put() in read-only mode expected FailedPrecondition, got %v", err)
+	}
+	if _, err := client.Delete(ctx, &pb.DeleteRequest{Key: "existing"}); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("Delete() in read-only mode expected FailedPrecondition, got %v", err)
+	}
+	bulk, err := client.BulkPut(ctx)
+	if err != nil {
+		t.Fatalf("BulkPut() failed: %v", err)
+	}
+	if _, err := bulk.CloseAndRecv(); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("BulkPut() in read-only mode expected FailedPrecondition, got %v", err)
+	}
+
+	// leituras continuam funcionando
+	got, err := client.Get(ctx, &pb.GetRequest{Key: "existing"})
+	if err != nil || got.Value != "value" {
+		t.Errorf("Get() in read-only mode = %v, %v", got, err)
+	}
+	if _, err := client.GetAll(ctx, &pb.GetAllRequest{}); err != nil {
+		t.Errorf("GetAll() in read-only mode failed: %v", err)
+	}
+	st, err := client.Status(ctx, &pb.StatusRequest{})
+	if err != nil || !st.ReadOnly {
+		t.Errorf("Status() expected read_only, got %v, %v", st, err)
+	}
+
+	// o Verify só diagnostica, mas com repair escreveria
+	if _, err := client.Verify(ctx, &pb.VerifyRequest{}); err != nil {
+		t.Errorf("Verify() in read-only mode failed: %v", err)
+	}
+	if _, err := client.Verify(ctx, &pb.VerifyRequest{Repair: true}); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("Verify(repair) in read-only mode expected FailedPrecondition, got %v", err)
+	}
+
+	// desligar o modo volta a aceitar escritas
+	resp, err := client.SetReadOnly(ctx, &pb.SetReadOnlyRequest{ReadOnly: false})
+	if err != nil {
+		t.Fatalf("SetReadOnly() failed: %v", err)
+	}
+	if !resp.Previous || resp.ReadOnly {
+		t.Errorf("unexpected SetReadOnly() response %+v", resp)
+	}
+	if _, err := client.Put(ctx, &pb.PutRequest{Key: "k", Value: "v"}); err != nil {
+		t.Errorf("Put() after leaving read-only mode failed: %v", err)
+	}
+	if v := s.store.Get("k"); v != "v" {
+		t.Errorf("expected k=v after leaving read-only mode, got %q", v)
+	}
+}
+
+func TestServer_SetReadOnly_Disabled(t *testing.T) {
+	s := &server{store: store.NewKVStore(store.WithBackend(store.NewMemoryBackend()))}
+	s.readOnly.Store(true)
+
+	// sem --enable-set-read-only o modo não pode ser desligado
+	_, err := s.SetReadOnly(context.Background(), &pb.SetReadOnlyRequest{ReadOnly: false})
+	if status.Code(err) != codes.PermissionDenied {
+		t.Errorf("SetReadOnly() without the flag expected PermissionDenied, got %v", err)
+	}
+	if !s.readOnly.Load() {
+		t.Error("SetReadOnly() without the flag left read-only mode")
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"sync"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// WatchLimiter limita os streams de Watch abertos ao mesmo tempo, no total e
+// por cliente. Cada stream segura uma goroutine e um watcher no servidor até
+// o cliente desconectar, então sem limite um cliente pode esgotar a memória
+// abrindo milhares deles.
+type WatchLimiter struct {
+	mu        sync.Mutex
+	max       int
+	perClient int
+	active    int
+	clients   map[string]int
+}
+
+// NewWatchLimiter cria o limitador; zero ou negativo desliga o limite
+// correspondente.
+func NewWatchLimiter(max, perClient int) *WatchLimiter {
+	return &WatchLimiter{
+		max:       max,
+		perClient: perClient,
+		clients:   make(map[string]int),
+	}
+}
+
+// Acquire reserva uma vaga para um stream do cliente, ou devolve
+// ResourceExhausted se algum dos limites já foi atingido. A função devolvida
+// libera a vaga e deve ser chamada quando o stream terminar. Num limitador
+// nil tudo é permitido.
+func (l *WatchLimiter) Acquire(client string) (release func(), err error) {
+	if l == nil {
+		return func() {}, nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.max > 0 && l.active >= l.max {
+		return nil, status.Errorf(codes.ResourceExhausted, "too many active watches (limit %d)", l.max)
+	}
+	if l.perClient > 0 && l.clients[client] >= l.perClient {
+		return nil, status.Errorf(codes.ResourceExhausted, "too many active watches for %s (limit %d)", client, l.perClient)
+	}
+
+	l.active++
+	l.clients[client]++
+
+	var once sync.Once
+	return func() { once.Do(func() { l.release(client) }) }, nil
+}
+
+func (l *WatchLimiter) release(client string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.active--
+	if l.clients[client]--; l.clients[client] <= 0 {
+		delete(l.clients, client)
+	}
+}
+
+// Active devolve quantos streams estão abertos agora
+func (l *WatchLimiter) Active() int {
+	if l == nil {
+		return 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.active
+}
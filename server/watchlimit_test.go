@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	pb "github.com/carvalhodanielg/kvstore/pb/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestWatchLimiter_Acquire(t *testing.T) {
+	l := NewWatchLimiter(3, 2)
+
+	releaseA1, err := l.Acquire("a")
+	if err != nil {
+		t.Fatalf("first watch rejected: %v", err)
+	}
+	if _, err := l.Acquire("a"); err != nil {
+		t.Fatalf("second watch rejected: %v", err)
+	}
+
+	// limite por cliente
+	if _, err := l.Acquire("a"); status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("expected ResourceExhausted over the per-client limit, got %v", err)
+	}
+
+	// limite global, mesmo para outro cliente
+	if _, err := l.Acquire("b"); err != nil {
+		t.Fatalf("watch from another client rejected: %v", err)
+	}
+	if _, err := l.Acquire("c"); status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("expected ResourceExhausted over the global limit, got %v", err)
+	}
+
+	// liberar duas vezes conta uma só
+	releaseA1()
+	releaseA1()
+	if got := l.Active(); got != 2 {
+		t.Errorf("Active() = %d, expected 2", got)
+	}
+	if _, err := l.Acquire("a"); err != nil {
+		t.Errorf("expected a watch after release, got %v", err)
+	}
+
+	var nilLimiter *WatchLimiter
+	if _, err := nilLimiter.Acquire("a"); err != nil {
+		t.Errorf("nil limiter rejected a watch: %v", err)
+	}
+}
+
+func TestServer_Watch_MaxStreams(t *testing.T) {
+	srv, s, addr := setupTestServer(t, func(s *server) { s.watches = NewWatchLimiter(2, 0) })
+	defer cleanupTestServer(t, srv, addr)
+
+	client := createTestClient(t, addr)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	firstCtx, closeFirst := context.WithCancel(ctx)
+	if _, err := client.Watch(firstCtx, &pb.WatchRequest{Key: "a"}); err != nil {
+		t.Fatalf("Watch() failed: %v", err)
+	}
+	if _, err := client.WatchAll(ctx, &pb.WatchAllRequest{}); err != nil {
+		t.Fatalf("WatchAll() failed: %v", err)
+	}
+	waitForWatchers(t, s.store, 2)
+
+	// o erro de um stream só chega no primeiro Recv
+	stream, err := client.Watch(ctx, &pb.WatchRequest{Key: "b"})
+	if err != nil {
+		t.Fatalf("Watch() failed: %v", err)
+	}
+	if _, err := stream.Recv(); status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("expected ResourceExhausted over the limit, got %v", err)
+	}
+
+	// fechar um stream libera a vaga
+	closeFirst()
+	deadline := time.Now().Add(2 * time.Second)
+	for s.watches.Active() != 1 {
+		if time.Now().After(deadline) {
+			t.Fatalf("Active() = %d after closing a stream, expected 1", s.watches.Active())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if _, err := client.Watch(ctx, &pb.WatchRequest{Key: "b"}); err != nil {
+		t.Fatalf("Watch() failed: %v", err)
+	}
+	waitForWatchers(t, s.store, 2)
+}
@@ -0,0 +1,216 @@
+package store
+
+import (
+	"errors"
+	"sync"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Backend é onde a KVStore persiste os dados, organizados em buckets.
+// Buckets são criados sob demanda no Put; ler, apagar ou percorrer um bucket
+// inexistente não é erro.
+//
+// Os slices passados para o ForEach só valem durante o callback. O Get
+// devolve uma cópia, ou nil se a chave não existir.
+type Backend interface {
+	Get(bucket, key []byte) ([]byte, error)
+	Put(bucket, key, value []byte) error
+	Delete(bucket, key []byte) error
+	// ForEach percorre as chaves do bucket em ordem
+	ForEach(bucket []byte, fn func(key, value []byte) error) error
+	// ClearBucket apaga todas as chaves, mantendo o bucket
+	ClearBucket(bucket []byte) error
+	DeleteBucket(bucket []byte) error
+	// Buckets percorre os nomes dos buckets em ordem
+	Buckets(fn func(name []byte) error) error
+	// Update agrupa várias operações: no bbolt elas vão na mesma transação.
+	// Chamadas concorrentes devem rodar fn uma de cada vez.
+	Update(fn func(tx Backend) error) error
+}
+
+// noBackend é usado no lugar de um banco nil, falhando em vez de entrar em panic
+type noBackend struct{}
+
+func (noBackend) Get(_, _ []byte) ([]byte, error)                   { return nil, ErrNoBackend }
+func (noBackend) Put(_, _, _ []byte) error                          { return ErrNoBackend }
+func (noBackend) Delete(_, _ []byte) error                          { return ErrNoBackend }
+func (noBackend) ForEach(_ []byte, _ func(k, v []byte) error) error { return ErrNoBackend }
+func (noBackend) ClearBucket(_ []byte) error                        { return ErrNoBackend }
+func (noBackend) DeleteBucket(_ []byte) error                       { return ErrNoBackend }
+func (noBackend) Buckets(_ func(name []byte) error) error           { return ErrNoBackend }
+func (noBackend) Update(_ func(tx Backend) error) error             { return ErrNoBackend }
+
+// BoltBackend grava no bbolt, uma transação por operação
+type BoltBackend struct {
+	// mu protege a troca do banco pelo Compact; as operações seguram o
+	// read lock
+	mu sync.RWMutex
+	db *bolt.DB
+}
+
+// NewBoltBackend usa o banco já aberto; quem abriu continua responsável por fechá-lo
+func NewBoltBackend(db *bolt.DB) *BoltBackend {
+	return &BoltBackend{db: db}
+}
+
+func (b *BoltBackend) Get(bucket, key []byte) (value []byte, err error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	err = b.db.View(func(tx *bolt.Tx) error {
+		value, err = boltTx{tx}.Get(bucket, key)
+		return err
+	})
+	return value, err
+}
+
+func (b *BoltBackend) Put(bucket, key, value []byte) error {
+	return b.Update(func(tx Backend) error {
+		return tx.Put(bucket, key, value)
+	})
+}
+
+func (b *BoltBackend) Delete(bucket, key []byte) error {
+	return b.Update(func(tx Backend) error {
+		return tx.Delete(bucket, key)
+	})
+}
+
+func (b *BoltBackend) ForEach(bucket []byte, fn func(key, value []byte) error) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	return b.db.View(func(tx *bolt.Tx) error {
+		return boltTx{tx}.ForEach(bucket, fn)
+	})
+}
+
+// ForEachFrom posiciona um cursor em start e percorre dali em diante
+func (b *BoltBackend) ForEachFrom(bucket, start []byte, fn func(key, value []byte) error) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	return b.db.View(func(tx *bolt.Tx) error {
+		bk := tx.Bucket(bucket)
+		if bk == nil {
+			return nil
+		}
+
+		c := bk.Cursor()
+		for k, v := c.Seek(start); k != nil; k, v = c.Next() {
+			if err := fn(k, v); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Sync força o fsync do arquivo, necessário quando o banco foi aberto com NoSync
+func (b *BoltBackend) Sync() error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	return b.db.Sync()
+}
+
+func (b *BoltBackend) ClearBucket(bucket []byte) error {
+	return b.Update(func(tx Backend) error {
+		return tx.ClearBucket(bucket)
+	})
+}
+
+func (b *BoltBackend) DeleteBucket(bucket []byte) error {
+	return b.Update(func(tx Backend) error {
+		return tx.DeleteBucket(bucket)
+	})
+}
+
+func (b *BoltBackend) Buckets(fn func(name []byte) error) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	return b.db.View(func(tx *bolt.Tx) error {
+		return boltTx{tx}.Buckets(fn)
+	})
+}
+
+func (b *BoltBackend) Update(fn func(tx Backend) error) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	return b.db.Update(func(tx *bolt.Tx) error {
+		return fn(boltTx{tx})
+	})
+}
+
+// boltTx executa as operações dentro de uma transação já aberta
+type boltTx struct {
+	tx *bolt.Tx
+}
+
+func (t boltTx) Get(bucket, key []byte) ([]byte, error) {
+	b := t.tx.Bucket(bucket)
+	if b == nil {
+		return nil, nil
+	}
+
+	v := b.Get(key)
+	if v == nil {
+		return nil, nil
+	}
+	//append([]byte{}) e não nil: um valor vazio gravado não é uma chave ausente
+	return append([]byte{}, v...), nil
+}
+
+func (t boltTx) Put(bucket, key, value []byte) error {
+	b, err := t.tx.CreateBucketIfNotExists(bucket)
+	if err != nil {
+		return err
+	}
+	return b.Put(key, value)
+}
+
+func (t boltTx) Delete(bucket, key []byte) error {
+	b := t.tx.Bucket(bucket)
+	if b == nil {
+		return nil
+	}
+	return b.Delete(key)
+}
+
+func (t boltTx) ForEach(bucket []byte, fn func(key, value []byte) error) error {
+	b := t.tx.Bucket(bucket)
+	if b == nil {
+		return nil
+	}
+	return b.ForEach(fn)
+}
+
+func (t boltTx) ClearBucket(bucket []byte) error {
+	if err := t.DeleteBucket(bucket); err != nil {
+		return err
+	}
+	_, err := t.tx.CreateBucket(bucket)
+	return err
+}
+
+func (t boltTx) DeleteBucket(bucket []byte) error {
+	err := t.tx.DeleteBucket(bucket)
+	if errors.Is(err, bolt.ErrBucketNotFound) {
+		return nil
+	}
+	return err
+}
+
+func (t boltTx) Buckets(fn func(name []byte) error) error {
+	return t.tx.ForEach(func(name []byte, _ *bolt.Bucket) error {
+		return fn(name)
+	})
+}
+
+// Update dentro de uma transação reaproveita a mesma transação
+func (t boltTx) Update(fn func(tx Backend) error) error {
+	return fn(t)
+}
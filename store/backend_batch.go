@@ -0,0 +1,344 @@
+package store
+
+import (
+	"bytes"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+)
+
+// DefaultBatchSize é quantas operações pendentes forçam um flush antes do fim da janela
+const DefaultBatchSize = 1000
+
+// BatchBackend junta as escritas feitas dentro de uma janela curta em uma
+// única transação do backend de baixo, trocando alguns milissegundos até o
+// dado chegar ao disco por muito mais escritas por segundo (no bbolt, um fsync
+// por lote em vez de um por Put).
+//
+// As escritas retornam assim que entram no lote; um erro no flush é logado e
+// as operações daquele lote se perdem no disco (a memória e o WAL já as têm).
+// O Get procura a chave no lote antes do disco, sem flush; as outras leituras
+// fazem flush antes. As duas enxergam as escritas anteriores.
+// Chame Flush antes de fechar o banco.
+type BatchBackend struct {
+	inner  Backend
+	window time.Duration
+	size   int
+
+	mu      sync.Mutex
+	pending []batchOp
+	// flushing é o lote que o Flush está gravando, ainda visível para o Get
+	flushing []batchOp
+	timer    *time.Timer
+
+	// flushMu mantém os lotes na ordem em que foram retirados de pending
+	flushMu sync.Mutex
+	// updateMu roda um Update de cada vez, como as transações do bbolt, então
+	// as operações entram no lote na ordem em que fn as gravou
+	updateMu sync.Mutex
+}
+
+// NewBatchBackend agrupa as escritas em inner por até window ou até size
+// operações pendentes, o que vier primeiro
+func NewBatchBackend(inner Backend, window time.Duration, size int) *BatchBackend {
+	if size <= 0 {
+		size = DefaultBatchSize
+	}
+	return &BatchBackend{inner: inner, window: window, size: size}
+}
+
+type batchOpKind uint8
+
+const (
+	batchPut batchOpKind = iota
+	batchDelete
+	batchClearBucket
+	batchDeleteBucket
+)
+
+// batchOp é uma escrita guardada até o flush, com cópias de bucket, key e value
+type batchOp struct {
+	kind   batchOpKind
+	bucket []byte
+	key    []byte
+	value  []byte
+}
+
+func (op batchOp) apply(tx Backend) error {
+	switch op.kind {
+	case batchPut:
+		return tx.Put(op.bucket, op.key, op.value)
+	case batchDelete:
+		return tx.Delete(op.bucket, op.key)
+	case batchClearBucket:
+		return tx.ClearBucket(op.bucket)
+	default:
+		return tx.DeleteBucket(op.bucket)
+	}
+}
+
+// lookupOps procura a última operação das ops que decide o valor da chave e
+// diz se achou uma. Um ClearBucket ou DeleteBucket do bucket apaga a chave.
+func lookupOps(ops []batchOp, bucket, key []byte) ([]byte, bool) {
+	for i := len(ops) - 1; i >= 0; i-- {
+		op := ops[i]
+		if !bytes.Equal(op.bucket, bucket) {
+			continue
+		}
+		switch op.kind {
+		case batchPut:
+			if bytes.Equal(op.key, key) {
+				return slices.Clone(op.value), true
+			}
+		case batchDelete:
+			if bytes.Equal(op.key, key) {
+				return nil, true
+			}
+		default:
+			return nil, true
+		}
+	}
+	return nil, false
+}
+
+// Get responde pelo lote pendente (ou o que está sendo gravado) quando ele tem
+// a chave e só então lê o backend de baixo. Não faz flush, então leituras de
+// chaves ausentes, como as do read-through, não quebram a janela do lote.
+func (b *BatchBackend) Get(bucket, key []byte) ([]byte, error) {
+	b.mu.Lock()
+	value, ok := lookupOps(b.pending, bucket, key)
+	if !ok {
+		value, ok = lookupOps(b.flushing, bucket, key)
+	}
+	b.mu.Unlock()
+
+	if ok {
+		return value, nil
+	}
+	return b.inner.Get(bucket, key)
+}
+
+func (b *BatchBackend) Put(bucket, key, value []byte) error {
+	return b.Update(func(tx Backend) error {
+		return tx.Put(bucket, key, value)
+	})
+}
+
+func (b *BatchBackend) Delete(bucket, key []byte) error {
+	return b.Update(func(tx Backend) error {
+		return tx.Delete(bucket, key)
+	})
+}
+
+func (b *BatchBackend) ForEach(bucket []byte, fn func(key, value []byte) error) error {
+	if err := b.Flush(); err != nil {
+		return err
+	}
+	return b.inner.ForEach(bucket, fn)
+}
+
+func (b *BatchBackend) ForEachFrom(bucket, start []byte, fn func(key, value []byte) error) error {
+	if err := b.Flush(); err != nil {
+		return err
+	}
+	return forEachFrom(b.inner, bucket, start, fn)
+}
+
+// Sync grava o lote pendente e faz o fsync do backend de baixo, se ele tiver um
+func (b *BatchBackend) Sync() error {
+	if err := b.Flush(); err != nil {
+		return err
+	}
+	if s, ok := b.inner.(interface{ Sync() error }); ok {
+		return s.Sync()
+	}
+	return nil
+}
+
+// Size e Compact repassam para o backend de baixo, se ele tiver um arquivo;
+// o Compact grava o lote pendente antes
+func (b *BatchBackend) Size() (int64, error) {
+	if c, ok := b.inner.(dbCompacter); ok {
+		return c.Size()
+	}
+	return 0, nil
+}
+
+func (b *BatchBackend) Compact() (before, after int64, err error) {
+	if err := b.Flush(); err != nil {
+		return 0, 0, err
+	}
+	if c, ok := b.inner.(dbCompacter); ok {
+		return c.Compact()
+	}
+	return 0, 0, nil
+}
+
+func (b *BatchBackend) ClearBucket(bucket []byte) error {
+	return b.Update(func(tx Backend) error {
+		return tx.ClearBucket(bucket)
+	})
+}
+
+func (b *BatchBackend) DeleteBucket(bucket []byte) error {
+	return b.Update(func(tx Backend) error {
+		return tx.DeleteBucket(bucket)
+	})
+}
+
+func (b *BatchBackend) Buckets(fn func(name []byte) error) error {
+	if err := b.Flush(); err != nil {
+		return err
+	}
+	return b.inner.Buckets(fn)
+}
+
+// Update roda fn na hora gravando as operações; se fn não falhar, elas entram
+// inteiras no lote e vão para o disco na mesma transação
+func (b *BatchBackend) Update(fn func(tx Backend) error) error {
+	b.updateMu.Lock()
+	defer b.updateMu.Unlock()
+
+	tx := &batchTx{b: b}
+	if err := fn(tx); err != nil {
+		return err
+	}
+	b.enqueue(tx.ops)
+	return nil
+}
+
+func (b *BatchBackend) enqueue(ops []batchOp) {
+	if len(ops) == 0 {
+		return
+	}
+
+	b.mu.Lock()
+	b.pending = append(b.pending, ops...)
+	full := len(b.pending) >= b.size
+	if !full && b.timer == nil {
+		b.timer = time.AfterFunc(b.window, func() {
+			if err := b.Flush(); err != nil {
+				slog.Error("batched write failed", "error", err)
+			}
+		})
+	}
+	b.mu.Unlock()
+
+	if full {
+		if err := b.Flush(); err != nil {
+			slog.Error("batched write failed", "error", err)
+		}
+	}
+}
+
+// Flush grava agora as operações pendentes em uma única transação
+func (b *BatchBackend) Flush() error {
+	b.flushMu.Lock()
+	defer b.flushMu.Unlock()
+
+	b.mu.Lock()
+	ops := b.pending
+	b.pending = nil
+	b.flushing = ops
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+	b.mu.Unlock()
+
+	if len(ops) == 0 {
+		return nil
+	}
+
+	err := b.inner.Update(func(tx Backend) error {
+		for _, op := range ops {
+			if err := op.apply(tx); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	b.mu.Lock()
+	b.flushing = nil
+	b.mu.Unlock()
+	return err
+}
+
+// batchTx grava as operações de um Update. As leituras aplicam as operações
+// já gravadas por cima do que está no backend.
+type batchTx struct {
+	b   *BatchBackend
+	ops []batchOp
+}
+
+func (t *batchTx) Get(bucket, key []byte) ([]byte, error) {
+	if value, ok := lookupOps(t.ops, bucket, key); ok {
+		return value, nil
+	}
+	return t.b.Get(bucket, key)
+}
+
+func (t *batchTx) Put(bucket, key, value []byte) error {
+	t.ops = append(t.ops, batchOp{kind: batchPut, bucket: slices.Clone(bucket), key: slices.Clone(key), value: append([]byte{}, value...)})
+	return nil
+}
+
+func (t *batchTx) Delete(bucket, key []byte) error {
+	t.ops = append(t.ops, batchOp{kind: batchDelete, bucket: slices.Clone(bucket), key: slices.Clone(key)})
+	return nil
+}
+
+func (t *batchTx) ForEach(bucket []byte, fn func(key, value []byte) error) error {
+	view := make(memoryTx)
+	if err := t.b.ForEach(bucket, func(k, v []byte) error {
+		return view.Put(bucket, k, v)
+	}); err != nil {
+		return err
+	}
+
+	for _, op := range t.ops {
+		if bytes.Equal(op.bucket, bucket) {
+			op.apply(view)
+		}
+	}
+	return view.ForEach(bucket, fn)
+}
+
+func (t *batchTx) ClearBucket(bucket []byte) error {
+	t.ops = append(t.ops, batchOp{kind: batchClearBucket, bucket: slices.Clone(bucket)})
+	return nil
+}
+
+func (t *batchTx) DeleteBucket(bucket []byte) error {
+	t.ops = append(t.ops, batchOp{kind: batchDeleteBucket, bucket: slices.Clone(bucket)})
+	return nil
+}
+
+func (t *batchTx) Buckets(fn func(name []byte) error) error {
+	view := make(memoryTx)
+	if err := t.b.Buckets(func(name []byte) error {
+		return view.ClearBucket(name)
+	}); err != nil {
+		return err
+	}
+
+	for _, op := range t.ops {
+		op.apply(view)
+	}
+	return view.Buckets(fn)
+}
+
+func (t *batchTx) Update(fn func(tx Backend) error) error {
+	return fn(t)
+}
+
+// Flush grava as escritas que o backend ainda está agrupando (ver
+// BatchBackend). Com os outros backends não faz nada.
+func (kv *KVStore) Flush() error {
+	if f, ok := kv.storage().(interface{ Flush() error }); ok {
+		return f.Flush()
+	}
+	return nil
+}
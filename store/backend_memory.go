@@ -0,0 +1,150 @@
+package store
+
+import (
+	"slices"
+	"sync"
+)
+
+// MemoryBackend guarda tudo em memória, sem tocar no disco. Serve para testes
+// e para stores efêmeras. Um Update com erro não desfaz as operações que já
+// foram aplicadas dentro dele.
+type MemoryBackend struct {
+	mu   sync.RWMutex
+	data memoryTx
+}
+
+func NewMemoryBackend() *MemoryBackend {
+	return &MemoryBackend{data: make(memoryTx)}
+}
+
+func (m *MemoryBackend) Get(bucket, key []byte) ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.data.Get(bucket, key)
+}
+
+func (m *MemoryBackend) Put(bucket, key, value []byte) error {
+	return m.Update(func(tx Backend) error {
+		return tx.Put(bucket, key, value)
+	})
+}
+
+func (m *MemoryBackend) Delete(bucket, key []byte) error {
+	return m.Update(func(tx Backend) error {
+		return tx.Delete(bucket, key)
+	})
+}
+
+func (m *MemoryBackend) ForEach(bucket []byte, fn func(key, value []byte) error) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.data.ForEach(bucket, fn)
+}
+
+func (m *MemoryBackend) ForEachFrom(bucket, start []byte, fn func(key, value []byte) error) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.data.ForEach(bucket, func(k, v []byte) error {
+		if start != nil && string(k) < string(start) {
+			return nil
+		}
+		return fn(k, v)
+	})
+}
+
+func (m *MemoryBackend) ClearBucket(bucket []byte) error {
+	return m.Update(func(tx Backend) error {
+		return tx.ClearBucket(bucket)
+	})
+}
+
+func (m *MemoryBackend) DeleteBucket(bucket []byte) error {
+	return m.Update(func(tx Backend) error {
+		return tx.DeleteBucket(bucket)
+	})
+}
+
+func (m *MemoryBackend) Buckets(fn func(name []byte) error) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.data.Buckets(fn)
+}
+
+func (m *MemoryBackend) Update(fn func(tx Backend) error) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return fn(m.data)
+}
+
+// memoryTx são os buckets em si; quem chama já segura o lock do MemoryBackend
+type memoryTx map[string]map[string][]byte
+
+func (t memoryTx) Get(bucket, key []byte) ([]byte, error) {
+	v, ok := t[string(bucket)][string(key)]
+	if !ok {
+		return nil, nil
+	}
+	return slices.Clone(v), nil
+}
+
+func (t memoryTx) Put(bucket, key, value []byte) error {
+	b, ok := t[string(bucket)]
+	if !ok {
+		b = make(map[string][]byte)
+		t[string(bucket)] = b
+	}
+	// copia e nunca guarda nil, para o Get distinguir valor vazio de chave ausente
+	b[string(key)] = append([]byte{}, value...)
+	return nil
+}
+
+func (t memoryTx) Delete(bucket, key []byte) error {
+	delete(t[string(bucket)], string(key))
+	return nil
+}
+
+func (t memoryTx) ForEach(bucket []byte, fn func(key, value []byte) error) error {
+	b := t[string(bucket)]
+
+	keys := make([]string, 0, len(b))
+	for k := range b {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+
+	for _, k := range keys {
+		if err := fn([]byte(k), b[k]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t memoryTx) ClearBucket(bucket []byte) error {
+	t[string(bucket)] = make(map[string][]byte)
+	return nil
+}
+
+func (t memoryTx) DeleteBucket(bucket []byte) error {
+	delete(t, string(bucket))
+	return nil
+}
+
+func (t memoryTx) Buckets(fn func(name []byte) error) error {
+	names := make([]string, 0, len(t))
+	for name := range t {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	for _, name := range names {
+		if err := fn([]byte(name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t memoryTx) Update(fn func(tx Backend) error) error {
+	return fn(t)
+}
@@ -0,0 +1,304 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/carvalhodanielg/kvstore/internal/constants"
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestBoltBackend(t *testing.T) {
+	testBackend(t, func(t *testing.T) Backend {
+		d, err := bolt.Open(filepath.Join(t.TempDir(), "backend.db"), constants.DBFilePermission, nil)
+		if err != nil {
+			t.Fatalf("failed to open test db: %v", err)
+		}
+		t.Cleanup(func() { d.Close() })
+		return NewBoltBackend(d)
+	})
+}
+
+func TestMemoryBackend(t *testing.T) {
+	testBackend(t, func(t *testing.T) Backend {
+		return NewMemoryBackend()
+	})
+}
+
+func TestBatchBackend(t *testing.T) {
+	// Sem flush pela janela: só o tamanho do lote e as leituras forçam a escrita
+	testBackend(t, func(t *testing.T) Backend {
+		return NewBatchBackend(NewMemoryBackend(), time.Hour, 3)
+	})
+}
+
+func TestBatchBackend_FlushBoundary(t *testing.T) {
+	defer os.Remove("walog.ndjson")
+
+	inner := NewMemoryBackend()
+	kv := NewKVStore(WithBackend(NewBatchBackend(inner, time.Hour, 20)))
+
+	watcher := kv.Watch("key07")
+	defer kv.Unwatch(watcher)
+
+	// Cada Put ou Delete grava 6 operações (valor, tombstone, sequência,
+	// revisão, tempos e contador), então um lote fecha a cada 4 escritas: os
+	// 24 primeiros Puts vão para o banco e key24, o Delete e o último Put
+	// ficam pendentes (18 operações)
+	for i := range 25 {
+		kv.Put(fmt.Sprintf("key%02d", i), fmt.Sprintf("v%d", i))
+	}
+	kv.Delete("key03")
+	kv.Put("key07", "updated")
+
+	countInner := func() int {
+		n := 0
+		inner.ForEach([]byte(constants.BucketStore), func(_, _ []byte) error {
+			n++
+			return nil
+		})
+		return n
+	}
+
+	// Os primeiros lotes já foram gravados pelo tamanho, o último continua pendente
+	if v, _ := inner.Get([]byte(constants.BucketStore), []byte("key00")); string(v) != "v0" {
+		t.Errorf("Expected key00 to be flushed by batch size, got %q", v)
+	}
+	if v, _ := inner.Get([]byte(constants.BucketStore), []byte("key24")); v != nil {
+		t.Errorf("Expected key24 to still be pending, got %q", v)
+	}
+
+	if err := kv.Flush(); err != nil {
+		t.Fatalf("Flush() failed: %v", err)
+	}
+	if n := countInner(); n != 24 {
+		t.Errorf("Expected 24 keys on disk after Flush(), got %d", n)
+	}
+
+	if v, _ := inner.Get([]byte(constants.BucketStore), []byte("key07")); string(v) != "updated" {
+		t.Errorf("Expected the last write to key07 to win, got %q", v)
+	}
+	if v, _ := inner.Get([]byte(constants.BucketStore), []byte("key03")); v != nil {
+		t.Errorf("Expected key03 to be deleted on disk, got %q", v)
+	}
+
+	// Os watchers continuam recebendo um evento por escrita
+	if got := drainEvents(watcher); len(got) != 2 {
+		t.Errorf("Expected 2 events for key07, got %v", got)
+	}
+
+	// A revisão persistida acompanha a última escrita
+	reopened := NewKVStore(WithBackend(inner))
+	if err := reopened.LoadRevisions(); err != nil {
+		t.Fatalf("LoadRevisions() failed: %v", err)
+	}
+	if got, want := reopened.Revision("key07"), kv.Revision("key07"); got != want {
+		t.Errorf("Persisted revision of key07 = %d, expected %d", got, want)
+	}
+}
+
+func TestBatchBackend_FlushAfterWindow(t *testing.T) {
+	inner := NewMemoryBackend()
+	b := NewBatchBackend(inner, 10*time.Millisecond, 100)
+
+	b.Put([]byte("bucket"), []byte("k"), []byte("v"))
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if v, _ := inner.Get([]byte("bucket"), []byte("k")); string(v) == "v" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Write was not flushed after the batch window")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// testBackend é o comportamento que toda implementação de Backend deve seguir
+func testBackend(t *testing.T, newBackend func(t *testing.T) Backend) {
+	bucket := []byte("bucket")
+
+	collect := func(t *testing.T, b Backend, bucket []byte) map[string]string {
+		got := map[string]string{}
+		var order []string
+		err := b.ForEach(bucket, func(k, v []byte) error {
+			got[string(k)] = string(v)
+			order = append(order, string(k))
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("ForEach() failed: %v", err)
+		}
+		for i := 1; i < len(order); i++ {
+			if order[i-1] > order[i] {
+				t.Errorf("ForEach() not in key order: %v", order)
+			}
+		}
+		return got
+	}
+
+	buckets := func(t *testing.T, b Backend) []string {
+		var names []string
+		if err := b.Buckets(func(name []byte) error {
+			names = append(names, string(name))
+			return nil
+		}); err != nil {
+			t.Fatalf("Buckets() failed: %v", err)
+		}
+		return names
+	}
+
+	t.Run("PutGet", func(t *testing.T) {
+		b := newBackend(t)
+
+		if err := b.Put(bucket, []byte("k"), []byte("v")); err != nil {
+			t.Fatalf("Put() failed: %v", err)
+		}
+		if err := b.Put(bucket, []byte("empty"), []byte{}); err != nil {
+			t.Fatalf("Put() failed: %v", err)
+		}
+
+		if v, err := b.Get(bucket, []byte("k")); err != nil || string(v) != "v" {
+			t.Errorf("Get(k) = %q, %v; expected v", v, err)
+		}
+		if v, err := b.Get(bucket, []byte("empty")); err != nil || v == nil || len(v) != 0 {
+			t.Errorf("Get(empty) = %#v, %v; expected a non-nil empty value", v, err)
+		}
+		if v, err := b.Get(bucket, []byte("missing")); err != nil || v != nil {
+			t.Errorf("Get(missing) = %q, %v; expected nil", v, err)
+		}
+		if v, err := b.Get([]byte("no-bucket"), []byte("k")); err != nil || v != nil {
+			t.Errorf("Get() on missing bucket = %q, %v; expected nil", v, err)
+		}
+
+		// O valor devolvido é uma cópia
+		v, _ := b.Get(bucket, []byte("k"))
+		v[0] = 'x'
+		if v, _ := b.Get(bucket, []byte("k")); string(v) != "v" {
+			t.Errorf("changing the Get result changed the stored value to %q", v)
+		}
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		b := newBackend(t)
+		b.Put(bucket, []byte("k"), []byte("v"))
+
+		if err := b.Delete(bucket, []byte("k")); err != nil {
+			t.Fatalf("Delete() failed: %v", err)
+		}
+		if v, _ := b.Get(bucket, []byte("k")); v != nil {
+			t.Errorf("Get() after Delete() = %q, expected nil", v)
+		}
+		if err := b.Delete(bucket, []byte("k")); err != nil {
+			t.Errorf("Delete() of a missing key returned %v", err)
+		}
+		if err := b.Delete([]byte("no-bucket"), []byte("k")); err != nil {
+			t.Errorf("Delete() on a missing bucket returned %v", err)
+		}
+	})
+
+	t.Run("ForEach", func(t *testing.T) {
+		b := newBackend(t)
+		for _, k := range []string{"c", "a", "b"} {
+			b.Put(bucket, []byte(k), []byte("v-"+k))
+		}
+		b.Put([]byte("other"), []byte("z"), []byte("v-z"))
+
+		expected := map[string]string{"a": "v-a", "b": "v-b", "c": "v-c"}
+		if got := collect(t, b, bucket); !reflect.DeepEqual(got, expected) {
+			t.Errorf("ForEach() = %v, expected %v", got, expected)
+		}
+		if got := collect(t, b, []byte("no-bucket")); len(got) != 0 {
+			t.Errorf("ForEach() on a missing bucket = %v, expected nothing", got)
+		}
+
+		stop := errors.New("stop")
+		calls := 0
+		err := b.ForEach(bucket, func(_, _ []byte) error {
+			calls++
+			return stop
+		})
+		if !errors.Is(err, stop) || calls != 1 {
+			t.Errorf("ForEach() should stop on the first error, got %v after %d calls", err, calls)
+		}
+	})
+
+	t.Run("Buckets", func(t *testing.T) {
+		b := newBackend(t)
+		b.Put([]byte("b"), []byte("k"), []byte("v"))
+		b.Put([]byte("a"), []byte("k"), []byte("v"))
+		b.Put([]byte("c"), []byte("k"), []byte("v"))
+
+		if err := b.DeleteBucket([]byte("b")); err != nil {
+			t.Fatalf("DeleteBucket() failed: %v", err)
+		}
+		if err := b.DeleteBucket([]byte("missing")); err != nil {
+			t.Errorf("DeleteBucket() of a missing bucket returned %v", err)
+		}
+
+		if got := buckets(t, b); !reflect.DeepEqual(got, []string{"a", "c"}) {
+			t.Errorf("Buckets() = %v, expected [a c]", got)
+		}
+	})
+
+	t.Run("ClearBucket", func(t *testing.T) {
+		b := newBackend(t)
+		b.Put(bucket, []byte("k"), []byte("v"))
+		b.Put([]byte("other"), []byte("k"), []byte("v"))
+
+		if err := b.ClearBucket(bucket); err != nil {
+			t.Fatalf("ClearBucket() failed: %v", err)
+		}
+		if got := collect(t, b, bucket); len(got) != 0 {
+			t.Errorf("ForEach() after ClearBucket() = %v, expected nothing", got)
+		}
+		if got := collect(t, b, []byte("other")); len(got) != 1 {
+			t.Errorf("ClearBucket() touched another bucket: %v", got)
+		}
+
+		// O bucket continua existindo, e um bucket inexistente passa a existir vazio
+		if err := b.ClearBucket([]byte("new")); err != nil {
+			t.Fatalf("ClearBucket() of a missing bucket failed: %v", err)
+		}
+		if got := buckets(t, b); !reflect.DeepEqual(got, []string{"bucket", "new", "other"}) {
+			t.Errorf("Buckets() = %v, expected [bucket new other]", got)
+		}
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		b := newBackend(t)
+
+		err := b.Update(func(tx Backend) error {
+			if err := tx.Put(bucket, []byte("a"), []byte("1")); err != nil {
+				return err
+			}
+			if err := tx.Put([]byte("meta"), []byte("count"), []byte("1")); err != nil {
+				return err
+			}
+			// Leituras dentro do Update enxergam as escritas anteriores
+			v, err := tx.Get(bucket, []byte("a"))
+			if err != nil || string(v) != "1" {
+				t.Errorf("Get() inside Update() = %q, %v; expected 1", v, err)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("Update() failed: %v", err)
+		}
+
+		if v, _ := b.Get([]byte("meta"), []byte("count")); string(v) != "1" {
+			t.Errorf("Get(meta/count) = %q, expected 1", v)
+		}
+
+		fail := errors.New("fail")
+		if err := b.Update(func(Backend) error { return fail }); !errors.Is(err, fail) {
+			t.Errorf("Update() returned %v, expected the callback error", err)
+		}
+	})
+}
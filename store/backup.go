@@ -0,0 +1,101 @@
+package store
+
+import (
+	"fmt"
+	"iter"
+)
+
+// RestoreMode define o que acontece com os dados existentes durante um restore
+type RestoreMode uint8
+
+const (
+	// RestoreMerge mantém as chaves atuais e sobrescreve as que vierem no backup
+	RestoreMerge RestoreMode = iota
+	// RestoreReplace apaga todas as chaves antes de carregar o backup
+	RestoreReplace
+)
+
+// Backup percorre as chaves com o Scan, chamando fn para cada par fora do
+// lock, assim um consumidor lento (ex.: stream gRPC) não bloqueia os writers
+// e a store não precisa caber inteira em memória.
+func (kv *KVStore) Backup(fn func(key, value string) error) error {
+	return kv.Scan(fn)
+}
+
+// RestoreFrom carrega os pares do iterador pelo caminho normal de escrita
+// (log -> memória -> banco -> raft) e retorna quantos pares foram gravados.
+// O restore não é atômico: se falhar no meio, os pares anteriores já foram aplicados.
+func (kv *KVStore) RestoreFrom(pairs iter.Seq2[string, string], mode RestoreMode) (int, error) {
+	if mode == RestoreReplace {
+		kv.mu.RLock()
+		keys := make([]string, 0, len(kv.store))
+		for k := range kv.store {
+			keys = append(keys, k)
+		}
+		kv.mu.RUnlock()
+
+		for _, key := range keys {
+			if err := kv.Delete(key); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	restored := 0
+	for key, value := range pairs {
+		if err := kv.Put(key, value); err != nil {
+			return restored, err
+		}
+		restored++
+	}
+
+	return restored, nil
+}
+
+// maxRestoreErrors limita quantos erros o CheckRestore descreve; os demais
+// só entram na contagem de Invalid
+const maxRestoreErrors = 100
+
+// RestoreReport resume os pares verificados pelo CheckRestore
+type RestoreReport struct {
+	// Pairs é quantos pares foram lidos, válidos ou não
+	Pairs int
+	// Bytes soma o tamanho das chaves e valores válidos
+	Bytes int64
+	// Duplicates conta chaves repetidas; num restore vale a última
+	Duplicates int
+	Invalid    int
+	// Errors descreve os primeiros pares inválidos, na ordem em que apareceram
+	Errors []string
+}
+
+// AddError registra um par inválido, descrevendo no máximo maxRestoreErrors
+func (r *RestoreReport) AddError(msg string) {
+	r.Invalid++
+	if len(r.Errors) < maxRestoreErrors {
+		r.Errors = append(r.Errors, msg)
+	}
+}
+
+// CheckRestore valida os pares como o RestoreFrom faria (limites de chave e
+// valor), sem alterar a store, e devolve o resumo do que seria gravado
+func (kv *KVStore) CheckRestore(pairs iter.Seq2[string, string]) RestoreReport {
+	var report RestoreReport
+	seen := make(map[string]struct{})
+
+	for key, value := range pairs {
+		report.Pairs++
+		if err := kv.limits.validate(key, value); err != nil {
+			report.AddError(fmt.Sprintf("key %.64q: %v", key, err))
+			continue
+		}
+
+		if _, dup := seen[key]; dup {
+			report.Duplicates++
+		}
+		seen[key] = struct{}{}
+		report.Bytes += int64(len(key) + len(value))
+	}
+
+	return report
+}
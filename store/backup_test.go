@@ -0,0 +1,115 @@
+package store
+
+import (
+	"maps"
+	"os"
+	"testing"
+
+	"github.com/carvalhodanielg/kvstore/internal/constants"
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestKVStore_Backup(t *testing.T) {
+	db := setupTestDB(t)
+	defer cleanupTestDB(t, db)
+	defer os.Remove("walog.ndjson")
+
+	Init(db)
+	store := NewKVStore()
+
+	testData := map[string]string{
+		"key1": "value1",
+		"key2": "value2",
+		"key3": "",
+	}
+
+	for key, value := range testData {
+		store.Put(key, value)
+	}
+
+	backup := make(map[string]string)
+	err := store.Backup(func(key, value string) error {
+		backup[key] = value
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Backup() failed: %v", err)
+	}
+
+	if !maps.Equal(backup, testData) {
+		t.Errorf("Backup() returned wrong data. Expected %v, got %v", testData, backup)
+	}
+}
+
+func TestKVStore_RestoreFrom_Merge(t *testing.T) {
+	db := setupTestDB(t)
+	defer cleanupTestDB(t, db)
+	defer os.Remove("walog.ndjson")
+
+	Init(db)
+	store := NewKVStore()
+
+	store.Put("existing", "old")
+	store.Put("key1", "old")
+
+	backup := map[string]string{
+		"key1": "value1",
+		"key2": "value2",
+	}
+
+	restored, err := store.RestoreFrom(maps.All(backup), RestoreMerge)
+	if err != nil {
+		t.Fatalf("RestoreFrom() failed: %v", err)
+	}
+
+	if restored != len(backup) {
+		t.Errorf("RestoreFrom() returned wrong count. Expected %d, got %d", len(backup), restored)
+	}
+
+	// No modo merge as chaves que não estão no backup continuam na store
+	expected := map[string]string{
+		"existing": "old",
+		"key1":     "value1",
+		"key2":     "value2",
+	}
+
+	if !maps.Equal(store.GetAll(), expected) {
+		t.Errorf("RestoreFrom() merge produced wrong state. Expected %v, got %v", expected, store.GetAll())
+	}
+}
+
+func TestKVStore_RestoreFrom_Replace(t *testing.T) {
+	db := setupTestDB(t)
+	defer cleanupTestDB(t, db)
+	defer os.Remove("walog.ndjson")
+
+	Init(db)
+	store := NewKVStore()
+
+	store.Put("existing", "old")
+
+	backup := map[string]string{
+		"key1": "value1",
+		"key2": "value2",
+	}
+
+	if _, err := store.RestoreFrom(maps.All(backup), RestoreReplace); err != nil {
+		t.Fatalf("RestoreFrom() failed: %v", err)
+	}
+
+	if !maps.Equal(store.GetAll(), backup) {
+		t.Errorf("RestoreFrom() replace produced wrong state. Expected %v, got %v", backup, store.GetAll())
+	}
+
+	// Verifica se a chave antiga também saiu do banco
+	db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(constants.BucketStore))
+		if b.Get([]byte("existing")) != nil {
+			t.Error("RestoreFrom() replace left stale key in database")
+		}
+		if string(b.Get([]byte("key1"))) != "value1" {
+			t.Error("RestoreFrom() replace failed to store key1 in database")
+		}
+		return nil
+	})
+}
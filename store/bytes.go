@@ -0,0 +1,64 @@
+package store
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"unicode/utf8"
+)
+
+// Os valores ficam em memória como string, que em Go guarda qualquer
+// sequência de bytes. O que não aceita bytes arbitrários é o JSON do WAL e dos
+// comandos do raft, que trocam UTF-8 inválido por U+FFFD; nesses dois lugares
+// um valor binário vai em base64.
+
+// PutBytes funciona como o Put para valores binários, que não precisam ser
+// UTF-8 válido. value pode ser alterado por quem chamou depois do retorno.
+func (kv *KVStore) PutBytes(key string, value []byte) error {
+	return kv.applyLocal(context.Background(), command{Op: "put", Key: key, Value: string(value)})
+}
+
+// PutBytesContext funciona como o PutContext para valores binários
+func (kv *KVStore) PutBytesContext(ctx context.Context, key string, value []byte) error {
+	return kv.applyLocal(ctx, command{Op: "put", Key: key, Value: string(value)})
+}
+
+// GetBytes retorna uma cópia do valor da chave e se ela existe
+func (kv *KVStore) GetBytes(key string) ([]byte, bool) {
+	value, ok := kv.Lookup(key)
+	if !ok {
+		return nil, false
+	}
+	return []byte(value), true
+}
+
+// setWALValue preenche o Value da entrada, cifrado se a store usar
+// WithEncryptionKey ou em base64 se ele não for UTF-8 válido
+func (kv *KVStore) setWALValue(e *WalLog, value string) {
+	e.Value, e.Encrypted = kv.walValue(value)
+	if !e.Encrypted && !utf8.ValidString(value) {
+		e.Value, e.Binary = base64.StdEncoding.EncodeToString([]byte(value)), true
+	}
+}
+
+// commandJSON é o formato do command no log do raft
+type commandJSON command
+
+// MarshalJSON manda em ValueBytes (base64) os valores que não são UTF-8 válido
+func (c command) MarshalJSON() ([]byte, error) {
+	if utf8.ValidString(c.Value) {
+		return json.Marshal(commandJSON(c))
+	}
+	c.ValueBytes, c.Value = []byte(c.Value), ""
+	return json.Marshal(commandJSON(c))
+}
+
+func (c *command) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, (*commandJSON)(c)); err != nil {
+		return err
+	}
+	if c.ValueBytes != nil {
+		c.Value, c.ValueBytes = string(c.ValueBytes), nil
+	}
+	return nil
+}
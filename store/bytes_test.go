@@ -0,0 +1,104 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/carvalhodanielg/kvstore/internal/constants"
+)
+
+func TestKVStore_PutBytes(t *testing.T) {
+	dir := t.TempDir()
+	useTempWAL(t, dir)
+
+	d, err := OpenDB(filepath.Join(dir, constants.DBFileName), constants.BucketStore, DefaultDBConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//não é UTF-8 válido e tem um byte zero no meio
+	binary := []byte{0xff, 0x00, 0xfe, 'k', 'v', 0x80, 0xc3}
+
+	// as escritas ficam só no WAL, como num crash com --batch-window
+	kv := NewKVStore(WithBackend(NewBatchBackend(NewBoltBackend(d), time.Hour, 1<<20)))
+	value := bytes.Clone(binary)
+	if err := kv.PutBytes("bin", value); err != nil {
+		t.Fatalf("PutBytes() failed: %v", err)
+	}
+	value[0] = 'x'
+	kv.Txn(context.Background(), nil, []TxnOp{{Type: TxnPut, Key: "txn", Value: string(binary)}}, nil)
+
+	got, ok := kv.GetBytes("bin")
+	if !ok || !bytes.Equal(got, binary) {
+		t.Errorf("GetBytes() = %x, %v, expected %x", got, ok, binary)
+	}
+	if _, ok := kv.GetBytes("missing"); ok {
+		t.Error("GetBytes() found a missing key")
+	}
+
+	// as leituras não esvaziam o lote: o bbolt continua sem as chaves e o
+	// replay abaixo precisa recuperar as duas do WAL
+	for _, key := range []string{"bin", "txn"} {
+		if v, err := NewBoltBackend(d).Get([]byte(constants.BucketStore), []byte(key)); err != nil || v != nil {
+			t.Fatalf("%s reached bbolt before the replay: %x, %v", key, v, err)
+		}
+	}
+
+	for _, e := range readAllLogEntries(t, filepath.Join(dir, WALFileName)) {
+		if !e.Binary {
+			t.Errorf("WAL entry not marked as binary: %+v", e)
+		}
+	}
+
+	path := d.Path()
+	d.Close()
+	d, err = OpenDB(path, constants.BucketStore, DefaultDBConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+
+	restored := NewKVStore(WithDB(d))
+	if n, err := restored.ReplayWAL(); err != nil || n != 2 {
+		t.Fatalf("ReplayWAL() = %d, %v, expected 2 entries", n, err)
+	}
+	if err := restored.LoadKeys(); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"bin", "txn"} {
+		if got, _ := restored.GetBytes(key); !bytes.Equal(got, binary) {
+			t.Errorf("%s after replay = %x, expected %x", key, got, binary)
+		}
+	}
+}
+
+func TestCommand_BinaryValueJSON(t *testing.T) {
+	binary := string([]byte{0xff, 0x00, 0xfe})
+	c := command{Op: "txn", Ops: []command{
+		{Op: "put", Key: "bin", Value: binary},
+		{Op: "put", Key: "text", Value: "ção"},
+	}}
+
+	data, err := json.Marshal(&c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got command
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Ops[0].Value != binary || got.Ops[1].Value != "ção" {
+		t.Errorf("values after round trip = %q, %q", got.Ops[0].Value, got.Ops[1].Value)
+	}
+	if got.Ops[0].ValueBytes != nil {
+		t.Error("ValueBytes should be folded back into Value")
+	}
+	//valores UTF-8 continuam legíveis no log do raft
+	if !bytes.Contains(data, []byte(`"value":"ção"`)) {
+		t.Errorf("UTF-8 value not stored as text: %s", data)
+	}
+}
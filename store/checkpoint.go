@@ -0,0 +1,391 @@
+package store
+
+import (
+	"bufio"
+	"cmp"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"slices"
+	"time"
+)
+
+// Toda escrita vai para o WAL antes do banco, então depois que o banco faz
+// fsync as entradas anteriores do WAL não são mais necessárias. O Checkpoint
+// grava no bbolt a última sequência durável e trunca o WAL até ela; o
+// ReplayWAL reaplica no banco só o que veio depois, ex.: lotes do
+// BatchBackend ou commits com NoSync perdidos num crash. Quando o banco já tem
+// parte dessas escritas, as sequências e os tombstones de cada chave
+// (tombstone.go) decidem qual operação vale.
+
+// walCheckpointKey guarda no bucket de metadados a sequência do último checkpoint
+var walCheckpointKey = []byte("wal_checkpoint")
+
+// WALSequence retorna a sequência da última entrada gravada no WAL atual
+func WALSequence() uint64 {
+	walMu.Lock()
+	defer walMu.Unlock()
+
+	loadWALSequenceLocked()
+	return walSeq
+}
+
+// WALCheckpoint retorna a sequência do último checkpoint gravado no banco,
+// 0 se nunca houve um
+func (kv *KVStore) WALCheckpoint() (uint64, error) {
+	v, err := kv.storage().Get(kv.metaBucket(), walCheckpointKey)
+	if err != nil || len(v) != 8 {
+		return 0, err
+	}
+	return decodeRevision(v), nil
+}
+
+// Checkpoint grava no banco (com fsync) tudo o que já está no WAL, registra a
+// sequência coberta e trunca o WAL até ela. As escritas esperam só enquanto o
+// banco é sincronizado, não durante a reescrita do WAL.
+func (kv *KVStore) Checkpoint() (uint64, error) {
+	kv.lockAll()
+	seq := WALSequence()
+	err := kv.storage().Put(kv.metaBucket(), walCheckpointKey, encodeRevision(seq))
+	if err == nil {
+		err = kv.Flush()
+	}
+	if err == nil {
+		err = kv.syncBackend()
+	}
+	kv.unlockAll()
+
+	if err != nil {
+		return 0, fmt.Errorf("checkpoint: %w", err)
+	}
+	if err := TruncateWAL(seq); err != nil {
+		return 0, fmt.Errorf("truncate wal: %w", err)
+	}
+	if _, err := kv.pruneSequences(seq); err != nil {
+		return 0, err
+	}
+
+	kv.logger.Debug("wal checkpoint", "sequence", seq)
+	return seq, nil
+}
+
+// syncBackend força o fsync do backend, necessário quando o bbolt foi aberto
+// com NoSync. Backends sem disco não fazem nada.
+func (kv *KVStore) syncBackend() error {
+	if s, ok := kv.storage().(interface{ Sync() error }); ok {
+		return s.Sync()
+	}
+	return nil
+}
+
+// TruncateWAL remove do log as entradas com sequência até upTo. O arquivo é
+// reescrito ao lado e trocado com rename, então um crash no meio deixa o log
+// antigo inteiro. O novo arquivo começa com uma entrada Checkpoint com a
+// sequência upTo, para a numeração continuar de onde estava mesmo que nada
+// tenha sido escrito depois.
+func TruncateWAL(upTo uint64) error {
+	walMu.Lock()
+	defer walMu.Unlock()
+
+	loadWALSequenceLocked()
+
+	in, err := os.Open(walPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	tmp := walPath + ".tmp"
+	out, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp)
+
+	w := bufio.NewWriter(out)
+	err = copyWALAfter(w, in, upTo)
+	if err == nil {
+		err = w.Flush()
+	}
+	if err == nil {
+		err = out.Sync()
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmp, walPath); err != nil {
+		return err
+	}
+	//o arquivo novo é reconhecido de novo na próxima escrita
+	walCodecPath = ""
+	return nil
+}
+
+// copyWALAfter escreve em w, no formato de r, a entrada Checkpoint e as
+// entradas de r com sequência maior que upTo, na ordem do arquivo. Um r vazio
+// é copiado no walFormat. Deve ser chamado com walMu travado.
+func copyWALAfter(w io.Writer, r io.Reader, upTo uint64) error {
+	br := bufio.NewReader(r)
+	codec, ok, err := detectWALCodec(br)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		codec = walCodecFor(walFormat)
+	}
+
+	marker, err := codec.appendEntry(codec.header(), WalLog{SequenceNumber: upTo, Operation: Checkpoint, Timestamp: time.Now().UnixNano()})
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(marker); err != nil {
+		return err
+	}
+
+	return codec.each(br, func(raw []byte, e WalLog) error {
+		if e.SequenceNumber <= upTo {
+			return nil
+		}
+		_, err := w.Write(raw)
+		return err
+	})
+}
+
+// readWALEntries é o ReadWAL, mas um arquivo inexistente é um WAL vazio
+func readWALEntries(path string) ([]WalLog, error) {
+	entries, err := ReadWAL(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	return entries, err
+}
+
+// ReadWAL lê as entradas do arquivo de WAL em path, ordenadas pela sequência,
+// nos dois formatos de WALFormat. Entradas ilegíveis (ex.: a última, cortada
+// por um crash) são ignoradas.
+func ReadWAL(path string) ([]WalLog, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []WalLog
+	err = eachWALEntry(f, func(_ []byte, e WalLog) error {
+		entries = append(entries, e)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slices.SortStableFunc(entries, func(a, b WalLog) int {
+		return cmp.Compare(a.SequenceNumber, b.SequenceNumber)
+	})
+	return entries, nil
+}
+
+// ReplayWAL reaplica no banco, em ordem de sequência, as entradas do WAL
+// posteriores ao último checkpoint e retorna quantas foram aplicadas. Deve
+// rodar na inicialização, antes do LoadKeys e do LoadRevisions, que carregam
+// o resultado em memória. Uma entrada do namespace padrão só é aplicada se
+// for mais nova que a última operação que o banco tem para a chave (escrita,
+// tombstone ou Clear), então um delete nunca é desfeito por uma escrita mais
+// antiga. Entradas gravadas antes das sequências não são reaplicadas.
+func (kv *KVStore) ReplayWAL() (int, error) {
+	checkpoint, err := kv.WALCheckpoint()
+	if err != nil {
+		return 0, err
+	}
+
+	walMu.Lock()
+	path := walPath
+	walMu.Unlock()
+
+	entries, err := readWALEntries(path)
+	if err != nil {
+		return 0, fmt.Errorf("read wal: %w", err)
+	}
+
+	var pending []WalLog
+	var maxRev uint64
+	for _, e := range entries {
+		if e.SequenceNumber > checkpoint && e.Operation != Checkpoint {
+			pending = append(pending, e)
+			maxRev = max(maxRev, e.Revision)
+		}
+	}
+	if len(pending) == 0 {
+		return 0, nil
+	}
+
+	applied := 0
+	err = kv.storage().Update(func(tx Backend) error {
+		for _, e := range pending {
+			ok, err := kv.replayEntry(tx, e)
+			if err != nil {
+				return fmt.Errorf("replay wal entry %d: %w", e.SequenceNumber, err)
+			}
+			if ok {
+				applied++
+			}
+		}
+
+		//o contador global não pode ficar abaixo das revisões reaplicadas
+		v, err := tx.Get(kv.metaBucket(), revisionCounterKey)
+		if err != nil {
+			return err
+		}
+		if len(v) != 8 || decodeRevision(v) < maxRev {
+			return tx.Put(kv.metaBucket(), revisionCounterKey, encodeRevision(maxRev))
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	if err := kv.Flush(); err != nil {
+		return 0, err
+	}
+
+	kv.logger.Info("replayed wal", "entries", applied, "skipped", len(pending)-applied, "after_sequence", checkpoint)
+	return applied, nil
+}
+
+// replayEntry aplica uma entrada do WAL direto no backend, sem passar pela
+// memória, pelos watchers ou pelo raft, e diz se ela foi aplicada. No
+// namespace padrão as entradas que o banco já tem (ou que uma operação mais
+// nova substituiu) são ignoradas.
+func (kv *KVStore) replayEntry(tx Backend, e WalLog) (bool, error) {
+	bucket := kv.bucketFor(e.Namespace)
+	key := []byte(e.Key)
+
+	if e.Namespace == "" && (e.Operation == Write || e.Operation == Delete || e.Operation == Clear) {
+		cleared, err := clearSequence(tx, kv.metaBucket())
+		if err != nil {
+			return false, err
+		}
+		if e.SequenceNumber <= cleared {
+			return false, nil
+		}
+	}
+
+	switch e.Operation {
+	case Write:
+		value, err := kv.walEntryValue(e)
+		if err != nil {
+			return false, err
+		}
+		if e.Namespace != "" {
+			return true, tx.Put(bucket, key, kv.encodeValue(value))
+		}
+		if newer, err := kv.hasNewerOperation(tx, e); err != nil || newer {
+			return false, err
+		}
+		if err := kv.indexValue(tx, e.Key, value, false); err != nil {
+			return false, err
+		}
+		var t keyTimes
+		if e.CreatedAt != 0 {
+			t = keyTimes{created: time.Unix(0, e.CreatedAt), updated: time.Unix(0, e.Timestamp)}
+		}
+		if err := tx.Put(bucket, key, kv.encodeEntry(value, e.Revision, t)); err != nil {
+			return false, err
+		}
+		if err := kv.persistSequence(tx, e.Key, e.SequenceNumber, false); err != nil {
+			return false, err
+		}
+		//com o envelope a revisão e os tempos já foram junto com o valor
+		if e.Revision == 0 || kv.valueEnvelope {
+			return true, nil
+		}
+		if err := tx.Put(kv.revisionsBucket(), key, encodeRevision(e.Revision)); err != nil {
+			return false, err
+		}
+		if e.CreatedAt == 0 {
+			return true, nil
+		}
+		return true, tx.Put(kv.timesBucket(), key, encodeTimes(t))
+	case Delete:
+		if e.Namespace != "" {
+			return true, tx.Delete(bucket, key)
+		}
+		if newer, err := kv.hasNewerOperation(tx, e); err != nil || newer {
+			return false, err
+		}
+		return true, kv.replayDelete(tx, e.Key, e.SequenceNumber)
+	case Clear:
+		if e.Namespace != "" {
+			return true, tx.ClearBucket(bucket)
+		}
+		return true, kv.replayClear(tx, e.SequenceNumber)
+	case DropNamespace:
+		return true, tx.DeleteBucket(bucket)
+	default:
+		return false, nil
+	}
+}
+
+// hasNewerOperation diz se o banco já tem para a chave uma operação com
+// sequência igual ou maior que a da entrada
+func (kv *KVStore) hasNewerOperation(tx Backend, e WalLog) (bool, error) {
+	seq, err := kv.keySequence(tx, e.Key)
+	if err != nil {
+		return false, err
+	}
+	return seq >= e.SequenceNumber, nil
+}
+
+// replayDelete apaga a chave e deixa o tombstone com a sequência do delete
+func (kv *KVStore) replayDelete(tx Backend, key string, seq uint64) error {
+	if err := kv.indexValue(tx, key, "", true); err != nil {
+		return err
+	}
+	for _, bucket := range [][]byte{kv.bucket, kv.revisionsBucket(), kv.timesBucket()} {
+		if err := tx.Delete(bucket, []byte(key)); err != nil {
+			return err
+		}
+	}
+	return kv.persistSequence(tx, key, seq, true)
+}
+
+// replayClear apaga as chaves do namespace padrão cuja última operação é
+// anterior ao Clear; as que o banco já tem com sequência maior ficam
+func (kv *KVStore) replayClear(tx Backend, seq uint64) error {
+	var keys []string
+	err := tx.ForEach(kv.bucket, func(k, _ []byte) error {
+		keys = append(keys, string(k))
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		last, err := kv.keySequence(tx, key)
+		if err != nil {
+			return err
+		}
+		if last > seq {
+			continue
+		}
+		if err := kv.indexValue(tx, key, "", true); err != nil {
+			return err
+		}
+		for _, bucket := range [][]byte{kv.bucket, kv.revisionsBucket(), kv.timesBucket(), kv.sequencesBucket()} {
+			if err := tx.Delete(bucket, []byte(key)); err != nil {
+				return err
+			}
+		}
+	}
+	return tx.Put(kv.metaBucket(), clearSequenceKey, encodeRevision(seq))
+}
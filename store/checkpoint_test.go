@@ -0,0 +1,121 @@
+package store
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/carvalhodanielg/kvstore/internal/constants"
+)
+
+func TestKVStore_CheckpointAndReplay(t *testing.T) {
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, constants.DBFileName)
+	walFile := filepath.Join(dir, WALFileName)
+	SetWALPath(walFile)
+	defer SetWALPath(WALFileName)
+
+	d, err := OpenDB(dbPath, constants.BucketStore, DefaultDBConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// o lote só vai para o bbolt no Flush, então as escritas depois do
+	// checkpoint ficam só no WAL, como num crash com --batch-window
+	kv := NewKVStore(WithBackend(NewBatchBackend(NewBoltBackend(d), time.Hour, 1<<20)))
+	kv.Put("a", "1")
+	kv.Put("b", "2")
+
+	seq, err := kv.Checkpoint()
+	if err != nil {
+		t.Fatalf("Checkpoint() failed: %v", err)
+	}
+	if seq == 0 {
+		t.Fatal("Checkpoint() returned sequence 0 after writes")
+	}
+	if entries := readAllLogEntries(t, walFile); len(entries) != 1 || entries[0].Operation != Checkpoint || entries[0].SequenceNumber != seq {
+		t.Fatalf("expected only the checkpoint marker in the WAL, got %+v", entries)
+	}
+
+	kv.Put("c", "3")
+	kv.Put("a", "10")
+	kv.Delete("b")
+	wantRev := kv.Revision("a")
+
+	if entries := readAllLogEntries(t, walFile); len(entries) != 4 || entries[1].SequenceNumber != seq+1 {
+		t.Fatalf("expected the marker plus 3 entries continuing from %d, got %+v", seq, entries)
+	}
+
+	// crash: o lote pendente nunca chega ao bbolt
+	d.Close()
+
+	d, err = OpenDB(dbPath, constants.BucketStore, DefaultDBConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+
+	restored := NewKVStore(WithDB(d))
+	n, err := restored.ReplayWAL()
+	if err != nil {
+		t.Fatalf("ReplayWAL() failed: %v", err)
+	}
+	if n != 3 {
+		t.Errorf("ReplayWAL() applied %d entries, expected 3", n)
+	}
+	if err := restored.LoadKeys(); err != nil {
+		t.Fatal(err)
+	}
+	if err := restored.LoadRevisions(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{"a": "10", "c": "3"}
+	got := restored.GetAll()
+	if len(got) != len(want) || got["a"] != "10" || got["c"] != "3" {
+		t.Errorf("state after replay = %v, expected %v", got, want)
+	}
+	if rev := restored.Revision("a"); rev != wantRev {
+		t.Errorf("revision of a after replay = %d, expected %d", rev, wantRev)
+	}
+
+	// a próxima escrita continua acima das revisões reaplicadas
+	restored.Put("d", "4")
+	if rev := restored.Revision("d"); rev <= wantRev {
+		t.Errorf("new write got revision %d, expected above %d", rev, wantRev)
+	}
+}
+
+func TestTruncateWAL_KeepsLaterEntries(t *testing.T) {
+	walFile := filepath.Join(t.TempDir(), WALFileName)
+	SetWALPath(walFile)
+	defer SetWALPath(WALFileName)
+
+	for _, key := range []string{"a", "b", "c", "d"} {
+		LogWrite(key, "v")
+	}
+	entries := readAllLogEntries(t, walFile)
+
+	if err := TruncateWAL(entries[1].SequenceNumber); err != nil {
+		t.Fatalf("TruncateWAL() failed: %v", err)
+	}
+
+	kept := readAllLogEntries(t, walFile)
+	if len(kept) != 3 || kept[0].Operation != Checkpoint || kept[1].Key != "c" || kept[2].Key != "d" {
+		t.Fatalf("unexpected WAL after truncation: %+v", kept)
+	}
+
+	// depois de um restart a sequência continua a partir do arquivo truncado
+	walMu.Lock()
+	walSeq, walSeqPath = 0, ""
+	walMu.Unlock()
+
+	if err := TruncateWAL(entries[3].SequenceNumber); err != nil {
+		t.Fatalf("TruncateWAL() failed: %v", err)
+	}
+	LogWrite("e", "v")
+
+	if last := readLastLogEntry(t, walFile); last.SequenceNumber != entries[3].SequenceNumber+1 {
+		t.Errorf("sequence after a full truncation = %d, expected %d", last.SequenceNumber, entries[3].SequenceNumber+1)
+	}
+}
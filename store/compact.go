@@ -0,0 +1,162 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/carvalhodanielg/kvstore/internal/constants"
+	"github.com/hashicorp/raft"
+	bolt "go.etcd.io/bbolt"
+)
+
+// compactTxMaxSize é quantos bytes a cópia do Compact grava por transação,
+// para não segurar o banco inteiro em memória
+const compactTxMaxSize = 64 << 20
+
+// CompactResult traz os tamanhos em bytes antes e depois do Compact
+type CompactResult struct {
+	DBSizeBefore  int64
+	DBSizeAfter   int64
+	WALSizeBefore int64
+	WALSizeAfter  int64
+	// Snapshot indica que um snapshot do raft foi tirado
+	Snapshot bool
+}
+
+// dbCompacter é implementado pelos backends com arquivo em disco
+type dbCompacter interface {
+	Size() (int64, error)
+	Compact() (before, after int64, err error)
+}
+
+// Compact faz a manutenção do disco sem reiniciar o nó: um Checkpoint trunca
+// o WAL até o que já está no banco, um snapshot do raft descarta o log antigo
+// e, com compactDB, o bbolt é copiado para um arquivo novo sem as páginas
+// livres. Sem compactDB o tamanho do banco só é reportado. Tudo é local a
+// este nó; nada passa pelo raft.
+func (kv *KVStore) Compact(compactDB bool) (CompactResult, error) {
+	res := CompactResult{WALSizeBefore: WALSize()}
+
+	if _, err := kv.Checkpoint(); err != nil {
+		return res, err
+	}
+	res.WALSizeAfter = WALSize()
+
+	if kv.raft != nil {
+		err := kv.raft.Snapshot().Error()
+		switch {
+		case err == nil:
+			res.Snapshot = true
+		case !errors.Is(err, raft.ErrNothingNewToSnapshot):
+			return res, fmt.Errorf("raft snapshot: %w", err)
+		}
+	}
+
+	backend := kv.storage()
+	c, ok := backend.(dbCompacter)
+	if !ok {
+		return res, nil
+	}
+
+	if !compactDB {
+		size, err := c.Size()
+		res.DBSizeBefore, res.DBSizeAfter = size, size
+		return res, err
+	}
+
+	var err error
+	res.DBSizeBefore, res.DBSizeAfter, err = c.Compact()
+	if err != nil {
+		return res, err
+	}
+
+	//sem WithBackend a store usa o banco global do Init, que o Compact trocou
+	if b, ok := backend.(*BoltBackend); ok && kv.backend == nil {
+		Init(b.DB())
+	}
+
+	kv.logger.Info("compacted", "db_size_before", res.DBSizeBefore, "db_size_after", res.DBSizeAfter,
+		"wal_size_before", res.WALSizeBefore, "wal_size_after", res.WALSizeAfter)
+	return res, nil
+}
+
+// DB devolve o banco atual, que muda depois de um Compact
+func (b *BoltBackend) DB() *bolt.DB {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.db
+}
+
+// Size devolve o tamanho do arquivo do banco
+func (b *BoltBackend) Size() (int64, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return fileSize(b.db.Path())
+}
+
+// Compact copia o banco para um arquivo novo ao lado, só com as páginas em
+// uso, e troca os dois com rename. O bbolt não devolve ao sistema o espaço
+// das chaves apagadas, então a cópia é o único jeito de encolher o arquivo.
+// As outras operações esperam enquanto isso. Quem abriu o banco deve fechar
+// o DB() atual, não o original.
+func (b *BoltBackend) Compact() (before, after int64, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	path := b.db.Path()
+	if before, err = fileSize(path); err != nil {
+		return 0, 0, err
+	}
+
+	tmp := path + ".compact"
+	os.Remove(tmp)
+
+	dst, err := bolt.Open(tmp, constants.DBFilePermission, nil)
+	if err != nil {
+		return before, 0, fmt.Errorf("compact %s: %w", path, err)
+	}
+	err = bolt.Compact(dst, b.db, compactTxMaxSize)
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return before, 0, fmt.Errorf("compact %s: %w", path, err)
+	}
+
+	opts := &bolt.Options{Timeout: DefaultDBTimeout, NoSync: b.db.NoSync}
+	if err := b.db.Close(); err != nil {
+		os.Remove(tmp)
+		return before, 0, fmt.Errorf("close db %s: %w", path, err)
+	}
+
+	//o banco antigo já está fechado: se o rename falhar, reabre ele
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return before, 0, errors.Join(fmt.Errorf("replace db %s: %w", path, err), b.reopen(path, opts))
+	}
+	if err := b.reopen(path, opts); err != nil {
+		return before, 0, err
+	}
+
+	after, err = fileSize(path)
+	return before, after, err
+}
+
+func (b *BoltBackend) reopen(path string, opts *bolt.Options) error {
+	d, err := bolt.Open(path, constants.DBFilePermission, opts)
+	if err != nil {
+		return fmt.Errorf("reopen db %s: %w", path, err)
+	}
+	b.db = d
+	return nil
+}
+
+func fileSize(path string) (int64, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
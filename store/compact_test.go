@@ -0,0 +1,74 @@
+package store
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/carvalhodanielg/kvstore/internal/constants"
+)
+
+func TestKVStore_Compact(t *testing.T) {
+	dir := t.TempDir()
+	SetWALPath(filepath.Join(dir, WALFileName))
+	defer SetWALPath(WALFileName)
+
+	d, err := OpenDB(filepath.Join(dir, constants.DBFileName), constants.BucketStore, DefaultDBConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	backend := NewBoltBackend(d)
+	defer func() { backend.DB().Close() }()
+
+	// valores grandes, sem compressão, para o arquivo crescer; depois apaga
+	// quase tudo: o bbolt mantém as páginas livres no arquivo
+	kv := NewKVStore(WithBackend(backend), WithCompressionThreshold(0))
+	value := strings.Repeat("x", 4096)
+	for i := range 500 {
+		kv.Put(fmt.Sprintf("key%03d", i), value)
+	}
+	for i := 10; i < 500; i++ {
+		kv.Delete(fmt.Sprintf("key%03d", i))
+	}
+
+	// sem compactDB o banco só é medido
+	res, err := kv.Compact(false)
+	if err != nil {
+		t.Fatalf("Compact(false) failed: %v", err)
+	}
+	if res.DBSizeBefore == 0 || res.DBSizeBefore != res.DBSizeAfter {
+		t.Errorf("expected an unchanged, non-zero db size without compactDB, got %+v", res)
+	}
+	if res.WALSizeBefore == 0 || res.WALSizeAfter >= res.WALSizeBefore {
+		t.Errorf("expected the WAL to shrink, got %+v", res)
+	}
+
+	res, err = kv.Compact(true)
+	if err != nil {
+		t.Fatalf("Compact(true) failed: %v", err)
+	}
+	if res.DBSizeAfter == 0 || res.DBSizeAfter >= res.DBSizeBefore {
+		t.Errorf("expected the db to shrink, got %+v", res)
+	}
+	if res.Snapshot {
+		t.Error("reported a raft snapshot without raft")
+	}
+
+	// o banco novo continua em uso pela store e tem as chaves que sobraram
+	kv.Put("after", "compact")
+
+	restored := NewKVStore(WithBackend(backend))
+	if err := restored.LoadKeys(); err != nil {
+		t.Fatalf("LoadKeys() failed: %v", err)
+	}
+	if n := restored.Count(); n != 11 {
+		t.Errorf("expected 11 keys after compaction, got %d", n)
+	}
+	if v := restored.Get("key009"); v != value {
+		t.Errorf("key009 lost its value after compaction, got %d bytes", len(v))
+	}
+	if v := restored.Get("after"); v != "compact" {
+		t.Errorf("write after compaction not persisted, got %q", v)
+	}
+}
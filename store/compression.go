@@ -0,0 +1,62 @@
+package store
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+)
+
+// DefaultCompressionThreshold é o tamanho a partir do qual os valores são
+// comprimidos antes de ir para o bbolt
+const DefaultCompressionThreshold = 1024
+
+// compressedMarker prefixa os valores comprimidos no bbolt. Valores gravados
+// antes da compressão não têm o marcador e continuam sendo lidos como estão.
+const compressedMarker byte = 0x00
+
+// encodeValue comprime o valor com gzip se ele passar do threshold e se a
+// compressão realmente diminuir o tamanho. threshold <= 0 desliga a compressão.
+func encodeValue(value string, threshold int) []byte {
+	if threshold <= 0 || len(value) <= threshold {
+		return []byte(value)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte(compressedMarker)
+
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write([]byte(value)); err != nil {
+		return []byte(value)
+	}
+	if err := zw.Close(); err != nil {
+		return []byte(value)
+	}
+
+	//valores incompressíveis ficam crus
+	if buf.Len() >= len(value) {
+		return []byte(value)
+	}
+
+	return buf.Bytes()
+}
+
+// decodeValue desfaz o encodeValue. Se o valor não tiver o marcador ou não
+// for um gzip válido, ele é devolvido sem alteração.
+func decodeValue(value string) string {
+	if len(value) < 2 || value[0] != compressedMarker {
+		return value
+	}
+
+	zr, err := gzip.NewReader(bytes.NewReader([]byte(value[1:])))
+	if err != nil {
+		return value
+	}
+	defer zr.Close()
+
+	raw, err := io.ReadAll(zr)
+	if err != nil {
+		return value
+	}
+
+	return string(raw)
+}
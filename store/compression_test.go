@@ -0,0 +1,131 @@
+package store
+
+import (
+	"crypto/rand"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/carvalhodanielg/kvstore/internal/constants"
+	bolt "go.etcd.io/bbolt"
+)
+
+func randomValue(t testing.TB, n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		t.Fatalf("failed to generate random value: %v", err)
+	}
+	return string(b)
+}
+
+// storedValue lê o valor como ele está gravado no bbolt
+func storedValue(t testing.TB, db *bolt.DB, key string) []byte {
+	var value []byte
+	db.View(func(tx *bolt.Tx) error {
+		value = append([]byte(nil), tx.Bucket([]byte(constants.BucketStore)).Get([]byte(key))...)
+		return nil
+	})
+	return value
+}
+
+func TestCompression_RoundTrip(t *testing.T) {
+	db := setupTestDB(t)
+	defer cleanupTestDB(t, db)
+	defer os.Remove("walog.ndjson")
+
+	Init(db)
+
+	tests := []struct {
+		name           string
+		value          string
+		wantCompressed bool
+	}{
+		{"small", "small value", false},
+		{"compressible", strings.Repeat("compressible ", 1000), true},
+		{"incompressible", randomValue(t, 4096), false},
+		{"empty", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kv := NewKVStore()
+			kv.Put(tt.name, tt.value)
+
+			stored := storedValue(t, db, tt.name)
+			compressed := len(stored) > 0 && stored[0] == compressedMarker
+			if compressed != tt.wantCompressed {
+				t.Errorf("value compressed = %v, expected %v", compressed, tt.wantCompressed)
+			}
+			if tt.wantCompressed && len(stored) >= len(tt.value) {
+				t.Errorf("compressed value is not smaller: %d >= %d bytes", len(stored), len(tt.value))
+			}
+
+			// Get lê da memória e deve devolver o valor original
+			if value := kv.Get(tt.name); value != tt.value {
+				t.Errorf("Get() returned wrong value after Put()")
+			}
+
+			// Simula um restart carregando do bbolt
+			restarted := NewKVStore()
+			restarted.PutFromDb(tt.name, string(stored))
+			if value := restarted.Get(tt.name); value != tt.value {
+				t.Errorf("Get() returned wrong value after reload from db")
+			}
+		})
+	}
+}
+
+func TestCompression_Disabled(t *testing.T) {
+	db := setupTestDB(t)
+	defer cleanupTestDB(t, db)
+	defer os.Remove("walog.ndjson")
+
+	Init(db)
+	kv := NewKVStore(WithCompressionThreshold(0))
+
+	value := strings.Repeat("a", 4096)
+	kv.Put("key", value)
+
+	if stored := storedValue(t, db, "key"); string(stored) != value {
+		t.Errorf("value was modified with compression disabled")
+	}
+}
+
+func TestDecodeValue_LegacyData(t *testing.T) {
+	// Dados gravados antes da compressão, inclusive começando com o byte
+	// do marcador, devem ser lidos como estão
+	tests := []string{
+		"plain value",
+		"\x00not gzip",
+		"\x00",
+		"",
+	}
+
+	for _, value := range tests {
+		if decoded := decodeValue(value); decoded != value {
+			t.Errorf("decodeValue(%q) = %q, expected unchanged", value, decoded)
+		}
+	}
+}
+
+func BenchmarkCompression_StoredSize(b *testing.B) {
+	values := map[string]string{
+		"text":   strings.Repeat("the quick brown fox jumps over the lazy dog ", 100),
+		"json":   strings.Repeat(`{"id":1,"name":"kvstore","tags":["a","b"]},`, 100),
+		"random": randomValue(b, 4096),
+	}
+
+	for name, value := range values {
+		for _, threshold := range []int{0, DefaultCompressionThreshold} {
+			b.Run(fmt.Sprintf("%s/threshold=%d", name, threshold), func(b *testing.B) {
+				var stored []byte
+				for i := 0; i < b.N; i++ {
+					stored = encodeValue(value, threshold)
+				}
+				b.ReportMetric(float64(len(value)), "raw-bytes")
+				b.ReportMetric(float64(len(stored)), "stored-bytes")
+			})
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package store
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/carvalhodanielg/kvstore/internal/constants"
+	bolt "go.etcd.io/bbolt"
+)
+
+// DefaultDBTimeout é quanto o OpenDB espera pelo lock do arquivo antes de desistir
+const DefaultDBTimeout = 5 * time.Second
+
+// DBConfig são as opções do bbolt expostas pela store
+type DBConfig struct {
+	// Timeout limita a espera pelo lock do arquivo, que fica preso enquanto
+	// outro processo estiver com o banco aberto. Zero espera para sempre.
+	Timeout time.Duration
+
+	// NoSync desliga o fsync a cada commit. As escritas ficam bem mais rápidas,
+	// mas um crash da máquina (não só do processo) pode perder os últimos
+	// commits ou corromper o arquivo. Só use quando os dados puderem ser
+	// reconstruídos, ex.: a partir do WAL ou do raft.
+	NoSync bool
+}
+
+// DefaultDBConfig mantém o fsync ligado e não trava para sempre num lock antigo
+func DefaultDBConfig() DBConfig {
+	return DBConfig{Timeout: DefaultDBTimeout}
+}
+
+func (c DBConfig) boltOptions() *bolt.Options {
+	return &bolt.Options{Timeout: c.Timeout, NoSync: c.NoSync}
+}
+
+// OpenDB abre (ou cria) o banco em path e garante que o bucket existe
+func OpenDB(path, bucket string, cfg DBConfig) (*bolt.DB, error) {
+	d, err := bolt.Open(path, constants.DBFilePermission, cfg.boltOptions())
+	if err != nil {
+		return nil, fmt.Errorf("open db %s: %w", path, err)
+	}
+
+	err = d.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists([]byte(bucket))
+		return err
+	})
+	if err != nil {
+		d.Close()
+		return nil, fmt.Errorf("create bucket %q: %w", bucket, err)
+	}
+
+	return d, nil
+}
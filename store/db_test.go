@@ -0,0 +1,64 @@
+package store
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/carvalhodanielg/kvstore/internal/constants"
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestOpenDB(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store.db")
+
+	d, err := OpenDB(path, constants.BucketStore, DBConfig{NoSync: true})
+	if err != nil {
+		t.Fatalf("OpenDB() failed: %v", err)
+	}
+	defer d.Close()
+
+	if !d.NoSync {
+		t.Error("Expected NoSync to be applied to the db")
+	}
+
+	err = d.View(func(tx *bolt.Tx) error {
+		if tx.Bucket([]byte(constants.BucketStore)) == nil {
+			return errors.New("bucket not found")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Errorf("Bucket not created: %v", err)
+	}
+}
+
+func TestOpenDB_LockTimeout(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store.db")
+
+	// O primeiro handle segura o lock do arquivo, como um processo que não fechou o banco
+	held, err := OpenDB(path, constants.BucketStore, DefaultDBConfig())
+	if err != nil {
+		t.Fatalf("OpenDB() failed: %v", err)
+	}
+	defer held.Close()
+
+	done := make(chan error, 1)
+	go func() {
+		d, err := OpenDB(path, constants.BucketStore, DBConfig{Timeout: 50 * time.Millisecond})
+		if d != nil {
+			d.Close()
+		}
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, bolt.ErrTimeout) {
+			t.Errorf("OpenDB() on locked file returned %v, expected %v", err, bolt.ErrTimeout)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("OpenDB() hung on a locked file")
+	}
+}
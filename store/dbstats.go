@@ -0,0 +1,118 @@
+package store
+
+import (
+	bolt "go.etcd.io/bbolt"
+)
+
+// DBStats são as métricas do banco para planejar capacidade. Os campos de
+// páginas só são preenchidos quando o backend é o bbolt.
+type DBStats struct {
+	// FileSize é o tamanho do arquivo em disco
+	FileSize int64
+	PageSize int
+	// FreePages estão livres para novas escritas; PendingPages foram liberadas
+	// mas ainda são vistas por leituras abertas
+	FreePages    int
+	PendingPages int
+	FreeBytes    int
+	OpenReadTxs  int
+
+	// Páginas e bytes do bucket das chaves do namespace padrão
+	LeafPages   int
+	BranchPages int
+	LeafInuse   int
+	BranchInuse int
+	// Utilization é a fração dos bytes alocados nas páginas do bucket que
+	// está em uso, entre 0 e 1
+	Utilization float64
+
+	// DBKeys e MemoryKeys contam as chaves do namespace padrão no banco e em
+	// memória. Elas diferem com WithMaxEntries ou depois de uma recuperação
+	// parcial.
+	DBKeys     int
+	MemoryKeys int
+}
+
+// dbStatser é implementado pelos backends que sabem reportar as métricas do bbolt
+type dbStatser interface {
+	dbStats(bucket []byte) (DBStats, error)
+}
+
+// DBStats lê as métricas do banco. No bbolt percorre as páginas do bucket,
+// então custa proporcionalmente ao tamanho da store; nos outros backends só
+// as chaves são contadas.
+func (kv *KVStore) DBStats() (DBStats, error) {
+	var (
+		stats DBStats
+		err   error
+	)
+	backend := kv.storage()
+	if s, ok := backend.(dbStatser); ok {
+		stats, err = s.dbStats(kv.bucket)
+	} else {
+		err = backend.ForEach(kv.bucket, func(_, _ []byte) error {
+			stats.DBKeys++
+			return nil
+		})
+	}
+	if err != nil {
+		return DBStats{}, err
+	}
+
+	stats.MemoryKeys = kv.memoryCount()
+	return stats, nil
+}
+
+func (b *BoltBackend) dbStats(bucket []byte) (DBStats, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	size, err := fileSize(b.db.Path())
+	if err != nil {
+		return DBStats{}, err
+	}
+
+	s := b.db.Stats()
+	stats := DBStats{
+		FileSize:     size,
+		PageSize:     b.db.Info().PageSize,
+		FreePages:    s.FreePageN,
+		PendingPages: s.PendingPageN,
+		FreeBytes:    s.FreeAlloc,
+		OpenReadTxs:  s.OpenTxN,
+	}
+
+	err = b.db.View(func(tx *bolt.Tx) error {
+		bkt := tx.Bucket(bucket)
+		if bkt == nil {
+			return nil
+		}
+		bs := bkt.Stats()
+		stats.LeafPages, stats.BranchPages = bs.LeafPageN, bs.BranchPageN
+		stats.LeafInuse, stats.BranchInuse = bs.LeafInuse, bs.BranchInuse
+		if alloc := bs.LeafAlloc + bs.BranchAlloc; alloc > 0 {
+			stats.Utilization = float64(bs.LeafInuse+bs.BranchInuse) / float64(alloc)
+		}
+		stats.DBKeys = bs.KeyN
+		return nil
+	})
+	return stats, err
+}
+
+// dbStats grava o lote pendente antes, para a contagem de chaves incluir as
+// escritas que ainda não chegaram ao banco
+func (b *BatchBackend) dbStats(bucket []byte) (DBStats, error) {
+	if err := b.Flush(); err != nil {
+		return DBStats{}, err
+	}
+	if s, ok := b.inner.(dbStatser); ok {
+		return s.dbStats(bucket)
+	}
+
+	var stats DBStats
+	err := b.inner.ForEach(bucket, func(_, _ []byte) error {
+		stats.DBKeys++
+		return nil
+	})
+	return stats, err
+}
@@ -0,0 +1,53 @@
+package store
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/carvalhodanielg/kvstore/internal/constants"
+)
+
+func TestKVStore_DBStats(t *testing.T) {
+	dir := t.TempDir()
+	useTempWAL(t, dir)
+
+	d, err := OpenDB(filepath.Join(dir, constants.DBFileName), constants.BucketStore, DefaultDBConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+
+	//o lote pendente entra na contagem, e o LRU deixa só 5 chaves em memória
+	kv := NewKVStore(WithBackend(NewBatchBackend(NewBoltBackend(d), time.Hour, 1<<20)), WithMaxEntries(5, EvictMemory))
+	for i := range 20 {
+		if err := kv.Put(fmt.Sprintf("key%02d", i), "value"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	kv.Delete("key00")
+
+	stats, err := kv.DBStats()
+	if err != nil {
+		t.Fatalf("DBStats() failed: %v", err)
+	}
+	if stats.DBKeys != 19 || stats.MemoryKeys != 5 {
+		t.Errorf("DBStats() keys = %d in the db, %d in memory, expected 19 and 5", stats.DBKeys, stats.MemoryKeys)
+	}
+	info, err := os.Stat(d.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.FileSize != info.Size() || stats.PageSize != d.Info().PageSize {
+		t.Errorf("DBStats() file size %d, page size %d, expected %d and %d", stats.FileSize, stats.PageSize, info.Size(), d.Info().PageSize)
+	}
+
+	//sem bbolt só as chaves são contadas
+	mem := NewKVStore(WithBackend(NewMemoryBackend()))
+	mem.Put("a", "1")
+	if stats, err := mem.DBStats(); err != nil || stats.DBKeys != 1 || stats.FileSize != 0 {
+		t.Errorf("DBStats() on the memory backend = %+v, %v", stats, err)
+	}
+}
@@ -0,0 +1,74 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/carvalhodanielg/kvstore/internal/constants"
+)
+
+// EmbeddedStore é a store completa (bbolt, WAL e mapa em memória) para usar
+// como biblioteca dentro de outro programa, sem listener gRPC e sem raft. Put,
+// Get, Delete, Watch etc. são os do KVStore embutido; as escritas ficam só
+// neste processo.
+type EmbeddedStore struct {
+	*KVStore
+	// bolt guarda o banco atual, que o Compact pode trocar
+	bolt *BoltBackend
+}
+
+// NewEmbeddedStore abre a store em dir, criando o diretório, o banco e o WAL
+// se ainda não existirem, e carrega o que já estava persistido. As opções são
+// as mesmas do NewKVStore; WithDB e WithBackend são ignoradas, já que o banco
+// é o de dir.
+//
+// O WAL é global no pacote, então só uma EmbeddedStore (ou servidor) deve
+// ficar aberta por processo.
+func NewEmbeddedStore(dir string, opts ...Option) (*EmbeddedStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("create data dir %s: %w", dir, err)
+	}
+
+	kv := NewKVStore(opts...)
+
+	d, err := OpenDB(filepath.Join(dir, constants.DBFileName), string(kv.bucket), DefaultDBConfig())
+	if err != nil {
+		return nil, err
+	}
+	b := NewBoltBackend(d)
+	kv.backend = b
+
+	SetWALPath(filepath.Join(dir, WALFileName))
+	OpenWAL()
+
+	e := &EmbeddedStore{KVStore: kv, bolt: b}
+	if err := e.load(); err != nil {
+		d.Close()
+		return nil, err
+	}
+	return e, nil
+}
+
+func (e *EmbeddedStore) load() error {
+	if err := e.LoadKeys(); err != nil {
+		return fmt.Errorf("load keys: %w", err)
+	}
+	if err := e.LoadNamespaces(); err != nil {
+		return fmt.Errorf("load namespaces: %w", err)
+	}
+	if err := e.LoadRevisions(); err != nil {
+		return fmt.Errorf("load revisions: %w", err)
+	}
+	return e.RebuildValueIndex()
+}
+
+// Close fecha os watchers, grava o que estiver pendente e fecha o WAL e o
+// banco. Depois disso as escritas retornam ErrClosed.
+func (e *EmbeddedStore) Close() error {
+	flushErr := e.KVStore.Close()
+	CloseWAL()
+
+	return errors.Join(flushErr, e.bolt.DB().Close())
+}
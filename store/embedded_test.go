@@ -0,0 +1,71 @@
+package store
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/carvalhodanielg/kvstore/internal/constants"
+)
+
+func TestEmbeddedStore(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "data")
+	defer SetWALPath(WALFileName)
+	// o Close fecha o WAL global, que os outros testes continuam usando
+	defer OpenWAL()
+
+	e, err := NewEmbeddedStore(dir)
+	if err != nil {
+		t.Fatalf("NewEmbeddedStore() failed: %v", err)
+	}
+
+	w := e.Watch("key1")
+	if err := e.PutContext(context.Background(), "key1", "value1"); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+
+	select {
+	case ev := <-w.Events:
+		if ev.Message != "Key key1 updated to value1" {
+			t.Errorf("unexpected event %q", ev)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("watcher was not notified")
+	}
+	e.Unwatch(w)
+
+	e.Put("key2", "value2")
+	if err := e.DeleteContext(context.Background(), "key2"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	rev := e.Revision("key1")
+
+	if err := e.Close(); err != nil {
+		t.Fatalf("Close() failed: %v", err)
+	}
+
+	for _, name := range []string{constants.DBFileName, WALFileName} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("expected %s in the data dir: %v", name, err)
+		}
+	}
+
+	// reabrir carrega do banco o que foi gravado antes
+	e, err = NewEmbeddedStore(dir)
+	if err != nil {
+		t.Fatalf("reopen failed: %v", err)
+	}
+	defer e.Close()
+
+	if v, ok := e.Lookup("key1"); !ok || v != "value1" {
+		t.Errorf("expected key1=value1 after reopen, got %q (found %v)", v, ok)
+	}
+	if _, ok := e.Lookup("key2"); ok {
+		t.Error("deleted key2 came back after reopen")
+	}
+	if got := e.Revision("key1"); got != rev {
+		t.Errorf("expected revision %d after reopen, got %d", rev, got)
+	}
+}
@@ -0,0 +1,117 @@
+package store
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+)
+
+// Com WithEncryptionKey os valores são cifrados com AES-256-GCM antes de ir
+// para o bbolt e para o WAL. No bbolt o valor cifrado é
+//
+//	encryptedMarker | encryptionVersion | nonce (12 bytes) | texto cifrado + tag
+//
+// e é aplicado depois da compressão. No WAL o mesmo formato vai em base64 no
+// Value, com Encrypted marcado. As chaves continuam em claro, assim como o log
+// e os snapshots do raft e o começo dos valores guardado pelo WithValueIndex.
+
+// encryptedMarker prefixa os valores cifrados, como o compressedMarker
+const encryptedMarker byte = 0x01
+
+// encryptionVersion identifica o formato, para poder trocar o algoritmo depois
+const encryptionVersion byte = 0x01
+
+// seal cifra o valor com um nonce aleatório no formato descrito acima
+func (kv *KVStore) seal(plain []byte) []byte {
+	out := make([]byte, 2+kv.aead.NonceSize(), 2+kv.aead.NonceSize()+len(plain)+kv.aead.Overhead())
+	out[0], out[1] = encryptedMarker, encryptionVersion
+	nonce := out[2:]
+	if _, err := rand.Read(nonce); err != nil {
+		panic(err) //crypto/rand não falha
+	}
+	return kv.aead.Seal(out, nonce, plain, nil)
+}
+
+// open desfaz o seal. ok é false se o valor não estiver no formato cifrado.
+func (kv *KVStore) open(sealed []byte) (plain []byte, ok bool, err error) {
+	n := 2 + kv.aead.NonceSize()
+	if len(sealed) < n+kv.aead.Overhead() || sealed[0] != encryptedMarker || sealed[1] != encryptionVersion {
+		return nil, false, nil
+	}
+	plain, err = kv.aead.Open(nil, sealed[2:n], sealed[n:], nil)
+	if err != nil {
+		return nil, true, fmt.Errorf("%w: %w", ErrDecrypt, err)
+	}
+	return plain, true, nil
+}
+
+// encodeValue prepara o valor para o bbolt: comprime e, com a cifragem
+// ligada, cifra
+func (kv *KVStore) encodeValue(value string) []byte {
+	raw := encodeValue(value, kv.compressionThreshold)
+	if kv.aead == nil {
+		return raw
+	}
+	return kv.seal(raw)
+}
+
+// decodeValue devolve o valor de uma chave como gravado no bbolt, sem
+// cifragem, compressão nem envelope
+func (kv *KVStore) decodeValue(value string) string {
+	e, _ := kv.decodeEntry(value)
+	return e.value()
+}
+
+// decodeRaw desfaz o encodeValue. Valores em claro (gravados antes da
+// cifragem) passam direto; um valor cifrado que não abre é devolvido como
+// está, como faz a descompressão, e fica registrado no log.
+func (kv *KVStore) decodeRaw(value string) string {
+	if kv.aead != nil {
+		plain, ok, err := kv.open([]byte(value))
+		if err != nil {
+			kv.logger.Error("failed to decrypt value", "error", err)
+			return value
+		}
+		if ok {
+			value = string(plain)
+		}
+	}
+	return decodeValue(value)
+}
+
+// walValue é o Value gravado no WAL e se ele foi cifrado
+func (kv *KVStore) walValue(value string) (string, bool) {
+	if kv.aead == nil {
+		return value, false
+	}
+	return base64.StdEncoding.EncodeToString(kv.seal([]byte(value))), true
+}
+
+// walEntryValue devolve o valor em claro de uma entrada do WAL
+func (kv *KVStore) walEntryValue(e WalLog) (string, error) {
+	if e.Binary {
+		raw, err := base64.StdEncoding.DecodeString(e.Value)
+		if err != nil {
+			return "", fmt.Errorf("entry %d: invalid binary value: %w", e.SequenceNumber, err)
+		}
+		return string(raw), nil
+	}
+	if !e.Encrypted {
+		return e.Value, nil
+	}
+	if kv.aead == nil {
+		return "", fmt.Errorf("%w: entry %d is encrypted and no key was configured", ErrDecrypt, e.SequenceNumber)
+	}
+	sealed, err := base64.StdEncoding.DecodeString(e.Value)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrDecrypt, err)
+	}
+	plain, ok, err := kv.open(sealed)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return "", fmt.Errorf("%w: entry %d has an unknown format", ErrDecrypt, e.SequenceNumber)
+	}
+	return string(plain), nil
+}
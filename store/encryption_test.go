@@ -0,0 +1,125 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/carvalhodanielg/kvstore/internal/constants"
+)
+
+func TestKVStore_Encryption_RoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	walFile := filepath.Join(dir, WALFileName)
+	useTempWAL(t, dir)
+
+	d, err := OpenDB(filepath.Join(dir, constants.DBFileName), constants.BucketStore, DefaultDBConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+
+	secret := []byte("s3cr3t")
+	plain := "credit card 4111-1111"
+	large := strings.Repeat("compressible secret ", 200)
+
+	kv := NewKVStore(WithDB(d), WithEncryptionKey(secret))
+	if err := kv.Put("card", plain); err != nil {
+		t.Fatal(err)
+	}
+	if err := kv.Put("large", large); err != nil {
+		t.Fatal(err)
+	}
+	if got := kv.Get("card"); got != plain {
+		t.Errorf("Get(card) = %q, expected %q", got, plain)
+	}
+
+	// nem o bbolt nem o WAL têm o valor em claro
+	raw, err := kv.storage().Get(kv.bucket, []byte("card"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(raw, []byte(plain)) || raw[0] != encryptedMarker || raw[1] != encryptionVersion {
+		t.Errorf("unexpected on-disk value %q", raw)
+	}
+	wal, err := os.ReadFile(walFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(wal, []byte(plain)) || bytes.Contains(wal, []byte("compressible secret")) {
+		t.Error("WAL contains a plaintext value")
+	}
+
+	// outra store com o mesmo secret lê o que foi gravado
+	reopened := NewKVStore(WithDB(d), WithEncryptionKey(secret))
+	if err := reopened.LoadKeys(); err != nil {
+		t.Fatal(err)
+	}
+	if got := reopened.Get("card"); got != plain {
+		t.Errorf("Get(card) after reload = %q, expected %q", got, plain)
+	}
+	if got := reopened.Get("large"); got != large {
+		t.Error("compressed and encrypted value did not round-trip")
+	}
+
+	// com outro secret o valor não abre
+	wrong := NewKVStore(WithDB(d), WithEncryptionKey([]byte("other")))
+	if err := wrong.LoadKeys(); err != nil {
+		t.Fatal(err)
+	}
+	if got := wrong.Get("card"); got == plain {
+		t.Error("value decrypted with the wrong key")
+	}
+}
+
+func TestKVStore_Encryption_ReplayWAL(t *testing.T) {
+	dir := t.TempDir()
+	useTempWAL(t, dir)
+
+	d, err := OpenDB(filepath.Join(dir, constants.DBFileName), constants.BucketStore, DefaultDBConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	secret := []byte("s3cr3t")
+
+	// as escritas ficam só no WAL, como num crash com --batch-window
+	kv := NewKVStore(WithBackend(NewBatchBackend(NewBoltBackend(d), time.Hour, 1<<20)), WithEncryptionKey(secret))
+	kv.Put("a", "1")
+	kv.Txn(context.Background(), nil, []TxnOp{{Type: TxnPut, Key: "b", Value: "2"}}, nil)
+
+	for _, e := range readAllLogEntries(t, filepath.Join(dir, WALFileName)) {
+		if !e.Encrypted || e.Value == "1" || e.Value == "2" {
+			t.Errorf("WAL entry not encrypted: %+v", e)
+		}
+	}
+
+	path := d.Path()
+	d.Close()
+	d, err = OpenDB(path, constants.BucketStore, DefaultDBConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+
+	// sem a chave o WAL cifrado não pode ser reaplicado
+	if _, err := NewKVStore(WithDB(d)).ReplayWAL(); !errors.Is(err, ErrDecrypt) {
+		t.Errorf("ReplayWAL() without the key returned %v, expected ErrDecrypt", err)
+	}
+
+	restored := NewKVStore(WithDB(d), WithEncryptionKey(secret))
+	if n, err := restored.ReplayWAL(); err != nil || n != 2 {
+		t.Fatalf("ReplayWAL() = %d, %v, expected 2 entries", n, err)
+	}
+	if err := restored.LoadKeys(); err != nil {
+		t.Fatal(err)
+	}
+	if got := restored.GetAll(); len(got) != 2 || got["a"] != "1" || got["b"] != "2" {
+		t.Errorf("state after replay = %v", got)
+	}
+}
@@ -0,0 +1,116 @@
+package store
+
+import (
+	"encoding/json"
+	"time"
+	"unicode/utf8"
+)
+
+// Com WithValueEnvelope os valores vão para o bbolt dentro de um envelope
+// JSON com os metadados da chave, em vez dos buckets de revisões e tempos:
+//
+//	envelopeMarker | {"v":"valor","rev":3,"ct":1700000000000000000,"ts":1700000000000000000}
+//
+// O envelope é montado antes da compressão e da cifragem, e a API continua
+// vendo só o valor. A leitura aceita os dois formatos: valores gravados antes
+// do envelope (ou com ele desligado) são lidos como estão, com os metadados
+// dos buckets, e passam para o envelope na próxima escrita da chave.
+
+// envelopeMarker prefixa os valores com envelope, como o compressedMarker
+const envelopeMarker byte = 0x02
+
+// valueEnvelope é o valor gravado no bbolt com os metadados da chave. Os
+// tempos ficam em nanossegundos; fora do namespace padrão só o valor é
+// preenchido.
+type valueEnvelope struct {
+	Value string `json:"v,omitempty"`
+	// Bytes leva um valor que não é UTF-8 válido, que o JSON estragaria
+	Bytes    []byte `json:"b,omitempty"`
+	Revision uint64 `json:"rev,omitempty"`
+	// Expires é reservado para a expiração das chaves; nenhuma escrita o
+	// preenche ainda
+	Expires int64 `json:"exp,omitempty"`
+	Created int64 `json:"ct,omitempty"`
+	Updated int64 `json:"ts,omitempty"`
+}
+
+func newEnvelope(value string, rev uint64, t keyTimes) valueEnvelope {
+	e := valueEnvelope{Revision: rev}
+	if utf8.ValidString(value) {
+		e.Value = value
+	} else {
+		e.Bytes = []byte(value)
+	}
+	if !t.updated.IsZero() {
+		e.Created, e.Updated = t.created.UnixNano(), t.updated.UnixNano()
+	}
+	return e
+}
+
+func (e valueEnvelope) value() string {
+	if e.Bytes != nil {
+		return string(e.Bytes)
+	}
+	return e.Value
+}
+
+// times devolve os tempos do envelope, zerados se ele não os tiver
+func (e valueEnvelope) times() keyTimes {
+	if e.Updated == 0 {
+		return keyTimes{}
+	}
+	return keyTimes{created: time.Unix(0, e.Created), updated: time.Unix(0, e.Updated)}
+}
+
+func encodeEnvelope(e valueEnvelope) []byte {
+	b, err := json.Marshal(e)
+	if err != nil {
+		panic(err) //só strings e inteiros, o Marshal não falha
+	}
+	return append([]byte{envelopeMarker}, b...)
+}
+
+// decodeEnvelope desfaz o encodeEnvelope. Um valor sem o marcador ou com um
+// JSON inválido volta como está, só com Value, e ok false.
+func decodeEnvelope(value string) (e valueEnvelope, ok bool) {
+	if len(value) < 2 || value[0] != envelopeMarker {
+		return valueEnvelope{Value: value}, false
+	}
+	if err := json.Unmarshal([]byte(value[1:]), &e); err != nil {
+		return valueEnvelope{Value: value}, false
+	}
+	return e, true
+}
+
+// encodeEntry é o encodeValue de uma escrita: com WithValueEnvelope o valor
+// vai junto com a revisão e os tempos da chave
+func (kv *KVStore) encodeEntry(value string, rev uint64, t keyTimes) []byte {
+	if !kv.valueEnvelope {
+		return kv.encodeValue(value)
+	}
+	return kv.encodeValue(string(encodeEnvelope(newEnvelope(value, rev, t))))
+}
+
+// decodeEntry desfaz o encodeEntry. ok indica que o valor estava num
+// envelope, e então a revisão e os tempos dele valem.
+func (kv *KVStore) decodeEntry(value string) (valueEnvelope, bool) {
+	return decodeEnvelope(kv.decodeRaw(value))
+}
+
+// entryMeta lê a revisão e os tempos da chave gravados no banco, do envelope
+// em raw ou, sem ele, dos buckets de revisões e tempos
+func (kv *KVStore) entryMeta(backend Backend, key string, raw []byte) (uint64, keyTimes) {
+	if e, ok := kv.decodeEntry(string(raw)); ok {
+		return e.Revision, e.times()
+	}
+
+	var rev uint64
+	if v, err := backend.Get(kv.revisionsBucket(), []byte(key)); err == nil && len(v) == 8 {
+		rev = decodeRevision(v)
+	}
+	var times keyTimes
+	if v, err := backend.Get(kv.timesBucket(), []byte(key)); err == nil {
+		times, _ = decodeTimes(v)
+	}
+	return rev, times
+}
@@ -0,0 +1,93 @@
+package store
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/carvalhodanielg/kvstore/internal/constants"
+)
+
+func TestValueEnvelope_RoundTrip(t *testing.T) {
+	now := time.Now()
+	tests := []valueEnvelope{
+		{Value: "v", Revision: 3, Expires: now.Add(time.Hour).UnixNano(), Created: now.UnixNano(), Updated: now.UnixNano() + 1},
+		{Value: ""},
+		newEnvelope("\xff\x00bin", 7, keyTimes{created: now, updated: now}),
+	}
+
+	for _, want := range tests {
+		got, ok := decodeEnvelope(string(encodeEnvelope(want)))
+		if !ok || got.value() != want.value() || got.Revision != want.Revision || got.Expires != want.Expires ||
+			got.Created != want.Created || got.Updated != want.Updated {
+			t.Errorf("decodeEnvelope() = %+v, %v, expected %+v", got, ok, want)
+		}
+	}
+
+	// valores sem o marcador são lidos como estão
+	for _, raw := range []string{"plain", "", "\x02not json"} {
+		if e, ok := decodeEnvelope(raw); ok || e.value() != raw {
+			t.Errorf("decodeEnvelope(%q) = %+v, %v", raw, e, ok)
+		}
+	}
+}
+
+func TestKVStore_ValueEnvelope(t *testing.T) {
+	os.Remove("walog.ndjson")
+	defer os.Remove("walog.ndjson")
+
+	backend := NewMemoryBackend()
+	bucket := []byte(constants.BucketStore)
+
+	// "old" foi gravada antes do envelope: valor cru e metadados nos buckets
+	plain := NewKVStore(WithBackend(backend))
+	plain.Put("old", "raw value")
+	oldEntry, _ := plain.LookupEntry("old")
+
+	store := NewKVStore(WithBackend(backend), WithValueEnvelope(true), WithCompressionThreshold(0))
+	store.Put("new", "v1")
+	time.Sleep(2 * time.Millisecond)
+	store.Put("new", "v2")
+	want, _ := store.LookupEntry("new")
+
+	raw, _ := backend.Get(bucket, []byte("new"))
+	e, ok := decodeEnvelope(string(raw))
+	if !ok || e.Value != "v2" || e.Revision != want.Revision || e.Updated != want.UpdatedAt.UnixNano() {
+		t.Fatalf("stored value %q, expected an envelope for %+v", raw, want)
+	}
+	if v, _ := backend.Get(store.revisionsBucket(), []byte("new")); v != nil {
+		t.Errorf("revision of an envelope key also went to the revisions bucket: %v", v)
+	}
+
+	// Simula um restart: os dois formatos voltam com os metadados
+	restarted := NewKVStore(WithBackend(backend), WithValueEnvelope(true))
+	if err := restarted.LoadKeys(); err != nil {
+		t.Fatalf("LoadKeys() failed: %v", err)
+	}
+	if err := restarted.LoadRevisions(); err != nil {
+		t.Fatalf("LoadRevisions() failed: %v", err)
+	}
+	for key, expected := range map[string]Entry{"old": oldEntry, "new": want} {
+		got, ok := restarted.LookupEntry(key)
+		if !ok || got.Value != expected.Value || got.Revision != expected.Revision ||
+			!got.CreatedAt.Equal(expected.CreatedAt) || !got.UpdatedAt.Equal(expected.UpdatedAt) {
+			t.Errorf("LookupEntry(%q) after reload = %+v, expected %+v", key, got, expected)
+		}
+	}
+
+	// O read-through também lê o envelope
+	cold := NewKVStore(WithBackend(backend))
+	if got, ok := cold.LookupEntry("new"); !ok || got.Value != "v2" || got.Revision != want.Revision {
+		t.Errorf("Read-through entry = %+v, expected %+v", got, want)
+	}
+
+	// A próxima escrita leva a chave antiga para o envelope
+	restarted.Put("old", "rewritten")
+	raw, _ = backend.Get(bucket, []byte("old"))
+	if e, ok := decodeEnvelope(string(raw)); !ok || e.Value != "rewritten" {
+		t.Errorf("stored value %q, expected an envelope after rewriting", raw)
+	}
+	if v, _ := backend.Get(store.revisionsBucket(), []byte("old")); v != nil {
+		t.Errorf("stale revision left in the revisions bucket: %v", v)
+	}
+}
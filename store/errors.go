@@ -0,0 +1,65 @@
+package store
+
+import "errors"
+
+// Erros retornados pela store. Eles podem vir embrulhados com mais contexto
+// (ex.: o tamanho do valor recusado), então compare com errors.Is.
+var (
+	// ErrNotFound é retornado pelas leituras que exigem que a chave exista,
+	// como o GetEntry
+	ErrNotFound = errors.New("key not found")
+
+	// ErrRevisionMismatch é retornado pelo PutIfVersion quando a revisão
+	// atual da chave não é a esperada
+	ErrRevisionMismatch = errors.New("revision mismatch")
+	// ErrVersionMismatch é o mesmo erro, com o nome do PutIfVersion
+	ErrVersionMismatch = ErrRevisionMismatch
+
+	// ErrReadOnly é retornado pelas escritas quando a store não aceita escritas
+	ErrReadOnly = errors.New("store is read-only")
+
+	// ErrWriteFailed embrulha a falha do backend numa escrita local. A
+	// memória e o WAL já têm a escrita, que volta ao banco no ReplayWAL;
+	// os watchers não são avisados e nada é enviado ao raft.
+	ErrWriteFailed = errors.New("local write failed")
+	// ErrReplicationFailed embrulha a falha do raft depois de uma escrita
+	// local bem-sucedida: este nó tem a escrita, mas o cluster pode não ter
+	ErrReplicationFailed = errors.New("raft replication failed")
+
+	// Erros de validação da chave e do valor, ver Limits
+	ErrEmptyKey      = errors.New("key must not be empty")
+	ErrKeyTooLarge   = errors.New("key too large")
+	ErrValueTooLarge = errors.New("value too large")
+	ErrInvalidKey    = errors.New("key contains control characters")
+
+	// ErrNotLeader é retornado por escritas feitas em um nó que não é o líder do raft
+	ErrNotLeader = errors.New("node is not the raft leader")
+	// ErrNoLeaderContact é retornado pelo Staleness em um follower que ainda
+	// não recebeu nada de um líder
+	ErrNoLeaderContact = errors.New("follower has not heard from a leader")
+
+	// ErrNoBackend é retornado quando a store não tem onde persistir: nem Init
+	// nem WithBackend/WithDB foram usados
+	ErrNoBackend = errors.New("store has no backend, call Init or use WithBackend")
+
+	ErrDefaultNamespace = errors.New("the default namespace cannot be dropped")
+
+	// ErrRaftNotOpen é retornado pelas consultas ao raft em um nó sem Open
+	ErrRaftNotOpen = errors.New("raft is not open")
+	// ErrUnknownServer é retornado pelo StepDown quando o destino não é outro
+	// servidor da configuração do raft
+	ErrUnknownServer = errors.New("target is not another server in the raft configuration")
+
+	// ErrClosed é retornado pelas escritas feitas depois do Close
+	ErrClosed = errors.New("store is closed")
+
+	// ErrDecrypt é retornado quando um valor cifrado não pode ser aberto:
+	// chave errada ou dado corrompido
+	ErrDecrypt = errors.New("cannot decrypt value")
+)
+
+// IsValidationError indica se o erro veio da validação de chave/valor
+func IsValidationError(err error) bool {
+	return errors.Is(err, ErrEmptyKey) || errors.Is(err, ErrKeyTooLarge) || errors.Is(err, ErrValueTooLarge) ||
+		errors.Is(err, ErrInvalidKey)
+}
@@ -0,0 +1,58 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestErrors_Is(t *testing.T) {
+	defer os.Remove("walog.ndjson")
+
+	kv := NewKVStore(WithBackend(NewMemoryBackend()), WithLimits(Limits{MaxKeySize: 8, MaxValueSize: 8}))
+	kv.PutContext(context.Background(), "counter", "1")
+	large := strings.Repeat("v", 9)
+
+	tests := []struct {
+		name string
+		call func() error
+		want error
+	}{
+		{"GetEntry on a missing key", func() error {
+			_, err := kv.GetEntry(context.Background(), "missing")
+			return err
+		}, ErrNotFound},
+		{"PutIfVersion with a stale revision", func() error {
+			_, err := kv.PutIfVersion("counter", "2", 99)
+			return err
+		}, ErrVersionMismatch},
+		{"Put with a large value", func() error {
+			return kv.PutContext(context.Background(), "k", large)
+		}, ErrValueTooLarge},
+		{"Namespace Put with a large key", func() error {
+			return kv.Namespace("tenant").Put("long-key-here", "v")
+		}, ErrKeyTooLarge},
+		{"Txn with an empty key", func() error {
+			_, err := kv.Txn(context.Background(), nil, []TxnOp{{Type: TxnPut, Key: "", Value: "v"}}, nil)
+			return err
+		}, ErrEmptyKey},
+		{"DropNamespace on the default namespace", func() error {
+			return kv.DropNamespace("")
+		}, ErrDefaultNamespace},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.call(); !errors.Is(err, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+
+	// o nome antigo continua valendo
+	if !errors.Is(ErrVersionMismatch, ErrRevisionMismatch) {
+		t.Error("ErrVersionMismatch should match ErrRevisionMismatch")
+	}
+}
@@ -0,0 +1,111 @@
+package store
+
+// Os hooks avisam integrações (ex.: espelhar a store em outro sistema) das
+// escritas e remoções de chaves do namespace padrão. Eles rodam na goroutine
+// da escrita, depois que ela chegou ao backend e com todos os locks da store
+// soltos, então podem ler e escrever na store. Uma escrita que falha antes do
+// backend não dispara hook; uma que falha só no raft dispara, já que continua
+// aplicada localmente. Nos followers, as escritas vindas do raft também
+// disparam. Clear e DropNamespace não chamam o OnDelete para cada chave.
+
+// mutation é uma escrita ou remoção aplicada, guardada até os locks serem soltos
+type mutation struct {
+	key     string
+	value   string
+	deleted bool
+}
+
+// OnPut registra fn para ser chamada depois de cada escrita de uma chave, por
+// Put, PutIfAbsent, PutIfVersion ou Txn
+func (kv *KVStore) OnPut(fn func(key, value string)) {
+	kv.hooksMu.Lock()
+	defer kv.hooksMu.Unlock()
+
+	kv.onPut = append(kv.onPut, fn)
+}
+
+// OnDelete registra fn para ser chamada depois que uma chave existente é
+// apagada, por Delete, Txn ou pelo despejo de WithMaxEntries com EvictDelete.
+// value é o valor que a chave tinha.
+func (kv *KVStore) OnDelete(fn func(key, value string)) {
+	kv.hooksMu.Lock()
+	defer kv.hooksMu.Unlock()
+
+	kv.onDelete = append(kv.onDelete, fn)
+}
+
+func (kv *KVStore) hasHooks() bool {
+	kv.hooksMu.RLock()
+	defer kv.hooksMu.RUnlock()
+
+	return len(kv.onPut) > 0 || len(kv.onDelete) > 0
+}
+
+// runHooks chama os hooks das mutações na ordem em que elas aconteceram. Não
+// pode ser chamado com nenhum lock da store travado.
+func (kv *KVStore) runHooks(ms []mutation) {
+	if len(ms) == 0 {
+		return
+	}
+
+	kv.hooksMu.RLock()
+	onPut, onDelete := kv.onPut, kv.onDelete
+	kv.hooksMu.RUnlock()
+
+	for _, m := range ms {
+		hooks := onPut
+		if m.deleted {
+			hooks = onDelete
+		}
+		for _, fn := range hooks {
+			fn(m.key, m.value)
+		}
+	}
+}
+
+// valueLocked devolve o valor atual da chave: da memória ou, se ela foi
+// despejada pelo LRU, do backend. Deve ser chamado com kv.mu travado.
+func (kv *KVStore) valueLocked(key string) (string, bool) {
+	if value, ok := kv.store[key]; ok {
+		return value, true
+	}
+	raw, err := kv.storage().Get(kv.bucket, []byte(key))
+	if err != nil || raw == nil {
+		return "", false
+	}
+	return kv.decodeValue(string(raw)), true
+}
+
+// txnMutationsLocked calcula as mutações de um Txn antes dele ir para o
+// backend, seguindo as operações em ordem: um delete depois de um put da mesma
+// chave remove o valor do put. Deve ser chamado com kv.mu travado.
+func (kv *KVStore) txnMutationsLocked(ops []TxnOp) []mutation {
+	//estado das chaves já tocadas pela transação; nil é uma chave apagada
+	current := make(map[string]*string)
+
+	var ms []mutation
+	for _, op := range ops {
+		if op.Type == TxnPut {
+			current[op.Key] = &op.Value
+			ms = append(ms, mutation{key: op.Key, value: op.Value})
+			continue
+		}
+
+		var (
+			old string
+			ok  bool
+		)
+		if v, seen := current[op.Key]; seen {
+			if v != nil {
+				old, ok = *v, true
+			}
+		} else {
+			old, ok = kv.valueLocked(op.Key)
+		}
+		current[op.Key] = nil
+		if ok {
+			ms = append(ms, mutation{key: op.Key, value: old, deleted: true})
+		}
+	}
+	return ms
+}
@@ -0,0 +1,66 @@
+package store
+
+import (
+	"context"
+	"os"
+	"slices"
+	"testing"
+)
+
+func TestKVStore_Hooks(t *testing.T) {
+	defer os.Remove("walog.ndjson")
+
+	store := NewKVStore(WithBackend(NewMemoryBackend()), WithMaxEntries(2, EvictDelete))
+
+	var events []string
+	store.OnPut(func(key, value string) {
+		events = append(events, "put "+key+"="+value)
+	})
+	store.OnDelete(func(key, value string) {
+		events = append(events, "delete "+key+"="+value)
+		//os hooks rodam sem os locks da store
+		if store.Get(key) != "" {
+			t.Errorf("key %s still visible in OnDelete", key)
+		}
+	})
+
+	store.Put("a", "1")
+	store.Put("b", "2")
+	store.Delete("b")
+	store.Delete("missing")
+	store.Put("b", "3")
+	//passa do limite de 2 chaves e despeja a, a menos recente
+	store.Put("c", "4")
+	store.Txn(context.Background(), nil, []TxnOp{
+		{Type: TxnPut, Key: "d", Value: "5"},
+		{Type: TxnDelete, Key: "d"},
+		{Type: TxnDelete, Key: "c"},
+	}, nil)
+	store.Namespace("other").Put("x", "ignored")
+
+	want := []string{
+		"put a=1",
+		"put b=2",
+		"delete b=2",
+		"put b=3",
+		"put c=4",
+		"delete a=1",
+		"put d=5",
+		"delete d=5",
+		"delete c=4",
+	}
+	if !slices.Equal(events, want) {
+		t.Errorf("hooks fired %q, expected %q", events, want)
+	}
+
+	//um hook pode escrever na store sem travar
+	store.OnPut(func(key, value string) {
+		if key == "source" {
+			store.Put("mirror", value)
+		}
+	})
+	store.Put("source", "v")
+	if got := store.Get("mirror"); got != "v" {
+		t.Errorf("mirror = %q, expected %q", got, "v")
+	}
+}
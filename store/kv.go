@@ -1,13 +1,20 @@
 package store
 
 import (
+	"context"
+	"crypto/cipher"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
-	"log"
+	"log/slog"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	transport "github.com/Jille/raft-grpc-transport"
@@ -20,81 +27,419 @@ import (
 )
 
 type KVWatcher struct {
-	Key    string
-	Events chan string
+	Namespace string
+	Key       string
+	Events    chan WatchEvent
+
+	// all indica um watcher do WatchAll, guardado em kv.allWatchers
+	all bool
+	// closeOnDelete fecha o watcher no delete da chave (WithCloseOnDelete)
+	closeOnDelete bool
+
+	policy OverflowPolicy
+	// queue é a fila de entrega de um watcher com OverflowBlock
+	queue *blockQueue
+	// closing é fechado no Unwatch e no Close para a goroutine de entrega
+	// (OverflowBlock) parar sem esperar a fila esvaziar
+	closing   chan struct{}
+	closeOnce sync.Once
 }
 type command struct {
-	Op    string `json:"op"`
-	Key   string `json:"key"`
-	Value string `json:"value,omitempty"`
+	Op        string `json:"op"`
+	Namespace string `json:"namespace,omitempty"`
+	Key       string `json:"key"`
+	Value     string `json:"value,omitempty"`
+	// ValueBytes leva um Value que não é UTF-8 válido (ver bytes.go)
+	ValueBytes []byte `json:"value_bytes,omitempty"`
+	// Ops são as escritas de um Txn, aplicadas juntas
+	Ops []command `json:"ops,omitempty"`
+	// Origin é o nó que aplicou a escrita localmente antes de replicá-la
+	Origin string `json:"origin,omitempty"`
 }
 
+// fromRaftKey marca o contexto das escritas aplicadas pelo FSM: elas já vieram
+// do raft e não devem ser replicadas de novo
+type fromRaftKey struct{}
+
 type KVStore struct {
-	mu       sync.RWMutex
+	mu sync.RWMutex
+	// stripes serializam as escritas de cada chave (ver stripe.go)
+	stripes [lockStripes]sync.Mutex
+
 	store    map[string]string
 	watchers map[string][]*KVWatcher
-
+	// allWatchers recebem as mudanças de todas as chaves (WatchAll)
+	allWatchers []*KVWatcher
+
+	// namespaces guarda as chaves dos namespaces além do padrão (kv.store)
+	namespaces map[string]map[string]string
+
+	// snapshot é a cópia de kv.store servida pelo GetAll, nil quando está
+	// desatualizada
+	snapshot atomic.Pointer[map[string]string]
+
+	// loading são as consultas ao backend em andamento do readThrough
+	loadMu  sync.Mutex
+	loading map[string]*loadCall
+
+	// revision é o contador global de escritas e revisions guarda a revisão
+	// da última escrita de cada chave do namespace padrão
+	revision  uint64
+	revisions map[string]uint64
+	// persistedRevision é o maior contador já gravado (persistRevisionCounter)
+	persistedRevision atomic.Uint64
+	// times guarda a criação e a última escrita das chaves do namespace padrão
+	times map[string]keyTimes
+
+	// watchBufferSize é o buffer padrão do canal de cada watcher
+	watchBufferSize int
+	// watchBlockTimeout é quanto um watcher com OverflowBlock pode ficar sem ler
+	watchBlockTimeout time.Duration
+	// droppedEvents conta os eventos descartados por buffers de watcher cheios
+	droppedEvents atomic.Uint64
+
+	// snapshotThreshold é quantas entradas aplicadas disparam um snapshot do raft
+	snapshotThreshold uint64
+	// snapshotInterval dispara um snapshot depois desse tempo se houve escritas
+	snapshotInterval time.Duration
+	// retainSnapshots é quantos snapshots do raft ficam em disco
+	retainSnapshots int
+
+	leaderObservers map[<-chan raft.Observation]*raft.Observer
+
+	// raftDir guarda logs, estado e snapshots do raft, em um subdiretório por nó.
+	// raftBind é o endereço usado pelo Open quando nenhum é informado.
 	raftDir  string
 	raftBind string
 	raft     *raft.Raft
-
-	logger *log.Logger
-	// db       *bolt.DB
+	nodeID   string
+	// raftStores são o log e o estado estável do raft, fechados no ShutdownRaft
+	raftStores []*boltdb.BoltStore
+	// bootstrap faz o Open iniciar um cluster novo com este nó quando o raft
+	// ainda não tem estado em disco
+	bootstrap bool
+
+	logger *slog.Logger
+	limits Limits
+
+	// backend e bucket permitem que cada store use seu próprio armazenamento.
+	// Sem WithDB/WithBackend a store usa o banco global definido em Init.
+	backend Backend
+	bucket  []byte
+
+	// compressionThreshold é o tamanho a partir do qual os valores são
+	// comprimidos no bbolt. Zero desliga a compressão.
+	compressionThreshold int
+	// valueEnvelope grava os metadados junto com o valor (WithValueEnvelope)
+	valueEnvelope bool
+
+	// lru limita as chaves do namespace padrão em memória; nil sem WithMaxEntries
+	lru *lru
+
+	// valueIndexPrefix é quantos bytes do valor entram no índice do
+	// FindByValuePrefix; zero desliga o índice
+	valueIndexPrefix int
+
+	// aead cifra os valores em disco; nil sem WithEncryptionKey
+	aead cipher.AEAD
+
+	// onPut e onDelete são os hooks registrados com OnPut e OnDelete
+	hooksMu  sync.RWMutex
+	onPut    []func(key, value string)
+	onDelete []func(key, value string)
+
+	// applyFn substitui o kv.raft.Apply nos testes que simulam falhas do raft
+	applyFn func(cmd []byte, timeout time.Duration) error
+
+	// closed é marcado pelo Close, com kv.mu travado para escrita
+	closed atomic.Bool
 }
 
 const (
-	// retainSnapshotCount = 2
 	raftTimeout = 10 * time.Second
+	// backoff entre as tentativas de um raft.Apply que falhou por um erro transitório
+	applyRetryMin = 10 * time.Millisecond
+	applyRetryMax = 500 * time.Millisecond
+
+	// DefaultRaftDir é onde o raft guarda seus arquivos se WithRaftDir não for usado
+	DefaultRaftDir = "./data"
 )
 
 var db *bolt.DB
 
+// errStopIteration interrompe um ForEach do backend quando o callback pede para parar
+var errStopIteration = errors.New("stop iteration")
+
 func Init(d *bolt.DB) {
 	db = d
 }
 
-func NewKVStore() *KVStore {
-	return &KVStore{
+func NewKVStore(opts ...Option) *KVStore {
+	kv := &KVStore{
 		store:    make(map[string]string),
 		watchers: make(map[string][]*KVWatcher),
-		logger:   log.New(os.Stderr, "[store]", log.LstdFlags),
+		logger:   slog.Default().With("component", "store"),
+		limits:   DefaultLimits(),
+		bucket:   []byte(constants.BucketStore),
+		raftDir:  DefaultRaftDir,
+
+		revisions:            make(map[string]uint64),
+		times:                make(map[string]keyTimes),
+		compressionThreshold: DefaultCompressionThreshold,
+		watchBufferSize:      DefaultWatchBufferSize,
+		watchBlockTimeout:    DefaultWatchBlockTimeout,
+		bootstrap:            true,
+		snapshotThreshold:    DefaultSnapshotThreshold,
+		retainSnapshots:      DefaultRetainSnapshotCount,
+	}
+
+	for _, opt := range opts {
+		opt(kv)
+	}
+
+	return kv
+}
+
+// storage retorna o backend da store ou, se nenhum foi passado, o banco de Init
+func (kv *KVStore) storage() Backend {
+	if kv.backend != nil {
+		return kv.backend
+	}
+	if db == nil {
+		return noBackend{}
 	}
+	return NewBoltBackend(db)
 }
 
+// GetAll retorna um snapshot imutável do namespace padrão. O snapshot é
+// copiado uma vez sob o read lock e reaproveitado até a próxima escrita,
+// então quem serializa a resposta não segura o lock e não vê escritas
+// concorrentes. O mapa retornado é compartilhado e não deve ser alterado.
 func (kv *KVStore) GetAll() map[string]string {
+	if snap := kv.snapshot.Load(); snap != nil {
+		return *snap
+	}
+
 	kv.mu.RLock()
 	defer kv.mu.RUnlock()
 
-	return kv.store
+	//outro GetAll pode ter criado o snapshot enquanto esperávamos o lock
+	if snap := kv.snapshot.Load(); snap != nil {
+		return *snap
+	}
+
+	snap := maps.Clone(kv.store)
+	if snap == nil {
+		snap = make(map[string]string)
+	}
+	kv.snapshot.Store(&snap)
 
+	return snap
 }
 
-func (kv *KVStore) Delete(key string) interface{} {
-	kv.mu.Lock()
-	defer kv.mu.Unlock()
+// KeyValue é um par chave e valor do GetAllSorted
+type KeyValue struct {
+	Key   string
+	Value string
+}
+
+// GetAllSorted funciona como o GetAll, mas retorna os pares em ordem
+// lexicográfica de chave, a mesma em toda chamada. A ordenação é feita sobre o
+// snapshot do GetAll, sem segurar o lock da store.
+func (kv *KVStore) GetAllSorted() []KeyValue {
+	all := kv.GetAll()
+
+	pairs := make([]KeyValue, 0, len(all))
+	for _, key := range slices.Sorted(maps.Keys(all)) {
+		pairs = append(pairs, KeyValue{Key: key, Value: all[key]})
+	}
+	return pairs
+}
+
+// invalidateSnapshot descarta o snapshot do GetAll. Deve ser chamado com
+// kv.mu travado para escrita, depois de alterar kv.store.
+func (kv *KVStore) invalidateSnapshot() {
+	kv.snapshot.Store(nil)
+}
+
+// Delete apaga a chave. O erro pode ser ErrWriteFailed (o backend recusou a
+// escrita), ErrReplicationFailed ou ErrNotLeader (o raft não confirmou).
+func (kv *KVStore) Delete(key string) error {
+	return kv.applyLocal(context.Background(), command{Op: "del", Key: key})
+}
+
+// DeleteContext funciona como o Delete, mas desiste antes de tocar no WAL
+// se o contexto já tiver sido cancelado.
+func (kv *KVStore) DeleteContext(ctx context.Context, key string) error {
+	return kv.applyLocal(ctx, command{Op: "del", Key: key})
+}
+
+func (kv *KVStore) delete(ctx context.Context, ns, key string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	//os hooks rodam depois de soltar o stripe
+	var applied []mutation
+	defer func() { kv.runHooks(applied) }()
 
-	//log -> memoria -> db
-	LogDelete(key)
-	delete(kv.store, key)
-	db.Update(func(tx *bolt.Tx) error {
-		b := tx.Bucket([]byte(constants.BucketStore))
-		err := b.Delete([]byte(key))
+	s := kv.stripe(ns, key)
+	s.Lock()
+	defer s.Unlock()
+
+	//o lock pode ter demorado, confere de novo antes de escrever
+	if err := ctx.Err(); err != nil {
 		return err
+	}
+
+	kv.mu.Lock()
+	if kv.closed.Load() {
+		kv.mu.Unlock()
+		return ErrClosed
+	}
+
+	var (
+		rev     uint64
+		removed []mutation
+	)
+	if ns == "" {
+		rev = kv.nextRevision()
+		if kv.hasHooks() {
+			if old, ok := kv.valueLocked(key); ok {
+				removed = []mutation{{key: key, value: old, deleted: true}}
+			}
+		}
+	}
+
+	//log -> db -> memória: a memória só muda se o banco aceitar o delete
+	seq := LogDeleteNamespace(ns, key, rev)
+
+	kv.unlockForIO()
+	err := kv.storage().Update(func(tx Backend) error {
+		if ns == "" {
+			if err := kv.indexValue(tx, key, "", true); err != nil {
+				return err
+			}
+		}
+		if err := tx.Delete(kv.bucketFor(ns), []byte(key)); err != nil {
+			return err
+		}
+		if ns != "" {
+			return nil
+		}
+		if err := kv.persistSequence(tx, key, seq, true); err != nil {
+			return err
+		}
+		return kv.persistRevision(tx, key, rev, keyTimes{}, true)
 	})
+	kv.lockAfterIO()
+	if err != nil {
+		kv.logUndo(ns, key)
+		kv.mu.Unlock()
+		return fmt.Errorf("%w: delete %q: %w", ErrWriteFailed, key, err)
+	}
+	if data := kv.data(ns, false); data != nil {
+		delete(data, key)
+	}
+	if ns == "" {
+		delete(kv.revisions, key)
+		delete(kv.times, key)
+		kv.untrackLocked(key)
+		kv.invalidateSnapshot()
+	}
+	applied = removed
+	e := deleteEvent(ns, key, rev)
+	kv.notifyDeleteLocked(e)
+	kv.notifyAllLocked(e)
+	kv.mu.Unlock()
+
 	c := &command{
-		Op:    "del",
-		Key:   key,
-		Value: "",
+		Op:        "del",
+		Namespace: ns,
+		Key:       key,
+		Value:     "",
 	}
 
-	b, err := json.Marshal(c)
+	return kv.replicate(ctx, c)
+
+}
+
+// Clear apaga todas as chaves do namespace padrão: limpa o mapa em memória,
+// recria o bucket vazio numa única transação e avisa os watchers.
+func (kv *KVStore) Clear() error {
+	return kv.applyLocal(context.Background(), command{Op: "clear"})
+}
+
+func (kv *KVStore) clear(ctx context.Context, ns string) error {
+	kv.lockAll()
+	defer kv.unlockAll()
+
+	if kv.closed.Load() {
+		return ErrClosed
+	}
+
+	watched := kv.watchedKeysLocked(ns)
+
+	seq := LogClearNamespace(ns)
+	if ns == "" {
+		kv.store = make(map[string]string)
+		kv.revisions = make(map[string]uint64)
+		kv.times = make(map[string]keyTimes)
+		kv.nextRevision()
+		kv.invalidateSnapshot()
+		if kv.lru != nil {
+			kv.lru.reset()
+		}
+	} else {
+		delete(kv.namespaces, ns)
+	}
+
+	err := kv.storage().Update(func(tx Backend) error {
+		names := [][]byte{kv.bucketFor(ns)}
+		if ns == "" {
+			names = append(names, kv.revisionsBucket(), kv.timesBucket(), kv.sequencesBucket(), kv.tombstonesBucket(), kv.valueIndexBucket())
+		}
+		for _, name := range names {
+			if err := tx.ClearBucket(name); err != nil {
+				return err
+			}
+		}
+		if ns != "" {
+			return nil
+		}
+		if seq != 0 {
+			if err := tx.Put(kv.metaBucket(), clearSequenceKey, encodeRevision(seq)); err != nil {
+				return err
+			}
+		}
+		return kv.persistRevisionCounter(tx, kv.revision)
+	})
 	if err != nil {
 		return err
 	}
 
-	f := kv.raft.Apply(b, raftTimeout)
-	return f.Error()
+	//cada watcher de uma chave que existia recebe o mesmo delete do Delete;
+	//os do WatchAll recebem um evento só para o Clear inteiro
+	var rev uint64
+	if ns == "" {
+		rev = kv.revision
+	}
+	for _, key := range watched {
+		kv.notifyDeleteLocked(deleteEvent(ns, key, rev))
+	}
+	kv.notifyAllLocked(clearEvent(ns, rev))
+
+	return kv.replicate(ctx, &command{Op: "clear", Namespace: ns})
+}
 
+// LoadKeys recarrega em memória as chaves do namespace padrão gravadas no
+// backend. Um bucket inexistente (ex.: banco criado por outra ferramenta)
+// carrega nada, sem erro.
+func (kv *KVStore) LoadKeys() error {
+	return kv.storage().ForEach(kv.bucket, func(k, v []byte) error {
+		kv.PutFromDb(string(k), string(v))
+		return nil
+	})
 }
 
 // Function that put data in memory after restart. It does not write to log or db
@@ -106,157 +451,718 @@ func (kv *KVStore) PutFromDb(key, value string) {
 		kv.store = make(map[string]string)
 	}
 
-	//escreve apenas em memória
-	kv.store[key] = value
+	//escreve apenas em memória. O valor vem do bbolt e pode estar comprimido
+	kv.store[key] = kv.decodeValue(value)
+	kv.invalidateSnapshot()
+	kv.trackLocked(key, true)
 
 }
 
-func (kv *KVStore) Put(key, value string) interface{} {
-	kv.mu.Lock()
-	defer kv.mu.Unlock()
+// Put grava a chave. Além dos erros de validação, pode devolver
+// ErrWriteFailed (o backend recusou a escrita), ErrReplicationFailed ou
+// ErrNotLeader (o raft não confirmou).
+func (kv *KVStore) Put(key, value string) error {
+	return kv.applyLocal(context.Background(), command{Op: "put", Key: key, Value: value})
+}
 
-	if kv.store == nil {
-		kv.store = make(map[string]string)
+// PutContext funciona como o Put, mas desiste antes de tocar no WAL se o
+// contexto já tiver sido cancelado. Depois que a escrita local começa ela vai
+// até o fim; só a espera pelo raft fica limitada ao deadline do contexto.
+func (kv *KVStore) PutContext(ctx context.Context, key, value string) error {
+	return kv.applyLocal(ctx, command{Op: "put", Key: key, Value: value})
+}
+
+func (kv *KVStore) put(ctx context.Context, ns, key, value string) error {
+	if err := kv.limits.validate(key, value); err != nil {
+		return err
 	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	//os hooks rodam depois de soltar o stripe
+	var applied []mutation
+	defer func() { kv.runHooks(applied) }()
 
-	//escreve no log -> memória -> banco
-	LogWrite(key, value)
-	kv.store[key] = value
+	s := kv.stripe(ns, key)
+	s.Lock()
+	defer s.Unlock()
 
-	db.Update(func(tx *bolt.Tx) error {
-		b := tx.Bucket([]byte(constants.BucketStore))
-		err := b.Put([]byte(key), []byte(value))
+	//o lock pode ter demorado, confere de novo antes de escrever
+	if err := ctx.Err(); err != nil {
 		return err
-	})
+	}
 
-	if wlist, ok := kv.watchers[key]; ok {
+	kv.mu.Lock()
+	return kv.putLocked(ctx, ns, key, value, &applied)
+}
 
-		for _, w := range wlist {
-			select {
-			case w.Events <- fmt.Sprintf("Key %s updated to %s", key, value):
-			default:
-				fmt.Printf("Envio não foi feito pro canal")
+// PutIfAbsent grava a chave apenas se ela ainda não existir e informa se a
+// escrita aconteceu. Uma chave com valor vazio conta como existente.
+func (kv *KVStore) PutIfAbsent(key, value string) (bool, error) {
+	if err := kv.limits.validate(key, value); err != nil {
+		return false, err
+	}
+	if err := kv.checkLeader(context.Background()); err != nil {
+		return false, err
+	}
+
+	var applied []mutation
+	defer func() { kv.runHooks(applied) }()
+
+	s := kv.stripe("", key)
+	s.Lock()
+	defer s.Unlock()
+
+	kv.mu.Lock()
+	if kv.closed.Load() {
+		kv.mu.Unlock()
+		return false, ErrClosed
+	}
+	if _, ok := kv.valueLocked(key); ok {
+		kv.mu.Unlock()
+		return false, nil
+	}
+
+	if err := kv.putLocked(context.Background(), "", key, value, &applied); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// putLocked faz a escrita com o stripe da chave e kv.mu travados. kv.mu é
+// solto durante a escrita no banco (ver unlockForIO) e antes do raft, então
+// ele já está livre quando putLocked retorna. As mutações que chegaram ao
+// banco vão para applied, para quem chamou rodar os hooks sem o stripe.
+func (kv *KVStore) putLocked(ctx context.Context, ns, key, value string, applied *[]mutation) error {
+	if kv.closed.Load() {
+		kv.mu.Unlock()
+		return ErrClosed
+	}
+
+	var (
+		rev   uint64
+		times keyTimes
+	)
+	if ns == "" {
+		rev = kv.nextRevision()
+		times = kv.stamp(key, time.Now())
+	}
+
+	//escreve no log -> banco -> memória: a memória só muda se o banco aceitar
+	seq := kv.logWrite(ns, key, value, rev, times)
+
+	kv.unlockForIO()
+	err := kv.storage().Update(func(tx Backend) error {
+		if ns == "" {
+			if err := kv.indexValue(tx, key, value, false); err != nil {
+				return err
 			}
 		}
+		if err := tx.Put(kv.bucketFor(ns), []byte(key), kv.encodeEntry(value, rev, times)); err != nil {
+			return err
+		}
+		if ns != "" {
+			return nil
+		}
+		if err := kv.persistSequence(tx, key, seq, false); err != nil {
+			return err
+		}
+		return kv.persistRevision(tx, key, rev, times, false)
+	})
+	kv.lockAfterIO()
+	if err != nil {
+		kv.logUndo(ns, key)
+		kv.mu.Unlock()
+		return fmt.Errorf("%w: put %q: %w", ErrWriteFailed, key, err)
+	}
+	kv.data(ns, true)[key] = value
+	if ns == "" {
+		kv.revisions[key] = rev
+		kv.times[key] = times
+		kv.invalidateSnapshot()
+		evicted := kv.trackLocked(key, false)
+		if kv.hasHooks() {
+			*applied = append(append(*applied, mutation{key: key, value: value}), evicted...)
+		}
 	}
 
-	fmt.Printf("[PUT] key=%s, value=%s\n", key, value)
+	kv.notifyLocked(updateEvent(ns, key, value, rev))
+	kv.mu.Unlock()
+
+	kv.logger.Debug("put", "namespace", ns, "key", key, "value", value)
 
 	c := &command{
-		Op:    "put",
-		Key:   key,
-		Value: value,
+		Op:        "put",
+		Namespace: ns,
+		Key:       key,
+		Value:     value,
+	}
+
+	return kv.replicate(ctx, c)
+}
+
+// logUndo grava no WAL o estado da chave de antes de uma escrita que o banco
+// recusou, para o replay não aplicar a entrada que já foi para o log. Deve
+// ser chamado com o stripe da chave e kv.mu travados; a memória e o banco
+// ainda têm o estado anterior.
+func (kv *KVStore) logUndo(ns, key string) {
+	var (
+		value string
+		ok    bool
+	)
+	if ns == "" {
+		value, ok = kv.valueLocked(key)
+	} else {
+		value, ok = kv.data(ns, false)[key]
+	}
+	if !ok {
+		LogDeleteNamespace(ns, key, 0)
+		return
+	}
+	kv.logWrite(ns, key, value, kv.revisions[key], kv.times[key])
+}
+
+// checkLeader recusa com ErrNotLeader uma escrita local feita num follower,
+// antes dela tocar no WAL, na memória ou no banco: o raft só recusaria depois,
+// e não há como desfazer a escrita local. As escritas aplicadas pelo FSM e as
+// de um nó sem raft passam direto.
+func (kv *KVStore) checkLeader(ctx context.Context) error {
+	if kv.raft == nil || ctx.Value(fromRaftKey{}) != nil {
+		return nil
+	}
+	if kv.raft.State() != raft.Leader {
+		return ErrNotLeader
+	}
+	return nil
+}
+
+// replicate envia o comando para o raft. Se o raft não foi aberto (ex.: testes
+// ou nó standalone), a escrita fica apenas local.
+func (kv *KVStore) replicate(ctx context.Context, c *command) error {
+	if kv.raft == nil || ctx.Value(fromRaftKey{}) != nil {
+		return nil
 	}
+	c.Origin = kv.nodeID
 
 	b, err := json.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrReplicationFailed, err)
+	}
+
+	timeout, err := raftTimeoutFor(ctx)
 	if err != nil {
 		return err
 	}
 
-	f := kv.raft.Apply(b, raftTimeout)
-	return f.Error()
+	//as tentativas dividem o mesmo prazo; uma troca de líder rápida não
+	//derruba a escrita, mas o raft continua limitado a raftTimeout
+	deadline := time.Now().Add(timeout)
+	backoff := applyRetryMin
+	for attempt := 1; ; attempt++ {
+		err = kv.apply(b, time.Until(deadline))
+		if err == nil {
+			return nil
+		}
+		if errors.Is(err, raft.ErrNotLeader) {
+			return ErrNotLeader
+		}
+		if !retryableRaftError(err) || time.Until(deadline) <= backoff {
+			return fmt.Errorf("%w: %w", ErrReplicationFailed, err)
+		}
+
+		kv.logger.Warn("raft apply failed, retrying", "op", c.Op, "attempt", attempt, "backoff", backoff, "error", err)
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return fmt.Errorf("%w: %w", ErrReplicationFailed, err)
+		}
+		backoff = min(backoff*2, applyRetryMax)
+	}
+}
+
+// apply envia o comando ao raft e espera o resultado
+func (kv *KVStore) apply(b []byte, timeout time.Duration) error {
+	if kv.applyFn != nil {
+		return kv.applyFn(b, timeout)
+	}
+	return kv.raft.Apply(b, timeout).Error()
+}
+
+// retryableRaftError indica os erros do Apply que passam sozinhos: a fila do
+// raft cheia, uma transferência de liderança em andamento ou a liderança
+// perdida no meio da escrita (se o nó voltar a ser líder). Reenviar um
+// comando que já foi aplicado não muda o resultado, já que put, del, clear e
+// txn gravam valores absolutos.
+func retryableRaftError(err error) bool {
+	return errors.Is(err, raft.ErrEnqueueTimeout) || errors.Is(err, raft.ErrLeadershipLost) ||
+		errors.Is(err, raft.ErrLeadershipTransferInProgress)
 }
 
+// raftTimeoutFor devolve o menor entre raftTimeout e o que resta do deadline do contexto
+func raftTimeoutFor(ctx context.Context) (time.Duration, error) {
+	timeout := raftTimeout
+	if deadline, ok := ctx.Deadline(); ok {
+		if remaining := time.Until(deadline); remaining < timeout {
+			timeout = remaining
+		}
+	}
+	if timeout <= 0 {
+		return 0, context.DeadlineExceeded
+	}
+	return timeout, nil
+}
+
+// ReadIndex prepara uma leitura linearizável: confirma com o quorum que este
+// nó ainda é o líder e espera o FSM aplicar tudo que já foi confirmado, então
+// uma leitura feita em seguida enxerga toda escrita concluída antes da chamada.
+// Em um follower (ou líder deposto) retorna ErrNotLeader em vez de dados
+// antigos. Sem raft a memória local já é a fonte da verdade.
+func (kv *KVStore) ReadIndex(ctx context.Context) error {
+	if kv.raft == nil {
+		return ctx.Err()
+	}
+
+	timeout, err := raftTimeoutFor(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := waitFuture(ctx, kv.raft.VerifyLeader()); err != nil {
+		if errors.Is(err, raft.ErrNotLeader) || errors.Is(err, raft.ErrLeadershipLost) {
+			return ErrNotLeader
+		}
+		return err
+	}
+
+	if err := waitFuture(ctx, kv.raft.Barrier(timeout)); err != nil {
+		if errors.Is(err, raft.ErrNotLeader) || errors.Is(err, raft.ErrLeadershipLost) {
+			return ErrNotLeader
+		}
+		return err
+	}
+	return nil
+}
+
+// waitFuture espera o future do raft, desistindo se o contexto for cancelado
+func waitFuture(ctx context.Context, f raft.Future) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- f.Error()
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Get retorna o valor da chave, ou "" se ela não existir. Uma chave que
+// falta em memória mas existe no banco é recuperada (ver readThrough).
 func (kv *KVStore) Get(key string) string {
+	value, _ := kv.Lookup(key)
+	return value
+}
+
+// Lookup funciona como o Get, mas também informa se a chave existe,
+// permitindo diferenciar uma chave ausente de uma chave com valor vazio.
+func (kv *KVStore) Lookup(key string) (string, bool) {
+	value, _, ok := kv.LookupRevision(key)
+	return value, ok
+}
+
+// LookupContext funciona como o Lookup, mas retorna o erro do contexto se
+// quem chamou já desistiu.
+func (kv *KVStore) LookupContext(ctx context.Context, key string) (string, bool, error) {
+	if err := ctx.Err(); err != nil {
+		return "", false, err
+	}
+
+	value, ok := kv.Lookup(key)
+	return value, ok, nil
+}
+
+// MultiGet busca várias chaves com um único read lock. Chaves inexistentes
+// ficam de fora do mapa, então um valor vazio continua distinguível. As que
+// faltam em memória passam pelo read-through depois do lock.
+func (kv *KVStore) MultiGet(keys []string) map[string]string {
+	result := make(map[string]string, len(keys))
+	var missing []string
+
+	kv.mu.RLock()
+	for _, key := range keys {
+		if value, ok := kv.store[key]; ok {
+			result[key] = value
+		} else {
+			missing = append(missing, key)
+		}
+	}
+	kv.mu.RUnlock()
+
+	for _, key := range missing {
+		if e, ok := kv.readThrough(key); ok {
+			result[key] = e.Value
+		}
+	}
+	return result
+}
+
+// Count retorna o número de chaves na store, incluindo as despejadas da
+// memória pelo LRU
+func (kv *KVStore) Count() int {
+	if kv.lru != nil {
+		return kv.CountPrefix("")
+	}
+	return kv.memoryCount()
+}
+
+// memoryCount retorna quantas chaves do namespace padrão estão em memória
+func (kv *KVStore) memoryCount() int {
 	kv.mu.RLock()
 	defer kv.mu.RUnlock()
 
-	if kv.store == nil {
-		return ""
+	return len(kv.store)
+}
+
+// CountPrefix retorna o número de chaves que começam com prefix
+func (kv *KVStore) CountPrefix(prefix string) int {
+	if prefix == "" && kv.lru == nil {
+		return kv.Count()
+	}
+
+	count := 0
+	kv.scan(func(key, _ string) {
+		if strings.HasPrefix(key, prefix) {
+			count++
+		}
+	})
+	return count
+}
+
+// ForEach chama fn para cada par do namespace padrão sem copiar a store,
+// parando assim que fn retornar false. A ordem não é definida. fn roda com o
+// read lock travado, então não pode escrever na store (daria deadlock); para
+// processar devagar sem bloquear os writers use o snapshot do GetAll.
+func (kv *KVStore) ForEach(fn func(key, value string) bool) {
+	kv.mu.RLock()
+	defer kv.mu.RUnlock()
+
+	for key, value := range kv.store {
+		if !fn(key, value) {
+			return
+		}
+	}
+}
+
+// ForEachPersisted percorre os pares gravados no backend (um cursor, no bbolt)
+// em ordem de chave, sem carregar nada em memória, e para quando fn retornar
+// false. Enxerga o que já foi persistido, não escritas em andamento.
+func (kv *KVStore) ForEachPersisted(fn func(key, value string) bool) error {
+	err := kv.storage().ForEach(kv.bucket, func(k, v []byte) error {
+		if !fn(string(k), kv.decodeValue(string(v))) {
+			return errStopIteration
+		}
+		return nil
+	})
+	if errors.Is(err, errStopIteration) {
+		return nil
+	}
+	return err
+}
+
+// Keys retorna os nomes das chaves que começam com prefix, ordenados.
+// Útil quando só é preciso saber o que está armazenado, sem os valores.
+func (kv *KVStore) Keys(prefix string) []string {
+	var keys []string
+	kv.scan(func(key, _ string) {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	})
+	if keys == nil {
+		keys = []string{}
+	}
+
+	sort.Strings(keys)
+	return keys
+}
+
+// MultiScan retorna as chaves e valores que começam com cada um dos prefixos,
+// agrupados por prefixo, percorrendo a store uma única vez. Uma chave que
+// combina com mais de um prefixo aparece só no mais longo deles, o mais
+// específico. Prefixos repetidos contam uma vez e todo prefixo pedido aparece
+// no resultado, mesmo sem chaves; sem prefixos o resultado é vazio.
+func (kv *KVStore) MultiScan(prefixes []string) map[string]map[string]string {
+	result := make(map[string]map[string]string, len(prefixes))
+	for _, prefix := range prefixes {
+		result[prefix] = make(map[string]string)
 	}
+	if len(result) == 0 {
+		return result
+	}
+
+	//do mais longo para o mais curto, o primeiro que combina é o mais específico
+	ordered := slices.Collect(maps.Keys(result))
+	slices.SortFunc(ordered, func(a, b string) int {
+		return len(b) - len(a)
+	})
 
-	//tratar isso aqui caso nao exista em memoria
-	//e exista suspeita de desatualização em relação ao db
-	return kv.store[key]
+	kv.scan(func(key, value string) {
+		for _, prefix := range ordered {
+			if strings.HasPrefix(key, prefix) {
+				result[prefix][key] = value
+				break
+			}
+		}
+	})
+	return result
 }
 
 // Esse Watch vai receber uma key, criar um watcher pra quem chamou
 // e fará o append do watcher na slice de watchers da store
 // logo depois retorna o watcher específico para a key fornecida
 // assim, quem chamou o watch pode acompanhar as atualizações daquela key.
-func (kv *KVStore) Watch(key string) *KVWatcher {
-	kv.mu.RLock()
-	defer kv.mu.RUnlock()
+func (kv *KVStore) Watch(key string, opts ...WatchOption) *KVWatcher {
+	return kv.watch("", key, opts...)
+}
+
+func (kv *KVStore) watch(ns, key string, opts ...WatchOption) *KVWatcher {
+	o := kv.watchOptions(opts)
+
+	//o stripe espera a escrita em andamento na chave terminar de avisar os
+	//watchers, e o valor inicial é lido no mesmo lock em que o watcher é
+	//registrado, então nenhuma escrita fica entre os dois
+	s := kv.stripe(ns, key)
+	s.Lock()
+	defer s.Unlock()
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+
+	w := kv.newWatcher(ns, key, o)
+	if kv.closed.Load() {
+		w.close()
+		return w
+	}
 
-	w := &KVWatcher{
-		Key:    key,
-		Events: make(chan string, 10),
+	if o.initialValue {
+		if value, ok := kv.data(ns, false)[key]; ok {
+			w.Events <- WatchEvent{
+				Type:      EventPut,
+				Namespace: ns,
+				Key:       key,
+				Value:     value,
+				Revision:  kv.revisions[key],
+				Message:   fmt.Sprintf("Key %s current value %s", key, value),
+			}
+		}
 	}
 
-	kv.watchers[key] = append(kv.watchers[key], w)
+	wk := watchKey(ns, key)
+	kv.watchers[wk] = append(kv.watchers[wk], w)
 
 	return w
 }
 
+// Unwatch remove o watcher e fecha o canal dele. Quando a chave fica sem
+// watchers, a entrada do mapa também é removida.
 func (kv *KVStore) Unwatch(watcherToUnwatch *KVWatcher) {
-	kv.mu.RLock()
-	defer kv.mu.RUnlock()
+	//com OverflowBlock os eventos que ainda estão na fila são descartados
+	watcherToUnwatch.stop()
+
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
 
-	watchersList := kv.watchers[watcherToUnwatch.Key]
+	if watcherToUnwatch.all {
+		if i := slices.Index(kv.allWatchers, watcherToUnwatch); i >= 0 {
+			kv.allWatchers = slices.Delete(kv.allWatchers, i, i+1)
+			watcherToUnwatch.close()
+		}
+		return
+	}
+
+	wk := watchKey(watcherToUnwatch.Namespace, watcherToUnwatch.Key)
+	watchersList := kv.watchers[wk]
 
 	for i, watcher := range watchersList {
 		if watcher == watcherToUnwatch {
-			kv.watchers[watcherToUnwatch.Key] = append(watchersList[:i], watchersList[i+1:]...)
-			close(watcherToUnwatch.Events)
+			watchersList = append(watchersList[:i], watchersList[i+1:]...)
+			watcherToUnwatch.close()
 			break
 		}
 	}
+
+	if len(watchersList) == 0 {
+		delete(kv.watchers, wk)
+	} else {
+		kv.watchers[wk] = watchersList
+	}
+}
+
+// UnwatchAll fecha e remove todos os watchers da chave de uma vez
+func (kv *KVStore) UnwatchAll(key string) {
+	kv.unwatchAll("", key)
+}
+
+func (kv *KVStore) unwatchAll(ns, key string) {
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+
+	wk := watchKey(ns, key)
+	for _, w := range kv.watchers[wk] {
+		w.close()
+	}
+	delete(kv.watchers, wk)
+}
+
+// Close encerra a store: fecha o canal de todos os watchers (inclusive os do
+// WatchAll), esvazia o registro deles e descarrega o backend. Depois dele as
+// escritas retornam ErrClosed e um Watch devolve um watcher já fechado; as
+// leituras continuam servindo o que está em memória. O WAL não tem buffer, cada
+// entrada já está no arquivo; fechar o WAL, o bbolt e o raft continua a cargo
+// de quem chama. Um segundo Close só repete o Flush.
+func (kv *KVStore) Close() error {
+	kv.lockAll()
+	kv.closed.Store(true)
+	kv.closeWatchersLocked()
+	kv.unlockAll()
+
+	return kv.Flush()
+}
+
+// CloseWatchers fecha o canal de todos os watchers, como o Close, mas deixa a
+// store aberta: as escritas continuam valendo. Serve para encerrar os Watch de
+// um servidor que está parando antes das requisições em andamento terminarem.
+func (kv *KVStore) CloseWatchers() {
+	kv.lockAll()
+	kv.closeWatchersLocked()
+	kv.unlockAll()
+}
+
+func (kv *KVStore) closeWatchersLocked() {
+	for _, wlist := range kv.watchers {
+		for _, w := range wlist {
+			w.stop()
+			w.close()
+		}
+	}
+	for _, w := range kv.allWatchers {
+		w.stop()
+		w.close()
+	}
+	kv.watchers = make(map[string][]*KVWatcher)
+	kv.allWatchers = nil
 }
 
 type fsm KVStore
 
+// Join adiciona o nó como voter. É idempotente: um nó que já está na
+// configuração com o mesmo id e endereço não muda nada. Com o mesmo id e outro
+// endereço o AddVoter atualiza o endereço, e um outro id no mesmo endereço (ex.:
+// um nó recriado com id novo) é removido antes. Só o líder muda a
+// configuração; nos outros nós retorna ErrNotLeader, e sem Open ErrRaftNotOpen.
 func (s *KVStore) Join(myAddress, myID string) error {
-	s.logger.Printf("received join request for remote node %s at %s", myID, myAddress)
+	if s.raft == nil {
+		return ErrRaftNotOpen
+	}
+	if s.raft.State() != raft.Leader {
+		return ErrNotLeader
+	}
+	s.logger.Info("received join request", "node_id", myID, "address", myAddress)
 
 	configFuture := s.raft.GetConfiguration()
-	log.Printf("config joining %v", configFuture)
 
 	if err := configFuture.Error(); err != nil {
-		s.logger.Printf("failed get configuration: %v", err)
+		s.logger.Error("failed to get raft configuration", "error", err)
 		return err
 	}
 
-	f := s.raft.AddVoter(raft.ServerID(myID), raft.ServerAddress(myAddress), 0, 0)
+	id, addr := raft.ServerID(myID), raft.ServerAddress(myAddress)
+	for _, srv := range configFuture.Configuration().Servers {
+		switch {
+		case srv.ID == id && srv.Address == addr && srv.Suffrage == raft.Voter:
+			s.logger.Info("node already joined, ignoring", "node_id", myID, "address", myAddress)
+			return nil
+		case srv.ID == id && srv.Address != addr:
+			s.logger.Info("node rejoined with a new address", "node_id", myID, "old_address", srv.Address, "address", myAddress)
+		case srv.ID != id && srv.Address == addr:
+			s.logger.Warn("removing server that had the joining address", "node_id", srv.ID, "address", myAddress)
+			if err := s.raft.RemoveServer(srv.ID, 0, 0).Error(); err != nil {
+				return fmt.Errorf("remove server %s at %s: %w", srv.ID, myAddress, err)
+			}
+		}
+	}
 
-	if f.Error() != nil {
-		return f.Error()
+	f := s.raft.AddVoter(id, addr, 0, 0)
+
+	if err := f.Error(); err != nil {
+		if errors.Is(err, raft.ErrNotLeader) || errors.Is(err, raft.ErrLeadershipLost) {
+			return ErrNotLeader
+		}
+		return err
 	}
 
-	s.logger.Printf("Joined sucessfully, %v, %v", myAddress, myID)
+	s.logger.Info("node joined", "node_id", myID, "address", myAddress)
 	return nil
 
 }
 
+// Open inicia o raft deste nó em <raftDir>/<myID>. Com myAddress vazio usa o
+// endereço configurado com WithRaftBind.
+//
+// O cluster só é criado (bootstrap) se a store foi configurada para isso e o
+// raft ainda não tem estado salvo; os outros nós sobem sem configuração e
+// esperam o líder adicioná-los.
 func (s *KVStore) Open(myAddress, myID string) error {
+	if myAddress == "" {
+		myAddress = s.raftBind
+	}
+	if s.retainSnapshots < 1 {
+		return fmt.Errorf("snapshot retention must be at least 1, got %d", s.retainSnapshots)
+	}
+
 	config := raft.DefaultConfig()
 	config.LocalID = raft.ServerID(myID)
+	//num restart o nó se recupera pelo bbolt e pelo WAL; o snapshot só é
+	//aplicado num follower que ficou para trás do log do líder
+	config.NoSnapshotRestoreOnStart = true
+	s.snapshotConfig(config)
+	s.nodeID = myID
 
-	raftDir := "./data"
-	// myID := "1"
-	// myAddress := "localhost:5001"
-
-	baseDir := filepath.Join(raftDir, myID)
+	baseDir := filepath.Join(s.raftDir, myID)
 
 	if err := os.MkdirAll(baseDir, 0755); err != nil {
-		log.Printf("Error creating raft directory for id=%v, %v", myID, err)
+		s.logger.Error("failed to create raft directory", "node_id", myID, "error", err)
 		return err
 	}
 
 	logsDb, err := boltdb.NewBoltStore(filepath.Join(baseDir, "logs.dat"))
 
 	if err != nil {
-		log.Printf("Error creating logsDB for id=%v, %v", myID, err)
+		s.logger.Error("failed to create raft log store", "node_id", myID, "error", err)
+		return err
 	}
 
 	stableDb, err := boltdb.NewBoltStore(filepath.Join(baseDir, "stable.dat"))
 
 	if err != nil {
-		log.Printf("Error creating stableDB for id=%v, %v", myID, err)
+		logsDb.Close()
+		s.logger.Error("failed to create raft stable store", "node_id", myID, "error", err)
+		return err
+	}
+	s.raftStores = []*boltdb.BoltStore{logsDb, stableDb}
+
+	snapshotStore, err := raft.NewFileSnapshotStore(baseDir, s.retainSnapshots, os.Stderr)
+	if err != nil {
+		s.logger.Error("failed to create raft snapshot store", "node_id", myID, "error", err)
+		return err
 	}
 
-	snapshotStore, err := raft.NewFileSnapshotStore(baseDir, 3, os.Stderr)
+	hasState, err := raft.HasExistingState(logsDb, stableDb, snapshotStore)
 	if err != nil {
-		log.Printf("Error creating raft snapshot for id=%v, %v", myID, err)
+		s.logger.Error("failed to check existing raft state", "node_id", myID, "error", err)
+		return err
 	}
 
 	//setup transport RPC
@@ -264,24 +1170,54 @@ func (s *KVStore) Open(myAddress, myID string) error {
 
 	myRaft, err := raft.NewRaft(config, (*fsm)(s), logsDb, stableDb, snapshotStore, transportManager.Transport())
 	if err != nil {
-		log.Printf("Error creating new raft id=%v, %v", myID, err)
+		s.logger.Error("failed to create raft", "node_id", myID, "error", err)
+		return err
 	}
 
 	s.raft = myRaft
 
-	configuration := raft.Configuration{
-		Servers: []raft.Server{
-			{
-				ID:      config.LocalID,
-				Address: raft.ServerAddress(myAddress),
+	switch {
+	case hasState:
+		s.logger.Info("found existing raft state, skipping bootstrap", "node_id", myID)
+	case s.bootstrap:
+		configuration := raft.Configuration{
+			Servers: []raft.Server{
+				{
+					ID:      config.LocalID,
+					Address: raft.ServerAddress(myAddress),
+				},
 			},
-		},
+		}
+		if err := myRaft.BootstrapCluster(configuration).Error(); err != nil && !errors.Is(err, raft.ErrCantBootstrap) {
+			s.logger.Error("failed to bootstrap cluster", "node_id", myID, "error", err)
+			return err
+		}
+	default:
+		s.logger.Info("waiting to be added to the cluster by the leader", "node_id", myID)
 	}
-	myRaft.BootstrapCluster(configuration)
-	log.Printf("state: %v | config: %v | leader: %v", myRaft.State(), s.raft.GetConfiguration().Configuration().Servers, myRaft.Leader())
+
+	s.logger.Info("raft started", "state", myRaft.State(), "servers", s.raft.GetConfiguration().Configuration().Servers, "leader", myRaft.Leader())
 	return nil
 }
 
+// ShutdownRaft desliga o raft aberto pelo Open e fecha os arquivos do log e do
+// estado estável, liberando o diretório para outro Open. Sem raft não faz nada.
+func (s *KVStore) ShutdownRaft() error {
+	var errs []error
+	if s.raft != nil {
+		errs = append(errs, s.raft.Shutdown().Error())
+	}
+	for _, store := range s.raftStores {
+		errs = append(errs, store.Close())
+	}
+	s.raftStores = nil
+	return errors.Join(errs...)
+}
+
+// Apply aplica nos followers as escritas feitas no líder. O nó de origem já
+// aplicou a escrita antes do raft.Apply (e espera por ele segurando o stripe
+// da chave), então ignora o comando sem travar nada. Comandos sem Origin são anteriores
+// à replicação pelo FSM e também são ignorados.
 func (f *fsm) Apply(l *raft.Log) interface{} {
 
 	var c command
@@ -290,42 +1226,45 @@ func (f *fsm) Apply(l *raft.Log) interface{} {
 		panic(fmt.Sprintf("failed to unmarshal command: %s", err.Error()))
 	}
 
-	if c.Op == "put" {
-		return f.ApplyPut(c.Key, c.Value)
+	if c.Origin == "" || c.Origin == f.nodeID {
+		return nil
 	}
 
-	if c.Op == "del" {
-		return f.ApplyDelete(c.Key)
-	}
-
-	panic(fmt.Sprintf("unrecognized command op: %s", c.Op))
-
+	ctx := context.WithValue(context.Background(), fromRaftKey{}, true)
+	return (*KVStore)(f).applyLocal(ctx, c)
 }
 
-func (f *fsm) ApplyPut(key, value string) interface{} {
-	return nil
-}
-
-func (f *fsm) ApplyDelete(key string) interface{} {
-	return nil
-}
-
-type kvSnapshot struct {
-	data map[string]string
-}
-
-func (s *fsm) Snapshot() (raft.FSMSnapshot, error) {
-	var snapshot map[string]string
-	return &kvSnapshot{data: snapshot}, nil
-}
-
-func (s *fsm) Restore(rc io.ReadCloser) error {
-	return nil
+// applyLocal aplica um comando neste nó: WAL, memória, banco e watchers, e
+// depois o replica se o ctx não vier do FSM. É o caminho das escritas locais
+// e das recebidas pelo raft, então as duas avisam os watchers do mesmo jeito,
+// uma vez por escrita. Um txn replicado é aplicado pelo Txn, inteiro e sob o
+// mesmo lock, como no nó de origem.
+func (kv *KVStore) applyLocal(ctx context.Context, c command) error {
+	if err := kv.checkLeader(ctx); err != nil {
+		return err
+	}
 
-}
+	switch c.Op {
+	case "put":
+		return kv.put(ctx, c.Namespace, c.Key, c.Value)
+	case "del":
+		return kv.delete(ctx, c.Namespace, c.Key)
+	case "clear":
+		return kv.clear(ctx, c.Namespace)
+	case "drop":
+		return kv.dropNamespace(ctx, c.Namespace)
+	case "txn":
+		ops := make([]TxnOp, 0, len(c.Ops))
+		for _, op := range c.Ops {
+			t := TxnOp{Type: TxnPut, Key: op.Key, Value: op.Value}
+			if op.Op == "del" {
+				t.Type = TxnDelete
+			}
+			ops = append(ops, t)
+		}
+		_, err := kv.Txn(ctx, nil, ops, nil)
+		return err
+	}
 
-func (s *kvSnapshot) Persist(sink raft.SnapshotSink) error {
-	return json.NewEncoder(sink).Encode(s.data)
+	panic(fmt.Sprintf("unrecognized command op: %s", c.Op))
 }
-
-func (s *kvSnapshot) Release() {}
@@ -1,8 +1,15 @@
 package store
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"maps"
 	"os"
+	"reflect"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 
@@ -11,7 +18,7 @@ import (
 )
 
 // setupTestDB cria um banco de dados temporário para testes
-func setupTestDB(t *testing.T) *bolt.DB {
+func setupTestDB(t testing.TB) *bolt.DB {
 	dbPath := "test_store.db"
 
 	// Remove arquivo se existir
@@ -35,7 +42,7 @@ func setupTestDB(t *testing.T) *bolt.DB {
 }
 
 // cleanupTestDB remove o banco de dados de teste
-func cleanupTestDB(t *testing.T, db *bolt.DB) {
+func cleanupTestDB(t testing.TB, db *bolt.DB) {
 	if db != nil {
 		db.Close()
 	}
@@ -79,64 +86,1115 @@ func TestKVStore_Put(t *testing.T) {
 	}{
 		{"key1", "value1"},
 		{"key2", "value2"},
-		{"", "empty_key"},
 		{"empty_value", ""},
 		{"special_chars", "!@#$%^&*()"},
 	}
 
+	// O bbolt não grava chaves vazias, então o Put recusa antes de escrever
+	if err := store.Put("", "empty_key"); !errors.Is(err, ErrEmptyKey) {
+		t.Errorf("Put() with an empty key returned %v, expected ErrEmptyKey", err)
+	}
+	if _, ok := store.Lookup(""); ok {
+		t.Error("Put() with an empty key left it in memory")
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key+"="+tt.value, func(t *testing.T) {
+			store.Put(tt.key, tt.value)
+
+			// Verifica se foi salvo na memória
+			if store.store[tt.key] != tt.value {
+				t.Errorf("Put() failed to store in memory. Expected %s, got %s", tt.value, store.store[tt.key])
+			}
+
+			// Verifica se foi salvo no banco
+			db.View(func(tx *bolt.Tx) error {
+				b := tx.Bucket([]byte(constants.BucketStore))
+				if b == nil {
+					t.Fatal("bucket not found")
+				}
+
+				storedValue := b.Get([]byte(tt.key))
+				if string(storedValue) != tt.value {
+					t.Errorf("Put() failed to store in database. Expected %s, got %s", tt.value, string(storedValue))
+				}
+				return nil
+			})
+		})
+	}
+}
+
+func TestKVStore_Get(t *testing.T) {
+	db := setupTestDB(t)
+	defer cleanupTestDB(t, db)
+
+	Init(db)
+	store := NewKVStore()
+
+	// Testa chave inexistente
+	value := store.Get("nonexistent")
+	if value != "" {
+		t.Errorf("Get() for nonexistent key should return empty string, got %s", value)
+	}
+
+	// Testa chaves existentes
+	testData := map[string]string{
+		"key1": "value1",
+		"key2": "value2",
+	}
+
+	for key, expectedValue := range testData {
+		store.Put(key, expectedValue)
+		actualValue := store.Get(key)
+		if actualValue != expectedValue {
+			t.Errorf("Get() failed. Expected %s, got %s", expectedValue, actualValue)
+		}
+	}
+}
+
+func TestKVStore_Lookup(t *testing.T) {
+	store := NewKVStore()
+
+	store.PutFromDb("key1", "value1")
+	store.PutFromDb("empty_value", "")
+
+	tests := []struct {
+		key       string
+		wantValue string
+		wantOk    bool
+	}{
+		{"key1", "value1", true},
+		{"empty_value", "", true},
+		{"nonexistent", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			value, ok := store.Lookup(tt.key)
+			if value != tt.wantValue || ok != tt.wantOk {
+				t.Errorf("Lookup(%q) = (%q, %v), expected (%q, %v)", tt.key, value, ok, tt.wantValue, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestKVStore_MultiGet(t *testing.T) {
+	store := NewKVStore()
+
+	store.PutFromDb("key1", "value1")
+	store.PutFromDb("key2", "value2")
+	store.PutFromDb("empty_value", "")
+
+	result := store.MultiGet([]string{"key1", "missing", "empty_value", "key2", "key1"})
+
+	expected := map[string]string{
+		"key1":        "value1",
+		"key2":        "value2",
+		"empty_value": "",
+	}
+
+	if len(result) != len(expected) {
+		t.Errorf("MultiGet() returned %d keys, expected %d", len(result), len(expected))
+	}
+
+	for key, want := range expected {
+		value, ok := result[key]
+		if !ok {
+			t.Errorf("MultiGet() missing key %s", key)
+			continue
+		}
+		if value != want {
+			t.Errorf("MultiGet() returned wrong value for %s. Expected %q, got %q", key, want, value)
+		}
+	}
+
+	if _, ok := result["missing"]; ok {
+		t.Errorf("MultiGet() returned absent key")
+	}
+
+	if result := store.MultiGet(nil); len(result) != 0 {
+		t.Errorf("MultiGet(nil) returned %d keys, expected 0", len(result))
+	}
+}
+
+func TestKVStore_Count(t *testing.T) {
+	db := setupTestDB(t)
+	defer cleanupTestDB(t, db)
+	defer os.Remove("walog.ndjson")
+
+	Init(db)
+	store := NewKVStore()
+
+	if count := store.Count(); count != 0 {
+		t.Errorf("Count() on empty store returned %d, expected 0", count)
+	}
+
+	for _, key := range []string{"user:1", "user:2", "user:3", "order:1", "order:2"} {
+		store.Put(key, "value")
+	}
+	store.Put("user:1", "updated") // sobrescrever não muda o total
+	store.Delete("user:2")
+	store.Delete("nonexistent")
+
+	tests := []struct {
+		prefix   string
+		expected int
+	}{
+		{"", 4},
+		{"user:", 2},
+		{"order:", 2},
+		{"order:1", 1},
+		{"missing:", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.prefix, func(t *testing.T) {
+			if count := store.CountPrefix(tt.prefix); count != tt.expected {
+				t.Errorf("CountPrefix(%q) = %d, expected %d", tt.prefix, count, tt.expected)
+			}
+		})
+	}
+
+	if count := store.Count(); count != 4 {
+		t.Errorf("Count() = %d, expected 4", count)
+	}
+}
+
+func TestKVStore_Keys(t *testing.T) {
+	db := setupTestDB(t)
+	defer cleanupTestDB(t, db)
+	defer os.Remove("walog.ndjson")
+
+	Init(db)
+	store := NewKVStore()
+
+	if keys := store.Keys(""); len(keys) != 0 {
+		t.Errorf("Keys() on empty store returned %v, expected none", keys)
+	}
+
+	for _, key := range []string{"user:3", "order:2", "user:1", "order:1", "user:2"} {
+		store.Put(key, "value")
+	}
+	store.Delete("user:2")
+
+	tests := []struct {
+		prefix   string
+		expected []string
+	}{
+		{"", []string{"order:1", "order:2", "user:1", "user:3"}},
+		{"user:", []string{"user:1", "user:3"}},
+		{"order:1", []string{"order:1"}},
+		{"missing:", []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.prefix, func(t *testing.T) {
+			keys := store.Keys(tt.prefix)
+			if !reflect.DeepEqual(keys, tt.expected) {
+				t.Errorf("Keys(%q) = %v, expected %v", tt.prefix, keys, tt.expected)
+			}
+		})
+	}
+}
+
+func TestKVStore_MultiScan(t *testing.T) {
+	db := setupTestDB(t)
+	defer cleanupTestDB(t, db)
+	defer os.Remove("walog.ndjson")
+
+	Init(db)
+	store := NewKVStore()
+
+	if got := store.MultiScan(nil); len(got) != 0 {
+		t.Errorf("MultiScan(nil) returned %v, expected an empty result", got)
+	}
+
+	for _, key := range []string{"user:1", "user:admin:1", "order:1", "other"} {
+		store.Put(key, key+"-value")
+	}
+
+	//user:admin: é mais específico que user:, então user:admin:1 só aparece nele
+	got := store.MultiScan([]string{"user:", "user:admin:", "order:", "user:", "missing:"})
+	expected := map[string]map[string]string{
+		"user:":       {"user:1": "user:1-value"},
+		"user:admin:": {"user:admin:1": "user:admin:1-value"},
+		"order:":      {"order:1": "order:1-value"},
+		"missing:":    {},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("MultiScan() returned %v, expected %v", got, expected)
+	}
+
+	//o prefixo vazio fica com as chaves que nenhum outro pegou
+	got = store.MultiScan([]string{"", "user:"})
+	expected = map[string]map[string]string{
+		"":      {"order:1": "order:1-value", "other": "other-value"},
+		"user:": {"user:1": "user:1-value", "user:admin:1": "user:admin:1-value"},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("MultiScan() with an empty prefix returned %v, expected %v", got, expected)
+	}
+}
+
+func TestKVStore_ForEach(t *testing.T) {
+	db := setupTestDB(t)
+	defer cleanupTestDB(t, db)
+	defer os.Remove("walog.ndjson")
+
+	Init(db)
+	store := NewKVStore()
+
+	for i := range 20 {
+		store.Put(fmt.Sprintf("key%02d", i), fmt.Sprintf("value%d", i))
+	}
+
+	seen := make(map[string]string)
+	store.ForEach(func(key, value string) bool {
+		seen[key] = value
+		return true
+	})
+	if len(seen) != 20 {
+		t.Fatalf("ForEach() visited %d keys, expected 20", len(seen))
+	}
+	if seen["key07"] != "value7" {
+		t.Errorf("ForEach() gave key07 = %q, expected value7", seen["key07"])
+	}
+
+	// Para assim que o callback retorna false
+	calls := 0
+	store.ForEach(func(key, value string) bool {
+		calls++
+		return calls < 5
+	})
+	if calls != 5 {
+		t.Errorf("ForEach() called fn %d times after stop, expected 5", calls)
+	}
+}
+
+func TestKVStore_ForEachPersisted(t *testing.T) {
+	defer os.Remove("walog.ndjson")
+
+	store := NewKVStore(WithBackend(NewMemoryBackend()), WithCompressionThreshold(0))
+
+	for i := range 10 {
+		store.Put(fmt.Sprintf("key%d", i), strings.Repeat("v", i+1))
+	}
+	store.Delete("key3")
+
+	var keys []string
+	err := store.ForEachPersisted(func(key, value string) bool {
+		if want := store.Get(key); value != want {
+			t.Errorf("ForEachPersisted() gave %s = %q, expected %q", key, value, want)
+		}
+		keys = append(keys, key)
+		return true
+	})
+	if err != nil {
+		t.Fatalf("ForEachPersisted() failed: %v", err)
+	}
+	if len(keys) != 9 || !slices.IsSorted(keys) {
+		t.Errorf("ForEachPersisted() visited %v, expected 9 sorted keys", keys)
+	}
+
+	calls := 0
+	err = store.ForEachPersisted(func(key, value string) bool {
+		calls++
+		return false
+	})
+	if err != nil {
+		t.Errorf("ForEachPersisted() with early stop returned %v, expected nil", err)
+	}
+	if calls != 1 {
+		t.Errorf("ForEachPersisted() called fn %d times after stop, expected 1", calls)
+	}
+}
+
+func TestKVStore_MissingBucket(t *testing.T) {
+	defer os.Remove("walog.ndjson")
+
+	// banco sem nenhum bucket, como um arquivo criado por outra ferramenta
+	db, err := bolt.Open("test_nobucket.db", constants.DBFilePermission, nil)
+	if err != nil {
+		t.Fatalf("Failed to open test db: %v", err)
+	}
+	defer os.Remove("test_nobucket.db")
+	defer db.Close()
+
+	store := NewKVStore(WithDB(db))
+
+	if got := store.Get("key"); got != "" {
+		t.Errorf("Get() on missing bucket = %q, expected empty", got)
+	}
+	if all := store.GetAll(); len(all) != 0 {
+		t.Errorf("GetAll() on missing bucket = %v, expected empty", all)
+	}
+	for name, load := range map[string]func() error{
+		"LoadKeys":       store.LoadKeys,
+		"LoadNamespaces": store.LoadNamespaces,
+		"LoadRevisions":  store.LoadRevisions,
+		"Clear":          store.Clear,
+	} {
+		if err := load(); err != nil {
+			t.Errorf("%s() on missing bucket returned %v, expected nil", name, err)
+		}
+	}
+	store.Delete("key")
+
+	// o Put cria o bucket sob demanda
+	store.Put("key", "value")
+	db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(store.bucket)
+		if b == nil {
+			t.Fatalf("Put() did not create the bucket")
+		}
+		if b.Get([]byte("key")) == nil {
+			t.Errorf("Put() did not persist the key")
+		}
+		return nil
+	})
+
+	// sem backend nenhum as operações falham em vez de entrar em panic
+	orphan := NewKVStore(WithBackend(noBackend{}))
+	orphan.Put("key", "value")
+	if err := orphan.LoadKeys(); !errors.Is(err, ErrNoBackend) {
+		t.Errorf("LoadKeys() without backend returned %v, expected ErrNoBackend", err)
+	}
+}
+
+func TestKVStore_Clear(t *testing.T) {
+	db := setupTestDB(t)
+	defer cleanupTestDB(t, db)
+	defer os.Remove("walog.ndjson")
+
+	Init(db)
+	store := NewKVStore()
+
+	for i := range 10 {
+		store.Put(fmt.Sprintf("key%d", i), fmt.Sprintf("value%d", i))
+	}
+	store.Namespace("other").Put("key0", "kept")
+
+	watcher := store.Watch("key0")
+	defer store.Unwatch(watcher)
+
+	if err := store.Clear(); err != nil {
+		t.Fatalf("Clear() failed: %v", err)
+	}
+
+	if all := store.GetAll(); len(all) != 0 {
+		t.Errorf("GetAll() after Clear() returned %d keys, expected 0", len(all))
+	}
+
+	select {
+	case msg := <-watcher.Events:
+		if msg.Message != "Key key0 deleted" {
+			t.Errorf("Wrong event message. Expected Key key0 deleted, got %s", msg)
+		}
+	default:
+		t.Errorf("watcher was not notified of Clear()")
+	}
+
+	// Outros namespaces não são afetados
+	if value := store.Namespace("other").Get("key0"); value != "kept" {
+		t.Errorf("Clear() affected other namespace. Expected kept, got %s", value)
+	}
+
+	// A store continua utilizável depois do Clear
+	store.Put("after", "clear")
+
+	// Reabre o banco para garantir que o Clear foi persistido
+	db.Close()
+	db, err := bolt.Open("test_store.db", constants.DBFilePermission, nil)
+	if err != nil {
+		t.Fatalf("failed to reopen db: %v", err)
+	}
+
+	db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(constants.BucketStore))
+		if b == nil {
+			t.Fatal("bucket missing after Clear()")
+		}
+
+		keys := 0
+		b.ForEach(func(k, v []byte) error {
+			keys++
+			return nil
+		})
+		if keys != 1 {
+			t.Errorf("bucket has %d keys after Clear() and reopen, expected 1", keys)
+		}
+		if value := string(b.Get([]byte("after"))); value != "clear" {
+			t.Errorf("key written after Clear() not persisted. Expected clear, got %s", value)
+		}
+		return nil
+	})
+	db.Close()
+}
+
+func TestKVStore_Clear_DrainsWatchers(t *testing.T) {
+	defer os.Remove("walog.ndjson")
+
+	store := NewKVStore(WithBackend(NewMemoryBackend()), WithMaxEntries(2, EvictMemory))
+	store.Put("evicted", "1")
+	store.Put("kept", "2")
+	store.Put("closed", "3")
+	store.Namespace("tenant").Put("key", "4")
+
+	// evicted saiu da memória pelo LRU, mas continua no backend
+	if inMemory(store, "evicted") {
+		t.Fatal("expected evicted to be out of memory")
+	}
+
+	evicted := store.Watch("evicted")
+	kept := store.Watch("kept")
+	closed := store.Watch("closed", WithCloseOnDelete())
+	missing := store.Watch("missing")
+	nsWatcher := store.Namespace("tenant").Watch("key", WithCloseOnDelete())
+	defer store.Unwatch(evicted)
+	defer store.Unwatch(kept)
+	defer store.Unwatch(missing)
+
+	if err := store.Clear(); err != nil {
+		t.Fatalf("Clear() failed: %v", err)
+	}
+
+	for _, w := range []*KVWatcher{evicted, kept, closed} {
+		select {
+		case msg := <-w.Events:
+			if want := "Key " + w.Key + " deleted"; msg.Message != want {
+				t.Errorf("expected %q, got %q", want, msg)
+			}
+		default:
+			t.Errorf("watcher on %s did not see the delete from Clear()", w.Key)
+		}
+	}
+
+	// a chave não existia: nada foi removido, então não há evento
+	select {
+	case msg := <-missing.Events:
+		t.Errorf("watcher on a missing key received %q", msg)
+	default:
+	}
+
+	// WithCloseOnDelete fecha o canal depois do delete
+	if _, ok := <-closed.Events; ok {
+		t.Error("expected the WithCloseOnDelete watcher to be closed")
+	}
+
+	// o Clear do namespace padrão não mexe no tenant
+	select {
+	case msg := <-nsWatcher.Events:
+		t.Errorf("namespace watcher received %q from Clear()", msg)
+	default:
+	}
+
+	if err := store.DropNamespace("tenant"); err != nil {
+		t.Fatalf("DropNamespace() failed: %v", err)
+	}
+	if msg := <-nsWatcher.Events; msg.Message != "Key key deleted in namespace tenant" {
+		t.Errorf("expected the delete from DropNamespace(), got %q", msg)
+	}
+	if _, ok := <-nsWatcher.Events; ok {
+		t.Error("expected the namespace watcher to be closed after DropNamespace()")
+	}
+
+	// kept, evicted e missing continuam registrados
+	if got := store.WatcherCount(); got != 3 {
+		t.Errorf("expected 3 watchers left, got %d", got)
+	}
+
+	// Unwatch de um watcher já fechado pela store não faz nada
+	store.Unwatch(closed)
+}
+
+func TestKVStore_PutDelete_BackendFailure(t *testing.T) {
+	useTempWAL(t, t.TempDir())
+
+	inner := NewMemoryBackend()
+	store := NewKVStore(WithBackend(inner))
+	store.Put("a", "1")
+	rev := store.Revision("a")
+	watcher := store.Watch("a")
+	defer store.Unwatch(watcher)
+
+	store.backend = failingBackend{inner}
+
+	for name, err := range map[string]error{
+		"Put":        store.Put("a", "2"),
+		"Delete":     store.Delete("a"),
+		"Put(new)":   store.Put("b", "1"),
+		"Namespaced": store.Namespace("ns").Put("a", "1"),
+	} {
+		if !errors.Is(err, ErrWriteFailed) || !errors.Is(err, errBackendDown) {
+			t.Errorf("%s() returned %v, expected ErrWriteFailed wrapping %v", name, err, errBackendDown)
+		}
+		if errors.Is(err, ErrReplicationFailed) {
+			t.Errorf("%s() reported a replication failure for a local write failure", name)
+		}
+	}
+
+	// a escrita não chegou ao banco, então os watchers não são avisados
+	select {
+	case ev := <-watcher.Events:
+		t.Errorf("watcher notified of a failed write: %q", ev)
+	default:
+	}
+
+	// nem a memória muda
+	if got := store.GetAll(); !reflect.DeepEqual(got, map[string]string{"a": "1"}) {
+		t.Errorf("Memory changed after failed writes: %v", got)
+	}
+	if got := store.Revision("a"); got != rev {
+		t.Errorf("Revision(a) = %d, expected %d", got, rev)
+	}
+	if _, ok := store.Namespace("ns").Lookup("a"); ok {
+		t.Error("Failed namespace write left the key in memory")
+	}
+
+	// e o replay do WAL não aplica as escritas que falharam
+	store.backend = inner
+	restored := NewKVStore(WithBackend(inner))
+	if _, err := restored.ReplayWAL(); err != nil {
+		t.Fatalf("ReplayWAL() failed: %v", err)
+	}
+	if err := restored.LoadKeys(); err != nil {
+		t.Fatal(err)
+	}
+	if got := restored.GetAll(); !reflect.DeepEqual(got, map[string]string{"a": "1"}) {
+		t.Errorf("GetAll() after replay = %v, expected only a=1", got)
+	}
+}
+
+func TestKVStore_PutIfAbsent(t *testing.T) {
+	db := setupTestDB(t)
+	defer cleanupTestDB(t, db)
+	defer os.Remove("walog.ndjson")
+
+	Init(db)
+	store := NewKVStore()
+
+	store.Put("existing", "original")
+	store.Put("empty_value", "")
+
+	tests := []struct {
+		name       string
+		key        string
+		wantStored bool
+		wantValue  string
+	}{
+		{"absent key is stored", "new_key", true, "new"},
+		{"present key is left alone", "existing", false, "original"},
+		{"empty value counts as present", "empty_value", false, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			watcher := store.Watch(tt.key)
+			defer store.Unwatch(watcher)
+
+			stored, err := store.PutIfAbsent(tt.key, "new")
+			if err != nil {
+				t.Fatalf("PutIfAbsent() failed: %v", err)
+			}
+			if stored != tt.wantStored {
+				t.Errorf("PutIfAbsent() = %v, expected %v", stored, tt.wantStored)
+			}
+
+			if value := store.Get(tt.key); value != tt.wantValue {
+				t.Errorf("PutIfAbsent() left wrong value. Expected %s, got %s", tt.wantValue, value)
+			}
+
+			// Só notifica os watchers quando a escrita acontece
+			select {
+			case <-watcher.Events:
+				if !tt.wantStored {
+					t.Errorf("watcher notified for a no-op PutIfAbsent()")
+				}
+			default:
+				if tt.wantStored {
+					t.Errorf("watcher not notified after PutIfAbsent() stored the key")
+				}
+			}
+
+			var persisted []byte
+			db.View(func(tx *bolt.Tx) error {
+				persisted = tx.Bucket([]byte(constants.BucketStore)).Get([]byte(tt.key))
+				return nil
+			})
+			if string(persisted) != tt.wantValue {
+				t.Errorf("bbolt has wrong value. Expected %s, got %s", tt.wantValue, persisted)
+			}
+		})
+	}
+}
+
+func TestKVStore_PutContext_Canceled(t *testing.T) {
+	db := setupTestDB(t)
+	defer cleanupTestDB(t, db)
+	os.Remove("walog.ndjson")
+	defer os.Remove("walog.ndjson")
+
+	Init(db)
+	store := NewKVStore()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := store.PutContext(ctx, "key1", "value1"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("PutContext() expected context.Canceled, got %v", err)
+	}
+
+	// Nada deve ter sido escrito: memória, bbolt e WAL
+	if _, ok := store.Lookup("key1"); ok {
+		t.Errorf("PutContext() wrote to memory after cancel")
+	}
+	db.View(func(tx *bolt.Tx) error {
+		if v := tx.Bucket([]byte(constants.BucketStore)).Get([]byte("key1")); v != nil {
+			t.Errorf("PutContext() wrote to bbolt after cancel")
+		}
+		return nil
+	})
+	if _, err := os.Stat("walog.ndjson"); !os.IsNotExist(err) {
+		t.Errorf("PutContext() wrote to the WAL after cancel")
+	}
+
+	// O Delete também é interrompido
+	store.Put("key2", "value2")
+	if err := store.DeleteContext(ctx, "key2"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("DeleteContext() expected context.Canceled, got %v", err)
+	}
+	if value := store.Get("key2"); value != "value2" {
+		t.Errorf("DeleteContext() removed key after cancel")
+	}
+
+	if _, _, err := store.LookupContext(ctx, "key2"); !errors.Is(err, context.Canceled) {
+		t.Errorf("LookupContext() expected context.Canceled, got %v", err)
+	}
+
+	// Com um contexto válido a escrita acontece normalmente
+	if err := store.PutContext(context.Background(), "key1", "value1"); err != nil {
+		t.Fatalf("PutContext() failed: %v", err)
+	}
+	if value := store.Get("key1"); value != "value1" {
+		t.Errorf("PutContext() did not store value. Expected value1, got %s", value)
+	}
+}
+
+func TestKVStore_GetAll_Snapshot(t *testing.T) {
+	db := setupTestDB(t)
+	defer cleanupTestDB(t, db)
+	defer os.Remove("walog.ndjson")
+
+	Init(db)
+	store := NewKVStore()
+
+	store.Put("key1", "value1")
+	first := store.GetAll()
+
+	// Sem escritas o mesmo snapshot é reaproveitado
+	if reflect.ValueOf(store.GetAll()).Pointer() != reflect.ValueOf(first).Pointer() {
+		t.Errorf("GetAll() rebuilt the snapshot without any write")
+	}
+
+	store.Put("key2", "value2")
+
+	// O snapshot antigo não enxerga escritas posteriores
+	if len(first) != 1 {
+		t.Errorf("old snapshot changed after Put(). Expected 1 key, got %d", len(first))
+	}
+
+	tests := []struct {
+		name     string
+		write    func()
+		expected map[string]string
+	}{
+		{"put", func() {}, map[string]string{"key1": "value1", "key2": "value2"}},
+		{"delete", func() { store.Delete("key1") }, map[string]string{"key2": "value2"}},
+		{"put from db", func() { store.PutFromDb("key3", "value3") }, map[string]string{"key2": "value2", "key3": "value3"}},
+		{"other namespace", func() { store.Namespace("other").Put("key4", "value4") }, map[string]string{"key2": "value2", "key3": "value3"}},
+		{"clear", func() { store.Clear() }, map[string]string{}},
+	}
+
 	for _, tt := range tests {
-		t.Run(tt.key+"="+tt.value, func(t *testing.T) {
-			store.Put(tt.key, tt.value)
+		t.Run(tt.name, func(t *testing.T) {
+			tt.write()
+			if all := store.GetAll(); !maps.Equal(all, tt.expected) {
+				t.Errorf("GetAll() = %v, expected %v", all, tt.expected)
+			}
+		})
+	}
+}
 
-			// Verifica se foi salvo na memória
-			if store.store[tt.key] != tt.value {
-				t.Errorf("Put() failed to store in memory. Expected %s, got %s", tt.value, store.store[tt.key])
+func TestKVStore_GetAllSorted(t *testing.T) {
+	db := setupTestDB(t)
+	defer cleanupTestDB(t, db)
+	defer os.Remove("walog.ndjson")
+
+	Init(db)
+	store := NewKVStore()
+
+	if pairs := store.GetAllSorted(); len(pairs) != 0 {
+		t.Errorf("GetAllSorted() on empty store returned %v, expected none", pairs)
+	}
+
+	for _, key := range []string{"b", "user:2", "a", "user:10", "B", "user:1"} {
+		store.Put(key, "v-"+key)
+	}
+
+	expected := []KeyValue{
+		{"B", "v-B"}, {"a", "v-a"}, {"b", "v-b"},
+		{"user:1", "v-user:1"}, {"user:10", "v-user:10"}, {"user:2", "v-user:2"},
+	}
+	// A ordem não muda entre chamadas, mesmo com o snapshot sendo refeito
+	for i := range 10 {
+		if i%2 == 1 {
+			store.Put("a", "v-a")
+		}
+		if got := store.GetAllSorted(); !slices.Equal(got, expected) {
+			t.Fatalf("GetAllSorted() call %d = %v, expected %v", i, got, expected)
+		}
+	}
+}
+
+func TestKVStore_Watch_InitialValue(t *testing.T) {
+	db := setupTestDB(t)
+	defer cleanupTestDB(t, db)
+	defer os.Remove("walog.ndjson")
+
+	Init(db)
+	store := NewKVStore()
+
+	store.Put("existing", "before")
+
+	watcher := store.Watch("existing", WithInitialValue())
+	defer store.Unwatch(watcher)
+
+	store.Put("existing", "after")
+
+	expected := []string{
+		"Key existing current value before",
+		"Key existing updated to after",
+	}
+
+	for _, want := range expected {
+		select {
+		case msg := <-watcher.Events:
+			if msg.Message != want {
+				t.Errorf("Wrong event message. Expected %s, got %s", want, msg)
 			}
+		case <-time.After(time.Second):
+			t.Fatalf("Timeout waiting for event %q", want)
+		}
+	}
 
-			// Verifica se foi salvo no banco
-			db.View(func(tx *bolt.Tx) error {
-				b := tx.Bucket([]byte(constants.BucketStore))
-				if b == nil {
-					t.Fatal("bucket not found")
-				}
+	// Chave inexistente não gera evento inicial
+	missing := store.Watch("missing", WithInitialValue())
+	defer store.Unwatch(missing)
 
-				storedValue := b.Get([]byte(tt.key))
-				if string(storedValue) != tt.value {
-					t.Errorf("Put() failed to store in database. Expected %s, got %s", tt.value, string(storedValue))
-				}
-				return nil
-			})
-		})
+	select {
+	case msg := <-missing.Events:
+		t.Errorf("unexpected initial event for missing key: %s", msg)
+	default:
+	}
+
+	// Sem a opção o comportamento continua o mesmo
+	plain := store.Watch("existing")
+	defer store.Unwatch(plain)
+
+	select {
+	case msg := <-plain.Events:
+		t.Errorf("unexpected initial event without WithInitialValue: %s", msg)
+	default:
 	}
 }
 
-func TestKVStore_Get(t *testing.T) {
+func TestKVStore_Watch_BufferSize(t *testing.T) {
 	db := setupTestDB(t)
 	defer cleanupTestDB(t, db)
+	defer os.Remove("walog.ndjson")
 
 	Init(db)
+	store := NewKVStore(WithWatchBufferSize(5))
+
+	const updates = 200
+
+	// Buffer por chamada tem prioridade sobre o da store
+	watcher := store.Watch("burst", WithBufferSize(updates))
+	defer store.Unwatch(watcher)
+
+	if got := cap(watcher.Events); got != updates {
+		t.Fatalf("Expected buffer %d, got %d", updates, got)
+	}
+
+	// Nenhuma leitura durante a rajada: tudo precisa caber no buffer
+	for i := 0; i < updates; i++ {
+		store.Put("burst", fmt.Sprintf("v%d", i))
+	}
+
+	for i := 0; i < updates; i++ {
+		want := fmt.Sprintf("Key burst updated to v%d", i)
+		select {
+		case msg := <-watcher.Events:
+			if msg.Message != want {
+				t.Fatalf("Wrong event order. Expected %s, got %s", want, msg)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Timeout waiting for event %d", i)
+		}
+	}
+
+	// Sem a opção o watcher usa o buffer configurado na store
+	plain := store.Watch("burst")
+	defer store.Unwatch(plain)
+
+	if got := cap(plain.Events); got != 5 {
+		t.Errorf("Expected store buffer 5, got %d", got)
+	}
+}
+
+// drainEvents lê tudo que já está no buffer do watcher, sem esperar
+func drainEvents(w *KVWatcher) []string {
+	var events []string
+	for {
+		select {
+		case msg := <-w.Events:
+			events = append(events, msg.Message)
+		default:
+			return events
+		}
+	}
+}
+
+func TestKVStore_Watch_OverflowPolicy(t *testing.T) {
+	defer os.Remove("walog.ndjson")
+
+	tests := []struct {
+		policy   OverflowPolicy
+		expected []string
+	}{
+		{OverflowDropNewest, []string{"v0", "v1"}},
+		{OverflowDropOldest, []string{"v3", "v4"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.policy.String(), func(t *testing.T) {
+			store := NewKVStore(WithBackend(NewMemoryBackend()))
+
+			watcher := store.Watch("k", WithBufferSize(2), WithOverflowPolicy(tt.policy))
+			defer store.Unwatch(watcher)
+
+			// Ninguém lê durante as escritas: o buffer de 2 transborda
+			for i := range 5 {
+				store.Put("k", fmt.Sprintf("v%d", i))
+			}
+
+			var expected []string
+			for _, v := range tt.expected {
+				expected = append(expected, "Key k updated to "+v)
+			}
+			if got := drainEvents(watcher); !reflect.DeepEqual(got, expected) {
+				t.Errorf("Expected events %v, got %v", expected, got)
+			}
+		})
+	}
+}
+
+func TestKVStore_Watch_DroppedEvents(t *testing.T) {
+	defer os.Remove("walog.ndjson")
+
+	store := NewKVStore(WithBackend(NewMemoryBackend()))
+
+	watcher := store.Watch("k", WithBufferSize(2))
+	defer store.Unwatch(watcher)
+
+	for i := range 5 {
+		store.Put("k", fmt.Sprintf("v%d", i))
+	}
+
+	// 2 cabem no buffer, 3 são descartados
+	status := store.Status()
+	if status.DroppedEvents != 3 || store.DroppedEvents() != 3 {
+		t.Errorf("Expected 3 dropped events, got %d", status.DroppedEvents)
+	}
+	if status.WatchQueueDepth != 2 {
+		t.Errorf("Expected queue depth 2, got %d", status.WatchQueueDepth)
+	}
+
+	drainEvents(watcher)
+	if got := store.WatchQueueDepth(); got != 0 {
+		t.Errorf("Expected queue depth 0 after reading, got %d", got)
+	}
+}
+
+func TestKVStore_Watch_OverflowBlock(t *testing.T) {
+	defer os.Remove("walog.ndjson")
+
+	store := NewKVStore(WithBackend(NewMemoryBackend()))
+
+	watcher := store.Watch("k", WithBufferSize(1), WithOverflowPolicy(OverflowBlock))
+
+	// Com o buffer cheio os eventos esperam na fila, sem segurar a escrita
+	const updates = 5
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := range updates {
+			store.Put("k", fmt.Sprintf("v%d", i))
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Expected puts not to wait for the watcher")
+	}
+
+	// Lendo, todos os eventos chegam em ordem
+	for i := range updates {
+		want := fmt.Sprintf("Key k updated to v%d", i)
+		select {
+		case msg := <-watcher.Events:
+			if msg.Message != want {
+				t.Fatalf("Expected %q, got %q", want, msg)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Timeout waiting for event %d", i)
+		}
+	}
+	if got := store.DroppedEvents(); got != 0 {
+		t.Errorf("Expected no dropped events, got %d", got)
+	}
+
+	// O Unwatch fecha o canal mesmo com eventos ainda na fila
+	store.Put("k", "fill")
+	store.Put("k", "queued")
+	store.Unwatch(watcher)
+
+	select {
+	case <-closedAfter(watcher):
+	case <-time.After(time.Second):
+		t.Fatal("Watcher channel not closed after Unwatch")
+	}
+}
+
+func TestKVStore_Watch_OverflowBlockStalled(t *testing.T) {
+	defer os.Remove("walog.ndjson")
+
+	store := NewKVStore(WithBackend(NewMemoryBackend()), WithWatchBlockTimeout(100*time.Millisecond))
+	store.Put("other", "v")
+
+	// Um watcher que nunca lê não segura escritas nem leituras de outras chaves
+	watcher := store.Watch("k", WithBufferSize(1), WithOverflowPolicy(OverflowBlock))
+	for i := range 5 {
+		store.Put("k", fmt.Sprintf("v%d", i))
+	}
+
+	got := make(chan string)
+	go func() {
+		got <- store.Get("other")
+	}()
+	select {
+	case v := <-got:
+		if v != "v" {
+			t.Errorf("Get(other) = %q, want v", v)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Get on another key blocked behind a stalled watcher")
+	}
+
+	// Passado o timeout o watcher é desconectado e sai da store
+	deadline := time.Now().Add(time.Second)
+	for store.WatcherCount() != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("Stalled watcher not removed from the store")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	select {
+	case <-closedAfter(watcher):
+	case <-time.After(time.Second):
+		t.Fatal("Stalled watcher channel not closed")
+	}
+	if got := store.DroppedEvents(); got == 0 {
+		t.Error("Expected the pending events to be counted as dropped")
+	}
+}
+
+// closedAfter fecha o canal devolvido quando Events é fechado, descartando
+// os eventos que ainda estavam nele
+func closedAfter(w *KVWatcher) <-chan struct{} {
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for range w.Events {
+		}
+	}()
+	return closed
+}
+
+func TestKVStore_Unwatch_CleansUpMap(t *testing.T) {
 	store := NewKVStore()
 
-	// Testa chave inexistente
-	value := store.Get("nonexistent")
-	if value != "" {
-		t.Errorf("Get() for nonexistent key should return empty string, got %s", value)
+	var watchers []*KVWatcher
+	for i := range 5 {
+		key := fmt.Sprintf("transient_%d", i)
+		watchers = append(watchers, store.Watch(key), store.Watch(key))
 	}
 
-	// Testa chaves existentes
-	testData := map[string]string{
-		"key1": "value1",
-		"key2": "value2",
-		"":     "empty_key",
+	if count := store.WatcherCount(); count != 10 {
+		t.Fatalf("WatcherCount() = %d, expected 10", count)
 	}
 
-	for key, expectedValue := range testData {
-		store.Put(key, expectedValue)
-		actualValue := store.Get(key)
-		if actualValue != expectedValue {
-			t.Errorf("Get() failed. Expected %s, got %s", expectedValue, actualValue)
+	// Remover um dos dois watchers mantém a chave no mapa
+	store.Unwatch(watchers[0])
+	if _, ok := store.watchers["transient_0"]; !ok {
+		t.Errorf("key removed from watchers map while it still has a watcher")
+	}
+
+	for _, w := range watchers[1:] {
+		store.Unwatch(w)
+	}
+
+	if len(store.watchers) != 0 {
+		t.Errorf("watchers map not empty after Unwatch() of every watcher: %d keys left", len(store.watchers))
+	}
+
+	// Unwatch repetido não fecha o canal de novo
+	store.Unwatch(watchers[0])
+}
+
+func TestKVStore_UnwatchAll(t *testing.T) {
+	store := NewKVStore()
+
+	w1 := store.Watch("key")
+	w2 := store.Watch("key")
+	other := store.Watch("other")
+	nsWatcher := store.Namespace("ns").Watch("key")
+
+	store.UnwatchAll("key")
+
+	for i, w := range []*KVWatcher{w1, w2} {
+		if _, ok := <-w.Events; ok {
+			t.Errorf("watcher %d channel not closed by UnwatchAll()", i)
 		}
 	}
+
+	if count := store.WatcherCount(); count != 2 {
+		t.Errorf("WatcherCount() after UnwatchAll() = %d, expected 2", count)
+	}
+
+	// Unwatch depois do UnwatchAll é inofensivo
+	store.Unwatch(w1)
+
+	store.Unwatch(other)
+	store.Namespace("ns").UnwatchAll("key")
+
+	if _, ok := <-nsWatcher.Events; ok {
+		t.Errorf("namespace watcher channel not closed by UnwatchAll()")
+	}
+	if len(store.watchers) != 0 {
+		t.Errorf("watchers map not empty after all unwatch: %d keys left", len(store.watchers))
+	}
 }
 
 func TestKVStore_Delete(t *testing.T) {
@@ -315,7 +1373,7 @@ func TestKVStore_Unwatch(t *testing.T) {
 	}
 
 	// Remove watcher inexistente (não deve causar erro)
-	store.Unwatch(&KVWatcher{Key: "nonexistent", Events: make(chan string)})
+	store.Unwatch(&KVWatcher{Key: "nonexistent", Events: make(chan WatchEvent)})
 }
 
 func TestKVStore_WatchNotifications(t *testing.T) {
@@ -334,7 +1392,7 @@ func TestKVStore_WatchNotifications(t *testing.T) {
 
 	go func() {
 		for event := range watcher.Events {
-			notifications = append(notifications, event)
+			notifications = append(notifications, event.Message)
 		}
 		done <- true
 	}()
@@ -369,6 +1427,122 @@ func TestKVStore_WatchNotifications(t *testing.T) {
 	}
 }
 
+func TestKVStore_WatchAll(t *testing.T) {
+	defer os.Remove("walog.ndjson")
+
+	store := NewKVStore(WithBackend(NewMemoryBackend()))
+	keyWatcher := store.Watch("a")
+	all := store.WatchAll()
+
+	store.Put("a", "1")
+	store.Put("b", "2")
+	store.Namespace("users").Put("c", "3")
+	store.Delete("b")
+	_, err := store.Txn(context.Background(), nil, []TxnOp{
+		{Type: TxnPut, Key: "a", Value: "4"},
+		{Type: TxnDelete, Key: "a"},
+	}, nil)
+	if err != nil {
+		t.Fatalf("Txn() failed: %v", err)
+	}
+	store.Clear()
+
+	if got := store.WatcherCount(); got != 2 {
+		t.Errorf("expected 2 watchers, got %d", got)
+	}
+
+	// Unwatch fecha o canal; os eventos já entregues continuam no buffer
+	store.Unwatch(all)
+	store.Unwatch(keyWatcher)
+
+	var events []string
+	for ev := range all.Events {
+		events = append(events, ev.Message)
+	}
+	expected := []string{
+		"Key a updated to 1",
+		"Key b updated to 2",
+		"Key c updated to 3 in namespace users",
+		"Key b deleted",
+		"Key a updated to 4",
+		"Key a deleted",
+		"All keys cleared",
+	}
+	if !reflect.DeepEqual(events, expected) {
+		t.Errorf("expected global events %v, got %v", expected, events)
+	}
+
+	// o watcher da chave continua recebendo só os eventos dela, uma vez cada
+	var keyEvents []string
+	for ev := range keyWatcher.Events {
+		keyEvents = append(keyEvents, ev.Message)
+	}
+	// a já tinha sido apagada pela txn, então o Clear não gera outro delete
+	expectedKey := []string{"Key a updated to 1", "Key a updated to 4", "Key a deleted"}
+	if !reflect.DeepEqual(keyEvents, expectedKey) {
+		t.Errorf("expected events %v for key a, got %v", expectedKey, keyEvents)
+	}
+
+	if got := store.WatcherCount(); got != 0 {
+		t.Errorf("expected no watchers after Unwatch, got %d", got)
+	}
+}
+
+func TestKVStore_Close(t *testing.T) {
+	defer os.Remove("walog.ndjson")
+
+	store := NewKVStore(WithBackend(NewMemoryBackend()))
+	store.Put("a", "1")
+
+	watchers := []*KVWatcher{
+		store.Watch("a"),
+		store.Watch("a"),
+		store.Namespace("users").Watch("b"),
+		store.WatchAll(),
+	}
+
+	if err := store.Close(); err != nil {
+		t.Fatalf("Close() failed: %v", err)
+	}
+
+	for i, w := range watchers {
+		select {
+		case _, ok := <-w.Events:
+			if ok {
+				t.Errorf("watcher %d: expected a closed channel, got an event", i)
+			}
+		case <-time.After(time.Second):
+			t.Errorf("watcher %d: channel was not closed", i)
+		}
+	}
+	if got := store.WatcherCount(); got != 0 {
+		t.Errorf("expected no watchers after Close, got %d", got)
+	}
+
+	// Unwatch depois do Close não fecha o canal de novo
+	store.Unwatch(watchers[0])
+	store.Unwatch(watchers[3])
+
+	if err := store.Put("a", "2"); !errors.Is(err, ErrClosed) {
+		t.Errorf("Put() after Close returned %v, expected ErrClosed", err)
+	}
+	if err := store.Delete("a"); !errors.Is(err, ErrClosed) {
+		t.Errorf("Delete() after Close returned %v, expected ErrClosed", err)
+	}
+	if _, err := store.Txn(context.Background(), nil, []TxnOp{{Type: TxnPut, Key: "a", Value: "2"}}, nil); !errors.Is(err, ErrClosed) {
+		t.Errorf("Txn() after Close returned %v, expected ErrClosed", err)
+	}
+	// as leituras continuam
+	if got := store.Get("a"); got != "1" {
+		t.Errorf("Get(a) after Close = %q, expected 1", got)
+	}
+
+	// um Watch depois do Close já vem fechado
+	if _, ok := <-store.Watch("a").Events; ok {
+		t.Error("Watch() after Close returned an open channel")
+	}
+}
+
 func TestKVStore_Concurrency(t *testing.T) {
 	db := setupTestDB(t)
 	defer cleanupTestDB(t, db)
@@ -410,3 +1584,98 @@ func TestKVStore_Concurrency(t *testing.T) {
 		t.Errorf("Concurrency test: expected %d items, got %d", expectedCount, len(all))
 	}
 }
+
+func TestKVStore_WatchEvents(t *testing.T) {
+	defer os.Remove("walog.ndjson")
+
+	store := NewKVStore(WithBackend(NewMemoryBackend()))
+	store.Put("key", "v0")
+
+	w := store.Watch("key", WithInitialValue())
+	all := store.WatchAll()
+	defer store.Unwatch(w)
+	defer store.Unwatch(all)
+
+	store.Put("key", "v1")
+	putRev := store.Revision("key")
+	store.Delete("key")
+	store.Clear()
+
+	want := []WatchEvent{
+		{Type: EventPut, Key: "key", Value: "v0", Revision: putRev - 1, Message: "Key key current value v0"},
+		{Type: EventPut, Key: "key", Value: "v1", Revision: putRev, Message: "Key key updated to v1"},
+		{Type: EventDelete, Key: "key", Revision: putRev + 1, Message: "Key key deleted"},
+	}
+	for _, e := range want {
+		select {
+		case got := <-w.Events:
+			if got != e {
+				t.Errorf("key watcher got %+v, expected %+v", got, e)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("key watcher: timed out waiting for %+v", e)
+		}
+	}
+
+	//o WatchAll não recebe o valor inicial, mas recebe o Clear
+	want = append(want[1:], WatchEvent{Type: EventClear, Revision: putRev + 2, Message: "All keys cleared"})
+	for _, e := range want {
+		select {
+		case got := <-all.Events:
+			if got != e {
+				t.Errorf("WatchAll got %+v, expected %+v", got, e)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("WatchAll: timed out waiting for %+v", e)
+		}
+	}
+}
+
+// BenchmarkKVStore_WriteDuringGetAll mede a latência das escritas enquanto
+// outra goroutine serializa continuamente um GetAll de 10k chaves. "locked"
+// serializa segurando o read lock; "snapshot" usa o GetAll atual. O writer é
+// o PutFromDb para medir só a disputa pelo lock, sem o custo de disco.
+func BenchmarkKVStore_WriteDuringGetAll(b *testing.B) {
+	modes := map[string]func(kv *KVStore){
+		"locked": func(kv *KVStore) {
+			kv.mu.RLock()
+			json.Marshal(kv.store)
+			kv.mu.RUnlock()
+		},
+		"snapshot": func(kv *KVStore) {
+			json.Marshal(kv.GetAll())
+		},
+	}
+
+	for name, readAll := range modes {
+		b.Run(name, func(b *testing.B) {
+			kv := NewKVStore()
+			for i := 0; i < 10000; i++ {
+				kv.PutFromDb(fmt.Sprintf("key_%d", i), fmt.Sprintf("value_%d", i))
+			}
+
+			stop := make(chan struct{})
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				for {
+					select {
+					case <-stop:
+						return
+					default:
+						readAll(kv)
+					}
+				}
+			}()
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				kv.PutFromDb(fmt.Sprintf("key_%d", i%10000), "updated")
+			}
+			b.StopTimer()
+
+			close(stop)
+			<-done
+		})
+	}
+}
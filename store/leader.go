@@ -0,0 +1,121 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/raft"
+)
+
+// leaderChangesBuffer é quantas mudanças de líder ficam pendentes por assinante;
+// além disso o raft descarta as observações em vez de bloquear.
+const leaderChangesBuffer = 8
+
+// LeaderChanges devolve um canal que recebe uma raft.Observation (com
+// raft.LeaderObservation em Data) a cada troca de líder, inclusive quando o
+// cluster fica sem líder. Quem assina deve chamar StopLeaderChanges ao terminar.
+// Sem raft o canal nunca recebe nada.
+func (kv *KVStore) LeaderChanges() <-chan raft.Observation {
+	ch := make(chan raft.Observation, leaderChangesBuffer)
+	if kv.raft == nil {
+		return ch
+	}
+
+	observer := raft.NewObserver(ch, false, func(o *raft.Observation) bool {
+		_, ok := o.Data.(raft.LeaderObservation)
+		return ok
+	})
+
+	kv.mu.Lock()
+	if kv.leaderObservers == nil {
+		kv.leaderObservers = make(map[<-chan raft.Observation]*raft.Observer)
+	}
+	kv.leaderObservers[ch] = observer
+	kv.mu.Unlock()
+
+	kv.raft.RegisterObserver(observer)
+	return ch
+}
+
+// StopLeaderChanges cancela a assinatura feita com LeaderChanges
+func (kv *KVStore) StopLeaderChanges(ch <-chan raft.Observation) {
+	kv.mu.Lock()
+	observer, ok := kv.leaderObservers[ch]
+	delete(kv.leaderObservers, ch)
+	kv.mu.Unlock()
+
+	if ok {
+		kv.raft.DeregisterObserver(observer)
+	}
+}
+
+// IsLeader informa se este nó é o líder do raft no momento
+func (kv *KVStore) IsLeader() bool {
+	return kv.raft != nil && kv.raft.State() == raft.Leader
+}
+
+// IsFollower informa se este nó está em um cluster raft sem ser o líder
+// (follower ou candidato). Um nó sem raft não é follower.
+func (kv *KVStore) IsFollower() bool {
+	return kv.raft != nil && kv.raft.State() != raft.Leader
+}
+
+// Staleness estima o quanto a memória local pode estar atrasada em relação ao
+// líder: em um follower é o tempo desde o último contato do líder. No líder,
+// ou sem raft, é 0. Não inclui entradas recebidas e ainda não aplicadas.
+func (kv *KVStore) Staleness() (time.Duration, error) {
+	if kv.raft == nil || kv.raft.State() == raft.Leader {
+		return 0, nil
+	}
+
+	last := kv.raft.LastContact()
+	if last.IsZero() {
+		return 0, ErrNoLeaderContact
+	}
+	return time.Since(last), nil
+}
+
+// StepDown transfere a liderança para outro nó, por exemplo antes de reiniciar
+// este num rolling upgrade, sem esperar uma eleição por timeout. Com target
+// vazio o raft escolhe o follower mais atualizado; senão a liderança vai para
+// o servidor com esse id. Espera a transferência terminar, limitada ao
+// deadline do ctx. Retorna ErrNotLeader se este nó não for o líder.
+func (kv *KVStore) StepDown(ctx context.Context, target string) error {
+	if kv.raft == nil {
+		return ErrRaftNotOpen
+	}
+	if kv.raft.State() != raft.Leader {
+		return ErrNotLeader
+	}
+
+	var f raft.Future
+	if target == "" {
+		f = kv.raft.LeadershipTransfer()
+	} else {
+		cf := kv.raft.GetConfiguration()
+		if err := cf.Error(); err != nil {
+			return err
+		}
+		var addr raft.ServerAddress
+		for _, s := range cf.Configuration().Servers {
+			if s.ID == raft.ServerID(target) && target != kv.nodeID {
+				addr = s.Address
+			}
+		}
+		if addr == "" {
+			return fmt.Errorf("%w: %q", ErrUnknownServer, target)
+		}
+		f = kv.raft.LeadershipTransferToServer(raft.ServerID(target), addr)
+	}
+
+	if err := waitFuture(ctx, f); err != nil {
+		if errors.Is(err, raft.ErrNotLeader) {
+			return ErrNotLeader
+		}
+		return fmt.Errorf("leadership transfer: %w", err)
+	}
+	kv.logger.Info("stepped down as raft leader", "target", target)
+	return nil
+}
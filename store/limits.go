@@ -0,0 +1,69 @@
+package store
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	// DefaultMaxKeySize é o maior tamanho de chave que o bbolt aceita
+	DefaultMaxKeySize = bolt.MaxKeySize
+	// DefaultMaxValueSize limita cada valor a 1MB
+	DefaultMaxValueSize = 1 << 20
+)
+
+// Limits define os tamanhos aceitos pelo Put. Campos zerados usam os valores
+// padrão. A chave vazia é sempre recusada com ErrEmptyKey: o bbolt não
+// consegue gravá-la.
+type Limits struct {
+	MaxKeySize   int
+	MaxValueSize int
+	// Deprecated: a chave vazia é sempre recusada; o campo não tem efeito.
+	RejectEmptyKeys bool
+	// RejectControlChars recusa chaves com \n, \t e outros caracteres de
+	// controle. O WAL escapa essas chaves no JSON, então uma linha continua
+	// sendo uma entrada; a opção protege ferramentas que leem o WAL ou a saída
+	// do cliente linha a linha sem decodificar o JSON.
+	RejectControlChars bool
+}
+
+// DefaultLimits retorna os limites usados quando nada é configurado
+func DefaultLimits() Limits {
+	return Limits{
+		MaxKeySize:   DefaultMaxKeySize,
+		MaxValueSize: DefaultMaxValueSize,
+	}
+}
+
+func (l Limits) validate(key, value string) error {
+	maxKey := l.MaxKeySize
+	if maxKey <= 0 {
+		maxKey = DefaultMaxKeySize
+	}
+
+	maxValue := l.MaxValueSize
+	if maxValue <= 0 {
+		maxValue = DefaultMaxValueSize
+	}
+
+	if key == "" {
+		return ErrEmptyKey
+	}
+
+	if l.RejectControlChars && strings.ContainsFunc(key, unicode.IsControl) {
+		return fmt.Errorf("%w: %q", ErrInvalidKey, key)
+	}
+
+	if len(key) > maxKey {
+		return fmt.Errorf("%w: %d bytes (max %d)", ErrKeyTooLarge, len(key), maxKey)
+	}
+
+	if len(value) > maxValue {
+		return fmt.Errorf("%w: %d bytes (max %d)", ErrValueTooLarge, len(value), maxValue)
+	}
+
+	return nil
+}
@@ -0,0 +1,95 @@
+package store
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestLimits_Validate(t *testing.T) {
+	limits := Limits{MaxKeySize: 4, MaxValueSize: 8}
+
+	tests := []struct {
+		name    string
+		key     string
+		value   string
+		wantErr error
+	}{
+		{"key_at_limit", strings.Repeat("k", 4), "v", nil},
+		{"key_over_limit", strings.Repeat("k", 5), "v", ErrKeyTooLarge},
+		{"value_at_limit", "k", strings.Repeat("v", 8), nil},
+		{"value_over_limit", "k", strings.Repeat("v", 9), ErrValueTooLarge},
+		{"empty_key", "", "v", ErrEmptyKey},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := limits.validate(tt.key, tt.value)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("validate() = %v, expected %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLimits_EmptyKey(t *testing.T) {
+	// Recusada mesmo sem nenhum limite configurado
+	if err := (Limits{}).validate("", "value"); !errors.Is(err, ErrEmptyKey) {
+		t.Errorf("validate() with empty key = %v, expected %v", err, ErrEmptyKey)
+	}
+}
+
+func TestLimits_RejectControlChars(t *testing.T) {
+	// Por padrão a chave com \n é aceita: o WAL escapa ela no JSON
+	if err := (Limits{}).validate("line\nbreak", "v"); err != nil {
+		t.Errorf("validate() with newline key = %v, expected nil by default", err)
+	}
+
+	limits := Limits{RejectControlChars: true}
+	for _, key := range []string{"line\nbreak", "tab\tkey", "nul\x00"} {
+		if err := limits.validate(key, "v"); !errors.Is(err, ErrInvalidKey) || !IsValidationError(err) {
+			t.Errorf("validate(%q) = %v, expected %v", key, err, ErrInvalidKey)
+		}
+	}
+	if err := limits.validate("user:1 ção", "line\nvalue"); err != nil {
+		t.Errorf("validate() with printable key = %v, expected nil", err)
+	}
+}
+
+func TestLimits_Defaults(t *testing.T) {
+	// Campos zerados caem nos limites padrão
+	var limits Limits
+
+	if err := limits.validate(strings.Repeat("k", DefaultMaxKeySize), "v"); err != nil {
+		t.Errorf("validate() at default key limit failed: %v", err)
+	}
+	if err := limits.validate(strings.Repeat("k", DefaultMaxKeySize+1), "v"); !errors.Is(err, ErrKeyTooLarge) {
+		t.Errorf("validate() over default key limit = %v, expected %v", err, ErrKeyTooLarge)
+	}
+	if err := limits.validate("k", strings.Repeat("v", DefaultMaxValueSize+1)); !errors.Is(err, ErrValueTooLarge) {
+		t.Errorf("validate() over default value limit = %v, expected %v", err, ErrValueTooLarge)
+	}
+}
+
+func TestKVStore_PutRespectsLimits(t *testing.T) {
+	db := setupTestDB(t)
+	defer cleanupTestDB(t, db)
+	defer os.Remove("walog.ndjson")
+
+	Init(db)
+	store := NewKVStore(WithLimits(Limits{MaxKeySize: 4, MaxValueSize: 8}))
+
+	if err := store.Put("long_key", "v"); !errors.Is(err, ErrKeyTooLarge) {
+		t.Errorf("Put() with long key = %v, expected %v", err, ErrKeyTooLarge)
+	}
+
+	// Nada deve ter sido gravado quando a validação falha
+	if _, ok := store.Lookup("long_key"); ok {
+		t.Error("Put() stored a key that failed validation")
+	}
+
+	if err := store.Put("key", strings.Repeat("v", 8)); err != nil {
+		t.Errorf("Put() at value limit failed: %v", err)
+	}
+}
@@ -0,0 +1,184 @@
+package store
+
+import (
+	"container/list"
+	"fmt"
+	"sync"
+)
+
+// EvictionMode define o destino de uma chave removida pelo limite de WithMaxEntries
+type EvictionMode int
+
+const (
+	// EvictMemory tira a chave só da memória: ela continua no backend e volta
+	// no próximo Get pelo read-through
+	EvictMemory EvictionMode = iota
+	// EvictDelete apaga a chave também do backend e registra o delete no WAL.
+	// Os watchers não são avisados, e a remoção não é replicada pelo raft.
+	EvictDelete
+)
+
+func (m EvictionMode) String() string {
+	switch m {
+	case EvictMemory:
+		return "memory"
+	case EvictDelete:
+		return "delete"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseEvictionMode converte o nome usado em flags ("memory" ou "delete")
+func ParseEvictionMode(s string) (EvictionMode, error) {
+	for _, m := range []EvictionMode{EvictMemory, EvictDelete} {
+		if m.String() == s {
+			return m, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown eviction mode %q, expected memory or delete", s)
+}
+
+// lru guarda a ordem de acesso das chaves do namespace padrão. Tem lock
+// próprio porque o Get só segura o read lock da store.
+type lru struct {
+	mu    sync.Mutex
+	max   int
+	mode  EvictionMode
+	order *list.List // frente = usada mais recentemente
+	items map[string]*list.Element
+}
+
+func newLRU(max int, mode EvictionMode) *lru {
+	return &lru{
+		max:   max,
+		mode:  mode,
+		order: list.New(),
+		items: make(map[string]*list.Element),
+	}
+}
+
+// add marca a chave como a mais recente e devolve as chaves que passaram do
+// limite, da menos recente para a mais recente
+func (l *lru) add(key string) []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if e, ok := l.items[key]; ok {
+		l.order.MoveToFront(e)
+	} else {
+		l.items[key] = l.order.PushFront(key)
+	}
+
+	var evicted []string
+	for l.order.Len() > l.max {
+		e := l.order.Back()
+		key := l.order.Remove(e).(string)
+		delete(l.items, key)
+		evicted = append(evicted, key)
+	}
+	return evicted
+}
+
+// promote marca um acesso de leitura. Uma chave que não está na lista (já
+// removida por uma escrita concorrente) é ignorada.
+func (l *lru) promote(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if e, ok := l.items[key]; ok {
+		l.order.MoveToFront(e)
+	}
+}
+
+func (l *lru) remove(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if e, ok := l.items[key]; ok {
+		l.order.Remove(e)
+		delete(l.items, key)
+	}
+}
+
+func (l *lru) reset() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.order.Init()
+	clear(l.items)
+}
+
+// trackLocked registra a escrita da chave no LRU e despeja o que passou do
+// limite. Deve ser chamado com kv.mu travado para escrita, depois de gravar
+// a chave. Com persisted, as chaves despejadas só saem da memória, qualquer
+// que seja o modo (ex.: chaves carregadas do próprio banco). Retorna as
+// chaves apagadas do banco pelo EvictDelete, para o OnDelete.
+func (kv *KVStore) trackLocked(key string, persisted bool) []mutation {
+	if kv.lru == nil {
+		return nil
+	}
+
+	var removed []mutation
+	for _, victim := range kv.lru.add(key) {
+		value := kv.store[victim]
+		delete(kv.store, victim)
+		delete(kv.revisions, victim)
+		delete(kv.times, victim)
+		kv.invalidateSnapshot()
+
+		if persisted || kv.lru.mode != EvictDelete {
+			continue
+		}
+
+		seq := LogDelete(victim)
+		err := kv.storage().Update(func(tx Backend) error {
+			if err := kv.indexValue(tx, victim, "", true); err != nil {
+				return err
+			}
+			if err := tx.Delete(kv.bucket, []byte(victim)); err != nil {
+				return err
+			}
+			if err := kv.persistSequence(tx, victim, seq, true); err != nil {
+				return err
+			}
+			return kv.persistRevision(tx, victim, 0, keyTimes{}, true)
+		})
+		if err != nil {
+			kv.logger.Error("failed to delete evicted key", "key", victim, "error", err)
+			continue
+		}
+		removed = append(removed, mutation{key: victim, value: value, deleted: true})
+	}
+	return removed
+}
+
+// scan chama fn para cada par do namespace padrão. Com o LRU ligado a memória
+// só tem parte das chaves, então o percurso é feito no backend, que tem todas;
+// sem ele, na memória sob o read lock.
+func (kv *KVStore) scan(fn func(key, value string)) {
+	if kv.lru == nil {
+		kv.mu.RLock()
+		defer kv.mu.RUnlock()
+
+		for key, value := range kv.store {
+			fn(key, value)
+		}
+		return
+	}
+
+	err := kv.ForEachPersisted(func(key, value string) bool {
+		fn(key, value)
+		return true
+	})
+	if err != nil {
+		kv.logger.Warn("scan of evicted keys failed", "error", err)
+	}
+}
+
+// untrackLocked tira a chave do LRU quando ela é apagada
+func (kv *KVStore) untrackLocked(key string) {
+	if kv.lru != nil {
+		kv.lru.remove(key)
+	}
+}
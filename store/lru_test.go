@@ -0,0 +1,187 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+	"testing"
+)
+
+// inMemory informa se a chave está no mapa, sem passar pelo read-through
+func inMemory(kv *KVStore, key string) bool {
+	kv.mu.RLock()
+	defer kv.mu.RUnlock()
+	_, ok := kv.store[key]
+	return ok
+}
+
+func TestKVStore_MaxEntries_EvictionOrder(t *testing.T) {
+	defer os.Remove("walog.ndjson")
+
+	backend := NewMemoryBackend()
+	store := NewKVStore(WithBackend(backend), WithMaxEntries(3, EvictMemory))
+
+	store.Put("a", "1")
+	store.Put("b", "2")
+	store.Put("c", "3")
+
+	// O Get conta como uso: b passa a ser a menos recente
+	store.Get("a")
+	store.Put("d", "4")
+
+	for key, want := range map[string]bool{"a": true, "b": false, "c": true, "d": true} {
+		if got := inMemory(store, key); got != want {
+			t.Errorf("key %s in memory = %v, expected %v", key, got, want)
+		}
+	}
+	// As chaves despejadas continuam contando
+	if n := store.Count(); n != 4 {
+		t.Errorf("Count() = %d, expected 4", n)
+	}
+
+	// A chave despejada continua no backend e volta pelo read-through,
+	// despejando a próxima menos recente (c)
+	if got := store.Get("b"); got != "2" {
+		t.Errorf("Get() of evicted key = %q, expected 2", got)
+	}
+	if inMemory(store, "c") || !inMemory(store, "b") {
+		t.Errorf("read-through did not evict c in favor of b: %v", store.GetAll())
+	}
+
+	// Um delete libera a vaga sem despejar ninguém
+	store.Delete("a")
+	store.Put("e", "5")
+	if !inMemory(store, "b") || !inMemory(store, "d") || !inMemory(store, "e") {
+		t.Errorf("unexpected eviction after delete: %v", store.GetAll())
+	}
+}
+
+func TestKVStore_MaxEntries_EvictedKeys(t *testing.T) {
+	defer os.Remove("walog.ndjson")
+
+	store := NewKVStore(WithBackend(NewMemoryBackend()), WithMaxEntries(1, EvictMemory))
+
+	store.Put("a", "1")
+	_, rev, _ := store.LookupRevision("a")
+
+	// evict tira a da memória escrevendo outra chave; cada caso começa com a despejada
+	evict := func() {
+		t.Helper()
+		store.Put("z", "filler")
+		if inMemory(store, "a") {
+			t.Fatal("a still in memory after the filler write")
+		}
+	}
+
+	evict()
+	if stored, err := store.PutIfAbsent("a", "2"); err != nil || stored {
+		t.Errorf("PutIfAbsent() on an evicted key = %v, %v, expected false", stored, err)
+	}
+
+	evict()
+	if _, err := store.PutIfVersion("a", "2", 0); !errors.Is(err, ErrRevisionMismatch) {
+		t.Errorf("PutIfVersion(0) on an evicted key = %v, expected ErrRevisionMismatch", err)
+	}
+
+	evict()
+	res, err := store.Txn(context.Background(),
+		[]Compare{{Key: "a", Target: CompareValue, Value: "1"}, {Key: "a", Target: CompareRevision, Revision: rev}},
+		[]TxnOp{{Type: TxnPut, Key: "t", Value: "then"}}, nil)
+	if err != nil || !res.Succeeded {
+		t.Errorf("Txn() comparing an evicted key = %+v, %v, expected the then branch", res, err)
+	}
+
+	evict()
+	if got := store.MultiGet([]string{"a", "z", "missing"}); len(got) != 2 || got["a"] != "1" {
+		t.Errorf("MultiGet() = %v, expected a and z", got)
+	}
+
+	evict()
+	if n := store.Count(); n != 3 {
+		t.Errorf("Count() = %d, expected 3", n)
+	}
+	if n := store.CountPrefix("a"); n != 1 {
+		t.Errorf("CountPrefix(a) = %d, expected 1", n)
+	}
+	if got := store.Keys(""); !slices.Equal(got, []string{"a", "t", "z"}) {
+		t.Errorf("Keys() = %v, expected [a t z]", got)
+	}
+	if got := store.MultiScan([]string{"a"}); got["a"]["a"] != "1" {
+		t.Errorf("MultiScan() = %v, expected a=1", got)
+	}
+
+	// Com a revisão certa o PutIfVersion grava a chave despejada
+	evict()
+	if _, err := store.PutIfVersion("a", "2", rev); err != nil {
+		t.Errorf("PutIfVersion() with the current revision failed: %v", err)
+	}
+	if got := store.Get("a"); got != "2" {
+		t.Errorf("Get() after PutIfVersion() = %q, expected 2", got)
+	}
+}
+
+func TestKVStore_MaxEntries_EvictDelete(t *testing.T) {
+	defer os.Remove("walog.ndjson")
+
+	backend := NewMemoryBackend()
+	store := NewKVStore(WithBackend(backend), WithMaxEntries(2, EvictDelete))
+
+	store.Put("a", "1")
+	store.Put("b", "2")
+	store.Put("c", "3")
+
+	if v, _ := backend.Get(store.bucket, []byte("a")); v != nil {
+		t.Errorf("evicted key still in the backend: %q", v)
+	}
+	if _, ok := store.Lookup("a"); ok {
+		t.Error("Lookup() found a key evicted with EvictDelete")
+	}
+	if got := store.Get("c"); got != "3" {
+		t.Errorf("Get() = %q, expected 3", got)
+	}
+}
+
+func TestKVStore_MaxEntries_StaysAtCap(t *testing.T) {
+	defer os.Remove("walog.ndjson")
+
+	const max = 50
+	store := NewKVStore(WithBackend(NewMemoryBackend()), WithMaxEntries(max, EvictMemory))
+
+	var wg sync.WaitGroup
+	for w := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 250 {
+				key := fmt.Sprintf("w%d-key%d", w, i)
+				store.Put(key, "v")
+				store.Get(fmt.Sprintf("w%d-key%d", w, i/2))
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := store.memoryCount(); n != max {
+		t.Errorf("memoryCount() = %d, expected the cap %d", n, max)
+	}
+	if n := len(store.GetAll()); n != max {
+		t.Errorf("GetAll() has %d keys, expected the cap %d", n, max)
+	}
+	if n := store.lru.order.Len(); n != max {
+		t.Errorf("LRU tracks %d keys, expected %d", n, max)
+	}
+}
+
+func TestParseEvictionMode(t *testing.T) {
+	for _, m := range []EvictionMode{EvictMemory, EvictDelete} {
+		if got, err := ParseEvictionMode(m.String()); err != nil || got != m {
+			t.Errorf("ParseEvictionMode(%q) = %v, %v", m.String(), got, err)
+		}
+	}
+	if _, err := ParseEvictionMode("lfu"); err == nil {
+		t.Error("ParseEvictionMode() accepted an unknown mode")
+	}
+}
@@ -0,0 +1,97 @@
+package store
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"time"
+)
+
+// Cada chave do namespace padrão guarda quando foi criada e quando foi
+// escrita pela última vez, num bucket ao lado das revisões e na mesma
+// transação da escrita. Apagar e recriar a chave reinicia o CreatedAt.
+// Chaves gravadas antes dos metadados têm os tempos zerados.
+
+// keyTimes são os tempos de uma chave, guardados em nanossegundos no bbolt
+type keyTimes struct {
+	created time.Time
+	updated time.Time
+}
+
+// Entry é o valor de uma chave junto com os metadados dela
+type Entry struct {
+	Value     string
+	Revision  uint64
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// LookupEntry funciona como o LookupRevision e também retorna quando a chave
+// foi criada e atualizada
+func (kv *KVStore) LookupEntry(key string) (Entry, bool) {
+	kv.mu.RLock()
+	value, ok := kv.store[key]
+	e := kv.entryLocked(key, value)
+	kv.mu.RUnlock()
+
+	if ok {
+		if kv.lru != nil {
+			kv.lru.promote(key)
+		}
+		return e, true
+	}
+	return kv.readThrough(key)
+}
+
+// GetEntry funciona como o LookupEntry, mas devolve ErrNotFound quando a
+// chave não existe e o erro do contexto se quem chamou já desistiu
+func (kv *KVStore) GetEntry(ctx context.Context, key string) (Entry, error) {
+	if err := ctx.Err(); err != nil {
+		return Entry{}, err
+	}
+
+	e, ok := kv.LookupEntry(key)
+	if !ok {
+		return Entry{}, fmt.Errorf("%w: %q", ErrNotFound, key)
+	}
+	return e, nil
+}
+
+// entryLocked monta a Entry com os metadados em memória; deve ser chamado com kv.mu travado
+func (kv *KVStore) entryLocked(key, value string) Entry {
+	t := kv.times[key]
+	return Entry{Value: value, Revision: kv.revisions[key], CreatedAt: t.created, UpdatedAt: t.updated}
+}
+
+// stamp calcula os tempos da chave para uma escrita em now, sem alterar a
+// memória. Deve ser chamado com kv.mu travado.
+func (kv *KVStore) stamp(key string, now time.Time) keyTimes {
+	t := kv.times[key]
+	if t.created.IsZero() {
+		t.created = now
+	}
+	t.updated = now
+	return t
+}
+
+func (kv *KVStore) timesBucket() []byte {
+	return []byte(string(kv.bucket) + ".times")
+}
+
+func encodeTimes(t keyTimes) []byte {
+	b := make([]byte, 16)
+	binary.BigEndian.PutUint64(b, uint64(t.created.UnixNano()))
+	binary.BigEndian.PutUint64(b[8:], uint64(t.updated.UnixNano()))
+	return b
+}
+
+// decodeTimes desfaz o encodeTimes; ok é false para um valor corrompido
+func decodeTimes(b []byte) (keyTimes, bool) {
+	if len(b) != 16 {
+		return keyTimes{}, false
+	}
+	return keyTimes{
+		created: time.Unix(0, int64(binary.BigEndian.Uint64(b))),
+		updated: time.Unix(0, int64(binary.BigEndian.Uint64(b[8:]))),
+	}, true
+}
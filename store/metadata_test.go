@@ -0,0 +1,95 @@
+package store
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestKVStore_EntryTimes(t *testing.T) {
+	defer os.Remove("walog.ndjson")
+
+	store := NewKVStore(WithBackend(NewMemoryBackend()))
+
+	before := time.Now()
+	store.Put("key", "v1")
+	first, ok := store.LookupEntry("key")
+	if !ok || first.Value != "v1" {
+		t.Fatalf("LookupEntry() = %+v, %v", first, ok)
+	}
+	if first.CreatedAt.Before(before) || !first.UpdatedAt.Equal(first.CreatedAt) {
+		t.Errorf("First write: CreatedAt %v, UpdatedAt %v, expected equal and after %v", first.CreatedAt, first.UpdatedAt, before)
+	}
+
+	// Sobrescrever muda só o UpdatedAt
+	time.Sleep(2 * time.Millisecond)
+	store.Put("key", "v2")
+	second, _ := store.LookupEntry("key")
+	if !second.CreatedAt.Equal(first.CreatedAt) {
+		t.Errorf("Overwrite changed CreatedAt from %v to %v", first.CreatedAt, second.CreatedAt)
+	}
+	if !second.UpdatedAt.After(first.UpdatedAt) {
+		t.Errorf("Overwrite did not advance UpdatedAt: %v -> %v", first.UpdatedAt, second.UpdatedAt)
+	}
+	if second.Revision != first.Revision+1 {
+		t.Errorf("Revision = %d, expected %d", second.Revision, first.Revision+1)
+	}
+
+	// Apagar e recriar reinicia o CreatedAt
+	store.Delete("key")
+	if e, ok := store.LookupEntry("key"); ok || !e.UpdatedAt.IsZero() {
+		t.Errorf("LookupEntry() after delete = %+v, %v", e, ok)
+	}
+	time.Sleep(2 * time.Millisecond)
+	store.Put("key", "v3")
+	if third, _ := store.LookupEntry("key"); !third.CreatedAt.After(second.UpdatedAt) {
+		t.Errorf("Recreated key kept the old CreatedAt %v", third.CreatedAt)
+	}
+
+	// Txn também registra os tempos
+	if _, err := store.Txn(t.Context(), nil, []TxnOp{{Type: TxnPut, Key: "txn", Value: "v"}}, nil); err != nil {
+		t.Fatalf("Txn() failed: %v", err)
+	}
+	if e, _ := store.LookupEntry("txn"); e.CreatedAt.IsZero() || !e.UpdatedAt.Equal(e.CreatedAt) {
+		t.Errorf("Txn() put has times %v / %v", e.CreatedAt, e.UpdatedAt)
+	}
+}
+
+func TestKVStore_EntryTimesPersisted(t *testing.T) {
+	os.Remove("walog.ndjson")
+	defer os.Remove("walog.ndjson")
+
+	backend := NewMemoryBackend()
+	store := NewKVStore(WithBackend(backend))
+
+	store.Put("key", "v1")
+	time.Sleep(2 * time.Millisecond)
+	store.Put("key", "v2")
+	want, _ := store.LookupEntry("key")
+
+	// Simula um restart com o mesmo backend
+	restarted := NewKVStore(WithBackend(backend))
+	if err := restarted.LoadKeys(); err != nil {
+		t.Fatalf("LoadKeys() failed: %v", err)
+	}
+	if err := restarted.LoadRevisions(); err != nil {
+		t.Fatalf("LoadRevisions() failed: %v", err)
+	}
+	got, _ := restarted.LookupEntry("key")
+	if !got.CreatedAt.Equal(want.CreatedAt) || !got.UpdatedAt.Equal(want.UpdatedAt) {
+		t.Errorf("Times after reload = %v / %v, expected %v / %v", got.CreatedAt, got.UpdatedAt, want.CreatedAt, want.UpdatedAt)
+	}
+
+	// O read-through também traz os tempos do backend
+	cold := NewKVStore(WithBackend(backend))
+	if e, ok := cold.LookupEntry("key"); !ok || !e.UpdatedAt.Equal(want.UpdatedAt) {
+		t.Errorf("Read-through entry = %+v, expected UpdatedAt %v", e, want.UpdatedAt)
+	}
+
+	// O WAL guarda a criação ao lado do Timestamp da escrita
+	entry := readLastLogEntry(t, "walog.ndjson")
+	if entry.CreatedAt != want.CreatedAt.UnixNano() || entry.Timestamp != want.UpdatedAt.UnixNano() {
+		t.Errorf("WAL entry has CreatedAt %d and Timestamp %d, expected %d and %d",
+			entry.CreatedAt, entry.Timestamp, want.CreatedAt.UnixNano(), want.UpdatedAt.UnixNano())
+	}
+}
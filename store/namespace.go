@@ -0,0 +1,216 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"strings"
+)
+
+// namespaceSeparator separa o bucket da store do nome do namespace no bbolt
+const namespaceSeparator = "/"
+
+// Namespace é uma visão da store restrita a um namespace. Cada namespace
+// é gravado em um bucket próprio do bbolt, então a mesma chave pode ter
+// valores independentes em namespaces diferentes.
+type Namespace struct {
+	kv   *KVStore
+	name string
+}
+
+// Namespace retorna a visão do namespace informado. O nome vazio é o
+// namespace padrão, o mesmo usado por Put/Get/Delete da KVStore.
+func (kv *KVStore) Namespace(name string) *Namespace {
+	return &Namespace{kv: kv, name: name}
+}
+
+func (n *Namespace) Name() string {
+	return n.name
+}
+
+func (n *Namespace) Put(key, value string) error {
+	return n.kv.applyLocal(context.Background(), command{Op: "put", Namespace: n.name, Key: key, Value: value})
+}
+
+func (n *Namespace) Get(key string) string {
+	value, _ := n.Lookup(key)
+	return value
+}
+
+func (n *Namespace) Lookup(key string) (string, bool) {
+	n.kv.mu.RLock()
+	defer n.kv.mu.RUnlock()
+
+	value, ok := n.kv.data(n.name, false)[key]
+	return value, ok
+}
+
+func (n *Namespace) Delete(key string) error {
+	return n.kv.applyLocal(context.Background(), command{Op: "del", Namespace: n.name, Key: key})
+}
+
+// GetAll retorna uma cópia das chaves do namespace
+func (n *Namespace) GetAll() map[string]string {
+	n.kv.mu.RLock()
+	defer n.kv.mu.RUnlock()
+
+	all := maps.Clone(n.kv.data(n.name, false))
+	if all == nil {
+		all = make(map[string]string)
+	}
+	return all
+}
+
+// Clear apaga as chaves do namespace mantendo o bucket
+func (n *Namespace) Clear() error {
+	return n.kv.applyLocal(context.Background(), command{Op: "clear", Namespace: n.name})
+}
+
+func (n *Namespace) Watch(key string, opts ...WatchOption) *KVWatcher {
+	return n.kv.watch(n.name, key, opts...)
+}
+
+func (n *Namespace) UnwatchAll(key string) {
+	n.kv.unwatchAll(n.name, key)
+}
+
+// PutFromDb carrega a chave apenas em memória, como KVStore.PutFromDb
+func (n *Namespace) PutFromDb(key, value string) {
+	n.kv.mu.Lock()
+	defer n.kv.mu.Unlock()
+
+	n.kv.data(n.name, true)[key] = n.kv.decodeValue(value)
+}
+
+// DropNamespace remove o namespace inteiro da memória e do bbolt.
+// Os outros namespaces não são afetados.
+func (kv *KVStore) DropNamespace(name string) error {
+	if name == "" {
+		return ErrDefaultNamespace
+	}
+	return kv.applyLocal(context.Background(), command{Op: "drop", Namespace: name})
+}
+
+func (kv *KVStore) dropNamespace(ctx context.Context, name string) error {
+	kv.lockAll()
+	defer kv.unlockAll()
+
+	if kv.closed.Load() {
+		return ErrClosed
+	}
+
+	watched := kv.watchedKeysLocked(name)
+
+	LogDropNamespace(name)
+	delete(kv.namespaces, name)
+
+	if err := kv.storage().DeleteBucket(kv.bucketFor(name)); err != nil {
+		return err
+	}
+
+	for _, key := range watched {
+		kv.notifyDeleteLocked(deleteEvent(name, key, 0))
+	}
+	kv.notifyAllLocked(dropEvent(name))
+
+	return kv.replicate(ctx, &command{Op: "drop", Namespace: name})
+}
+
+// LoadNamespaces recarrega em memória os namespaces gravados no bbolt.
+// O namespace padrão continua sendo carregado via PutFromDb.
+func (kv *KVStore) LoadNamespaces() error {
+	prefix := string(kv.bucket) + namespaceSeparator
+
+	var names []string
+	err := kv.storage().Buckets(func(name []byte) error {
+		if ns, ok := strings.CutPrefix(string(name), prefix); ok {
+			names = append(names, ns)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, ns := range names {
+		err := kv.storage().ForEach(kv.bucketFor(ns), func(k, v []byte) error {
+			kv.Namespace(ns).PutFromDb(string(k), string(v))
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// data retorna o mapa em memória do namespace. Com create, o mapa é criado
+// se ainda não existir. Deve ser chamado com kv.mu travado.
+func (kv *KVStore) data(ns string, create bool) map[string]string {
+	if ns == "" {
+		if kv.store == nil && create {
+			kv.store = make(map[string]string)
+		}
+		return kv.store
+	}
+
+	data, ok := kv.namespaces[ns]
+	if !ok && create {
+		if kv.namespaces == nil {
+			kv.namespaces = make(map[string]map[string]string)
+		}
+		data = make(map[string]string)
+		kv.namespaces[ns] = data
+	}
+	return data
+}
+
+// bucketFor retorna o bucket do bbolt onde o namespace é gravado
+func (kv *KVStore) bucketFor(ns string) []byte {
+	if ns == "" {
+		return kv.bucket
+	}
+	return []byte(string(kv.bucket) + namespaceSeparator + ns)
+}
+
+// watchKey identifica os watchers de uma chave dentro de um namespace
+func watchKey(ns, key string) string {
+	if ns == "" {
+		return key
+	}
+	return ns + "\x00" + key
+}
+
+func updateEvent(ns, key, value string, rev uint64) WatchEvent {
+	e := WatchEvent{Type: EventPut, Namespace: ns, Key: key, Value: value, Revision: rev}
+	if ns == "" {
+		e.Message = fmt.Sprintf("Key %s updated to %s", key, value)
+	} else {
+		e.Message = fmt.Sprintf("Key %s updated to %s in namespace %s", key, value, ns)
+	}
+	return e
+}
+
+func deleteEvent(ns, key string, rev uint64) WatchEvent {
+	e := WatchEvent{Type: EventDelete, Namespace: ns, Key: key, Revision: rev}
+	if ns == "" {
+		e.Message = fmt.Sprintf("Key %s deleted", key)
+	} else {
+		e.Message = fmt.Sprintf("Key %s deleted in namespace %s", key, ns)
+	}
+	return e
+}
+
+// dropEvent é o evento do DropNamespace para os watchers do WatchAll
+func dropEvent(ns string) WatchEvent {
+	return WatchEvent{Type: EventDrop, Namespace: ns, Message: fmt.Sprintf("Namespace %s dropped", ns)}
+}
+
+// clearEvent é o evento do Clear para os watchers do WatchAll
+func clearEvent(ns string, rev uint64) WatchEvent {
+	e := WatchEvent{Type: EventClear, Namespace: ns, Revision: rev, Message: "All keys cleared"}
+	if ns != "" {
+		e.Message = fmt.Sprintf("All keys cleared in namespace %s", ns)
+	}
+	return e
+}
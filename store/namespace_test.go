@@ -0,0 +1,202 @@
+package store
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"testing"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestNamespace_IndependentValues(t *testing.T) {
+	db := setupTestDB(t)
+	defer cleanupTestDB(t, db)
+	defer os.Remove("walog.ndjson")
+
+	Init(db)
+	kv := NewKVStore()
+
+	kv.Put("key", "default")
+	kv.Namespace("tenant1").Put("key", "one")
+	kv.Namespace("tenant2").Put("key", "two")
+
+	tests := []struct {
+		namespace string
+		expected  string
+	}{
+		{"", "default"},
+		{"tenant1", "one"},
+		{"tenant2", "two"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.namespace, func(t *testing.T) {
+			if value := kv.Namespace(tt.namespace).Get("key"); value != tt.expected {
+				t.Errorf("Get() returned wrong value. Expected %s, got %s", tt.expected, value)
+			}
+		})
+	}
+
+	// Cada namespace tem seu próprio bucket no bbolt
+	err := db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(kv.bucketFor("tenant1"))
+		if b == nil {
+			t.Fatal("bucket for tenant1 not created")
+		}
+		if value := string(b.Get([]byte("key"))); value != "one" {
+			t.Errorf("tenant1 bucket has wrong value. Expected one, got %s", value)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to read db: %v", err)
+	}
+
+	kv.Namespace("tenant1").Delete("key")
+
+	if _, ok := kv.Namespace("tenant1").Lookup("key"); ok {
+		t.Errorf("Delete() did not remove key from tenant1")
+	}
+	if value := kv.Namespace("tenant2").Get("key"); value != "two" {
+		t.Errorf("Delete() on tenant1 affected tenant2. Expected two, got %s", value)
+	}
+	if value := kv.Get("key"); value != "default" {
+		t.Errorf("Delete() on tenant1 affected default namespace. Expected default, got %s", value)
+	}
+}
+
+func TestKVStore_DropNamespace(t *testing.T) {
+	db := setupTestDB(t)
+	defer cleanupTestDB(t, db)
+	defer os.Remove("walog.ndjson")
+
+	Init(db)
+	kv := NewKVStore()
+
+	kv.Put("key", "default")
+	kv.Namespace("drop").Put("key1", "value1")
+	kv.Namespace("drop").Put("key2", "value2")
+	kv.Namespace("keep").Put("key1", "kept")
+
+	if err := kv.DropNamespace("drop"); err != nil {
+		t.Fatalf("DropNamespace() failed: %v", err)
+	}
+
+	if all := kv.Namespace("drop").GetAll(); len(all) != 0 {
+		t.Errorf("dropped namespace still has %d keys", len(all))
+	}
+	if value := kv.Namespace("keep").Get("key1"); value != "kept" {
+		t.Errorf("DropNamespace() affected other namespace. Expected kept, got %s", value)
+	}
+	if value := kv.Get("key"); value != "default" {
+		t.Errorf("DropNamespace() affected default namespace. Expected default, got %s", value)
+	}
+
+	db.View(func(tx *bolt.Tx) error {
+		if tx.Bucket(kv.bucketFor("drop")) != nil {
+			t.Errorf("bucket for dropped namespace still exists")
+		}
+		if tx.Bucket(kv.bucketFor("keep")) == nil {
+			t.Errorf("bucket for kept namespace was removed")
+		}
+		return nil
+	})
+
+	// Remover um namespace que não existe não é erro
+	if err := kv.DropNamespace("missing"); err != nil {
+		t.Errorf("DropNamespace() on missing namespace failed: %v", err)
+	}
+
+	if err := kv.DropNamespace(""); err != ErrDefaultNamespace {
+		t.Errorf("DropNamespace(\"\") should return ErrDefaultNamespace, got %v", err)
+	}
+}
+
+func TestNamespace_WatchAndWAL(t *testing.T) {
+	db := setupTestDB(t)
+	defer cleanupTestDB(t, db)
+	os.Remove("walog.ndjson")
+	defer os.Remove("walog.ndjson")
+
+	Init(db)
+	kv := NewKVStore()
+
+	defaultWatcher := kv.Watch("key")
+	nsWatcher := kv.Namespace("tenant").Watch("key")
+	defer kv.Unwatch(defaultWatcher)
+	defer kv.Unwatch(nsWatcher)
+
+	if nsWatcher.Namespace != "tenant" {
+		t.Errorf("watcher namespace not set. Expected tenant, got %s", nsWatcher.Namespace)
+	}
+
+	kv.Namespace("tenant").Put("key", "value")
+
+	select {
+	case msg := <-nsWatcher.Events:
+		expected := "Key key updated to value in namespace tenant"
+		if msg.Message != expected {
+			t.Errorf("Wrong event message. Expected %s, got %s", expected, msg)
+		}
+	default:
+		t.Errorf("namespace watcher did not receive event")
+	}
+
+	// O watcher do namespace padrão não deve ser notificado
+	select {
+	case msg := <-defaultWatcher.Events:
+		t.Errorf("default watcher received event from other namespace: %s", msg)
+	default:
+	}
+
+	kv.DropNamespace("tenant")
+
+	file, err := os.Open("walog.ndjson")
+	if err != nil {
+		t.Fatalf("Failed to open log file: %v", err)
+	}
+	defer file.Close()
+
+	var entries []WalLog
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry WalLog
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatalf("Failed to parse log entry: %v", err)
+		}
+		entries = append(entries, entry)
+	}
+
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 log entries, got %d", len(entries))
+	}
+	if entries[0].Operation != Write || entries[0].Namespace != "tenant" {
+		t.Errorf("Expected Write in namespace tenant, got %v in %q", entries[0].Operation, entries[0].Namespace)
+	}
+	if entries[1].Operation != DropNamespace || entries[1].Namespace != "tenant" {
+		t.Errorf("Expected DropNamespace in namespace tenant, got %v in %q", entries[1].Operation, entries[1].Namespace)
+	}
+}
+
+func TestKVStore_LoadNamespaces(t *testing.T) {
+	db := setupTestDB(t)
+	defer cleanupTestDB(t, db)
+	defer os.Remove("walog.ndjson")
+
+	Init(db)
+	NewKVStore().Namespace("tenant").Put("key", "value")
+
+	// Simula um restart: uma store nova lendo o mesmo banco
+	kv := NewKVStore()
+	if err := kv.LoadNamespaces(); err != nil {
+		t.Fatalf("LoadNamespaces() failed: %v", err)
+	}
+
+	if value := kv.Namespace("tenant").Get("key"); value != "value" {
+		t.Errorf("LoadNamespaces() did not restore key. Expected value, got %s", value)
+	}
+	if _, ok := kv.Lookup("key"); ok {
+		t.Errorf("LoadNamespaces() leaked namespaced key into default namespace")
+	}
+}
@@ -0,0 +1,179 @@
+package store
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"log/slog"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Option configura um KVStore na criação
+type Option func(*KVStore)
+
+// WithLimits define os limites de tamanho aplicados no Put
+func WithLimits(l Limits) Option {
+	return func(kv *KVStore) {
+		kv.limits = l
+	}
+}
+
+// WithDB faz a store gravar no banco informado em vez do banco global de Init
+func WithDB(d *bolt.DB) Option {
+	return WithBackend(NewBoltBackend(d))
+}
+
+// WithBackend define onde a store persiste os dados, ex.: NewMemoryBackend()
+// para testes ou stores efêmeras
+func WithBackend(b Backend) Option {
+	return func(kv *KVStore) {
+		kv.backend = b
+	}
+}
+
+// WithBucket define o bucket do bbolt onde as chaves são gravadas
+func WithBucket(name string) Option {
+	return func(kv *KVStore) {
+		kv.bucket = []byte(name)
+	}
+}
+
+// WithRaftDir define o diretório dos arquivos do raft. Cada nó usa um
+// subdiretório com o seu id, então nós na mesma máquina precisam de ids
+// diferentes ou de diretórios diferentes.
+func WithRaftDir(dir string) Option {
+	return func(kv *KVStore) {
+		kv.raftDir = dir
+	}
+}
+
+// WithBootstrap define se o Open cria um cluster novo com este nó quando não
+// há estado do raft em disco (o padrão). Só o nó inicial do cluster deve
+// fazer o bootstrap; os demais usam WithBootstrap(false) e entram pelo Join.
+func WithBootstrap(b bool) Option {
+	return func(kv *KVStore) {
+		kv.bootstrap = b
+	}
+}
+
+// WithRaftBind define o endereço do raft usado quando o Open recebe um vazio
+func WithRaftBind(addr string) Option {
+	return func(kv *KVStore) {
+		kv.raftBind = addr
+	}
+}
+
+// WithSnapshotThreshold define a cada quantas entradas do log o raft tira um
+// snapshot. Zero desliga os snapshots periódicos.
+func WithSnapshotThreshold(n uint64) Option {
+	return func(kv *KVStore) {
+		kv.snapshotThreshold = n
+	}
+}
+
+// WithSnapshotInterval faz o raft conferir o log a cada d (o raft sorteia a
+// espera entre d e 2d) e tirar um snapshot se alguma entrada foi aplicada
+// desde o último, no lugar do gatilho por tamanho do WithSnapshotThreshold.
+// Limita o replay num restart mesmo com poucas escritas. Zero desliga; o raft
+// recusa intervalos abaixo de 5ms.
+func WithSnapshotInterval(d time.Duration) Option {
+	return func(kv *KVStore) {
+		kv.snapshotInterval = d
+	}
+}
+
+// WithSnapshotRetention define quantos snapshots do raft ficam em disco. O
+// Open recusa valores menores que 1.
+func WithSnapshotRetention(n int) Option {
+	return func(kv *KVStore) {
+		kv.retainSnapshots = n
+	}
+}
+
+// WithValueIndex liga o índice usado pelo FindByValuePrefix, guardando os
+// primeiros prefixLen bytes de cada valor. Zero ou negativo deixa o índice
+// desligado. Numa store com dados, chame RebuildValueIndex depois de carregar.
+func WithValueIndex(prefixLen int) Option {
+	return func(kv *KVStore) {
+		kv.valueIndexPrefix = prefixLen
+	}
+}
+
+// WithEncryptionKey liga a cifragem dos valores em disco. A chave AES é o
+// SHA-256 de secret, então qualquer tamanho serve. Valores gravados antes
+// continuam sendo lidos em claro; um secret vazio deixa a cifragem desligada.
+func WithEncryptionKey(secret []byte) Option {
+	return func(kv *KVStore) {
+		if len(secret) == 0 {
+			kv.aead = nil
+			return
+		}
+		key := sha256.Sum256(secret)
+		block, err := aes.NewCipher(key[:])
+		if err != nil {
+			panic(err) //nunca acontece com 32 bytes
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			panic(err)
+		}
+		kv.aead = aead
+	}
+}
+
+// WithCompressionThreshold define a partir de quantos bytes os valores são
+// comprimidos no bbolt. Zero ou negativo desliga a compressão.
+func WithCompressionThreshold(n int) Option {
+	return func(kv *KVStore) {
+		kv.compressionThreshold = n
+	}
+}
+
+// WithValueEnvelope grava cada valor no bbolt junto com a revisão e os tempos
+// da chave, num envelope JSON, em vez de usar os buckets de metadados (ver
+// envelope.go). Valores gravados sem o envelope continuam sendo lidos.
+func WithValueEnvelope(enabled bool) Option {
+	return func(kv *KVStore) {
+		kv.valueEnvelope = enabled
+	}
+}
+
+// WithLogger define o logger da store. Por padrão é usado o slog.Default(),
+// que descarta as mensagens de debug.
+func WithLogger(l *slog.Logger) Option {
+	return func(kv *KVStore) {
+		kv.logger = l.With("component", "store")
+	}
+}
+
+// WithWatchBufferSize define o buffer padrão dos watchers da store
+func WithWatchBufferSize(n int) Option {
+	return func(kv *KVStore) {
+		kv.watchBufferSize = n
+	}
+}
+
+// WithWatchBlockTimeout define quanto um watcher com OverflowBlock pode ficar
+// sem ler antes de ser desconectado
+func WithWatchBlockTimeout(d time.Duration) Option {
+	return func(kv *KVStore) {
+		kv.watchBlockTimeout = d
+	}
+}
+
+// WithMaxEntries limita as chaves do namespace padrão mantidas em memória,
+// despejando a usada há mais tempo (Get e Put contam como uso) quando o
+// limite é passado. mode decide se a chave despejada continua no backend.
+// Put condicionais, Txn, MultiGet e as contagens e listagens consultam o
+// backend para as chaves despejadas. Zero ou negativo desliga o limite.
+func WithMaxEntries(n int, mode EvictionMode) Option {
+	return func(kv *KVStore) {
+		if n <= 0 {
+			kv.lru = nil
+			return
+		}
+		kv.lru = newLRU(n, mode)
+	}
+}
@@ -0,0 +1,172 @@
+package store
+
+import (
+	"bytes"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/carvalhodanielg/kvstore/internal/constants"
+	bolt "go.etcd.io/bbolt"
+)
+
+// openBucketDB abre um banco em path com o bucket informado
+func openBucketDB(t *testing.T, path, bucket string) *bolt.DB {
+	os.Remove(path)
+
+	d, err := bolt.Open(path, constants.DBFilePermission, nil)
+	if err != nil {
+		t.Fatalf("failed to open test db: %v", err)
+	}
+
+	err = d.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists([]byte(bucket))
+		return err
+	})
+	if err != nil {
+		t.Fatalf("failed to create bucket in test db: %v", err)
+	}
+
+	t.Cleanup(func() {
+		d.Close()
+		os.Remove(path)
+	})
+
+	return d
+}
+
+func readBucket(t *testing.T, d *bolt.DB, bucket, key string) (string, bool) {
+	var value []byte
+	err := d.View(func(tx *bolt.Tx) error {
+		value = tx.Bucket([]byte(bucket)).Get([]byte(key))
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to read db: %v", err)
+	}
+	return string(value), value != nil
+}
+
+func TestKVStore_WithDB_Isolated(t *testing.T) {
+	dbA := openBucketDB(t, "test_node_a.db", "node_a")
+	dbB := openBucketDB(t, "test_node_b.db", "node_b")
+	defer os.Remove("walog.ndjson")
+
+	kvA := NewKVStore(WithDB(dbA), WithBucket("node_a"))
+	kvB := NewKVStore(WithDB(dbB), WithBucket("node_b"))
+
+	kvA.Put("shared", "from_a")
+	kvA.Put("only_a", "value_a")
+	kvB.Put("shared", "from_b")
+
+	if value, _ := readBucket(t, dbA, "node_a", "shared"); value != "from_a" {
+		t.Errorf("node A db has wrong value. Expected from_a, got %s", value)
+	}
+	if value, _ := readBucket(t, dbB, "node_b", "shared"); value != "from_b" {
+		t.Errorf("node B db has wrong value. Expected from_b, got %s", value)
+	}
+	if _, ok := readBucket(t, dbB, "node_b", "only_a"); ok {
+		t.Errorf("key written on node A leaked into node B db")
+	}
+
+	kvB.Delete("shared")
+
+	if value, _ := readBucket(t, dbA, "node_a", "shared"); value != "from_a" {
+		t.Errorf("Delete() on node B affected node A. Expected from_a, got %s", value)
+	}
+	if _, ok := readBucket(t, dbB, "node_b", "shared"); ok {
+		t.Errorf("Delete() did not remove key from node B db")
+	}
+}
+
+func TestKVStore_WithLogger_Levels(t *testing.T) {
+	db := setupTestDB(t)
+	defer cleanupTestDB(t, db)
+	defer os.Remove("walog.ndjson")
+
+	Init(db)
+
+	tests := []struct {
+		name      string
+		level     slog.Level
+		wantPut   bool
+		wantWatch bool
+	}{
+		{"debug", slog.LevelDebug, true, true},
+		{"info", slog.LevelInfo, false, true},
+		{"error", slog.LevelError, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: tt.level}))
+			kv := NewKVStore(WithLogger(logger))
+
+			// Enche o canal do watcher para forçar o aviso de evento descartado
+			w := kv.Watch("key")
+			defer kv.Unwatch(w)
+			for i := 0; i <= cap(w.Events); i++ {
+				kv.Put("key", "value")
+			}
+
+			out := buf.String()
+			if got := strings.Contains(out, "msg=put"); got != tt.wantPut {
+				t.Errorf("debug put line logged = %v, expected %v", got, tt.wantPut)
+			}
+			if got := strings.Contains(out, "watcher channel full"); got != tt.wantWatch {
+				t.Errorf("dropped event warning logged = %v, expected %v", got, tt.wantWatch)
+			}
+			if tt.wantPut && !strings.Contains(out, "component=store") {
+				t.Errorf("store logs missing component attribute: %s", out)
+			}
+		})
+	}
+}
+
+func TestKVStore_WithBackend_Memory(t *testing.T) {
+	defer os.Remove("walog.ndjson")
+
+	backend := NewMemoryBackend()
+	kv := NewKVStore(WithBackend(backend))
+
+	kv.Put("a", "1")
+	kv.Put("b", "2")
+	kv.Put("a", "3")
+	kv.Delete("b")
+	kv.Namespace("users").Put("u1", "joao")
+
+	// Uma nova store sobre o mesmo backend recupera dados, namespaces e revisões
+	reopened := NewKVStore(WithBackend(backend))
+	err := backend.ForEach([]byte(constants.BucketStore), func(k, v []byte) error {
+		reopened.PutFromDb(string(k), string(v))
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ForEach() failed: %v", err)
+	}
+	if err := reopened.LoadNamespaces(); err != nil {
+		t.Fatalf("LoadNamespaces() failed: %v", err)
+	}
+	if err := reopened.LoadRevisions(); err != nil {
+		t.Fatalf("LoadRevisions() failed: %v", err)
+	}
+
+	if got := reopened.GetAll(); len(got) != 1 || got["a"] != "3" {
+		t.Errorf("GetAll() = %v, expected map[a:3]", got)
+	}
+	if got := reopened.Namespace("users").Get("u1"); got != "joao" {
+		t.Errorf("Namespace(users).Get(u1) = %q, expected joao", got)
+	}
+	if got, want := reopened.Revision("a"), kv.Revision("a"); got != want || got == 0 {
+		t.Errorf("Revision(a) = %d, expected %d", got, want)
+	}
+
+	if err := reopened.Clear(); err != nil {
+		t.Fatalf("Clear() failed: %v", err)
+	}
+	if v, _ := backend.Get([]byte(constants.BucketStore), []byte("a")); v != nil {
+		t.Errorf("key still in the backend after Clear(): %q", v)
+	}
+}
@@ -0,0 +1,911 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/raft"
+)
+
+// testCluster é um cluster raft em memória, um KVStore por nó
+type testCluster struct {
+	stores     []*KVStore
+	addrs      []raft.ServerAddress
+	transports []*raft.InmemTransport
+	logs       []*raft.InmemStore
+	snapshots  []*raft.InmemSnapshotStore
+}
+
+func testRaftConfig(id raft.ServerID) *raft.Config {
+	config := raft.DefaultConfig()
+	config.LocalID = id
+	config.HeartbeatTimeout = 50 * time.Millisecond
+	config.ElectionTimeout = 50 * time.Millisecond
+	config.LeaderLeaseTimeout = 50 * time.Millisecond
+	config.CommitTimeout = 5 * time.Millisecond
+	// O log é truncado logo depois de cada snapshot
+	config.TrailingLogs = 10
+	config.LogOutput = io.Discard
+	return config
+}
+
+// newTestCluster sobe n nós conectados por transporte em memória, cada um com
+// seu próprio backend em memória e as opções em opts, e espera a eleição de
+// um líder.
+func newTestCluster(t *testing.T, n int, opts ...Option) *testCluster {
+	c := &testCluster{}
+
+	var servers []raft.Server
+	for range n {
+		i := c.addNode(t, opts...)
+		servers = append(servers, raft.Server{ID: raft.ServerID(c.stores[i].nodeID), Address: c.addrs[i]})
+	}
+
+	if err := c.stores[0].raft.BootstrapCluster(raft.Configuration{Servers: servers}).Error(); err != nil {
+		t.Fatalf("failed to bootstrap cluster: %v", err)
+	}
+
+	c.leader(t)
+	return c
+}
+
+// addNode sobe mais um nó conectado aos que já existem e devolve o índice
+// dele. O nó não tem configuração: só entra no cluster pelo bootstrap do
+// newTestCluster ou quando o líder o adiciona.
+func (c *testCluster) addNode(t *testing.T, opts ...Option) int {
+	i := len(c.stores)
+	id := raft.ServerID(fmt.Sprintf("node%d", i))
+
+	addr, tr := raft.NewInmemTransport("")
+	for j := range c.transports {
+		tr.Connect(c.addrs[j], c.transports[j])
+		c.transports[j].Connect(addr, tr)
+	}
+
+	kv := NewKVStore(append([]Option{WithBackend(NewMemoryBackend())}, opts...)...)
+	config := testRaftConfig(id)
+	kv.snapshotConfig(config)
+	// O raft confere o snapshotThreshold a cada 10s; nos testes, bem mais
+	// rápido, a não ser que o teste tenha pedido um intervalo
+	if kv.snapshotInterval == 0 {
+		config.SnapshotInterval = 10 * time.Millisecond
+	}
+
+	logs, snapshots := raft.NewInmemStore(), raft.NewInmemSnapshotStore()
+	r, err := raft.NewRaft(config, (*fsm)(kv), logs, raft.NewInmemStore(), snapshots, tr)
+	if err != nil {
+		t.Fatalf("failed to create raft: %v", err)
+	}
+	t.Cleanup(func() { r.Shutdown().Error() })
+
+	kv.raft = r
+	kv.nodeID = string(id)
+
+	c.stores = append(c.stores, kv)
+	c.addrs = append(c.addrs, addr)
+	c.transports = append(c.transports, tr)
+	c.logs = append(c.logs, logs)
+	c.snapshots = append(c.snapshots, snapshots)
+	return i
+}
+
+// leader espera até algum nó se tornar líder e devolve o índice dele
+func (c *testCluster) leader(t *testing.T) int {
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		for i, kv := range c.stores {
+			if kv.raft.State() == raft.Leader {
+				return i
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Fatal("timeout waiting for a raft leader")
+	return -1
+}
+
+// isolate corta a comunicação do nó i com o resto do cluster
+func (c *testCluster) isolate(i int) {
+	c.transports[i].DisconnectAll()
+	for j, tr := range c.transports {
+		if j != i {
+			tr.Disconnect(c.addrs[i])
+		}
+	}
+}
+
+func TestKVStore_ReadIndex_NoRaft(t *testing.T) {
+	store := NewKVStore()
+
+	if err := store.ReadIndex(context.Background()); err != nil {
+		t.Errorf("ReadIndex() without raft returned %v, expected nil", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := store.ReadIndex(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("ReadIndex() with canceled context returned %v, expected context.Canceled", err)
+	}
+}
+
+func TestKVStore_ReadIndex_Linearizable(t *testing.T) {
+	c := newTestCluster(t, 3)
+	defer os.Remove("walog.ndjson")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	leader := c.leader(t)
+
+	// Toda leitura linearizável logo após uma escrita confirmada vê o valor novo;
+	// os followers recusam em vez de responder com dados antigos
+	for i := range 20 {
+		want := fmt.Sprintf("v%d", i)
+		if err := c.stores[leader].PutContext(ctx, "key", want); err != nil {
+			t.Fatalf("Put() failed: %v", err)
+		}
+
+		for n, kv := range c.stores {
+			err := kv.ReadIndex(ctx)
+			if n != leader {
+				if !errors.Is(err, ErrNotLeader) {
+					t.Fatalf("ReadIndex() on follower returned %v, expected ErrNotLeader", err)
+				}
+				continue
+			}
+
+			if err != nil {
+				t.Fatalf("ReadIndex() on leader failed: %v", err)
+			}
+			if got := kv.Get("key"); got != want {
+				t.Fatalf("Stale linearizable read: expected %s, got %s", want, got)
+			}
+		}
+	}
+
+	// Um líder isolado não consegue confirmar a liderança com o quorum
+	c.isolate(leader)
+
+	if err := c.stores[leader].ReadIndex(ctx); !errors.Is(err, ErrNotLeader) {
+		t.Errorf("ReadIndex() on isolated leader returned %v, expected ErrNotLeader", err)
+	}
+}
+
+func TestKVStore_SnapshotThreshold(t *testing.T) {
+	c := newTestCluster(t, 1, WithSnapshotThreshold(50))
+	defer os.Remove("walog.ndjson")
+
+	kv := c.stores[0]
+	ctx := context.Background()
+	for i := range 200 {
+		if err := kv.PutContext(ctx, fmt.Sprintf("key-%d", i), "value"); err != nil {
+			t.Fatalf("Put() failed: %v", err)
+		}
+	}
+
+	// Espera um snapshot que cubra as escritas e o log ser truncado
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		snaps, err := c.snapshots[0].List()
+		if err != nil {
+			t.Fatalf("failed to list snapshots: %v", err)
+		}
+		first, err := c.logs[0].FirstIndex()
+		if err != nil {
+			t.Fatalf("failed to read first log index: %v", err)
+		}
+
+		if len(snaps) > 0 && snaps[0].Index >= 150 && first > 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("no snapshot/truncation: snapshots=%v first log index=%d", snaps, first)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// O snapshot guarda os dados do store, não um estado vazio
+	snaps, _ := c.snapshots[0].List()
+	_, rc, err := c.snapshots[0].Open(snaps[0].ID)
+	if err != nil {
+		t.Fatalf("failed to open snapshot: %v", err)
+	}
+	defer rc.Close()
+
+	var state snapshotState
+	if err := json.NewDecoder(rc).Decode(&state); err != nil {
+		t.Fatalf("failed to decode snapshot: %v", err)
+	}
+	if len(state.Keys) < 150 || state.Revision < 150 {
+		t.Errorf("Expected at least 150 keys in snapshot, got %d keys at revision %d", len(state.Keys), state.Revision)
+	}
+}
+
+func TestKVStore_SnapshotThreshold_Disabled(t *testing.T) {
+	c := newTestCluster(t, 1, WithSnapshotThreshold(0))
+	defer os.Remove("walog.ndjson")
+
+	for i := range 100 {
+		if err := c.stores[0].Put(fmt.Sprintf("key-%d", i), "value"); err != nil {
+			t.Fatalf("Put() failed: %v", err)
+		}
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	if snaps, _ := c.snapshots[0].List(); len(snaps) != 0 {
+		t.Errorf("snapshot threshold 0 still took snapshots: %v", snaps)
+	}
+}
+
+func TestKVStore_SnapshotRestore(t *testing.T) {
+	c := newTestCluster(t, 1)
+	defer os.Remove("walog.ndjson")
+
+	leader := c.stores[0]
+	for i := range 100 {
+		if err := leader.Put(fmt.Sprintf("key-%d", i), fmt.Sprintf("value-%d", i)); err != nil {
+			t.Fatalf("Put() failed: %v", err)
+		}
+	}
+	if err := leader.PutBytes("binary", []byte{0xff, 0x00, 0xfe}); err != nil {
+		t.Fatalf("PutBytes() failed: %v", err)
+	}
+	if err := leader.Namespace("users").Put("alice", "admin"); err != nil {
+		t.Fatalf("Namespace Put() failed: %v", err)
+	}
+	if err := leader.raft.Snapshot().Error(); err != nil {
+		t.Fatalf("Snapshot() failed: %v", err)
+	}
+	if first, _ := c.logs[0].FirstIndex(); first <= 1 {
+		t.Fatalf("log was not truncated by the snapshot, first index %d", first)
+	}
+
+	// O nó novo não alcança o começo do log: recebe o snapshot (InstallSnapshot)
+	// e o Restore carrega tudo, inclusive namespaces e revisões
+	i := c.addNode(t)
+	follower := c.stores[i]
+	follower.PutFromDb("stale", "value")
+	if err := leader.Join(string(c.addrs[i]), follower.nodeID); err != nil {
+		t.Fatalf("Join() failed: %v", err)
+	}
+
+	eventually(t, "the follower to restore the snapshot", func() bool {
+		return follower.Count() == leader.Count()
+	})
+
+	if !maps.Equal(follower.GetAll(), leader.GetAll()) {
+		t.Errorf("follower has %d keys after restore, leader has %d", follower.Count(), leader.Count())
+	}
+	if got, _ := follower.GetBytes("binary"); !bytes.Equal(got, []byte{0xff, 0x00, 0xfe}) {
+		t.Errorf("binary value after restore = %v", got)
+	}
+	if got := follower.Namespace("users").Get("alice"); got != "admin" {
+		t.Errorf("namespace key after restore = %q, expected admin", got)
+	}
+	if got, want := follower.Revision("key-42"), leader.Revision("key-42"); got != want || got == 0 {
+		t.Errorf("revision after restore = %d, expected %d", got, want)
+	}
+	if _, ok := follower.Lookup("stale"); ok {
+		t.Error("restore kept a key that is not in the snapshot")
+	}
+
+	// O banco do follower também foi reescrito
+	if diffs, err := follower.Verify(); err != nil || len(diffs) != 0 {
+		t.Errorf("Verify() after restore = %v, %v", diffs, err)
+	}
+}
+
+func TestKVStore_SnapshotInterval(t *testing.T) {
+	c := newTestCluster(t, 1, WithSnapshotInterval(50*time.Millisecond))
+	defer os.Remove("walog.ndjson")
+
+	kv := c.stores[0]
+
+	// snapshotIndex devolve o índice do snapshot mais recente, 0 sem nenhum
+	snapshotIndex := func() uint64 {
+		snaps, err := c.snapshots[0].List()
+		if err != nil {
+			t.Fatalf("failed to list snapshots: %v", err)
+		}
+		if len(snaps) == 0 {
+			return 0
+		}
+		return snaps[0].Index
+	}
+	before := snapshotIndex()
+
+	if err := kv.PutContext(context.Background(), "key", "value"); err != nil {
+		t.Fatalf("Put() failed: %v", err)
+	}
+	applied := kv.raft.AppliedIndex()
+
+	// Uma escrita basta, bem abaixo do threshold
+	deadline := time.Now().Add(5 * time.Second)
+	for snapshotIndex() < applied {
+		if time.Now().After(deadline) {
+			t.Fatalf("no snapshot after the interval: last snapshot index %d, before %d, applied %d", snapshotIndex(), before, applied)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// Sem escritas novas, os próximos intervalos não tiram outro snapshot
+	taken := snapshotIndex()
+	time.Sleep(4 * kv.snapshotInterval)
+	if got := snapshotIndex(); got != taken {
+		t.Errorf("snapshot index moved from %d to %d without writes", taken, got)
+	}
+}
+
+func TestKVStore_Join_Idempotent(t *testing.T) {
+	c := newTestCluster(t, 3)
+	defer os.Remove("walog.ndjson")
+
+	i := c.leader(t)
+	leader := c.stores[i]
+	member, moved := (i+1)%3, (i+2)%3
+
+	config := func() ([]raft.Server, uint64) {
+		f := leader.raft.GetConfiguration()
+		if err := f.Error(); err != nil {
+			t.Fatalf("GetConfiguration() failed: %v", err)
+		}
+		return f.Configuration().Servers, f.Index()
+	}
+	find := func(servers []raft.Server, id string) []raft.Server {
+		var found []raft.Server
+		for _, srv := range servers {
+			if string(srv.ID) == id {
+				found = append(found, srv)
+			}
+		}
+		return found
+	}
+
+	// Repetir o join de um membro não gera uma nova configuração
+	_, before := config()
+	for range 2 {
+		if err := leader.Join(string(c.addrs[member]), c.stores[member].nodeID); err != nil {
+			t.Fatalf("Join() failed: %v", err)
+		}
+	}
+	servers, after := config()
+	if got := find(servers, c.stores[member].nodeID); len(got) != 1 {
+		t.Errorf("configuration lists %s %d times: %v", c.stores[member].nodeID, len(got), servers)
+	}
+	if after != before {
+		t.Errorf("duplicate Join() changed the configuration index from %d to %d", before, after)
+	}
+
+	// O mesmo id com outro endereço só troca o endereço
+	addr, _ := raft.NewInmemTransport("")
+	if err := leader.Join(string(addr), c.stores[moved].nodeID); err != nil {
+		t.Fatalf("Join() with a new address failed: %v", err)
+	}
+	servers, _ = config()
+	if got := find(servers, c.stores[moved].nodeID); len(got) != 1 || got[0].Address != addr {
+		t.Errorf("after the address change the configuration has %v, expected one entry at %s", got, addr)
+	}
+	if len(servers) != 3 {
+		t.Errorf("configuration has %d servers, expected 3: %v", len(servers), servers)
+	}
+
+	// Só o líder muda a configuração
+	if err := c.stores[member].Join("new:7000", "new"); !errors.Is(err, ErrNotLeader) {
+		t.Errorf("Join() on a follower returned %v, expected ErrNotLeader", err)
+	}
+	if err := NewKVStore().Join("new:7000", "new"); !errors.Is(err, ErrRaftNotOpen) {
+		t.Errorf("Join() without raft returned %v, expected ErrRaftNotOpen", err)
+	}
+}
+
+func TestKVStore_LeaderChanges(t *testing.T) {
+	c := newTestCluster(t, 3)
+	defer os.Remove("walog.ndjson")
+
+	old := c.leader(t)
+	oldID := raft.ServerID(c.stores[old].nodeID)
+
+	subs := make([]<-chan raft.Observation, len(c.stores))
+	for i, kv := range c.stores {
+		subs[i] = kv.LeaderChanges()
+		defer kv.StopLeaderChanges(subs[i])
+	}
+
+	if err := c.stores[old].raft.LeadershipTransfer().Error(); err != nil {
+		t.Fatalf("LeadershipTransfer() failed: %v", err)
+	}
+
+	// Todo nó, inclusive o líder antigo, fica sabendo do novo líder
+	for i, sub := range subs {
+		deadline := time.After(5 * time.Second)
+		for notified := false; !notified; {
+			select {
+			case o := <-sub:
+				leader := o.Data.(raft.LeaderObservation)
+				notified = leader.LeaderID != "" && leader.LeaderID != oldID
+			case <-deadline:
+				t.Fatalf("node %d was not notified of the new leader", i)
+			}
+		}
+	}
+
+	if c.stores[old].IsLeader() {
+		t.Error("old leader still reports IsLeader() after the transfer")
+	}
+	if !c.stores[c.leader(t)].IsLeader() {
+		t.Error("new leader does not report IsLeader()")
+	}
+
+	// Depois do Stop a assinatura não recebe mais nada
+	c.stores[old].StopLeaderChanges(subs[old])
+	if len(c.stores[old].leaderObservers) != 0 {
+		t.Errorf("Expected no observers after StopLeaderChanges, got %d", len(c.stores[old].leaderObservers))
+	}
+}
+
+// eventually repete check até ele passar ou o tempo acabar
+func eventually(t *testing.T, what string, check func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !check() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timeout waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestKVStore_FollowerWriteRejected(t *testing.T) {
+	c := newTestCluster(t, 3)
+	defer os.Remove("walog.ndjson")
+
+	leader := c.leader(t)
+	kv := c.stores[(leader+1)%len(c.stores)]
+
+	writes := map[string]func() error{
+		"Put":    func() error { return kv.Put("k", "v") },
+		"Delete": func() error { return kv.Delete("k") },
+		"PutIfAbsent": func() error {
+			_, err := kv.PutIfAbsent("k", "v")
+			return err
+		},
+		"PutIfVersion": func() error {
+			_, err := kv.PutIfVersion("k", "v", 0)
+			return err
+		},
+		"Txn": func() error {
+			_, err := kv.Txn(context.Background(), nil, []TxnOp{{Type: TxnPut, Key: "k", Value: "v"}}, nil)
+			return err
+		},
+		"Namespace.Put": func() error { return kv.Namespace("ns").Put("k", "v") },
+	}
+	for name, write := range writes {
+		if err := write(); !errors.Is(err, ErrNotLeader) {
+			t.Errorf("%s() on a follower returned %v, expected ErrNotLeader", name, err)
+		}
+	}
+
+	// Nada da escrita recusada pode ter ficado no follower
+	if _, ok := kv.Lookup("k"); ok {
+		t.Errorf("Rejected write left the key in the follower's memory")
+	}
+	if v, err := kv.storage().Get(kv.bucket, []byte("k")); err != nil || v != nil {
+		t.Errorf("Rejected write reached the follower's backend: %q, %v", v, err)
+	}
+	if v := kv.Namespace("ns").Get("k"); v != "" {
+		t.Errorf("Rejected namespace write left %q on the follower", v)
+	}
+	if rev := kv.Revision("k"); rev != 0 {
+		t.Errorf("Rejected write bumped the key revision to %d", rev)
+	}
+}
+
+func TestKVStore_ReplicaRead(t *testing.T) {
+	c := newTestCluster(t, 3)
+	defer os.Remove("walog.ndjson")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	leader := c.leader(t)
+	follower := (leader + 1) % len(c.stores)
+	kv := c.stores[follower]
+
+	if !kv.IsFollower() || c.stores[leader].IsFollower() {
+		t.Fatalf("IsFollower() does not match the raft state")
+	}
+
+	if err := c.stores[leader].PutContext(ctx, "key", "v1"); err != nil {
+		t.Fatalf("Put() failed: %v", err)
+	}
+	eventually(t, "the follower to apply the put", func() bool {
+		return kv.Get("key") == "v1"
+	})
+	if got, want := kv.Revision("key"), c.stores[leader].Revision("key"); got != want {
+		t.Errorf("Follower revision = %d, expected the leader's %d", got, want)
+	}
+
+	// Txn, namespaces e deletes também chegam ao follower
+	_, err := c.stores[leader].Txn(ctx, nil, []TxnOp{{Type: TxnPut, Key: "a", Value: "1"}, {Type: TxnDelete, Key: "key"}}, nil)
+	if err != nil {
+		t.Fatalf("Txn() failed: %v", err)
+	}
+	c.stores[leader].Namespace("other").Put("key", "ns")
+	eventually(t, "the follower to apply the txn", func() bool {
+		_, ok := kv.Lookup("key")
+		return !ok && kv.Get("a") == "1" && kv.Namespace("other").Get("key") == "ns"
+	})
+
+	staleness, err := kv.Staleness()
+	if err != nil {
+		t.Fatalf("Staleness() on follower failed: %v", err)
+	}
+	if staleness > time.Second {
+		t.Errorf("Staleness() on follower = %s, expected around the heartbeat interval", staleness)
+	}
+	if staleness, err := c.stores[leader].Staleness(); staleness != 0 || err != nil {
+		t.Errorf("Staleness() on leader = %s, %v, expected 0, nil", staleness, err)
+	}
+
+	// O líder não reaplica as próprias escritas quando elas voltam pelo FSM
+	if got := c.stores[leader].Get("a"); got != "1" {
+		t.Errorf("Leader Get() = %q, expected 1", got)
+	}
+	if got, want := c.stores[leader].Revision("a"), kv.Revision("a"); got != want {
+		t.Errorf("Leader revision = %d, follower %d, expected equal", got, want)
+	}
+}
+
+func TestKVStore_LeaderChanges_NoRaft(t *testing.T) {
+	store := NewKVStore()
+
+	ch := store.LeaderChanges()
+	defer store.StopLeaderChanges(ch)
+
+	if store.IsLeader() {
+		t.Error("standalone store reports IsLeader()")
+	}
+
+	select {
+	case o := <-ch:
+		t.Errorf("unexpected leader observation without raft: %v", o)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestKVStore_Open_RaftDir(t *testing.T) {
+	dirA, dirB := t.TempDir(), t.TempDir()
+
+	kvA := NewKVStore(WithRaftDir(dirA), WithRaftBind("127.0.0.1:7001"))
+	kvB := NewKVStore(WithRaftDir(dirB), WithRaftBind("127.0.0.1:7002"))
+
+	// Mesmo id nos dois: só o diretório separa os arquivos
+	for _, kv := range []*KVStore{kvA, kvB} {
+		if err := kv.Open("", "1"); err != nil {
+			t.Fatalf("Open() failed: %v", err)
+		}
+		t.Cleanup(func() { kv.raft.Shutdown().Error() })
+	}
+
+	for _, dir := range []string{dirA, dirB} {
+		for _, name := range []string{"logs.dat", "stable.dat"} {
+			if _, err := os.Stat(filepath.Join(dir, "1", name)); err != nil {
+				t.Errorf("Expected %s in %s: %v", name, dir, err)
+			}
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(DefaultRaftDir, "1")); err == nil {
+		t.Errorf("Open() should not write to the default %s directory", DefaultRaftDir)
+	}
+
+	// Sem endereço no Open, o raft anuncia o raftBind
+	for kv, bind := range map[*KVStore]string{kvA: "127.0.0.1:7001", kvB: "127.0.0.1:7002"} {
+		servers := kv.raft.GetConfiguration().Configuration().Servers
+		if len(servers) != 1 || string(servers[0].Address) != bind {
+			t.Errorf("Expected raft configuration with %s, got %v", bind, servers)
+		}
+	}
+}
+
+func TestKVStore_Open_Bootstrap(t *testing.T) {
+	dirA, dirB := t.TempDir(), t.TempDir()
+
+	kvA := NewKVStore(WithRaftDir(dirA), WithRaftBind("127.0.0.1:7011"))
+	kvB := NewKVStore(WithRaftDir(dirB), WithRaftBind("127.0.0.1:7012"), WithBootstrap(false))
+
+	for id, kv := range map[string]*KVStore{"1": kvA, "2": kvB} {
+		if err := kv.Open("", id); err != nil {
+			t.Fatalf("Open() failed: %v", err)
+		}
+	}
+	defer kvB.ShutdownRaft()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !kvA.IsLeader() {
+		if time.Now().After(deadline) {
+			t.Fatal("timeout waiting for the bootstrap node to become leader")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// O nó sem bootstrap teve o mesmo tempo para se eleger, mas não tem
+	// configuração: fica esperando ser adicionado
+	if servers := kvB.raft.GetConfiguration().Configuration().Servers; len(servers) != 0 {
+		t.Errorf("node without bootstrap has its own configuration: %v", servers)
+	}
+	if kvB.IsLeader() {
+		t.Error("node without bootstrap elected itself leader")
+	}
+
+	// Reabrir com estado em disco não faz um novo bootstrap
+	if err := kvA.ShutdownRaft(); err != nil {
+		t.Fatalf("ShutdownRaft() failed: %v", err)
+	}
+	kvA = NewKVStore(WithRaftDir(dirA), WithRaftBind("127.0.0.1:7011"))
+	if err := kvA.Open("", "1"); err != nil {
+		t.Fatalf("reopen failed: %v", err)
+	}
+	defer kvA.ShutdownRaft()
+
+	if servers := kvA.raft.GetConfiguration().Configuration().Servers; len(servers) != 1 {
+		t.Errorf("expected the saved configuration after reopen, got %v", servers)
+	}
+}
+
+func TestKVStore_ClusterInfo(t *testing.T) {
+	if _, err := NewKVStore().ClusterInfo(); !errors.Is(err, ErrRaftNotOpen) {
+		t.Errorf("ClusterInfo() without raft returned %v, expected ErrRaftNotOpen", err)
+	}
+
+	kv := NewKVStore(WithBackend(NewMemoryBackend()), WithRaftDir(t.TempDir()), WithRaftBind("127.0.0.1:7031"))
+	if err := kv.Open("", "1"); err != nil {
+		t.Fatalf("Open() failed: %v", err)
+	}
+	defer kv.ShutdownRaft()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !kv.IsLeader() {
+		if time.Now().After(deadline) {
+			t.Fatal("timeout waiting for the bootstrap node to become leader")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	info, err := kv.ClusterInfo()
+	if err != nil {
+		t.Fatalf("ClusterInfo() failed: %v", err)
+	}
+	want := []ClusterServer{{ID: "1", Address: "127.0.0.1:7031", Suffrage: "Voter"}}
+	if !slices.Equal(info.Servers, want) {
+		t.Errorf("servers = %+v, expected %+v", info.Servers, want)
+	}
+	if info.LeaderID != "1" || info.LeaderAddress != "127.0.0.1:7031" {
+		t.Errorf("leader = %s at %s, expected 1 at 127.0.0.1:7031", info.LeaderID, info.LeaderAddress)
+	}
+	if info.Term == 0 {
+		t.Error("expected a term after the election")
+	}
+}
+
+func TestKVStore_Open_SnapshotRetention(t *testing.T) {
+	dir := t.TempDir()
+	SetWALPath(filepath.Join(dir, WALFileName))
+	defer SetWALPath(WALFileName)
+
+	if err := NewKVStore(WithRaftDir(dir), WithSnapshotRetention(0)).Open("127.0.0.1:7021", "0"); err == nil {
+		t.Error("Open() accepted a snapshot retention of 0")
+	}
+
+	kv := NewKVStore(WithBackend(NewMemoryBackend()), WithRaftDir(dir), WithRaftBind("127.0.0.1:7021"),
+		WithSnapshotRetention(1), WithSnapshotThreshold(0))
+	if err := kv.Open("", "1"); err != nil {
+		t.Fatalf("Open() failed: %v", err)
+	}
+	defer kv.ShutdownRaft()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !kv.IsLeader() {
+		if time.Now().After(deadline) {
+			t.Fatal("timeout waiting for leader")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	for i := range 3 {
+		if err := kv.Put(fmt.Sprintf("key%d", i), "v"); err != nil {
+			t.Fatalf("Put failed: %v", err)
+		}
+		if err := kv.raft.Snapshot().Error(); err != nil {
+			t.Fatalf("snapshot %d failed: %v", i, err)
+		}
+	}
+
+	snapshots, err := os.ReadDir(filepath.Join(dir, "1", "snapshots"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snapshots) != 1 {
+		t.Errorf("expected 1 snapshot on disk, got %d", len(snapshots))
+	}
+}
+
+func TestKVStore_ApplyRetry(t *testing.T) {
+	c := newTestCluster(t, 1)
+	defer os.Remove("walog.ndjson")
+	kv := c.stores[c.leader(t)]
+
+	//as duas primeiras tentativas perdem a liderança, a terceira chega ao raft
+	attempts := 0
+	kv.applyFn = func(cmd []byte, timeout time.Duration) error {
+		attempts++
+		if attempts <= 2 {
+			return raft.ErrLeadershipLost
+		}
+		return kv.raft.Apply(cmd, timeout).Error()
+	}
+	if err := kv.Put("key", "value"); err != nil {
+		t.Fatalf("Put() failed after transient errors: %v", err)
+	}
+	if attempts != 3 {
+		t.Errorf("Expected 3 apply attempts, got %d", attempts)
+	}
+	if got := kv.Get("key"); got != "value" {
+		t.Errorf("Get() = %q, expected %q", got, "value")
+	}
+
+	tests := []struct {
+		name     string
+		applyErr error
+		want     error
+	}{
+		{"not leader", raft.ErrNotLeader, ErrNotLeader},
+		{"shutdown", raft.ErrRaftShutdown, ErrReplicationFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := 0
+			kv.applyFn = func([]byte, time.Duration) error {
+				attempts++
+				return tt.applyErr
+			}
+			if err := kv.Delete("key"); !errors.Is(err, tt.want) {
+				t.Errorf("Delete() returned %v, expected %v", err, tt.want)
+			}
+			if attempts != 1 {
+				t.Errorf("Expected the error to fail fast, got %d attempts", attempts)
+			}
+		})
+	}
+
+	//um erro transitório que não passa esgota o prazo do contexto
+	kv.applyFn = func([]byte, time.Duration) error { return raft.ErrEnqueueTimeout }
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := kv.PutContext(ctx, "key", "other"); !errors.Is(err, ErrReplicationFailed) {
+		t.Errorf("PutContext() returned %v, expected ErrReplicationFailed", err)
+	}
+}
+
+func TestKVStore_StepDown(t *testing.T) {
+	c := newTestCluster(t, 3)
+	defer os.Remove("walog.ndjson")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	old := c.leader(t)
+	follower := (old + 1) % len(c.stores)
+	if err := c.stores[follower].StepDown(ctx, ""); !errors.Is(err, ErrNotLeader) {
+		t.Errorf("StepDown() on a follower returned %v, expected ErrNotLeader", err)
+	}
+	for _, target := range []string{"node9", c.stores[old].nodeID} {
+		if err := c.stores[old].StepDown(ctx, target); !errors.Is(err, ErrUnknownServer) {
+			t.Errorf("StepDown(%q) returned %v, expected ErrUnknownServer", target, err)
+		}
+	}
+
+	if err := c.stores[old].StepDown(ctx, ""); err != nil {
+		t.Fatalf("StepDown() failed: %v", err)
+	}
+	leader := -1
+	eventually(t, "another node to become leader", func() bool {
+		for i, kv := range c.stores {
+			if i != old && kv.IsLeader() {
+				leader = i
+				return !c.stores[old].IsLeader()
+			}
+		}
+		return false
+	})
+
+	// com destino, a liderança vai exatamente para ele
+	if err := c.stores[leader].StepDown(ctx, c.stores[old].nodeID); err != nil {
+		t.Fatalf("StepDown(%q) failed: %v", c.stores[old].nodeID, err)
+	}
+	eventually(t, "the target to become leader", func() bool {
+		return c.stores[old].IsLeader() && !c.stores[leader].IsLeader()
+	})
+
+	if err := NewKVStore().StepDown(ctx, ""); !errors.Is(err, ErrRaftNotOpen) {
+		t.Errorf("StepDown() without raft returned %v, expected ErrRaftNotOpen", err)
+	}
+}
+
+func TestKVStore_ApplyLocal_WatchOnce(t *testing.T) {
+	useTempWAL(t, t.TempDir())
+
+	kv := NewKVStore(WithBackend(NewMemoryBackend()))
+	kv.nodeID = "node1"
+	f := (*fsm)(kv)
+
+	apply := func(c command) {
+		t.Helper()
+		data, err := json.Marshal(c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res := f.Apply(&raft.Log{Data: data}); res != nil {
+			t.Fatalf("Apply(%s) = %v", c.Op, res)
+		}
+	}
+
+	w := kv.Watch("key")
+	all := kv.WatchAll()
+	defer kv.Unwatch(w)
+	defer kv.Unwatch(all)
+
+	// a mesma chave escrita localmente e pelo raft, alternando
+	kv.Put("key", "local-1")
+	apply(command{Op: "put", Key: "key", Value: "raft-1", Origin: "node2"})
+	//o comando da própria escrita local volta pelo raft e é ignorado
+	apply(command{Op: "put", Key: "key", Value: "local-1", Origin: "node1"})
+	kv.Put("key", "local-2")
+	apply(command{Op: "del", Key: "key", Origin: "node2"})
+	apply(command{Op: "txn", Origin: "node2", Ops: []command{
+		{Op: "put", Key: "key", Value: "txn"},
+		{Op: "put", Key: "other", Value: "txn"},
+	}})
+
+	want := []string{"put local-1", "put raft-1", "put local-2", "delete ", "put txn"}
+	var got []string
+	for range want {
+		select {
+		case e := <-w.Events:
+			got = append(got, e.Type.String()+" "+e.Value)
+		case <-time.After(time.Second):
+			t.Fatalf("key watcher got %q, expected %q", got, want)
+		}
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("key watcher got %q, expected %q", got, want)
+	}
+	select {
+	case e := <-w.Events:
+		t.Errorf("key watcher got an extra event %+v", e)
+	default:
+	}
+
+	// uma notificação por escrita também no WatchAll, e o txn replicado
+	// avança a revisão como o local
+	if n := len(all.Events); n != len(want)+1 {
+		t.Errorf("WatchAll got %d events, expected %d", n, len(want)+1)
+	}
+	if got := kv.GetAll(); got["key"] != "txn" || got["other"] != "txn" {
+		t.Errorf("GetAll() = %v after the replicated txn", got)
+	}
+	if rev := kv.Revision("other"); rev != kv.Revision("key")+1 {
+		t.Errorf("txn revisions = %d and %d, expected consecutive", kv.Revision("key"), rev)
+	}
+}
@@ -0,0 +1,90 @@
+package store
+
+// A memória é a fonte das leituras, mas depois de uma recuperação parcial
+// (ex.: crash entre o bbolt e a memória) uma chave pode existir só no banco.
+// Numa falta em memória o Get consulta o backend e, se achar a chave, coloca
+// ela de volta no mapa. Leituras concorrentes da mesma chave ausente esperam
+// uma única consulta ao banco.
+
+// loadCall é uma consulta ao backend em andamento
+type loadCall struct {
+	done  chan struct{}
+	entry Entry
+	ok    bool
+}
+
+// readThrough busca no backend uma chave que não estava em memória
+func (kv *KVStore) readThrough(key string) (Entry, bool) {
+	kv.loadMu.Lock()
+	if c, ok := kv.loading[key]; ok {
+		kv.loadMu.Unlock()
+		<-c.done
+		return c.entry, c.ok
+	}
+	c := &loadCall{done: make(chan struct{})}
+	if kv.loading == nil {
+		kv.loading = make(map[string]*loadCall)
+	}
+	kv.loading[key] = c
+	kv.loadMu.Unlock()
+
+	c.entry, c.ok = kv.loadFromBackend(key)
+
+	kv.loadMu.Lock()
+	delete(kv.loading, key)
+	kv.loadMu.Unlock()
+	close(c.done)
+
+	return c.entry, c.ok
+}
+
+func (kv *KVStore) loadFromBackend(key string) (Entry, bool) {
+	//uma escrita da chave só solta o stripe depois de chegar ao banco
+	s := kv.stripe("", key)
+	s.Lock()
+	defer s.Unlock()
+
+	kv.mu.RLock()
+	gen := kv.revision
+	kv.mu.RUnlock()
+
+	raw, err := kv.storage().Get(kv.bucket, []byte(key))
+	if err != nil {
+		kv.logger.Warn("read-through failed", "key", key, "error", err)
+		return Entry{}, false
+	}
+	if raw == nil {
+		return Entry{}, false
+	}
+
+	rev, times := kv.entryMeta(kv.storage(), key, raw)
+
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+
+	//uma escrita durante a consulta pode ter apagado ou trocado a chave;
+	//nesse caso o que vale é a memória, e o valor lido não é reaproveitado
+	if value, ok := kv.store[key]; ok || kv.revision != gen {
+		return kv.entryLocked(key, value), ok
+	}
+
+	value := kv.decodeValue(string(raw))
+	kv.store[key] = value
+	if rev != 0 {
+		kv.revisions[key] = rev
+	}
+	if !times.updated.IsZero() {
+		kv.times[key] = times
+	}
+	kv.invalidateSnapshot()
+	kv.trackLocked(key, true)
+
+	//com o LRU ligado, chaves despejadas voltando do banco são o caso normal
+	if kv.lru != nil {
+		kv.logger.Debug("evicted key reloaded from backend", "key", key)
+	} else {
+		kv.logger.Warn("key missing from memory, recovered from backend", "key", key)
+	}
+
+	return kv.entryLocked(key, value), true
+}
@@ -0,0 +1,129 @@
+package store
+
+import (
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// dropFromMemory simula memória desatualizada: a chave some só do mapa
+func dropFromMemory(kv *KVStore, key string) {
+	kv.mu.Lock()
+	delete(kv.store, key)
+	delete(kv.revisions, key)
+	kv.invalidateSnapshot()
+	kv.mu.Unlock()
+}
+
+func TestKVStore_Get_ReadThrough(t *testing.T) {
+	db := setupTestDB(t)
+	defer cleanupTestDB(t, db)
+	defer os.Remove("walog.ndjson")
+
+	Init(db)
+	store := NewKVStore()
+
+	store.Put("user:1", "Daniel")
+	rev := store.Revision("user:1")
+	dropFromMemory(store, "user:1")
+
+	if got := store.Get("user:1"); got != "Daniel" {
+		t.Fatalf("Get() = %q, expected the value recovered from bbolt", got)
+	}
+
+	// A chave volta para a memória, com a revisão persistida
+	if _, ok := store.GetAll()["user:1"]; !ok {
+		t.Error("Expected the recovered key to be back in memory")
+	}
+	if got := store.Revision("user:1"); got != rev {
+		t.Errorf("Revision() = %d after recovery, expected %d", got, rev)
+	}
+
+	// Chave apagada de verdade continua ausente
+	store.Delete("user:1")
+	if value, ok := store.Lookup("user:1"); ok {
+		t.Errorf("Lookup() = %q after Delete(), expected missing", value)
+	}
+}
+
+// countingBackend conta os Gets e segura cada um até release ser fechado
+type countingBackend struct {
+	Backend
+	gets    atomic.Int32
+	release chan struct{}
+}
+
+func (b *countingBackend) Get(bucket, key []byte) ([]byte, error) {
+	b.gets.Add(1)
+	<-b.release
+	return b.Backend.Get(bucket, key)
+}
+
+func TestKVStore_Get_ReadThroughSingleFlight(t *testing.T) {
+	defer os.Remove("walog.ndjson")
+
+	backend := &countingBackend{Backend: NewMemoryBackend(), release: make(chan struct{})}
+	close(backend.release)
+
+	store := NewKVStore(WithBackend(backend))
+	store.Put("hot", "value")
+	dropFromMemory(store, "hot")
+
+	backend.release = make(chan struct{})
+	backend.gets.Store(0)
+
+	const readers = 20
+	var wg sync.WaitGroup
+	results := make(chan string, readers)
+	for range readers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results <- store.Get("hot")
+		}()
+	}
+
+	// Dá tempo de todos os leitores chegarem à mesma consulta
+	time.Sleep(50 * time.Millisecond)
+	close(backend.release)
+	wg.Wait()
+	close(results)
+
+	for got := range results {
+		if got != "value" {
+			t.Errorf("Get() = %q, expected value", got)
+		}
+	}
+
+	// Uma consulta para o valor, uma para a revisão e uma para os tempos, não
+	// uma por leitor
+	if n := backend.gets.Load(); n != 3 {
+		t.Errorf("Expected a single read-through (3 backend Gets), got %d", n)
+	}
+}
+
+func TestKVStore_Get_ReadThroughBatch(t *testing.T) {
+	defer os.Remove("walog.ndjson")
+
+	inner := NewMemoryBackend()
+	store := NewKVStore(WithBackend(NewBatchBackend(inner, time.Hour, 1000)))
+
+	store.Put("pending", "value")
+	dropFromMemory(store, "pending")
+
+	// A chave só existe no lote: o read-through acha ela lá, sem flush
+	if got := store.Get("pending"); got != "value" {
+		t.Errorf("Get() = %q, expected the value from the pending batch", got)
+	}
+	for range 10 {
+		if _, ok := store.Lookup("missing"); ok {
+			t.Error("Lookup() found a key that was never written")
+		}
+	}
+
+	if v, _ := inner.Get(store.bucket, []byte("pending")); v != nil {
+		t.Errorf("read-through flushed the batch: inner has %q", v)
+	}
+}
@@ -0,0 +1,196 @@
+package store
+
+import (
+	"context"
+	"encoding/binary"
+)
+
+// revisionCounterKey guarda o contador global no bucket de metadados
+var revisionCounterKey = []byte("revision")
+
+// As revisões valem para o namespace padrão. Cada Put/Delete incrementa um
+// contador global e a chave guarda o valor do contador na sua última escrita,
+// então apagar e recriar uma chave nunca repete uma revisão antiga.
+// Revisão 0 significa chave inexistente (ou gravada antes das revisões).
+
+// Revision retorna a revisão atual da chave
+func (kv *KVStore) Revision(key string) uint64 {
+	kv.mu.RLock()
+	defer kv.mu.RUnlock()
+
+	return kv.revisions[key]
+}
+
+// LookupRevision funciona como o Lookup e também retorna a revisão da chave,
+// lidas sob o mesmo lock
+func (kv *KVStore) LookupRevision(key string) (string, uint64, bool) {
+	e, ok := kv.LookupEntry(key)
+	return e.Value, e.Revision, ok
+}
+
+// revisionLocked devolve a revisão atual da chave: da memória ou, se ela foi
+// despejada pelo LRU, do backend. Deve ser chamado com kv.mu travado.
+func (kv *KVStore) revisionLocked(key string) uint64 {
+	if _, ok := kv.store[key]; ok {
+		return kv.revisions[key]
+	}
+	raw, err := kv.storage().Get(kv.bucket, []byte(key))
+	if err != nil || raw == nil {
+		return 0
+	}
+	rev, _ := kv.entryMeta(kv.storage(), key, raw)
+	return rev
+}
+
+// PutIfVersion grava a chave apenas se a revisão atual for igual a expected e
+// retorna a nova revisão. expected 0 exige que a chave não exista.
+func (kv *KVStore) PutIfVersion(key, value string, expected uint64) (uint64, error) {
+	if err := kv.limits.validate(key, value); err != nil {
+		return 0, err
+	}
+	if err := kv.checkLeader(context.Background()); err != nil {
+		return 0, err
+	}
+
+	var applied []mutation
+	defer func() { kv.runHooks(applied) }()
+
+	s := kv.stripe("", key)
+	s.Lock()
+	defer s.Unlock()
+
+	kv.mu.Lock()
+	if kv.closed.Load() {
+		kv.mu.Unlock()
+		return 0, ErrClosed
+	}
+	if current := kv.revisionLocked(key); current != expected {
+		kv.mu.Unlock()
+		return current, ErrRevisionMismatch
+	}
+	rev := kv.revision + 1
+
+	if err := kv.putLocked(context.Background(), "", key, value, &applied); err != nil {
+		return 0, err
+	}
+	return rev, nil
+}
+
+// LoadRevisions recarrega do bbolt as revisões, o contador global e os
+// tempos de criação e atualização das chaves
+func (kv *KVStore) LoadRevisions() error {
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+
+	v, err := kv.storage().Get(kv.metaBucket(), revisionCounterKey)
+	if err != nil {
+		return err
+	}
+	if len(v) == 8 {
+		kv.revision = binary.BigEndian.Uint64(v)
+		kv.persistedRevision.Store(kv.revision)
+	}
+
+	err = kv.storage().ForEach(kv.revisionsBucket(), func(k, v []byte) error {
+		if len(v) == 8 {
+			kv.revisions[string(k)] = binary.BigEndian.Uint64(v)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	err = kv.storage().ForEach(kv.timesBucket(), func(k, v []byte) error {
+		if t, ok := decodeTimes(v); ok {
+			kv.times[string(k)] = t
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	//os envelopes são lidos mesmo com WithValueEnvelope desligado, para não
+	//perder os metadados das chaves gravadas enquanto ele estava ligado
+	return kv.storage().ForEach(kv.bucket, func(k, v []byte) error {
+		if e, ok := kv.decodeEntry(string(v)); ok && e.Revision != 0 {
+			kv.revisions[string(k)] = e.Revision
+			if t := e.times(); !t.updated.IsZero() {
+				kv.times[string(k)] = t
+			}
+		}
+		return nil
+	})
+}
+
+// nextRevision incrementa o contador global. Deve ser chamado com kv.mu travado.
+func (kv *KVStore) nextRevision() uint64 {
+	kv.revision++
+	return kv.revision
+}
+
+func (kv *KVStore) revisionsBucket() []byte {
+	return []byte(string(kv.bucket) + ".revisions")
+}
+
+func (kv *KVStore) metaBucket() []byte {
+	return []byte(string(kv.bucket) + ".meta")
+}
+
+// persistRevision grava a revisão e os tempos da chave e o contador global
+// na mesma transação da escrita. Com deleted a revisão e os tempos são
+// removidos e rev só avança o contador (0 no despejo do LRU).
+func (kv *KVStore) persistRevision(tx Backend, key string, rev uint64, t keyTimes, deleted bool) error {
+	var err error
+	if deleted {
+		err = tx.Delete(kv.revisionsBucket(), []byte(key))
+		if err == nil {
+			err = tx.Delete(kv.timesBucket(), []byte(key))
+		}
+	} else if kv.valueEnvelope {
+		//a revisão e os tempos foram no envelope; uma entrada antiga nos
+		//buckets perderia para ele, mas é apagada para não ficar desatualizada
+		err = tx.Delete(kv.revisionsBucket(), []byte(key))
+		if err == nil {
+			err = tx.Delete(kv.timesBucket(), []byte(key))
+		}
+	} else {
+		err = tx.Put(kv.revisionsBucket(), []byte(key), encodeRevision(rev))
+		if err == nil {
+			err = tx.Put(kv.timesBucket(), []byte(key), encodeTimes(t))
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	return kv.persistRevisionCounter(tx, rev)
+}
+
+// persistRevisionCounter grava o contador global. Escritas de chaves
+// diferentes chegam ao banco fora de ordem, então cada transação grava o
+// maior valor já visto e o contador no banco nunca volta.
+func (kv *KVStore) persistRevisionCounter(tx Backend, rev uint64) error {
+	for {
+		high := kv.persistedRevision.Load()
+		if rev <= high {
+			rev = high
+			break
+		}
+		if kv.persistedRevision.CompareAndSwap(high, rev) {
+			break
+		}
+	}
+	return tx.Put(kv.metaBucket(), revisionCounterKey, encodeRevision(rev))
+}
+
+func encodeRevision(rev uint64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, rev)
+	return b
+}
+
+func decodeRevision(b []byte) uint64 {
+	return binary.BigEndian.Uint64(b)
+}
@@ -0,0 +1,147 @@
+package store
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestKVStore_Revisions(t *testing.T) {
+	db := setupTestDB(t)
+	defer cleanupTestDB(t, db)
+	defer os.Remove("walog.ndjson")
+
+	Init(db)
+	store := NewKVStore()
+
+	if rev := store.Revision("key1"); rev != 0 {
+		t.Errorf("Revision() of absent key = %d, expected 0", rev)
+	}
+
+	store.Put("key1", "a")
+	first := store.Revision("key1")
+	store.Put("key2", "b")
+	store.Put("key1", "c")
+	second := store.Revision("key1")
+
+	if first == 0 || second <= first {
+		t.Errorf("revisions not increasing: first=%d, second=%d", first, second)
+	}
+
+	// Apagar e recriar a chave gera uma revisão nova
+	store.Delete("key1")
+	if rev := store.Revision("key1"); rev != 0 {
+		t.Errorf("Revision() after Delete() = %d, expected 0", rev)
+	}
+	store.Put("key1", "d")
+	if rev := store.Revision("key1"); rev <= second {
+		t.Errorf("recreated key reused an old revision: %d <= %d", rev, second)
+	}
+
+	value, rev, ok := store.LookupRevision("key1")
+	if value != "d" || rev != store.Revision("key1") || !ok {
+		t.Errorf("LookupRevision() = (%s, %d, %v), expected (d, %d, true)", value, rev, ok, store.Revision("key1"))
+	}
+}
+
+func TestKVStore_PutIfVersion(t *testing.T) {
+	db := setupTestDB(t)
+	defer cleanupTestDB(t, db)
+	defer os.Remove("walog.ndjson")
+
+	Init(db)
+	store := NewKVStore()
+
+	// expected 0 cria a chave
+	rev, err := store.PutIfVersion("counter", "1", 0)
+	if err != nil {
+		t.Fatalf("PutIfVersion() on absent key failed: %v", err)
+	}
+
+	// Atualização com a revisão correta
+	newRev, err := store.PutIfVersion("counter", "2", rev)
+	if err != nil {
+		t.Fatalf("PutIfVersion() with current revision failed: %v", err)
+	}
+	if newRev <= rev {
+		t.Errorf("PutIfVersion() returned non-increasing revision: %d <= %d", newRev, rev)
+	}
+
+	// Revisão desatualizada é rejeitada e não escreve
+	current, err := store.PutIfVersion("counter", "stale", rev)
+	if !errors.Is(err, ErrRevisionMismatch) {
+		t.Fatalf("PutIfVersion() with stale revision expected ErrRevisionMismatch, got %v", err)
+	}
+	if current != newRev {
+		t.Errorf("PutIfVersion() mismatch returned revision %d, expected current %d", current, newRev)
+	}
+	if value := store.Get("counter"); value != "2" {
+		t.Errorf("stale PutIfVersion() changed value. Expected 2, got %s", value)
+	}
+
+	// expected 0 falha quando a chave já existe
+	if _, err := store.PutIfVersion("counter", "again", 0); !errors.Is(err, ErrRevisionMismatch) {
+		t.Errorf("PutIfVersion() with 0 on existing key expected ErrRevisionMismatch, got %v", err)
+	}
+}
+
+func TestKVStore_RevisionsPersisted(t *testing.T) {
+	db := setupTestDB(t)
+	defer cleanupTestDB(t, db)
+	os.Remove("walog.ndjson")
+	defer os.Remove("walog.ndjson")
+
+	Init(db)
+	store := NewKVStore()
+
+	store.Put("key1", "value1")
+	store.Put("key2", "value2")
+	store.Delete("key2")
+	rev := store.Revision("key1")
+
+	// Simula um restart com o mesmo banco
+	restarted := NewKVStore()
+	restarted.PutFromDb("key1", "value1")
+	if err := restarted.LoadRevisions(); err != nil {
+		t.Fatalf("LoadRevisions() failed: %v", err)
+	}
+
+	if got := restarted.Revision("key1"); got != rev {
+		t.Errorf("Revision() after reload = %d, expected %d", got, rev)
+	}
+
+	// O contador continua de onde parou, incluindo o Delete
+	restarted.Put("key3", "value3")
+	if got := restarted.Revision("key3"); got != 4 {
+		t.Errorf("Revision() of first write after reload = %d, expected 4", got)
+	}
+
+	// O WAL registra a revisão de cada escrita
+	file, err := os.Open("walog.ndjson")
+	if err != nil {
+		t.Fatalf("Failed to open log file: %v", err)
+	}
+	defer file.Close()
+
+	var revisions []uint64
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry WalLog
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatalf("Failed to parse log entry: %v", err)
+		}
+		revisions = append(revisions, entry.Revision)
+	}
+
+	expected := []uint64{1, 2, 3, 4}
+	if len(revisions) != len(expected) {
+		t.Fatalf("Expected %d log entries, got %d", len(expected), len(revisions))
+	}
+	for i := range expected {
+		if revisions[i] != expected[i] {
+			t.Errorf("log entry %d has revision %d, expected %d", i, revisions[i], expected[i])
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package store
+
+import (
+	"bytes"
+	"errors"
+	"slices"
+	"strings"
+)
+
+// scanPageSize é quantas chaves o Scan lê do backend em cada transação
+const scanPageSize = 256
+
+// rangeBackend é implementado pelos backends que começam a percorrer um
+// bucket a partir de uma chave, sem passar pelas anteriores (cursor do bbolt).
+// Os outros são percorridos do início, pulando as chaves menores que start.
+type rangeBackend interface {
+	// ForEachFrom percorre em ordem as chaves >= start; start nil começa do início
+	ForEachFrom(bucket, start []byte, fn func(key, value []byte) error) error
+}
+
+// forEachFrom usa o ForEachFrom do backend quando ele existir
+func forEachFrom(b Backend, bucket, start []byte, fn func(key, value []byte) error) error {
+	if r, ok := b.(rangeBackend); ok {
+		return r.ForEachFrom(bucket, start, fn)
+	}
+	return b.ForEach(bucket, func(k, v []byte) error {
+		if start != nil && bytes.Compare(k, start) < 0 {
+			return nil
+		}
+		return fn(k, v)
+	})
+}
+
+type scanPair struct {
+	key   string
+	value string
+	// persisted indica que value veio do backend e ainda está codificado
+	persisted bool
+}
+
+// Scan percorre todas as chaves do namespace padrão em ordem, lendo o backend
+// com um cursor em páginas de scanPageSize. Diferente do GetAll ele não monta
+// um mapa com a store inteira, então enxerga também as chaves despejadas por
+// WithMaxEntries, e nenhum lock nem transação fica aberto enquanto fn roda.
+//
+// Para uma chave em memória vale o valor da memória, que é gravado antes do
+// backend. Chaves que ainda não estavam no backend quando a página delas foi
+// lida entram no fim, depois das persistidas. Escritas concorrentes ao Scan
+// podem ou não aparecer. Sem backend, percorre o snapshot do GetAll.
+func (kv *KVStore) Scan(fn func(key, value string) error) error {
+	backend := kv.storage()
+	if _, ok := backend.(noBackend); ok {
+		for k, v := range kv.GetAll() {
+			if err := fn(k, v); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	//seen guarda as chaves em memória já entregues, no máximo o que já está em memória
+	seen := make(map[string]struct{})
+
+	var after []byte
+	for {
+		page, err := kv.scanPage(backend, after)
+		if err != nil {
+			return err
+		}
+
+		kv.mu.RLock()
+		for i := range page {
+			if v, ok := kv.store[page[i].key]; ok {
+				page[i].value, page[i].persisted = v, false
+				seen[page[i].key] = struct{}{}
+			}
+		}
+		kv.mu.RUnlock()
+
+		for _, p := range page {
+			value := p.value
+			if p.persisted {
+				value = kv.decodeValue(value)
+			}
+			if err := fn(p.key, value); err != nil {
+				return err
+			}
+		}
+
+		if len(page) < scanPageSize {
+			break
+		}
+		after = []byte(page[len(page)-1].key)
+	}
+
+	kv.mu.RLock()
+	var rest []scanPair
+	for k, v := range kv.store {
+		if _, ok := seen[k]; !ok {
+			rest = append(rest, scanPair{key: k, value: v})
+		}
+	}
+	kv.mu.RUnlock()
+
+	slices.SortFunc(rest, func(a, b scanPair) int { return strings.Compare(a.key, b.key) })
+	for _, p := range rest {
+		if err := fn(p.key, p.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// scanPage lê até scanPageSize pares do backend com chave maior que after
+// (ou do início, com after nil). A transação fecha antes de voltar.
+func (kv *KVStore) scanPage(backend Backend, after []byte) ([]scanPair, error) {
+	page := make([]scanPair, 0, scanPageSize)
+
+	err := forEachFrom(backend, kv.bucket, after, func(k, v []byte) error {
+		if after != nil && bytes.Equal(k, after) {
+			return nil
+		}
+		page = append(page, scanPair{key: string(k), value: string(v), persisted: true})
+		if len(page) == scanPageSize {
+			return errStopIteration
+		}
+		return nil
+	})
+	if errors.Is(err, errStopIteration) {
+		err = nil
+	}
+	return page, err
+}
@@ -0,0 +1,100 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+// scanAll coleta o Scan na ordem em que as chaves chegaram
+func scanAll(t *testing.T, kv *KVStore) ([]string, map[string]string) {
+	t.Helper()
+
+	var keys []string
+	values := make(map[string]string)
+	err := kv.Scan(func(key, value string) error {
+		keys = append(keys, key)
+		values[key] = value
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Scan() failed: %v", err)
+	}
+	return keys, values
+}
+
+func TestKVStore_Scan_BeyondMaxEntries(t *testing.T) {
+	db := setupTestDB(t)
+	defer cleanupTestDB(t, db)
+	defer os.Remove("walog.ndjson")
+
+	// várias páginas, valores comprimidos e só 10 chaves em memória
+	store := NewKVStore(WithDB(db), WithMaxEntries(10, EvictMemory), WithCompressionThreshold(16))
+
+	const n = 3*scanPageSize + 7
+	for i := range n {
+		store.Put(fmt.Sprintf("key-%04d", i), strings.Repeat(fmt.Sprint(i), 10))
+	}
+
+	if got := len(store.GetAll()); got != 10 {
+		t.Fatalf("expected 10 keys in memory, got %d", got)
+	}
+
+	keys, values := scanAll(t, store)
+	if len(keys) != n {
+		t.Fatalf("Scan() returned %d keys, expected %d", len(keys), n)
+	}
+	for i, key := range keys {
+		if want := fmt.Sprintf("key-%04d", i); key != want {
+			t.Fatalf("key %d is %s, expected %s in order", i, key, want)
+		}
+		if want := strings.Repeat(fmt.Sprint(i), 10); values[key] != want {
+			t.Errorf("value of %s is %q, expected %q", key, values[key], want)
+		}
+	}
+}
+
+func TestKVStore_Scan_MemoryOverrides(t *testing.T) {
+	defer os.Remove("walog.ndjson")
+
+	store := NewKVStore(WithBackend(NewMemoryBackend()))
+	store.Put("a", "1")
+	store.Put("b", "2")
+
+	// simula escritas que já estão em memória mas ainda não no backend
+	store.mu.Lock()
+	store.store["a"] = "new"
+	store.store["0-pending"] = "x"
+	store.mu.Unlock()
+
+	keys, values := scanAll(t, store)
+
+	if want := []string{"a", "b", "0-pending"}; fmt.Sprint(keys) != fmt.Sprint(want) {
+		t.Errorf("Scan() order %v, expected %v", keys, want)
+	}
+	if values["a"] != "new" || values["0-pending"] != "x" {
+		t.Errorf("memory values should win over the backend, got %v", values)
+	}
+}
+
+func TestKVStore_Scan_StopsOnError(t *testing.T) {
+	defer os.Remove("walog.ndjson")
+
+	store := NewKVStore(WithBackend(NewMemoryBackend()))
+	for i := range 5 {
+		store.Put(fmt.Sprint(i), "v")
+	}
+
+	stop := errors.New("stop")
+	calls := 0
+	err := store.Scan(func(string, string) error {
+		calls++
+		return stop
+	})
+
+	if !errors.Is(err, stop) || calls != 1 {
+		t.Errorf("Scan() = %v after %d calls, expected the callback error after 1", err, calls)
+	}
+}
@@ -0,0 +1,249 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	"github.com/hashicorp/raft"
+)
+
+// DefaultSnapshotThreshold é quantas entradas aplicadas o log do raft acumula
+// antes de um novo snapshot
+const DefaultSnapshotThreshold uint64 = 8192
+
+// DefaultRetainSnapshotCount é quantos snapshots do raft ficam em disco; os
+// mais antigos são apagados a cada novo snapshot
+const DefaultRetainSnapshotCount = 3
+
+// snapshotCheckInterval é de quanto em quanto tempo o raft confere se o log
+// passou do snapshotThreshold
+const snapshotCheckInterval = 10 * time.Second
+
+// snapshotConfig passa para o raft os gatilhos de snapshot: a cada
+// SnapshotInterval (com um atraso aleatório de até outro intervalo) o raft
+// tira um snapshot se o log avançou SnapshotThreshold entradas desde o último,
+// e depois descarta o log antigo (mantendo só TrailingLogs). Com
+// snapshotInterval o raft confere nesse intervalo e qualquer entrada nova
+// basta; com os dois zerados o raft nunca chega ao limite e só o Compact tira
+// snapshots.
+func (kv *KVStore) snapshotConfig(config *raft.Config) {
+	config.SnapshotInterval = snapshotCheckInterval
+	config.SnapshotThreshold = kv.snapshotThreshold
+	switch {
+	case kv.snapshotInterval > 0:
+		config.SnapshotInterval = kv.snapshotInterval
+		config.SnapshotThreshold = 1
+	case kv.snapshotThreshold == 0:
+		config.SnapshotThreshold = math.MaxUint64
+	}
+}
+
+// snapshotEntry é uma chave do snapshot. O valor vai como []byte (base64 no
+// JSON) para não perder valores que não são UTF-8 válido.
+type snapshotEntry struct {
+	Key       string `json:"key"`
+	Value     []byte `json:"value"`
+	Revision  uint64 `json:"revision,omitempty"`
+	CreatedAt int64  `json:"created_at,omitempty"`
+	UpdatedAt int64  `json:"updated_at,omitempty"`
+}
+
+// snapshotState é o conteúdo de um snapshot do raft: o namespace padrão com
+// as revisões e os tempos das chaves, o contador global e os outros namespaces
+type snapshotState struct {
+	Revision   uint64                     `json:"revision"`
+	Keys       []snapshotEntry            `json:"keys"`
+	Namespaces map[string][]snapshotEntry `json:"namespaces,omitempty"`
+}
+
+type kvSnapshot struct {
+	state snapshotState
+}
+
+// Snapshot copia a store sob o read lock; o Persist grava a cópia em paralelo
+// com novas escritas.
+func (s *fsm) Snapshot() (raft.FSMSnapshot, error) {
+	kv := (*KVStore)(s)
+
+	kv.mu.RLock()
+	defer kv.mu.RUnlock()
+
+	state := snapshotState{
+		Revision: kv.revision,
+		Keys:     make([]snapshotEntry, 0, len(kv.store)),
+	}
+	for key, value := range kv.store {
+		e := snapshotEntry{Key: key, Value: []byte(value), Revision: kv.revisions[key]}
+		if t, ok := kv.times[key]; ok {
+			e.CreatedAt, e.UpdatedAt = t.created.UnixNano(), t.updated.UnixNano()
+		}
+		state.Keys = append(state.Keys, e)
+	}
+	if len(kv.namespaces) > 0 {
+		state.Namespaces = make(map[string][]snapshotEntry, len(kv.namespaces))
+		for ns, data := range kv.namespaces {
+			entries := make([]snapshotEntry, 0, len(data))
+			for key, value := range data {
+				entries = append(entries, snapshotEntry{Key: key, Value: []byte(value)})
+			}
+			state.Namespaces[ns] = entries
+		}
+	}
+
+	return &kvSnapshot{state: state}, nil
+}
+
+// Restore troca todo o estado do nó pelo do snapshot, em memória e no banco.
+// O raft só chama o Restore quando um follower ficou para trás do log que o
+// líder ainda tem (InstallSnapshot): num restart o nó se recupera pelo
+// próprio banco e pelo WAL (ver NoSnapshotRestoreOnStart no Open).
+func (s *fsm) Restore(rc io.ReadCloser) error {
+	defer rc.Close()
+
+	var state snapshotState
+	if err := json.NewDecoder(rc).Decode(&state); err != nil {
+		return fmt.Errorf("decode raft snapshot: %w", err)
+	}
+	return (*KVStore)(s).restoreSnapshot(state)
+}
+
+// restoreSnapshot apaga o namespace padrão, os namespaces e os metadados das
+// chaves e grava o estado do snapshot numa única transação. O checkpoint do
+// WAL avança junto, então o ReplayWAL não reaplica por cima do snapshot
+// escritas anteriores a ele. Os watchers das chaves que mudaram são avisados;
+// os do WatchAll não recebem eventos do restore.
+func (kv *KVStore) restoreSnapshot(state snapshotState) error {
+	kv.lockAll()
+	defer kv.unlockAll()
+
+	if kv.closed.Load() {
+		return ErrClosed
+	}
+
+	store := make(map[string]string, len(state.Keys))
+	revisions := make(map[string]uint64, len(state.Keys))
+	times := make(map[string]keyTimes, len(state.Keys))
+	for _, e := range state.Keys {
+		store[e.Key] = string(e.Value)
+		if e.Revision != 0 {
+			revisions[e.Key] = e.Revision
+		}
+		if e.UpdatedAt != 0 {
+			times[e.Key] = keyTimes{created: time.Unix(0, e.CreatedAt), updated: time.Unix(0, e.UpdatedAt)}
+		}
+	}
+	namespaces := make(map[string]map[string]string, len(state.Namespaces))
+	for ns, entries := range state.Namespaces {
+		data := make(map[string]string, len(entries))
+		for _, e := range entries {
+			data[e.Key] = string(e.Value)
+		}
+		namespaces[ns] = data
+	}
+
+	checkpoint := WALSequence()
+	err := kv.storage().Update(func(tx Backend) error {
+		for _, name := range [][]byte{kv.bucket, kv.revisionsBucket(), kv.timesBucket(), kv.sequencesBucket(), kv.tombstonesBucket(), kv.valueIndexBucket()} {
+			if err := tx.ClearBucket(name); err != nil {
+				return err
+			}
+		}
+		for ns := range kv.namespaces {
+			if err := tx.DeleteBucket(kv.bucketFor(ns)); err != nil {
+				return err
+			}
+		}
+
+		for key, value := range store {
+			rev, t := revisions[key], times[key]
+			if err := kv.indexValue(tx, key, value, false); err != nil {
+				return err
+			}
+			if err := tx.Put(kv.bucket, []byte(key), kv.encodeEntry(value, rev, t)); err != nil {
+				return err
+			}
+			if rev == 0 || kv.valueEnvelope {
+				continue
+			}
+			if err := tx.Put(kv.revisionsBucket(), []byte(key), encodeRevision(rev)); err != nil {
+				return err
+			}
+			if t.updated.IsZero() {
+				continue
+			}
+			if err := tx.Put(kv.timesBucket(), []byte(key), encodeTimes(t)); err != nil {
+				return err
+			}
+		}
+		for ns, data := range namespaces {
+			for key, value := range data {
+				if err := tx.Put(kv.bucketFor(ns), []byte(key), kv.encodeEntry(value, 0, keyTimes{})); err != nil {
+					return err
+				}
+			}
+		}
+
+		if err := tx.Put(kv.metaBucket(), revisionCounterKey, encodeRevision(state.Revision)); err != nil {
+			return err
+		}
+		return tx.Put(kv.metaBucket(), walCheckpointKey, encodeRevision(checkpoint))
+	})
+	if err == nil {
+		err = kv.Flush()
+	}
+	if err != nil {
+		return fmt.Errorf("restore raft snapshot: %w", err)
+	}
+
+	oldStore, oldNamespaces := kv.store, kv.namespaces
+	kv.store, kv.namespaces = store, namespaces
+	kv.revisions, kv.times = revisions, times
+	kv.revision = state.Revision
+	kv.persistedRevision.Store(state.Revision)
+	kv.invalidateSnapshot()
+	if kv.lru != nil {
+		kv.lru.reset()
+		for key := range store {
+			kv.trackLocked(key, true)
+		}
+	}
+
+	//notifyDeleteLocked mexe em kv.watchers, então os watchers são lidos antes
+	var watched []*KVWatcher
+	for _, ws := range kv.watchers {
+		watched = append(watched, ws[0])
+	}
+	for _, w := range watched {
+		before, after := oldStore, store
+		if w.Namespace != "" {
+			before, after = oldNamespaces[w.Namespace], namespaces[w.Namespace]
+		}
+		old, existed := before[w.Key]
+		value, exists := after[w.Key]
+		switch {
+		case exists && (!existed || old != value):
+			e := updateEvent(w.Namespace, w.Key, value, revisions[w.Key])
+			for _, kw := range kv.watchers[watchKey(w.Namespace, w.Key)] {
+				kv.notify(kw, e)
+			}
+		case existed && !exists:
+			kv.notifyDeleteLocked(deleteEvent(w.Namespace, w.Key, state.Revision))
+		}
+	}
+
+	kv.logger.Info("restored raft snapshot", "keys", len(store), "namespaces", len(namespaces), "revision", state.Revision)
+	return nil
+}
+
+func (s *kvSnapshot) Persist(sink raft.SnapshotSink) error {
+	if err := json.NewEncoder(sink).Encode(s.state); err != nil {
+		sink.Cancel()
+		return err
+	}
+	return sink.Close()
+}
+
+func (s *kvSnapshot) Release() {}
@@ -0,0 +1,122 @@
+package store
+
+import "strconv"
+
+// stateStandalone é reportado quando o raft não foi aberto
+const stateStandalone = "Standalone"
+
+// NodeStatus resume o estado do nó para health checks
+type NodeStatus struct {
+	NodeID        string
+	State         string
+	LeaderAddress string
+	LeaderID      string
+	Keys          int
+	WALSize       int64
+	// DroppedEvents e WatchQueueDepth mostram se os watchers estão atrasados
+	DroppedEvents   uint64
+	WatchQueueDepth int
+}
+
+// Status agrega o estado do raft e da store. Sem raft o nó se reporta
+// como Standalone e sem líder.
+func (kv *KVStore) Status() NodeStatus {
+	status := NodeStatus{
+		NodeID:  kv.nodeID,
+		State:   stateStandalone,
+		Keys:    kv.Count(),
+		WALSize: WALSize(),
+
+		DroppedEvents:   kv.DroppedEvents(),
+		WatchQueueDepth: kv.WatchQueueDepth(),
+	}
+
+	if kv.raft != nil {
+		leaderAddr, leaderID := kv.raft.LeaderWithID()
+		status.State = kv.raft.State().String()
+		status.LeaderAddress = string(leaderAddr)
+		status.LeaderID = string(leaderID)
+	}
+
+	return status
+}
+
+// WatcherCount retorna quantos watchers estão ativos
+func (kv *KVStore) WatcherCount() int {
+	kv.mu.RLock()
+	defer kv.mu.RUnlock()
+
+	count := len(kv.allWatchers)
+	for _, watchers := range kv.watchers {
+		count += len(watchers)
+	}
+
+	return count
+}
+
+// DroppedEvents retorna quantos eventos foram descartados desde a abertura da
+// store porque o buffer de um watcher estava cheio (OverflowDropNewest e
+// OverflowDropOldest) ou porque um watcher com OverflowBlock foi desconectado
+// sem ler. Um valor que cresce indica consumidores atrasados.
+func (kv *KVStore) DroppedEvents() uint64 {
+	return kv.droppedEvents.Load()
+}
+
+// WatchQueueDepth retorna quantos eventos estão nos buffers e nas filas dos
+// watchers esperando ser lidos, somando todos
+func (kv *KVStore) WatchQueueDepth() int {
+	kv.mu.RLock()
+	defer kv.mu.RUnlock()
+
+	depth := 0
+	for _, w := range kv.allWatchers {
+		depth += w.queueDepth()
+	}
+	for _, watchers := range kv.watchers {
+		for _, w := range watchers {
+			depth += w.queueDepth()
+		}
+	}
+
+	return depth
+}
+
+// ClusterServer é um servidor da configuração do raft
+type ClusterServer struct {
+	ID      string
+	Address string
+	// Suffrage é Voter, Nonvoter ou Staging
+	Suffrage string
+}
+
+// ClusterInfo é a configuração do raft vista por este nó
+type ClusterInfo struct {
+	Servers       []ClusterServer
+	Term          uint64
+	LeaderID      string
+	LeaderAddress string
+}
+
+// ClusterInfo devolve os servidores da configuração do raft, o termo atual e
+// o líder conhecido por este nó. Sem raft retorna ErrRaftNotOpen.
+func (kv *KVStore) ClusterInfo() (ClusterInfo, error) {
+	if kv.raft == nil {
+		return ClusterInfo{}, ErrRaftNotOpen
+	}
+
+	f := kv.raft.GetConfiguration()
+	if err := f.Error(); err != nil {
+		return ClusterInfo{}, err
+	}
+
+	var info ClusterInfo
+	for _, s := range f.Configuration().Servers {
+		info.Servers = append(info.Servers, ClusterServer{ID: string(s.ID), Address: string(s.Address), Suffrage: s.Suffrage.String()})
+	}
+
+	leaderAddr, leaderID := kv.raft.LeaderWithID()
+	info.LeaderAddress, info.LeaderID = string(leaderAddr), string(leaderID)
+	info.Term, _ = strconv.ParseUint(kv.raft.Stats()["term"], 10, 64)
+
+	return info, nil
+}
@@ -0,0 +1,73 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestKVStore_Status_Standalone(t *testing.T) {
+	store := NewKVStore()
+
+	store.PutFromDb("key1", "value1")
+	store.PutFromDb("key2", "value2")
+
+	status := store.Status()
+	if status.State != stateStandalone {
+		t.Errorf("Status() returned wrong state. Expected %s, got %s", stateStandalone, status.State)
+	}
+	if status.Keys != 2 {
+		t.Errorf("Status() returned wrong key count. Expected 2, got %d", status.Keys)
+	}
+	if status.LeaderAddress != "" {
+		t.Errorf("Status() without raft should not report a leader, got %s", status.LeaderAddress)
+	}
+}
+
+func TestKVStore_Status_BootstrappedLeader(t *testing.T) {
+	db := setupTestDB(t)
+	defer cleanupTestDB(t, db)
+	defer os.Remove("walog.ndjson")
+
+	Init(db)
+	store := NewKVStore()
+
+	nodeID := "status_test_node"
+	defer os.RemoveAll(filepath.Join("data", nodeID))
+	defer os.Remove("data")
+
+	if err := store.Open("localhost:0", nodeID); err != nil {
+		t.Fatalf("Open() failed: %v", err)
+	}
+	defer store.ShutdownRaft()
+
+	// Um nó único com bootstrap se elege líder sozinho
+	deadline := time.Now().Add(5 * time.Second)
+	for store.Status().State != "Leader" && time.Now().Before(deadline) {
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	for _, key := range []string{"key1", "key2", "key3"} {
+		if err := store.Put(key, "value"); err != nil {
+			t.Fatalf("Put() failed: %v", err)
+		}
+	}
+
+	status := store.Status()
+	if status.State != "Leader" {
+		t.Fatalf("Status() returned wrong state. Expected Leader, got %s", status.State)
+	}
+	if status.NodeID != nodeID {
+		t.Errorf("Status() returned wrong node id. Expected %s, got %s", nodeID, status.NodeID)
+	}
+	if status.LeaderID != nodeID {
+		t.Errorf("Status() returned wrong leader id. Expected %s, got %s", nodeID, status.LeaderID)
+	}
+	if status.Keys != 3 {
+		t.Errorf("Status() returned wrong key count. Expected 3, got %d", status.Keys)
+	}
+	if status.WALSize == 0 {
+		t.Error("Status() returned empty WAL size after writes")
+	}
+}
@@ -0,0 +1,57 @@
+package store
+
+import (
+	"hash/fnv"
+	"sync"
+)
+
+// lockStripes é quantos mutexes dividem as chaves entre as escritas
+const lockStripes = 64
+
+// As escritas de uma chave (Put, Delete, PutIfAbsent, PutIfVersion) seguram
+// o stripe da chave do WAL até o raft, e kv.mu só enquanto mexem no log, na
+// memória e nos watchers. A escrita no banco e a espera pelo raft ficam fora
+// de kv.mu, então escritas de chaves diferentes andam em paralelo, enquanto
+// as de uma mesma chave continuam na ordem em que entraram no WAL.
+//
+// As operações que mexem na store inteira (Clear, DropNamespace, Txn,
+// Checkpoint e Close) travam todos os stripes com lockAll, esperando as
+// escritas em andamento terminarem.
+
+// stripe devolve o mutex da chave
+func (kv *KVStore) stripe(ns, key string) *sync.Mutex {
+	h := fnv.New32a()
+	h.Write([]byte(watchKey(ns, key)))
+	return &kv.stripes[h.Sum32()%lockStripes]
+}
+
+// lockAll trava todos os stripes, sempre na mesma ordem, e depois kv.mu
+func (kv *KVStore) lockAll() {
+	for i := range kv.stripes {
+		kv.stripes[i].Lock()
+	}
+	kv.mu.Lock()
+}
+
+func (kv *KVStore) unlockAll() {
+	kv.mu.Unlock()
+	for i := range kv.stripes {
+		kv.stripes[i].Unlock()
+	}
+}
+
+// unlockForIO solta kv.mu antes da escrita de uma chave no banco. Com
+// WithMaxEntries o lock fica: o despejo do LRU apaga do banco chaves de outros
+// stripes e não pode cruzar com a escrita delas.
+func (kv *KVStore) unlockForIO() {
+	if kv.lru == nil {
+		kv.mu.Unlock()
+	}
+}
+
+// lockAfterIO trava de novo o que o unlockForIO soltou
+func (kv *KVStore) lockAfterIO() {
+	if kv.lru == nil {
+		kv.mu.Lock()
+	}
+}
@@ -0,0 +1,122 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// Rode com -race: escritas de chaves diferentes não dividem mais o kv.mu
+// durante a escrita no banco
+func TestKVStore_ConcurrentWrites(t *testing.T) {
+	defer os.Remove("walog.ndjson")
+
+	kv := NewKVStore(WithBackend(NewMemoryBackend()))
+
+	const keys = 16
+	watchers := make([]*KVWatcher, keys)
+	for i := range keys {
+		//com um buffer de 1 e DropOldest sobra só o último evento da chave
+		watchers[i] = kv.Watch(fmt.Sprintf("k%d", i), WithBufferSize(1), WithOverflowPolicy(OverflowDropOldest))
+	}
+
+	var wg sync.WaitGroup
+	for g := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 200 {
+				key := fmt.Sprintf("k%d", (g+i)%keys)
+				switch i % 5 {
+				case 3:
+					kv.Delete(key)
+				case 4:
+					kv.Namespace("users").Put(key, "x")
+				default:
+					kv.Put(key, fmt.Sprintf("%d-%d", g, i))
+				}
+			}
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := range 20 {
+			kv.Txn(context.Background(), nil, []TxnOp{{Type: TxnPut, Key: "k0", Value: fmt.Sprintf("txn-%d", i)}}, nil)
+			kv.Unwatch(kv.Watch("k1", WithInitialValue()))
+		}
+	}()
+	wg.Wait()
+
+	var maxRev uint64
+	for i := range keys {
+		key := fmt.Sprintf("k%d", i)
+		value, ok := kv.Lookup(key)
+
+		// memória e banco terminam com a mesma escrita
+		raw, err := kv.storage().Get(kv.bucket, []byte(key))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok != (raw != nil) || (ok && kv.decodeValue(string(raw)) != value) {
+			t.Errorf("%s: memory has %q (%v), backend has %q", key, value, ok, raw)
+		}
+		rev, _ := kv.storage().Get(kv.revisionsBucket(), []byte(key))
+		if ok {
+			if len(rev) != 8 || decodeRevision(rev) != kv.Revision(key) {
+				t.Errorf("%s: persisted revision %v, expected %d", key, rev, kv.Revision(key))
+			}
+			maxRev = max(maxRev, kv.Revision(key))
+		}
+
+		// e o watcher viu por último a escrita que ficou
+		want := fmt.Sprintf("Key %s deleted", key)
+		if ok {
+			want = fmt.Sprintf("Key %s updated to %s", key, value)
+		}
+		if got := <-watchers[i].Events; got.Message != want {
+			t.Errorf("%s: last event %q, expected %q", key, got, want)
+		}
+	}
+
+	counter, err := kv.storage().Get(kv.metaBucket(), revisionCounterKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(counter) != 8 || decodeRevision(counter) < maxRev {
+		t.Errorf("persisted revision counter %v is behind revision %d", counter, maxRev)
+	}
+}
+
+// latencyBackend atrasa cada Update, como um backend remoto ou um fsync lento
+type latencyBackend struct {
+	Backend
+	delay time.Duration
+}
+
+func (b latencyBackend) Update(fn func(tx Backend) error) error {
+	time.Sleep(b.delay)
+	return b.Backend.Update(fn)
+}
+
+// Com o kv.mu solto durante a escrita no banco, as esperas de chaves
+// diferentes se sobrepõem e o ns/op cai com -cpu maior
+func BenchmarkKVStore_ConcurrentPut(b *testing.B) {
+	defer os.Remove("walog.ndjson")
+
+	kv := NewKVStore(WithBackend(latencyBackend{NewMemoryBackend(), 100 * time.Microsecond}))
+
+	var n atomic.Int64
+	b.SetParallelism(4)
+	b.ResetTimer()
+	b.RunParallel(func(p *testing.PB) {
+		for p.Next() {
+			i := n.Add(1)
+			kv.Put(fmt.Sprintf("key_%d", i), "value")
+		}
+	})
+}
@@ -0,0 +1,102 @@
+package store
+
+import "fmt"
+
+// Cada escrita no namespace padrão grava no banco a sequência do WAL que a
+// gerou, e cada delete deixa um tombstone com a sua. O ReplayWAL só aplica
+// uma entrada se ela for mais nova que a última operação gravada na chave,
+// então vale sempre a operação de maior sequência: um delete posterior ao
+// snapshot do banco não é desfeito por uma escrita mais antiga do log, mesmo
+// quando o banco já tem escritas depois do checkpoint (ex.: o Txn grava no
+// banco antes do WAL). Um Clear vale como tombstone de todas as chaves, com
+// a sequência guardada no bucket de metadados.
+//
+// O Checkpoint descarta as sequências cobertas por ele, já que nenhuma
+// entrada até ali volta a ser reaplicada.
+
+// clearSequenceKey guarda no bucket de metadados a sequência do último Clear
+var clearSequenceKey = []byte("clear_sequence")
+
+func (kv *KVStore) sequencesBucket() []byte {
+	return []byte(string(kv.bucket) + ".sequences")
+}
+
+func (kv *KVStore) tombstonesBucket() []byte {
+	return []byte(string(kv.bucket) + ".tombstones")
+}
+
+// persistSequence grava a sequência da última operação na chave: a da
+// escrita ou, com deleted, um tombstone. seq 0 (WAL fechado) não grava nada.
+func (kv *KVStore) persistSequence(tx Backend, key string, seq uint64, deleted bool) error {
+	if seq == 0 {
+		return nil
+	}
+
+	set, unset := kv.sequencesBucket(), kv.tombstonesBucket()
+	if deleted {
+		set, unset = unset, set
+	}
+	if err := tx.Delete(unset, []byte(key)); err != nil {
+		return err
+	}
+	return tx.Put(set, []byte(key), encodeRevision(seq))
+}
+
+// keySequence retorna a sequência da última operação gravada na chave,
+// escrita ou tombstone, 0 se não houver nenhuma
+func (kv *KVStore) keySequence(tx Backend, key string) (uint64, error) {
+	var seq uint64
+	for _, bucket := range [][]byte{kv.sequencesBucket(), kv.tombstonesBucket()} {
+		v, err := tx.Get(bucket, []byte(key))
+		if err != nil {
+			return 0, err
+		}
+		if len(v) == 8 {
+			seq = max(seq, decodeRevision(v))
+		}
+	}
+	return seq, nil
+}
+
+// clearSequence retorna a sequência do último Clear gravado no banco
+func clearSequence(tx Backend, meta []byte) (uint64, error) {
+	v, err := tx.Get(meta, clearSequenceKey)
+	if err != nil || len(v) != 8 {
+		return 0, err
+	}
+	return decodeRevision(v), nil
+}
+
+// pruneSequences apaga as sequências e os tombstones até upTo e retorna
+// quantos foram removidos
+func (kv *KVStore) pruneSequences(upTo uint64) (int, error) {
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+
+	var n int
+	err := kv.storage().Update(func(tx Backend) error {
+		for _, bucket := range [][]byte{kv.sequencesBucket(), kv.tombstonesBucket()} {
+			var stale [][]byte
+			err := tx.ForEach(bucket, func(k, v []byte) error {
+				if len(v) != 8 || decodeRevision(v) <= upTo {
+					stale = append(stale, append([]byte(nil), k...))
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			for _, k := range stale {
+				if err := tx.Delete(bucket, k); err != nil {
+					return err
+				}
+			}
+			n += len(stale)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("prune sequences: %w", err)
+	}
+	return n, nil
+}
@@ -0,0 +1,153 @@
+package store
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/carvalhodanielg/kvstore/internal/constants"
+	bolt "go.etcd.io/bbolt"
+)
+
+// crashAndReplay fecha o banco sem gravar o lote pendente, reabre e roda o
+// ReplayWAL, como o servidor faz na inicialização
+func crashAndReplay(t *testing.T, d *bolt.DB) (*KVStore, *bolt.DB, int) {
+	t.Helper()
+
+	path := d.Path()
+	d.Close()
+
+	d, err := OpenDB(path, constants.BucketStore, DefaultDBConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { d.Close() })
+
+	kv := NewKVStore(WithDB(d))
+	n, err := kv.ReplayWAL()
+	if err != nil {
+		t.Fatalf("ReplayWAL() failed: %v", err)
+	}
+	if err := kv.LoadKeys(); err != nil {
+		t.Fatal(err)
+	}
+	return kv, d, n
+}
+
+// useTempWAL grava o WAL em dir, reabrindo-o caso um teste anterior tenha
+// fechado (ex.: o Close da EmbeddedStore)
+func useTempWAL(t *testing.T, dir string) {
+	SetWALPath(filepath.Join(dir, WALFileName))
+	OpenWAL()
+	t.Cleanup(func() { SetWALPath(WALFileName) })
+}
+
+func TestKVStore_ReplayWAL_DeleteAfterSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	useTempWAL(t, dir)
+
+	d, err := OpenDB(filepath.Join(dir, constants.DBFileName), constants.BucketStore, DefaultDBConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	kv := NewKVStore(WithBackend(NewBatchBackend(NewBoltBackend(d), time.Hour, 1<<20)))
+	kv.Put("k", "v")
+	kv.Put("other", "1")
+
+	// snapshot: o bbolt passa a ter k, mas o WAL continua com a escrita
+	if err := kv.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	// o delete fica só no WAL
+	if err := kv.Delete("k"); err != nil {
+		t.Fatal(err)
+	}
+
+	restored, d, n := crashAndReplay(t, d)
+	if n != 1 {
+		t.Errorf("ReplayWAL() applied %d entries, expected only the delete", n)
+	}
+	if _, ok := restored.Lookup("k"); ok {
+		t.Fatal("k came back after recovery")
+	}
+	if got := restored.Get("other"); got != "1" {
+		t.Errorf("expected other=1 after recovery, got %q", got)
+	}
+
+	// com o tombstone no banco, um segundo replay do mesmo WAL não recria a chave
+	restored, _, n = crashAndReplay(t, d)
+	if n != 0 {
+		t.Errorf("second ReplayWAL() applied %d entries, expected 0", n)
+	}
+	if _, ok := restored.Lookup("k"); ok {
+		t.Error("k came back after a second recovery")
+	}
+}
+
+func TestKVStore_ReplayWAL_KeepsNewerOperation(t *testing.T) {
+	dir := t.TempDir()
+	useTempWAL(t, dir)
+
+	d, err := OpenDB(filepath.Join(dir, constants.DBFileName), constants.BucketStore, DefaultDBConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	kv := NewKVStore(WithBackend(NewBatchBackend(NewBoltBackend(d), time.Hour, 1<<20)))
+	kv.Put("k", "old")
+	kv.Delete("k")
+
+	// o banco já tem uma escrita mais nova que tudo no WAL, como um Txn que
+	// chegou ao bbolt antes do crash mas não ao log
+	newer := WALSequence() + 1
+	err = NewBoltBackend(d).Update(func(tx Backend) error {
+		if err := tx.Put(kv.bucket, []byte("k"), encodeValue("new", 0)); err != nil {
+			return err
+		}
+		return kv.persistSequence(tx, "k", newer, false)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	restored, _, n := crashAndReplay(t, d)
+	if n != 0 {
+		t.Errorf("ReplayWAL() applied %d entries older than the database, expected 0", n)
+	}
+	if got := restored.Get("k"); got != "new" {
+		t.Errorf("expected the newer write to win, got k=%q", got)
+	}
+}
+
+func TestKVStore_Checkpoint_PrunesTombstones(t *testing.T) {
+	useTempWAL(t, t.TempDir())
+	db := setupTestDB(t)
+	defer cleanupTestDB(t, db)
+
+	kv := NewKVStore(WithDB(db))
+	kv.Put("a", "1")
+	kv.Put("b", "2")
+	kv.Delete("b")
+
+	count := func(bucket []byte) int {
+		n := 0
+		kv.storage().ForEach(bucket, func(_, _ []byte) error {
+			n++
+			return nil
+		})
+		return n
+	}
+	if count(kv.sequencesBucket()) != 1 || count(kv.tombstonesBucket()) != 1 {
+		t.Fatalf("expected one sequence and one tombstone before the checkpoint, got %d and %d",
+			count(kv.sequencesBucket()), count(kv.tombstonesBucket()))
+	}
+
+	if _, err := kv.Checkpoint(); err != nil {
+		t.Fatalf("Checkpoint() failed: %v", err)
+	}
+	if n := count(kv.sequencesBucket()) + count(kv.tombstonesBucket()); n != 0 {
+		t.Errorf("expected the checkpoint to prune covered sequences, %d left", n)
+	}
+}
@@ -0,0 +1,329 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// CompareTarget define o que uma Compare confere na chave
+type CompareTarget uint8
+
+const (
+	// CompareValue exige que a chave exista com o valor informado
+	CompareValue CompareTarget = iota
+	// CompareRevision exige que a chave esteja na revisão informada (0: não existe)
+	CompareRevision
+)
+
+// Compare é uma condição de igualdade sobre uma chave do namespace padrão
+type Compare struct {
+	Key      string
+	Target   CompareTarget
+	Value    string
+	Revision uint64
+}
+
+// TxnOpType é o tipo de uma escrita dentro do Txn
+type TxnOpType uint8
+
+const (
+	TxnPut TxnOpType = iota
+	TxnDelete
+)
+
+// TxnOp é uma escrita do Txn; Value só vale para TxnPut
+type TxnOp struct {
+	Type  TxnOpType
+	Key   string
+	Value string
+}
+
+// TxnResult diz qual ramo do Txn foi aplicado e a revisão da store depois dele
+type TxnResult struct {
+	Succeeded bool
+	Revision  uint64
+}
+
+// Txn confere todas as compares e, se todas baterem, aplica thenOps; senão
+// aplica elseOps. Tudo acontece sob o mesmo write lock: as escritas de um ramo
+// vão para o WAL juntas, para o banco em uma única transação e para o raft
+// como um único comando. Uma operação inválida em qualquer ramo faz o Txn
+// falhar antes de escrever qualquer coisa, e uma falha no banco não altera a
+// memória.
+func (kv *KVStore) Txn(ctx context.Context, compares []Compare, thenOps, elseOps []TxnOp) (TxnResult, error) {
+	for _, ops := range [][]TxnOp{thenOps, elseOps} {
+		for _, op := range ops {
+			if err := kv.validateTxnOp(op); err != nil {
+				return TxnResult{}, err
+			}
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return TxnResult{}, err
+	}
+	if err := kv.checkLeader(ctx); err != nil {
+		return TxnResult{}, err
+	}
+
+	//os hooks rodam depois de soltar os locks
+	var applied []mutation
+	defer func() { kv.runHooks(applied) }()
+
+	kv.lockAll()
+	defer kv.unlockAll()
+
+	if err := ctx.Err(); err != nil {
+		return TxnResult{}, err
+	}
+	if kv.closed.Load() {
+		return TxnResult{}, ErrClosed
+	}
+
+	succeeded := true
+	for _, c := range compares {
+		if !kv.compareLocked(c) {
+			succeeded = false
+			break
+		}
+	}
+
+	ops := thenOps
+	if !succeeded {
+		ops = elseOps
+	}
+	if len(ops) == 0 {
+		return TxnResult{Succeeded: succeeded, Revision: kv.revision}, nil
+	}
+
+	c, err := kv.applyOpsLocked(ops, &applied)
+	if err != nil {
+		return TxnResult{}, fmt.Errorf("txn: %w", err)
+	}
+
+	kv.logger.Debug("txn", "succeeded", succeeded, "ops", len(ops))
+
+	if err := kv.replicate(ctx, c); err != nil {
+		return TxnResult{}, err
+	}
+	return TxnResult{Succeeded: succeeded, Revision: kv.revision}, nil
+}
+
+// applyOpsLocked grava ops no WAL, depois no banco numa única transação e
+// por fim na memória, avisa os watchers e devolve o comando que vai para o
+// raft. As mutações para os hooks vão para applied. Deve ser chamado com
+// kv.lockAll; um erro do banco não altera a memória e é desfeito no WAL.
+func (kv *KVStore) applyOpsLocked(ops []TxnOp, applied *[]mutation) (*command, error) {
+	//as revisões só ficam valendo se o banco aceitar a transação
+	base := kv.revision
+	revs := make([]uint64, len(ops))
+	for i := range ops {
+		revs[i] = base + uint64(i) + 1
+	}
+	kv.revision = revs[len(revs)-1]
+
+	//os tempos seguem a ordem das operações, então um delete seguido de put
+	//na mesma transação recria a chave
+	stamped := time.Now()
+	times := make([]keyTimes, len(ops))
+	overlay := make(map[string]keyTimes)
+	for i, op := range ops {
+		if op.Type == TxnDelete {
+			overlay[op.Key] = keyTimes{}
+			continue
+		}
+		t, ok := overlay[op.Key]
+		if !ok {
+			t = kv.times[op.Key]
+		}
+		if t.created.IsZero() {
+			t.created = stamped
+		}
+		t.updated = stamped
+		overlay[op.Key], times[i] = t, t
+	}
+
+	var mutations []mutation
+	if kv.hasHooks() {
+		mutations = kv.txnMutationsLocked(ops)
+	}
+
+	//escreve no log -> banco -> memória, como o put
+	entries := make([]WalLog, len(ops))
+	for i, op := range ops {
+		entries[i] = WalLog{Operation: Write, Key: op.Key, Timestamp: stamped.UnixNano(), CreatedAt: times[i].created.UnixNano(), Revision: revs[i]}
+		if op.Type == TxnDelete {
+			entries[i].Operation = Delete
+			entries[i].CreatedAt = 0
+		} else {
+			kv.setWALValue(&entries[i], op.Value)
+		}
+	}
+	//as entradas são gravadas juntas e recebem sequências seguidas; a última
+	//é 0 com o WAL fechado
+	last := appendLogsToFile(entries)
+
+	err := kv.storage().Update(func(tx Backend) error {
+		for i, op := range ops {
+			var seq uint64
+			if last != 0 {
+				seq = last - uint64(len(ops)-1-i)
+			}
+			if err := kv.persistSequence(tx, op.Key, seq, op.Type == TxnDelete); err != nil {
+				return err
+			}
+			if err := kv.indexValue(tx, op.Key, op.Value, op.Type == TxnDelete); err != nil {
+				return err
+			}
+			if op.Type == TxnDelete {
+				if err := tx.Delete(kv.bucket, []byte(op.Key)); err != nil {
+					return err
+				}
+				if err := kv.persistRevision(tx, op.Key, revs[i], keyTimes{}, true); err != nil {
+					return err
+				}
+				continue
+			}
+
+			if err := tx.Put(kv.bucket, []byte(op.Key), kv.encodeEntry(op.Value, revs[i], times[i])); err != nil {
+				return err
+			}
+			if err := kv.persistRevision(tx, op.Key, revs[i], times[i], false); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		kv.revision = base
+		//o replay não pode reaplicar as entradas que o banco recusou
+		undone := make(map[string]bool, len(ops))
+		for _, op := range ops {
+			if !undone[op.Key] {
+				undone[op.Key] = true
+				kv.logUndo("", op.Key)
+			}
+		}
+		return nil, err
+	}
+
+	//memória, depois os watchers e o raft
+	c := &command{Op: "txn"}
+	for i, op := range ops {
+		if op.Type == TxnPut {
+			kv.store[op.Key] = op.Value
+			kv.revisions[op.Key] = revs[i]
+			kv.times[op.Key] = times[i]
+			c.Ops = append(c.Ops, command{Op: "put", Key: op.Key, Value: op.Value})
+		} else {
+			delete(kv.store, op.Key)
+			delete(kv.revisions, op.Key)
+			delete(kv.times, op.Key)
+			kv.untrackLocked(op.Key)
+			c.Ops = append(c.Ops, command{Op: "del", Key: op.Key})
+		}
+	}
+	kv.invalidateSnapshot()
+	*applied = mutations
+	for _, op := range ops {
+		if op.Type == TxnPut {
+			*applied = append(*applied, kv.trackLocked(op.Key, false)...)
+		}
+	}
+
+	for i, op := range ops {
+		if op.Type == TxnPut {
+			kv.notifyLocked(updateEvent("", op.Key, op.Value, revs[i]))
+		} else {
+			e := deleteEvent("", op.Key, revs[i])
+			kv.notifyDeleteLocked(e)
+			kv.notifyAllLocked(e)
+		}
+	}
+
+	return c, nil
+}
+
+// DeleteMany apaga as chaves do namespace padrão sob um único lock e numa
+// única transação do banco, avisando os watchers de cada chave apagada, e
+// retorna quantas existiam. Chaves inexistentes ou repetidas são ignoradas.
+// Para o raft vai como um txn só de deletes.
+func (kv *KVStore) DeleteMany(keys []string) (int, error) {
+	return kv.DeleteManyContext(context.Background(), keys)
+}
+
+// DeleteManyContext funciona como o DeleteMany, mas desiste antes de
+// escrever se o contexto já tiver sido cancelado
+func (kv *KVStore) DeleteManyContext(ctx context.Context, keys []string) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	if err := kv.checkLeader(ctx); err != nil {
+		return 0, err
+	}
+
+	var applied []mutation
+	defer func() { kv.runHooks(applied) }()
+
+	kv.lockAll()
+	defer kv.unlockAll()
+
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	if kv.closed.Load() {
+		return 0, ErrClosed
+	}
+
+	//uma chave despejada pelo LRU só está no banco, mas também é apagada
+	var ops []TxnOp
+	seen := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		if _, ok := kv.valueLocked(key); ok {
+			ops = append(ops, TxnOp{Type: TxnDelete, Key: key})
+		}
+	}
+	if len(ops) == 0 {
+		return 0, nil
+	}
+
+	c, err := kv.applyOpsLocked(ops, &applied)
+	if err != nil {
+		return 0, fmt.Errorf("%w: delete many: %w", ErrWriteFailed, err)
+	}
+
+	kv.logger.Debug("delete many", "keys", len(keys), "deleted", len(ops))
+
+	if err := kv.replicate(ctx, c); err != nil {
+		return 0, err
+	}
+	return len(ops), nil
+}
+
+func (kv *KVStore) validateTxnOp(op TxnOp) error {
+	switch op.Type {
+	case TxnPut:
+		return kv.limits.validate(op.Key, op.Value)
+	case TxnDelete:
+		return nil
+	default:
+		return fmt.Errorf("txn: unknown operation type %d", op.Type)
+	}
+}
+
+// compareLocked avalia a condição; deve ser chamado com kv.mu travado
+func (kv *KVStore) compareLocked(c Compare) bool {
+	switch c.Target {
+	case CompareValue:
+		value, ok := kv.valueLocked(c.Key)
+		return ok && value == c.Value
+	case CompareRevision:
+		return kv.revisionLocked(c.Key) == c.Revision
+	default:
+		return false
+	}
+}
@@ -0,0 +1,244 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestKVStore_Txn_Then(t *testing.T) {
+	defer os.Remove("walog.ndjson")
+
+	backend := NewMemoryBackend()
+	store := NewKVStore(WithBackend(backend))
+	store.Put("balance:a", "100")
+	store.Put("balance:b", "0")
+	store.Put("lock", "held")
+
+	watcher := store.Watch("balance:b")
+	defer store.Unwatch(watcher)
+
+	compares := []Compare{
+		{Key: "balance:a", Target: CompareValue, Value: "100"},
+		{Key: "balance:b", Target: CompareRevision, Revision: store.Revision("balance:b")},
+		{Key: "pending", Target: CompareRevision, Revision: 0},
+	}
+	thenOps := []TxnOp{
+		{Type: TxnPut, Key: "balance:a", Value: "50"},
+		{Type: TxnPut, Key: "balance:b", Value: "50"},
+		{Type: TxnDelete, Key: "lock"},
+	}
+	elseOps := []TxnOp{{Type: TxnPut, Key: "conflict", Value: "1"}}
+
+	res, err := store.Txn(context.Background(), compares, thenOps, elseOps)
+	if err != nil {
+		t.Fatalf("Txn() failed: %v", err)
+	}
+	if !res.Succeeded {
+		t.Fatal("Txn() took the else branch, expected then")
+	}
+
+	expected := map[string]string{"balance:a": "50", "balance:b": "50"}
+	if got := store.GetAll(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("GetAll() after Txn() = %v, expected %v", got, expected)
+	}
+	// Cada escrita ganha uma revisão, na ordem do ramo
+	if a, b := store.Revision("balance:a"), store.Revision("balance:b"); b != a+1 || res.Revision != b+1 {
+		t.Errorf("Txn() revisions = a:%d b:%d result:%d, expected consecutive", a, b, res.Revision)
+	}
+
+	// O banco recebeu as mesmas escritas
+	if v, _ := backend.Get(store.bucket, []byte("balance:a")); string(v) != "50" {
+		t.Errorf("Backend balance:a = %q, expected 50", v)
+	}
+	if v, _ := backend.Get(store.bucket, []byte("lock")); v != nil {
+		t.Errorf("Backend lock = %q, expected deleted", v)
+	}
+
+	if got := drainEvents(watcher); !reflect.DeepEqual(got, []string{"Key balance:b updated to 50"}) {
+		t.Errorf("Expected one watch event for balance:b, got %v", got)
+	}
+
+	// As escritas entram juntas no WAL
+	wal, _ := os.ReadFile("walog.ndjson")
+	if !strings.Contains(string(wal), `"Key":"balance:a","Value":"50"`) || !strings.Contains(string(wal), `"Operation":"Delete","Key":"lock"`) {
+		t.Errorf("Expected the txn writes in the WAL, got:\n%s", wal)
+	}
+}
+
+func TestKVStore_Txn_Else(t *testing.T) {
+	defer os.Remove("walog.ndjson")
+
+	store := NewKVStore(WithBackend(NewMemoryBackend()))
+	store.Put("config", "v1")
+	rev := store.Revision("config")
+
+	res, err := store.Txn(context.Background(),
+		[]Compare{{Key: "config", Target: CompareValue, Value: "v0"}},
+		[]TxnOp{{Type: TxnPut, Key: "config", Value: "v2"}},
+		[]TxnOp{{Type: TxnPut, Key: "config_conflicts", Value: "1"}},
+	)
+	if err != nil {
+		t.Fatalf("Txn() failed: %v", err)
+	}
+	if res.Succeeded {
+		t.Fatal("Txn() took the then branch, expected else")
+	}
+
+	if got := store.Get("config"); got != "v1" {
+		t.Errorf("Then branch was applied: config = %q", got)
+	}
+	if got := store.Revision("config"); got != rev {
+		t.Errorf("config revision changed to %d, expected %d", got, rev)
+	}
+	if got := store.Get("config_conflicts"); got != "1" {
+		t.Errorf("Else branch was not applied: config_conflicts = %q", got)
+	}
+
+	// Sem ramo a aplicar, nada muda
+	res, err = store.Txn(context.Background(), []Compare{{Key: "missing", Target: CompareValue}}, nil, nil)
+	if err != nil || res.Succeeded {
+		t.Errorf("Txn() with a failing compare and no else = %+v, %v", res, err)
+	}
+}
+
+// failingBackend recusa qualquer transação
+type failingBackend struct {
+	Backend
+}
+
+var errBackendDown = errors.New("backend down")
+
+func (failingBackend) Update(func(tx Backend) error) error {
+	return errBackendDown
+}
+
+func TestKVStore_Txn_PartialFailure(t *testing.T) {
+	defer os.Remove("walog.ndjson")
+
+	t.Run("invalid op", func(t *testing.T) {
+		store := NewKVStore(WithBackend(NewMemoryBackend()), WithLimits(Limits{MaxKeySize: 8, MaxValueSize: 8}))
+		store.Put("a", "1")
+		rev := store.Revision("a")
+
+		// O else tem um valor grande demais: nenhum ramo é aplicado
+		_, err := store.Txn(context.Background(),
+			[]Compare{{Key: "a", Target: CompareValue, Value: "1"}},
+			[]TxnOp{{Type: TxnPut, Key: "a", Value: "2"}, {Type: TxnPut, Key: "b", Value: "2"}},
+			[]TxnOp{{Type: TxnPut, Key: "c", Value: strings.Repeat("x", 9)}},
+		)
+		if !errors.Is(err, ErrValueTooLarge) {
+			t.Fatalf("Txn() returned %v, expected %v", err, ErrValueTooLarge)
+		}
+
+		if got := store.GetAll(); !reflect.DeepEqual(got, map[string]string{"a": "1"}) {
+			t.Errorf("GetAll() after failed Txn() = %v, expected only a=1", got)
+		}
+		if got := store.Revision("a"); got != rev {
+			t.Errorf("Revision(a) = %d, expected %d", got, rev)
+		}
+	})
+
+	t.Run("backend failure", func(t *testing.T) {
+		dir := t.TempDir()
+		useTempWAL(t, dir)
+
+		inner := NewMemoryBackend()
+		store := NewKVStore(WithBackend(inner))
+		store.Put("a", "1")
+		before := store.revision
+
+		store.backend = failingBackend{inner}
+
+		_, err := store.Txn(context.Background(), nil,
+			[]TxnOp{{Type: TxnPut, Key: "a", Value: "2"}, {Type: TxnDelete, Key: "a"}, {Type: TxnPut, Key: "b", Value: "3"}},
+			nil,
+		)
+		if !errors.Is(err, errBackendDown) {
+			t.Fatalf("Txn() returned %v, expected %v", err, errBackendDown)
+		}
+
+		if got := store.GetAll(); !reflect.DeepEqual(got, map[string]string{"a": "1"}) {
+			t.Errorf("Memory changed after a failed Txn(): %v", got)
+		}
+		if store.revision != before {
+			t.Errorf("Revision counter moved to %d, expected %d", store.revision, before)
+		}
+
+		// as operações foram para o WAL antes do banco, seguidas das entradas
+		// que as desfazem
+		entries, err := ReadWAL(filepath.Join(dir, WALFileName))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var keys []string
+		for _, e := range entries[1:] {
+			keys = append(keys, e.Key)
+		}
+		if len(keys) < 3 || !reflect.DeepEqual(keys[:3], []string{"a", "a", "b"}) {
+			t.Errorf("WAL keys after a failed Txn() = %v, expected the ops a, a, b first", keys)
+		}
+
+		// e o replay não aplica a transação
+		restored := NewKVStore(WithBackend(inner))
+		if _, err := restored.ReplayWAL(); err != nil {
+			t.Fatalf("ReplayWAL() failed: %v", err)
+		}
+		if err := restored.LoadKeys(); err != nil {
+			t.Fatal(err)
+		}
+		if got := restored.GetAll(); !reflect.DeepEqual(got, map[string]string{"a": "1"}) {
+			t.Errorf("GetAll() after replay = %v, expected only a=1", got)
+		}
+	})
+}
+
+func TestKVStore_DeleteMany(t *testing.T) {
+	defer os.Remove("walog.ndjson")
+
+	backend := NewMemoryBackend()
+	store := NewKVStore(WithBackend(backend))
+	store.Put("a", "1")
+	store.Put("b", "2")
+	store.Put("keep", "3")
+
+	all := store.WatchAll()
+	defer store.Unwatch(all)
+	watcher := store.Watch("b")
+	defer store.Unwatch(watcher)
+
+	// "missing" não existe e "a" vem repetida: só a e b contam
+	n, err := store.DeleteMany([]string{"a", "missing", "b", "a"})
+	if err != nil {
+		t.Fatalf("DeleteMany() failed: %v", err)
+	}
+	if n != 2 {
+		t.Errorf("DeleteMany() = %d, expected 2", n)
+	}
+
+	expected := map[string]string{"keep": "3"}
+	if got := store.GetAll(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("GetAll() after DeleteMany() = %v, expected %v", got, expected)
+	}
+	for _, key := range []string{"a", "b"} {
+		if v, _ := backend.Get(store.bucket, []byte(key)); v != nil {
+			t.Errorf("Backend %s = %q, expected deleted", key, v)
+		}
+	}
+
+	// Um evento de delete por chave apagada, nenhum para a inexistente
+	if got := drainEvents(all); !reflect.DeepEqual(got, []string{"Key a deleted", "Key b deleted"}) {
+		t.Errorf("WatchAll events = %v", got)
+	}
+	if got := drainEvents(watcher); !reflect.DeepEqual(got, []string{"Key b deleted"}) {
+		t.Errorf("Watch(b) events = %v", got)
+	}
+
+	if n, err := store.DeleteMany([]string{"missing"}); err != nil || n != 0 {
+		t.Errorf("DeleteMany() of missing keys = %d, %v, expected 0", n, err)
+	}
+}
@@ -0,0 +1,154 @@
+package store
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// O índice por valor é opcional (WithValueIndex) e só cobre o namespace
+// padrão. Cada chave tem uma entrada no bucket <bucket>.values cuja chave é o
+// começo do valor, um zero e a chave da store, e cujo valor é a chave da
+// store. Como as chaves não podem ter caracteres de controle, o último zero
+// sempre separa as duas partes. O índice é atualizado na mesma transação da
+// escrita.
+
+func (kv *KVStore) valueIndexBucket() []byte {
+	return []byte(string(kv.bucket) + ".values")
+}
+
+// valueIndexKey monta a entrada do índice para a chave com o valor dado
+func (kv *KVStore) valueIndexKey(key, value string) []byte {
+	if len(value) > kv.valueIndexPrefix {
+		value = value[:kv.valueIndexPrefix]
+	}
+	return []byte(value + "\x00" + key)
+}
+
+// indexValue atualiza o índice dentro da transação da escrita: remove a
+// entrada do valor que a chave tem no banco e, se não for um delete, grava a
+// do novo valor. Deve rodar antes de o valor ser gravado ou apagado.
+func (kv *KVStore) indexValue(tx Backend, key, value string, deleted bool) error {
+	if kv.valueIndexPrefix <= 0 {
+		return nil
+	}
+
+	old, err := tx.Get(kv.bucket, []byte(key))
+	if err != nil {
+		return err
+	}
+	if old != nil {
+		if err := tx.Delete(kv.valueIndexBucket(), kv.valueIndexKey(key, kv.decodeValue(string(old)))); err != nil {
+			return err
+		}
+	}
+	if deleted {
+		return nil
+	}
+	return tx.Put(kv.valueIndexBucket(), kv.valueIndexKey(key, value), []byte(key))
+}
+
+// FindByValuePrefix retorna, em ordem, as chaves do namespace padrão cujo
+// valor começa com prefix. Com o índice ligado só as entradas do prefixo são
+// lidas (e, para prefixos maiores que o indexado, o valor de cada candidata);
+// sem ele a store inteira é percorrida com o Scan.
+func (kv *KVStore) FindByValuePrefix(prefix string) []string {
+	var keys []string
+	var err error
+	if kv.valueIndexPrefix > 0 {
+		keys, err = kv.findIndexed(prefix)
+	} else {
+		err = kv.Scan(func(key, value string) error {
+			if strings.HasPrefix(value, prefix) {
+				keys = append(keys, key)
+			}
+			return nil
+		})
+	}
+	if err != nil {
+		kv.logger.Warn("find by value prefix failed", "prefix", prefix, "error", err)
+		return nil
+	}
+
+	slices.Sort(keys)
+	return keys
+}
+
+func (kv *KVStore) findIndexed(prefix string) ([]string, error) {
+	indexed := prefix
+	if len(indexed) > kv.valueIndexPrefix {
+		indexed = indexed[:kv.valueIndexPrefix]
+	}
+
+	var candidates []string
+	start := []byte(indexed)
+	err := forEachFrom(kv.storage(), kv.valueIndexBucket(), start, func(k, v []byte) error {
+		if !bytes.HasPrefix(k, start) {
+			return errStopIteration
+		}
+		//o começo do valor é o que vem antes do zero que precede a chave
+		if bytes.HasPrefix(k[:len(k)-len(v)-1], start) {
+			candidates = append(candidates, string(v))
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errStopIteration) {
+		return nil, err
+	}
+
+	if len(prefix) <= kv.valueIndexPrefix {
+		return candidates, nil
+	}
+
+	//o índice só guarda valueIndexPrefix bytes: confere o valor inteiro
+	var keys []string
+	for _, key := range candidates {
+		v, err := kv.storage().Get(kv.bucket, []byte(key))
+		if err != nil {
+			return nil, err
+		}
+		if v != nil && strings.HasPrefix(kv.decodeValue(string(v)), prefix) {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+// RebuildValueIndex recria o índice a partir dos valores no banco, para uma
+// store que já tinha dados quando o índice foi ligado. Sem WithValueIndex não
+// faz nada.
+func (kv *KVStore) RebuildValueIndex() error {
+	if kv.valueIndexPrefix <= 0 {
+		return nil
+	}
+
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+
+	err := kv.storage().Update(func(tx Backend) error {
+		if err := tx.ClearBucket(kv.valueIndexBucket()); err != nil {
+			return err
+		}
+
+		var entries [][2][]byte
+		err := tx.ForEach(kv.bucket, func(k, v []byte) error {
+			entries = append(entries, [2][]byte{kv.valueIndexKey(string(k), kv.decodeValue(string(v))), bytes.Clone(k)})
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, e := range entries {
+			if err := tx.Put(kv.valueIndexBucket(), e[0], e[1]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("rebuild value index: %w", err)
+	}
+	return nil
+}
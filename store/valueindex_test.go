@@ -0,0 +1,81 @@
+package store
+
+import (
+	"os"
+	"slices"
+	"testing"
+)
+
+func TestKVStore_FindByValuePrefix(t *testing.T) {
+	db := setupTestDB(t)
+	defer cleanupTestDB(t, db)
+	defer os.Remove("walog.ndjson")
+
+	kv := NewKVStore(WithDB(db), WithValueIndex(4))
+	kv.Put("user:1", "admin:daniel")
+	kv.Put("user:2", "admin:maria")
+	kv.Put("user:3", "guest:joao")
+
+	tests := []struct {
+		prefix string
+		want   []string
+	}{
+		{"admin", []string{"user:1", "user:2"}},
+		{"adm", []string{"user:1", "user:2"}},
+		// maior que o prefixo indexado: o valor inteiro é conferido
+		{"admin:m", []string{"user:2"}},
+		{"guest", []string{"user:3"}},
+		{"root", nil},
+		{"", []string{"user:1", "user:2", "user:3"}},
+	}
+	for _, tt := range tests {
+		if got := kv.FindByValuePrefix(tt.prefix); !slices.Equal(got, tt.want) {
+			t.Errorf("FindByValuePrefix(%q) = %v, expected %v", tt.prefix, got, tt.want)
+		}
+	}
+
+	// sobrescrever tira a chave do prefixo antigo
+	kv.Put("user:1", "guest:daniel")
+	if got := kv.FindByValuePrefix("admin"); !slices.Equal(got, []string{"user:2"}) {
+		t.Errorf("after overwrite, admin = %v, expected [user:2]", got)
+	}
+	if got := kv.FindByValuePrefix("guest"); !slices.Equal(got, []string{"user:1", "user:3"}) {
+		t.Errorf("after overwrite, guest = %v, expected [user:1 user:3]", got)
+	}
+
+	kv.Delete("user:3")
+	if got := kv.FindByValuePrefix("guest"); !slices.Equal(got, []string{"user:1"}) {
+		t.Errorf("after delete, guest = %v, expected [user:1]", got)
+	}
+
+	kv.Clear()
+	if got := kv.FindByValuePrefix(""); len(got) != 0 {
+		t.Errorf("after Clear, expected an empty index, got %v", got)
+	}
+}
+
+func TestKVStore_RebuildValueIndex(t *testing.T) {
+	db := setupTestDB(t)
+	defer cleanupTestDB(t, db)
+	defer os.Remove("walog.ndjson")
+
+	// dados gravados antes de o índice ser ligado
+	NewKVStore(WithDB(db)).Put("a", "red apple")
+
+	kv := NewKVStore(WithDB(db), WithValueIndex(8))
+	if got := kv.FindByValuePrefix("red"); len(got) != 0 {
+		t.Fatalf("expected no indexed keys before the rebuild, got %v", got)
+	}
+
+	if err := kv.RebuildValueIndex(); err != nil {
+		t.Fatalf("RebuildValueIndex() failed: %v", err)
+	}
+	if got := kv.FindByValuePrefix("red"); !slices.Equal(got, []string{"a"}) {
+		t.Errorf("FindByValuePrefix(red) after rebuild = %v, expected [a]", got)
+	}
+
+	// sem o índice o resultado vem do Scan
+	if got := NewKVStore(WithDB(db)).FindByValuePrefix("red"); !slices.Equal(got, []string{"a"}) {
+		t.Errorf("FindByValuePrefix(red) without the index = %v, expected [a]", got)
+	}
+}
@@ -0,0 +1,213 @@
+package store
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+)
+
+// DiscrepancyKind é o tipo de divergência encontrada pelo Verify
+type DiscrepancyKind int
+
+const (
+	// ValueMismatch é uma chave com valores diferentes na memória e no banco
+	ValueMismatch DiscrepancyKind = iota
+	// MissingInDB é uma chave em memória que não está no banco
+	MissingInDB
+	// MissingInMemory é uma chave do banco que não está em memória
+	MissingInMemory
+)
+
+func (k DiscrepancyKind) String() string {
+	switch k {
+	case ValueMismatch:
+		return "value_mismatch"
+	case MissingInDB:
+		return "missing_in_db"
+	case MissingInMemory:
+		return "missing_in_memory"
+	default:
+		return "unknown"
+	}
+}
+
+// Discrepancy é uma chave em que a memória e o banco não concordam. O valor do
+// lado onde a chave falta fica vazio.
+type Discrepancy struct {
+	Kind        DiscrepancyKind
+	Key         string
+	MemoryValue string
+	DBValue     string
+}
+
+// Verify compara as chaves do namespace padrão em memória com as do banco e
+// retorna as divergências ordenadas por chave, ex.: uma escrita que ficou só
+// no WAL ou só no banco depois de um crash. É um diagnóstico: nada é
+// corrigido. As escritas esperam enquanto o banco é percorrido, para uma
+// escrita em andamento não aparecer como divergência. Com WithMaxEntries as
+// chaves despejadas da memória não contam como MissingInMemory; sem backend
+// não há o que comparar.
+func (kv *KVStore) Verify() ([]Discrepancy, error) {
+	backend := kv.storage()
+	if _, ok := backend.(noBackend); ok {
+		return nil, nil
+	}
+
+	kv.lockAll()
+	defer kv.unlockAll()
+
+	return kv.verifyLocked(backend)
+}
+
+// verifyLocked é o Verify. Deve ser chamado com kv.lockAll.
+func (kv *KVStore) verifyLocked(backend Backend) ([]Discrepancy, error) {
+	var found []Discrepancy
+	seen := make(map[string]struct{}, len(kv.store))
+	err := backend.ForEach(kv.bucket, func(k, v []byte) error {
+		key, dbValue := string(k), kv.decodeValue(string(v))
+
+		memValue, ok := kv.store[key]
+		switch {
+		case !ok && kv.lru == nil:
+			found = append(found, Discrepancy{Kind: MissingInMemory, Key: key, DBValue: dbValue})
+		case ok && memValue != dbValue:
+			found = append(found, Discrepancy{Kind: ValueMismatch, Key: key, MemoryValue: memValue, DBValue: dbValue})
+		}
+		if ok {
+			seen[key] = struct{}{}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for key, value := range kv.store {
+		if _, ok := seen[key]; !ok {
+			found = append(found, Discrepancy{Kind: MissingInDB, Key: key, MemoryValue: value})
+		}
+	}
+
+	slices.SortFunc(found, func(a, b Discrepancy) int {
+		return cmp.Compare(a.Key, b.Key)
+	})
+	return found, nil
+}
+
+// Source é o lado tomado como verdade pelo Repair
+type Source int
+
+const (
+	// SourceDB reescreve a memória a partir do banco
+	SourceDB Source = iota
+	// SourceMemory reescreve o banco a partir da memória
+	SourceMemory
+)
+
+func (s Source) String() string {
+	switch s {
+	case SourceDB:
+		return "db"
+	case SourceMemory:
+		return "memory"
+	default:
+		return "unknown"
+	}
+}
+
+// Repair corrige as divergências que o Verify encontraria, copiando para o
+// outro lado o que está em source, e retorna as divergências corrigidas. Com
+// SourceDB, útil depois de um desligamento sem Close, os watchers recebem um
+// evento para cada chave cujo valor em memória mudou ou sumiu. Todas as
+// escritas esperam enquanto ele roda. A correção é local: não passa pelo WAL,
+// pelo raft nem pelos hooks.
+func (kv *KVStore) Repair(source Source) ([]Discrepancy, error) {
+	backend := kv.storage()
+	if _, ok := backend.(noBackend); ok {
+		return nil, nil
+	}
+
+	kv.lockAll()
+	defer kv.unlockAll()
+
+	found, err := kv.verifyLocked(backend)
+	if err != nil || len(found) == 0 {
+		return found, err
+	}
+
+	if source == SourceMemory {
+		err = backend.Update(func(tx Backend) error {
+			for _, d := range found {
+				if err := kv.repairBackend(tx, d); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("%w: repair: %w", ErrWriteFailed, err)
+		}
+		kv.logger.Warn("repaired db from memory", "keys", len(found))
+		return found, nil
+	}
+
+	for _, d := range found {
+		kv.repairMemoryLocked(backend, d)
+	}
+	kv.invalidateSnapshot()
+	kv.logger.Warn("repaired memory from db", "keys", len(found))
+	return found, nil
+}
+
+// repairBackend grava no banco o lado da memória da divergência d
+func (kv *KVStore) repairBackend(tx Backend, d Discrepancy) error {
+	key := []byte(d.Key)
+	if d.Kind == MissingInMemory {
+		if err := kv.indexValue(tx, d.Key, "", true); err != nil {
+			return err
+		}
+		if err := tx.Delete(kv.bucket, key); err != nil {
+			return err
+		}
+		return kv.persistRevision(tx, d.Key, 0, keyTimes{}, true)
+	}
+
+	if err := kv.indexValue(tx, d.Key, d.MemoryValue, false); err != nil {
+		return err
+	}
+	if err := tx.Put(kv.bucket, key, kv.encodeEntry(d.MemoryValue, kv.revisions[d.Key], kv.times[d.Key])); err != nil {
+		return err
+	}
+	if rev := kv.revisions[d.Key]; rev != 0 {
+		return kv.persistRevision(tx, d.Key, rev, kv.times[d.Key], false)
+	}
+	return nil
+}
+
+// repairMemoryLocked copia para a memória o lado do banco da divergência d,
+// com a revisão e os tempos gravados no banco, e avisa os watchers. Deve ser
+// chamado com kv.lockAll.
+func (kv *KVStore) repairMemoryLocked(backend Backend, d Discrepancy) {
+	if d.Kind == MissingInDB {
+		delete(kv.store, d.Key)
+		delete(kv.revisions, d.Key)
+		delete(kv.times, d.Key)
+		kv.untrackLocked(d.Key)
+
+		e := deleteEvent("", d.Key, 0)
+		kv.notifyDeleteLocked(e)
+		kv.notifyAllLocked(e)
+		return
+	}
+
+	kv.store[d.Key] = d.DBValue
+	raw, _ := backend.Get(kv.bucket, []byte(d.Key))
+	rev, times := kv.entryMeta(backend, d.Key, raw)
+	if rev != 0 {
+		kv.revisions[d.Key] = rev
+	}
+	if !times.updated.IsZero() {
+		kv.times[d.Key] = times
+	}
+	kv.notifyLocked(updateEvent("", d.Key, d.DBValue, kv.revisions[d.Key]))
+}
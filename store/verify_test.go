@@ -0,0 +1,162 @@
+package store
+
+import (
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/carvalhodanielg/kvstore/internal/constants"
+)
+
+func TestKVStore_Verify(t *testing.T) {
+	dir := t.TempDir()
+	useTempWAL(t, dir)
+
+	d, err := OpenDB(filepath.Join(dir, constants.DBFileName), constants.BucketStore, DefaultDBConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+
+	//valores comprimidos no banco são comparados já decodificados
+	kv := NewKVStore(WithBackend(NewBoltBackend(d)), WithCompressionThreshold(8))
+	big := strings.Repeat("x", 64)
+	for _, key := range []string{"ok", "changed", "mem-only"} {
+		kv.Put(key, big)
+	}
+
+	if found, err := kv.Verify(); err != nil || len(found) != 0 {
+		t.Fatalf("Verify() on a consistent store = %v, %v, expected nothing", found, err)
+	}
+
+	// dessincroniza: uma chave só no banco, uma só em memória e uma com valores diferentes
+	backend := kv.storage()
+	backend.Put(kv.bucket, []byte("db-only"), kv.encodeValue("from-db"))
+	backend.Put(kv.bucket, []byte("changed"), kv.encodeValue("new"))
+	backend.Delete(kv.bucket, []byte("mem-only"))
+
+	found, err := kv.Verify()
+	if err != nil {
+		t.Fatalf("Verify() failed: %v", err)
+	}
+	expected := []Discrepancy{
+		{Kind: ValueMismatch, Key: "changed", MemoryValue: big, DBValue: "new"},
+		{Kind: MissingInMemory, Key: "db-only", DBValue: "from-db"},
+		{Kind: MissingInDB, Key: "mem-only", MemoryValue: big},
+	}
+	if !slices.Equal(found, expected) {
+		t.Errorf("Verify() = %+v, expected %+v", found, expected)
+	}
+
+	// o Verify não corrige nada
+	if again, _ := kv.Verify(); !slices.Equal(again, expected) {
+		t.Errorf("second Verify() = %+v, expected the same discrepancies", again)
+	}
+}
+
+func TestKVStore_Verify_EvictedKeys(t *testing.T) {
+	useTempWAL(t, t.TempDir())
+	kv := NewKVStore(WithBackend(NewMemoryBackend()), WithMaxEntries(2, EvictMemory))
+
+	for _, key := range []string{"a", "b", "c", "d"} {
+		kv.Put(key, "v")
+	}
+
+	// as chaves despejadas pelo LRU continuam só no banco, o que é esperado
+	if found, err := kv.Verify(); err != nil || len(found) != 0 {
+		t.Errorf("Verify() with evicted keys = %v, %v, expected nothing", found, err)
+	}
+}
+
+func TestKVStore_Repair(t *testing.T) {
+	dir := t.TempDir()
+	useTempWAL(t, dir)
+
+	d, err := OpenDB(filepath.Join(dir, constants.DBFileName), constants.BucketStore, DefaultDBConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+
+	kv := NewKVStore(WithBackend(NewBoltBackend(d)))
+	for _, key := range []string{"ok", "changed", "mem-only"} {
+		kv.Put(key, "v-"+key)
+	}
+	rev := kv.Revision("changed")
+
+	backend := kv.storage()
+	backend.Put(kv.bucket, []byte("db-only"), kv.encodeValue("from-db"))
+	backend.Put(kv.bucket, []byte("changed"), kv.encodeValue("new"))
+	backend.Delete(kv.bucket, []byte("mem-only"))
+
+	all := kv.WatchAll()
+	defer kv.Unwatch(all)
+
+	repaired, err := kv.Repair(SourceDB)
+	if err != nil {
+		t.Fatalf("Repair() failed: %v", err)
+	}
+	if len(repaired) != 3 {
+		t.Errorf("Repair() returned %+v, expected the 3 discrepancies", repaired)
+	}
+
+	// a memória passa a ser igual ao banco
+	expected := map[string]string{"ok": "v-ok", "changed": "new", "db-only": "from-db"}
+	if got := kv.GetAll(); !maps.Equal(got, expected) {
+		t.Errorf("GetAll() after Repair() = %v, expected %v", got, expected)
+	}
+	if found, err := kv.Verify(); err != nil || len(found) != 0 {
+		t.Errorf("Verify() after Repair() = %v, %v, expected nothing", found, err)
+	}
+	if got := kv.Revision("changed"); got != rev {
+		t.Errorf("Revision(changed) = %d, expected the revision kept in the db %d", got, rev)
+	}
+
+	// os watchers veem cada valor que mudou na memória, na ordem das chaves
+	want := []WatchEvent{
+		{Type: EventPut, Key: "changed", Value: "new", Revision: rev, Message: "Key changed updated to new"},
+		{Type: EventPut, Key: "db-only", Value: "from-db", Message: "Key db-only updated to from-db"},
+		{Type: EventDelete, Key: "mem-only", Message: "Key mem-only deleted"},
+	}
+	for _, e := range want {
+		if got := <-all.Events; got != e {
+			t.Errorf("watcher got %+v, expected %+v", got, e)
+		}
+	}
+}
+
+func TestKVStore_Repair_FromMemory(t *testing.T) {
+	dir := t.TempDir()
+	useTempWAL(t, dir)
+
+	d, err := OpenDB(filepath.Join(dir, constants.DBFileName), constants.BucketStore, DefaultDBConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+
+	kv := NewKVStore(WithBackend(NewBoltBackend(d)))
+	kv.Put("changed", "mem")
+	kv.Put("mem-only", "mem")
+
+	backend := kv.storage()
+	backend.Put(kv.bucket, []byte("db-only"), kv.encodeValue("from-db"))
+	backend.Put(kv.bucket, []byte("changed"), kv.encodeValue("new"))
+	backend.Delete(kv.bucket, []byte("mem-only"))
+
+	if _, err := kv.Repair(SourceMemory); err != nil {
+		t.Fatalf("Repair() failed: %v", err)
+	}
+
+	if found, err := kv.Verify(); err != nil || len(found) != 0 {
+		t.Errorf("Verify() after Repair() = %v, %v, expected nothing", found, err)
+	}
+	for key, want := range map[string]string{"changed": "mem", "mem-only": "mem", "db-only": ""} {
+		raw, _ := backend.Get(kv.bucket, []byte(key))
+		if got := kv.decodeValue(string(raw)); got != want {
+			t.Errorf("db %s = %q, expected %q", key, got, want)
+		}
+	}
+}
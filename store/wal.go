@@ -1,18 +1,27 @@
 package store
 
 import (
+	"bufio"
+	"bytes"
 	"encoding/json"
-	"fmt"
+	"io"
 	"log"
+	"log/slog"
 	"os"
+	"sync"
 	"time"
 )
 
 type Operation uint8
 
 const (
-	Write  Operation = iota
-	Delete Operation = iota
+	Write         Operation = iota
+	Delete        Operation = iota
+	DropNamespace Operation = iota
+	Clear         Operation = iota
+	// Checkpoint abre um WAL truncado pelo TruncateWAL e só guarda a
+	// sequência até onde as entradas foram removidas
+	Checkpoint Operation = iota
 )
 
 func (o Operation) String() string {
@@ -21,6 +30,12 @@ func (o Operation) String() string {
 		return "Write"
 	case Delete:
 		return "Delete"
+	case DropNamespace:
+		return "DropNamespace"
+	case Clear:
+		return "Clear"
+	case Checkpoint:
+		return "Checkpoint"
 	default:
 		return "Unknown"
 	}
@@ -41,29 +56,118 @@ func (o *Operation) UnmarshalJSON(data []byte) error {
 		*o = Write
 	case "Delete":
 		*o = Delete
+	case "DropNamespace":
+		*o = DropNamespace
+	case "Clear":
+		*o = Clear
+	case "Checkpoint":
+		*o = Checkpoint
 	default:
 		*o = Operation(99) // Unknown
 	}
 	return nil
 }
 
+// WALFileName é o nome do arquivo de log; sem SetWALPath ele fica no diretório
+// atual. O nome é o mesmo com SetWALFormat(WALBinary).
+const WALFileName = "walog.ndjson"
+
+// walTailChunk é quanto do fim do log é lido por vez para achar a última sequência
+const walTailChunk = 64 << 10
+
+var (
+	walMu     sync.Mutex
+	walClosed bool
+	walPath   = WALFileName
+
+	// walSeq é a última sequência gravada em walSeqPath. Ela é lida do fim do
+	// arquivo na primeira escrita, então continua crescendo depois de um restart.
+	walSeq     uint64
+	walSeqPath string
+
+	// walFormat é o formato dos arquivos novos; walFileCodec é o do arquivo
+	// em walCodecPath, reconhecido na primeira escrita nele
+	walFormat    = WALNDJSON
+	walFileCodec walCodec
+	walCodecPath string
+)
+
+// WalLog é uma entrada do log. Timestamps são Unix em nanossegundos; para
+// ordenar as entradas use o SequenceNumber, que nunca se repete.
 type WalLog struct {
-	Operation Operation `json:"Operation"`
-	Key       string    `json:"Key"`
-	Value     string    `json:"Value"`
-	Timestamp int64     `json:"Timestamp"` //Unix timestamp
+	// SequenceNumber cresce a cada entrada, na ordem em que foram gravadas
+	SequenceNumber uint64    `json:"SequenceNumber"`
+	Operation      Operation `json:"Operation"`
+	Namespace      string    `json:"Namespace,omitempty"`
+	Key            string    `json:"Key"`
+	Value          string    `json:"Value"`
+	Timestamp      int64     `json:"Timestamp"`
+	// CreatedAt é quando a chave escrita foi criada, só no namespace padrão
+	CreatedAt int64  `json:"CreatedAt,omitempty"`
+	Revision  uint64 `json:"Revision,omitempty"`
+	// Encrypted indica que Value está cifrado e em base64 (WithEncryptionKey)
+	Encrypted bool `json:"Encrypted,omitempty"`
+	// Binary indica que Value não era UTF-8 válido e foi gravado em base64
+	Binary bool `json:"Binary,omitempty"`
+}
+
+// SetWALPath muda o arquivo onde o log é gravado. Deve ser chamado antes das
+// primeiras escritas; o diretório precisa existir.
+func SetWALPath(path string) {
+	walMu.Lock()
+	defer walMu.Unlock()
+
+	walPath = path
+}
+
+// SetWALFormat escolhe o formato dos arquivos de log criados daqui em diante.
+// Um arquivo que já existe continua sendo gravado no formato dele.
+func SetWALFormat(f WALFormat) {
+	walMu.Lock()
+	defer walMu.Unlock()
+
+	walFormat = f
+}
+
+// OpenWAL libera a escrita no log (ele começa aberto)
+func OpenWAL() {
+	walMu.Lock()
+	defer walMu.Unlock()
+
+	walClosed = false
+}
+
+// CloseWAL espera a escrita em andamento terminar e bloqueia novas escritas,
+// garantindo que o processo não saia com uma linha pela metade no arquivo.
+func CloseWAL() {
+	walMu.Lock()
+	defer walMu.Unlock()
+
+	walClosed = true
 }
 
 // Função deve ser privada
-func appendLogToFile(wallog WalLog) {
-	data, err := json.Marshal(wallog)
-	fmt.Println(string(data))
+func appendLogToFile(wallog WalLog) uint64 {
+	return appendLogsToFile([]WalLog{wallog})
+}
 
-	if err != nil {
-		log.Fatalf("Erro ao converter para json %v", err)
+// appendLogsToFile grava as entradas com um único write, então um Txn nunca
+// aparece pela metade para quem lê o log. Retorna a sequência da última
+// entrada, 0 se o WAL estiver fechado.
+func appendLogsToFile(entries []WalLog) uint64 {
+	walMu.Lock()
+	defer walMu.Unlock()
+
+	if walClosed {
+		for _, wallog := range entries {
+			slog.Warn("WAL is closed, dropping entry", "operation", wallog.Operation, "namespace", wallog.Namespace, "key", wallog.Key)
+		}
+		return 0
 	}
 
-	file, error := os.OpenFile("walog.ndjson", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	loadWALSequenceLocked()
+
+	file, error := os.OpenFile(walPath, os.O_APPEND|os.O_CREATE|os.O_RDWR, 0644)
 
 	if error != nil {
 		panic(error)
@@ -71,16 +175,177 @@ func appendLogToFile(wallog WalLog) {
 
 	defer file.Close()
 
-	if _, err := file.Write(append(data, '\n')); err != nil {
+	codec, fresh, err := walCodecLocked(file)
+	if err != nil {
 		panic(err)
 	}
 
+	var data []byte
+	if fresh {
+		data = codec.header()
+	}
+	for _, wallog := range entries {
+		walSeq++
+		wallog.SequenceNumber = walSeq
+
+		if data, err = codec.appendEntry(data, wallog); err != nil {
+			log.Fatalf("Erro ao codificar a entrada do WAL %v", err)
+		}
+
+		slog.Debug("WAL append", "sequence", wallog.SequenceNumber, "operation", wallog.Operation, "namespace", wallog.Namespace, "key", wallog.Key)
+	}
+
+	if _, err := file.Write(data); err != nil {
+		panic(err)
+	}
+
+	return walSeq
+}
+
+// walCodecLocked devolve o formato do arquivo aberto em file. Um arquivo vazio
+// usa o walFormat e fresh indica que ele precisa do header. Deve ser chamado
+// com walMu travado.
+func walCodecLocked(file *os.File) (codec walCodec, fresh bool, err error) {
+	info, err := file.Stat()
+	if err != nil {
+		return nil, false, err
+	}
+	if info.Size() == 0 {
+		walFileCodec, walCodecPath = walCodecFor(walFormat), walPath
+		return walFileCodec, true, nil
+	}
+	if walCodecPath == walPath && walFileCodec != nil {
+		return walFileCodec, false, nil
+	}
+
+	head := make([]byte, len(walBinaryMagic))
+	n, err := file.ReadAt(head, 0)
+	if err != nil && err != io.EOF {
+		return nil, false, err
+	}
+	codec, _, err = detectWALCodec(bufio.NewReader(bytes.NewReader(head[:n])))
+	if err != nil {
+		return nil, false, err
+	}
+	walFileCodec, walCodecPath = codec, walPath
+	return codec, false, nil
+}
+
+// loadWALSequenceLocked lê a última sequência do arquivo atual na primeira
+// vez que ele é usado. Deve ser chamado com walMu travado.
+func loadWALSequenceLocked() {
+	if walSeqPath != walPath {
+		walSeq = lastWALSequence(walPath)
+		walSeqPath = walPath
+	}
+}
+
+// lastWALSequence devolve o SequenceNumber da última entrada legível do
+// arquivo, ou 0 se não houver nenhuma (arquivo inexistente ou gravado antes
+// das sequências). Uma entrada cortada por um crash é ignorada. O ndjson é
+// lido de trás para frente; o binário, que não tem separador entre as
+// entradas, é lido inteiro.
+func lastWALSequence(path string) uint64 {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0
+	}
+	defer f.Close()
+
+	codec, _, err := detectWALCodec(bufio.NewReader(io.NewSectionReader(f, 0, int64(len(walBinaryMagic)))))
+	if err != nil {
+		return 0
+	}
+	if codec.format() == WALBinary {
+		var last uint64
+		eachWALEntry(f, func(_ []byte, e WalLog) error {
+			last = e.SequenceNumber
+			return nil
+		})
+		return last
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		return 0
+	}
+
+	var tail []byte
+	for off := info.Size(); off > 0; {
+		n := min(walTailChunk, off)
+		off -= n
+
+		buf := make([]byte, n)
+		if _, err := f.ReadAt(buf, off); err != nil {
+			return 0
+		}
+		tail = append(buf, tail...)
+
+		lines := bytes.Split(tail, []byte{'\n'})
+		if off > 0 {
+			//a primeira linha pode ter começado antes do trecho lido
+			lines = lines[1:]
+		}
+		for i := len(lines) - 1; i >= 0; i-- {
+			var entry WalLog
+			if len(bytes.TrimSpace(lines[i])) > 0 && json.Unmarshal(lines[i], &entry) == nil {
+				return entry.SequenceNumber
+			}
+		}
+	}
+	return 0
+}
+
+// WALSize retorna o tamanho atual do arquivo de log em bytes
+func WALSize() int64 {
+	walMu.Lock()
+	path := walPath
+	walMu.Unlock()
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0
+	}
+	return info.Size()
 }
 
 func LogWrite(key, value string) {
-	appendLogToFile(WalLog{Operation: Write, Key: key, Value: value, Timestamp: time.Now().Unix()})
+	LogWriteNamespace("", key, value, 0)
+}
+
+func LogDelete(key string) uint64 {
+	return LogDeleteNamespace("", key, 0)
+}
+
+// LogWriteNamespace registra a escrita com a revisão que ela gerou
+// (0 para namespaces sem revisão)
+func LogWriteNamespace(ns, key, value string, rev uint64) {
+	appendLogToFile(WalLog{Operation: Write, Namespace: ns, Key: key, Value: value, Timestamp: time.Now().UnixNano(), Revision: rev})
+}
+
+// logWrite registra uma escrita da store, com o valor cifrado se ela usar
+// WithEncryptionKey. No namespace padrão Timestamp é a atualização da chave e
+// CreatedAt a criação. Retorna a sequência da entrada.
+func (kv *KVStore) logWrite(ns, key, value string, rev uint64, t keyTimes) uint64 {
+	e := WalLog{Operation: Write, Namespace: ns, Key: key, Timestamp: time.Now().UnixNano(), Revision: rev}
+	kv.setWALValue(&e, value)
+	if ns == "" {
+		e.Timestamp, e.CreatedAt = t.updated.UnixNano(), t.created.UnixNano()
+	}
+	return appendLogToFile(e)
+}
+
+// LogDeleteNamespace registra o delete e retorna a sequência da entrada, que
+// vira o tombstone da chave
+func LogDeleteNamespace(ns, key string, rev uint64) uint64 {
+	return appendLogToFile(WalLog{Operation: Delete, Namespace: ns, Key: key, Value: "", Timestamp: time.Now().UnixNano(), Revision: rev})
+}
+
+func LogDropNamespace(ns string) {
+	appendLogToFile(WalLog{Operation: DropNamespace, Namespace: ns, Timestamp: time.Now().UnixNano()})
 }
 
-func LogDelete(key string) {
-	appendLogToFile(WalLog{Operation: Delete, Key: key, Value: "", Timestamp: time.Now().Unix()})
+// LogClearNamespace marca no log que todas as chaves do namespace foram apagadas
+func LogClearNamespace(ns string) uint64 {
+	return appendLogToFile(WalLog{Operation: Clear, Namespace: ns, Timestamp: time.Now().UnixNano()})
 }
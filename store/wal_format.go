@@ -0,0 +1,274 @@
+package store
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+)
+
+// WALFormat é a codificação das entradas no arquivo do WAL. O formato vale
+// para arquivos novos: um arquivo que já existe continua no formato em que foi
+// criado, reconhecido pelo cabeçalho, e a leitura aceita os dois.
+type WALFormat int
+
+const (
+	// WALNDJSON grava uma entrada JSON por linha, legível com qualquer editor
+	WALNDJSON WALFormat = iota
+	// WALBinary grava as entradas com tamanho e CRC na frente, sem JSON. É
+	// mais rápido de gravar e ler, e o arquivo começa com walBinaryMagic.
+	WALBinary
+)
+
+func (f WALFormat) String() string {
+	switch f {
+	case WALNDJSON:
+		return "ndjson"
+	case WALBinary:
+		return "binary"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseWALFormat converte o nome usado em flags ("ndjson" ou "binary")
+func ParseWALFormat(s string) (WALFormat, error) {
+	for _, f := range []WALFormat{WALNDJSON, WALBinary} {
+		if f.String() == s {
+			return f, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown wal format %q, expected ndjson or binary", s)
+}
+
+// walBinaryMagic abre um WAL binário; um JSON nunca começa com esses bytes
+var walBinaryMagic = []byte("\x00KVWAL1\n")
+
+// walRecordHeader é o tamanho do payload e o CRC dele, antes de cada entrada binária
+const walRecordHeader = 8
+
+var errBadWALRecord = errors.New("malformed wal record")
+
+// walCodec codifica as entradas de um formato de WAL
+type walCodec interface {
+	format() WALFormat
+	// header é gravado no início de um arquivo novo
+	header() []byte
+	// appendEntry acrescenta a entrada codificada a dst
+	appendEntry(dst []byte, e WalLog) ([]byte, error)
+	// each chama fn para cada entrada legível de r, que já está depois do
+	// header, com os bytes da entrada como foram gravados
+	each(r *bufio.Reader, fn func(raw []byte, e WalLog) error) error
+}
+
+func walCodecFor(f WALFormat) walCodec {
+	if f == WALBinary {
+		return binaryWAL{}
+	}
+	return ndjsonWAL{}
+}
+
+// detectWALCodec reconhece o formato de r pelo cabeçalho e o consome. ok é
+// false quando r está vazio, então o formato ainda não está definido.
+func detectWALCodec(r *bufio.Reader) (codec walCodec, ok bool, err error) {
+	head, err := r.Peek(len(walBinaryMagic))
+	if len(head) == 0 {
+		if err == io.EOF {
+			err = nil
+		}
+		return ndjsonWAL{}, false, err
+	}
+	if bytes.Equal(head, walBinaryMagic) {
+		_, err := r.Discard(len(walBinaryMagic))
+		return binaryWAL{}, true, err
+	}
+	return ndjsonWAL{}, true, nil
+}
+
+// eachWALEntry detecta o formato de r e chama fn para cada entrada dele
+func eachWALEntry(r io.Reader, fn func(raw []byte, e WalLog) error) error {
+	br := bufio.NewReader(r)
+	codec, _, err := detectWALCodec(br)
+	if err != nil {
+		return err
+	}
+	return codec.each(br, fn)
+}
+
+type ndjsonWAL struct{}
+
+func (ndjsonWAL) format() WALFormat { return WALNDJSON }
+
+func (ndjsonWAL) header() []byte { return nil }
+
+func (ndjsonWAL) appendEntry(dst []byte, e WalLog) ([]byte, error) {
+	line, err := json.Marshal(e)
+	if err != nil {
+		return dst, err
+	}
+	return append(append(dst, line...), '\n'), nil
+}
+
+// each ignora as linhas que não são JSON válido (ex.: a última, cortada por um crash)
+func (ndjsonWAL) each(r *bufio.Reader, fn func(raw []byte, e WalLog) error) error {
+	for {
+		line, err := r.ReadBytes('\n')
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			var e WalLog
+			if json.Unmarshal(line, &e) == nil {
+				if err := fn(append(line, '\n'), e); err != nil {
+					return err
+				}
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+type binaryWAL struct{}
+
+func (binaryWAL) format() WALFormat { return WALBinary }
+
+func (binaryWAL) header() []byte { return walBinaryMagic }
+
+// appendEntry grava o tamanho e o CRC do payload em little endian e depois o
+// payload: os inteiros em varint e as strings com o tamanho na frente, então
+// chaves e valores podem ter qualquer byte, inclusive quebras de linha
+func (binaryWAL) appendEntry(dst []byte, e WalLog) ([]byte, error) {
+	start := len(dst)
+	dst = append(dst, make([]byte, walRecordHeader)...)
+
+	var flags byte
+	if e.Encrypted {
+		flags |= 1
+	}
+	if e.Binary {
+		flags |= 2
+	}
+	dst = binary.AppendUvarint(dst, e.SequenceNumber)
+	dst = append(dst, byte(e.Operation), flags)
+	dst = appendWALString(dst, e.Namespace)
+	dst = appendWALString(dst, e.Key)
+	dst = appendWALString(dst, e.Value)
+	dst = binary.AppendVarint(dst, e.Timestamp)
+	dst = binary.AppendVarint(dst, e.CreatedAt)
+	dst = binary.AppendUvarint(dst, e.Revision)
+
+	payload := dst[start+walRecordHeader:]
+	binary.LittleEndian.PutUint32(dst[start:], uint32(len(payload)))
+	binary.LittleEndian.PutUint32(dst[start+4:], crc32.ChecksumIEEE(payload))
+	return dst, nil
+}
+
+// each para na primeira entrada incompleta ou com CRC errado: sem separador
+// entre as entradas não há como achar a próxima, e no fim do arquivo ela é a
+// escrita cortada por um crash
+func (binaryWAL) each(r *bufio.Reader, fn func(raw []byte, e WalLog) error) error {
+	for {
+		head := make([]byte, walRecordHeader)
+		if _, err := io.ReadFull(r, head); err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				return nil
+			}
+			return err
+		}
+
+		size := binary.LittleEndian.Uint32(head)
+		record := append(head, make([]byte, size)...)
+		if _, err := io.ReadFull(r, record[walRecordHeader:]); err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				return nil
+			}
+			return err
+		}
+
+		payload := record[walRecordHeader:]
+		if crc32.ChecksumIEEE(payload) != binary.LittleEndian.Uint32(head[4:]) {
+			return nil
+		}
+		e, err := decodeWALRecord(payload)
+		if err != nil {
+			return nil
+		}
+		if err := fn(record, e); err != nil {
+			return err
+		}
+	}
+}
+
+func appendWALString(dst []byte, s string) []byte {
+	return append(binary.AppendUvarint(dst, uint64(len(s))), s...)
+}
+
+// walDecoder lê os campos de um payload binário, guardando o primeiro erro
+type walDecoder struct {
+	buf []byte
+	err error
+}
+
+func (d *walDecoder) uvarint() uint64 {
+	v, n := binary.Uvarint(d.buf)
+	if n <= 0 {
+		d.err, d.buf = errBadWALRecord, nil
+		return 0
+	}
+	d.buf = d.buf[n:]
+	return v
+}
+
+func (d *walDecoder) varint() int64 {
+	v, n := binary.Varint(d.buf)
+	if n <= 0 {
+		d.err, d.buf = errBadWALRecord, nil
+		return 0
+	}
+	d.buf = d.buf[n:]
+	return v
+}
+
+func (d *walDecoder) byte() byte {
+	if len(d.buf) == 0 {
+		d.err = errBadWALRecord
+		return 0
+	}
+	b := d.buf[0]
+	d.buf = d.buf[1:]
+	return b
+}
+
+func (d *walDecoder) string() string {
+	n := d.uvarint()
+	if uint64(len(d.buf)) < n {
+		d.err, d.buf = errBadWALRecord, nil
+		return ""
+	}
+	s := string(d.buf[:n])
+	d.buf = d.buf[n:]
+	return s
+}
+
+func decodeWALRecord(payload []byte) (WalLog, error) {
+	d := &walDecoder{buf: payload}
+
+	var e WalLog
+	e.SequenceNumber = d.uvarint()
+	e.Operation = Operation(d.byte())
+	flags := d.byte()
+	e.Encrypted, e.Binary = flags&1 != 0, flags&2 != 0
+	e.Namespace = d.string()
+	e.Key = d.string()
+	e.Value = d.string()
+	e.Timestamp = d.varint()
+	e.CreatedAt = d.varint()
+	e.Revision = d.uvarint()
+	return e, d.err
+}
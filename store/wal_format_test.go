@@ -0,0 +1,165 @@
+package store
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWALCodec_RoundTrip(t *testing.T) {
+	entries := []WalLog{
+		{SequenceNumber: 1, Operation: Write, Key: "line\nbreak", Value: "a\nb\r\n", Timestamp: 10, CreatedAt: 5, Revision: 3},
+		{SequenceNumber: 2, Operation: Write, Namespace: "users", Key: "k", Value: "", Timestamp: -1},
+		{SequenceNumber: 3, Operation: Write, Key: "\x00bin", Value: "c2VjcmV0", Encrypted: true, Binary: true},
+		{SequenceNumber: 4, Operation: Delete, Key: "line\nbreak", Revision: 4},
+		{SequenceNumber: 5, Operation: Checkpoint},
+	}
+
+	for _, format := range []WALFormat{WALNDJSON, WALBinary} {
+		t.Run(format.String(), func(t *testing.T) {
+			codec := walCodecFor(format)
+			data := codec.header()
+			for _, e := range entries {
+				var err error
+				if data, err = codec.appendEntry(data, e); err != nil {
+					t.Fatalf("appendEntry() failed: %v", err)
+				}
+			}
+
+			// o formato é reconhecido pelo cabeçalho, sem ser informado
+			var got []WalLog
+			err := eachWALEntry(bytes.NewReader(data), func(raw []byte, e WalLog) error {
+				got = append(got, e)
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("eachWALEntry() failed: %v", err)
+			}
+			if len(got) != len(entries) {
+				t.Fatalf("read %d entries, expected %d: %+v", len(got), len(entries), got)
+			}
+			for i := range entries {
+				if got[i] != entries[i] {
+					t.Errorf("entry %d = %+v, expected %+v", i, got[i], entries[i])
+				}
+			}
+
+			// uma entrada cortada no fim é ignorada
+			got = nil
+			eachWALEntry(bytes.NewReader(data[:len(data)-3]), func(_ []byte, e WalLog) error {
+				got = append(got, e)
+				return nil
+			})
+			if len(got) != len(entries)-1 {
+				t.Errorf("read %d entries from a torn file, expected %d", len(got), len(entries)-1)
+			}
+		})
+	}
+}
+
+func TestWALCodec_Detect(t *testing.T) {
+	tests := []struct {
+		data     string
+		expected WALFormat
+		ok       bool
+	}{
+		{"", WALNDJSON, false},
+		{`{"SequenceNumber":1}` + "\n", WALNDJSON, true},
+		{string(walBinaryMagic), WALBinary, true},
+		{"\x00KV", WALNDJSON, true},
+	}
+
+	for _, tt := range tests {
+		codec, ok, err := detectWALCodec(bufio.NewReader(bytes.NewReader([]byte(tt.data))))
+		if err != nil || codec.format() != tt.expected || ok != tt.ok {
+			t.Errorf("detectWALCodec(%q) = %v, %v, %v, expected %v, %v", tt.data, codec.format(), ok, err, tt.expected, tt.ok)
+		}
+	}
+}
+
+func TestParseWALFormat(t *testing.T) {
+	for _, f := range []WALFormat{WALNDJSON, WALBinary} {
+		if got, err := ParseWALFormat(f.String()); err != nil || got != f {
+			t.Errorf("ParseWALFormat(%q) = %v, %v", f.String(), got, err)
+		}
+	}
+	if _, err := ParseWALFormat("xml"); err == nil {
+		t.Error("ParseWALFormat(xml) should fail")
+	}
+}
+
+func TestWAL_BinaryFormat(t *testing.T) {
+	dir := t.TempDir()
+	useTempWAL(t, dir)
+	SetWALFormat(WALBinary)
+	defer SetWALFormat(WALNDJSON)
+
+	path := filepath.Join(dir, WALFileName)
+	LogWriteNamespace("", "a\nb", "1", 1)
+	LogWriteNamespace("", "c", "2", 2)
+	LogDelete("a\nb")
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(data, walBinaryMagic) {
+		t.Fatalf("WAL starts with %q, expected the binary header", data[:min(len(data), 16)])
+	}
+
+	entries := readAllLogEntries(t, path)
+	if len(entries) != 3 || entries[0].Key != "a\nb" || entries[2].Operation != Delete {
+		t.Fatalf("ReadWAL() returned %+v", entries)
+	}
+
+	// depois de um restart a sequência continua do fim do arquivo binário
+	walMu.Lock()
+	walSeq, walSeqPath, walCodecPath = 0, "", ""
+	walMu.Unlock()
+	if got := lastWALSequence(path); got != entries[2].SequenceNumber {
+		t.Errorf("lastWALSequence() = %d, expected %d", got, entries[2].SequenceNumber)
+	}
+
+	// o truncamento mantém o formato do arquivo
+	if err := TruncateWAL(entries[1].SequenceNumber); err != nil {
+		t.Fatalf("TruncateWAL() failed: %v", err)
+	}
+	LogWrite("d", "3")
+
+	entries = readAllLogEntries(t, path)
+	if len(entries) != 3 || entries[0].Operation != Checkpoint || entries[1].Operation != Delete || entries[2].Key != "d" {
+		t.Fatalf("after TruncateWAL() got %+v", entries)
+	}
+	if entries[2].SequenceNumber != entries[1].SequenceNumber+1 {
+		t.Errorf("sequence %d after %d", entries[2].SequenceNumber, entries[1].SequenceNumber)
+	}
+}
+
+func TestWAL_ExistingFileKeepsFormat(t *testing.T) {
+	dir := t.TempDir()
+	useTempWAL(t, dir)
+
+	path := filepath.Join(dir, WALFileName)
+	LogWrite("a", "1")
+
+	// trocar o formato não muda um arquivo que já tem entradas
+	SetWALFormat(WALBinary)
+	defer SetWALFormat(WALNDJSON)
+	walMu.Lock()
+	walCodecPath = ""
+	walMu.Unlock()
+	LogWrite("b", "2")
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(data, walBinaryMagic) || bytes.Count(data, []byte("\n")) != 2 {
+		t.Errorf("expected two ndjson lines, got %q", data)
+	}
+	if entries := readAllLogEntries(t, path); len(entries) != 2 {
+		t.Errorf("ReadWAL() returned %+v, expected 2 entries", entries)
+	}
+}
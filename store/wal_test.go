@@ -3,7 +3,11 @@ package store
 import (
 	"bufio"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -54,32 +58,10 @@ func readLastLogEntry(t *testing.T, logFile string) *WalLog {
 
 // readAllLogEntries lê todas as entradas do arquivo de log
 func readAllLogEntries(t *testing.T, logFile string) []WalLog {
-	file, err := os.Open(logFile)
+	entries, err := ReadWAL(logFile)
 	if err != nil {
-		t.Fatalf("Failed to open log file: %v", err)
+		t.Fatalf("Failed to read log file: %v", err)
 	}
-	defer file.Close()
-
-	var entries []WalLog
-	scanner := bufio.NewScanner(file)
-
-	for scanner.Scan() {
-		line := scanner.Text()
-		if line == "" {
-			continue
-		}
-
-		var entry WalLog
-		if err := json.Unmarshal([]byte(line), &entry); err != nil {
-			t.Fatalf("Failed to unmarshal log entry: %v", err)
-		}
-		entries = append(entries, entry)
-	}
-
-	if err := scanner.Err(); err != nil {
-		t.Fatalf("Error reading log file: %v", err)
-	}
-
 	return entries
 }
 
@@ -90,6 +72,8 @@ func TestOperation_String(t *testing.T) {
 	}{
 		{Write, "Write"},
 		{Delete, "Delete"},
+		{DropNamespace, "DropNamespace"},
+		{Clear, "Clear"},
 		{Operation(99), "Unknown"},
 	}
 
@@ -110,6 +94,8 @@ func TestOperation_MarshalJSON(t *testing.T) {
 	}{
 		{Write, `"Write"`},
 		{Delete, `"Delete"`},
+		{DropNamespace, `"DropNamespace"`},
+		{Clear, `"Clear"`},
 	}
 
 	for _, tt := range tests {
@@ -132,7 +118,7 @@ func TestWalLog_Structure(t *testing.T) {
 		Operation: Write,
 		Key:       "test_key",
 		Value:     "test_value",
-		Timestamp: time.Now().Unix(),
+		Timestamp: time.Now().UnixNano(),
 	}
 
 	if log.Operation != Write {
@@ -196,8 +182,8 @@ func TestLogWrite(t *testing.T) {
 	}
 
 	// Verifica se o timestamp é recente (dentro dos últimos 5 segundos)
-	now := time.Now().Unix()
-	if now-lastEntry.Timestamp > 5 {
+	now := time.Now().UnixNano()
+	if now-lastEntry.Timestamp > int64(5*time.Second) {
 		t.Error("Timestamp is too old")
 	}
 
@@ -419,3 +405,109 @@ func TestLogWrite_JSONFormat(t *testing.T) {
 	// Limpa o arquivo de log
 	os.Remove(originalLogFile)
 }
+
+func TestWAL_SequenceNumbers(t *testing.T) {
+	dir := t.TempDir()
+	SetWALPath(filepath.Join(dir, WALFileName))
+	defer SetWALPath(WALFileName)
+
+	const n = 500
+	for i := 0; i < n; i++ {
+		LogWrite(fmt.Sprintf("key-%d", i), "v")
+	}
+
+	entries := readAllLogEntries(t, filepath.Join(dir, WALFileName))
+	if len(entries) != n {
+		t.Fatalf("expected %d entries, got %d", n, len(entries))
+	}
+	for i, e := range entries {
+		// a ordem do arquivo tem que ser a ordem das escritas
+		if e.Key != fmt.Sprintf("key-%d", i) {
+			t.Fatalf("entry %d has key %s", i, e.Key)
+		}
+		if i > 0 && e.SequenceNumber <= entries[i-1].SequenceNumber {
+			t.Fatalf("sequence not increasing at %d: %d after %d", i, e.SequenceNumber, entries[i-1].SequenceNumber)
+		}
+	}
+
+	// simula um restart: a sequência volta a ser lida do fim do arquivo
+	last := entries[n-1].SequenceNumber
+	walMu.Lock()
+	walSeq, walSeqPath = 0, ""
+	walMu.Unlock()
+	LogWrite("after-restart", "v")
+
+	entry := readLastLogEntry(t, filepath.Join(dir, WALFileName))
+	if entry.SequenceNumber != last+1 {
+		t.Errorf("expected sequence %d after reload, got %d", last+1, entry.SequenceNumber)
+	}
+}
+
+func TestLastWALSequence_TornLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), WALFileName)
+	data := `{"SequenceNumber":7,"Operation":"Write","Key":"a","Value":"1","Timestamp":1}` + "\n" + `{"SequenceNumber":8,"Oper`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := lastWALSequence(path); got != 7 {
+		t.Errorf("expected 7, got %d", got)
+	}
+	if got := lastWALSequence(filepath.Join(t.TempDir(), "missing")); got != 0 {
+		t.Errorf("expected 0 for a missing file, got %d", got)
+	}
+}
+
+func TestReadWAL(t *testing.T) {
+	walFile := filepath.Join(t.TempDir(), WALFileName)
+	SetWALPath(walFile)
+	defer SetWALPath(WALFileName)
+
+	LogWriteNamespace("", "a", "1", 1)
+	LogDelete("a")
+	LogWriteNamespace("users", "b", "2", 0)
+	LogClearNamespace("")
+
+	// uma linha cortada por um crash no fim do arquivo é ignorada
+	f, err := os.OpenFile(walFile, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"SequenceNumber":99,"Opera`)
+	f.Close()
+
+	entries, err := ReadWAL(walFile)
+	if err != nil {
+		t.Fatalf("ReadWAL() failed: %v", err)
+	}
+
+	want := []struct {
+		op  Operation
+		ns  string
+		key string
+	}{
+		{Write, "", "a"},
+		{Delete, "", "a"},
+		{Write, "users", "b"},
+		{Clear, "", ""},
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("ReadWAL() returned %d entries, expected %d: %+v", len(entries), len(want), entries)
+	}
+	for i, w := range want {
+		e := entries[i]
+		if e.Operation != w.op || e.Namespace != w.ns || e.Key != w.key {
+			t.Errorf("entry %d = %+v, expected %v %s/%s", i, e, w.op, w.ns, w.key)
+		}
+		if i > 0 && e.SequenceNumber != entries[i-1].SequenceNumber+1 {
+			t.Errorf("entry %d has sequence %d after %d", i, e.SequenceNumber, entries[i-1].SequenceNumber)
+		}
+	}
+	if entries[0].Value != "1" || entries[0].Revision != 1 {
+		t.Errorf("unexpected write entry %+v", entries[0])
+	}
+
+	if _, err := ReadWAL(filepath.Join(t.TempDir(), "missing")); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("ReadWAL() on a missing file returned %v, expected fs.ErrNotExist", err)
+	}
+}
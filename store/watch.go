@@ -0,0 +1,413 @@
+package store
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+// DefaultWatchBufferSize é quantos eventos um watcher acumula sem ser lido.
+//
+// Por padrão as notificações são enviadas sem bloquear a escrita: se o buffer
+// do watcher estiver cheio, o evento é descartado (e logado como warning) em
+// vez de segurar o Put. Consumidores que recebem rajadas de escritas devem usar
+// um buffer maior com WithWatchBufferSize ou WithBufferSize, ou escolher outra
+// OverflowPolicy.
+const DefaultWatchBufferSize = 10
+
+// DefaultWatchBlockTimeout é quanto um watcher com OverflowBlock pode ficar
+// sem ler o próximo evento antes de ser desconectado
+const DefaultWatchBlockTimeout = 30 * time.Second
+
+// EventType é o tipo de mudança de um WatchEvent
+type EventType uint8
+
+const (
+	EventPut EventType = iota
+	EventDelete
+	// EventClear e EventDrop só chegam aos watchers do WatchAll, um por Clear
+	// ou DropNamespace; os watchers das chaves recebem um EventDelete cada
+	EventClear
+	EventDrop
+)
+
+func (t EventType) String() string {
+	switch t {
+	case EventPut:
+		return "put"
+	case EventDelete:
+		return "delete"
+	case EventClear:
+		return "clear"
+	case EventDrop:
+		return "drop"
+	default:
+		return fmt.Sprintf("EventType(%d)", t)
+	}
+}
+
+// WatchEvent é uma mudança entregue a um watcher. Revision é a revisão gerada
+// pela mudança no namespace padrão (0 nos outros namespaces e no drop); no
+// clear é a revisão do Clear. Message é o texto legível que os watchers
+// recebiam antes dos campos estruturados, mantido para quem ainda o usa.
+type WatchEvent struct {
+	Type      EventType
+	Namespace string
+	Key       string
+	Value     string
+	Revision  uint64
+	Message   string
+}
+
+func (e WatchEvent) String() string {
+	return e.Message
+}
+
+// OverflowPolicy define o que acontece quando o buffer de um watcher enche
+type OverflowPolicy uint8
+
+const (
+	// OverflowDropNewest descarta o evento novo e mantém os que já estão no buffer
+	OverflowDropNewest OverflowPolicy = iota
+	// OverflowDropOldest descarta o evento mais antigo do buffer para caber o novo,
+	// então o watcher sempre termina com os valores mais recentes
+	OverflowDropOldest
+	// OverflowBlock não perde eventos: os que não cabem no buffer esperam numa
+	// fila do watcher, entregue por uma goroutine própria, então a escrita não
+	// espera o consumidor. Um watcher que fica mais que o block timeout da store
+	// sem ler é desconectado (o canal fecha) e os eventos pendentes são descartados.
+	OverflowBlock
+)
+
+func (p OverflowPolicy) String() string {
+	switch p {
+	case OverflowDropNewest:
+		return "drop-newest"
+	case OverflowDropOldest:
+		return "drop-oldest"
+	case OverflowBlock:
+		return "block"
+	default:
+		return fmt.Sprintf("OverflowPolicy(%d)", p)
+	}
+}
+
+// WatchOption configura um watcher criado pelo Watch
+type WatchOption func(*watchOptions)
+
+type watchOptions struct {
+	initialValue  bool
+	closeOnDelete bool
+	bufferSize    int
+	policy        OverflowPolicy
+}
+
+// WithOverflowPolicy define o que fazer quando o buffer deste watcher enche
+func WithOverflowPolicy(p OverflowPolicy) WatchOption {
+	return func(o *watchOptions) {
+		o.policy = p
+	}
+}
+
+// WithBufferSize define o buffer deste watcher, sobrescrevendo o da store
+func WithBufferSize(n int) WatchOption {
+	return func(o *watchOptions) {
+		o.bufferSize = n
+	}
+}
+
+// WithInitialValue faz o watcher receber o valor atual da chave (se ela
+// existir) como primeiro evento, antes das atualizações
+func WithInitialValue() WatchOption {
+	return func(o *watchOptions) {
+		o.initialValue = true
+	}
+}
+
+// WithCloseOnDelete fecha o watcher logo depois do evento de delete da chave,
+// seja por Delete, Txn, Clear ou DropNamespace. Sem ela o watcher continua
+// registrado e volta a receber eventos se a chave for recriada.
+func WithCloseOnDelete() WatchOption {
+	return func(o *watchOptions) {
+		o.closeOnDelete = true
+	}
+}
+
+// watchOptions aplica as opções sobre o buffer padrão da store
+func (kv *KVStore) watchOptions(opts []WatchOption) watchOptions {
+	o := watchOptions{bufferSize: kv.watchBufferSize}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.bufferSize <= 0 {
+		o.bufferSize = DefaultWatchBufferSize
+	}
+	return o
+}
+
+// newWatcher cria o watcher e, com OverflowBlock, sobe a goroutine que
+// entrega a fila dele
+func (kv *KVStore) newWatcher(ns, key string, o watchOptions) *KVWatcher {
+	w := &KVWatcher{
+		Namespace: ns,
+		Key:       key,
+		Events:    make(chan WatchEvent, o.bufferSize),
+		policy:    o.policy,
+		closing:   make(chan struct{}),
+
+		closeOnDelete: o.closeOnDelete,
+	}
+	if w.policy == OverflowBlock {
+		w.queue = &blockQueue{wake: make(chan struct{}, 1)}
+		go kv.deliver(w)
+	}
+	return w
+}
+
+// blockQueue guarda os eventos de um watcher com OverflowBlock até a
+// goroutine dele entregá-los em Events
+type blockQueue struct {
+	mu      sync.Mutex
+	pending []WatchEvent
+	// done pede para entregar o que falta e fechar Events
+	done bool
+	wake chan struct{}
+}
+
+func (q *blockQueue) push(event WatchEvent) {
+	q.mu.Lock()
+	q.pending = append(q.pending, event)
+	q.mu.Unlock()
+	q.signal()
+}
+
+func (q *blockQueue) signal() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// next tira o próximo evento da fila; ok é falso quando ela está vazia
+func (q *blockQueue) next() (event WatchEvent, ok, done bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if len(q.pending) == 0 {
+		return WatchEvent{}, false, q.done
+	}
+	event = q.pending[0]
+	q.pending = q.pending[1:]
+	return event, true, q.done
+}
+
+func (q *blockQueue) len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.pending)
+}
+
+// deliver é a goroutine de um watcher com OverflowBlock: entrega a fila em
+// ordem sem segurar o lock da store e é a única que fecha Events. Se o
+// consumidor passar do block timeout sem ler, o watcher sai da store.
+func (kv *KVStore) deliver(w *KVWatcher) {
+	defer close(w.Events)
+
+	timer := time.NewTimer(kv.watchBlockTimeout)
+	defer timer.Stop()
+
+	for {
+		event, ok, done := w.queue.next()
+		if !ok {
+			if done {
+				return
+			}
+			select {
+			case <-w.queue.wake:
+				continue
+			case <-w.closing:
+				return
+			}
+		}
+
+		timer.Reset(kv.watchBlockTimeout)
+		select {
+		case w.Events <- event:
+		case <-w.closing:
+			return
+		case <-timer.C:
+			kv.removeWatcher(w)
+			dropped := 1 + w.queue.len()
+			kv.droppedEvents.Add(uint64(dropped))
+			kv.logger.Warn("watcher stopped reading, disconnecting", "namespace", w.Namespace, "key", w.Key, "dropped", dropped)
+			return
+		}
+	}
+}
+
+// queueDepth conta os eventos no canal e, com OverflowBlock, os que ainda
+// esperam na fila
+func (w *KVWatcher) queueDepth() int {
+	depth := len(w.Events)
+	if w.queue != nil {
+		depth += w.queue.len()
+	}
+	return depth
+}
+
+// close fecha o canal do watcher. Com OverflowBlock quem fecha é a goroutine
+// de entrega, depois de entregar os eventos que ainda estão na fila.
+func (w *KVWatcher) close() {
+	if w.queue == nil {
+		close(w.Events)
+		return
+	}
+	w.queue.mu.Lock()
+	w.queue.done = true
+	w.queue.mu.Unlock()
+	w.queue.signal()
+}
+
+// removeWatcher tira o watcher do registro da store sem fechar o canal
+func (kv *KVStore) removeWatcher(w *KVWatcher) {
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+
+	if w.all {
+		if i := slices.Index(kv.allWatchers, w); i >= 0 {
+			kv.allWatchers = slices.Delete(kv.allWatchers, i, i+1)
+		}
+		return
+	}
+
+	wk := watchKey(w.Namespace, w.Key)
+	if i := slices.Index(kv.watchers[wk], w); i >= 0 {
+		kv.watchers[wk] = slices.Delete(kv.watchers[wk], i, i+1)
+	}
+	if len(kv.watchers[wk]) == 0 {
+		delete(kv.watchers, wk)
+	}
+}
+
+// WatchAll cria um watcher que recebe as mudanças de todas as chaves, de
+// qualquer namespace: as mesmas mensagens dos watchers de chave para escritas
+// e deletes, e uma mensagem por Clear. Serve para replicadores e sinks de
+// change data capture. WithInitialValue é ignorada; as outras opções valem
+// como no Watch. Para parar, use Unwatch.
+func (kv *KVStore) WatchAll(opts ...WatchOption) *KVWatcher {
+	w := kv.newWatcher("", "", kv.watchOptions(opts))
+	w.all = true
+
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+
+	if kv.closed.Load() {
+		w.close()
+		return w
+	}
+	kv.allWatchers = append(kv.allWatchers, w)
+	return w
+}
+
+// notifyLocked avisa os watchers da chave do evento e os do WatchAll, cada
+// um uma vez. Deve ser chamado com kv.mu travado para escrita.
+func (kv *KVStore) notifyLocked(event WatchEvent) {
+	for _, w := range kv.watchers[watchKey(event.Namespace, event.Key)] {
+		kv.notify(w, event)
+	}
+	kv.notifyAllLocked(event)
+}
+
+func (kv *KVStore) notifyAllLocked(event WatchEvent) {
+	for _, w := range kv.allWatchers {
+		kv.notify(w, event)
+	}
+}
+
+// notifyDeleteLocked manda o evento de delete para os watchers da chave e
+// fecha os criados com WithCloseOnDelete. Os do WatchAll ficam por conta de
+// quem chama, já que uma remoção em massa manda um evento só para eles.
+func (kv *KVStore) notifyDeleteLocked(event WatchEvent) {
+	wk := watchKey(event.Namespace, event.Key)
+
+	var kept []*KVWatcher
+	for _, w := range kv.watchers[wk] {
+		kv.notify(w, event)
+		if w.closeOnDelete {
+			w.close()
+			continue
+		}
+		kept = append(kept, w)
+	}
+
+	if len(kept) == 0 {
+		delete(kv.watchers, wk)
+	} else {
+		kv.watchers[wk] = kept
+	}
+}
+
+// watchedKeysLocked devolve as chaves observadas do namespace que existem,
+// em memória ou só no backend (ex.: despejadas pelo LRU). Clear e
+// DropNamespace chamam antes de apagar, para avisar só quem perdeu a chave.
+func (kv *KVStore) watchedKeysLocked(ns string) []string {
+	data := kv.data(ns, false)
+
+	var keys []string
+	for _, wlist := range kv.watchers {
+		if len(wlist) == 0 || wlist[0].Namespace != ns {
+			continue
+		}
+		key := wlist[0].Key
+		if _, ok := data[key]; !ok {
+			v, err := kv.storage().Get(kv.bucketFor(ns), []byte(key))
+			if err != nil || v == nil {
+				continue
+			}
+		}
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// notify entrega event ao watcher seguindo a política dele. Roda com o write
+// lock da store travado, então nunca espera o consumidor.
+func (kv *KVStore) notify(w *KVWatcher, event WatchEvent) {
+	switch w.policy {
+	case OverflowBlock:
+		w.queue.push(event)
+	case OverflowDropOldest:
+		for {
+			select {
+			case w.Events <- event:
+				return
+			default:
+			}
+
+			//o consumidor pode ter lido entre as duas tentativas, então não bloqueia aqui
+			select {
+			case <-w.Events:
+				kv.droppedEvents.Add(1)
+				kv.logger.Warn("watcher channel full, dropping oldest event", "namespace", w.Namespace, "key", w.Key)
+			default:
+			}
+		}
+	default:
+		select {
+		case w.Events <- event:
+		default:
+			kv.droppedEvents.Add(1)
+			kv.logger.Warn("watcher channel full, dropping event", "namespace", w.Namespace, "key", w.Key)
+		}
+	}
+}
+
+// stop sinaliza que o watcher está sendo removido; pode ser chamado mais de uma vez
+func (w *KVWatcher) stop() {
+	w.closeOnce.Do(func() {
+		if w.closing != nil {
+			close(w.closing)
+		}
+	})
+}
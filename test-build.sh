@@ -5,7 +5,7 @@ echo "1. Verificando arquivos protobuf..."
 ls -la pb/proto/
 
 echo "2. Testando build do servidor..."
-go build -o server ./server/main.go
+go build -o server ./server
 if [ $? -eq 0 ]; then
     echo "✅ Build do servidor OK"
     ls -la server
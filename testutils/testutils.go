@@ -3,12 +3,15 @@ package testutils
 import (
 	"context"
 	"fmt"
+	"io"
 	"net"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/carvalhodanielg/kvstore/internal/constants"
+	"github.com/carvalhodanielg/kvstore/internal/version"
 	pb "github.com/carvalhodanielg/kvstore/pb/proto"
 	"github.com/carvalhodanielg/kvstore/store"
 	bolt "go.etcd.io/bbolt"
@@ -42,15 +45,127 @@ func (s *server) Put(_ context.Context, in *pb.PutRequest) (*pb.PutResponse, err
 }
 
 func (s *server) Watch(in *pb.WatchRequest, stream pb.KvStore_WatchServer) error {
-	w := s.store.Watch(in.Key)
+	var opts []store.WatchOption
+	if in.GetSendInitialValue() {
+		opts = append(opts, store.WithInitialValue())
+	}
+
+	w := s.store.Watch(in.Key, opts...)
+	defer s.store.Unwatch(w)
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		case event, ok := <-w.Events:
+			if !ok {
+				return nil
+			}
+			if err := stream.Send(&pb.WatchResponse{Message: event.Message}); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (s *server) WatchAll(in *pb.WatchAllRequest, stream pb.KvStore_WatchAllServer) error {
+	w := s.store.WatchAll()
 	defer s.store.Unwatch(w)
 
-	for event := range w.Events {
-		if err := stream.Send(&pb.WatchResponse{Message: event}); err != nil {
+	for {
+		select {
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		case event, ok := <-w.Events:
+			if !ok {
+				return nil
+			}
+			if !strings.HasPrefix(event.Key, in.GetPrefix()) {
+				continue
+			}
+			resp := &pb.WatchResponse{Message: event.Message, Key: event.Key, Value: event.Value, Revision: event.Revision}
+			if event.Type == store.EventDelete {
+				resp.Type = pb.WatchEventType_WATCH_EVENT_DELETE
+			}
+			if err := stream.Send(resp); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (s *server) Ping(_ context.Context, in *pb.PingRequest) (*pb.PingResponse, error) {
+	return &pb.PingResponse{Nonce: in.GetNonce(), Timestamp: time.Now().UnixNano()}, nil
+}
+
+func (s *server) Version(_ context.Context, _ *pb.VersionRequest) (*pb.VersionResponse, error) {
+	return &pb.VersionResponse{Version: version.Version, Commit: version.GetCommit(), GoVersion: version.GoVersion()}, nil
+}
+
+func (s *server) Backup(_ *pb.BackupRequest, stream pb.KvStore_BackupServer) error {
+	return s.store.Backup(func(key, value string) error {
+		return stream.Send(&pb.BackupResponse{Key: key, Value: value})
+	})
+}
+
+func (s *server) Restore(stream pb.KvStore_RestoreServer) error {
+	var (
+		restored int64
+		dryRun   bool
+		pairs    [][2]string
+	)
+	for n := 0; ; n++ {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			dryRun = req.GetDryRun()
+		}
+		if dryRun {
+			pairs = append(pairs, [2]string{req.GetKey(), req.GetValue()})
+			continue
+		}
+		s.store.Put(req.GetKey(), req.GetValue())
+		restored++
+	}
+
+	if !dryRun {
+		return stream.SendAndClose(&pb.RestoreResponse{Restored: restored})
+	}
+
+	report := s.store.CheckRestore(func(yield func(string, string) bool) {
+		for _, p := range pairs {
+			if !yield(p[0], p[1]) {
+				return
+			}
+		}
+	})
+	return stream.SendAndClose(&pb.RestoreResponse{
+		Checked:    int64(report.Pairs),
+		Bytes:      report.Bytes,
+		Duplicates: int64(report.Duplicates),
+		Invalid:    int64(report.Invalid),
+		Errors:     report.Errors,
+	})
+}
+
+func (s *server) BulkPut(stream pb.KvStore_BulkPutServer) error {
+	var count int64
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			return stream.SendAndClose(&pb.BulkPutResponse{Count: count})
+		}
+		if err != nil {
 			return err
 		}
+		s.store.Put(req.GetKey(), req.GetValue())
+		count++
 	}
-	return nil
 }
 
 // TestServer representa um servidor de teste com todos os componentes
@@ -91,8 +206,9 @@ func SetupTestServer(t testing.TB) *TestServer {
 	// Inicializa o store
 	store.Init(db)
 
-	// Cria o servidor
-	srv := grpc.NewServer()
+	// Cria o servidor. O Stop espera os handlers, então os watchers de
+	// streams encerrados já saíram da store quando ele retorna
+	srv := grpc.NewServer(grpc.WaitForHandlers(true))
 	kvStore := store.NewKVStore()
 	s := &server{
 		store: kvStore,
@@ -125,6 +241,30 @@ func SetupTestServer(t testing.TB) *TestServer {
 	}
 }
 
+// Restart derruba o servidor gRPC, encerrando conexões e streams abertos, e
+// sobe outro no mesmo endereço usando a mesma store. Quando retorna, os
+// watchers dos streams antigos já foram removidos.
+func (ts *TestServer) Restart(t testing.TB) {
+	ts.Server.Stop()
+
+	listener, err := net.Listen("tcp", ts.Addr)
+	if err != nil {
+		t.Fatalf("failed to listen again on %s: %v", ts.Addr, err)
+	}
+
+	srv := grpc.NewServer(grpc.WaitForHandlers(true))
+	pb.RegisterKvStoreServer(srv, &server{store: ts.Store})
+
+	go func() {
+		if err := srv.Serve(listener); err != nil {
+			t.Logf("server error: %v", err)
+		}
+	}()
+
+	ts.Server = srv
+	ts.Listener = listener
+}
+
 // CleanupTestServer limpa o servidor de teste
 func CleanupTestServer(t testing.TB, ts *TestServer) {
 	if ts.Server != nil {